@@ -0,0 +1,85 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// LeaseArchive is the ledger's record of one compacted window [From, Through).
+//
+// The funding fold replays every GPULease from the first one ever minted, and
+// closed leases are otherwise never removed, so both the object count and the
+// replay grow without bound. The compactor folds the window into one of these
+// — what every run accrued on every envelope, in each class — and deletes the
+// closed leases it no longer needs. From then on the fold seeds its accounts
+// from the archives and replays only from the latest Through.
+//
+// Archives chain: each one's From is its predecessor's Through. An archive is
+// a fact like a lease, so it is immutable once written.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=leasearchives,scope=Cluster,shortName=larc
+// +kubebuilder:printcolumn:name="From",type=string,JSONPath=`.spec.from`
+// +kubebuilder:printcolumn:name="Through",type=string,JSONPath=`.spec.through`
+// +kubebuilder:printcolumn:name="Folded",type=integer,JSONPath=`.spec.foldedLeases`
+// +kubebuilder:validation:XValidation:rule="self.spec == oldSelf.spec",message="spec is immutable; an archive is a ledger fact"
+type LeaseArchive struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec LeaseArchiveSpec `json:"spec,omitempty"`
+}
+
+// LeaseArchiveSpec is the folded window.
+type LeaseArchiveSpec struct {
+	From    metav1.Time `json:"from"`
+	Through metav1.Time `json:"through"`
+	// FoldedLeases counts the leases that had ended by Through when the window
+	// was folded: the ones the compactor may delete.
+	FoldedLeases int32 `json:"foldedLeases,omitempty"`
+	// Charges are the window's GPU-hours by run, payer envelope, class, and
+	// lender — a chargeback statement for the window.
+	Charges []ArchivedCharge `json:"charges,omitempty"`
+	// RoleHours splits a multi-role run's hours in the window by role.
+	RoleHours []ArchivedRoleHours `json:"roleHours,omitempty"`
+	// FairShare is every fair-share meter as of Through, so the replay that
+	// resumes there ranks family claims exactly as the full replay would.
+	FairShare []ArchivedShare `json:"fairShare,omitempty"`
+}
+
+// ArchivedCharge is one run's hours on one envelope in one class.
+type ArchivedCharge struct {
+	// Run is the run's namespace/name key.
+	Run string `json:"run"`
+	// Owner is the run's derived owner when the window was folded.
+	Owner           string `json:"owner,omitempty"`
+	BudgetNamespace string `json:"budgetNamespace,omitempty"`
+	Budget          string `json:"budget,omitempty"`
+	Envelope        string `json:"envelope"`
+	Class           string `json:"class"`
+	// Lender is the envelope owner whose capacity funded Shared and Borrowed
+	// hours; empty otherwise.
+	Lender   string  `json:"lender,omitempty"`
+	GPUHours float64 `json:"gpuHours"`
+}
+
+// ArchivedRoleHours is one role's hours within a run.
+type ArchivedRoleHours struct {
+	Run      string  `json:"run"`
+	Role     string  `json:"role"`
+	GPUHours float64 `json:"gpuHours"`
+}
+
+// ArchivedShare is one principal's decayed family use under one half-life.
+type ArchivedShare struct {
+	HalfLife metav1.Duration `json:"halfLife"`
+	Owner    string          `json:"owner"`
+	GPUHours float64         `json:"gpuHours"`
+}
+
+// LeaseArchiveList contains a list of LeaseArchives.
+// +kubebuilder:object:root=true
+type LeaseArchiveList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []LeaseArchive `json:"items"`
+}
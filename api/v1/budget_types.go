@@ -34,18 +34,40 @@ type BudgetSpec struct {
 	AggregateCaps []AggregateCap     `json:"aggregateCaps,omitempty"`
 	Parents       []string           `json:"parents,omitempty"`
 	AutoRenew     *AutoRenewSchedule `json:"autoRenew,omitempty"`
+	// FairShare, when set, ranks family claims on this budget's envelopes by
+	// how much family capacity each claimant has used lately, so a sibling
+	// that has been living on excess yields it to one that has not.
+	FairShare *FairSharePolicy `json:"fairShare,omitempty"`
 }
 
-// AutoRenewSchedule defines reporting rotation for expiring envelopes.
+// FairSharePolicy decays a principal's Shared and Unfunded GPU-hours
+// exponentially. A family claim ranks within its tier by the decayed figure,
+// in doubling bands: under one GPU-hour, under two, under four, and so on.
+// Claimants in the same band fall back to priority and admission order, so
+// two siblings using about the same do not swap places every evaluation.
+type FairSharePolicy struct {
+	// HalfLife is how long a GPU-hour of family use takes to count half as
+	// much.
+	HalfLife metav1.Duration `json:"halfLife"`
+}
+
+// AutoRenewSchedule rotates expiring envelopes.
 //
 // It matters MORE now, not less: INV-WINDOW-REQUIRED means every envelope has an
 // end, so every envelope eventually stops funding work. There are no open-ended
-// envelopes for it to rotate any more — it is the notice that an expiry is
-// coming. It still does NOT auto-extend anything; window rotation stays an
-// explicit operator action.
+// envelopes to rotate any more. Within notifyBefore of an envelope's end the
+// budget controller appends its next window as a new envelope (renewalOf the
+// old one) rather than moving the old end: every window stays explicit in the
+// spec, and the old one still expires on the date somebody chose.
 type AutoRenewSchedule struct {
+	// Period is the length of each appended window; zero repeats the length
+	// of the window being renewed.
 	Period       metav1.Duration `json:"period"`
 	NotifyBefore metav1.Duration `json:"notifyBefore"`
+	// RequireApproval holds each renewal until the Budget's
+	// rq.davidlangworthy.io/approve-renewal annotation names the envelope.
+	// Until then the envelope is only listed in status.pendingRenewals.
+	RequireApproval bool `json:"requireApproval,omitempty"`
 }
 
 // Envelope sharing modes: family sharing of excess needs no lending policy
@@ -68,7 +90,8 @@ type BudgetEnvelope struct {
 	Selector map[string]string `json:"selector"`
 	// Concurrency is the whole allocation. GPU-hours are metered and reported,
 	// never enforced (Ruling 10, DESIGN-v5 §1), so an envelope grants
-	// `concurrency` GPUs of `flavor` over `[start, end)` and nothing else.
+	// `concurrency` GPUs of `flavor` over `[start, end)` and nothing else. For
+	// a MIG flavor (a100-1g.10gb) the units are slices of its profile.
 	// +kubebuilder:validation:Minimum=0
 	Concurrency int32 `json:"concurrency"`
 	// Start and End are REQUIRED (INV-WINDOW-REQUIRED, DESIGN-v5 §1). They are
@@ -85,6 +108,24 @@ type BudgetEnvelope struct {
 	Sharing       string               `json:"sharing,omitempty"`
 	PreActivation *PreActivationPolicy `json:"preActivation,omitempty"`
 	Lending       *LendingPolicy       `json:"lending,omitempty"`
+	// MaxPriority is the highest spec.priority this envelope honors for the
+	// runs it funds; a run asking for more ranks at this. Zero (the default)
+	// authorizes none, so urgency is always the envelope owner's grant.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=9
+	MaxPriority int32 `json:"maxPriority,omitempty"`
+	// RenewalOf names the envelope of this budget whose window this one
+	// continues. Leases paid by that envelope and still open at its end are
+	// charged to this one from then on, so a renewal is a seam, not a drop
+	// to Unfunded. Only a renewal opening at that end continues them; one
+	// opening later leaves a gap they do not cross. Spec.autoRenew writes
+	// it; an operator rotating by hand may too.
+	RenewalOf string `json:"renewalOf,omitempty"`
+	// NextConcurrency is the concurrency spec.autoRenew grants the next
+	// window instead of this one's. It applies once: the renewal it shapes
+	// does not inherit it.
+	// +kubebuilder:validation:Minimum=1
+	NextConcurrency *int32 `json:"nextConcurrency,omitempty"`
 }
 
 // PreActivationPolicy controls reservation/admission before start.
@@ -128,11 +169,25 @@ type BudgetStatus struct {
 	Usage              []EnvelopeUsage     `json:"usage,omitempty"`
 	UpdatedAt          *metav1.Time        `json:"updatedAt,omitempty"`
 	// PendingRenewals lists envelopes whose window is closing within
-	// spec.autoRenew.notifyBefore and have not yet been rotated by an
-	// operator. Populated only when spec.autoRenew is set; an unset
-	// AutoRenew always yields an empty list (the real, non-fabricated
-	// reader of spec.autoRenew — see quota-semantics.md).
+	// spec.autoRenew.notifyBefore and have not yet been renewed: with
+	// requireApproval, the ones waiting on approval. Populated only when
+	// spec.autoRenew is set; an unset AutoRenew always yields an empty list
+	// (the real, non-fabricated reader of spec.autoRenew — see
+	// quota-semantics.md).
 	PendingRenewals []EnvelopeRenewalDue `json:"pendingRenewals,omitempty"`
+	// FairShare lists the family principals with recent Shared or Unfunded
+	// use, decayed at spec.fairShare.halfLife. Empty unless the policy is set.
+	FairShare []FairShareUsage `json:"fairShare,omitempty"`
+}
+
+// FairShareUsage is one principal's decayed family consumption and the band
+// it ranks in on this budget's envelopes.
+type FairShareUsage struct {
+	Owner           string  `json:"owner"`
+	DecayedGPUHours float64 `json:"decayedGPUHours"`
+	// Band is the rank penalty: 0 below one decayed GPU-hour, then one more
+	// for each doubling. A lower band ranks first within a tier.
+	Band int32 `json:"band"`
 }
 
 // EnvelopeRenewalDue reports one envelope whose window needs rotating.
@@ -213,6 +268,14 @@ func (b *Budget) validate() error {
 		}
 		envelopeFlavors[name] = b.Spec.Envelopes[i].Flavor
 	}
+	// A renewal continues its predecessor's leases, so it must fund the same
+	// flavor. The predecessor itself may since have been pruned from the spec.
+	for i := range b.Spec.Envelopes {
+		env := &b.Spec.Envelopes[i]
+		if flavor, ok := envelopeFlavors[env.RenewalOf]; ok && flavor != env.Flavor {
+			return fmt.Errorf("envelope[%d]: renewalOf %q has flavor %q, not %q", i, env.RenewalOf, flavor, env.Flavor)
+		}
+	}
 	capNames := make(map[string]struct{}, len(b.Spec.AggregateCaps))
 	for i := range b.Spec.AggregateCaps {
 		cap := &b.Spec.AggregateCaps[i]
@@ -224,6 +287,9 @@ func (b *Budget) validate() error {
 		}
 		capNames[cap.Name] = struct{}{}
 	}
+	if fs := b.Spec.FairShare; fs != nil && fs.HalfLife.Duration <= 0 {
+		return fmt.Errorf("fairShare.halfLife must be positive")
+	}
 	return nil
 }
 
@@ -296,6 +362,15 @@ func (e *BudgetEnvelope) Validate() error {
 			return err
 		}
 	}
+	if e.MaxPriority < 0 || e.MaxPriority > MaxRunPriority {
+		return fmt.Errorf("maxPriority must be between 0 and %d", MaxRunPriority)
+	}
+	if e.NextConcurrency != nil && *e.NextConcurrency <= 0 {
+		return fmt.Errorf("nextConcurrency must be positive")
+	}
+	if e.RenewalOf == e.Name {
+		return fmt.Errorf("renewalOf must name another envelope")
+	}
 	return nil
 }
 
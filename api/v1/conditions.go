@@ -133,6 +133,9 @@ var (
 	RunStateUnfunded = RunState{Reason: "Unfunded", Phase: RunPhasePending, whenFalse: []string{RunConditionAdmitted}}
 	// RunStateReserved — parked behind a Reservation with a forecast start.
 	RunStateReserved = RunState{Reason: "Reserved", Phase: RunPhasePending, whenTrue: []string{RunConditionAdmitted}}
+	// RunStateBooked — holds a booking (spec.schedule) that passed its checks;
+	// it waits for the booked start, not for capacity.
+	RunStateBooked = RunState{Reason: "Booked", Phase: RunPhasePending, whenTrue: []string{RunConditionAdmitted}}
 	// RunStateGangForming — pods are out and some leases are held, but not the
 	// whole width. "Start together or not at all": a partial gang is NOT Running.
 	RunStateGangForming = RunState{Reason: "GangForming", Phase: RunPhasePending, whenTrue: []string{RunConditionAdmitted}}
@@ -152,6 +155,9 @@ var (
 	// RunStateCapacityLost — a rank was lost (eviction or node failure) and the
 	// run dropped below its minimum runnable width; it is re-assembling.
 	RunStateCapacityLost = RunState{Reason: "CapacityLost", Phase: RunPhasePending, whenTrue: []string{RunConditionAdmitted}}
+	// RunStateDefragmented — the defragmenter moved the run to open a domain for
+	// a larger one; it drains to its checkpoint and re-admits elsewhere.
+	RunStateDefragmented = RunState{Reason: "Defragmented", Phase: RunPhasePending, whenTrue: []string{RunConditionAdmitted}}
 
 	// --- Running -------------------------------------------------------------
 
@@ -169,12 +175,20 @@ var (
 	// RunStateWorkloadFailed — a workload container failed terminally under a
 	// Fail (or exhausted Retry) policy (R8 / R9 9A-3).
 	RunStateWorkloadFailed = RunState{Reason: "WorkloadFailed", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
+	// RunStateTimeLimitExceeded — the run reached spec.runtime.maxDuration, or
+	// spent its retries on attempts that each outran the role's attemptTimeout.
+	RunStateTimeLimitExceeded = RunState{Reason: "TimeLimitExceeded", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
 	// RunStateNodeFailureNoSpare — a fenced node took capacity the run could not
 	// replace, and its grace expired.
 	RunStateNodeFailureNoSpare = RunState{Reason: "NodeFailureNoSpare", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
 	// RunStateUpstreamFailed — an upstream in the follow forest failed and this
 	// run's policy (or its expired grace) fails it too.
 	RunStateUpstreamFailed = RunState{Reason: "UpstreamFailed", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
+	// RunStateFollowSkipped — the run's follow condition can no longer hold: a
+	// Failed branch whose upstreams all succeeded, or a Succeeded follower of a
+	// skipped run. It never ran, so it produced nothing a success-follower could
+	// use, which is why it is terminal as Failed rather than Completed.
+	RunStateFollowSkipped = RunState{Reason: "FollowSkipped", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
 	// RunStateFollowCycle — the follow edges form a cycle. A spec error, not an
 	// upstream failure, and worth its own reason: the fix is different.
 	RunStateFollowCycle = RunState{Reason: "FollowCycle", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
@@ -183,6 +197,10 @@ var (
 	RunStateCheckpointExpired = RunState{Reason: "CheckpointExpired", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
 	// RunStateEndedByResolver — the oversubscription resolver ended the run.
 	RunStateEndedByResolver = RunState{Reason: "EndedByResolver", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
+	// RunStateBookingRejected — the run's booking cannot be had: the window's
+	// capacity is committed to other bookings, or its envelopes do not fund it
+	// then. It never ran; book another slot.
+	RunStateBookingRejected = RunState{Reason: "BookingRejected", Phase: RunPhaseFailed, whenTrue: []string{RunConditionFailed}}
 )
 
 // AllRunStates is every declared state. Its only consumer is the table test that
@@ -193,21 +211,40 @@ var AllRunStates = []RunState{
 	RunStateUnschedulable,
 	RunStateUnfunded,
 	RunStateReserved,
+	RunStateBooked,
 	RunStateGangForming,
 	RunStateScheduling,
 	RunStatePromised,
 	RunStateCheckpointGrace,
 	RunStateReclaimed,
 	RunStateCapacityLost,
+	RunStateDefragmented,
 	RunStateGangBound,
 	RunStateShrunk,
 	RunStateAllSucceeded,
 	RunStateWorkloadFailed,
+	RunStateTimeLimitExceeded,
 	RunStateNodeFailureNoSpare,
 	RunStateUpstreamFailed,
+	RunStateFollowSkipped,
 	RunStateFollowCycle,
 	RunStateCheckpointExpired,
 	RunStateEndedByResolver,
+	RunStateBookingRejected,
+}
+
+// RunSkipped reports whether a Failed run never ran because its follow
+// condition could not hold, as opposed to failing on its own.
+func RunSkipped(status *RunStatus) bool {
+	cond := meta.FindStatusCondition(status.Conditions, RunConditionFailed)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.Reason == RunStateFollowSkipped.Reason
+}
+
+// RunDefragmented reports whether the run is waiting to re-admit after the
+// defragmenter moved it.
+func RunDefragmented(status *RunStatus) bool {
+	cond := meta.FindStatusCondition(status.Conditions, RunConditionAdmitted)
+	return cond != nil && cond.Status == metav1.ConditionTrue && cond.Reason == RunStateDefragmented.Reason
 }
 
 // SetRunState applies a state to a RunStatus: every managed condition is written
@@ -0,0 +1,115 @@
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Remedy directive phases. An empty phase is outstanding: the aggregator issues
+// no further directive for the same cap until every cluster has answered.
+const (
+	DirectivePhaseExecuted = "Executed"
+	DirectivePhaseFailed   = "Failed"
+)
+
+// RemedyDirective asks one cluster to free width under an aggregate cap that is
+// breached across clusters (M10).
+//
+// Every cluster enforces an AggregateCap against its OWN leases, so three clusters
+// sharing one org budget can each run to the cap and together hold three times it.
+// cmd/aggregator sums the funded width each cluster reports, apportions the excess,
+// and writes one directive per contributing cluster into that cluster. The local
+// manager executes it with resolver.Resolve over the cap's member envelopes only,
+// seeded with the shared seed, so every cluster's lottery is replayable from the
+// same attested value.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=remedydirectives,shortName=rd
+// +kubebuilder:printcolumn:name="Cap",type=string,JSONPath=`.spec.cap`
+// +kubebuilder:printcolumn:name="Deficit",type=integer,JSONPath=`.spec.deficit`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Freed",type=integer,JSONPath=`.status.freedGPUs`
+// +kubebuilder:validation:XValidation:rule="self.spec == oldSelf.spec",message="spec is immutable; the aggregator issues a new directive instead"
+type RemedyDirective struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RemedyDirectiveSpec   `json:"spec,omitempty"`
+	Status RemedyDirectiveStatus `json:"status,omitempty"`
+}
+
+// RemedyDirectiveSpec is the resolver scope one cluster is asked to clear. It
+// lives in the namespace of the Budget declaring the cap.
+type RemedyDirectiveSpec struct {
+	// Budget and Cap name the breached AggregateCap.
+	// +kubebuilder:validation:MinLength=1
+	Budget string `json:"budget"`
+	// +kubebuilder:validation:MinLength=1
+	Cap string `json:"cap"`
+	// Envelopes are the cap's member envelopes as the aggregator saw them. Only
+	// leases charged to one of them count toward the cap, so only they are cut.
+	// +kubebuilder:validation:MinItems=1
+	Envelopes []string `json:"envelopes"`
+	// +kubebuilder:validation:MinLength=1
+	Flavor string `json:"flavor"`
+	// Scope narrows the cut to nodes carrying these labels; empty is the whole
+	// cluster.
+	Scope map[string]string `json:"scope,omitempty"`
+	// Deficit is this cluster's share of the global excess, in GPUs.
+	// +kubebuilder:validation:Minimum=1
+	Deficit int32 `json:"deficit"`
+	// Seed is shared by every directive issued for one breach. Together with
+	// IssuedAt it is the resolver's seed source, so each cluster's lottery draws
+	// from the same attested seed.
+	// +kubebuilder:validation:MinLength=1
+	Seed     string      `json:"seed"`
+	IssuedAt metav1.Time `json:"issuedAt"`
+}
+
+// RemedyDirectiveStatus records the cluster's answer. The aggregator reads it to
+// know the breach has been acted on before it looks again.
+type RemedyDirectiveStatus struct {
+	Phase      string       `json:"phase,omitempty"`
+	FreedGPUs  int32        `json:"freedGPUs,omitempty"`
+	ExecutedAt *metav1.Time `json:"executedAt,omitempty"`
+	Message    string       `json:"message,omitempty"`
+}
+
+// RemedyDirectiveList contains a list of RemedyDirectives.
+// +kubebuilder:object:root=true
+type RemedyDirectiveList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RemedyDirective `json:"items"`
+}
+
+// Outstanding reports whether the cluster has not yet answered the directive.
+func (d *RemedyDirective) Outstanding() bool {
+	return d.Status.Phase != DirectivePhaseExecuted && d.Status.Phase != DirectivePhaseFailed
+}
+
+// Validate checks the fields the executing cluster relies on. The apiserver holds
+// the spec immutable (CEL); a directive failing this is answered Failed, not run.
+func (s *RemedyDirectiveSpec) Validate() error {
+	if s.Budget == "" || s.Cap == "" {
+		return fmt.Errorf("spec.budget and spec.cap are required")
+	}
+	if len(s.Envelopes) == 0 {
+		return fmt.Errorf("spec.envelopes must name at least one member envelope")
+	}
+	if s.Flavor == "" {
+		return fmt.Errorf("spec.flavor is required")
+	}
+	if s.Deficit <= 0 {
+		return fmt.Errorf("spec.deficit must be positive")
+	}
+	if s.Seed == "" {
+		return fmt.Errorf("spec.seed is required")
+	}
+	if s.IssuedAt.IsZero() {
+		return fmt.Errorf("spec.issuedAt is required")
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRemedyDirectiveValidation(t *testing.T) {
+	d := &RemedyDirective{Spec: RemedyDirectiveSpec{
+		Budget: "org", Cap: "global", Envelopes: []string{"h100"}, Flavor: "H100",
+		Deficit: 2, Seed: "0x0123456789abcdef", IssuedAt: NewTime(time.Now()),
+	}}
+	if err := d.Spec.Validate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !d.Outstanding() {
+		t.Fatalf("an unanswered directive must read outstanding")
+	}
+
+	d.Spec.Deficit = 0
+	if err := d.Spec.Validate(); err == nil {
+		t.Fatalf("expected error for a zero deficit")
+	}
+	d.Spec.Deficit = 2
+	d.Spec.Envelopes = nil
+	if err := d.Spec.Validate(); err == nil {
+		t.Fatalf("expected error when no member envelope is named")
+	}
+
+	d.Status.Phase = DirectivePhaseFailed
+	if d.Outstanding() {
+		t.Fatalf("an answered directive must not read outstanding")
+	}
+}
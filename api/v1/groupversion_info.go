@@ -28,7 +28,13 @@ func addKnownTypes(s *runtime.Scheme) error {
 		&GPULease{}, &GPULeaseList{},
 		&Reservation{}, &ReservationList{},
 		&Grant{}, &GrantList{},
+		&BudgetTransfer{}, &BudgetTransferList{},
 		&QuotaSnapshot{}, &QuotaSnapshotList{},
+		&RemedyDirective{}, &RemedyDirectiveList{},
+		&RunSweep{}, &RunSweepList{},
+		&Pipeline{}, &PipelineList{},
+		&LeaseArchive{}, &LeaseArchiveList{},
+		&TopologyHierarchy{}, &TopologyHierarchyList{},
 	)
 	metav1.AddToGroupVersion(s, GroupVersion)
 	return nil
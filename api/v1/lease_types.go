@@ -110,8 +110,17 @@ type GPULeaseSlice struct {
 	// Slots, as node#ordinal. A lease holding no slot is a charge for nothing.
 	// +kubebuilder:validation:MinItems=1
 	Nodes []string `json:"nodes"`
+	// Role is the slice fact — Active work or a held Spare — and nothing else.
+	// A multi-role run's role NAME (actor, learner, ...) rides the
+	// rq.davidlangworthy.io/role-name label instead: every Active/Spare reader
+	// in the engine would otherwise have to learn that "learner" means Active.
 	// +kubebuilder:validation:Enum=Active;Spare
 	Role string `json:"role"`
+	// Profile is the MIG profile the slots are slices of (1g.10gb); empty
+	// when each slot is a whole GPU. Usage counts a node's slices apart from
+	// its whole GPUs.
+	// +optional
+	Profile string `json:"profile,omitempty"`
 }
 
 // GPULeaseInterval timestamps the lease.
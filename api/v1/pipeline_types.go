@@ -0,0 +1,130 @@
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// Pipeline phases. A pipeline is Running until every stage has finished.
+const (
+	PipelinePhaseRunning  = "Running"
+	PipelinePhaseComplete = "Completed"
+	PipelinePhaseFailed   = "Failed"
+)
+
+// PipelineStageSkipped is the phase a pipeline records for a stage whose follow
+// condition could not hold, so it never ran. It is never a Run's own phase.
+const PipelineStageSkipped = "Skipped"
+
+// Pipeline is a DAG of Runs joined by follow edges — preprocess, train, eval,
+// export — declared as one object so the whole graph is checked before any of
+// it is created.
+//
+// Each stage becomes a Run named <pipeline>-<stage>, owned by the pipeline, whose
+// spec.follow is the stage's follow with stage names mapped to those Run names.
+// The engine gates each stage exactly as it gates any followed Run, and hands a
+// stage its upstreams' /artifacts locations through the pod env.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=pipelines,shortName=pipe
+// +kubebuilder:printcolumn:name="Stages",type=integer,JSONPath=`.status.total`
+// +kubebuilder:printcolumn:name="Succeeded",type=integer,JSONPath=`.status.succeeded`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+type Pipeline struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   PipelineSpec   `json:"spec,omitempty"`
+	Status PipelineStatus `json:"status,omitempty"`
+}
+
+// PipelineSpec lists the stages. Order is not significant: the follow edges are
+// the graph.
+type PipelineSpec struct {
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=64
+	Stages []PipelineStage `json:"stages"`
+}
+
+// PipelineStage is one node of the DAG.
+type PipelineStage struct {
+	// Name is the stage's name within the pipeline; its Run is
+	// <pipeline>-<name>.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=40
+	Name string `json:"name"`
+	// Follow gates the stage on other stages: After names stages of this
+	// pipeline, and When, MinSucceeded, and OnUpstreamFailure mean what they
+	// mean on a Run. A stage without it starts at once.
+	Follow   *RunFollow  `json:"follow,omitempty"`
+	Template RunTemplate `json:"template"`
+}
+
+// PipelineStatus is the per-stage view of the DAG.
+type PipelineStatus struct {
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	Total   int32  `json:"total,omitempty"`
+	// Succeeded counts stages that completed; Skipped ones are not counted.
+	Succeeded   int32                 `json:"succeeded,omitempty"`
+	CompletedAt *metav1.Time          `json:"completedAt,omitempty"`
+	Stages      []PipelineStageStatus `json:"stages,omitempty"`
+}
+
+// PipelineStageStatus is one stage as the pipeline last saw it.
+type PipelineStageStatus struct {
+	Name string `json:"name"`
+	Run  string `json:"run,omitempty"`
+	// Phase is the stage Run's phase, or Skipped.
+	Phase string `json:"phase,omitempty"`
+	// Artifacts is where the stage's outputs land, as injected into the stages
+	// that follow it.
+	Artifacts string `json:"artifacts,omitempty"`
+}
+
+// PipelineList contains a list of Pipelines.
+// +kubebuilder:object:root=true
+type PipelineList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []Pipeline `json:"items"`
+}
+
+// Validate checks each stage on its own and that every edge names a stage. It
+// does not look for cycles, which need the whole graph; pipeline.Validate does
+// both.
+func (s *PipelineSpec) Validate() error {
+	if len(s.Stages) == 0 {
+		return fmt.Errorf("spec.stages must declare at least one stage")
+	}
+	names := make(map[string]bool, len(s.Stages))
+	for _, stage := range s.Stages {
+		if errs := validation.IsDNS1123Label(stage.Name); len(errs) > 0 {
+			return fmt.Errorf("stage name %q: %s", stage.Name, errs[0])
+		}
+		if names[stage.Name] {
+			return fmt.Errorf("stage %q is declared twice", stage.Name)
+		}
+		names[stage.Name] = true
+	}
+	for _, stage := range s.Stages {
+		if stage.Template.Spec.Follow != nil {
+			return fmt.Errorf("stage %q: the template must not set spec.follow; declare the stage's follow instead", stage.Name)
+		}
+		if stage.Follow == nil {
+			continue
+		}
+		if err := stage.Follow.Validate(stage.Name); err != nil {
+			return fmt.Errorf("stage %q: %w", stage.Name, err)
+		}
+		for _, up := range stage.Follow.After {
+			if !names[up] {
+				return fmt.Errorf("stage %q follows %q, which is not a stage of this pipeline", stage.Name, up)
+			}
+		}
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package v1
 import (
 	"fmt"
 	"reflect"
+	"time"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
@@ -36,6 +37,37 @@ type ReservationSpec struct {
 	// +kubebuilder:validation:MinLength=1
 	PayingEnvelope string      `json:"payingEnvelope"`
 	EarliestStart  metav1.Time `json:"earliestStart"`
+	// Flavor is the GPU flavor the reservation holds the run a place on, one
+	// of its gpuType and alternatives. Empty reads as the run's gpuType.
+	Flavor string `json:"flavor,omitempty"`
+	// Booking is set when the reservation holds the run's spec.schedule
+	// rather than a forecast: earliestStart is the booked start, and the
+	// intended slice is drained of unfunded work ahead of it.
+	Booking *ReservationBooking `json:"booking,omitempty"`
+}
+
+// ReservationBooking is the calendar half of a booked reservation.
+type ReservationBooking struct {
+	// DrainFrom is when the controller starts reclaiming unfunded work from
+	// the intended slice, in step with the clock, so the booked width is free
+	// at earliestStart.
+	DrainFrom metav1.Time `json:"drainFrom"`
+	// Until ends the window the booking holds capacity for; other bookings
+	// overlapping it are checked against it. Empty is open-ended.
+	Until *metav1.Time `json:"until,omitempty"`
+}
+
+// BookingOverlaps reports whether the reservation is a booking that holds
+// capacity at some instant of [from, until); a nil until is open-ended.
+func (r *Reservation) BookingOverlaps(from time.Time, until *metav1.Time) bool {
+	b := r.Spec.Booking
+	if b == nil {
+		return false
+	}
+	if until != nil && !r.Spec.EarliestStart.Time.Before(until.Time) {
+		return false
+	}
+	return b.Until == nil || from.Before(b.Until.Time)
 }
 
 // IntendedSlice defines the target topology.
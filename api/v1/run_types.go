@@ -2,6 +2,7 @@ package v1
 
 import (
 	"fmt"
+	"time"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -48,9 +49,12 @@ type RunSpec struct {
 	// width (docs/project/remediation/R9-jobset-amendment.md); we keep its
 	// shape as a reference contract and own the pods.
 	//
-	// v1 admits exactly one role; the field is a list so heterogeneous
-	// multi-role Runs (RL gang-of-gangs: trainer/sampler/grader) land later as
-	// a purely additive, non-breaking change (borrow-vs-build.md §2.2).
+	// Several roles make a heterogeneous multi-role Run (RL gang-of-gangs:
+	// trainer/sampler/grader), landed as the purely additive change the list
+	// shape was kept for (borrow-vs-build.md §2.2). Every role's pods belong to
+	// ONE gang: the scheduler plugin admits and funds them atomically, so the
+	// half-admitted RL job that two separate Runs produced cannot occur. The
+	// roles' width*gpusPerPod must sum to Resources.TotalGPUs.
 	//
 	// Roles is optional: a Run with no role still materializes, but with a
 	// default terminating container rather than the researcher's workload. That
@@ -64,8 +68,69 @@ type RunSpec struct {
 	// +kubebuilder:validation:Minimum=0
 	Spares *int32     `json:"sparesPerGroup,omitempty"`
 	Follow *RunFollow `json:"follow,omitempty"`
+	// Priority asks for urgency inside an envelope: among claims of the same
+	// tier, a higher priority ranks ahead of an earlier admission, and the
+	// preemption lottery draws the run less often. It is a request, not a
+	// grant — each envelope honors it only up to its maxPriority, so an
+	// unauthorized priority is simply 0.
+	// +kubebuilder:validation:Minimum=0
+	// +kubebuilder:validation:Maximum=9
+	Priority int32 `json:"priority,omitempty"`
+	// Schedule books the run a future window instead of asking for capacity
+	// now. See RunSchedule.
+	Schedule *RunSchedule `json:"schedule,omitempty"`
+}
+
+// DefaultBookingDrainLead is how long before a booking's start the controller
+// begins clearing unfunded work from the booked slice when the run names no
+// drainLead.
+const DefaultBookingDrainLead = time.Hour
+
+// RunSchedule is a calendar booking: "128 H100s next Tuesday 09:00-21:00".
+//
+// The controller checks it once, when it first sees the run: the fleet must
+// have the width in one placement that no overlapping booking already holds,
+// and the run's envelopes must fund it at startAfter (and at until, when set)
+// on top of the owner's other overlapping bookings. A booking that passes is
+// held as a Reservation at startAfter; one that does not fails the run as
+// BookingRejected with the reason, since a slot that cannot be had is worth
+// knowing now rather than at 09:00.
+//
+// From startAfter - drainLead the controller reclaims unfunded work from the
+// booked slice in step with the clock, so the width is free when the booking
+// starts; at startAfter it activates like any reservation. A booking reserves
+// a start, not a stop: the run holds its leases until it completes.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.until) || self.until > self.startAfter",message="schedule.until must be after schedule.startAfter"
+type RunSchedule struct {
+	// StartAfter is the booked start. The run is not admitted before it.
+	StartAfter metav1.Time `json:"startAfter"`
+	// Until ends the window the booking commits capacity and funding for.
+	// Empty books it open-ended.
+	Until *metav1.Time `json:"until,omitempty"`
+	// DrainLead is how long before StartAfter the booked slice starts
+	// draining of unfunded work. Defaults to DefaultBookingDrainLead.
+	DrainLead *metav1.Duration `json:"drainLead,omitempty"`
+}
+
+// Validate checks a schedule's window.
+func (s *RunSchedule) Validate() error {
+	if s.StartAfter.IsZero() {
+		return fmt.Errorf("schedule.startAfter is required")
+	}
+	if s.Until != nil && !s.Until.Time.After(s.StartAfter.Time) {
+		return fmt.Errorf("schedule.until must be after schedule.startAfter")
+	}
+	if s.DrainLead != nil && s.DrainLead.Duration < 0 {
+		return fmt.Errorf("schedule.drainLead must not be negative")
+	}
+	return nil
 }
 
+// MaxRunPriority is the highest priority a run may request or an envelope
+// authorize.
+const MaxRunPriority = 9
+
 // GPUTargetContainerName is the convention for the container that receives the
 // injected nvidia.com/gpu request/limit — and, once R9 phase 9A-2 lands, the
 // rendezvous env. A role's template should name its workload container this; if
@@ -86,7 +151,7 @@ const (
 // ReplicatedJob; we keep the shape as a reference contract and not as a
 // dependency — see controllers/kube.buildPod.) It carries the per-role workload
 // template plus the width/topology/spare knobs that were previously spread
-// across RunSpec, so a future multi-role Run can size each role independently.
+// across RunSpec, so each role of a multi-role Run is sized independently.
 type RunRole struct {
 	// Name identifies the role (e.g. "trainer"). It becomes the gang-role label
 	// value and the pod-name prefix, so it must be a non-empty DNS label.
@@ -119,8 +184,8 @@ type RunRole struct {
 	Template corev1.PodTemplateSpec `json:"template"`
 
 	// Width is the number of pods in this role's gang: all of them run, or none
-	// does. Must be positive. Width*GPUsPerPod must equal the Run's
-	// Resources.TotalGPUs in v1 (single role).
+	// does. Must be positive. Summed over every role, Width*GPUsPerPod must
+	// equal the Run's Resources.TotalGPUs.
 	// +kubebuilder:validation:Minimum=1
 	Width int32 `json:"width"`
 
@@ -162,6 +227,12 @@ type RunRole struct {
 	// the run waits this long (parked, via status.retryAfter) before the next
 	// attempt. Zero/unset re-emits immediately.
 	Backoff *metav1.Duration `json:"backoff,omitempty"`
+
+	// AttemptTimeout limits each attempt of a member under Retry: a member whose
+	// lease has been open this long is taken as hung, deleted, and retried like a
+	// failed one, counting against Retries. When the retries are spent the run
+	// ends TimeLimitExceeded. Only valid with failurePolicy Retry.
+	AttemptTimeout *metav1.Duration `json:"attemptTimeout,omitempty"`
 }
 
 // GPUTargetContainerIndex returns the index of the container that receives the
@@ -188,26 +259,80 @@ const (
 	OnUpstreamFailureFail = "fail"
 )
 
+// Follow conditions: which upstream outcomes let a followed run start.
+// Succeeded is the default (empty string).
+const (
+	FollowWhenSucceeded = "Succeeded"
+	FollowWhenFailed    = "Failed"
+	FollowWhenDone      = "Done"
+)
+
 // RunFollow makes a run wait for other runs in the same namespace to complete
 // before it is admitted — a "job forest" of runs joined by follow edges. All
 // runs in After must reach Completed. If one fails (or is deleted),
 // onUpstreamFailure decides: "wait" (default) keeps this run Waiting for a
 // grace period so the researcher can fix and resubmit the failed stage, then
 // fails it; "fail" fails this run immediately.
+//
+// When turns an edge into a branch. "Failed" starts the run only once every
+// upstream has finished and at least one failed (a cleanup or alert stage);
+// "Done" starts it once every upstream has finished, whatever the outcome. A run
+// whose condition can no longer hold is Skipped, and a Succeeded follower of a
+// skipped run is skipped in turn. MinSucceeded fans in on a subset: the run
+// starts as soon as that many of After have completed.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.minSucceeded) || self.minSucceeded <= size(self.after)",message="follow.minSucceeded cannot exceed the number of upstreams"
+// +kubebuilder:validation:XValidation:rule="!has(self.minSucceeded) || !has(self.when) || size(self.when) == 0 || self.when == 'Succeeded'",message="follow.minSucceeded applies only to a Succeeded follow"
 type RunFollow struct {
 	// +kubebuilder:validation:MinItems=1
 	After []string `json:"after"`
+	// +kubebuilder:validation:Enum="";Succeeded;Failed;Done
+	When string `json:"when,omitempty"`
+	// +kubebuilder:validation:Minimum=1
+	MinSucceeded *int32 `json:"minSucceeded,omitempty"`
 	// +kubebuilder:validation:Enum="";wait;fail
 	OnUpstreamFailure    string           `json:"onUpstreamFailure,omitempty"`
 	UpstreamFailureGrace *metav1.Duration `json:"upstreamFailureGrace,omitempty"`
 }
 
+// Needed is how many upstreams must complete before a Succeeded follow starts.
+func (f *RunFollow) Needed() int {
+	if f.MinSucceeded != nil && *f.MinSucceeded > 0 && int(*f.MinSucceeded) < len(f.After) {
+		return int(*f.MinSucceeded)
+	}
+	return len(f.After)
+}
+
 // RunResources describes GPU requirements.
 type RunResources struct {
+	// GPUType is the flavor: a node gpu.flavor label, or one with a MIG
+	// profile appended (a100-1g.10gb) for slices of that GPU. TotalGPUs and
+	// every other width then counts slices.
 	// +kubebuilder:validation:MinLength=1
 	GPUType string `json:"gpuType"`
 	// +kubebuilder:validation:Minimum=1
 	TotalGPUs int32 `json:"totalGPUs"`
+	// Alternatives are further flavors the run will take, in order of
+	// preference after GPUType. Admission places the run on the first flavor
+	// that can both fit and fund it now; a run that must wait reserves on
+	// whichever flavor forecasts the earliest start.
+	// +listType=map
+	// +listMapKey=gpuType
+	// +kubebuilder:validation:MaxItems=8
+	Alternatives []FlavorAlternative `json:"alternatives,omitempty"`
+}
+
+// FlavorAlternative is one flavor a run will take besides its gpuType.
+type FlavorAlternative struct {
+	// +kubebuilder:validation:MinLength=1
+	GPUType string `json:"gpuType"`
+	// WidthPercent scales a malleable run's desired and maximum width on this
+	// flavor, e.g. 200 for a flavor half as fast per GPU. The base gang keeps
+	// resources.totalGPUs; only the elastic ceiling moves. Defaults to 100,
+	// and only a malleable run may set it.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=1000
+	WidthPercent *int32 `json:"widthPercent,omitempty"`
 }
 
 // RunLocality captures placement preferences.
@@ -215,11 +340,53 @@ type RunLocality struct {
 	// +kubebuilder:validation:Minimum=1
 	GroupGPUs             *int32 `json:"groupGPUs,omitempty"`
 	AllowCrossGroupSpread *bool  `json:"allowCrossGroupSpread,omitempty"`
+	// GroupWithin names a TopologyHierarchy level that each group must fit
+	// inside one unit of, e.g. "nvlink".
+	// +kubebuilder:validation:MaxLength=63
+	GroupWithin string `json:"groupWithin,omitempty"`
+	// RunWithin names a TopologyHierarchy level that the whole run, spares
+	// included, must fit inside one unit of, e.g. "spine".
+	// +kubebuilder:validation:MaxLength=63
+	RunWithin string `json:"runWithin,omitempty"`
 }
 
 // RunRuntime covers runtime behavior hints.
 type RunRuntime struct {
 	Checkpoint metav1.Duration `json:"checkpoint,omitempty"`
+	// NoDefrag keeps the defragmenter from moving this run to open a
+	// fast-fabric domain for a larger one. Only a run that checkpoints is
+	// ever moved.
+	NoDefrag bool `json:"noDefrag,omitempty"`
+	// MaxDuration is the run's wall-clock limit, counted from when its gang
+	// started (its earliest open lease): a run requeued whole starts a fresh
+	// clock when it re-admits. WarnBefore ahead of the limit the run gets a
+	// TimeLimitWarning Event and its pods the time-limit annotation, their cue
+	// to checkpoint; at the limit its leases close (TimeLimit) and it ends
+	// TimeLimitExceeded. A hung job stops charging its budget.
+	MaxDuration *metav1.Duration `json:"maxDuration,omitempty"`
+	// WarnBefore is how long before MaxDuration the run is warned. Defaults to
+	// Checkpoint when that is set, else DefaultTimeLimitWarning.
+	WarnBefore *metav1.Duration `json:"warnBefore,omitempty"`
+}
+
+// DefaultTimeLimitWarning is how long before its limit a run that declares no
+// warnBefore or checkpoint is warned.
+const DefaultTimeLimitWarning = 10 * time.Minute
+
+// Validate checks the runtime's durations.
+func (r *RunRuntime) Validate() error {
+	if r.MaxDuration != nil && r.MaxDuration.Duration <= 0 {
+		return fmt.Errorf("runtime.maxDuration must be positive")
+	}
+	if r.WarnBefore != nil {
+		if r.MaxDuration == nil {
+			return fmt.Errorf("runtime.warnBefore is only valid with runtime.maxDuration")
+		}
+		if r.WarnBefore.Duration < 0 || r.WarnBefore.Duration >= r.MaxDuration.Duration {
+			return fmt.Errorf("runtime.warnBefore must be non-negative and shorter than runtime.maxDuration")
+		}
+	}
+	return nil
 }
 
 // RunMalleability allows elastic scaling.
@@ -236,6 +403,26 @@ type RunMalleability struct {
 	StepGPUs int32 `json:"stepGPUs"`
 	// +kubebuilder:validation:Minimum=1
 	DesiredTotalGPUs *int32 `json:"desiredTotalGPUs,omitempty"`
+	// Goodput sizes the run by its measured throughput: it grows toward
+	// DesiredTotalGPUs one step at a time, and only while the last step paid
+	// for itself. Unset keeps the mechanical grow straight to the desired width.
+	Goodput *RunGoodputPolicy `json:"goodput,omitempty"`
+}
+
+// RunGoodputPolicy tunes goodput-driven sizing. The workload reports its
+// throughput on a pod annotation; the controller keeps the curve in
+// status.goodput and moves the target width in stepGPUs increments.
+type RunGoodputPolicy struct {
+	// MinGainPercent is the least a step up must return: each added GPU must add
+	// at least this percent of the run's current per-GPU throughput. A step whose
+	// GPUs added nothing is given back; between the two the width holds.
+	// Defaults to 10.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=100
+	MinGainPercent int32 `json:"minGainPercent,omitempty"`
+	// Cooldown is the least time between two resizes, and how long a new width
+	// runs before its throughput is believed. Defaults to 10m.
+	Cooldown metav1.Duration `json:"cooldown,omitempty"`
 }
 
 // RunFunding captures borrowing intents.
@@ -263,9 +450,14 @@ type RunStatus struct {
 	Width              *RunWidthStatus    `json:"width,omitempty"`
 	Funding            *RunFundingStatus  `json:"funding,omitempty"`
 	ETA                *RunETA            `json:"eta,omitempty"`
+	Goodput            *RunGoodputStatus  `json:"goodput,omitempty"`
 	// FollowDeadline is set while the run waits on a failed upstream under the
 	// "wait" policy: if the upstream is not resolved by then, the run fails.
 	FollowDeadline *metav1.Time `json:"followDeadline,omitempty"`
+	// Upstreams records, when the follow gate opens, how each upstream finished
+	// and where it left its outputs. Pods read it: each upstream's artifact
+	// location is injected into the run's workload containers.
+	Upstreams []RunUpstream `json:"upstreams,omitempty"`
 	// CheckpointDeadline is set when a node fails with no spare to swap onto
 	// and spec.runtime.checkpoint is a positive duration: instead of failing
 	// immediately, the run is parked Pending and given until this deadline
@@ -273,12 +465,51 @@ type RunStatus struct {
 	// terminally. A zero/unset Checkpoint keeps the old behavior of failing
 	// immediately on an uncovered node failure.
 	CheckpointDeadline *metav1.Time `json:"checkpointDeadline,omitempty"`
+	// DrainDeadline is set when the resolver cuts a run that declares
+	// spec.runtime.checkpoint: its pods are deleted gracefully (SIGTERM and
+	// the template's preStop hooks) with this long to write a checkpoint
+	// before they are killed, and a run requeued by the cut waits it out
+	// before re-admitting. Cleared once the drain is over.
+	DrainDeadline *metav1.Time `json:"drainDeadline,omitempty"`
 	// FailedAttempts counts how many times a Retry-policy run has re-emitted a
 	// failed member (R9 9A-3). At the role's Retries, the run Fails.
 	FailedAttempts int32 `json:"failedAttempts,omitempty"`
 	// RetryAfter is set while a Retry-policy run waits out its Backoff before the
 	// next re-emit, so a crash-looping member does not re-emit in a tight spin.
 	RetryAfter *metav1.Time `json:"retryAfter,omitempty"`
+	// Flavor is the GPU flavor admission chose among spec.resources.gpuType
+	// and its alternatives: set when the run's pods are emitted or it
+	// reserves, and kept while its pods or leases are out. Empty reads as
+	// gpuType.
+	Flavor string `json:"flavor,omitempty"`
+	// TimeLimit tracks spec.runtime.maxDuration from the first pass the run
+	// is seen running. It is cleared when the run is requeued whole and kept
+	// once the run ends.
+	TimeLimit *RunTimeLimitStatus `json:"timeLimit,omitempty"`
+}
+
+// RunTimeLimitStatus is a running run's wall-clock limit.
+type RunTimeLimitStatus struct {
+	// Deadline is when the run's leases close: its gang's start plus
+	// spec.runtime.maxDuration.
+	Deadline metav1.Time `json:"deadline"`
+	// WarnAt is when the run is warned: Deadline less spec.runtime.warnBefore.
+	WarnAt metav1.Time `json:"warnAt"`
+	// WarnedAt is set once the run has been warned and its pods annotated
+	// with the deadline.
+	WarnedAt *metav1.Time `json:"warnedAt,omitempty"`
+}
+
+// RunUpstream is one followed run as the gate saw it when this run started.
+type RunUpstream struct {
+	Name string `json:"name"`
+	// Phase is the upstream's phase, Skipped for one that never ran, or empty
+	// when it had been deleted.
+	Phase string `json:"phase,omitempty"`
+	// Artifacts is the upstream's durable output volume at the /artifacts
+	// convention, as a URI (pvc://claim, nfs://server/path, csi://driver/handle);
+	// empty when it keeps none.
+	Artifacts string `json:"artifacts,omitempty"`
 }
 
 // RunETA is an optional, best-effort estimate of when the run will finish. It
@@ -292,6 +523,28 @@ type RunETA struct {
 	Source              string      `json:"source,omitempty"`
 }
 
+// RunGoodputStatus is a malleable run's measured throughput curve and the
+// width goodput sizing chose from it. Unlike status.funding it is not a cache:
+// the curve exists nowhere else, and the resolver reads it to decide whose
+// width is cheapest to take.
+type RunGoodputStatus struct {
+	// Curve is the latest throughput observed at each width the run has
+	// settled at, ascending by GPUs.
+	Curve []RunGoodputPoint `json:"curve,omitempty"`
+	// TargetGPUs is the width goodput sizing is steering toward;
+	// spec.malleable.desiredTotalGPUs bounds it from above.
+	TargetGPUs int32 `json:"targetGPUs,omitempty"`
+	// LastResize is when TargetGPUs last changed.
+	LastResize *metav1.Time `json:"lastResize,omitempty"`
+}
+
+// RunGoodputPoint is one throughput observation at one width.
+type RunGoodputPoint struct {
+	GPUs       int32       `json:"gpus"`
+	Throughput float64     `json:"throughput"`
+	ObservedAt metav1.Time `json:"observedAt"`
+}
+
 // RunWidthStatus summarises elastic width bookkeeping.
 type RunWidthStatus struct {
 	Min       int32  `json:"min,omitempty"`
@@ -315,6 +568,14 @@ type RunFundingStatus struct {
 	UnfundedGPUs     int32                   `json:"unfundedGPUs,omitempty"`
 	UnfundedGPUHours float64                 `json:"unfundedGPUHours,omitempty"`
 	Lenders          []RunFundingLenderShare `json:"lenders,omitempty"`
+	// Priority is the part of spec.priority the run's funding envelopes
+	// authorize: the rank it holds in funding and lottery, not what it asked.
+	Priority int32 `json:"priority,omitempty"`
+	// Roles splits the active width by role for a multi-role run, so the
+	// owner of an RL gang can see which role is running on borrowed or
+	// unfunded capacity. Empty for single-role and role-less runs, whose
+	// whole width is the one role.
+	Roles []RunFundingRoleShare `json:"roles,omitempty"`
 }
 
 // RunFundingLenderShare attributes shared or borrowed capacity to the owner
@@ -325,6 +586,17 @@ type RunFundingLenderShare struct {
 	GPUHours float64 `json:"gpuHours,omitempty"`
 }
 
+// RunFundingRoleShare is one role's slice of a multi-role run's funding: its
+// active width per derived class and the GPU-hours it has accrued.
+type RunFundingRoleShare struct {
+	Role         string  `json:"role"`
+	OwnedGPUs    int32   `json:"ownedGPUs,omitempty"`
+	SharedGPUs   int32   `json:"sharedGPUs,omitempty"`
+	BorrowedGPUs int32   `json:"borrowedGPUs,omitempty"`
+	UnfundedGPUs int32   `json:"unfundedGPUs,omitempty"`
+	GPUHours     float64 `json:"gpuHours,omitempty"`
+}
+
 // RunList contains a list of Run.
 // +kubebuilder:object:root=true
 type RunList struct {
@@ -343,6 +615,25 @@ func (s *RunSpec) AllowCrossGroupSpread() bool {
 	return *s.Locality.AllowCrossGroupSpread
 }
 
+// Flavors lists the GPU flavors the run will take, in order of preference:
+// GPUType, then each alternative.
+func (r *RunResources) Flavors() []string {
+	out := []string{r.GPUType}
+	for _, alt := range r.Alternatives {
+		out = append(out, alt.GPUType)
+	}
+	return out
+}
+
+// Flavor is the GPU flavor the run is placed or reserved on: status.flavor
+// once admission has chosen one, spec.resources.gpuType before.
+func (r *Run) Flavor() string {
+	if r.Status.Flavor != "" {
+		return r.Status.Flavor
+	}
+	return r.Spec.Resources.GPUType
+}
+
 // Desired returns the effective desired width, applying the API default
 // (MaxTotalGPUs) when the field is unset. Consumers must use this instead
 // of re-implementing the default.
@@ -390,6 +681,24 @@ func (r *Run) validate() error {
 	if r.Spec.Resources.TotalGPUs <= 0 {
 		return fmt.Errorf("spec.resources.totalGPUs must be positive")
 	}
+	seen := map[string]bool{r.Spec.Resources.GPUType: true}
+	for i, alt := range r.Spec.Resources.Alternatives {
+		if alt.GPUType == "" {
+			return fmt.Errorf("spec.resources.alternatives[%d].gpuType is required", i)
+		}
+		if seen[alt.GPUType] {
+			return fmt.Errorf("spec.resources.alternatives[%d]: flavor %q is already listed", i, alt.GPUType)
+		}
+		seen[alt.GPUType] = true
+		if alt.WidthPercent != nil {
+			if *alt.WidthPercent < 1 || *alt.WidthPercent > 1000 {
+				return fmt.Errorf("spec.resources.alternatives[%d].widthPercent must be within 1..1000", i)
+			}
+			if r.Spec.Malleable == nil && *alt.WidthPercent != 100 {
+				return fmt.Errorf("spec.resources.alternatives[%d].widthPercent scales a malleable width; the run is not malleable", i)
+			}
+		}
+	}
 	if r.Spec.Locality != nil && r.Spec.Locality.GroupGPUs != nil {
 		if *r.Spec.Locality.GroupGPUs <= 0 {
 			return fmt.Errorf("spec.locality.groupGPUs must be positive when set")
@@ -437,54 +746,114 @@ func (r *Run) validate() error {
 			return err
 		}
 	}
+	if r.Spec.Priority < 0 || r.Spec.Priority > MaxRunPriority {
+		return fmt.Errorf("priority must be between 0 and %d", MaxRunPriority)
+	}
+	if r.Spec.Schedule != nil {
+		if err := r.Spec.Schedule.Validate(); err != nil {
+			return err
+		}
+	}
+	if r.Spec.Runtime != nil {
+		if err := r.Spec.Runtime.Validate(); err != nil {
+			return err
+		}
+	}
 	if err := r.Spec.validateRoles(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// validateRoles enforces the v1 workload contract. Roles is optional while the
-// legacy pause-pod path still exists; when present, v1 admits exactly one role
-// (multi-role RL is a later additive fast-follow) and fully validates it.
+// validateRoles enforces the workload contract. Roles is optional while the
+// legacy pause-pod path still exists; when present, every role is fully
+// validated and together the roles must account for resources.totalGPUs
+// exactly, since that is the figure cover funds and the packer places.
+//
+// Several roles form ONE heterogeneous gang — the RL gang-of-gangs (rollout
+// actors, learners, a reward model) — admitted by one Permit, funded by one
+// cover plan and released together. Two things a mixed gang cannot have yet
+// are refused rather than silently mis-sized: elastic width (the grow/shrink
+// loop adds and cuts one uniform pod size) and hot spares (a spare is a held
+// pod of one size, and the swap that consumes it must know which role it
+// stands in for).
 func (s *RunSpec) validateRoles() error {
 	if len(s.Roles) == 0 {
 		return nil
 	}
-	if len(s.Roles) > 1 {
-		return fmt.Errorf("spec.roles: v1 supports exactly one role; multi-role runs are a later additive feature")
+	seen := make(map[string]struct{}, len(s.Roles))
+	var total int32
+	for i := range s.Roles {
+		role := &s.Roles[i]
+		field := fmt.Sprintf("spec.roles[%d]", i)
+		if err := role.validate(field); err != nil {
+			return err
+		}
+		if _, dup := seen[role.Name]; dup {
+			return fmt.Errorf("%s.name %q is used by more than one role", field, role.Name)
+		}
+		seen[role.Name] = struct{}{}
+		total += role.Width * role.GPUsPerPod
 	}
-	role := &s.Roles[0]
-	if role.Name == "" {
-		return fmt.Errorf("spec.roles[0].name is required")
+	if len(s.Roles) == 1 {
+		if total != s.Resources.TotalGPUs {
+			return fmt.Errorf("spec.roles[0]: width*gpusPerPod (%d) must equal resources.totalGPUs (%d)", total, s.Resources.TotalGPUs)
+		}
+		return nil
 	}
-	if role.Width <= 0 {
-		return fmt.Errorf("spec.roles[0].width must be positive")
+	if total != s.Resources.TotalGPUs {
+		return fmt.Errorf("spec.roles: the roles' width*gpusPerPod sum to %d but resources.totalGPUs is %d", total, s.Resources.TotalGPUs)
 	}
-	if role.GPUsPerPod <= 0 {
-		return fmt.Errorf("spec.roles[0].gpusPerPod must be positive")
+	if s.Malleable != nil {
+		return fmt.Errorf("spec.malleable is not supported on a multi-role run: elastic width grows and shrinks one uniform pod size")
 	}
-	if role.Width*role.GPUsPerPod != s.Resources.TotalGPUs {
-		return fmt.Errorf("spec.roles[0]: width*gpusPerPod (%d) must equal resources.totalGPUs (%d)", role.Width*role.GPUsPerPod, s.Resources.TotalGPUs)
+	if s.Spares != nil && *s.Spares > 0 {
+		return fmt.Errorf("spec.sparesPerGroup is not supported on a multi-role run: a hot spare is sized for a single role's pods")
 	}
-	if role.GroupGPUs != nil && *role.GroupGPUs <= 0 {
-		return fmt.Errorf("spec.roles[0].groupGPUs must be positive when set")
+	for i := range s.Roles {
+		if sp := s.Roles[i].Spares; sp != nil && *sp > 0 {
+			return fmt.Errorf("spec.roles[%d].spares is not supported on a multi-role run: a hot spare is sized for a single role's pods", i)
+		}
 	}
-	if role.Spares != nil && *role.Spares < 0 {
-		return fmt.Errorf("spec.roles[0].spares must be >= 0 when set")
+	return nil
+}
+
+// validate checks one role's own fields; field is its path in error messages.
+func (r *RunRole) validate(field string) error {
+	if r.Name == "" {
+		return fmt.Errorf("%s.name is required", field)
+	}
+	if r.Width <= 0 {
+		return fmt.Errorf("%s.width must be positive", field)
+	}
+	if r.GPUsPerPod <= 0 {
+		return fmt.Errorf("%s.gpusPerPod must be positive", field)
 	}
-	switch role.FailurePolicy {
+	if r.GroupGPUs != nil && *r.GroupGPUs <= 0 {
+		return fmt.Errorf("%s.groupGPUs must be positive when set", field)
+	}
+	if r.Spares != nil && *r.Spares < 0 {
+		return fmt.Errorf("%s.spares must be >= 0 when set", field)
+	}
+	switch r.FailurePolicy {
 	case "", FailurePolicyFail, FailurePolicyIgnore:
-		if role.Retries != nil {
-			return fmt.Errorf("spec.roles[0].retries is only valid with failurePolicy Retry")
+		if r.Retries != nil {
+			return fmt.Errorf("%s.retries is only valid with failurePolicy Retry", field)
+		}
+		if r.AttemptTimeout != nil {
+			return fmt.Errorf("%s.attemptTimeout is only valid with failurePolicy Retry", field)
 		}
 	case FailurePolicyRetry:
-		if role.Retries == nil || *role.Retries <= 0 {
-			return fmt.Errorf("spec.roles[0].retries must be positive when failurePolicy is Retry")
+		if r.Retries == nil || *r.Retries <= 0 {
+			return fmt.Errorf("%s.retries must be positive when failurePolicy is Retry", field)
+		}
+		if r.AttemptTimeout != nil && r.AttemptTimeout.Duration <= 0 {
+			return fmt.Errorf("%s.attemptTimeout must be positive", field)
 		}
 	default:
-		return fmt.Errorf("spec.roles[0].failurePolicy %q must be Fail, Retry, or Ignore", role.FailurePolicy)
+		return fmt.Errorf("%s.failurePolicy %q must be Fail, Retry, or Ignore", field, r.FailurePolicy)
 	}
-	return role.validateTemplate()
+	return r.validateTemplate(field)
 }
 
 // validateTemplate checks the workload pod template. Because the template is
@@ -499,32 +868,32 @@ func (s *RunSpec) validateRoles() error {
 // overridden, so reject it at submission instead of confusing them later.
 var ReservedRendezvousEnvNames = []string{"MASTER_ADDR", "MASTER_PORT", "WORLD_SIZE", "NNODES", "NODE_RANK"}
 
-func (r *RunRole) validateTemplate() error {
+func (r *RunRole) validateTemplate(field string) error {
 	spec := &r.Template.Spec
 	if len(spec.Containers) == 0 {
-		return fmt.Errorf("spec.roles[0].template must define at least one container")
+		return fmt.Errorf("%s.template must define at least one container", field)
 	}
 	for i := range spec.Containers {
 		for _, e := range spec.Containers[i].Env {
 			for _, reserved := range ReservedRendezvousEnvNames {
 				if e.Name == reserved {
-					return fmt.Errorf("spec.roles[0].template: container %q sets env %q, which jobtree owns for distributed-training rendezvous (R9 9A-2) — remove it", spec.Containers[i].Name, e.Name)
+					return fmt.Errorf("%s.template: container %q sets env %q, which jobtree owns for distributed-training rendezvous (R9 9A-2) — remove it", field, spec.Containers[i].Name, e.Name)
 				}
 			}
 		}
 	}
 	target := r.GPUTargetContainerIndex()
 	if target < 0 || spec.Containers[target].Image == "" {
-		return fmt.Errorf("spec.roles[0].template: the GPU-target container (named %q, else the first) must set a non-empty image", GPUTargetContainerName)
+		return fmt.Errorf("%s.template: the GPU-target container (named %q, else the first) must set a non-empty image", field, GPUTargetContainerName)
 	}
 	if spec.NodeName != "" {
-		return fmt.Errorf("spec.roles[0].template.spec.nodeName is owned by jobtree and must not be set")
+		return fmt.Errorf("%s.template.spec.nodeName is owned by jobtree and must not be set", field)
 	}
 	if spec.SchedulerName != "" {
-		return fmt.Errorf("spec.roles[0].template.spec.schedulerName is owned by jobtree and must not be set")
+		return fmt.Errorf("%s.template.spec.schedulerName is owned by jobtree and must not be set", field)
 	}
 	if spec.RestartPolicy != "" {
-		return fmt.Errorf("spec.roles[0].template.spec.restartPolicy is owned by jobtree (forced to Never) and must not be set")
+		return fmt.Errorf("%s.template.spec.restartPolicy is owned by jobtree (forced to Never) and must not be set", field)
 	}
 	return nil
 }
@@ -549,6 +918,18 @@ func (f *RunFollow) Validate(selfName string) error {
 		}
 		seen[name] = struct{}{}
 	}
+	switch f.When {
+	case "", FollowWhenSucceeded:
+	case FollowWhenFailed, FollowWhenDone:
+		if f.MinSucceeded != nil {
+			return fmt.Errorf("follow.minSucceeded applies only to a %s follow", FollowWhenSucceeded)
+		}
+	default:
+		return fmt.Errorf("follow.when must be %q, %q, or %q when set", FollowWhenSucceeded, FollowWhenFailed, FollowWhenDone)
+	}
+	if f.MinSucceeded != nil && (*f.MinSucceeded < 1 || int(*f.MinSucceeded) > len(f.After)) {
+		return fmt.Errorf("follow.minSucceeded must be between 1 and the number of upstreams (%d)", len(f.After))
+	}
 	switch f.OnUpstreamFailure {
 	case "", OnUpstreamFailureWait, OnUpstreamFailureFail:
 	default:
@@ -69,6 +69,7 @@ func TestRunFollowValidation(t *testing.T) {
 	}
 
 	negGrace := metav1.Duration{Duration: -time.Hour}
+	one, three := int32(1), int32(3)
 	cases := []struct {
 		name    string
 		follow  *RunFollow
@@ -82,6 +83,11 @@ func TestRunFollowValidation(t *testing.T) {
 		{"duplicate", &RunFollow{After: []string{"a", "a"}}, true},
 		{"bad policy", &RunFollow{After: []string{"a"}, OnUpstreamFailure: "nuke"}, true},
 		{"negative grace", &RunFollow{After: []string{"a"}, UpstreamFailureGrace: &negGrace}, true},
+		{"failed branch", &RunFollow{After: []string{"a"}, When: FollowWhenFailed}, false},
+		{"bad when", &RunFollow{After: []string{"a"}, When: "Sometimes"}, true},
+		{"subset fan-in", &RunFollow{After: []string{"a", "b"}, MinSucceeded: &one}, false},
+		{"subset exceeds after", &RunFollow{After: []string{"a", "b"}, MinSucceeded: &three}, true},
+		{"subset on a done follow", &RunFollow{After: []string{"a", "b"}, When: FollowWhenDone, MinSucceeded: &one}, true},
 	}
 	for _, tc := range cases {
 		run := base()
@@ -149,7 +155,46 @@ func TestRunRoleValidation(t *testing.T) {
 			role.Template.Spec.Containers = []corev1.Container{{Name: "notworkload", Image: "img"}}
 			r.Spec.Roles = []RunRole{role}
 		}, false},
-		{"two roles rejected in v1", func(r *Run) { r.Spec.Roles = []RunRole{validRole(), validRole()} }, true},
+		{"two roles summing to totalGPUs", func(r *Run) {
+			actor := validRole()
+			actor.Name = "actor"
+			actor.Width, actor.GPUsPerPod = 4, 1
+			learner := validRole()
+			learner.Name = "learner"
+			learner.Width, learner.GPUsPerPod = 1, 4
+			r.Spec.Roles = []RunRole{actor, learner}
+		}, false},
+		{"two roles with one name", func(r *Run) {
+			a, b := validRole(), validRole()
+			a.Width, b.Width = 1, 1
+			r.Spec.Roles = []RunRole{a, b}
+		}, true},
+		{"two roles over totalGPUs", func(r *Run) {
+			a, b := validRole(), validRole()
+			b.Name = "grader"
+			r.Spec.Roles = []RunRole{a, b} // 8 + 8 != 8
+		}, true},
+		{"multi-role with spares", func(r *Run) {
+			a, b := validRole(), validRole()
+			a.Width, b.Width = 1, 1
+			b.Name = "grader"
+			b.Spares = &okSpares
+			r.Spec.Roles = []RunRole{a, b}
+		}, true},
+		{"multi-role malleable", func(r *Run) {
+			a, b := validRole(), validRole()
+			a.Width, b.Width = 1, 1
+			b.Name = "grader"
+			r.Spec.Roles = []RunRole{a, b}
+			r.Spec.Malleable = &RunMalleability{MinTotalGPUs: 4, MaxTotalGPUs: 8, StepGPUs: 4}
+		}, true},
+		{"second role invalid", func(r *Run) {
+			a, b := validRole(), validRole()
+			a.Width, b.Width = 1, 1
+			b.Name = "grader"
+			b.Template.Spec.Containers = nil
+			r.Spec.Roles = []RunRole{a, b}
+		}, true},
 		{"missing name", func(r *Run) {
 			role := validRole()
 			role.Name = ""
@@ -286,3 +331,39 @@ func TestValidateRejectsReservedRendezvousEnv(t *testing.T) {
 		t.Fatalf("the same run without the reserved env must validate, got %v", err)
 	}
 }
+
+func TestRunAlternativeFlavorValidation(t *testing.T) {
+	pct := func(v int32) *int32 { return &v }
+	run := &Run{Spec: RunSpec{Resources: RunResources{GPUType: "H100", TotalGPUs: 8,
+		Alternatives: []FlavorAlternative{{GPUType: "A100"}}}}}
+	if err := run.ValidateCreate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := run.Spec.Resources.Flavors(); len(got) != 2 || got[0] != "H100" || got[1] != "A100" {
+		t.Fatalf("flavors %v, want gpuType first then the alternatives", got)
+	}
+	if run.Flavor() != "H100" {
+		t.Fatalf("an unplaced run reads as its gpuType, got %q", run.Flavor())
+	}
+	run.Status.Flavor = "A100"
+	if run.Flavor() != "A100" {
+		t.Fatalf("a placed run reads as status.flavor, got %q", run.Flavor())
+	}
+
+	run.Spec.Resources.Alternatives = []FlavorAlternative{{GPUType: "H100"}}
+	if err := run.ValidateCreate(); err == nil {
+		t.Fatal("expected error for an alternative repeating gpuType")
+	}
+	run.Spec.Resources.Alternatives = []FlavorAlternative{{GPUType: "A100", WidthPercent: pct(200)}}
+	if err := run.ValidateCreate(); err == nil {
+		t.Fatal("expected error for widthPercent on a run that is not malleable")
+	}
+	run.Spec.Malleable = &RunMalleability{MinTotalGPUs: 8, MaxTotalGPUs: 16, StepGPUs: 8}
+	if err := run.ValidateCreate(); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	run.Spec.Resources.Alternatives[0].WidthPercent = pct(0)
+	if err := run.ValidateCreate(); err == nil {
+		t.Fatal("expected error for a zero widthPercent")
+	}
+}
@@ -38,12 +38,35 @@ func TestShippedSamplesAreValid(t *testing.T) {
 		if err != nil {
 			return err
 		}
-		// Only Budgets carry the rules this guards; other kinds are validated by
-		// their own paths and a decoding failure here would be a false alarm.
+		// Budgets carry the rules this guards, RunSweeps the matrix rules the
+		// expansion relies on, and Pipelines their stage graph; other kinds
+		// are validated by their own paths and a decoding failure here would
+		// be a false alarm.
 		var probe struct {
 			Kind string `json:"kind"`
 		}
-		if err := yaml.Unmarshal(raw, &probe); err != nil || probe.Kind != "Budget" {
+		if err := yaml.Unmarshal(raw, &probe); err != nil {
+			return nil
+		}
+		if probe.Kind == "RunSweep" {
+			var sweep RunSweep
+			if err := yaml.UnmarshalStrict(raw, &sweep); err != nil {
+				t.Errorf("%s: does not decode against the current API: %v", filepath.Base(path), err)
+			} else if err := sweep.Spec.Validate(); err != nil {
+				t.Errorf("%s: shipped sample is not a valid RunSweep: %v", filepath.Base(path), err)
+			}
+			return nil
+		}
+		if probe.Kind == "Pipeline" {
+			var p Pipeline
+			if err := yaml.UnmarshalStrict(raw, &p); err != nil {
+				t.Errorf("%s: does not decode against the current API: %v", filepath.Base(path), err)
+			} else if err := p.Spec.Validate(); err != nil {
+				t.Errorf("%s: shipped sample is not a valid Pipeline: %v", filepath.Base(path), err)
+			}
+			return nil
+		}
+		if probe.Kind != "Budget" {
 			return nil
 		}
 		found++
@@ -43,6 +43,11 @@ type QuotaSnapshotSpec struct {
 	Roots []string `json:"roots,omitempty"`
 	// Principals is the compiled graph.
 	Principals []SnapshotPrincipal `json:"principals,omitempty"`
+	// Transfers are the BudgetTransfers both parties accepted, clamped to the
+	// lending envelope's effective concurrency and window. funding.Evaluate
+	// classifies the recipient's width on the envelope Borrowed up to each one
+	// while its window is open.
+	Transfers []SnapshotTransfer `json:"transfers,omitempty"`
 }
 
 // Principal statuses. Quarantine attaches to a WRITE, never to a principal (§4),
@@ -130,6 +135,27 @@ type SnapshotEnvelope struct {
 	OverAllocatedUntil *metav1.Time `json:"overAllocatedUntil,omitempty"`
 }
 
+// SnapshotTransfer is one compiled, two-party BudgetTransfer.
+type SnapshotTransfer struct {
+	// Name is the transfer's name, shared by both parties' copies.
+	Name string `json:"name"`
+	// Lender is the principal bound to From.Namespace; Borrower the one bound
+	// to the recipient's namespace. Both are derived from where the copies were
+	// written, never from a writable field.
+	Lender   string `json:"lender"`
+	Borrower string `json:"borrower"`
+	// From is the lending envelope.
+	From   TransferSource `json:"from"`
+	Flavor string         `json:"flavor"`
+	// Concurrency is the EFFECTIVE concurrency: the agreed number clamped to
+	// the envelope's own effective concurrency.
+	Concurrency int32 `json:"concurrency"`
+	// Start and End are the EFFECTIVE window: the agreed window intersected
+	// with the envelope's.
+	Start metav1.Time `json:"start"`
+	End   metav1.Time `json:"end"`
+}
+
 // QuotaSnapshotStatus reports publication.
 type QuotaSnapshotStatus struct {
 	// +patchMergeKey=type
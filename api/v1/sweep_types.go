@@ -0,0 +1,204 @@
+package v1
+
+import (
+	"fmt"
+	"regexp"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// Sweep strategies. Grid is the default (empty string).
+const (
+	SweepStrategyGrid   = "Grid"
+	SweepStrategyRandom = "Random"
+)
+
+// Sweep phases. A sweep is Running until every trial it will ever run has
+// finished, or until its early-stop target is met.
+const (
+	SweepPhaseRunning  = "Running"
+	SweepPhaseComplete = "Completed"
+	SweepPhaseFailed   = "Failed"
+)
+
+// SweepChildStopped is the phase a sweep records for a trial it stopped early,
+// or whose Run was deleted out from under it. It is never a Run's own phase.
+const SweepChildStopped = "Stopped"
+
+// RunSweep expands one Run template into a family of child Runs, one per point of
+// a parameter matrix: the hyperparameter sweep a researcher would otherwise submit
+// as N near-identical manifests.
+//
+// Children are ordinary Runs in the sweep's namespace, owned by the sweep (so
+// deleting it deletes them, and their leases close through the Run finalizer).
+// They share the template's funding: the same derived owner, envelopes, and
+// sponsors, drawn by at most maxConcurrent children at a time.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=runsweeps,shortName=sweep
+// +kubebuilder:printcolumn:name="Trials",type=integer,JSONPath=`.status.trials`
+// +kubebuilder:printcolumn:name="Running",type=integer,JSONPath=`.status.running`
+// +kubebuilder:printcolumn:name="Succeeded",type=integer,JSONPath=`.status.succeeded`
+// +kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+// +kubebuilder:printcolumn:name="Winner",type=string,priority=1,JSONPath=`.status.winner`
+type RunSweep struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   RunSweepSpec   `json:"spec,omitempty"`
+	Status RunSweepStatus `json:"status,omitempty"`
+}
+
+// RunSweepSpec is a Run template and the matrix it is expanded over.
+//
+// A child is created once and never rewritten: editing the template or the
+// matrix affects only trials not yet started.
+//
+// +kubebuilder:validation:XValidation:rule="!has(self.strategy) || self.strategy != 'Random' || has(self.samples)",message="a Random sweep requires samples"
+type RunSweepSpec struct {
+	Template RunTemplate `json:"template"`
+	// Parameters are the matrix axes. A Grid sweep runs every combination, in
+	// order with the last parameter varying fastest.
+	// +kubebuilder:validation:MinItems=1
+	// +kubebuilder:validation:MaxItems=16
+	Parameters []SweepParameter `json:"parameters"`
+	// +kubebuilder:validation:Enum=Grid;Random
+	Strategy string `json:"strategy,omitempty"`
+	// Samples is how many distinct combinations a Random sweep draws from the
+	// grid. It must not exceed the grid's size.
+	// +kubebuilder:validation:Minimum=1
+	Samples *int32 `json:"samples,omitempty"`
+	// Seed makes a Random sweep's draw reproducible; empty seeds from the
+	// sweep's namespace and name. The draw is recomputed on every reconcile, so
+	// it must be a pure function of the spec.
+	Seed string `json:"seed,omitempty"`
+	// MaxConcurrent bounds how many children run at once; the next trial is
+	// created only when one finishes. Unset runs every trial at once.
+	// +kubebuilder:validation:Minimum=1
+	MaxConcurrent *int32 `json:"maxConcurrent,omitempty"`
+	// EarlyStop ends the sweep once enough children succeed: trials not yet
+	// started never are, and running children are deleted.
+	EarlyStop *RunSweepEarlyStop `json:"earlyStop,omitempty"`
+}
+
+// RunTemplate is the Run a sweep's children, or a pipeline's stages, are
+// stamped from.
+type RunTemplate struct {
+	// Labels and Annotations are copied onto every stamped Run, beside the
+	// owner's own bookkeeping labels.
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Spec        RunSpec           `json:"spec"`
+}
+
+// SweepParameter is one axis of the matrix. A child sees its value as an
+// environment variable in every container of every role.
+type SweepParameter struct {
+	// +kubebuilder:validation:Pattern=`^[A-Za-z][A-Za-z0-9_]*$`
+	Name string `json:"name"`
+	// Env names the environment variable; empty is SWEEP_ followed by the
+	// upper-cased name.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z_][A-Za-z0-9_]*$`
+	Env string `json:"env,omitempty"`
+	// +kubebuilder:validation:MinItems=1
+	Values []string `json:"values"`
+}
+
+// RunSweepEarlyStop is the success target that ends a sweep early.
+type RunSweepEarlyStop struct {
+	// Succeeded is how many children must complete; the first to do so is
+	// the sweep's winner.
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:default=1
+	Succeeded int32 `json:"succeeded,omitempty"`
+}
+
+// Target returns the number of successes that stop the sweep; unset is one.
+func (e *RunSweepEarlyStop) Target() int32 {
+	if e.Succeeded < 1 {
+		return 1
+	}
+	return e.Succeeded
+}
+
+// RunSweepStatus aggregates the children.
+type RunSweepStatus struct {
+	Phase   string `json:"phase,omitempty"`
+	Message string `json:"message,omitempty"`
+	// Trials is the number of combinations the sweep expands to.
+	Trials    int32 `json:"trials,omitempty"`
+	Created   int32 `json:"created,omitempty"`
+	Running   int32 `json:"running,omitempty"`
+	Succeeded int32 `json:"succeeded,omitempty"`
+	Failed    int32 `json:"failed,omitempty"`
+	Stopped   int32 `json:"stopped,omitempty"`
+	// Winner is the first child to complete.
+	Winner      string          `json:"winner,omitempty"`
+	CompletedAt *metav1.Time    `json:"completedAt,omitempty"`
+	Children    []RunSweepChild `json:"children,omitempty"`
+}
+
+// RunSweepChild is one created trial.
+type RunSweepChild struct {
+	Name       string            `json:"name"`
+	Index      int32             `json:"index"`
+	Parameters map[string]string `json:"parameters,omitempty"`
+	// Phase is the child Run's phase, or Stopped.
+	Phase string `json:"phase,omitempty"`
+}
+
+// RunSweepList contains a list of RunSweeps.
+// +kubebuilder:object:root=true
+type RunSweepList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []RunSweep `json:"items"`
+}
+
+var sweepParameterName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]*$`)
+
+// Validate checks the matrix the expansion relies on, and the template as the
+// Run webhook would. The apiserver enforces the matrix's shape with schema
+// markers; a sweep failing this is marked Failed, not expanded.
+func (s *RunSweepSpec) Validate() error {
+	if len(s.Parameters) == 0 {
+		return fmt.Errorf("spec.parameters must name at least one parameter")
+	}
+	seen := make(map[string]bool, len(s.Parameters))
+	for _, p := range s.Parameters {
+		if !sweepParameterName.MatchString(p.Name) {
+			return fmt.Errorf("parameter name %q must be an identifier", p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("parameter %q is declared twice", p.Name)
+		}
+		seen[p.Name] = true
+		if len(p.Values) == 0 {
+			return fmt.Errorf("parameter %q has no values", p.Name)
+		}
+	}
+	switch s.Strategy {
+	case "", SweepStrategyGrid:
+	case SweepStrategyRandom:
+		if s.Samples == nil || *s.Samples < 1 {
+			return fmt.Errorf("a Random sweep requires samples >= 1")
+		}
+	default:
+		return fmt.Errorf("strategy %q: want Grid or Random", s.Strategy)
+	}
+	if s.MaxConcurrent != nil && *s.MaxConcurrent < 1 {
+		return fmt.Errorf("maxConcurrent must be at least 1")
+	}
+	if s.EarlyStop != nil && s.EarlyStop.Succeeded < 0 {
+		return fmt.Errorf("earlyStop.succeeded must not be negative")
+	}
+	// Every child is the template plus env, so a template the Run webhook would
+	// refuse fails here once rather than at each child's create.
+	tmpl := Run{Spec: *s.Template.Spec.DeepCopy()}
+	tmpl.Default()
+	if err := tmpl.validate(); err != nil {
+		return fmt.Errorf("spec.template: %w", err)
+	}
+	return nil
+}
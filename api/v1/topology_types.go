@@ -0,0 +1,55 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// TopologyHierarchyName is the one TopologyHierarchy the engine reads.
+const TopologyHierarchyName = "default"
+
+// TopologyHierarchy declares the tiers of the fleet below a fast-fabric
+// domain — spine pods, racks, NVLink islands — each read from a node label.
+//
+// Placement packs every group into the innermost tier that can hold it, and a
+// Run's locality may require a group or the whole run to stay inside one unit
+// of a named tier. Without this object a fabric domain is flat.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:resource:path=topologyhierarchies,scope=Cluster,shortName=topo
+// +kubebuilder:validation:XValidation:rule="self.metadata.name == 'default'",message="the topology hierarchy is a singleton named default"
+type TopologyHierarchy struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec TopologyHierarchySpec `json:"spec,omitempty"`
+}
+
+// TopologyHierarchySpec lists the tiers, outermost first.
+type TopologyHierarchySpec struct {
+	// Levels nest: every unit of a level lies inside one unit of the level
+	// before it, e.g. spine, then rack, then nvlink.
+	// +kubebuilder:validation:MaxItems=8
+	// +listType=map
+	// +listMapKey=name
+	Levels []TopologyLevel `json:"levels,omitempty"`
+}
+
+// TopologyLevel is one tier of the hierarchy.
+type TopologyLevel struct {
+	// Name is how a Run's locality refers to the tier.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// NodeLabel is the node label whose value names a node's unit at this
+	// tier. A node without it is a unit of its own.
+	// +kubebuilder:validation:MinLength=1
+	NodeLabel string `json:"nodeLabel"`
+}
+
+// TopologyHierarchyList contains a list of TopologyHierarchies.
+// +kubebuilder:object:root=true
+type TopologyHierarchyList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []TopologyHierarchy `json:"items"`
+}
@@ -0,0 +1,144 @@
+package v1
+
+import (
+	"fmt"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// BudgetTransferSpec moves concurrency from one envelope to another principal
+// for a bounded window.
+//
+// It sits between the two tools that existed before it. Editing
+// BudgetEnvelope.Concurrency on two Budgets moves capacity for good and needs
+// write on budgets, which a lead deliberately does not hold (see Grant). A
+// LendingPolicy is standing and open-ended: it lends whatever the envelope is
+// not using to whoever it names, for as long as it is there. A transfer is one
+// agreed number, to one principal, that expires on its own.
+//
+// BOTH PARTIES MUST ACCEPT, and acceptance is a write, not a field. Each party
+// writes the same transfer — same name, same spec — in its OWN namespace: the
+// lender in from.namespace, the recipient in to.namespace. The producer derives
+// each party from the namespace a copy was written in, exactly as it derives a
+// grantor (INV-NO-SELF-GRANT), so "can you write here" is the consent check and
+// neither side can accept on the other's behalf. A copy whose counterpart is
+// missing or disagrees compiles to nothing and says so on its status.
+type BudgetTransferSpec struct {
+	// From names the lending envelope. Its namespace is the lender's.
+	From TransferSource `json:"from"`
+	// To names the receiving principal.
+	To TransferRecipient `json:"to"`
+	// Concurrency is how many of the envelope's GPUs (or MIG slices) the
+	// recipient's runs may hold on it at once, classified Borrowed.
+	// +kubebuilder:validation:Minimum=1
+	Concurrency int32 `json:"concurrency"`
+	// Start and End are required (INV-WINDOW-REQUIRED). At End the borrowed
+	// width stops being funded without anyone deleting anything.
+	// +kubebuilder:validation:Required
+	Start *metav1.Time `json:"start"`
+	// +kubebuilder:validation:Required
+	End *metav1.Time `json:"end"`
+}
+
+// TransferSource locates the envelope a transfer lends from.
+type TransferSource struct {
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+	// +kubebuilder:validation:MinLength=1
+	Budget string `json:"budget"`
+	// +kubebuilder:validation:MinLength=1
+	Envelope string `json:"envelope"`
+}
+
+// TransferRecipient names the principal a transfer lends to, by owner and by
+// the namespace it is bound in; the producer checks the two agree.
+type TransferRecipient struct {
+	// +kubebuilder:validation:MinLength=1
+	Owner string `json:"owner"`
+	// +kubebuilder:validation:MinLength=1
+	Namespace string `json:"namespace"`
+}
+
+// Accepted-condition reasons on a BudgetTransfer.
+const (
+	// TransferReasonCompiled: both parties wrote the transfer and it is in the
+	// published snapshot.
+	TransferReasonCompiled = "Compiled"
+	// TransferReasonAwaitingCounterparty: this party has accepted; the other
+	// has not written a matching copy yet.
+	TransferReasonAwaitingCounterparty = "AwaitingCounterparty"
+	// TransferReasonNotCompiled: the copy was refused; the message says why.
+	TransferReasonNotCompiled = "NotCompiled"
+)
+
+// BudgetTransferStatus reports how the producer compiled this copy.
+type BudgetTransferStatus struct {
+	// Conditions carry Accepted.
+	// +patchMergeKey=type
+	// +patchStrategy=merge
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty" patchStrategy:"merge" patchMergeKey:"type"`
+	// SnapshotVersion is the snapshot that last incorporated this transfer.
+	SnapshotVersion string `json:"snapshotVersion,omitempty"`
+}
+
+// BudgetTransfer is one party's acceptance of a time-boxed concurrency
+// transfer between two principals.
+//
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:resource:path=budgettransfers,scope=Namespaced,shortName=btr
+// +kubebuilder:validation:XValidation:rule="self.spec.end > self.spec.start",message="end must be after start"
+// +kubebuilder:printcolumn:name="From",type=string,JSONPath=`.spec.from.envelope`
+// +kubebuilder:printcolumn:name="To",type=string,JSONPath=`.spec.to.owner`
+// +kubebuilder:printcolumn:name="Concurrency",type=integer,JSONPath=`.spec.concurrency`
+// +kubebuilder:printcolumn:name="End",type=date,JSONPath=`.spec.end`
+// +kubebuilder:printcolumn:name="Status",type=string,JSONPath=`.status.conditions[?(@.type=="Accepted")].reason`
+type BudgetTransfer struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   BudgetTransferSpec   `json:"spec,omitempty"`
+	Status BudgetTransferStatus `json:"status,omitempty"`
+}
+
+// BudgetTransferList contains a list of BudgetTransfers.
+// +kubebuilder:object:root=true
+type BudgetTransferList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []BudgetTransfer `json:"items"`
+}
+
+// Validate checks the field-level rules. Whether the envelope and recipient
+// resolve, and whether the counterpart agrees, need the whole graph and belong
+// to the producer.
+func (t *BudgetTransferSpec) Validate() error {
+	if t.From.Namespace == "" || t.From.Budget == "" || t.From.Envelope == "" {
+		return fmt.Errorf("from.namespace, from.budget and from.envelope are required")
+	}
+	if t.To.Owner == "" || t.To.Namespace == "" {
+		return fmt.Errorf("to.owner and to.namespace are required")
+	}
+	if t.To.Namespace == t.From.Namespace {
+		return fmt.Errorf("to.namespace is the lender's own namespace: a principal cannot transfer to itself")
+	}
+	if t.Concurrency <= 0 {
+		return fmt.Errorf("concurrency must be positive")
+	}
+	if t.Start == nil || t.End == nil {
+		return fmt.Errorf("start and end are both required: a transfer that never expires is a LendingPolicy (INV-WINDOW-REQUIRED)")
+	}
+	if !t.End.Time.After(t.Start.Time) {
+		return fmt.Errorf("end must be after start")
+	}
+	return nil
+}
+
+// Terms reports whether two copies describe the same transfer; the producer
+// compiles a transfer only when the lender's and the recipient's agree.
+func (t *BudgetTransferSpec) Terms(o *BudgetTransferSpec) bool {
+	return t.From == o.From && t.To == o.To && t.Concurrency == o.Concurrency &&
+		t.Start.Equal(o.Start) && t.End.Equal(o.End)
+}
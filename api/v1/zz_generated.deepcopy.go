@@ -54,6 +54,52 @@ func (in *AggregateHeadroom) DeepCopy() *AggregateHeadroom {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchivedCharge) DeepCopyInto(out *ArchivedCharge) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchivedCharge.
+func (in *ArchivedCharge) DeepCopy() *ArchivedCharge {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchivedCharge)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchivedRoleHours) DeepCopyInto(out *ArchivedRoleHours) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchivedRoleHours.
+func (in *ArchivedRoleHours) DeepCopy() *ArchivedRoleHours {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchivedRoleHours)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ArchivedShare) DeepCopyInto(out *ArchivedShare) {
+	*out = *in
+	out.HalfLife = in.HalfLife
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ArchivedShare.
+func (in *ArchivedShare) DeepCopy() *ArchivedShare {
+	if in == nil {
+		return nil
+	}
+	out := new(ArchivedShare)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *AutoRenewSchedule) DeepCopyInto(out *AutoRenewSchedule) {
 	*out = *in
@@ -126,6 +172,11 @@ func (in *BudgetEnvelope) DeepCopyInto(out *BudgetEnvelope) {
 		*out = new(LendingPolicy)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.NextConcurrency != nil {
+		in, out := &in.NextConcurrency, &out.NextConcurrency
+		*out = new(int32)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetEnvelope.
@@ -197,6 +248,11 @@ func (in *BudgetSpec) DeepCopyInto(out *BudgetSpec) {
 		*out = new(AutoRenewSchedule)
 		**out = **in
 	}
+	if in.FairShare != nil {
+		in, out := &in.FairShare, &out.FairShare
+		*out = new(FairSharePolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetSpec.
@@ -247,6 +303,11 @@ func (in *BudgetStatus) DeepCopyInto(out *BudgetStatus) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.FairShare != nil {
+		in, out := &in.FairShare, &out.FairShare
+		*out = make([]FairShareUsage, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetStatus.
@@ -259,6 +320,112 @@ func (in *BudgetStatus) DeepCopy() *BudgetStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BudgetTransfer) DeepCopyInto(out *BudgetTransfer) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetTransfer.
+func (in *BudgetTransfer) DeepCopy() *BudgetTransfer {
+	if in == nil {
+		return nil
+	}
+	out := new(BudgetTransfer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BudgetTransfer) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BudgetTransferList) DeepCopyInto(out *BudgetTransferList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]BudgetTransfer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetTransferList.
+func (in *BudgetTransferList) DeepCopy() *BudgetTransferList {
+	if in == nil {
+		return nil
+	}
+	out := new(BudgetTransferList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *BudgetTransferList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BudgetTransferSpec) DeepCopyInto(out *BudgetTransferSpec) {
+	*out = *in
+	out.From = in.From
+	out.To = in.To
+	if in.Start != nil {
+		in, out := &in.Start, &out.Start
+		*out = (*in).DeepCopy()
+	}
+	if in.End != nil {
+		in, out := &in.End, &out.End
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetTransferSpec.
+func (in *BudgetTransferSpec) DeepCopy() *BudgetTransferSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BudgetTransferSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BudgetTransferStatus) DeepCopyInto(out *BudgetTransferStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BudgetTransferStatus.
+func (in *BudgetTransferStatus) DeepCopy() *BudgetTransferStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(BudgetTransferStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EnvelopeHeadroom) DeepCopyInto(out *EnvelopeHeadroom) {
 	*out = *in
@@ -305,6 +472,57 @@ func (in *EnvelopeUsage) DeepCopy() *EnvelopeUsage {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FairSharePolicy) DeepCopyInto(out *FairSharePolicy) {
+	*out = *in
+	out.HalfLife = in.HalfLife
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FairSharePolicy.
+func (in *FairSharePolicy) DeepCopy() *FairSharePolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(FairSharePolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FairShareUsage) DeepCopyInto(out *FairShareUsage) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FairShareUsage.
+func (in *FairShareUsage) DeepCopy() *FairShareUsage {
+	if in == nil {
+		return nil
+	}
+	out := new(FairShareUsage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *FlavorAlternative) DeepCopyInto(out *FlavorAlternative) {
+	*out = *in
+	if in.WidthPercent != nil {
+		in, out := &in.WidthPercent, &out.WidthPercent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new FlavorAlternative.
+func (in *FlavorAlternative) DeepCopy() *FlavorAlternative {
+	if in == nil {
+		return nil
+	}
+	out := new(FlavorAlternative)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *GPULease) DeepCopyInto(out *GPULease) {
 	*out = *in
@@ -619,6 +837,96 @@ func (in *IntendedSlice) DeepCopy() *IntendedSlice {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseArchive) DeepCopyInto(out *LeaseArchive) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseArchive.
+func (in *LeaseArchive) DeepCopy() *LeaseArchive {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseArchive)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeaseArchive) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseArchiveList) DeepCopyInto(out *LeaseArchiveList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]LeaseArchive, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseArchiveList.
+func (in *LeaseArchiveList) DeepCopy() *LeaseArchiveList {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseArchiveList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *LeaseArchiveList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LeaseArchiveSpec) DeepCopyInto(out *LeaseArchiveSpec) {
+	*out = *in
+	in.From.DeepCopyInto(&out.From)
+	in.Through.DeepCopyInto(&out.Through)
+	if in.Charges != nil {
+		in, out := &in.Charges, &out.Charges
+		*out = make([]ArchivedCharge, len(*in))
+		copy(*out, *in)
+	}
+	if in.RoleHours != nil {
+		in, out := &in.RoleHours, &out.RoleHours
+		*out = make([]ArchivedRoleHours, len(*in))
+		copy(*out, *in)
+	}
+	if in.FairShare != nil {
+		in, out := &in.FairShare, &out.FairShare
+		*out = make([]ArchivedShare, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LeaseArchiveSpec.
+func (in *LeaseArchiveSpec) DeepCopy() *LeaseArchiveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(LeaseArchiveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LendingPolicy) DeepCopyInto(out *LendingPolicy) {
 	*out = *in
@@ -660,22 +968,7 @@ func (in *NamespaceBinding) DeepCopy() *NamespaceBinding {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PreActivationPolicy) DeepCopyInto(out *PreActivationPolicy) {
-	*out = *in
-}
-
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreActivationPolicy.
-func (in *PreActivationPolicy) DeepCopy() *PreActivationPolicy {
-	if in == nil {
-		return nil
-	}
-	out := new(PreActivationPolicy)
-	in.DeepCopyInto(out)
-	return out
-}
-
-// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *QuotaSnapshot) DeepCopyInto(out *QuotaSnapshot) {
+func (in *Pipeline) DeepCopyInto(out *Pipeline) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
@@ -683,18 +976,18 @@ func (in *QuotaSnapshot) DeepCopyInto(out *QuotaSnapshot) {
 	in.Status.DeepCopyInto(&out.Status)
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSnapshot.
-func (in *QuotaSnapshot) DeepCopy() *QuotaSnapshot {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Pipeline.
+func (in *Pipeline) DeepCopy() *Pipeline {
 	if in == nil {
 		return nil
 	}
-	out := new(QuotaSnapshot)
+	out := new(Pipeline)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *QuotaSnapshot) DeepCopyObject() runtime.Object {
+func (in *Pipeline) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -702,31 +995,31 @@ func (in *QuotaSnapshot) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *QuotaSnapshotList) DeepCopyInto(out *QuotaSnapshotList) {
+func (in *PipelineList) DeepCopyInto(out *PipelineList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
-		*out = make([]QuotaSnapshot, len(*in))
+		*out = make([]Pipeline, len(*in))
 		for i := range *in {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSnapshotList.
-func (in *QuotaSnapshotList) DeepCopy() *QuotaSnapshotList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineList.
+func (in *PipelineList) DeepCopy() *PipelineList {
 	if in == nil {
 		return nil
 	}
-	out := new(QuotaSnapshotList)
+	out := new(PipelineList)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *QuotaSnapshotList) DeepCopyObject() runtime.Object {
+func (in *PipelineList) DeepCopyObject() runtime.Object {
 	if c := in.DeepCopy(); c != nil {
 		return c
 	}
@@ -734,12 +1027,168 @@ func (in *QuotaSnapshotList) DeepCopyObject() runtime.Object {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *QuotaSnapshotSpec) DeepCopyInto(out *QuotaSnapshotSpec) {
+func (in *PipelineSpec) DeepCopyInto(out *PipelineSpec) {
 	*out = *in
-	in.EffectiveFrom.DeepCopyInto(&out.EffectiveFrom)
-	if in.Roots != nil {
-		in, out := &in.Roots, &out.Roots
-		*out = make([]string, len(*in))
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]PipelineStage, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineSpec.
+func (in *PipelineSpec) DeepCopy() *PipelineSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStage) DeepCopyInto(out *PipelineStage) {
+	*out = *in
+	if in.Follow != nil {
+		in, out := &in.Follow, &out.Follow
+		*out = new(RunFollow)
+		(*in).DeepCopyInto(*out)
+	}
+	in.Template.DeepCopyInto(&out.Template)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStage.
+func (in *PipelineStage) DeepCopy() *PipelineStage {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStage)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStageStatus) DeepCopyInto(out *PipelineStageStatus) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStageStatus.
+func (in *PipelineStageStatus) DeepCopy() *PipelineStageStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStageStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PipelineStatus) DeepCopyInto(out *PipelineStatus) {
+	*out = *in
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Stages != nil {
+		in, out := &in.Stages, &out.Stages
+		*out = make([]PipelineStageStatus, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PipelineStatus.
+func (in *PipelineStatus) DeepCopy() *PipelineStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PipelineStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PreActivationPolicy) DeepCopyInto(out *PreActivationPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PreActivationPolicy.
+func (in *PreActivationPolicy) DeepCopy() *PreActivationPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PreActivationPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSnapshot) DeepCopyInto(out *QuotaSnapshot) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSnapshot.
+func (in *QuotaSnapshot) DeepCopy() *QuotaSnapshot {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSnapshot)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaSnapshot) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSnapshotList) DeepCopyInto(out *QuotaSnapshotList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]QuotaSnapshot, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSnapshotList.
+func (in *QuotaSnapshotList) DeepCopy() *QuotaSnapshotList {
+	if in == nil {
+		return nil
+	}
+	out := new(QuotaSnapshotList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *QuotaSnapshotList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *QuotaSnapshotSpec) DeepCopyInto(out *QuotaSnapshotSpec) {
+	*out = *in
+	in.EffectiveFrom.DeepCopyInto(&out.EffectiveFrom)
+	if in.Roots != nil {
+		in, out := &in.Roots, &out.Roots
+		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
 	if in.Principals != nil {
@@ -749,6 +1198,13 @@ func (in *QuotaSnapshotSpec) DeepCopyInto(out *QuotaSnapshotSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.Transfers != nil {
+		in, out := &in.Transfers, &out.Transfers
+		*out = make([]SnapshotTransfer, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new QuotaSnapshotSpec.
@@ -783,6 +1239,112 @@ func (in *QuotaSnapshotStatus) DeepCopy() *QuotaSnapshotStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemedyDirective) DeepCopyInto(out *RemedyDirective) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemedyDirective.
+func (in *RemedyDirective) DeepCopy() *RemedyDirective {
+	if in == nil {
+		return nil
+	}
+	out := new(RemedyDirective)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RemedyDirective) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemedyDirectiveList) DeepCopyInto(out *RemedyDirectiveList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RemedyDirective, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemedyDirectiveList.
+func (in *RemedyDirectiveList) DeepCopy() *RemedyDirectiveList {
+	if in == nil {
+		return nil
+	}
+	out := new(RemedyDirectiveList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RemedyDirectiveList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemedyDirectiveSpec) DeepCopyInto(out *RemedyDirectiveSpec) {
+	*out = *in
+	if in.Envelopes != nil {
+		in, out := &in.Envelopes, &out.Envelopes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Scope != nil {
+		in, out := &in.Scope, &out.Scope
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.IssuedAt.DeepCopyInto(&out.IssuedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemedyDirectiveSpec.
+func (in *RemedyDirectiveSpec) DeepCopy() *RemedyDirectiveSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RemedyDirectiveSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RemedyDirectiveStatus) DeepCopyInto(out *RemedyDirectiveStatus) {
+	*out = *in
+	if in.ExecutedAt != nil {
+		in, out := &in.ExecutedAt, &out.ExecutedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RemedyDirectiveStatus.
+func (in *RemedyDirectiveStatus) DeepCopy() *RemedyDirectiveStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RemedyDirectiveStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Reservation) DeepCopyInto(out *Reservation) {
 	*out = *in
@@ -810,6 +1372,26 @@ func (in *Reservation) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ReservationBooking) DeepCopyInto(out *ReservationBooking) {
+	*out = *in
+	in.DrainFrom.DeepCopyInto(&out.DrainFrom)
+	if in.Until != nil {
+		in, out := &in.Until, &out.Until
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationBooking.
+func (in *ReservationBooking) DeepCopy() *ReservationBooking {
+	if in == nil {
+		return nil
+	}
+	out := new(ReservationBooking)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ReservationForecast) DeepCopyInto(out *ReservationForecast) {
 	*out = *in
@@ -875,6 +1457,11 @@ func (in *ReservationSpec) DeepCopyInto(out *ReservationSpec) {
 	out.RunRef = in.RunRef
 	in.IntendedSlice.DeepCopyInto(&out.IntendedSlice)
 	in.EarliestStart.DeepCopyInto(&out.EarliestStart)
+	if in.Booking != nil {
+		in, out := &in.Booking, &out.Booking
+		*out = new(ReservationBooking)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ReservationSpec.
@@ -987,6 +1574,11 @@ func (in *RunFollow) DeepCopyInto(out *RunFollow) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.MinSucceeded != nil {
+		in, out := &in.MinSucceeded, &out.MinSucceeded
+		*out = new(int32)
+		**out = **in
+	}
 	if in.UpstreamFailureGrace != nil {
 		in, out := &in.UpstreamFailureGrace, &out.UpstreamFailureGrace
 		*out = new(metav1.Duration)
@@ -1045,30 +1637,108 @@ func (in *RunFundingLenderShare) DeepCopy() *RunFundingLenderShare {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunFundingStatus) DeepCopyInto(out *RunFundingStatus) {
+func (in *RunFundingRoleShare) DeepCopyInto(out *RunFundingRoleShare) {
 	*out = *in
-	if in.Lenders != nil {
-		in, out := &in.Lenders, &out.Lenders
-		*out = make([]RunFundingLenderShare, len(*in))
-		copy(*out, *in)
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunFundingStatus.
-func (in *RunFundingStatus) DeepCopy() *RunFundingStatus {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunFundingRoleShare.
+func (in *RunFundingRoleShare) DeepCopy() *RunFundingRoleShare {
 	if in == nil {
 		return nil
 	}
-	out := new(RunFundingStatus)
+	out := new(RunFundingRoleShare)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *RunList) DeepCopyInto(out *RunList) {
+func (in *RunFundingStatus) DeepCopyInto(out *RunFundingStatus) {
 	*out = *in
-	out.TypeMeta = in.TypeMeta
-	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Lenders != nil {
+		in, out := &in.Lenders, &out.Lenders
+		*out = make([]RunFundingLenderShare, len(*in))
+		copy(*out, *in)
+	}
+	if in.Roles != nil {
+		in, out := &in.Roles, &out.Roles
+		*out = make([]RunFundingRoleShare, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunFundingStatus.
+func (in *RunFundingStatus) DeepCopy() *RunFundingStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RunFundingStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunGoodputPoint) DeepCopyInto(out *RunGoodputPoint) {
+	*out = *in
+	in.ObservedAt.DeepCopyInto(&out.ObservedAt)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunGoodputPoint.
+func (in *RunGoodputPoint) DeepCopy() *RunGoodputPoint {
+	if in == nil {
+		return nil
+	}
+	out := new(RunGoodputPoint)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunGoodputPolicy) DeepCopyInto(out *RunGoodputPolicy) {
+	*out = *in
+	out.Cooldown = in.Cooldown
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunGoodputPolicy.
+func (in *RunGoodputPolicy) DeepCopy() *RunGoodputPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(RunGoodputPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunGoodputStatus) DeepCopyInto(out *RunGoodputStatus) {
+	*out = *in
+	if in.Curve != nil {
+		in, out := &in.Curve, &out.Curve
+		*out = make([]RunGoodputPoint, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.LastResize != nil {
+		in, out := &in.LastResize, &out.LastResize
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunGoodputStatus.
+func (in *RunGoodputStatus) DeepCopy() *RunGoodputStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RunGoodputStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunList) DeepCopyInto(out *RunList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
 	if in.Items != nil {
 		in, out := &in.Items, &out.Items
 		*out = make([]Run, len(*in))
@@ -1129,6 +1799,11 @@ func (in *RunMalleability) DeepCopyInto(out *RunMalleability) {
 		*out = new(int32)
 		**out = **in
 	}
+	if in.Goodput != nil {
+		in, out := &in.Goodput, &out.Goodput
+		*out = new(RunGoodputPolicy)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunMalleability.
@@ -1159,6 +1834,13 @@ func (in *RunReference) DeepCopy() *RunReference {
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunResources) DeepCopyInto(out *RunResources) {
 	*out = *in
+	if in.Alternatives != nil {
+		in, out := &in.Alternatives, &out.Alternatives
+		*out = make([]FlavorAlternative, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunResources.
@@ -1195,6 +1877,11 @@ func (in *RunRole) DeepCopyInto(out *RunRole) {
 		*out = new(metav1.Duration)
 		**out = **in
 	}
+	if in.AttemptTimeout != nil {
+		in, out := &in.AttemptTimeout, &out.AttemptTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunRole.
@@ -1211,6 +1898,16 @@ func (in *RunRole) DeepCopy() *RunRole {
 func (in *RunRuntime) DeepCopyInto(out *RunRuntime) {
 	*out = *in
 	out.Checkpoint = in.Checkpoint
+	if in.MaxDuration != nil {
+		in, out := &in.MaxDuration, &out.MaxDuration
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.WarnBefore != nil {
+		in, out := &in.WarnBefore, &out.WarnBefore
+		*out = new(metav1.Duration)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunRuntime.
@@ -1223,10 +1920,35 @@ func (in *RunRuntime) DeepCopy() *RunRuntime {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSchedule) DeepCopyInto(out *RunSchedule) {
+	*out = *in
+	in.StartAfter.DeepCopyInto(&out.StartAfter)
+	if in.Until != nil {
+		in, out := &in.Until, &out.Until
+		*out = (*in).DeepCopy()
+	}
+	if in.DrainLead != nil {
+		in, out := &in.DrainLead, &out.DrainLead
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSchedule.
+func (in *RunSchedule) DeepCopy() *RunSchedule {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSchedule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunSpec) DeepCopyInto(out *RunSpec) {
 	*out = *in
-	out.Resources = in.Resources
+	in.Resources.DeepCopyInto(&out.Resources)
 	if in.Roles != nil {
 		in, out := &in.Roles, &out.Roles
 		*out = make([]RunRole, len(*in))
@@ -1242,7 +1964,7 @@ func (in *RunSpec) DeepCopyInto(out *RunSpec) {
 	if in.Runtime != nil {
 		in, out := &in.Runtime, &out.Runtime
 		*out = new(RunRuntime)
-		**out = **in
+		(*in).DeepCopyInto(*out)
 	}
 	if in.Malleable != nil {
 		in, out := &in.Malleable, &out.Malleable
@@ -1264,6 +1986,11 @@ func (in *RunSpec) DeepCopyInto(out *RunSpec) {
 		*out = new(RunFollow)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Schedule != nil {
+		in, out := &in.Schedule, &out.Schedule
+		*out = new(RunSchedule)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSpec.
@@ -1335,18 +2062,37 @@ func (in *RunStatus) DeepCopyInto(out *RunStatus) {
 		*out = new(RunETA)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Goodput != nil {
+		in, out := &in.Goodput, &out.Goodput
+		*out = new(RunGoodputStatus)
+		(*in).DeepCopyInto(*out)
+	}
 	if in.FollowDeadline != nil {
 		in, out := &in.FollowDeadline, &out.FollowDeadline
 		*out = (*in).DeepCopy()
 	}
+	if in.Upstreams != nil {
+		in, out := &in.Upstreams, &out.Upstreams
+		*out = make([]RunUpstream, len(*in))
+		copy(*out, *in)
+	}
 	if in.CheckpointDeadline != nil {
 		in, out := &in.CheckpointDeadline, &out.CheckpointDeadline
 		*out = (*in).DeepCopy()
 	}
+	if in.DrainDeadline != nil {
+		in, out := &in.DrainDeadline, &out.DrainDeadline
+		*out = (*in).DeepCopy()
+	}
 	if in.RetryAfter != nil {
 		in, out := &in.RetryAfter, &out.RetryAfter
 		*out = (*in).DeepCopy()
 	}
+	if in.TimeLimit != nil {
+		in, out := &in.TimeLimit, &out.TimeLimit
+		*out = new(RunTimeLimitStatus)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunStatus.
@@ -1359,6 +2105,232 @@ func (in *RunStatus) DeepCopy() *RunStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSweep) DeepCopyInto(out *RunSweep) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSweep.
+func (in *RunSweep) DeepCopy() *RunSweep {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSweep)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RunSweep) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSweepChild) DeepCopyInto(out *RunSweepChild) {
+	*out = *in
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSweepChild.
+func (in *RunSweepChild) DeepCopy() *RunSweepChild {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSweepChild)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSweepEarlyStop) DeepCopyInto(out *RunSweepEarlyStop) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSweepEarlyStop.
+func (in *RunSweepEarlyStop) DeepCopy() *RunSweepEarlyStop {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSweepEarlyStop)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSweepList) DeepCopyInto(out *RunSweepList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]RunSweep, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSweepList.
+func (in *RunSweepList) DeepCopy() *RunSweepList {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSweepList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *RunSweepList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSweepSpec) DeepCopyInto(out *RunSweepSpec) {
+	*out = *in
+	in.Template.DeepCopyInto(&out.Template)
+	if in.Parameters != nil {
+		in, out := &in.Parameters, &out.Parameters
+		*out = make([]SweepParameter, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.Samples != nil {
+		in, out := &in.Samples, &out.Samples
+		*out = new(int32)
+		**out = **in
+	}
+	if in.MaxConcurrent != nil {
+		in, out := &in.MaxConcurrent, &out.MaxConcurrent
+		*out = new(int32)
+		**out = **in
+	}
+	if in.EarlyStop != nil {
+		in, out := &in.EarlyStop, &out.EarlyStop
+		*out = new(RunSweepEarlyStop)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSweepSpec.
+func (in *RunSweepSpec) DeepCopy() *RunSweepSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSweepSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunSweepStatus) DeepCopyInto(out *RunSweepStatus) {
+	*out = *in
+	if in.CompletedAt != nil {
+		in, out := &in.CompletedAt, &out.CompletedAt
+		*out = (*in).DeepCopy()
+	}
+	if in.Children != nil {
+		in, out := &in.Children, &out.Children
+		*out = make([]RunSweepChild, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunSweepStatus.
+func (in *RunSweepStatus) DeepCopy() *RunSweepStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RunSweepStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunTemplate) DeepCopyInto(out *RunTemplate) {
+	*out = *in
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunTemplate.
+func (in *RunTemplate) DeepCopy() *RunTemplate {
+	if in == nil {
+		return nil
+	}
+	out := new(RunTemplate)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunTimeLimitStatus) DeepCopyInto(out *RunTimeLimitStatus) {
+	*out = *in
+	in.Deadline.DeepCopyInto(&out.Deadline)
+	in.WarnAt.DeepCopyInto(&out.WarnAt)
+	if in.WarnedAt != nil {
+		in, out := &in.WarnedAt, &out.WarnedAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunTimeLimitStatus.
+func (in *RunTimeLimitStatus) DeepCopy() *RunTimeLimitStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(RunTimeLimitStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RunUpstream) DeepCopyInto(out *RunUpstream) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RunUpstream.
+func (in *RunUpstream) DeepCopy() *RunUpstream {
+	if in == nil {
+		return nil
+	}
+	out := new(RunUpstream)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *RunWidthStatus) DeepCopyInto(out *RunWidthStatus) {
 	*out = *in
@@ -1432,3 +2404,164 @@ func (in *SnapshotPrincipal) DeepCopy() *SnapshotPrincipal {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SnapshotTransfer) DeepCopyInto(out *SnapshotTransfer) {
+	*out = *in
+	out.From = in.From
+	in.Start.DeepCopyInto(&out.Start)
+	in.End.DeepCopyInto(&out.End)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SnapshotTransfer.
+func (in *SnapshotTransfer) DeepCopy() *SnapshotTransfer {
+	if in == nil {
+		return nil
+	}
+	out := new(SnapshotTransfer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SweepParameter) DeepCopyInto(out *SweepParameter) {
+	*out = *in
+	if in.Values != nil {
+		in, out := &in.Values, &out.Values
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SweepParameter.
+func (in *SweepParameter) DeepCopy() *SweepParameter {
+	if in == nil {
+		return nil
+	}
+	out := new(SweepParameter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyHierarchy) DeepCopyInto(out *TopologyHierarchy) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyHierarchy.
+func (in *TopologyHierarchy) DeepCopy() *TopologyHierarchy {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyHierarchy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TopologyHierarchy) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyHierarchyList) DeepCopyInto(out *TopologyHierarchyList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]TopologyHierarchy, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyHierarchyList.
+func (in *TopologyHierarchyList) DeepCopy() *TopologyHierarchyList {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyHierarchyList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *TopologyHierarchyList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyHierarchySpec) DeepCopyInto(out *TopologyHierarchySpec) {
+	*out = *in
+	if in.Levels != nil {
+		in, out := &in.Levels, &out.Levels
+		*out = make([]TopologyLevel, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyHierarchySpec.
+func (in *TopologyHierarchySpec) DeepCopy() *TopologyHierarchySpec {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyHierarchySpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TopologyLevel) DeepCopyInto(out *TopologyLevel) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TopologyLevel.
+func (in *TopologyLevel) DeepCopy() *TopologyLevel {
+	if in == nil {
+		return nil
+	}
+	out := new(TopologyLevel)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransferRecipient) DeepCopyInto(out *TransferRecipient) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransferRecipient.
+func (in *TransferRecipient) DeepCopy() *TransferRecipient {
+	if in == nil {
+		return nil
+	}
+	out := new(TransferRecipient)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *TransferSource) DeepCopyInto(out *TransferSource) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new TransferSource.
+func (in *TransferSource) DeepCopy() *TransferSource {
+	if in == nil {
+		return nil
+	}
+	out := new(TransferSource)
+	in.DeepCopyInto(out)
+	return out
+}
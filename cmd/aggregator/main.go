@@ -0,0 +1,182 @@
+// The jobtree aggregator: enforces aggregate budget caps across clusters (M10).
+//
+// Every member cluster bounds an AggregateCap by its own leases, so clusters
+// sharing one org budget can together hold several times the cap. Each pass the
+// aggregator lists the ledger (budgets, runs, GPU leases) from every member,
+// folds it through pkg/aggregator, and writes a RemedyDirective into each
+// cluster asked to free width. The cluster's manager executes it with its own
+// resolver; the aggregator never closes a lease or touches a run itself.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/clientcmd"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log/zap"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/aggregator"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+)
+
+var scheme = runtime.NewScheme()
+
+func init() {
+	if err := v1.AddToScheme(scheme); err != nil {
+		panic(err)
+	}
+}
+
+// member is one participating cluster.
+type member struct {
+	name   string
+	client client.Client
+	scope  map[string]string
+}
+
+// clusterFlags collects repeated --cluster name=kubeconfig arguments.
+type clusterFlags []string
+
+func (f *clusterFlags) String() string { return strings.Join(*f, ",") }
+
+func (f *clusterFlags) Set(value string) error {
+	if name, path, ok := strings.Cut(value, "="); !ok || name == "" || path == "" {
+		return fmt.Errorf("want name=kubeconfig, got %q", value)
+	}
+	*f = append(*f, value)
+	return nil
+}
+
+func main() {
+	var clusters clusterFlags
+	var interval time.Duration
+	var accountingPeriod time.Duration
+	var clusterLabel string
+	var enforce bool
+
+	flag.Var(&clusters, "cluster", "Member cluster as name=path/to/kubeconfig; repeat once per cluster")
+	flag.DurationVar(&interval, "interval", 30*time.Second, "How often the ledger is re-read from every member")
+	flag.DurationVar(&accountingPeriod, "accounting-period", funding.DefaultPeriod,
+		"Accounting horizon for each member's funding derivation; must match the managers'")
+	flag.StringVar(&clusterLabel, "cluster-label", "",
+		"Node label carrying the member's name; when set, each directive is scoped to the member's own nodes")
+	flag.BoolVar(&enforce, "enforce", false, "Write remedy directives; without it the aggregator only reports global usage")
+	opts := zap.Options{}
+	opts.BindFlags(flag.CommandLine)
+	flag.Parse()
+
+	ctrl.SetLogger(zap.New(zap.UseFlagOptions(&opts)))
+	log := ctrl.Log.WithName("aggregator")
+
+	if len(clusters) == 0 {
+		log.Error(nil, "no member clusters; pass --cluster name=kubeconfig at least once")
+		os.Exit(1)
+	}
+	var members []member
+	for _, arg := range clusters {
+		name, path, _ := strings.Cut(arg, "=")
+		cfg, err := clientcmd.BuildConfigFromFlags("", path)
+		if err != nil {
+			log.Error(err, "unable to load kubeconfig", "cluster", name)
+			os.Exit(1)
+		}
+		c, err := client.New(cfg, client.Options{Scheme: scheme})
+		if err != nil {
+			log.Error(err, "unable to create client", "cluster", name)
+			os.Exit(1)
+		}
+		m := member{name: name, client: c}
+		if clusterLabel != "" {
+			m.scope = map[string]string{clusterLabel: name}
+		}
+		members = append(members, m)
+	}
+
+	ctx := ctrl.SetupSignalHandler()
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	log.Info("starting aggregator", "clusters", len(members), "enforce", enforce)
+	for {
+		if err := pass(ctx, log, members, time.Now().UTC(), accountingPeriod, enforce); err != nil {
+			// A member that cannot be read leaves the global sum unknowable; the
+			// pass is skipped, not run on a partial ledger that would under-count.
+			// A directive that could not be written is re-issued next pass.
+			log.Error(err, "aggregation pass incomplete")
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// pass reads every member's ledger, evaluates the aggregate caps, and writes the
+// resulting directives when enforcing. A directive that already exists is the
+// same breach re-observed, and is left to the cluster already answering it. A
+// failed create does not stop the others: the breach's directives that landed
+// keep the cap outstanding, and the next pass re-issues the rest from them.
+func pass(ctx context.Context, log logr.Logger, members []member, now time.Time, period time.Duration, enforce bool) error {
+	views := make([]aggregator.ClusterView, 0, len(members))
+	byName := make(map[string]client.Client, len(members))
+	for _, m := range members {
+		view, err := ingest(ctx, m)
+		if err != nil {
+			return fmt.Errorf("cluster %s: %w", m.name, err)
+		}
+		views = append(views, view)
+		byName[m.name] = m.client
+	}
+	result := aggregator.Evaluate(aggregator.Input{Clusters: views, Now: now, Period: period})
+	var errs []error
+	for _, u := range result.Usage {
+		log.Info("aggregate cap usage", "budget", keys.NamespacedKey(u.Namespace, u.Budget), "cap", u.Cap,
+			"width", u.Width, "max", u.MaxConcurrency, "byCluster", u.ByCluster, "outstanding", u.Outstanding)
+	}
+	for i := range result.Directives {
+		d := &result.Directives[i]
+		log.Info("remedy directive", "cluster", d.Cluster, "name", d.Directive.Name, "cap", d.Directive.Spec.Cap,
+			"deficit", d.Directive.Spec.Deficit, "seed", d.Directive.Spec.Seed, "enforce", enforce)
+		if !enforce {
+			continue
+		}
+		if err := byName[d.Cluster].Create(ctx, &d.Directive); err != nil && !apierrors.IsAlreadyExists(err) {
+			errs = append(errs, fmt.Errorf("cluster %s: create directive %s: %w", d.Cluster, d.Directive.Name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func ingest(ctx context.Context, m member) (aggregator.ClusterView, error) {
+	led, err := ledger.List(ctx, m.client)
+	if err != nil {
+		return aggregator.ClusterView{}, err
+	}
+	var directives v1.RemedyDirectiveList
+	if err := m.client.List(ctx, &directives); err != nil {
+		return aggregator.ClusterView{}, fmt.Errorf("list remedy directives: %w", err)
+	}
+	return aggregator.ClusterView{
+		Name:       m.name,
+		Budgets:    led.Budgets,
+		Leases:     led.Leases,
+		Runs:       led.Runs,
+		Archives:   led.Archives,
+		Transfers:  led.Transfers,
+		Directives: directives.Items,
+		Scope:      m.scope,
+	}, nil
+}
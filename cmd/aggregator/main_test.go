@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// memberWithLeases is a cluster holding n funded 2-GPU runs under a shared cap of 4.
+func memberWithLeases(name string, n int, now time.Time) member {
+	start, end := v1.NewTime(now.Add(-24*time.Hour)), v1.NewTime(now.Add(24*time.Hour))
+	maxConcurrency := int32(4)
+	objs := []client.Object{&v1.Budget{
+		ObjectMeta: v1.ObjectMeta{Name: "org", Namespace: "default"},
+		Spec: v1.BudgetSpec{
+			Owner:         "org:ai",
+			Envelopes:     []v1.BudgetEnvelope{{Name: "h100", Flavor: "H100", Concurrency: 16, Start: &start, End: &end}},
+			AggregateCaps: []v1.AggregateCap{{Name: "global", Flavor: "H100", Envelopes: []string{"h100"}, MaxConcurrency: &maxConcurrency}},
+		},
+	}}
+	for i := 0; i < n; i++ {
+		run := fmt.Sprintf("run-%d", i)
+		objs = append(objs,
+			&v1.Run{
+				ObjectMeta: v1.ObjectMeta{Name: run, Namespace: "default", CreationTimestamp: v1.NewTime(now.Add(-time.Hour))},
+				Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "H100", TotalGPUs: 2}},
+			},
+			&v1.GPULease{
+				ObjectMeta: v1.ObjectMeta{Name: run + "-g0", Namespace: "default",
+					Labels: map[string]string{binder.LabelRunName: run, binder.LabelGroupIndex: "0"}},
+				Spec: v1.GPULeaseSpec{
+					Owner:                 "org:ai",
+					RunRef:                v1.RunReference{Name: run, Namespace: "default"},
+					Slice:                 v1.GPULeaseSlice{Nodes: []string{fmt.Sprintf("node-%d#0", i), fmt.Sprintf("node-%d#1", i)}, Role: binder.RoleActive},
+					Interval:              v1.GPULeaseInterval{Start: v1.NewTime(now.Add(-time.Minute))},
+					PaidByBudgetNamespace: "default", PaidByBudget: "org", PaidByEnvelope: "h100",
+					Reason: "Start",
+				},
+			})
+	}
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).WithStatusSubresource(&v1.RemedyDirective{}).Build()
+	return member{name: name, client: c}
+}
+
+func directivesIn(t *testing.T, m member) []v1.RemedyDirective {
+	t.Helper()
+	var list v1.RemedyDirectiveList
+	if err := m.client.List(context.Background(), &list); err != nil {
+		t.Fatalf("list directives in %s: %v", m.name, err)
+	}
+	return list.Items
+}
+
+// Two clusters each at the cap hold twice it: an enforcing pass writes one
+// directive into each, and the next pass — with them still unanswered — writes
+// nothing more. Without --enforce the same breach is only reported.
+func TestPassWritesOneDirectivePerClusterAndWaitsForTheAnswer(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	members := []member{memberWithLeases("a", 2, now), memberWithLeases("b", 2, now)}
+
+	if err := pass(context.Background(), logr.Discard(), members, now, 0, false); err != nil {
+		t.Fatalf("report-only pass: %v", err)
+	}
+	if got := directivesIn(t, members[0]); len(got) != 0 {
+		t.Fatalf("a report-only pass wrote %d directive(s)", len(got))
+	}
+
+	if err := pass(context.Background(), logr.Discard(), members, now, 0, true); err != nil {
+		t.Fatalf("enforcing pass: %v", err)
+	}
+	for _, m := range members {
+		got := directivesIn(t, m)
+		if len(got) != 1 || got[0].Spec.Deficit != 2 || got[0].Spec.Cap != "global" {
+			t.Fatalf("%s holds %+v, want one directive for 2 GPUs under cap global", m.name, got)
+		}
+	}
+
+	if err := pass(context.Background(), logr.Discard(), members, now.Add(time.Minute), 0, true); err != nil {
+		t.Fatalf("second pass: %v", err)
+	}
+	for _, m := range members {
+		if got := directivesIn(t, m); len(got) != 1 {
+			t.Errorf("%s holds %d directive(s) after a pass with the first unanswered, want 1", m.name, len(got))
+		}
+	}
+}
+
+// A create that fails leaves the breach half-written. The pass reports it but
+// still writes the other cluster's directive, and the next pass writes the
+// missing one under the same name and share rather than directing a new breach.
+func TestPassCompletesAPartlyWrittenBreach(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	members := []member{memberWithLeases("a", 2, now), memberWithLeases("b", 2, now)}
+	failing := true
+	members[0].client = interceptor.NewClient(members[0].client.(client.WithWatch), interceptor.Funcs{
+		Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+			if _, ok := obj.(*v1.RemedyDirective); ok && failing {
+				return fmt.Errorf("apiserver unavailable")
+			}
+			return c.Create(ctx, obj, opts...)
+		},
+	})
+
+	if err := pass(context.Background(), logr.Discard(), members, now, 0, true); err == nil {
+		t.Fatal("a failed create must be reported")
+	}
+	if got := directivesIn(t, members[0]); len(got) != 0 {
+		t.Fatalf("a holds %d directive(s) after its create failed", len(got))
+	}
+	written := directivesIn(t, members[1])
+	if len(written) != 1 {
+		t.Fatalf("b holds %d directive(s), want its share written despite a's failure", len(written))
+	}
+
+	failing = false
+	if err := pass(context.Background(), logr.Discard(), members, now.Add(time.Minute), 0, true); err != nil {
+		t.Fatalf("second pass: %v", err)
+	}
+	got := directivesIn(t, members[0])
+	if len(got) != 1 || got[0].Name != written[0].Name || got[0].Spec.Deficit != 2 {
+		t.Fatalf("a holds %+v, want the breach's own directive %s for 2 GPUs", got, written[0].Name)
+	}
+	if got := directivesIn(t, members[1]); len(got) != 1 {
+		t.Errorf("b holds %d directive(s), want still 1", len(got))
+	}
+}
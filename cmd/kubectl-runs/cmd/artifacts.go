@@ -7,6 +7,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/artifacts"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
 	"github.com/spf13/cobra"
 )
@@ -18,7 +19,7 @@ import (
 // that is. Anchoring on ONE conventional path is what lets `runs artifacts`
 // answer "where do my outputs go?" without the run declaring anything new: the
 // answer is read back out of the pod template the researcher already wrote.
-const ArtifactsMountPath = "/artifacts"
+const ArtifactsMountPath = artifacts.MountPath
 
 // R23: close the loop from a Run to where its outputs land. jobtree schedules and
 // funds GPUs; it does not move bytes. So rather than build a storage system, this
@@ -2,11 +2,15 @@ package cmd
 
 import (
 	"fmt"
+	"sort"
 	"time"
 
+	"k8s.io/apimachinery/pkg/api/meta"
+
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/controllers"
 	"github.com/davidlangworthy/jobtree/pkg/funding"
+	quotasnapshot "github.com/davidlangworthy/jobtree/pkg/snapshot"
 	"github.com/spf13/cobra"
 )
 
@@ -17,6 +21,7 @@ func NewBudgetsCommand(opts *RootOptions, store *StateStore, printer *Printer) *
 		Short: "Inspect budget usage and headroom",
 	}
 	cmd.AddCommand(newBudgetsUsageCommand(opts, store, printer))
+	cmd.AddCommand(newBudgetsTransfersCommand(opts, store, printer))
 	return cmd
 }
 
@@ -48,10 +53,12 @@ func budgetsUsageLocal(cmd *cobra.Command, opts *RootOptions, store *StateStore,
 	// One evaluation for the whole state: classification is global
 	// (family sharing and lending cross budget boundaries).
 	ev := funding.Evaluate(funding.Input{
-		Budgets: state.Budgets,
-		Leases:  state.Leases,
-		Runs:    state.Runs,
-		Now:     now,
+		Budgets:   state.Budgets,
+		Leases:    state.Leases,
+		Runs:      state.Runs,
+		Now:       now,
+		Archives:  state.Archives,
+		Transfers: state.Transfers,
 	})
 	for i := range state.Budgets {
 		budgetObj := state.Budgets[i]
@@ -139,3 +146,115 @@ func findHeadroom(heads []v1.EnvelopeHeadroom, name string) v1.EnvelopeHeadroom
 	}
 	return v1.EnvelopeHeadroom{}
 }
+
+func newBudgetsTransfersCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "transfers",
+		Short: "List BudgetTransfers: who lends how much to whom, until when, and whether both parties accepted (read-only)",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if opts.UseLocal() {
+				return budgetsTransfersLocal(cmd, opts, store, printer)
+			}
+			return budgetsTransfersLive(cmd, opts, printer)
+		},
+	}
+	return cmd
+}
+
+var transferHeaders = []string{"Name", "From", "To", "Concurrency", "Start", "End", "State"}
+
+// budgetsTransfersLocal lists the state file's compiled transfers. The local
+// simulator has no producer, so there is no pending half to show: a transfer
+// in the state file is one both parties already accepted.
+func budgetsTransfersLocal(cmd *cobra.Command, opts *RootOptions, store *StateStore, printer *Printer) error {
+	state, err := store.Load(opts.StatePath)
+	if err != nil {
+		return err
+	}
+	now := time.Now().UTC()
+	transfers := append([]v1.SnapshotTransfer(nil), state.Transfers...)
+	sort.Slice(transfers, func(i, j int) bool { return transfers[i].Name < transfers[j].Name })
+	rows := [][]string{}
+	raw := make([]map[string]interface{}, 0, len(transfers))
+	for _, t := range transfers {
+		row, r := transferRow(t.Name, t.From, t.Borrower, t.Concurrency, t.Start, t.End, transferWindowState(t.Start.Time, t.End.Time, now))
+		rows = append(rows, row)
+		raw = append(raw, r)
+	}
+	return printer.Print(cmd, opts, Payload{Headers: transferHeaders, Rows: rows, Raw: raw, Title: "Budget Transfers"})
+}
+
+// budgetsTransfersLive lists the namespace's BudgetTransfer copies — the ones
+// it wrote, whether as lender or recipient — with the producer's verdict. A
+// compiled transfer shows its EFFECTIVE concurrency and window from the
+// published snapshot, which may be narrower than the agreed ones when the
+// lending envelope holds less.
+func budgetsTransfersLive(cmd *cobra.Command, opts *RootOptions, printer *Printer) error {
+	c, err := opts.LiveClient()
+	if err != nil {
+		return err
+	}
+	copies, err := liveListTransfers(cmd.Context(), c, opts.Namespace)
+	if err != nil {
+		return err
+	}
+	var snapshots v1.QuotaSnapshotList
+	if err := c.List(cmd.Context(), &snapshots); err != nil {
+		return fmt.Errorf("list quota snapshots: %w", err)
+	}
+	compiled := map[v1.TransferSource]map[string]v1.SnapshotTransfer{}
+	for _, t := range quotasnapshot.PublishedTransfers(snapshots.Items) {
+		if compiled[t.From] == nil {
+			compiled[t.From] = map[string]v1.SnapshotTransfer{}
+		}
+		compiled[t.From][t.Name] = t
+	}
+	now := time.Now().UTC()
+	rows := [][]string{}
+	raw := make([]map[string]interface{}, 0, len(copies))
+	for i := range copies {
+		bt := &copies[i]
+		concurrency, start, end := bt.Spec.Concurrency, *bt.Spec.Start, *bt.Spec.End
+		state := "Unknown"
+		if cond := meta.FindStatusCondition(bt.Status.Conditions, "Accepted"); cond != nil {
+			state = cond.Reason
+		}
+		if t, ok := compiled[bt.Spec.From][bt.Name]; ok {
+			concurrency, start, end = t.Concurrency, t.Start, t.End
+			state = transferWindowState(start.Time, end.Time, now)
+		}
+		row, r := transferRow(bt.Name, bt.Spec.From, bt.Spec.To.Owner, concurrency, start, end, state)
+		r["namespace"] = bt.Namespace
+		rows = append(rows, row)
+		raw = append(raw, r)
+	}
+	return printer.Print(cmd, opts, Payload{Headers: transferHeaders, Rows: rows, Raw: raw, Title: "Budget Transfers"})
+}
+
+func transferRow(name string, from v1.TransferSource, to string, concurrency int32, start, end v1.Time, state string) ([]string, map[string]interface{}) {
+	source := from.Namespace + "/" + from.Budget + "/" + from.Envelope
+	row := []string{name, source, to, fmt.Sprintf("%d", concurrency), start.UTC().Format(time.RFC3339), end.UTC().Format(time.RFC3339), state}
+	return row, map[string]interface{}{
+		"name":        name,
+		"from":        source,
+		"to":          to,
+		"concurrency": concurrency,
+		"start":       start.UTC().Format(time.RFC3339),
+		"end":         end.UTC().Format(time.RFC3339),
+		"state":       state,
+	}
+}
+
+// transferWindowState places a compiled transfer on the clock: Scheduled
+// before its window, Active inside it, Expired after — at which point funding
+// has already stopped classifying the borrower's width Borrowed.
+func transferWindowState(start, end, now time.Time) string {
+	switch {
+	case now.Before(start):
+		return "Scheduled"
+	case now.Before(end):
+		return "Active"
+	default:
+		return "Expired"
+	}
+}
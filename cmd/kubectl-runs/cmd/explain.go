@@ -12,6 +12,7 @@ import (
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/controllers"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/resolver"
 	"github.com/spf13/cobra"
 )
 
@@ -121,7 +122,21 @@ func buildExplainPayload(state *controllers.ClusterState, run *v1.Run) Payload {
 		rows = append(rows, []string{"Reason", reason})
 	}
 	if run.Spec.Follow != nil && len(run.Spec.Follow.After) > 0 {
-		rows = append(rows, []string{"Follows", strings.Join(run.Spec.Follow.After, ", ")})
+		follows := strings.Join(run.Spec.Follow.After, ", ")
+		if f := run.Spec.Follow; f.MinSucceeded != nil && int(*f.MinSucceeded) < len(f.After) {
+			follows += fmt.Sprintf(" (any %d)", *f.MinSucceeded)
+		}
+		if when := run.Spec.Follow.When; when != "" && when != v1.FollowWhenSucceeded {
+			follows += " (when " + when + ")"
+		}
+		rows = append(rows, []string{"Follows", follows})
+	}
+	for _, up := range run.Status.Upstreams {
+		row := up.Name + " (" + up.Phase + ")"
+		if up.Artifacts != "" {
+			row += " artifacts " + up.Artifacts
+		}
+		rows = append(rows, []string{"Upstream", row})
 	}
 	if run.Status.Width != nil {
 		rows = append(rows,
@@ -149,6 +164,16 @@ func buildExplainPayload(state *controllers.ClusterState, run *v1.Run) Payload {
 			rows = append(rows, []string{"Lender", fmt.Sprintf("%s (%d GPUs)", lender.Owner, lender.GPUs)})
 		}
 	}
+	// Priority is a request the funding envelopes may grant only in part, so
+	// show both, and what the grant buys in the preemption lottery.
+	var authorized int32
+	if run.Status.Funding != nil {
+		authorized = run.Status.Funding.Priority
+	}
+	if run.Spec.Priority > 0 || authorized > 0 {
+		rows = append(rows, []string{"Priority", fmt.Sprintf("requested %d, authorized %d (lottery weight %d/%d)",
+			run.Spec.Priority, authorized, resolver.LotteryWeight(authorized), resolver.LotteryWeight(0))})
+	}
 	if run.Status.PendingReservation != nil {
 		resKey := keys.NamespacedKey(run.Namespace, *run.Status.PendingReservation)
 		if reservation := state.Reservations[resKey]; reservation != nil {
@@ -16,7 +16,7 @@ func reconcileRun(state *controllers.ClusterState, namespace, name string) error
 	if err := controller.Reconcile(namespace, name); err != nil {
 		return err
 	}
-	simulatePluginCommit(state)
+	simulatePluginCommit(state, time.Now().UTC())
 	// Re-reconcile so the adoption path flips the just-committed run Running.
 	return controller.Reconcile(namespace, name)
 }
@@ -39,7 +39,7 @@ func reconcileAll(state *controllers.ClusterState) error {
 			return err
 		}
 	}
-	simulatePluginCommit(state)
+	simulatePluginCommit(state, time.Now().UTC())
 	for _, key := range runKeys {
 		run := state.Runs[key]
 		if run == nil {
@@ -59,9 +59,17 @@ func reconcileAll(state *controllers.ClusterState) error {
 // admission.Plan would produce, so the demo shows the realistic bound state. A
 // run that cannot be admitted now (admission.Plan errors → it reserves) is left
 // Pending. This never runs on the live path; there the plugin is authoritative.
-func simulatePluginCommit(state *controllers.ClusterState) {
-	now := time.Now().UTC()
-	for _, run := range state.Runs {
+// now is the commit instant: the wall clock, or `simulate`'s virtual one.
+func simulatePluginCommit(state *controllers.ClusterState, now time.Time) {
+	// Sorted, as in reconcileAll: when two pending runs want the same GPUs,
+	// the commit order decides, and a replay must decide it the same way.
+	runKeys := make([]string, 0, len(state.Runs))
+	for key := range state.Runs {
+		runKeys = append(runKeys, key)
+	}
+	sort.Strings(runKeys)
+	for _, key := range runKeys {
+		run := state.Runs[key]
 		if run == nil || run.Status.Phase != controllers.RunPhasePending {
 			continue
 		}
@@ -69,12 +77,15 @@ func simulatePluginCommit(state *controllers.ClusterState) {
 			continue
 		}
 		res, err := admission.Plan(admission.Input{
-			Run:     run,
-			Budgets: state.Budgets,
-			Runs:    state.Runs,
-			Leases:  state.Leases,
-			Nodes:   state.Nodes,
-			Now:     now,
+			Run:       run,
+			Budgets:   state.Budgets,
+			Runs:      state.Runs,
+			Leases:    state.Leases,
+			Archives:  state.Archives,
+			Transfers: state.Transfers,
+			Nodes:     state.Nodes,
+			Topology:  state.Topology,
+			Now:       now,
 		})
 		if err != nil {
 			continue // not admittable now; the controller reserves it
@@ -11,14 +11,45 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// NewLeasesCommand lists leases associated with a Run.
+// NewLeasesCommand lists leases associated with a Run, or queries the lease
+// ledger across runs.
 func NewLeasesCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	var q ledgerQuery
+	var allNamespaces bool
+	var since, until string
 	cmd := &cobra.Command{
-		Use:   "leases RUN",
-		Short: "Show active and historical leases for a Run",
-		Args:  cobra.ExactArgs(1),
+		Use:   "leases [RUN]",
+		Short: "Show a Run's leases, or query the lease ledger across runs",
+		Long: `leases RUN lists one run's active and historical leases.
+
+With a filter, or without RUN, it queries the ledger instead (sacct for
+jobtree): every lease in the namespace, or in every namespace with
+--all-namespaces, that accrued GPU-hours in [--since, --until), narrowed by
+RUN (namespace/name with --all-namespaces), --owner (an owner and those
+beneath it), --envelope, --principal (the namespace whose Budget paid),
+--reason (the closure reason) and --class (the funding class). Each lease's
+hours are classified by the same replay report chargeback uses, so a lease
+recalled mid-period shows the hours of both classes. --by
+run,day,class,owner,envelope totals the hours by any of those instead of
+listing leases.
+
+--since and --until take RFC 3339, YYYY-MM-DD (UTC), or an age such as 36h or
+7d; --since defaults to the ledger's first lease, --until to now. Leases folded
+into a LeaseArchive no longer exist one by one, so --since defaults to no
+earlier than the archive horizon and a --since before it is refused; report
+chargeback counts the archived hours by run.`,
+		Args: cobra.MaximumNArgs(1),
 		RunE: func(cmd *cobra.Command, args []string) error {
-			name := args[0]
+			if len(args) == 1 {
+				q.Run = args[0]
+			}
+			if q.Run == "" || ledgerFlagsChanged(cmd) {
+				if !allNamespaces {
+					q.Namespace = opts.Namespace
+				}
+				return queryLedger(cmd, opts, store, printer, q, since, until)
+			}
+			name := q.Run
 			var leases []v1.GPULease
 			if opts.UseLocal() {
 				state, err := store.Load(opts.StatePath)
@@ -87,6 +118,15 @@ func NewLeasesCommand(opts *RootOptions, store *StateStore, printer *Printer) *c
 			return printer.Print(cmd, opts, payload)
 		},
 	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "Query leases in every namespace")
+	cmd.Flags().StringVar(&q.Owner, "owner", "", "Only leases of this owner or an owner beneath it")
+	cmd.Flags().StringVar(&q.Envelope, "envelope", "", "Only leases paid by this envelope")
+	cmd.Flags().StringVar(&q.Principal, "principal", "", "Only leases paid by a Budget in this namespace, the funding principal")
+	cmd.Flags().StringVar(&q.Reason, "reason", "", "Only leases closed for this reason (open for leases still open)")
+	cmd.Flags().StringVar(&q.Class, "class", "", "Only hours of this funding class (Owned, Shared, Borrowed, Unfunded)")
+	cmd.Flags().StringVar(&since, "since", "", "Start of the period, inclusive (RFC 3339, YYYY-MM-DD, or an age like 7d)")
+	cmd.Flags().StringVar(&until, "until", "", "End of the period, exclusive (default now)")
+	cmd.Flags().StringSliceVar(&q.By, "by", nil, "Total GPU-hours by run, day, class, owner, and/or envelope")
 	return cmd
 }
 
@@ -0,0 +1,356 @@
+package cmd
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/spf13/cobra"
+)
+
+// ledgerQuery selects leases across runs and, with By, how to total them.
+// Empty fields match everything.
+type ledgerQuery struct {
+	Namespace string
+	Run       string
+	Owner     string
+	Envelope  string
+	Principal string
+	Reason    string
+	Class     string
+	By        []string
+}
+
+// ledgerDimensions are the columns --by can total GPU-hours by.
+var ledgerDimensions = []string{"run", "day", "class", "owner", "envelope"}
+
+// ledgerReasonOpen is the --reason that selects leases still open.
+const ledgerReasonOpen = "open"
+
+// ledgerLease is one lease of a ledger listing as --output json carries it.
+type ledgerLease struct {
+	Lease     string                    `json:"lease"`
+	Run       string                    `json:"run"`
+	Owner     string                    `json:"owner"`
+	Envelope  string                    `json:"envelope"`
+	Principal string                    `json:"principal,omitempty"`
+	Role      string                    `json:"role"`
+	GPUs      int                       `json:"gpus"`
+	Start     time.Time                 `json:"start"`
+	End       *time.Time                `json:"end,omitempty"`
+	Closure   string                    `json:"closure,omitempty"`
+	Hours     map[funding.Class]float64 `json:"gpuHours"`
+}
+
+// ledgerTotal is one --by group as --output json carries it.
+type ledgerTotal struct {
+	Run      string  `json:"run,omitempty"`
+	Day      string  `json:"day,omitempty"`
+	Class    string  `json:"class,omitempty"`
+	Owner    string  `json:"owner,omitempty"`
+	Envelope string  `json:"envelope,omitempty"`
+	Leases   int     `json:"leases"`
+	GPUHours float64 `json:"gpuHours"`
+}
+
+// ledgerFlagsChanged reports whether any ledger filter was given, which turns
+// `leases RUN` into a query scoped to that run.
+func ledgerFlagsChanged(cmd *cobra.Command) bool {
+	for _, name := range []string{"all-namespaces", "owner", "envelope", "principal", "reason", "class", "since", "until", "by"} {
+		if cmd.Flags().Changed(name) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryLedger reads the whole ledger, classifies each lease's hours in the
+// period, and prints the leases or totals the query selects.
+func queryLedger(cmd *cobra.Command, opts *RootOptions, store *StateStore, printer *Printer, q ledgerQuery, since, until string) error {
+	for _, dim := range q.By {
+		if !slices.Contains(ledgerDimensions, dim) {
+			return fmt.Errorf("--by %q: want one of %s", dim, strings.Join(ledgerDimensions, ", "))
+		}
+	}
+	now := time.Now().UTC()
+	end := now
+	if until != "" {
+		var err error
+		if end, err = parseLedgerInstant(until, now); err != nil {
+			return fmt.Errorf("--until: %w", err)
+		}
+	}
+	in, err := chargebackLedger(cmd, opts, store)
+	if err != nil {
+		return err
+	}
+	start := end
+	if since != "" {
+		if start, err = parseLedgerInstant(since, now); err != nil {
+			return fmt.Errorf("--since: %w", err)
+		}
+	} else {
+		for i := range in.Leases {
+			if t := in.Leases[i].Spec.Interval.Start.Time; t.Before(start) {
+				start = t
+			}
+		}
+		// Hours before the archive horizon have no per-lease detail left.
+		if horizon := funding.ArchiveHorizon(in.Archives); start.Before(horizon) {
+			start = horizon
+		}
+	}
+	if !start.Before(end) {
+		return printer.Print(cmd, opts, buildLedger(nil, nil, q, start, end))
+	}
+	charges, err := funding.LeaseCharges(in, start, end)
+	if err != nil {
+		return err
+	}
+	return printer.Print(cmd, opts, buildLedger(in.Leases, charges, q, start, end))
+}
+
+// parseLedgerInstant reads a period bound as an instant, a date, or an age
+// before now: a Go duration (36h) or a number of days (7d).
+func parseLedgerInstant(value string, now time.Time) (time.Time, error) {
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return now.Add(-time.Duration(n) * 24 * time.Hour), nil
+		}
+	}
+	if age, err := time.ParseDuration(value); err == nil && age >= 0 {
+		return now.Add(-age), nil
+	}
+	t, err := funding.ParseInstant(value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want RFC 3339, YYYY-MM-DD, or an age like 36h or 7d, got %q", value)
+	}
+	return t, nil
+}
+
+// buildLedger applies the query to the classified charges: one row per lease
+// with hours in the period, or one per --by group.
+func buildLedger(leases []v1.GPULease, charges []funding.LeaseCharge, q ledgerQuery, from, to time.Time) Payload {
+	kept := make(map[string]*v1.GPULease)
+	for i := range leases {
+		if l := &leases[i]; q.keeps(l) {
+			kept[funding.LeaseKey(l)] = l
+		}
+	}
+	var selected []funding.LeaseCharge
+	for _, c := range charges {
+		if kept[c.Lease] == nil || c.GPUHours <= 0 {
+			continue
+		}
+		if q.Class != "" && !strings.EqualFold(string(c.Class), q.Class) {
+			continue
+		}
+		selected = append(selected, c)
+	}
+	period := fmt.Sprintf("%s to %s", from.UTC().Format(time.RFC3339), to.UTC().Format(time.RFC3339))
+	if len(q.By) > 0 {
+		return ledgerTotals(kept, selected, q.By, period)
+	}
+
+	byLease := make(map[string]*ledgerLease)
+	var order []string
+	for _, c := range selected {
+		row := byLease[c.Lease]
+		if row == nil {
+			l := kept[c.Lease]
+			row = &ledgerLease{
+				Lease:     c.Lease,
+				Run:       keys.NamespacedKey(l.Spec.RunRef.Namespace, l.Spec.RunRef.Name),
+				Owner:     l.Spec.Owner,
+				Envelope:  l.Spec.PaidByEnvelope,
+				Principal: l.Spec.PaidByBudgetNamespace,
+				Role:      l.Spec.Slice.Role,
+				GPUs:      len(l.Spec.Slice.Nodes),
+				Start:     l.Spec.Interval.Start.UTC(),
+				Closure:   l.Status.ClosureReason,
+				Hours:     map[funding.Class]float64{},
+			}
+			if l.Status.Ended != nil {
+				ended := l.Status.Ended.UTC()
+				row.End = &ended
+			}
+			byLease[c.Lease] = row
+			order = append(order, c.Lease)
+		}
+		row.Hours[c.Class] += c.GPUHours
+	}
+	sort.Slice(order, func(i, j int) bool {
+		a, b := byLease[order[i]], byLease[order[j]]
+		if !a.Start.Equal(b.Start) {
+			return a.Start.Before(b.Start)
+		}
+		return a.Lease < b.Lease
+	})
+	rows := make([][]string, 0, len(order))
+	raw := make([]ledgerLease, 0, len(order))
+	var total float64
+	for _, key := range order {
+		row := byLease[key]
+		end := "-"
+		if row.End != nil {
+			end = row.End.Format(time.RFC3339)
+		}
+		var classes []string
+		var hours float64
+		for _, class := range []funding.Class{funding.ClassOwned, funding.ClassShared, funding.ClassBorrowed, funding.ClassUnfunded} {
+			if h := row.Hours[class]; h > 0 {
+				classes = append(classes, string(class))
+				hours += h
+			}
+		}
+		total += hours
+		rows = append(rows, []string{
+			row.Lease, row.Run, row.Owner, row.Envelope, orDash(row.Principal), row.Role,
+			strconv.Itoa(row.GPUs), row.Start.Format(time.RFC3339), end, orDash(row.Closure),
+			strings.Join(classes, "+"), formatGPUHours(hours),
+		})
+		raw = append(raw, *row)
+	}
+	return Payload{
+		Headers: []string{"Lease", "Run", "Owner", "Envelope", "Principal", "Role", "GPUs", "Start", "End", "Closure", "Class", "GPU-Hours"},
+		Rows:    rows,
+		Raw:     raw,
+		Title:   fmt.Sprintf("Lease Ledger %s: %d lease(s), %s GPU-hours", period, len(rows), formatGPUHours(total)),
+	}
+}
+
+// ledgerTotals groups the selected charges by the --by dimensions, in the
+// order given.
+func ledgerTotals(kept map[string]*v1.GPULease, charges []funding.LeaseCharge, by []string, period string) Payload {
+	groups := make(map[ledgerTotal]*ledgerTotal)
+	members := make(map[ledgerTotal]map[string]bool)
+	for _, c := range charges {
+		l := kept[c.Lease]
+		var key ledgerTotal
+		for _, dim := range by {
+			switch dim {
+			case "run":
+				key.Run = keys.NamespacedKey(l.Spec.RunRef.Namespace, l.Spec.RunRef.Name)
+			case "day":
+				key.Day = c.Day.Format(time.DateOnly)
+			case "class":
+				key.Class = string(c.Class)
+			case "owner":
+				key.Owner = l.Spec.Owner
+			case "envelope":
+				key.Envelope = l.Spec.PaidByEnvelope
+			}
+		}
+		g := groups[key]
+		if g == nil {
+			copied := key
+			g = &copied
+			groups[key] = g
+			members[key] = map[string]bool{}
+		}
+		g.GPUHours += c.GPUHours
+		members[key][c.Lease] = true
+	}
+	column := func(t *ledgerTotal, dim string) string {
+		switch dim {
+		case "run":
+			return t.Run
+		case "day":
+			return t.Day
+		case "class":
+			return t.Class
+		case "owner":
+			return t.Owner
+		default:
+			return t.Envelope
+		}
+	}
+	totals := make([]*ledgerTotal, 0, len(groups))
+	for key, g := range groups {
+		g.Leases = len(members[key])
+		totals = append(totals, g)
+	}
+	sort.Slice(totals, func(i, j int) bool {
+		for _, dim := range by {
+			if a, b := column(totals[i], dim), column(totals[j], dim); a != b {
+				return a < b
+			}
+		}
+		return false
+	})
+	headers := make([]string, 0, len(by)+2)
+	for _, dim := range by {
+		headers = append(headers, strings.ToUpper(dim[:1])+dim[1:])
+	}
+	headers = append(headers, "Leases", "GPU-Hours")
+	rows := make([][]string, 0, len(totals))
+	raw := make([]ledgerTotal, 0, len(totals))
+	var sum float64
+	for _, t := range totals {
+		row := make([]string, 0, len(headers))
+		for _, dim := range by {
+			row = append(row, column(t, dim))
+		}
+		rows = append(rows, append(row, strconv.Itoa(t.Leases), formatGPUHours(t.GPUHours)))
+		raw = append(raw, *t)
+		sum += t.GPUHours
+	}
+	return Payload{
+		Headers: headers,
+		Rows:    rows,
+		Raw:     raw,
+		Title:   fmt.Sprintf("Lease Ledger %s by %s: %s GPU-hours", period, strings.Join(by, ", "), formatGPUHours(sum)),
+	}
+}
+
+// keeps reports whether the lease matches every filter but the class, which
+// selects hours rather than leases.
+func (q ledgerQuery) keeps(l *v1.GPULease) bool {
+	if q.Namespace != "" && l.Namespace != q.Namespace {
+		return false
+	}
+	if q.Run != "" {
+		// A run is its namespace/name: a bare name is the queried namespace's,
+		// and with --all-namespaces it needs its namespace to be one run.
+		namespace, name, qualified := strings.Cut(q.Run, "/")
+		if !qualified {
+			namespace, name = q.Namespace, q.Run
+		}
+		if l.Spec.RunRef.Name != name || (namespace != "" && l.Spec.RunRef.Namespace != namespace) {
+			return false
+		}
+	}
+	if q.Owner != "" && l.Spec.Owner != q.Owner && !strings.HasPrefix(l.Spec.Owner, q.Owner+":") {
+		return false
+	}
+	if q.Envelope != "" && l.Spec.PaidByEnvelope != q.Envelope {
+		return false
+	}
+	// The paying principal is the namespace whose Budget paid (R7: the
+	// namespace is the funding principal), which every lease records.
+	// spec.paidByPrincipal is not stamped yet, so it cannot be the filter.
+	if q.Principal != "" && l.Spec.PaidByBudgetNamespace != q.Principal {
+		return false
+	}
+	switch {
+	case q.Reason == "":
+	case strings.EqualFold(q.Reason, ledgerReasonOpen):
+		return !l.Status.Closed
+	default:
+		// A resolver closure carries its attested seed, ReclaimUnfunded(0x…);
+		// the reason is the name before it.
+		reason, _, _ := strings.Cut(l.Status.ClosureReason, "(")
+		return l.Status.Closed && strings.EqualFold(reason, q.Reason)
+	}
+	return true
+}
+
+func formatGPUHours(h float64) string {
+	return strconv.FormatFloat(h, 'f', 2, 64)
+}
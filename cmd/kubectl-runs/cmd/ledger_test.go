@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+)
+
+func ledgerLeaseOf(namespace, name, run, owner, envelope string, start time.Time, closure string) v1.GPULease {
+	l := v1.GPULease{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name},
+		Spec: v1.GPULeaseSpec{
+			Owner:          owner,
+			RunRef:         v1.RunReference{Namespace: namespace, Name: run},
+			Slice:          v1.GPULeaseSlice{Nodes: []string{"n1#0", "n1#1"}, Role: "Active"},
+			Interval:       v1.GPULeaseInterval{Start: metav1.NewTime(start)},
+			PaidByEnvelope: envelope,
+		},
+	}
+	if closure != "" {
+		l.Status.Closed, l.Status.ClosureReason = true, closure
+	}
+	return l
+}
+
+// Filters select leases, --class selects their hours, and --by totals what is
+// left by the dimensions given, in that order.
+func TestBuildLedgerFiltersAndTotals(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	day2 := day1.Add(24 * time.Hour)
+	leases := []v1.GPULease{
+		ledgerLeaseOf("team-a", "a1", "train", "org:ai:a", "west", day1, "ReclaimUnfunded(0x2ab5)"),
+		ledgerLeaseOf("team-a", "a2", "eval", "org:ai:a", "east", day1.Add(time.Hour), ""),
+		ledgerLeaseOf("team-b", "b1", "sweep", "org:ml:b", "west", day1, "Completed"),
+	}
+	charges := []funding.LeaseCharge{
+		{Lease: "team-a/a1", Day: day1, Class: funding.ClassOwned, GPUHours: 4},
+		{Lease: "team-a/a1", Day: day1, Class: funding.ClassUnfunded, GPUHours: 2},
+		{Lease: "team-a/a2", Day: day1, Class: funding.ClassOwned, GPUHours: 6},
+		{Lease: "team-a/a2", Day: day2, Class: funding.ClassOwned, GPUHours: 8},
+		{Lease: "team-b/b1", Day: day1, Class: funding.ClassBorrowed, GPUHours: 3},
+	}
+	from, to := day1, day2.Add(24*time.Hour)
+
+	payload := buildLedger(leases, charges, ledgerQuery{Owner: "org:ai"}, from, to)
+	var got []string
+	for _, row := range payload.Rows {
+		got = append(got, row[0]+" "+row[10]+" "+row[11])
+	}
+	if want := "team-a/a1 Owned+Unfunded 6.00; team-a/a2 Owned 14.00"; strings.Join(got, "; ") != want {
+		t.Errorf("owner query = %q, want %q", strings.Join(got, "; "), want)
+	}
+
+	payload = buildLedger(leases, charges, ledgerQuery{Reason: "reclaimunfunded", Class: "unfunded"}, from, to)
+	if len(payload.Rows) != 1 || payload.Rows[0][0] != "team-a/a1" || payload.Rows[0][11] != "2.00" {
+		t.Errorf("a reason matches the closure without its seed, and --class keeps only that class's hours: %v", payload.Rows)
+	}
+	if payload := buildLedger(leases, charges, ledgerQuery{Reason: ledgerReasonOpen}, from, to); len(payload.Rows) != 1 || payload.Rows[0][0] != "team-a/a2" {
+		t.Errorf("--reason open: %v", payload.Rows)
+	}
+
+	payload = buildLedger(leases, charges, ledgerQuery{Envelope: "west", By: []string{"day", "class"}}, from, to)
+	got = nil
+	for _, row := range payload.Rows {
+		got = append(got, strings.Join(row, " "))
+	}
+	if want := "2026-10-01 Borrowed 1 3.00; 2026-10-01 Owned 1 4.00; 2026-10-01 Unfunded 1 2.00"; strings.Join(got, "; ") != want {
+		t.Errorf("totals = %q, want %q", strings.Join(got, "; "), want)
+	}
+	if strings.Join(payload.Headers, ",") != "Day,Class,Leases,GPU-Hours" {
+		t.Errorf("headers = %v", payload.Headers)
+	}
+}
+
+// A run filter is a namespace/name: the same name in another namespace is
+// another run, whether the namespace comes from the query or the argument.
+func TestBuildLedgerRunFilterIsNamespaced(t *testing.T) {
+	day1 := time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC)
+	leases := []v1.GPULease{
+		ledgerLeaseOf("team-a", "a1", "train", "org:ai:a", "west", day1, ""),
+		ledgerLeaseOf("team-b", "b1", "train", "org:ai:b", "west", day1, ""),
+	}
+	charges := []funding.LeaseCharge{
+		{Lease: "team-a/a1", Day: day1, Class: funding.ClassOwned, GPUHours: 4},
+		{Lease: "team-b/b1", Day: day1, Class: funding.ClassOwned, GPUHours: 3},
+	}
+	from, to := day1, day1.Add(24*time.Hour)
+	for _, tc := range []struct {
+		q    ledgerQuery
+		want string
+	}{
+		{ledgerQuery{Namespace: "team-a", Run: "train"}, "team-a/a1"},
+		{ledgerQuery{Run: "team-b/train"}, "team-b/b1"},
+		{ledgerQuery{Run: "train"}, "team-a/a1 team-b/b1"},
+	} {
+		var got []string
+		for _, row := range buildLedger(leases, charges, tc.q, from, to).Rows {
+			got = append(got, row[0])
+		}
+		if strings.Join(got, " ") != tc.want {
+			t.Errorf("%+v: leases %v, want %s", tc.q, got, tc.want)
+		}
+	}
+}
+
+func TestParseLedgerInstantTakesAges(t *testing.T) {
+	now := time.Date(2026, 10, 17, 12, 0, 0, 0, time.UTC)
+	for value, want := range map[string]time.Time{
+		"7d":                   now.Add(-7 * 24 * time.Hour),
+		"36h":                  now.Add(-36 * time.Hour),
+		"2026-10-01":           time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC),
+		"2026-10-01T06:00:00Z": time.Date(2026, 10, 1, 6, 0, 0, 0, time.UTC),
+	} {
+		if got, err := parseLedgerInstant(value, now); err != nil || !got.Equal(want) {
+			t.Errorf("%s: got %v, %v; want %v", value, got, err, want)
+		}
+	}
+	if _, err := parseLedgerInstant("last week", now); err == nil {
+		t.Errorf("an unparseable bound must be rejected")
+	}
+}
@@ -99,6 +99,18 @@ func liveListBudgets(ctx context.Context, c client.Client, namespace string) ([]
 	return items, nil
 }
 
+// liveListTransfers lists the BudgetTransfer copies written in a namespace,
+// in name order.
+func liveListTransfers(ctx context.Context, c client.Client, namespace string) ([]v1.BudgetTransfer, error) {
+	var list v1.BudgetTransferList
+	if err := c.List(ctx, &list, client.InNamespace(namespace)); err != nil {
+		return nil, fmt.Errorf("list budget transfers: %w", err)
+	}
+	items := append([]v1.BudgetTransfer(nil), list.Items...)
+	sort.Slice(items, func(i, j int) bool { return items[i].Name < items[j].Name })
+	return items, nil
+}
+
 // liveListLeases lists Leases in a namespace belonging to the named Run. No
 // label index scopes Leases to a Run yet, so this filters client-side after
 // a namespaced List — the same filter the local simulator's filterLeases
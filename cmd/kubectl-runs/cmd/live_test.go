@@ -116,6 +116,24 @@ func TestLiveListBudgetsSortedByName(t *testing.T) {
 	}
 }
 
+// A party sees the copies written in its own namespace — its side of each
+// transfer — and not the other party's.
+func TestLiveListTransfersScopedAndSorted(t *testing.T) {
+	copyIn := func(ns, name string) *v1.BudgetTransfer {
+		return &v1.BudgetTransfer{ObjectMeta: v1.ObjectMeta{Name: name, Namespace: ns}}
+	}
+	c := fake.NewClientBuilder().WithScheme(liveScheme).
+		WithObjects(copyIn("default", "zebra"), copyIn("default", "alpha"), copyIn("other", "alpha")).Build()
+
+	transfers, err := liveListTransfers(context.Background(), c, "default")
+	if err != nil {
+		t.Fatalf("liveListTransfers: %v", err)
+	}
+	if len(transfers) != 2 || transfers[0].Name != "alpha" || transfers[1].Name != "zebra" {
+		t.Fatalf("expected default's two copies sorted by name, got %+v", transfers)
+	}
+}
+
 func TestLiveListLeasesFiltersByRunRef(t *testing.T) {
 	mine := &v1.GPULease{
 		ObjectMeta: v1.ObjectMeta{Name: "lease-mine", Namespace: "default"},
@@ -92,9 +92,12 @@ func buildPlanPayload(state *controllers.ClusterState, opts *RootOptions, run *v
 		// unfunded/spare capacity and malleable runs actually present — not
 		// a fabricated transcript; print exactly what the forecast emitted,
 		// including when that is just the lottery backstop.
-		if reservation != nil && len(reservation.Status.Forecast.Remedies) > 0 {
+		if reservation != nil && reservation.Status.Forecast != nil && len(reservation.Status.Forecast.Remedies) > 0 {
 			rows = append(rows, []string{"Remedies", strings.Join(reservation.Status.Forecast.Remedies, "; ")})
 		}
+		if reservation != nil && reservation.Spec.Booking != nil {
+			rows = append(rows, bookingRows(reservation, time.Now().UTC())...)
+		}
 	}
 	raw := map[string]interface{}{
 		"run": run,
@@ -109,3 +112,55 @@ func buildPlanPayload(state *controllers.ClusterState, opts *RootOptions, run *v
 		Title:   "Run Plan",
 	}
 }
+
+// timelineWidth is how many columns the booking timeline is drawn across.
+const timelineWidth = 48
+
+// bookingRows are a booked run's calendar: when its slice starts draining,
+// when it starts, until when it is booked, and the three on a timeline.
+func bookingRows(res *v1.Reservation, now time.Time) [][]string {
+	b := res.Spec.Booking
+	until := "open-ended"
+	if b.Until != nil {
+		until = b.Until.UTC().Format(time.RFC3339)
+	}
+	return [][]string{
+		{"Drain From", b.DrainFrom.UTC().Format(time.RFC3339)},
+		{"Booked Start", res.Spec.EarliestStart.UTC().Format(time.RFC3339)},
+		{"Booked Until", until},
+		{"Timeline", bookingTimeline(res, now, timelineWidth)},
+	}
+}
+
+// bookingTimeline draws a booking to scale from now: '.' while it waits, '~'
+// while its slice drains of unfunded work, '#' for the booked window, and '>'
+// in place of an end the booking does not have.
+func bookingTimeline(res *v1.Reservation, now time.Time, width int) string {
+	b := res.Spec.Booking
+	drain, start := b.DrainFrom.Time, res.Spec.EarliestStart.Time
+	end, open := start.Add(start.Sub(now)/3+time.Hour), true
+	if b.Until != nil {
+		end, open = b.Until.Time, false
+	}
+	if !end.After(now) {
+		return "[" + strings.Repeat("#", width) + "] ended"
+	}
+	col := func(t time.Time) int {
+		if t.Before(now) {
+			return 0
+		}
+		c := int(float64(t.Sub(now)) / float64(end.Sub(now)) * float64(width))
+		if c > width {
+			c = width
+		}
+		return c
+	}
+	fill := "#"
+	if open {
+		fill = ">"
+	}
+	bar := strings.Repeat(".", col(drain)) +
+		strings.Repeat("~", col(start)-col(drain)) +
+		strings.Repeat(fill, width-col(start))
+	return "now [" + bar + "]"
+}
@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func TestBookingTimelineIsToScale(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	until := v1.NewTime(now.Add(8 * time.Hour))
+	res := &v1.Reservation{Spec: v1.ReservationSpec{
+		EarliestStart: v1.NewTime(now.Add(4 * time.Hour)),
+		Booking:       &v1.ReservationBooking{DrainFrom: v1.NewTime(now.Add(2 * time.Hour)), Until: &until},
+	}}
+	if got, want := bookingTimeline(res, now, 8), "now [..~~####]"; got != want {
+		t.Errorf("timeline = %q, want %q", got, want)
+	}
+	res.Spec.Booking.Until = nil
+	if got := bookingTimeline(res, now, 8); got[len(got)-2] != '>' {
+		t.Errorf("open-ended timeline = %q, want it to end in '>'", got)
+	}
+	if got := bookingTimeline(res, now.Add(3*time.Hour), 8); got[5] != '~' {
+		t.Errorf("timeline mid-drain = %q, want it to start draining", got)
+	}
+}
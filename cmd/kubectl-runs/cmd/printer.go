@@ -1,6 +1,7 @@
 package cmd
 
 import (
+	"encoding/csv"
 	"encoding/json"
 	"fmt"
 	"strings"
@@ -9,7 +10,7 @@ import (
 	"github.com/spf13/cobra"
 )
 
-// Printer renders output in table or json formats.
+// Printer renders output in table, json, or csv formats.
 type Printer struct{}
 
 // Payload describes the data to render.
@@ -35,6 +36,19 @@ func (p *Printer) Print(cmd *cobra.Command, opts *RootOptions, payload Payload)
 		}
 		fmt.Fprintln(cmd.OutOrStdout(), string(enc))
 		return nil
+	case "csv":
+		// Headers and rows only: a title line would break the spreadsheet import
+		// csv exists for.
+		w := csv.NewWriter(cmd.OutOrStdout())
+		if len(payload.Headers) > 0 {
+			if err := w.Write(payload.Headers); err != nil {
+				return err
+			}
+		}
+		if err := w.WriteAll(payload.Rows); err != nil {
+			return err
+		}
+		return w.Error()
 	default:
 		w := tabwriter.NewWriter(cmd.OutOrStdout(), 2, 4, 2, ' ', 0)
 		if payload.Title != "" {
@@ -0,0 +1,327 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/spf13/cobra"
+)
+
+// queueFilter narrows the queue. Empty fields match everything.
+type queueFilter struct {
+	Namespace string
+	Owner     string
+	Flavor    string
+	Phase     string
+}
+
+// queueWorld is what the queue reads: every Run, the reservations that hold
+// their places, and the Budgets that say who owns each namespace.
+type queueWorld struct {
+	Runs         []*v1.Run
+	Reservations []v1.Reservation
+	Budgets      []v1.Budget
+}
+
+// queueEntry is one row of the queue as --output json carries it.
+type queueEntry struct {
+	Run           string               `json:"run"`
+	Owner         string               `json:"owner,omitempty"`
+	Phase         string               `json:"phase"`
+	Reason        string               `json:"reason,omitempty"`
+	Flavor        string               `json:"flavor"`
+	Width         *v1.RunWidthStatus   `json:"width,omitempty"`
+	Funding       *v1.RunFundingStatus `json:"funding,omitempty"`
+	EarliestStart *time.Time           `json:"earliestStart,omitempty"`
+	Envelope      string               `json:"envelope,omitempty"`
+	Position      int                  `json:"position,omitempty"`
+	Waiting       int                  `json:"waiting,omitempty"`
+
+	created time.Time
+}
+
+// NewQueueCommand lists Runs namespace- or cluster-wide, squeue-style.
+func NewQueueCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	var filter queueFilter
+	var allNamespaces, watch bool
+	cmd := &cobra.Command{
+		Use:   "queue",
+		Short: "List Runs with phase, reason, width, funding, and place in line (read-only)",
+		Long: `queue lists the namespace's Runs, or every namespace's with --all-namespaces:
+phase and the reason behind it, flavor, allocated and desired width, the funding
+class of the GPUs held, and for a run waiting on a reservation its earliest
+start and position in line on the paying envelope.
+
+The position ranks the runs one owner has reserved on the same flavor and
+envelope in the order the controller starts them, which is the order the
+funding evaluation ranks an owner's claims: the priority the envelope
+authorizes (spec.priority capped at its maxPriority) first, then creation
+time, then name. Runs are read cluster-wide so the position counts every
+waiting run, whatever --namespace shows.
+
+Completed and Failed runs are listed only when --phase names them. --watch
+re-renders every --watch-interval seconds, --watch-count times (0 = forever).`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if !allNamespaces {
+				filter.Namespace = opts.Namespace
+			}
+			iterations := 1
+			if watch {
+				iterations = opts.WatchCount
+				if iterations == 0 {
+					iterations = int(^uint(0) >> 1)
+				}
+			}
+			for i := 0; i < iterations; i++ {
+				if i > 0 {
+					time.Sleep(waitDuration(opts.WatchInterval))
+				}
+				world, err := loadQueueWorld(cmd, opts, store)
+				if err != nil {
+					return err
+				}
+				payload := buildQueue(world, filter)
+				if watch {
+					payload.Title = fmt.Sprintf("[%s] %s", time.Now().Format(time.RFC3339), payload.Title)
+				}
+				if err := printer.Print(cmd, opts, payload); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVarP(&allNamespaces, "all-namespaces", "A", false, "List Runs in every namespace")
+	cmd.Flags().StringVar(&filter.Owner, "owner", "", "Only Runs funded by this owner or an owner beneath it (e.g. org:ai)")
+	cmd.Flags().StringVar(&filter.Flavor, "flavor", "", "Only Runs of this GPU flavor")
+	cmd.Flags().StringVar(&filter.Phase, "phase", "", "Only Runs in this phase (Pending, Waiting, Running, Completed, Failed)")
+	cmd.Flags().BoolVarP(&watch, "watch", "w", false, "Re-render the queue until interrupted")
+	return cmd
+}
+
+// loadQueueWorld reads the local snapshot, or every Run, Reservation and
+// Budget of the live cluster.
+func loadQueueWorld(cmd *cobra.Command, opts *RootOptions, store *StateStore) (*queueWorld, error) {
+	world := &queueWorld{}
+	if opts.UseLocal() {
+		state, err := store.Load(opts.StatePath)
+		if err != nil {
+			return nil, err
+		}
+		for _, run := range state.Runs {
+			world.Runs = append(world.Runs, run)
+		}
+		for _, res := range state.Reservations {
+			world.Reservations = append(world.Reservations, *res)
+		}
+		world.Budgets = state.Budgets
+		return world, nil
+	}
+	c, err := opts.LiveClient()
+	if err != nil {
+		return nil, err
+	}
+	var runs v1.RunList
+	if err := c.List(cmd.Context(), &runs); err != nil {
+		return nil, fmt.Errorf("list runs: %w", err)
+	}
+	var reservations v1.ReservationList
+	if err := c.List(cmd.Context(), &reservations); err != nil {
+		return nil, fmt.Errorf("list reservations: %w", err)
+	}
+	var budgets v1.BudgetList
+	if err := c.List(cmd.Context(), &budgets); err != nil {
+		return nil, fmt.Errorf("list budgets: %w", err)
+	}
+	for i := range runs.Items {
+		world.Runs = append(world.Runs, &runs.Items[i])
+	}
+	world.Reservations, world.Budgets = reservations.Items, budgets.Items
+	return world, nil
+}
+
+// buildQueue ranks the waiting runs on each envelope, then lists the runs the
+// filter keeps: running first, then pending and waiting, each oldest first.
+func buildQueue(world *queueWorld, filter queueFilter) Payload {
+	owners := map[string]string{}
+	ownerOf := func(namespace string) string {
+		owner, ok := owners[namespace]
+		if !ok {
+			owner = funding.OwnerOfNamespace(world.Budgets, namespace)
+			owners[namespace] = owner
+		}
+		return owner
+	}
+
+	runs := make(map[string]*v1.Run, len(world.Runs))
+	entries := make(map[string]*queueEntry, len(world.Runs))
+	lines := map[string][]*queueEntry{}
+	for _, run := range world.Runs {
+		key := keys.NamespacedKey(run.Namespace, run.Name)
+		runs[key] = run
+		e := &queueEntry{
+			Run:     key,
+			Owner:   ownerOf(run.Namespace),
+			Phase:   run.Status.Phase,
+			Reason:  activeConditionReason(run),
+			Flavor:  runFlavor(run),
+			Width:   run.Status.Width,
+			Funding: run.Status.Funding,
+			created: run.CreationTimestamp.Time,
+		}
+		if e.Phase == "" {
+			e.Phase = v1.RunPhasePending
+		}
+		if es := run.Status.EarliestStart; es != nil {
+			t := es.Time.UTC()
+			e.EarliestStart = &t
+		}
+		entries[key] = e
+	}
+	for i := range world.Reservations {
+		res := &world.Reservations[i]
+		key := keys.NamespacedKey(res.Spec.RunRef.Namespace, res.Spec.RunRef.Name)
+		// Only the reservation the run is waiting on holds it a place; a
+		// released or superseded one is history.
+		run := runs[key]
+		if run == nil || run.Namespace != res.Namespace || run.Status.PendingReservation == nil || *run.Status.PendingReservation != res.Name {
+			continue
+		}
+		e := entries[key]
+		e.Envelope = res.Spec.PayingEnvelope
+		if res.Spec.Flavor != "" {
+			e.Flavor = res.Spec.Flavor
+		}
+		// The paying envelope is one of the run owner's own, so two owners'
+		// envelopes of the same name are two lines.
+		line := e.Owner + "/" + e.Flavor + "/" + e.Envelope
+		lines[line] = append(lines[line], e)
+	}
+	for _, line := range lines {
+		env := funding.OwnEnvelope(world.Budgets, line[0].Owner, line[0].Envelope)
+		sort.Slice(line, func(i, j int) bool {
+			return funding.WaitingLess(env, runs[line[i].Run], env, runs[line[j].Run])
+		})
+		for i, e := range line {
+			e.Position, e.Waiting = i+1, len(line)
+		}
+	}
+
+	var shown []*queueEntry
+	for _, run := range world.Runs {
+		e := entries[keys.NamespacedKey(run.Namespace, run.Name)]
+		if filter.keeps(run, e) {
+			shown = append(shown, e)
+		}
+	}
+	sort.Slice(shown, func(i, j int) bool {
+		a, b := shown[i], shown[j]
+		if queuePhaseRank(a.Phase) != queuePhaseRank(b.Phase) {
+			return queuePhaseRank(a.Phase) < queuePhaseRank(b.Phase)
+		}
+		if !a.created.Equal(b.created) {
+			return a.created.Before(b.created)
+		}
+		return a.Run < b.Run
+	})
+
+	rows := make([][]string, 0, len(shown))
+	raw := make([]queueEntry, 0, len(shown))
+	for _, e := range shown {
+		width, earliest, position := "-", "-", "-"
+		if e.Width != nil {
+			width = fmt.Sprintf("%d/%d", e.Width.Allocated, e.Width.Desired)
+		}
+		if e.EarliestStart != nil {
+			earliest = e.EarliestStart.Format(time.RFC3339)
+		}
+		if e.Position > 0 {
+			position = fmt.Sprintf("%d/%d on %s", e.Position, e.Waiting, e.Envelope)
+		}
+		rows = append(rows, []string{e.Run, orDash(e.Owner), e.Phase, orDash(e.Reason), e.Flavor, width, fundingMix(e.Funding), earliest, position})
+		raw = append(raw, *e)
+	}
+	scope := "namespace " + filter.Namespace
+	if filter.Namespace == "" {
+		scope = "all namespaces"
+	}
+	return Payload{
+		Headers: []string{"Run", "Owner", "Phase", "Reason", "Flavor", "Width", "Funding", "Earliest Start", "Position"},
+		Rows:    rows,
+		Raw:     raw,
+		Title:   fmt.Sprintf("Run Queue: %s, %d run(s)", scope, len(rows)),
+	}
+}
+
+// keeps reports whether the filter lists the run. Terminal runs are listed only
+// when the phase filter asks for them.
+func (f queueFilter) keeps(run *v1.Run, e *queueEntry) bool {
+	if f.Namespace != "" && run.Namespace != f.Namespace {
+		return false
+	}
+	if f.Owner != "" && e.Owner != f.Owner && !strings.HasPrefix(e.Owner, f.Owner+":") {
+		return false
+	}
+	if f.Flavor != "" && e.Flavor != f.Flavor {
+		return false
+	}
+	if f.Phase != "" {
+		return strings.EqualFold(e.Phase, f.Phase)
+	}
+	return e.Phase != v1.RunPhaseComplete && e.Phase != v1.RunPhaseFailed
+}
+
+// queuePhaseRank orders the listing: what holds GPUs, then what waits for them.
+func queuePhaseRank(phase string) int {
+	switch phase {
+	case v1.RunPhaseRunning:
+		return 0
+	case v1.RunPhasePending:
+		return 1
+	case v1.RunPhaseWaiting:
+		return 2
+	default:
+		return 3
+	}
+}
+
+// runFlavor is the flavor admission chose, else the one the run asks for.
+func runFlavor(run *v1.Run) string {
+	if run.Status.Flavor != "" {
+		return run.Status.Flavor
+	}
+	return run.Spec.Resources.GPUType
+}
+
+// fundingMix summarises the GPUs a run holds by funding class, e.g.
+// "owned 8, borrowed 4"; "-" when it holds none.
+func fundingMix(f *v1.RunFundingStatus) string {
+	if f == nil {
+		return "-"
+	}
+	var parts []string
+	for _, c := range []struct {
+		name string
+		gpus int32
+	}{{"owned", f.OwnedGPUs}, {"shared", f.SharedGPUs}, {"borrowed", f.BorrowedGPUs}, {"unfunded", f.UnfundedGPUs}} {
+		if c.gpus > 0 {
+			parts = append(parts, fmt.Sprintf("%s %d", c.name, c.gpus))
+		}
+	}
+	if len(parts) == 0 {
+		return "-"
+	}
+	return strings.Join(parts, ", ")
+}
+
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
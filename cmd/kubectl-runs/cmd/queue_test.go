@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+)
+
+func queueBudget(namespace, owner string, maxPriority int32) v1.Budget {
+	return v1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: "b"},
+		Spec: v1.BudgetSpec{Owner: owner, Envelopes: []v1.BudgetEnvelope{
+			{Name: "west", Flavor: "H100-80GB", Concurrency: 8, MaxPriority: maxPriority},
+		}},
+	}
+}
+
+func queueRun(namespace, name, phase string, priority int32, created time.Time) *v1.Run {
+	return &v1.Run{
+		ObjectMeta: metav1.ObjectMeta{Namespace: namespace, Name: name, CreationTimestamp: metav1.NewTime(created)},
+		Spec:       v1.RunSpec{Priority: priority, Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8}},
+		Status:     v1.RunStatus{Phase: phase},
+	}
+}
+
+// Waiting runs are placed in line per owner's envelope by priority, then age;
+// the listing puts running work first and leaves finished runs out unless
+// asked.
+func TestBuildQueueRanksWaitingRunsAndFilters(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	running := queueRun("team-a", "train", v1.RunPhaseRunning, 0, t0)
+	running.Status.Width = &v1.RunWidthStatus{Allocated: 8, Desired: 8}
+	running.Status.Funding = &v1.RunFundingStatus{OwnedGPUs: 6, BorrowedGPUs: 2}
+	older := queueRun("team-a", "older", v1.RunPhasePending, 0, t0.Add(time.Minute))
+	urgent := queueRun("team-a", "urgent", v1.RunPhaseWaiting, 5, t0.Add(time.Hour))
+	other := queueRun("team-b", "other", v1.RunPhasePending, 0, t0.Add(2*time.Hour))
+	done := queueRun("team-a", "done", v1.RunPhaseComplete, 0, t0.Add(-time.Hour))
+	reserve := func(run *v1.Run) v1.Reservation {
+		name := run.Name + "-res"
+		run.Status.PendingReservation = &name
+		start := metav1.NewTime(t0.Add(2 * time.Hour))
+		run.Status.EarliestStart = &start
+		return v1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: run.Namespace, Name: name},
+			Spec: v1.ReservationSpec{
+				RunRef:         v1.RunReference{Namespace: run.Namespace, Name: run.Name},
+				PayingEnvelope: "west",
+				EarliestStart:  start,
+			},
+		}
+	}
+	world := &queueWorld{
+		Runs:         []*v1.Run{done, other, urgent, older, running},
+		Reservations: []v1.Reservation{reserve(older), reserve(other), reserve(urgent)},
+		Budgets: []v1.Budget{
+			queueBudget("team-a", "org:ai:a", 5),
+			queueBudget("team-b", "org:ai:b", 5),
+		},
+	}
+
+	payload := buildQueue(world, queueFilter{})
+	var got []string
+	for _, row := range payload.Rows {
+		got = append(got, row[0]+" "+row[6]+" "+row[8])
+	}
+	want := []string{
+		"team-a/train owned 6, borrowed 2 -",
+		"team-a/older - 2/2 on west",
+		"team-b/other - 1/1 on west",
+		"team-a/urgent - 1/2 on west",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Fatalf("queue =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+
+	// The position counts every waiting run, even one the filter hides.
+	payload = buildQueue(world, queueFilter{Namespace: "team-a", Phase: "pending"})
+	if len(payload.Rows) != 1 || payload.Rows[0][8] != "2/2 on west" {
+		t.Errorf("namespace and phase filter: %v", payload.Rows)
+	}
+	if payload := buildQueue(world, queueFilter{Owner: "org:ai:b"}); len(payload.Rows) != 1 || payload.Rows[0][1] != "org:ai:b" {
+		t.Errorf("owner filter: %v", payload.Rows)
+	}
+	if payload := buildQueue(world, queueFilter{Owner: "org:ai", Phase: v1.RunPhaseComplete}); len(payload.Rows) != 1 || payload.Rows[0][0] != "team-a/done" {
+		t.Errorf("an owner above the run's, asking for finished runs: %v", payload.Rows)
+	}
+}
+
+// The line is the controller's order, not the raw spec.priority: a priority
+// above the envelope's maxPriority buys nothing, so the older of two runs
+// the envelope authorizes equally goes first.
+func TestBuildQueueRanksByAuthorizedPriority(t *testing.T) {
+	t0 := time.Date(2026, 10, 1, 9, 0, 0, 0, time.UTC)
+	capped := queueRun("team-a", "capped", v1.RunPhasePending, 5, t0)
+	eager := queueRun("team-a", "eager", v1.RunPhasePending, 50, t0.Add(time.Hour))
+	budget := queueBudget("team-a", "org:ai:a", 5)
+	world := &queueWorld{Runs: []*v1.Run{eager, capped}, Budgets: []v1.Budget{budget}}
+	for _, run := range world.Runs {
+		name := run.Name + "-res"
+		run.Status.PendingReservation = &name
+		world.Reservations = append(world.Reservations, v1.Reservation{
+			ObjectMeta: metav1.ObjectMeta{Namespace: run.Namespace, Name: name},
+			Spec: v1.ReservationSpec{
+				RunRef:         v1.RunReference{Namespace: run.Namespace, Name: run.Name},
+				PayingEnvelope: "west",
+			},
+		})
+	}
+
+	position := map[string]string{}
+	for _, row := range buildQueue(world, queueFilter{}).Rows {
+		position[row[0]] = row[8]
+	}
+	if position["team-a/capped"] != "1/2 on west" || position["team-a/eager"] != "2/2 on west" {
+		t.Fatalf("positions = %v, want capped first", position)
+	}
+	env := &budget.Spec.Envelopes[0]
+	if !funding.WaitingLess(env, capped, env, eager) {
+		t.Errorf("the controller's order disagrees with the queue's")
+	}
+}
@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+	"github.com/spf13/cobra"
+)
+
+// NewReportCommand groups period reports over the lease ledger.
+func NewReportCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "report",
+		Short: "Period reports folded from the lease ledger",
+	}
+	cmd.AddCommand(newReportChargebackCommand(opts, store, printer))
+	return cmd
+}
+
+func newReportChargebackCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	var from, to string
+	cmd := &cobra.Command{
+		Use:   "chargeback",
+		Short: "GPU-hours per owner, run, envelope, and funding class for a period (read-only)",
+		Long: `chargeback folds every GPULease interval that overlaps [--from, --to) into
+GPU-hours per run, per paying envelope, and per funding class, with the lender
+credited for Shared and Borrowed hours. Bounds are RFC 3339 or YYYY-MM-DD (UTC);
+--to defaults to now. Use --output csv for a spreadsheet.
+
+The whole cluster's ledger is read regardless of --namespace: funding classes
+are derived globally, so one owner's statement depends on everyone's claims.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			start, err := funding.ParseInstant(from)
+			if err != nil {
+				return fmt.Errorf("--from: %w", err)
+			}
+			end := time.Now().UTC()
+			if to != "" {
+				if end, err = funding.ParseInstant(to); err != nil {
+					return fmt.Errorf("--to: %w", err)
+				}
+			}
+			in, err := chargebackLedger(cmd, opts, store)
+			if err != nil {
+				return err
+			}
+			stmt, err := funding.Chargeback(in, start, end)
+			if err != nil {
+				return err
+			}
+			return printer.Print(cmd, opts, Payload{
+				Headers: funding.ChargebackHeaders,
+				Rows:    stmt.Rows(),
+				Raw:     stmt,
+				Title:   fmt.Sprintf("Chargeback %s to %s", start.Format(time.RFC3339), end.Format(time.RFC3339)),
+			})
+		},
+	}
+	cmd.Flags().StringVar(&from, "from", "", "Start of the period, inclusive (RFC 3339 or YYYY-MM-DD)")
+	cmd.Flags().StringVar(&to, "to", "", "End of the period, exclusive (default now)")
+	_ = cmd.MarkFlagRequired("from")
+	return cmd
+}
+
+// chargebackLedger reads the budgets, leases, archives, runs, and transfers the
+// statement is folded from: the local snapshot, or every namespace of the live
+// cluster.
+func chargebackLedger(cmd *cobra.Command, opts *RootOptions, store *StateStore) (funding.Input, error) {
+	if opts.UseLocal() {
+		state, err := store.Load(opts.StatePath)
+		if err != nil {
+			return funding.Input{}, err
+		}
+		return funding.Input{Budgets: state.Budgets, Leases: state.Leases, Runs: state.Runs, Archives: state.Archives, Transfers: state.Transfers}, nil
+	}
+	c, err := opts.LiveClient()
+	if err != nil {
+		return funding.Input{}, err
+	}
+	return ledger.List(cmd.Context(), c)
+}
@@ -105,7 +105,7 @@ no manager reconciles anything you submit to it.`,
 
 	root.PersistentFlags().StringVar(&opts.StatePath, "state", "cluster-state.json", "Path to the local cluster state snapshot (--local/--dry-run only)")
 	root.PersistentFlags().StringVar(&opts.Namespace, "namespace", "default", "Namespace to use for Run operations (live mode default: current kubeconfig context's namespace)")
-	root.PersistentFlags().StringVar(&opts.Output, "output", "table", "Output format: table|json")
+	root.PersistentFlags().StringVar(&opts.Output, "output", "table", "Output format: table|json|csv")
 	root.PersistentFlags().IntVar(&opts.WatchInterval, "watch-interval", 2, "Watch refresh interval in seconds")
 	root.PersistentFlags().IntVar(&opts.WatchCount, "watch-count", 0, "Number of watch iterations (0 = infinite)")
 	root.PersistentFlags().StringVar(&opts.Kubeconfig, "kubeconfig", "", "Path to a kubeconfig file (default: standard kubeconfig discovery, like kubectl)")
@@ -129,6 +129,10 @@ no manager reconciles anything you submit to it.`,
 	root.AddCommand(NewPodsCommand(opts, store, printer))
 	root.AddCommand(NewLogsCommand(opts, store, printer))
 	root.AddCommand(NewArtifactsCommand(opts, store, printer))
+	root.AddCommand(NewReportCommand(opts, store, printer))
+	root.AddCommand(NewSimulateCommand(opts, store, printer))
+	root.AddCommand(NewTimelineCommand(opts, store, printer))
+	root.AddCommand(NewQueueCommand(opts, store, printer))
 	root.AddCommand(NewCompletionsCommand(opts, printer))
 
 	return root
@@ -0,0 +1,567 @@
+package cmd
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	sigsyaml "sigs.k8s.io/yaml"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+const (
+	// defaultSimTick is how often the replay resyncs between events, as the
+	// manager's periodic resync would: a pending run retries admission on it.
+	defaultSimTick = 5 * time.Minute
+	// defaultSimHorizon bounds the replay past the last submission, so a trace
+	// whose runs can never start on the proposed fleet still ends.
+	defaultSimHorizon = 30 * 24 * time.Hour
+)
+
+// NewSimulateCommand replays a trace of historical Runs against the current
+// fleet and a proposed one and compares the outcomes. It never contacts a
+// cluster: both replays drive the engine in-process on a virtual clock.
+func NewSimulateCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	var tracePath, budgetsPath, nodesPath, baseBudgetsPath, baseNodesPath string
+	var tick, horizon time.Duration
+	cmd := &cobra.Command{
+		Use:   "simulate",
+		Short: "Replay a trace of Runs against a proposed Budget or node change and compare the outcomes (offline)",
+		Long: `simulate replays --trace twice, once on the baseline fleet and once on the
+proposed one, and reports queue times, GPU-hours by funding class, utilization,
+and resolver actions side by side.
+
+The trace is JSON Lines, one run per line:
+
+  {"run": {<Run manifest>}, "submitted": "2024-05-01T09:00:00Z", "duration": "6h"}
+
+submitted defaults to the run's creationTimestamp; duration is how long its
+workload ran, and the replay completes its pods once it has run that long in
+total, so a run preempted halfway resumes with the rest.
+
+The baseline is the budgets and nodes of the --state snapshot, each replaceable
+with --baseline-budgets and --baseline-nodes. The proposal replaces either or
+both with --budgets (a manifest file or a directory of them) and --nodes (a
+list of {name, labels, gpus, slices}). Nothing is written and no cluster is
+contacted: the engine runs in-process on a virtual clock, with the offline
+plugin stand-in minting leases, exactly as --local does.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if tracePath == "" {
+				return fmt.Errorf("--trace is required")
+			}
+			if budgetsPath == "" && nodesPath == "" {
+				return fmt.Errorf("nothing to compare: pass --budgets, --nodes, or both")
+			}
+			trace, err := readTrace(tracePath)
+			if err != nil {
+				return err
+			}
+			state, err := store.Load(opts.StatePath)
+			if err != nil {
+				return err
+			}
+			baseline := whatIf{Label: "baseline", Budgets: state.Budgets, Nodes: state.Nodes, Topology: state.Topology, Trace: trace, Tick: tick, Horizon: horizon}
+			if baseBudgetsPath != "" {
+				if baseline.Budgets, err = readBudgets(baseBudgetsPath); err != nil {
+					return err
+				}
+			}
+			if baseNodesPath != "" {
+				if baseline.Nodes, err = readNodes(baseNodesPath); err != nil {
+					return err
+				}
+			}
+			if len(baseline.Nodes) == 0 {
+				return fmt.Errorf("the baseline has no nodes: pass --baseline-nodes, or a --state snapshot that has some")
+			}
+			proposed := baseline
+			proposed.Label = "proposed"
+			if budgetsPath != "" {
+				if proposed.Budgets, err = readBudgets(budgetsPath); err != nil {
+					return err
+				}
+			}
+			if nodesPath != "" {
+				if proposed.Nodes, err = readNodes(nodesPath); err != nil {
+					return err
+				}
+			}
+
+			before, err := runWhatIf(baseline)
+			if err != nil {
+				return fmt.Errorf("baseline: %w", err)
+			}
+			after, err := runWhatIf(proposed)
+			if err != nil {
+				return fmt.Errorf("proposed: %w", err)
+			}
+			return printer.Print(cmd, opts, Payload{
+				Headers: []string{"Metric", "Baseline", "Proposed", "Delta"},
+				Rows:    compareOutcomes(before, after),
+				Raw:     map[string]interface{}{"baseline": before, "proposed": after},
+				Title:   fmt.Sprintf("What-if: %d runs from %s", len(trace), before.From.Format(time.RFC3339)),
+			})
+		},
+	}
+	cmd.Flags().StringVar(&tracePath, "trace", "", "JSON Lines trace of historical Runs to replay")
+	cmd.Flags().StringVar(&budgetsPath, "budgets", "", "Proposed Budget manifests: a file or a directory of them")
+	cmd.Flags().StringVar(&nodesPath, "nodes", "", "Proposed nodes: a YAML or JSON list of {name, labels, gpus, slices}")
+	cmd.Flags().StringVar(&baseBudgetsPath, "baseline-budgets", "", "Baseline Budget manifests (default: the --state snapshot's)")
+	cmd.Flags().StringVar(&baseNodesPath, "baseline-nodes", "", "Baseline nodes (default: the --state snapshot's)")
+	cmd.Flags().DurationVar(&tick, "tick", defaultSimTick, "Resync interval between events on the virtual clock")
+	cmd.Flags().DurationVar(&horizon, "horizon", defaultSimHorizon, "How long past the last submission the replay may run")
+	return cmd
+}
+
+// traceRecord is one line of a --trace file.
+type traceRecord struct {
+	Run v1.Run `json:"run"`
+	// Submitted defaults to the run's creationTimestamp.
+	Submitted *v1.Time `json:"submitted,omitempty"`
+	// Duration is the workload's recorded running time.
+	Duration v1.Duration `json:"duration"`
+}
+
+func readTrace(path string) ([]traceRecord, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("read trace: %w", err)
+	}
+	defer f.Close()
+	var trace []traceRecord
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var rec traceRecord
+		if err := sigsyaml.Unmarshal([]byte(text), &rec); err != nil {
+			return nil, fmt.Errorf("trace line %d: %w", line, err)
+		}
+		if rec.Run.Name == "" {
+			return nil, fmt.Errorf("trace line %d: run has no metadata.name", line)
+		}
+		if rec.Run.Namespace == "" {
+			rec.Run.Namespace = "default"
+		}
+		if rec.Submitted == nil {
+			if rec.Run.CreationTimestamp.IsZero() {
+				return nil, fmt.Errorf("trace line %d: run %s has neither submitted nor a creationTimestamp", line, rec.Run.Name)
+			}
+			at := rec.Run.CreationTimestamp
+			rec.Submitted = &at
+		}
+		if rec.Duration.Duration <= 0 {
+			return nil, fmt.Errorf("trace line %d: run %s needs a positive duration", line, rec.Run.Name)
+		}
+		trace = append(trace, rec)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read trace: %w", err)
+	}
+	if len(trace) == 0 {
+		return nil, fmt.Errorf("trace %s holds no runs", path)
+	}
+	return trace, nil
+}
+
+// readBudgets reads every Budget in a manifest file, or in each .yaml, .yml
+// and .json file of a directory. A file may hold several documents.
+func readBudgets(path string) ([]v1.Budget, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, fmt.Errorf("read budgets: %w", err)
+	}
+	files := []string{path}
+	if info.IsDir() {
+		entries, err := os.ReadDir(path)
+		if err != nil {
+			return nil, fmt.Errorf("read budgets: %w", err)
+		}
+		files = files[:0]
+		for _, entry := range entries {
+			switch filepath.Ext(entry.Name()) {
+			case ".yaml", ".yml", ".json":
+				if !entry.IsDir() {
+					files = append(files, filepath.Join(path, entry.Name()))
+				}
+			}
+		}
+	}
+	var budgets []v1.Budget
+	for _, file := range files {
+		f, err := os.Open(file)
+		if err != nil {
+			return nil, fmt.Errorf("read budgets: %w", err)
+		}
+		decoder := utilyaml.NewYAMLOrJSONDecoder(f, 4096)
+		for {
+			var budget v1.Budget
+			err := decoder.Decode(&budget)
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			if err != nil {
+				f.Close()
+				return nil, fmt.Errorf("decode %s: %w", file, err)
+			}
+			if budget.Name == "" && len(budget.Spec.Envelopes) == 0 {
+				continue // an empty document between separators
+			}
+			if budget.Kind != "" && budget.Kind != "Budget" {
+				f.Close()
+				return nil, fmt.Errorf("%s: %s %s is not a Budget", file, budget.Kind, budget.Name)
+			}
+			if budget.Namespace == "" {
+				budget.Namespace = "default"
+			}
+			budgets = append(budgets, budget)
+		}
+		f.Close()
+	}
+	if len(budgets) == 0 {
+		return nil, fmt.Errorf("no Budgets in %s", path)
+	}
+	return budgets, nil
+}
+
+func readNodes(path string) ([]topology.SourceNode, error) {
+	payload, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read nodes: %w", err)
+	}
+	var list []nodeSnapshot
+	if err := sigsyaml.Unmarshal(payload, &list); err != nil {
+		return nil, fmt.Errorf("decode nodes (a list of {name, labels, gpus, slices}): %w", err)
+	}
+	if len(list) == 0 {
+		return nil, fmt.Errorf("no nodes in %s", path)
+	}
+	nodes := make([]topology.SourceNode, len(list))
+	for i, node := range list {
+		nodes[i] = topology.SourceNode{Name: node.Name, Labels: cloneStringMap(node.Labels), GPUs: node.GPUs, Slices: node.Slices}
+	}
+	return nodes, nil
+}
+
+// whatIf is one replay: a fleet, its budgets, and the trace to run on them.
+type whatIf struct {
+	Label    string
+	Budgets  []v1.Budget
+	Nodes    []topology.SourceNode
+	Topology topology.Hierarchy
+	Trace    []traceRecord
+	// Tick is the resync interval; Horizon how long past the last
+	// submission the replay may run.
+	Tick    time.Duration
+	Horizon time.Duration
+}
+
+// whatIfOutcome is what one replay measured.
+type whatIfOutcome struct {
+	Label     string    `json:"label"`
+	From      time.Time `json:"from"`
+	To        time.Time `json:"to"`
+	Submitted int       `json:"submitted"`
+	Started   int       `json:"started"`
+	Completed int       `json:"completed"`
+	// QueueTimes are submission to first start, for the runs that started.
+	QueueTimes []time.Duration `json:"queueTimes"`
+	// ClassHours are the GPU-hours the ledger accrued, by funding class.
+	ClassHours map[funding.Class]float64 `json:"classHours"`
+	// CapacityHours are the fleet's GPU-hours over [From, To).
+	CapacityHours float64 `json:"capacityHours"`
+	// Actions counts the resolver's lease actions by kind, and its run
+	// outcomes by event reason.
+	Actions map[string]int `json:"actions"`
+}
+
+// simClock is the replay's virtual clock.
+type simClock struct{ now time.Time }
+
+func (c *simClock) Now() time.Time { return c.now }
+
+// actionCounter records the resolver's events as the replay raises them.
+type actionCounter struct{ counts map[string]int }
+
+func (a *actionCounter) Event(_ *v1.Run, _, reason, message string) {
+	if !strings.HasPrefix(reason, "Resolver") {
+		return
+	}
+	if reason == "ResolverAction" {
+		// "<kind>: <reason> (lease <name>)", one per lease the resolver closed.
+		kind, _, _ := strings.Cut(message, ":")
+		reason = "ResolverAction/" + kind
+	}
+	a.counts[reason]++
+}
+
+// tracedRun follows one traced run through a replay.
+type tracedRun struct {
+	key       string
+	submitted time.Time
+	remaining time.Duration
+	running   bool
+	since     time.Time
+	started   bool
+	done      bool
+}
+
+// runWhatIf replays the trace. Each step submits what is due, completes the
+// pods of runs that have run their duration, activates due reservations,
+// reconciles every live run, lets the offline plugin commit, and reconciles
+// again so committed runs adopt their leases. Steps fall on every submission,
+// completion and reservation start, and at least every Tick between them.
+func runWhatIf(in whatIf) (*whatIfOutcome, error) {
+	if in.Tick <= 0 {
+		in.Tick = defaultSimTick
+	}
+	if in.Horizon <= 0 {
+		in.Horizon = defaultSimHorizon
+	}
+	trace := append([]traceRecord(nil), in.Trace...)
+	sort.SliceStable(trace, func(i, j int) bool { return trace[i].Submitted.Before(trace[j].Submitted) })
+
+	state := &controllers.ClusterState{
+		Runs:         map[string]*v1.Run{},
+		Budgets:      make([]v1.Budget, len(in.Budgets)),
+		Nodes:        append([]topology.SourceNode(nil), in.Nodes...),
+		Reservations: map[string]*v1.Reservation{},
+		Topology:     in.Topology,
+	}
+	for i := range in.Budgets {
+		state.Budgets[i] = *in.Budgets[i].DeepCopy()
+	}
+	clock := &simClock{now: trace[0].Submitted.Time.UTC()}
+	counter := &actionCounter{counts: map[string]int{}}
+	controller := controllers.NewRunController(state, clock)
+	controller.Recorder = counter
+
+	out := &whatIfOutcome{Label: in.Label, From: clock.now, Actions: counter.counts}
+	until := trace[len(trace)-1].Submitted.Time.UTC().Add(in.Horizon)
+	traced := map[string]*tracedRun{}
+	var order []*tracedRun
+	next := 0
+	for {
+		now := clock.now
+		for ; next < len(trace) && !trace[next].Submitted.Time.After(now); next++ {
+			rec := trace[next]
+			run := rec.Run.DeepCopy()
+			run.ResourceVersion, run.UID = "", ""
+			run.CreationTimestamp = v1.NewTime(rec.Submitted.Time.UTC())
+			run.Status = v1.RunStatus{}
+			run.Default()
+			key := keys.NamespacedKey(run.Namespace, run.Name)
+			if _, dup := traced[key]; dup {
+				return nil, fmt.Errorf("run %s is traced twice", key)
+			}
+			state.Runs[key] = run
+			t := &tracedRun{key: key, submitted: run.CreationTimestamp.Time, remaining: rec.Duration.Duration}
+			traced[key] = t
+			order = append(order, t)
+			out.Submitted++
+		}
+		for _, t := range order {
+			if t.running && !now.Before(t.since.Add(t.remaining)) {
+				completePods(state, state.Runs[t.key])
+			}
+		}
+		if err := controller.ActivateReservations(now); err != nil {
+			return nil, err
+		}
+		if err := reconcileLive(controller, state); err != nil {
+			return nil, err
+		}
+		simulatePluginCommit(state, now)
+		if err := reconcileLive(controller, state); err != nil {
+			return nil, err
+		}
+
+		live := false
+		for _, t := range order {
+			run := state.Runs[t.key]
+			running := run.Status.Phase == controllers.RunPhaseRunning
+			switch {
+			case running && !t.running:
+				t.running, t.since = true, now
+				if !t.started {
+					t.started = true
+					out.Started++
+					out.QueueTimes = append(out.QueueTimes, now.Sub(t.submitted))
+				}
+			case !running && t.running:
+				t.running = false
+				t.remaining -= now.Sub(t.since)
+			}
+			if !t.done && (run.Status.Phase == controllers.RunPhaseComplete || run.Status.Phase == controllers.RunPhaseFailed) {
+				t.done = true
+				if run.Status.Phase == controllers.RunPhaseComplete {
+					out.Completed++
+				}
+			}
+			live = live || !t.done
+		}
+		if !live && next == len(trace) {
+			break
+		}
+
+		step := now.Add(in.Tick)
+		if next < len(trace) && trace[next].Submitted.Time.Before(step) {
+			step = trace[next].Submitted.Time.UTC()
+		}
+		for _, t := range order {
+			if end := t.since.Add(t.remaining); t.running && end.After(now) && end.Before(step) {
+				step = end
+			}
+		}
+		for _, reservation := range state.Reservations {
+			if start := reservation.Spec.EarliestStart.Time; start.After(now) && start.Before(step) {
+				step = start
+			}
+		}
+		if step.After(until) {
+			break
+		}
+		clock.now = step
+	}
+	out.To = clock.now
+	if !out.To.After(out.From) {
+		out.To = out.From.Add(in.Tick)
+	}
+
+	stmt, err := funding.Chargeback(funding.Input{Budgets: state.Budgets, Leases: state.Leases, Runs: state.Runs, Transfers: state.Transfers}, out.From, out.To)
+	if err != nil {
+		return nil, err
+	}
+	out.ClassHours = map[funding.Class]float64{}
+	for _, line := range stmt.Lines {
+		out.ClassHours[line.Class] += line.GPUHours
+	}
+	units := 0
+	for _, node := range state.Nodes {
+		units += node.GPUs
+		for _, slices := range node.Slices {
+			units += slices
+		}
+	}
+	out.CapacityHours = float64(units) * out.To.Sub(out.From).Hours()
+	return out, nil
+}
+
+// reconcileLive reconciles every run that has not finished, in key order.
+func reconcileLive(controller *controllers.RunController, state *controllers.ClusterState) error {
+	runKeys := make([]string, 0, len(state.Runs))
+	for key, run := range state.Runs {
+		if run.Status.Phase != controllers.RunPhaseComplete && run.Status.Phase != controllers.RunPhaseFailed {
+			runKeys = append(runKeys, key)
+		}
+	}
+	sort.Strings(runKeys)
+	for _, key := range runKeys {
+		run := state.Runs[key]
+		if err := controller.Reconcile(run.Namespace, run.Name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// completePods is `complete` for the replay: the run's active pods succeed.
+func completePods(state *controllers.ClusterState, run *v1.Run) {
+	for i := range state.Pods {
+		pod := &state.Pods[i]
+		if pod.Namespace == run.Namespace && pod.Labels[binder.LabelRunName] == run.Name && pod.Labels[binder.LabelRunRole] != binder.RoleSpare {
+			pod.Phase = binder.PodPhaseSucceeded
+		}
+	}
+}
+
+// compareOutcomes lays the two replays side by side, one metric per row.
+func compareOutcomes(before, after *whatIfOutcome) [][]string {
+	count := func(name string, b, a int) []string {
+		return []string{name, fmt.Sprint(b), fmt.Sprint(a), fmt.Sprintf("%+d", a-b)}
+	}
+	hours := func(name string, b, a float64) []string {
+		return []string{name, fmt.Sprintf("%.1f", b), fmt.Sprintf("%.1f", a), fmt.Sprintf("%+.1f", a-b)}
+	}
+	wait := func(name string, b, a time.Duration) []string {
+		delta := a - b
+		sign := "+"
+		if delta < 0 {
+			sign, delta = "-", -delta
+		}
+		return []string{name, b.Round(time.Minute).String(), a.Round(time.Minute).String(), sign + delta.Round(time.Minute).String()}
+	}
+
+	rows := [][]string{
+		count("Runs submitted", before.Submitted, after.Submitted),
+		count("Runs started", before.Started, after.Started),
+		count("Runs completed", before.Completed, after.Completed),
+		count("Runs never started", before.Submitted-before.Started, after.Submitted-after.Started),
+		wait("Queue time p50", percentile(before.QueueTimes, 50), percentile(after.QueueTimes, 50)),
+		wait("Queue time p90", percentile(before.QueueTimes, 90), percentile(after.QueueTimes, 90)),
+		wait("Queue time p99", percentile(before.QueueTimes, 99), percentile(after.QueueTimes, 99)),
+		wait("Queue time max", percentile(before.QueueTimes, 100), percentile(after.QueueTimes, 100)),
+	}
+	for _, class := range []funding.Class{funding.ClassOwned, funding.ClassShared, funding.ClassBorrowed, funding.ClassUnfunded} {
+		rows = append(rows, hours("GPU-hours "+string(class), before.ClassHours[class], after.ClassHours[class]))
+	}
+	rows = append(rows, hours("Utilization %", utilization(before), utilization(after)))
+
+	var kinds []string
+	for kind := range before.Actions {
+		kinds = append(kinds, kind)
+	}
+	for kind := range after.Actions {
+		if _, ok := before.Actions[kind]; !ok {
+			kinds = append(kinds, kind)
+		}
+	}
+	sort.Strings(kinds)
+	for _, kind := range kinds {
+		rows = append(rows, count(kind, before.Actions[kind], after.Actions[kind]))
+	}
+	return rows
+}
+
+// percentile is the nearest-rank p-th percentile; zero for no samples.
+func percentile(samples []time.Duration, p float64) time.Duration {
+	if len(samples) == 0 {
+		return 0
+	}
+	sorted := append([]time.Duration(nil), samples...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+func utilization(out *whatIfOutcome) float64 {
+	if out.CapacityHours <= 0 {
+		return 0
+	}
+	used := 0.0
+	for _, h := range out.ClassHours {
+		used += h
+	}
+	return 100 * used / out.CapacityHours
+}
@@ -0,0 +1,93 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const simBudget = `apiVersion: rq.davidlangworthy.io/v1
+kind: Budget
+metadata:
+  name: team
+  namespace: default
+spec:
+  owner: org:ai:team
+  envelopes:
+  - name: west
+    flavor: H100-80GB
+    concurrency: 16
+    selector: {}
+    start: "2024-01-01T00:00:00Z"
+    end: "2025-01-01T00:00:00Z"
+`
+
+func simNodes(n int) string {
+	var b strings.Builder
+	for i := 0; i < n; i++ {
+		fmt.Fprintf(&b, "- name: n%d\n  gpus: 8\n  labels: {region: us-west, cluster: cluster-a, fabric.domain: island-%d, gpu.flavor: H100-80GB}\n", i, i)
+	}
+	return b.String()
+}
+
+// Three 8-GPU runs of an hour each, a minute apart. One 8-GPU node runs them
+// in turn; a second node lets two run at once and halves the wait.
+func TestSimulateComparesQueueTimesOnAProposedFleet(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, body string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	var trace strings.Builder
+	for i := 0; i < 3; i++ {
+		fmt.Fprintf(&trace, `{"run": {"metadata": {"name": "train-%d", "namespace": "default"}, "spec": {"resources": {"gpuType": "H100-80GB", "totalGPUs": 8}}}, "submitted": %q, "duration": "1h"}`+"\n",
+			i, start.Add(time.Duration(i)*time.Minute).Format(time.RFC3339))
+	}
+	budgets := filepath.Join(dir, "budgets")
+	if err := os.Mkdir(budgets, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(budgets, "team.yaml"), []byte(simBudget), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	root := NewRootCommand()
+	root.SetOut(&out)
+	root.SetErr(&bytes.Buffer{})
+	root.SetArgs([]string{"--state", filepath.Join(dir, "absent.json"), "--output", "json", "simulate",
+		"--trace", write("runs.jsonl", trace.String()),
+		"--baseline-budgets", budgets, "--baseline-nodes", write("today.yaml", simNodes(1)),
+		"--nodes", write("proposed.yaml", simNodes(2))})
+	if err := root.Execute(); err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	var report map[string]whatIfOutcome
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatalf("decode %s: %v", out.String(), err)
+	}
+	before, after := report["baseline"], report["proposed"]
+	for _, o := range []whatIfOutcome{before, after} {
+		if o.Submitted != 3 || o.Started != 3 || o.Completed != 3 {
+			t.Fatalf("%s: submitted %d started %d completed %d, want all three", o.Label, o.Submitted, o.Started, o.Completed)
+		}
+		if owned := o.ClassHours["Owned"]; owned < 23.9 || owned > 24.1 {
+			t.Errorf("%s: %.2f owned GPU-hours, want the trace's 24", o.Label, owned)
+		}
+	}
+	if got := percentile(before.QueueTimes, 100); got < 2*time.Hour-5*time.Minute {
+		t.Errorf("baseline: the last run waited %s on one node, want about two hours", got)
+	}
+	if got := percentile(after.QueueTimes, 100); got > time.Hour {
+		t.Errorf("proposed: the last run waited %s on two nodes, want about one hour", got)
+	}
+}
@@ -99,12 +99,16 @@ type snapshot struct {
 	Leases       []v1.GPULease        `json:"leases,omitempty"`
 	Pods         []binder.PodManifest `json:"pods,omitempty"`
 	Reservations []v1.Reservation     `json:"reservations,omitempty"`
+	// Transfers are compiled BudgetTransfers, as the published snapshot
+	// carries them; the local simulator has no producer to pair copies.
+	Transfers []v1.SnapshotTransfer `json:"transfers,omitempty"`
 }
 
 type nodeSnapshot struct {
 	Name   string            `json:"name"`
 	Labels map[string]string `json:"labels,omitempty"`
 	GPUs   int               `json:"gpus,omitempty"`
+	Slices map[string]int    `json:"slices,omitempty"`
 }
 
 func (s snapshot) toState() *controllers.ClusterState {
@@ -115,10 +119,11 @@ func (s snapshot) toState() *controllers.ClusterState {
 		Leases:       make([]v1.GPULease, len(s.Leases)),
 		Pods:         append([]binder.PodManifest{}, s.Pods...),
 		Reservations: make(map[string]*v1.Reservation, len(s.Reservations)),
+		Transfers:    append([]v1.SnapshotTransfer(nil), s.Transfers...),
 	}
 	for i := range s.Nodes {
 		node := s.Nodes[i]
-		state.Nodes[i] = topology.SourceNode{Name: node.Name, Labels: cloneStringMap(node.Labels), GPUs: node.GPUs}
+		state.Nodes[i] = topology.SourceNode{Name: node.Name, Labels: cloneStringMap(node.Labels), GPUs: node.GPUs, Slices: node.Slices}
 	}
 	for i := range s.Budgets {
 		state.Budgets[i] = *s.Budgets[i].DeepCopy()
@@ -150,9 +155,10 @@ func fromState(state *controllers.ClusterState) snapshot {
 		snap.Leases = append(snap.Leases, *lease.DeepCopy())
 	}
 	for _, node := range state.Nodes {
-		snap.Nodes = append(snap.Nodes, nodeSnapshot{Name: node.Name, Labels: cloneStringMap(node.Labels), GPUs: node.GPUs})
+		snap.Nodes = append(snap.Nodes, nodeSnapshot{Name: node.Name, Labels: cloneStringMap(node.Labels), GPUs: node.GPUs, Slices: node.Slices})
 	}
 	snap.Pods = append(snap.Pods, state.Pods...)
+	snap.Transfers = append(snap.Transfers, state.Transfers...)
 
 	runKeys := make([]string, 0, len(state.Runs))
 	for key := range state.Runs {
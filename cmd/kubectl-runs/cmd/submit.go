@@ -4,9 +4,15 @@ import (
 	"fmt"
 	"os"
 	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/admission"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
 	"github.com/spf13/cobra"
 	sigsyaml "sigs.k8s.io/yaml"
 )
@@ -15,6 +21,8 @@ import (
 func NewSubmitCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
 	var file string
 	var follow []string
+	var priority int32
+	var preview bool
 	cmd := &cobra.Command{
 		Use:   "submit",
 		Short: "Submit a Run manifest (YAML or JSON)",
@@ -49,11 +57,17 @@ func NewSubmitCommand(opts *RootOptions, store *StateStore, printer *Printer) *c
 				}
 				run.Spec.Follow.After = append(run.Spec.Follow.After, follow...)
 			}
+			if cmd.Flags().Changed("priority") {
+				run.Spec.Priority = priority
+			}
 			run.Default()
 			if err := run.ValidateCreate(); err != nil {
 				return err
 			}
 
+			if preview {
+				return submitPreview(cmd, opts, store, printer, &run)
+			}
 			if opts.UseLocal() {
 				return submitLocal(cmd, opts, store, printer, &run)
 			}
@@ -62,6 +76,8 @@ func NewSubmitCommand(opts *RootOptions, store *StateStore, printer *Printer) *c
 	}
 	cmd.Flags().StringVar(&file, "file", "", "Path to a Run manifest (YAML or JSON)")
 	cmd.Flags().StringSliceVar(&follow, "follow", nil, "Run name(s) this run must wait to complete before starting (repeatable)")
+	cmd.Flags().Int32Var(&priority, "priority", 0, "Requested priority, 0-9; honored up to each funding envelope's maxPriority")
+	cmd.Flags().BoolVar(&preview, "preview", false, "Print how the run would be funded now, or why it would wait, without creating it")
 	return cmd
 }
 
@@ -115,3 +131,68 @@ func submitLive(cmd *cobra.Command, opts *RootOptions, printer *Printer, run *v1
 	}
 	return printer.Print(cmd, opts, summary)
 }
+
+// submitPreview prints admission.Preview for the run against the current
+// world and creates nothing. The Run webhook warns with the same Preview, but
+// over the manager's cache and only within its deadline, so the two agree
+// only as far as the cache is current.
+func submitPreview(cmd *cobra.Command, opts *RootOptions, store *StateStore, printer *Printer, run *v1.Run) error {
+	in, err := previewInput(cmd, opts, store)
+	if err != nil {
+		return err
+	}
+	key := keys.NamespacedKey(run.Namespace, run.Name)
+	in.Run, in.Now = run, time.Now().UTC()
+	in.Runs[key] = run
+	lines := admission.Preview(in)
+	rows := make([][]string, 0, len(lines))
+	for _, line := range lines {
+		rows = append(rows, []string{key, line})
+	}
+	return printer.Print(cmd, opts, Payload{
+		Headers: []string{"Run", "Preview"},
+		Rows:    rows,
+		Raw:     map[string]interface{}{"run": key, "preview": lines},
+		Title:   "Funding preview (nothing created)",
+	})
+}
+
+// previewInput reads the world admission decides against, from the local
+// state or the cluster.
+func previewInput(cmd *cobra.Command, opts *RootOptions, store *StateStore) (admission.Input, error) {
+	var in admission.Input
+	if opts.UseLocal() {
+		state, err := store.Load(opts.StatePath)
+		if err != nil {
+			return in, err
+		}
+		in.Budgets, in.Runs, in.Leases, in.Archives = state.Budgets, state.Runs, state.Leases, state.Archives
+		in.Transfers, in.Nodes, in.Topology = state.Transfers, state.Nodes, state.Topology
+		return in, nil
+	}
+	c, err := opts.LiveClient()
+	if err != nil {
+		return in, err
+	}
+	var nodes corev1.NodeList
+	if err := c.List(cmd.Context(), &nodes); err != nil {
+		return in, fmt.Errorf("list nodes: %w", err)
+	}
+	for i := range nodes.Items {
+		if node := &nodes.Items[i]; topology.NodeUsable(node) {
+			in.Nodes = append(in.Nodes, topology.SourceNodeOf(node))
+		}
+	}
+	led, err := ledger.List(cmd.Context(), c)
+	if err != nil {
+		return in, err
+	}
+	var hierarchies v1.TopologyHierarchyList
+	if err := c.List(cmd.Context(), &hierarchies); err != nil {
+		return in, fmt.Errorf("list topology hierarchies: %w", err)
+	}
+	in.Budgets, in.Runs, in.Leases, in.Archives = led.Budgets, led.Runs, led.Leases, led.Archives
+	in.Transfers = led.Transfers
+	in.Topology = admission.HierarchyFrom(hierarchies.Items)
+	return in, nil
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// submit --preview prints the funding preview and leaves the state alone.
+func TestSubmitPreviewCreatesNothing(t *testing.T) {
+	dir := t.TempDir()
+	statePath := filepath.Join(dir, "state.json")
+	store := &StateStore{}
+	initial := &controllers.ClusterState{
+		Runs:         map[string]*v1.Run{},
+		Reservations: map[string]*v1.Reservation{},
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "team-a", Namespace: "default"},
+			Spec: v1.BudgetSpec{
+				Owner: "org:team-a",
+				Envelopes: []v1.BudgetEnvelope{{
+					Name:        "west-h100",
+					Flavor:      "H100-80GB",
+					Selector:    map[string]string{topology.LabelRegion: "us-west", topology.LabelCluster: "gpu-a"},
+					Concurrency: 4, Start: &testWindowStart, End: &testWindowEnd,
+				}},
+			},
+		}},
+		Nodes: []topology.SourceNode{
+			{Name: "node-a1", Labels: map[string]string{topology.LabelRegion: "us-west", topology.LabelCluster: "gpu-a", topology.LabelFabricDomain: "0", topology.LabelGPUFlavor: "H100-80GB"}, GPUs: 8},
+		},
+	}
+	if err := store.Save(statePath, initial); err != nil {
+		t.Fatalf("save initial state: %v", err)
+	}
+
+	for _, tc := range []struct {
+		gpus string
+		want string
+	}{
+		{"4", "would start now on H100-80GB as 4 Owned"},
+		{"8", "exceeds every envelope's concurrency"},
+	} {
+		manifest := "metadata:\n  name: preview\n  namespace: default\nspec:\n  resources:\n    gpuType: H100-80GB\n    totalGPUs: " + tc.gpus + "\n"
+		manifestPath := filepath.Join(dir, "run.yaml")
+		if err := os.WriteFile(manifestPath, []byte(manifest), 0o600); err != nil {
+			t.Fatalf("write manifest: %v", err)
+		}
+		root := NewRootCommand()
+		buf := &bytes.Buffer{}
+		root.SetOut(buf)
+		root.SetErr(&bytes.Buffer{})
+		root.SetArgs([]string{"--local", "--state", statePath, "submit", "--preview", "--file", manifestPath})
+		if err := root.Execute(); err != nil {
+			t.Fatalf("submit --preview: %v", err)
+		}
+		if !strings.Contains(buf.String(), tc.want) {
+			t.Errorf("%s GPUs: output %q, want %q", tc.gpus, buf.String(), tc.want)
+		}
+	}
+
+	reloaded, err := store.Load(statePath)
+	if err != nil {
+		t.Fatalf("reload state: %v", err)
+	}
+	if len(reloaded.Runs) != 0 || len(reloaded.Leases) != 0 {
+		t.Errorf("preview changed the state: %d runs, %d leases", len(reloaded.Runs), len(reloaded.Leases))
+	}
+}
@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/forecast"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+	"github.com/spf13/cobra"
+)
+
+// NewTimelineCommand projects a flavor's capacity forward per fabric domain.
+func NewTimelineCommand(opts *RootOptions, store *StateStore, printer *Printer) *cobra.Command {
+	var flavor string
+	var hours int
+	cmd := &cobra.Command{
+		Use:   "timeline",
+		Short: "Free vs committed GPUs of a flavor per fabric domain per hour (read-only)",
+		Long: `timeline projects the flavor's fleet forward an hour at a time. Each domain
+shows its free GPUs, the GPUs held by running work — an open lease until its
+run's ETA or time limit, or through the horizon when the run has neither — and
+the GPUs promised to waiting reservations and bookings from their start.
+Envelope windows of the flavor that open or close inside the timeline are
+marked; on a budget with spec.autoRenew the end is marked as a renewal,
+preceded by the notice.
+
+An hour identical to the one above it with nothing marked is folded into it;
+--output json carries every hour. The whole cluster is read regardless of
+--namespace. The manager serves the same projection at /timeline.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			in := forecast.TimelineInput{Flavor: flavor, From: time.Now().UTC(), Hours: hours}
+			if err := timelineClaims(cmd, opts, store, &in); err != nil {
+				return err
+			}
+			tl, err := forecast.ProjectTimeline(in)
+			if err != nil {
+				return err
+			}
+			title := fmt.Sprintf("Capacity Timeline: %s, %d hours from %s (free / running / reserved GPUs)", tl.Flavor, tl.Hours, tl.From.Format(time.RFC3339))
+			if len(tl.Unplaced) > 0 {
+				title += "\nnot drawn, no domain named: " + strings.Join(tl.Unplaced, ", ")
+			}
+			return printer.Print(cmd, opts, Payload{Headers: tl.Headers(), Rows: tl.Rows(), Raw: tl, Title: title})
+		},
+	}
+	cmd.Flags().StringVar(&flavor, "flavor", "", "GPU flavor to project, as nodes label it (gpu.flavor)")
+	cmd.Flags().IntVar(&hours, "hours", 48, fmt.Sprintf("Hours to project, at most %d", forecast.MaxTimelineHours))
+	_ = cmd.MarkFlagRequired("flavor")
+	return cmd
+}
+
+// timelineClaims reads the fleet and everything that holds or will hold it:
+// the local snapshot, or every namespace of the live cluster.
+func timelineClaims(cmd *cobra.Command, opts *RootOptions, store *StateStore, in *forecast.TimelineInput) error {
+	if opts.UseLocal() {
+		state, err := store.Load(opts.StatePath)
+		if err != nil {
+			return err
+		}
+		in.Nodes, in.Leases, in.Runs, in.Budgets = state.Nodes, state.Leases, state.Runs, state.Budgets
+		for _, res := range state.Reservations {
+			in.Reservations = append(in.Reservations, *res)
+		}
+		return nil
+	}
+	c, err := opts.LiveClient()
+	if err != nil {
+		return err
+	}
+	var nodes corev1.NodeList
+	if err := c.List(cmd.Context(), &nodes); err != nil {
+		return fmt.Errorf("list nodes: %w", err)
+	}
+	for i := range nodes.Items {
+		if node := &nodes.Items[i]; topology.NodeUsable(node) {
+			in.Nodes = append(in.Nodes, topology.SourceNodeOf(node))
+		}
+	}
+	led, err := ledger.List(cmd.Context(), c)
+	if err != nil {
+		return err
+	}
+	var reservations v1.ReservationList
+	if err := c.List(cmd.Context(), &reservations); err != nil {
+		return fmt.Errorf("list reservations: %w", err)
+	}
+	in.Leases, in.Runs, in.Budgets, in.Reservations = led.Leases, led.Runs, led.Budgets, reservations.Items
+	return nil
+}
@@ -0,0 +1,59 @@
+package plugin
+
+import (
+	"context"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	fwk "k8s.io/kube-scheduler/framework"
+	framework "k8s.io/kubernetes/pkg/scheduler/framework"
+
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+func filterNode(name, flavor string, allocatable corev1.ResourceList) fwk.NodeInfo {
+	info := framework.NewNodeInfo()
+	info.SetNode(&corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Labels: map[string]string{topology.LabelGPUFlavor: flavor}},
+		Status:     corev1.NodeStatus{Capacity: allocatable, Allocatable: allocatable},
+	})
+	return info
+}
+
+// A MIG flavor lands on nodes of the GPU it slices, and only on those that
+// advertise its profile; a whole-GPU flavor still needs the exact label.
+func TestFilterMatchesMIGFlavorsByProfileResource(t *testing.T) {
+	sliced := filterNode("m1", "a100", corev1.ResourceList{
+		"nvidia.com/gpu":         resource.MustParse("2"),
+		"nvidia.com/mig-1g.10gb": resource.MustParse("14"),
+	})
+	whole := filterNode("m2", "a100", corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("8")})
+	other := filterNode("h1", "H100-80GB", corev1.ResourceList{"nvidia.com/gpu": resource.MustParse("8")})
+
+	pod := func(flavor string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "p", Annotations: map[string]string{binder.AnnotationFlavor: flavor}}}
+	}
+	j := &JobTree{}
+	cases := []struct {
+		flavor string
+		node   fwk.NodeInfo
+		fits   bool
+	}{
+		{"a100-1g.10gb", sliced, true},
+		{"a100-1g.10gb", whole, false},
+		{"a100-1g.10gb", other, false},
+		{"a100-2g.20gb", sliced, false},
+		{"a100", sliced, true},
+		{"a100", whole, true},
+		{"a100", other, false},
+	}
+	for _, tc := range cases {
+		status := j.Filter(context.Background(), nil, pod(tc.flavor), tc.node)
+		if fits := status.IsSuccess(); fits != tc.fits {
+			t.Errorf("%s on %s: fits=%v (%v), want %v", tc.flavor, tc.node.Node().Name, fits, status, tc.fits)
+		}
+	}
+}
@@ -43,7 +43,7 @@ func TestFoldReservesCommittedCapacityWhenTheRealLeaseIsNotYetInTheSnapshot(t *t
 		claimed:     1,
 		assigned:    map[string]int{"train-pod-0": 0},
 		minted:      []bool{true},
-		pending:     pendingLeases(trainRun(), []cover.Segment{segA}, 4, now),
+		pending:     pendingLeases(trainRun(), []cover.Segment{segA}, 4, nil, now),
 		lastTouched: now,
 	}
 
@@ -79,7 +79,7 @@ func TestFoldSkipsThePhantomWhenTheRealLeaseIsInTheSnapshot(t *testing.T) {
 		decided: true, fundable: true, gpusPerPod: 4,
 		payers: []cover.Segment{segA}, claimed: 1,
 		assigned: map[string]int{"train-pod-0": 0}, minted: []bool{true},
-		pending: pendingLeases(trainRun(), []cover.Segment{segA}, 4, now), lastTouched: now,
+		pending: pendingLeases(trainRun(), []cover.Segment{segA}, 4, nil, now), lastTouched: now,
 	}
 
 	podB := gangPod()
@@ -21,13 +21,10 @@ import (
 	"github.com/davidlangworthy/jobtree/pkg/keys"
 	"github.com/davidlangworthy/jobtree/pkg/metrics"
 	"github.com/davidlangworthy/jobtree/pkg/pack"
+	"github.com/davidlangworthy/jobtree/pkg/snapshot"
 	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
 
-// gpuResource is the extended resource the fake/real device plugin advertises
-// and each workload pod requests.
-const gpuResource = corev1.ResourceName("nvidia.com/gpu")
-
 const (
 	// gangTTL bounds how long an idle gang commit lingers before the sweep drops
 	// it. Kept well above the 2m Permit timeout so a slowly-forming or
@@ -78,6 +75,10 @@ type gangCommit struct {
 	claimed    int             // distinct pods that have claimed a payer
 	assigned   map[string]int  // pod name -> payer index (idempotent across PreBind retries)
 	gpusPerPod int
+	// sizes is each payer slot's pod GPUs when the gang's pods are not all one
+	// size — a multi-role run's base gang — and nil otherwise, when every slot
+	// is gpusPerPod. claimPayer hands a pod only a slot of its own size.
+	sizes []int
 	// pending are the placeholder leases this gang has decided to mint but whose
 	// real leases may not yet be in the API; they are folded into other gangs'
 	// funding checks to close the decide→mint overspend window. minted[i] is set
@@ -340,7 +341,13 @@ func (m *gangManager) decide(ctx context.Context, pod *corev1.Pod) (fundable boo
 		metrics.ObserveDecideLatency("unfundable", m.clock().Sub(start))
 		return false, g.reason
 	}
-	payers, err := admission.PerPodPayer(coverPlan, g.gpusPerPod)
+	var payers []cover.Segment
+	if len(run.Spec.Roles) > 1 && !isGrowCohort(pod) {
+		g.sizes = admission.GangPodSizes(run)
+		payers, err = admission.PerPodPayers(coverPlan, g.sizes)
+	} else {
+		payers, err = admission.PerPodPayer(coverPlan, g.gpusPerPod)
+	}
 	if err != nil {
 		g.reason = err.Error()
 		g.refusal = classifyRefusal(err)
@@ -349,7 +356,7 @@ func (m *gangManager) decide(ctx context.Context, pod *corev1.Pod) (fundable boo
 	}
 	g.payers = payers
 	g.fundable = true
-	g.pending = pendingLeases(run, payers, g.gpusPerPod, m.clock())
+	g.pending = pendingLeases(run, payers, g.gpusPerPod, g.sizes, m.clock())
 	g.minted = make([]bool, len(g.pending))
 	g.lastTouched = m.clock()
 	metrics.ObserveDecideLatency("fundable", m.clock().Sub(start))
@@ -370,18 +377,50 @@ func (m *gangManager) claimPayer(pod *corev1.Pod) (cover.Segment, int, bool) {
 	}
 	g.lastTouched = m.clock()
 	if idx, ok := g.assigned[pod.Name]; ok {
-		return g.payers[idx], g.gpusPerPod, true
+		return g.payers[idx], g.slotGPUs(idx), true
 	}
 	if g.claimed >= len(g.payers) {
 		return cover.Segment{}, 0, false
 	}
 	idx := g.claimed
+	if g.sizes != nil {
+		// A mixed-size gang is claimed in whatever order its pods PreBind, so
+		// the next slot is the first free one of this pod's size, not simply the
+		// next index.
+		idx = g.freeSlotOfSize(podInt(pod, binder.AnnotationGPUs, 1))
+		if idx < 0 {
+			return cover.Segment{}, 0, false
+		}
+	}
 	g.claimed++
 	if g.assigned == nil {
 		g.assigned = map[string]int{}
 	}
 	g.assigned[pod.Name] = idx
-	return g.payers[idx], g.gpusPerPod, true
+	return g.payers[idx], g.slotGPUs(idx), true
+}
+
+// slotGPUs is the pod size payer slot idx funds.
+func (g *gangCommit) slotGPUs(idx int) int {
+	if g.sizes != nil && idx < len(g.sizes) {
+		return g.sizes[idx]
+	}
+	return g.gpusPerPod
+}
+
+// freeSlotOfSize is the lowest unassigned payer slot sized for a gpus-GPU pod,
+// or -1 when every such slot is taken.
+func (g *gangCommit) freeSlotOfSize(gpus int) int {
+	taken := make(map[int]bool, len(g.assigned))
+	for _, idx := range g.assigned {
+		taken[idx] = true
+	}
+	for idx, size := range g.sizes {
+		if size == gpus && !taken[idx] {
+			return idx
+		}
+	}
+	return -1
 }
 
 // verdict reports a gang's decided funding outcome without triggering a
@@ -424,21 +463,31 @@ func (m *gangManager) Reconstruct(ctx context.Context) error {
 	if err := m.reader.List(ctx, &leaseList); err != nil {
 		return fmt.Errorf("list leases: %w", err)
 	}
+	var archiveList v1.LeaseArchiveList
+	if err := m.reader.List(ctx, &archiveList); err != nil {
+		return fmt.Errorf("list lease archives: %w", err)
+	}
 	var nodeList corev1.NodeList
 	if err := m.reader.List(ctx, &nodeList); err != nil {
 		return fmt.Errorf("list nodes: %w", err)
 	}
+	var hierarchyList v1.TopologyHierarchyList
+	if err := m.reader.List(ctx, &hierarchyList); err != nil {
+		return fmt.Errorf("list topology hierarchies: %w", err)
+	}
+	var snapshotList v1.QuotaSnapshotList
+	if err := m.reader.List(ctx, &snapshotList); err != nil {
+		return fmt.Errorf("list quota snapshots: %w", err)
+	}
+	transfers := snapshot.PublishedTransfers(snapshotList.Items)
+	hierarchy := admission.HierarchyFrom(hierarchyList.Items)
 	var nodes []topology.SourceNode
 	for i := range nodeList.Items {
 		n := &nodeList.Items[i]
 		if !schedulableNode(n) {
 			continue
 		}
-		gpus := 0
-		if qty, ok := n.Status.Capacity[gpuResource]; ok {
-			gpus = int(qty.Value())
-		}
-		nodes = append(nodes, topology.SourceNode{Name: n.Name, Labels: n.Labels, GPUs: gpus})
+		nodes = append(nodes, topology.SourceNodeOf(n))
 	}
 
 	// Group the OPEN, ACTIVE leases by gang key. Spares are not gang-active-width
@@ -477,8 +526,12 @@ func (m *gangManager) Reconstruct(ctx context.Context) error {
 			gpusPerPod = 1
 		}
 		g := &gangCommit{decided: true, fundable: true, lastTouched: m.clock(), gpusPerPod: gpusPerPod, assigned: map[string]int{}}
+		multiRole := cohortOfGang[key] == "0" && len(run.Spec.Roles) > 1
 		// The already-minted members, from each lease's own provenance.
 		for _, l := range leases {
+			if multiRole {
+				g.sizes = append(g.sizes, len(l.Spec.Slice.Nodes))
+			}
 			idx := len(g.payers)
 			g.payers = append(g.payers, cover.Segment{
 				Owner:        l.Spec.Owner,
@@ -494,12 +547,17 @@ func (m *gangManager) Reconstruct(ctx context.Context) error {
 		g.claimed = len(g.payers)
 
 		// Delta-fund the un-minted remainder of the BASE gang only.
-		if cohortOfGang[key] == "0" {
+		if multiRole {
+			m.reconstructRoleRemainder(g, run, admission.Input{
+				Run: run, Budgets: budgetList.Items, Runs: runs, Leases: leaseList.Items,
+				Archives: archiveList.Items, Transfers: transfers, Nodes: nodes, Topology: hierarchy, Now: m.clock(),
+			})
+		} else if cohortOfGang[key] == "0" {
 			expected := int(run.Spec.Resources.TotalGPUs) / gpusPerPod
 			if delta := expected - g.claimed; delta > 0 {
 				world := admission.Input{
 					Run: run, Budgets: budgetList.Items, Runs: runs, Leases: leaseList.Items,
-					Nodes: nodes, Now: m.clock(), Quantity: int32(delta * gpusPerPod),
+					Archives: archiveList.Items, Transfers: transfers, Nodes: nodes, Topology: hierarchy, Now: m.clock(), Quantity: int32(delta * gpusPerPod),
 				}
 				if _, coverPlan, _, err := admission.Feasible(world); err == nil {
 					if deltaPayers, perr := admission.PerPodPayer(coverPlan, gpusPerPod); perr == nil {
@@ -516,7 +574,7 @@ func (m *gangManager) Reconstruct(ctx context.Context) error {
 						// gang take the survivor's capacity. Full-width pending: the minted
 						// slots' phantoms are skipped (their real lease is present), the delta
 						// slots' (no assigned pod) are folded.
-						g.pending = pendingLeases(run, g.payers, gpusPerPod, m.clock())
+						g.pending = pendingLeases(run, g.payers, gpusPerPod, nil, m.clock())
 					}
 				}
 				// If the delta cannot fund now (capacity gone / budget tightened), the
@@ -888,10 +946,22 @@ func (m *gangManager) loadWorld(ctx context.Context, pod *corev1.Pod) (admission
 	if err := m.reader.List(ctx, &leaseList); err != nil {
 		return admission.Input{}, nil, fmt.Errorf("list leases: %w", err)
 	}
+	var archiveList v1.LeaseArchiveList
+	if err := m.reader.List(ctx, &archiveList); err != nil {
+		return admission.Input{}, nil, fmt.Errorf("list lease archives: %w", err)
+	}
 	var nodeList corev1.NodeList
 	if err := m.reader.List(ctx, &nodeList); err != nil {
 		return admission.Input{}, nil, fmt.Errorf("list nodes: %w", err)
 	}
+	var hierarchyList v1.TopologyHierarchyList
+	if err := m.reader.List(ctx, &hierarchyList); err != nil {
+		return admission.Input{}, nil, fmt.Errorf("list topology hierarchies: %w", err)
+	}
+	var snapshotList v1.QuotaSnapshotList
+	if err := m.reader.List(ctx, &snapshotList); err != nil {
+		return admission.Input{}, nil, fmt.Errorf("list quota snapshots: %w", err)
+	}
 
 	var nodes []topology.SourceNode
 	for i := range nodeList.Items {
@@ -899,33 +969,79 @@ func (m *gangManager) loadWorld(ctx context.Context, pod *corev1.Pod) (admission
 		if !schedulableNode(n) {
 			continue
 		}
-		gpus := 0
-		if qty, ok := n.Status.Capacity[gpuResource]; ok {
-			gpus = int(qty.Value())
-		}
-		nodes = append(nodes, topology.SourceNode{Name: n.Name, Labels: n.Labels, GPUs: gpus})
+		nodes = append(nodes, topology.SourceNodeOf(n))
 	}
 
 	return admission.Input{
-		Run:     run,
-		Budgets: budgetList.Items,
-		Runs:    runs,
-		Leases:  leaseList.Items,
-		Nodes:   nodes,
-		Now:     m.clock(),
-		Reason:  pod.Annotations[binder.AnnotationLeaseReason],
+		Run:       run,
+		Budgets:   budgetList.Items,
+		Runs:      runs,
+		Leases:    leaseList.Items,
+		Archives:  archiveList.Items,
+		Transfers: snapshot.PublishedTransfers(snapshotList.Items),
+		Nodes:     nodes,
+		Topology:  admission.HierarchyFrom(hierarchyList.Items),
+		Now:       m.clock(),
+		Reason:    pod.Annotations[binder.AnnotationLeaseReason],
+		Flavor:    pod.Annotations[binder.AnnotationFlavor],
 	}, run, nil
 }
 
+// reconstructRoleRemainder is Reconstruct's delta-funding for a multi-role base
+// gang, whose pods differ in size by role: the un-minted remainder is the
+// gang's pod sizes less those the surviving leases already hold, funded as one
+// delta and cut into pods by PerPodPayers. As in the uniform case, a remainder
+// that cannot fund now is left for the controller's normal re-admission.
+func (m *gangManager) reconstructRoleRemainder(g *gangCommit, run *v1.Run, world admission.Input) {
+	held := map[int]int{}
+	for _, size := range g.sizes {
+		held[size]++
+	}
+	var remaining []int
+	quantity := 0
+	for _, size := range admission.GangPodSizes(run) {
+		if held[size] > 0 {
+			held[size]--
+			continue
+		}
+		remaining = append(remaining, size)
+		quantity += size
+	}
+	if len(remaining) == 0 {
+		return
+	}
+	world.Quantity = int32(quantity)
+	_, coverPlan, _, err := admission.Feasible(world)
+	if err != nil {
+		return
+	}
+	deltaPayers, err := admission.PerPodPayers(coverPlan, remaining)
+	if err != nil {
+		return
+	}
+	for i, seg := range deltaPayers {
+		g.payers = append(g.payers, seg)
+		g.sizes = append(g.sizes, remaining[i])
+		g.minted = append(g.minted, false)
+	}
+	// Full-width pending, for the same slot-alignment reason as the uniform path.
+	g.pending = pendingLeases(run, g.payers, g.gpusPerPod, g.sizes, m.clock())
+}
+
 // pendingLeases builds placeholder leases (no bound node yet) that represent a
 // decided gang's funding claim, so concurrent gangs' checks account for it
 // before the real per-pod leases exist. Only the payer and GPU count matter for
 // the cross-gang funding math; nodes are filled with the payer envelope as a
-// stand-in and never persisted.
-func pendingLeases(run *v1.Run, payers []cover.Segment, gpusPerPod int, now time.Time) []v1.GPULease {
+// stand-in and never persisted. sizes, when non-nil, gives each slot's GPUs in
+// place of gpusPerPod (a multi-role gang).
+func pendingLeases(run *v1.Run, payers []cover.Segment, gpusPerPod int, sizes []int, now time.Time) []v1.GPULease {
 	out := make([]v1.GPULease, 0, len(payers))
 	for i, seg := range payers {
-		out = append(out, admission.PodLease(run, seg, fmt.Sprintf("pending-%s-%d", run.Name, i), gpusPerPod, "", now, "Start"))
+		gpus := gpusPerPod
+		if sizes != nil {
+			gpus = sizes[i]
+		}
+		out = append(out, admission.PodLease(run, seg, fmt.Sprintf("pending-%s-%d", run.Name, i), gpus, "", now, "Start"))
 	}
 	return out
 }
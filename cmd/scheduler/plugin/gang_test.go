@@ -21,6 +21,10 @@ import (
 	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
 
+// gpuResource is the extended resource the fake/real device plugin advertises
+// and each whole-GPU workload pod requests.
+const gpuResource = corev1.ResourceName(topology.GPUResource)
+
 func testScheme(t *testing.T) *apiruntime.Scheme {
 	t.Helper()
 	s := apiruntime.NewScheme()
@@ -595,3 +599,38 @@ func TestGangSweepDropsIdleGangOnly(t *testing.T) {
 		t.Errorf("sweep should reap a gang idle past gangTTL")
 	}
 }
+
+// A multi-role gang is funded whole, then each pod claims a payer slot of its own
+// size in whatever order it PreBinds: the learner is not handed an actor's 1-GPU
+// slot because it happened to bind first.
+func TestGangMultiRoleClaimsSlotsBySize(t *testing.T) {
+	run := trainRun()
+	run.Spec.Resources.TotalGPUs = 8
+	run.Spec.Roles = []v1.RunRole{
+		{Name: "actor", Width: 4, GPUsPerPod: 1},
+		{Name: "learner", Width: 1, GPUsPerPod: 4},
+	}
+	m := newManager(t, run, teamBudget(8), gpuNode("node-a", 8))
+	rolePod := func(name, role, gpus string) *corev1.Pod {
+		return &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default", Name: name,
+			Labels:      map[string]string{binder.LabelRunName: "train", binder.LabelRunRole: binder.RoleActive, binder.LabelRoleName: role},
+			Annotations: map[string]string{binder.AnnotationGPUs: gpus, binder.AnnotationExpectedWidth: "5", binder.AnnotationFlavor: "H100-80GB"},
+		}}
+	}
+	learner := rolePod("train-learner-0", "learner", "4")
+	if fundable, reason := m.decide(context.Background(), learner); !fundable {
+		t.Fatalf("expected the 8-GPU two-role gang to fund: %s", reason)
+	}
+	if _, gpus, ok := m.claimPayer(learner); !ok || gpus != 4 {
+		t.Fatalf("learner claim = (%d, %v), want a 4-GPU slot", gpus, ok)
+	}
+	for _, name := range []string{"train-actor-0", "train-actor-1", "train-actor-2", "train-actor-3"} {
+		if _, gpus, ok := m.claimPayer(rolePod(name, "actor", "1")); !ok || gpus != 1 {
+			t.Fatalf("%s claim = (%d, %v), want a 1-GPU slot", name, gpus, ok)
+		}
+	}
+	if _, _, ok := m.claimPayer(rolePod("train-actor-4", "actor", "1")); ok {
+		t.Errorf("a fifth actor must not find a slot: the gang funded four")
+	}
+}
@@ -112,10 +112,12 @@ func New(ctx context.Context, _ apiruntime.Object, h fwk.Handle) (fwk.Plugin, er
 // Name returns the plugin name.
 func (j *JobTree) Name() string { return Name }
 
-// Filter rejects nodes whose GPU flavor does not match the pod's run flavor.
-// Whether the node has enough free GPUs is left to the default NodeResourcesFit
-// plugin (the pod carries a real nvidia.com/gpu request); topology contiguity
-// comes from the controller's advisory nodeAffinity honored by NodeAffinity.
+// Filter rejects nodes whose GPU flavor does not match the pod's run flavor,
+// and, for a MIG flavor, nodes that advertise none of its profile's
+// nvidia.com/mig-<profile> resource. Whether the node has enough free GPUs or
+// slices is left to the default NodeResourcesFit plugin (the pod carries a real
+// request for the flavor's resource); topology contiguity comes from the
+// controller's advisory nodeAffinity honored by NodeAffinity.
 func (j *JobTree) Filter(_ context.Context, _ fwk.CycleState, pod *corev1.Pod, nodeInfo fwk.NodeInfo) *fwk.Status {
 	want := pod.Annotations[binder.AnnotationFlavor]
 	if want == "" {
@@ -125,10 +127,16 @@ func (j *JobTree) Filter(_ context.Context, _ fwk.CycleState, pod *corev1.Pod, n
 	if node == nil {
 		return fwk.NewStatus(fwk.Error, "jobtree: nil node in Filter")
 	}
-	if node.Labels[topology.LabelGPUFlavor] != want {
+	if !topology.ServesFlavor(node.Labels, want) {
 		return fwk.NewStatus(fwk.UnschedulableAndUnresolvable,
 			fmt.Sprintf("jobtree: node flavor %q != run flavor %q", node.Labels[topology.LabelGPUFlavor], want))
 	}
+	if resource := topology.ResourceName(want); resource != topology.GPUResource {
+		if qty, ok := node.Status.Allocatable[corev1.ResourceName(resource)]; !ok || qty.Value() <= 0 {
+			return fwk.NewStatus(fwk.UnschedulableAndUnresolvable,
+				fmt.Sprintf("jobtree: node advertises no %s for run flavor %q", resource, want))
+		}
+	}
 	return nil
 }
 
@@ -346,6 +354,7 @@ func (j *JobTree) PreBind(ctx context.Context, _ fwk.CycleState, pod *corev1.Pod
 	// belongs to and the exact pod it funds, so a scheduler restart can rebuild gang
 	// membership from the leases alone rather than string-parsing the lease name.
 	admission.StampGangIdentity(&lease, cohortOf(pod), pod.Name)
+	admission.StampRoleName(&lease, pod.Labels[binder.LabelRoleName])
 	if err := j.client.Create(ctx, &lease); err != nil {
 		if !apierrors.IsAlreadyExists(err) {
 			return fwk.NewStatus(fwk.Error, fmt.Sprintf("jobtree: mint lease for %s: %v", pod.Name, err))
@@ -495,13 +504,17 @@ func (j *JobTree) reportFlavorMismatch(ctx context.Context, pod *corev1.Pod) {
 		return // narration must never fail scheduling
 	}
 	for i := range nodes.Items {
-		if nodes.Items[i].Labels[topology.LabelGPUFlavor] == want {
+		if topology.ServesFlavor(nodes.Items[i].Labels, want) {
 			return
 		}
 	}
+	label := want
+	if base, _, ok := topology.MIGProfile(want); ok {
+		label = base // a MIG flavor slices nodes of its base flavor
+	}
 	j.emit(pod, corev1.EventTypeWarning, ReasonFlavorMismatch, "Filter",
 		"no node in this cluster is labelled %s=%s; the run asks for a GPU flavor that is not here",
-		topology.LabelGPUFlavor, want)
+		topology.LabelGPUFlavor, label)
 }
 
 // ErrNoPlacementGroup is returned when a pod reaching PreBind carries no placement
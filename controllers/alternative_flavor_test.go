@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// A run that would take H100 or A100. The fleet has one 4-GPU H100 node and
+// one 8-GPU A100 node, and the team an envelope of each.
+
+func flavorNode(name, flavor string, gpus int) topology.SourceNode {
+	return topology.SourceNode{Name: name, GPUs: gpus, Labels: map[string]string{
+		topology.LabelRegion: "us-west", topology.LabelCluster: "cluster-a",
+		topology.LabelFabricDomain: "island-" + name, topology.LabelGPUFlavor: flavor,
+	}}
+}
+
+func twoFlavorState(h100Start *v1.Time) *ClusterState {
+	policy := &v1.PreActivationPolicy{AllowReservations: true}
+	return &ClusterState{
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec: v1.BudgetSpec{Owner: "org:ai:team", Envelopes: []v1.BudgetEnvelope{
+				{Name: "west-h100", Flavor: "H100-80GB", Concurrency: 16, Start: h100Start, End: &testWindowEnd, PreActivation: policy},
+				{Name: "west-a100", Flavor: "A100-80GB", Concurrency: 16, Start: &testWindowStart, End: &testWindowEnd},
+			}},
+		}},
+		Nodes: []topology.SourceNode{flavorNode("h1", "H100-80GB", 4), flavorNode("a1", "A100-80GB", 8)},
+		Runs: map[string]*v1.Run{"default/train": {
+			ObjectMeta: v1.ObjectMeta{Name: "train", Namespace: "default"},
+			Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8,
+				Alternatives: []v1.FlavorAlternative{{GPUType: "A100-80GB"}}}},
+		}},
+	}
+}
+
+func TestRunAdmitsOnTheFirstFlavorThatFitsAndFunds(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	state := twoFlavorState(&testWindowStart)
+	controller := NewRunController(state, runClock{now: now})
+	if err := controller.Reconcile("default", "train"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	run := state.Runs["default/train"]
+	if run.Status.Flavor != "A100-80GB" {
+		t.Fatalf("status.flavor = %q, want the A100 alternative while H100 is too small", run.Status.Flavor)
+	}
+	if got := activeIntentPods(state, "default", "train"); got != 8 {
+		t.Fatalf("active intent pods = %d, want 8", got)
+	}
+	if !strings.Contains(run.Status.Message, "alternative flavor A100-80GB") {
+		t.Errorf("message %q does not say the run took an alternative", run.Status.Message)
+	}
+	if len(state.Reservations) != 0 {
+		t.Errorf("an admittable run reserved %d places", len(state.Reservations))
+	}
+
+	// H100 grows while the A100 pods are out: they carry their flavor, so the
+	// run stays on it.
+	state.Nodes[0].GPUs = 8
+	if err := controller.Reconcile("default", "train"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if run.Status.Flavor != "A100-80GB" {
+		t.Fatalf("status.flavor moved to %q with the A100 pods still out", run.Status.Flavor)
+	}
+}
+
+func TestRunReservesOnTheFlavorThatStartsItSoonest(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	nextWeek := v1.NewTime(now.Add(7 * 24 * time.Hour))
+	state := twoFlavorState(&nextWeek)
+	state.Nodes[0].GPUs = 8
+	state.Nodes[1].GPUs = 4 // A100 is short now, but its envelope is open
+
+	controller := NewRunController(state, runClock{now: now})
+	if err := controller.Reconcile("default", "train"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(state.Reservations) != 1 {
+		t.Fatalf("expected one reservation, got %d", len(state.Reservations))
+	}
+	for _, reservation := range state.Reservations {
+		if reservation.Spec.Flavor != "A100-80GB" || reservation.Spec.PayingEnvelope != "west-a100" {
+			t.Fatalf("reserved %s via %s, want A100, which starts before the H100 window", reservation.Spec.Flavor, reservation.Spec.PayingEnvelope)
+		}
+		if !reservation.Spec.EarliestStart.Time.Before(nextWeek.Time) {
+			t.Fatalf("earliest start %s is not before the H100 window", reservation.Spec.EarliestStart.Time)
+		}
+	}
+	if run := state.Runs["default/train"]; run.Status.Flavor != "A100-80GB" {
+		t.Errorf("status.flavor = %q, want the reservation's A100", run.Status.Flavor)
+	}
+}
+
+// On an alternative with widthPercent 200, a malleable run's ceiling doubles,
+// on the step grid; its floor and base gang do not move.
+func TestAlternativeFlavorScalesTheElasticCeiling(t *testing.T) {
+	double := int32(200)
+	run := &v1.Run{Spec: v1.RunSpec{
+		Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8,
+			Alternatives: []v1.FlavorAlternative{{GPUType: "A100-80GB", WidthPercent: &double}}},
+		Malleable: &v1.RunMalleability{MinTotalGPUs: 8, MaxTotalGPUs: 20, StepGPUs: 4, DesiredTotalGPUs: int32Ptr(12)},
+	}}
+	if w := summarizeRunWidth(run, nil); w.Max != 20 || w.Desired != 12 {
+		t.Fatalf("on gpuType: max %d desired %d, want the spec's 20 and 12", w.Max, w.Desired)
+	}
+	run.Status.Flavor = "A100-80GB"
+	if w := summarizeRunWidth(run, nil); w.Min != 8 || w.Max != 40 || w.Desired != 24 {
+		t.Fatalf("on A100: min %d max %d desired %d, want 8, 40 and 24", w.Min, w.Max, w.Desired)
+	}
+	half := int32(50)
+	run.Spec.Resources.Alternatives[0].WidthPercent = &half
+	if got := scaledWidth(run, 20); got != 8 {
+		t.Errorf("scaled 20 by half to %d; 10 is off the step grid above 8, want 8", got)
+	}
+}
@@ -0,0 +1,275 @@
+package controllers
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/cover"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/metrics"
+	"github.com/davidlangworthy/jobtree/pkg/resolver"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// heldBooking returns the run's booking reservation while it is still waiting
+// to activate.
+func (c *RunController) heldBooking(run *v1.Run) *v1.Reservation {
+	if run.Status.PendingReservation == nil {
+		return nil
+	}
+	res := c.State.Reservations[keys.NamespacedKey(run.Namespace, *run.Status.PendingReservation)]
+	if res == nil || res.Spec.Booking == nil || !reservationWaiting(res) {
+		return nil
+	}
+	return res
+}
+
+// reservationWaiting mirrors ActivateReservations' state filter: the states a
+// reservation can still activate from.
+func reservationWaiting(res *v1.Reservation) bool {
+	switch res.Status.State {
+	case "", "Pending", "BlockedFunding":
+		return true
+	}
+	return false
+}
+
+// bookRun checks a run's spec.schedule once, on each of its flavors in order,
+// and holds the first that passes as a booking reservation at the booked
+// start. A booking no flavor can have fails the run as BookingRejected with
+// every flavor's reason.
+func (c *RunController) bookRun(run *v1.Run, now time.Time) {
+	var refusals []string
+	for _, flavor := range run.Spec.Resources.Flavors() {
+		reservation, err := c.planBooking(run, flavor, now)
+		if err != nil {
+			refusals = append(refusals, fmt.Sprintf("%s: %v", flavor, err))
+			continue
+		}
+		key := keys.NamespacedKey(run.Namespace, reservation.Name)
+		c.State.Reservations[key] = reservation
+		metrics.SetReservationBacklog(key, flavor, reservation.Spec.EarliestStart.Sub(now).Seconds())
+
+		run.Status.Flavor = flavor
+		earliest := reservation.Spec.EarliestStart
+		run.Status.PendingReservation = ptrString(reservation.Name)
+		run.Status.EarliestStart = &earliest
+		msg := fmt.Sprintf("booked %d GPUs of %s from %s", run.Spec.Resources.TotalGPUs, flavor, earliest.UTC().Format(time.RFC3339))
+		if until := run.Spec.Schedule.Until; until != nil {
+			msg += " until " + until.UTC().Format(time.RFC3339)
+		}
+		setState(run, v1.RunStateBooked, msg)
+		c.emit(run, EventTypeNormal, "Booked", msg)
+		return
+	}
+	msg := "booking rejected: " + strings.Join(refusals, "; ")
+	c.endPreAdmission(run, v1.RunStateBookingRejected, msg)
+	c.emit(run, EventTypeWarning, "BookingRejected", msg)
+}
+
+// planBooking checks one flavor of a booking and returns the reservation that
+// holds it.
+//
+// Capacity is checked against the fleet as it will be, not as it is: the runs
+// holding GPUs today are not the booking's concern — unfunded ones are drained
+// ahead of the start and funded ones are outranked at activation, as for any
+// reservation. What the booking cannot take is capacity another booking
+// already holds in an overlapping window, so those bookings' nodes are counted
+// as used, whole. Funding is checked at the booked start (and at its end, so
+// an envelope closing inside the window refuses it) against an evaluation with
+// no open leases, for the run's width plus the owner's other overlapping
+// bookings of the flavor, which the same envelopes fund first.
+func (c *RunController) planBooking(run *v1.Run, flavor string, now time.Time) (*v1.Reservation, error) {
+	sched := run.Spec.Schedule
+	start := sched.StartAfter.Time
+	ev := c.evaluate(now)
+	owner := ev.OwnerOf(run.Namespace)
+	if owner == "" {
+		return nil, fmt.Errorf("namespace %q has no funding principal", run.Namespace)
+	}
+
+	units := map[string]int{}
+	for _, node := range c.State.Nodes {
+		units[node.Name] = node.Units(flavor)
+	}
+	_, profile, _ := topology.MIGProfile(flavor)
+	usage := map[string]int{}
+	committed := int32(0)
+	var holders []string
+	for key, other := range c.State.Reservations {
+		if !reservationWaiting(other) || !other.BookingOverlaps(start, sched.Until) {
+			continue
+		}
+		if other.Spec.RunRef.Name == run.Name && other.Spec.RunRef.Namespace == run.Namespace {
+			continue
+		}
+		otherRun := c.State.Runs[keys.NamespacedKey(other.Spec.RunRef.Namespace, other.Spec.RunRef.Name)]
+		if otherRun == nil || reservationFlavor(other, otherRun) != flavor {
+			continue
+		}
+		for _, node := range other.Spec.IntendedSlice.Nodes {
+			usage[topology.UsageKey(node, profile)] = units[node]
+		}
+		holders = append(holders, key)
+		if ev.OwnerOf(otherRun.Namespace) == owner {
+			committed += otherRun.Spec.Resources.TotalGPUs
+		}
+	}
+	snapshot, err := topology.BuildSnapshot(c.State.Nodes, usage, flavor, c.State.Topology)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := planPlacement(run, snapshot)
+	if err != nil {
+		if len(holders) > 0 {
+			sort.Strings(holders)
+			return nil, fmt.Errorf("the window's capacity is held by booking(s) %s: %v", strings.Join(holders, ", "), err)
+		}
+		return nil, err
+	}
+
+	instants := []time.Time{start}
+	if sched.Until != nil {
+		instants = append(instants, sched.Until.Time.Add(-time.Second))
+	}
+	var coverPlan cover.Plan
+	for i, at := range instants {
+		future := funding.Evaluate(funding.Input{
+			Budgets:   c.State.Budgets,
+			Runs:      c.State.Runs,
+			Now:       at,
+			Period:    c.Period,
+			Transfers: c.State.Transfers,
+		})
+		request := cover.Request{
+			Owner:       owner,
+			Flavor:      flavor,
+			Quantity:    run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, &plan) + committed,
+			Location:    deriveLocation(plan),
+			Now:         at,
+			Priority:    run.Spec.Priority,
+			Admitted:    run.CreationTimestamp.Time,
+			RunKey:      keys.NamespacedKey(run.Namespace, run.Name),
+			AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow,
+		}
+		if run.Spec.Funding != nil {
+			request.Sponsors = append(request.Sponsors, run.Spec.Funding.Sponsors...)
+			request.MaxBorrowGPUs = run.Spec.Funding.MaxBorrowGPUs
+		}
+		p, err := cover.NewInventory(future).Plan(request)
+		if err != nil {
+			what := "the run"
+			if committed > 0 {
+				what = fmt.Sprintf("the run and %d GPUs of the owner's other bookings", committed)
+			}
+			return nil, fmt.Errorf("%s cannot be funded at %s: %v", what, at.UTC().Format(time.RFC3339), err)
+		}
+		if i == 0 {
+			coverPlan = p
+		}
+	}
+
+	slice := v1.IntendedSlice{Domain: deriveLocation(plan)}
+	seen := map[string]bool{}
+	for _, group := range plan.Groups {
+		for _, alloc := range group.NodePlacements {
+			if !seen[alloc.Node] {
+				seen[alloc.Node] = true
+				slice.Nodes = append(slice.Nodes, alloc.Node)
+			}
+		}
+	}
+	sort.Strings(slice.Nodes)
+
+	drainFrom := start.Add(-bookingLead(sched))
+	if drainFrom.Before(now) {
+		drainFrom = now
+	}
+	var until *v1.Time
+	if sched.Until != nil {
+		until = sched.Until.DeepCopy()
+	}
+	countdown := int64(start.Sub(now).Seconds())
+	return &v1.Reservation{
+		ObjectMeta: v1.ObjectMeta{Name: run.Name + "-booking", Namespace: run.Namespace},
+		Spec: v1.ReservationSpec{
+			RunRef:         v1.RunReference{Name: run.Name, Namespace: run.Namespace},
+			IntendedSlice:  slice,
+			PayingEnvelope: coverPlan.Segments[0].EnvelopeName,
+			EarliestStart:  v1.NewTime(start),
+			Flavor:         flavor,
+			Booking:        &v1.ReservationBooking{DrainFrom: v1.NewTime(drainFrom), Until: until},
+		},
+		Status: v1.ReservationStatus{
+			State:            "Pending",
+			Reason:           fmt.Sprintf("booked; draining unfunded work from %s", drainFrom.UTC().Format(time.RFC3339)),
+			CountdownSeconds: &countdown,
+		},
+	}, nil
+}
+
+// bookingLead is the schedule's drain lead, defaulted.
+func bookingLead(sched *v1.RunSchedule) time.Duration {
+	if sched.DrainLead != nil && sched.DrainLead.Duration > 0 {
+		return sched.DrainLead.Duration
+	}
+	return v1.DefaultBookingDrainLead
+}
+
+// drainAheadOfBooking frees a booking's domain of unfunded work in step with
+// the clock: the share of the booked width that must be free grows linearly
+// from nothing at drainFrom to all of it at the start, and each pass reclaims
+// only what is short of that share. Funded work is never cut here; it is
+// outranked, if at all, by the activation's own resolver pass at the start.
+// Draining gradually rather than at the start keeps the opportunistic work
+// running as long as the booking can spare it, and spreads the cuts over the
+// lead instead of landing them all on one tick.
+func (c *RunController) drainAheadOfBooking(reservation *v1.Reservation, now time.Time) error {
+	run := c.State.Runs[keys.NamespacedKey(reservation.Spec.RunRef.Namespace, reservation.Spec.RunRef.Name)]
+	if run == nil {
+		return nil
+	}
+	from, start := reservation.Spec.Booking.DrainFrom.Time, reservation.Spec.EarliestStart.Time
+	needed := int(run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, nil))
+	target := needed
+	if lead := start.Sub(from); lead > 0 && now.Before(start) {
+		elapsed := now.Sub(from)
+		target = int((int64(needed)*int64(elapsed) + int64(lead) - 1) / int64(lead))
+	}
+	flavor := reservationFlavor(reservation, run)
+	snapshot, err := topology.BuildSnapshot(c.State.Nodes, computeUsage(c.State.Leases, now), flavor, c.State.Topology)
+	if err != nil {
+		return err
+	}
+	scope := reservation.Spec.IntendedSlice.Domain
+	free := totalFreeInScope(snapshot, scope)
+	if free >= target {
+		return nil
+	}
+	resolution, err := resolver.Resolve(resolver.Input{
+		Deficit:      target - free,
+		Flavor:       flavor,
+		Scope:        scope,
+		SeedSource:   reservation.Name,
+		Now:          now,
+		Nodes:        c.State.Nodes,
+		Leases:       activeLeasePointers(c.State.Leases),
+		Runs:         c.State.Runs,
+		Evaluation:   c.evaluate(now),
+		OnlyUnfunded: true,
+	})
+	if err != nil {
+		return err
+	}
+	c.applyResolution(resolution, now)
+	freed := 0
+	for _, action := range resolution.Actions {
+		freed += action.GPUs
+	}
+	reservation.Status.Reason = fmt.Sprintf("draining the booked slice: %d of %d GPUs free, %d due by now", free+freed, needed, target)
+	return nil
+}
@@ -0,0 +1,170 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+func bookedRun(name string, gpus int32, start, until time.Time, now time.Time) *v1.Run {
+	u := v1.NewTime(until)
+	return &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default", CreationTimestamp: v1.NewTime(now)},
+		Spec: v1.RunSpec{
+			Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: gpus},
+			Schedule: &v1.RunSchedule{
+				StartAfter: v1.NewTime(start),
+				Until:      &u,
+				DrainLead:  &metav1.Duration{Duration: time.Hour},
+			},
+		},
+	}
+}
+
+// A booking is checked when it is made: one that fits is held as a booking
+// reservation at its start, one that overlaps a booking holding the capacity
+// is rejected with the reason, and one after that booking's window is free to
+// take the same GPUs.
+func TestBookingIsCheckedAgainstOverlappingBookings(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	start, until := now.Add(24*time.Hour), now.Add(36*time.Hour)
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+		Runs: map[string]*v1.Run{
+			"default/demo":  bookedRun("demo", 8, start, until, now),
+			"default/clash": bookedRun("clash", 4, start.Add(6*time.Hour), until.Add(6*time.Hour), now),
+			"default/later": bookedRun("later", 8, until, until.Add(12*time.Hour), now),
+		},
+	}
+	c := NewRunController(state, runClock{now: now})
+	for _, name := range []string{"demo", "clash", "later"} {
+		if err := c.Reconcile("default", name); err != nil {
+			t.Fatalf("reconcile %s: %v", name, err)
+		}
+	}
+
+	demo := state.Runs["default/demo"]
+	if admittedReason(demo) != v1.RunStateBooked.Reason || activeIntentPods(state, "default", "demo") != 0 {
+		t.Fatalf("demo: %q (%s); want Booked with no pods before its start", admittedReason(demo), demo.Status.Message)
+	}
+	res := state.Reservations["default/demo-booking"]
+	if res == nil || res.Spec.Booking == nil || !res.Spec.EarliestStart.Time.Equal(start) {
+		t.Fatalf("demo's booking reservation = %+v, want one at the booked start", res)
+	}
+	if got := res.Spec.Booking.DrainFrom.Time; !got.Equal(start.Add(-time.Hour)) {
+		t.Errorf("drainFrom = %v, want an hour before the start", got)
+	}
+	if len(res.Spec.IntendedSlice.Nodes) != 2 {
+		t.Errorf("demo holds nodes %v, want both", res.Spec.IntendedSlice.Nodes)
+	}
+
+	clash := state.Runs["default/clash"]
+	if clash.Status.Phase != RunPhaseFailed || !strings.Contains(clash.Status.Message, "default/demo-booking") {
+		t.Errorf("clash: %s (%s); want BookingRejected naming the booking that holds the window", clash.Status.Phase, clash.Status.Message)
+	}
+	if later := state.Runs["default/later"]; admittedReason(later) != v1.RunStateBooked.Reason {
+		t.Errorf("later: %q (%s); a booking starting as demo's ends does not overlap it", admittedReason(later), later.Status.Message)
+	}
+
+	// A rejected booking stays rejected.
+	if err := c.Reconcile("default", "clash"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if clash.Status.Phase != RunPhaseFailed || activeIntentPods(state, "default", "clash") != 0 {
+		t.Errorf("a rejected booking re-admitted: %s (%s)", clash.Status.Phase, clash.Status.Message)
+	}
+}
+
+// The envelopes must fund the booking through its window: an envelope that
+// closes before the booking ends refuses it when it is made, not at the seam.
+func TestBookingIsRejectedWhenItsEnvelopeEndsInsideTheWindow(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	start, until := now.Add(24*time.Hour), now.Add(36*time.Hour)
+	budget := nfBudget("team", "org:ai:team")
+	end := v1.NewTime(now.Add(30 * time.Hour))
+	budget.Spec.Envelopes[0].End = &end
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{budget},
+		Runs:    map[string]*v1.Run{"default/demo": bookedRun("demo", 8, start, until, now)},
+	}
+	c := NewRunController(state, runClock{now: now})
+	if err := c.Reconcile("default", "demo"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	demo := state.Runs["default/demo"]
+	if demo.Status.Phase != RunPhaseFailed || !strings.Contains(demo.Status.Message, "cannot be funded at") {
+		t.Fatalf("demo: %s (%s); want BookingRejected on funding", demo.Status.Phase, demo.Status.Message)
+	}
+	if len(state.Reservations) != 0 {
+		t.Errorf("a rejected booking left %d reservation(s)", len(state.Reservations))
+	}
+}
+
+// Ahead of its start the booking drains unfunded work from its slice in step
+// with the clock — half the width half-way through the lead — and at the start
+// it activates like any reservation.
+func TestBookingDrainsUnfundedWorkAheadOfItsStart(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	start := now.Add(2 * time.Hour)
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+		Runs:    map[string]*v1.Run{"default/demo": bookedRun("demo", 8, start, start.Add(12*time.Hour), now)},
+	}
+	for i := 0; i < 4; i++ {
+		name := fmt.Sprintf("squat-%d", i)
+		node := []string{"node-a", "node-b"}[i/2]
+		state.Runs["default/"+name] = nfRun(name, "org:ai:nobody", 2, now)
+		state.Leases = append(state.Leases, nfLease(name+"-lease", name, "org:ai:nobody", "",
+			[]string{fmt.Sprintf("%s#%d", node, 2*(i%2)), fmt.Sprintf("%s#%d", node, 2*(i%2)+1)}, binder.RoleActive, now))
+	}
+	mirrorPods(state)
+
+	c := NewRunController(state, runClock{now: now})
+	if err := c.Reconcile("default", "demo"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	closed := func() int {
+		n := 0
+		for i := range state.Leases {
+			if state.Leases[i].Status.Closed {
+				n++
+			}
+		}
+		return n
+	}
+
+	if err := c.ActivateReservations(now.Add(30 * time.Minute)); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if n := closed(); n != 0 {
+		t.Fatalf("%d lease(s) reclaimed before the drain lead began", n)
+	}
+
+	if err := c.ActivateReservations(now.Add(90 * time.Minute)); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	if n := closed(); n != 2 {
+		t.Fatalf("half-way through the lead %d squatter lease(s) are reclaimed, want 2 (4 of 8 GPUs)", n)
+	}
+
+	c.Clock = runClock{now: start}
+	if err := c.ActivateReservations(start); err != nil {
+		t.Fatalf("activate: %v", err)
+	}
+	demo := state.Runs["default/demo"]
+	if got := activeIntentPods(state, "default", "demo"); got == 0 || admittedReason(demo) != v1.RunStateScheduling.Reason {
+		t.Fatalf("demo at its start: %d pods, %q (%s); want Scheduling", got, admittedReason(demo), demo.Status.Message)
+	}
+	if n := closed(); n != 4 {
+		t.Errorf("%d squatter lease(s) reclaimed by the start, want all 4", n)
+	}
+}
@@ -162,6 +162,7 @@ func (c *BudgetController) ReconcileBudget(budgetObj *v1.Budget, ev *funding.Eva
 		Usage:              usage,
 		UpdatedAt:          ptrTime(v1.NewTime(ev.Now)),
 		PendingRenewals:    pendingRenewals(budgetObj, ev.Now),
+		FairShare:          fairShareUsage(ev.FairShare(budgetObj.ObjectMeta.Namespace, budgetObj.ObjectMeta.Name)),
 	}
 	// R11: Healthy is a pure projection of the headroom computed just above, so it
 	// is derived here rather than written independently — an overcommitted envelope
@@ -175,18 +176,19 @@ func (c *BudgetController) ReconcileBudget(budgetObj *v1.Budget, ev *funding.Eva
 
 // pendingRenewals is the real reader of spec.autoRenew (audit finding #22):
 // when set, any time-scoped envelope whose End falls within notifyBefore of
-// now (or has already passed) is reported so an operator can rotate it. An
-// unset AutoRenew — the field's default — always yields an empty list; it
-// does not itself extend any window (windows stay operator-controlled), it
-// only surfaces that a rotation is due.
+// now (or has already passed) and that no envelope renews yet is reported.
+// RenewEnvelopes clears the list on its next pass unless the renewal waits on
+// approval, so what stays listed is what an operator has to act on. An unset
+// AutoRenew — the field's default — always yields an empty list.
 func pendingRenewals(budgetObj *v1.Budget, now time.Time) []v1.EnvelopeRenewalDue {
 	if budgetObj.Spec.AutoRenew == nil {
 		return nil
 	}
 	notifyBefore := budgetObj.Spec.AutoRenew.NotifyBefore.Duration
+	renewed := renewedEnvelopes(budgetObj)
 	var due []v1.EnvelopeRenewalDue
 	for _, env := range budgetObj.Spec.Envelopes {
-		if env.End == nil {
+		if env.End == nil || renewed[env.Name] {
 			continue
 		}
 		if !now.Before(env.End.Time.Add(-notifyBefore)) {
@@ -196,6 +198,18 @@ func pendingRenewals(budgetObj *v1.Budget, now time.Time) []v1.EnvelopeRenewalDu
 	return due
 }
 
+// fairShareUsage copies the evaluation's decayed family use onto the status.
+func fairShareUsage(shares []funding.ShareUsage) []v1.FairShareUsage {
+	if len(shares) == 0 {
+		return nil
+	}
+	out := make([]v1.FairShareUsage, 0, len(shares))
+	for _, share := range shares {
+		out = append(out, v1.FairShareUsage{Owner: share.Owner, DecayedGPUHours: share.GPUHours, Band: share.Band})
+	}
+	return out
+}
+
 func (c *BudgetController) updateMetrics(budgetObj *v1.Budget, acct *funding.EnvelopeAccount) {
 	snapshot := usageSnapshot{
 		Owned:            float64(acct.WidthByClass[funding.ClassOwned]),
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// AnnotationApproveRenewal approves the pending renewals of a Budget whose
+// spec.autoRenew.requireApproval is set: a comma-separated list of envelope
+// names. An approval names the envelope being renewed, and the renewal gets a
+// new name, so each approval is spent by the renewal it allows.
+const AnnotationApproveRenewal = "rq.davidlangworthy.io/approve-renewal"
+
+// EnvelopeRenewal records one window appended by RenewEnvelopes.
+type EnvelopeRenewal struct {
+	From        string
+	To          string
+	Start       time.Time
+	End         time.Time
+	Concurrency int32
+}
+
+// RenewalResult is what one RenewEnvelopes pass did: the windows it appended
+// and the due envelopes it held for approval.
+type RenewalResult struct {
+	Renewed  []EnvelopeRenewal
+	Awaiting []string
+}
+
+// RenewEnvelopes appends the next window of every envelope whose end falls
+// within spec.autoRenew.notifyBefore of now and that has no renewal yet. The
+// new envelope copies the old one, opens at its end for spec.autoRenew.period
+// (or the old window's length), grants its nextConcurrency if declared, and
+// names it in renewalOf so the funding evaluation carries its open leases
+// across the seam. Aggregate caps that bound the old envelope bound the new
+// one too. The old envelope is left untouched: it still ends when it said.
+//
+// An envelope whose end has already passed (the manager was down, or the
+// approval came late) is renewed from now, not from its end: opening in the
+// past would fund hours nobody granted. The gap means there is no seam, so its
+// leases are not continued (see v1.BudgetEnvelope.RenewalOf).
+//
+// Under requireApproval a due envelope is renewed only once the Budget's
+// AnnotationApproveRenewal names it; otherwise it is reported as awaiting.
+// The budget's spec is modified in place; the caller persists it.
+func RenewEnvelopes(budgetObj *v1.Budget, now time.Time) RenewalResult {
+	var result RenewalResult
+	renew := budgetObj.Spec.AutoRenew
+	if renew == nil {
+		return result
+	}
+	renewed := renewedEnvelopes(budgetObj)
+	approved := map[string]bool{}
+	for _, name := range strings.Split(budgetObj.Annotations[AnnotationApproveRenewal], ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			approved[name] = true
+		}
+	}
+	// Appending grows the slice; renew only what was there at the start, so
+	// a fresh renewal is not itself renewed in the same pass.
+	for i, n := 0, len(budgetObj.Spec.Envelopes); i < n; i++ {
+		env := budgetObj.Spec.Envelopes[i]
+		if env.Start == nil || env.End == nil || renewed[env.Name] {
+			continue
+		}
+		if now.Before(env.End.Time.Add(-renew.NotifyBefore.Duration)) {
+			continue
+		}
+		if renew.RequireApproval && !approved[env.Name] {
+			result.Awaiting = append(result.Awaiting, env.Name)
+			continue
+		}
+		period := renew.Period.Duration
+		if period <= 0 {
+			period = env.End.Sub(env.Start.Time)
+		}
+		next := *env.DeepCopy()
+		next.Name = renewalName(budgetObj, &env)
+		next.RenewalOf = env.Name
+		start := env.End.Time
+		if now.After(start) {
+			start = now
+		}
+		next.Start = ptrTime(v1.NewTime(start))
+		next.End = ptrTime(v1.NewTime(start.Add(period)))
+		if env.NextConcurrency != nil {
+			next.Concurrency = *env.NextConcurrency
+			next.NextConcurrency = nil
+		}
+		budgetObj.Spec.Envelopes = append(budgetObj.Spec.Envelopes, next)
+		for j := range budgetObj.Spec.AggregateCaps {
+			cap := &budgetObj.Spec.AggregateCaps[j]
+			for _, name := range cap.Envelopes {
+				if name == env.Name {
+					cap.Envelopes = append(cap.Envelopes, next.Name)
+					break
+				}
+			}
+		}
+		renewed[env.Name] = true
+		result.Renewed = append(result.Renewed, EnvelopeRenewal{
+			From:        env.Name,
+			To:          next.Name,
+			Start:       next.Start.Time,
+			End:         next.End.Time,
+			Concurrency: next.Concurrency,
+		})
+	}
+	return result
+}
+
+// Message is the text of the EnvelopeRenewed event.
+func (r EnvelopeRenewal) Message() string {
+	return fmt.Sprintf("envelope %s renewed as %s: %d GPUs from %s to %s", r.From, r.To, r.Concurrency,
+		r.Start.UTC().Format(time.RFC3339), r.End.UTC().Format(time.RFC3339))
+}
+
+// renewedEnvelopes is the set of envelopes some other envelope of the budget
+// is a renewal of.
+func renewedEnvelopes(budgetObj *v1.Budget) map[string]bool {
+	renewed := map[string]bool{}
+	for _, env := range budgetObj.Spec.Envelopes {
+		if env.RenewalOf != "" {
+			renewed[env.RenewalOf] = true
+		}
+	}
+	return renewed
+}
+
+// renewalName numbers successive windows of one envelope: gpus renews as
+// gpus-r2, gpus-r2 as gpus-r3. A name already taken moves the number on.
+func renewalName(budgetObj *v1.Budget, env *v1.BudgetEnvelope) string {
+	base, n := env.Name, 2
+	if env.RenewalOf != "" {
+		if i := strings.LastIndex(env.Name, "-r"); i > 0 {
+			if k, err := strconv.Atoi(env.Name[i+2:]); err == nil && k > 0 {
+				base, n = env.Name[:i], k+1
+			}
+		}
+	}
+	taken := make(map[string]bool, len(budgetObj.Spec.Envelopes))
+	for _, e := range budgetObj.Spec.Envelopes {
+		taken[e.Name] = true
+	}
+	for ; ; n++ {
+		if name := fmt.Sprintf("%s-r%d", base, n); !taken[name] {
+			return name
+		}
+	}
+}
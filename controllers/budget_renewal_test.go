@@ -0,0 +1,108 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// A due envelope is renewed once, at its next-period concurrency, into the
+// aggregate caps that bounded it; the renewal is renewed in turn when it falls
+// due, and under requireApproval only the envelopes the annotation names are.
+func TestRenewEnvelopesAppendsTheNextWindow(t *testing.T) {
+	end := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	start, closes := v1.NewTime(end.Add(-30*24*time.Hour)), v1.NewTime(end)
+	next := int32(12)
+	budgetObj := &v1.Budget{
+		ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "team"},
+		Spec: v1.BudgetSpec{
+			Owner: "org:team",
+			Envelopes: []v1.BudgetEnvelope{
+				{Name: "gpus", Flavor: "H100", Selector: map[string]string{"region": "us-west"}, Concurrency: 8, NextConcurrency: &next, Start: &start, End: &closes},
+				{Name: "spare", Flavor: "H100", Selector: map[string]string{"region": "us-east"}, Concurrency: 4, Start: &start, End: &closes},
+				{Name: "later", Flavor: "H100", Selector: map[string]string{"region": "us-east"}, Concurrency: 4, Start: &start, End: ptrTime(v1.NewTime(end.Add(60 * 24 * time.Hour)))},
+			},
+			AggregateCaps: []v1.AggregateCap{{Name: "west", Flavor: "H100", Envelopes: []string{"gpus"}}},
+			AutoRenew: &v1.AutoRenewSchedule{
+				Period:       metav1.Duration{Duration: 7 * 24 * time.Hour},
+				NotifyBefore: metav1.Duration{Duration: 48 * time.Hour},
+			},
+		},
+	}
+
+	if got := RenewEnvelopes(budgetObj, end.Add(-72*time.Hour)); len(got.Renewed) != 0 {
+		t.Fatalf("renewed before notifyBefore: %+v", got.Renewed)
+	}
+	got := RenewEnvelopes(budgetObj, end.Add(-24*time.Hour))
+	if len(got.Renewed) != 2 || got.Renewed[0].To != "gpus-r2" || got.Renewed[1].To != "spare-r2" {
+		t.Fatalf("renewed %+v, want gpus-r2 and spare-r2", got.Renewed)
+	}
+	renewal := budgetObj.Spec.Envelopes[3]
+	if renewal.RenewalOf != "gpus" || renewal.Concurrency != 12 || renewal.NextConcurrency != nil ||
+		!renewal.Start.Time.Equal(end) || !renewal.End.Time.Equal(end.Add(7*24*time.Hour)) {
+		t.Fatalf("renewal = %+v, want gpus continued at 12 GPUs for a week from its end", renewal)
+	}
+	if caps := budgetObj.Spec.AggregateCaps[0].Envelopes; len(caps) != 2 || caps[1] != "gpus-r2" {
+		t.Fatalf("aggregate cap envelopes %v, want the renewal added", caps)
+	}
+	if err := budgetObj.ValidateUpdate(nil); err != nil {
+		t.Fatalf("a renewed budget must still validate: %v", err)
+	}
+	if again := RenewEnvelopes(budgetObj, end.Add(-24*time.Hour)); len(again.Renewed) != 0 {
+		t.Fatalf("an envelope is renewed once, got %+v", again.Renewed)
+	}
+	if due := pendingRenewals(budgetObj, end.Add(-24*time.Hour)); len(due) != 0 {
+		t.Fatalf("renewed envelopes are no longer pending: %+v", due)
+	}
+
+	budgetObj.Spec.AutoRenew.RequireApproval = true
+	now := end.Add(6 * 24 * time.Hour)
+	if held := RenewEnvelopes(budgetObj, now); len(held.Renewed) != 0 || len(held.Awaiting) != 2 {
+		t.Fatalf("without approval nothing renews: %+v", held)
+	}
+	budgetObj.Annotations = map[string]string{AnnotationApproveRenewal: "gpus-r2"}
+	got = RenewEnvelopes(budgetObj, now)
+	if len(got.Renewed) != 1 || got.Renewed[0].To != "gpus-r3" || got.Renewed[0].Concurrency != 12 {
+		t.Fatalf("renewed %+v, want only the approved gpus-r2 as gpus-r3", got.Renewed)
+	}
+	if len(got.Awaiting) != 1 || got.Awaiting[0] != "spare-r2" {
+		t.Fatalf("awaiting %v, want spare-r2", got.Awaiting)
+	}
+}
+
+// A window renewed only after it closed opens at now, not back at its end, so
+// the renewal funds no hours before it was written; a timely renewal still
+// opens at the seam.
+func TestRenewEnvelopesAfterTheEndOpensAtNow(t *testing.T) {
+	end := time.Date(2026, 8, 1, 0, 0, 0, 0, time.UTC)
+	start, closes := v1.NewTime(end.Add(-30*24*time.Hour)), v1.NewTime(end)
+	budgetObj := &v1.Budget{
+		ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "team"},
+		Spec: v1.BudgetSpec{
+			Owner: "org:team",
+			Envelopes: []v1.BudgetEnvelope{
+				{Name: "gpus", Flavor: "H100", Selector: map[string]string{"region": "us-west"}, Concurrency: 8, Start: &start, End: &closes},
+				{Name: "spare", Flavor: "H100", Selector: map[string]string{"region": "us-east"}, Concurrency: 4, Start: &start, End: ptrTime(v1.NewTime(end.Add(3 * 24 * time.Hour)))},
+			},
+			AutoRenew: &v1.AutoRenewSchedule{
+				Period:       metav1.Duration{Duration: 7 * 24 * time.Hour},
+				NotifyBefore: metav1.Duration{Duration: 96 * time.Hour},
+			},
+		},
+	}
+
+	now := end.Add(6 * time.Hour)
+	got := RenewEnvelopes(budgetObj, now)
+	if len(got.Renewed) != 2 {
+		t.Fatalf("renewed %+v, want gpus and spare", got.Renewed)
+	}
+	if late := got.Renewed[0]; late.To != "gpus-r2" || !late.Start.Equal(now) || !late.End.Equal(now.Add(7*24*time.Hour)) {
+		t.Errorf("a lapsed window = %+v, want it renewed from now for a week", late)
+	}
+	if timely := got.Renewed[1]; !timely.Start.Equal(end.Add(3 * 24 * time.Hour)) {
+		t.Errorf("a window still open = %+v, want it renewed from its end", timely)
+	}
+}
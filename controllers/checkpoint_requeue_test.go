@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/resolver"
+)
+
+// A funded lottery cut ends a run that cannot checkpoint. One that declares
+// spec.runtime.checkpoint is requeued instead: its pods drain with the checkpoint
+// window as their grace, its leftover leases are released, and it waits Reclaimed
+// — not Failed — to resume from the checkpoint when capacity returns.
+func TestResolverCutRequeuesACheckpointedRun(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	run := nfRun("ckpt", "org:ai:team", 4, now)
+	run.Spec.Runtime = &v1.RunRuntime{Checkpoint: metav1.Duration{Duration: 10 * time.Minute}}
+
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+		Runs:    map[string]*v1.Run{"default/ckpt": run},
+		Leases: []v1.GPULease{
+			prodLeaseGroup("ckpt-g0", "ckpt", "org:ai:team", "team", "0", []string{"node-a#0", "node-a#1"}, binder.RoleActive, now),
+			prodLeaseGroup("ckpt-g1", "ckpt", "org:ai:team", "team", "1", []string{"node-b#0", "node-b#1"}, binder.RoleActive, now),
+		},
+	}
+	mirrorPods(state)
+	c := NewRunController(state, runClock{now: now})
+
+	c.applyResolution(resolver.Result{Seed: "0xseed", Actions: []resolver.Action{
+		{Kind: resolver.ActionLottery, Lease: &state.Leases[1], Run: run, GroupIndex: "1", GPUs: 2, Reason: "RandomPreempt(0xseed)"},
+	}}, now)
+
+	if got := run.Status.Phase; got != RunPhasePending {
+		t.Fatalf("a checkpointed run cut by the lottery must requeue, got phase %s (%s)", got, run.Status.Message)
+	}
+	if admittedReason(run) != v1.RunStateReclaimed.Reason {
+		t.Errorf("requeued run should read Reclaimed, got %q", run.Status.Message)
+	}
+	if run.Status.DrainDeadline == nil || !run.Status.DrainDeadline.Time.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("drain deadline = %v, want now+checkpoint", run.Status.DrainDeadline)
+	}
+	if closed, reason := closureOf(state, "ckpt-g0"); !closed || reason != "Requeued" {
+		t.Errorf("the surviving group's lease = closed %v (%s); a requeued run must not charge for half a gang", closed, reason)
+	}
+	if len(state.Pods) != 0 {
+		t.Errorf("%d pod(s) left in the world; the bridge drains what the engine drops", len(state.Pods))
+	}
+	assertSteady(t, c, "checkpointed run requeued by the resolver")
+}
+
+// A requeued run does not re-plan onto GPUs its own draining pods still hold: it
+// waits while a pod is Terminating inside the window, and re-admits once the pods
+// are gone — the checkpoint is written — even before the deadline.
+func TestRequeuedRunWaitsForItsPodsToDrain(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	run := nfRun("ckpt", "org:ai:team", 4, now)
+	run.Spec.Runtime = &v1.RunRuntime{Checkpoint: metav1.Duration{Duration: 10 * time.Minute}}
+	setState(run, v1.RunStateReclaimed, "cut by resolver; draining")
+	deadline := v1.NewTime(now.Add(5 * time.Minute))
+	run.Status.DrainDeadline = &deadline
+
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+		Runs:    map[string]*v1.Run{"default/ckpt": run},
+		Pods: []binder.PodManifest{{
+			Namespace: "default", Name: "ckpt-active-0", GPUs: 1, Terminating: true,
+			Labels: map[string]string{binder.LabelRunName: "ckpt", binder.LabelRunRole: binder.RoleActive, binder.LabelGroupIndex: "0"},
+		}},
+	}
+	c := NewRunController(state, runClock{now: now})
+	if err := c.Reconcile("default", "ckpt"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if len(state.Pods) != 1 || admittedReason(run) != v1.RunStateReclaimed.Reason {
+		t.Fatalf("a run whose pod is still draining must hold Reclaimed without emitting; pods=%d msg=%q", len(state.Pods), run.Status.Message)
+	}
+
+	state.Pods = nil // the checkpoint is written and the pod is gone
+	if err := c.Reconcile("default", "ckpt"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if admittedReason(run) != v1.RunStateScheduling.Reason || len(state.Pods) == 0 {
+		t.Errorf("a drained run must re-admit; got %q with %d pod(s)", run.Status.Message, len(state.Pods))
+	}
+}
+
+// admittedReason is the state a pre-Running run was last put in, as its Admitted
+// condition records it.
+func admittedReason(run *v1.Run) string {
+	if cond := meta.FindStatusCondition(run.Status.Conditions, v1.RunConditionAdmitted); cond != nil {
+		return cond.Reason
+	}
+	return ""
+}
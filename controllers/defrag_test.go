@@ -0,0 +1,149 @@
+package controllers
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// Two 8-GPU islands of two 4-GPU nodes. A checkpointing 4-GPU run sits on
+// island-a, a run that cannot checkpoint on island-b: 8 GPUs are free, four
+// on each island, and "big", which outranks both on priority, wants 8 on one.
+func defragState(now time.Time) *ClusterState {
+	var nodes []topology.SourceNode
+	for _, name := range []string{"a1", "a2", "b1", "b2"} {
+		nodes = append(nodes, topology.SourceNode{Name: name, GPUs: 4, Labels: map[string]string{
+			topology.LabelRegion: "us-west", topology.LabelCluster: "cluster-a",
+			topology.LabelFabricDomain: "island-" + name[:1], topology.LabelGPUFlavor: "H100-80GB",
+		}})
+	}
+	small := nfRun("small", "org:ai:team", 4, now)
+	small.Spec.Runtime = &v1.RunRuntime{Checkpoint: metav1.Duration{Duration: 10 * time.Minute}}
+	pinned := nfRun("pinned", "org:ai:team", 4, now)
+	big := &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: "big", Namespace: "default", CreationTimestamp: v1.NewTime(now)},
+		Spec: v1.RunSpec{
+			Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8},
+			Locality:  &v1.RunLocality{AllowCrossGroupSpread: new(bool)},
+			Priority:  10,
+		},
+	}
+	state := &ClusterState{
+		Nodes: nodes,
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec: v1.BudgetSpec{Owner: "org:ai:team", Envelopes: []v1.BudgetEnvelope{{
+				Name: "west", Flavor: "H100-80GB", Concurrency: 32, Start: &testWindowStart, End: &testWindowEnd,
+			}}},
+		}},
+		Runs: map[string]*v1.Run{"default/small": small, "default/pinned": pinned, "default/big": big},
+		Leases: []v1.GPULease{
+			nfLease("small-lease", "small", "org:ai:team", "team", []string{"a1#0", "a1#1", "a1#2", "a1#3"}, binder.RoleActive, now),
+			nfLease("pinned-lease", "pinned", "org:ai:team", "team", []string{"b1#0", "b1#1", "b1#2", "b1#3"}, binder.RoleActive, now),
+		},
+	}
+	mirrorPods(state)
+	return state
+}
+
+// The blocked run does not reserve behind the scattered ones: the checkpointing
+// run is moved off island-a, and "big" is scheduled there in the same pass. The
+// moved run drains and, once its pods are gone, re-admits on island-b with its
+// new leases marked Defrag.
+func TestDefragMovesACheckpointingRunToOpenADomain(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := defragState(now)
+	c := NewRunController(state, runClock{now: now})
+	if err := c.Reconcile("default", "big"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+
+	big, small := state.Runs["default/big"], state.Runs["default/small"]
+	if got := activeIntentPods(state, "default", "big"); got != 8 || admittedReason(big) != v1.RunStateScheduling.Reason {
+		t.Fatalf("big: %d intent pods, state %q (%s); want 8 and Scheduling", got, admittedReason(big), big.Status.Message)
+	}
+	if len(state.Reservations) != 0 {
+		t.Errorf("big reserved %d places after the defrag opened one", len(state.Reservations))
+	}
+	if closed, reason := closureOf(state, "small-lease"); !closed || reason != binder.LeaseReasonDefrag {
+		t.Errorf("small's lease closed=%v reason=%q, want closed with Defrag", closed, reason)
+	}
+	if closed, _ := closureOf(state, "pinned-lease"); closed {
+		t.Error("a run that cannot checkpoint was moved")
+	}
+	if admittedReason(small) != v1.RunStateDefragmented.Reason || small.Status.DrainDeadline == nil ||
+		!small.Status.DrainDeadline.Time.Equal(now.Add(10*time.Minute)) {
+		t.Fatalf("small: state %q, drain deadline %v; want Defragmented draining for its checkpoint window", admittedReason(small), small.Status.DrainDeadline)
+	}
+	if got := activeIntentPods(state, "default", "small"); got != 0 {
+		t.Fatalf("small still has %d pods; the bridge drains what the engine drops", got)
+	}
+	for i := range state.Pods {
+		if pod := state.Pods[i]; pod.Labels[binder.LabelRunName] == "big" && pod.NodeName[:1] != "a" {
+			t.Errorf("big's pod %s is hinted at %s, off the opened island-a", pod.Name, pod.NodeName)
+		}
+	}
+
+	// The plugin mints big's gang on island-a; small's pods are gone.
+	for i := 0; i < 8; i++ {
+		state.Leases = append(state.Leases, nfLease(fmt.Sprintf("big-%d", i), "big", "org:ai:team", "team",
+			[]string{fmt.Sprintf("a%d#%d", i/4+1, i%4)}, binder.RoleActive, now))
+	}
+	if err := c.Reconcile("default", "small"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got := activeIntentPods(state, "default", "small"); got == 0 || admittedReason(small) != v1.RunStateScheduling.Reason {
+		t.Fatalf("small did not re-admit: %d pods, %q", got, small.Status.Message)
+	}
+	for i := range state.Pods {
+		pod := state.Pods[i]
+		if pod.Labels[binder.LabelRunName] != "small" {
+			continue
+		}
+		if pod.Annotations[binder.AnnotationLeaseReason] != binder.LeaseReasonDefrag || pod.NodeName != "b2" {
+			t.Errorf("small's pod %s: reason %q on %s, want Defrag on b2", pod.Name, pod.Annotations[binder.AnnotationLeaseReason], pod.NodeName)
+		}
+	}
+}
+
+// spec.runtime.noDefrag keeps a run where it is: with nothing else movable,
+// the blocked run reserves as before.
+func TestDefragRespectsTheOptOut(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := defragState(now)
+	state.Runs["default/small"].Spec.Runtime.NoDefrag = true
+	c := NewRunController(state, runClock{now: now})
+	if err := c.Reconcile("default", "big"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if closed, _ := closureOf(state, "small-lease"); closed {
+		t.Fatal("a run that opted out was moved")
+	}
+	if big := state.Runs["default/big"]; admittedReason(big) != v1.RunStateReserved.Reason {
+		t.Errorf("big: %q (%s), want Reserved", admittedReason(big), big.Status.Message)
+	}
+}
+
+// A funded run is owed its place against a run it outranks: admitted first at
+// the same priority, "small" stays, and "big" reserves as before.
+func TestDefragDoesNotMoveAFundedRunThatOutranksTheBlockedOne(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := defragState(now)
+	state.Runs["default/big"].Spec.Priority = 0
+	c := NewRunController(state, runClock{now: now})
+	if err := c.Reconcile("default", "big"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if closed, _ := closureOf(state, "small-lease"); closed {
+		t.Fatal("a funded run that outranks the blocked one was moved")
+	}
+	if big := state.Runs["default/big"]; admittedReason(big) != v1.RunStateReserved.Reason {
+		t.Errorf("big: %q (%s), want Reserved", admittedReason(big), big.Status.Message)
+	}
+}
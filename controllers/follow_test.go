@@ -1,11 +1,13 @@
 package controllers
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/davidlangworthy/jobtree/pkg/keys"
@@ -175,3 +177,80 @@ func TestFollowCycleFailsTheRun(t *testing.T) {
 		t.Fatalf("a follow cycle should fail the run, got %s (%s)", got.Status.Phase, got.Status.Message)
 	}
 }
+
+// minSucceeded fans in on a subset: one failed shard is absorbed while enough
+// others can still complete, and the gate opens at the threshold.
+func TestMinSucceededFansInOnASubset(t *testing.T) {
+	a, b, c := followRun("shard-a"), followRun("shard-b"), followRun("shard-c")
+	a.Status.Phase = RunPhaseFailed
+	b.Status.Phase = RunPhaseComplete
+	c.Status.Phase = RunPhaseRunning
+	two := int32(2)
+	down := followRun("merge", "shard-a", "shard-b", "shard-c")
+	down.Spec.Follow.MinSucceeded = &two
+	state := followWorld(a, b, c, down)
+	clock := &qsClock{now: qsBase}
+
+	got := qsReconcile(t, state, clock, "merge")
+	if got.Status.Phase != RunPhaseWaiting || got.Status.FollowDeadline != nil {
+		t.Fatalf("a reachable threshold waits without a failure deadline, got %s (%s)", got.Status.Phase, got.Status.Message)
+	}
+	c.Status.Phase = RunPhaseComplete
+	if got = qsReconcile(t, state, clock, "merge"); got.Status.Phase != RunPhasePending {
+		t.Fatalf("two of three complete should open the gate, got %s (%s)", got.Status.Phase, got.Status.Message)
+	}
+}
+
+// A Failed branch runs only when an upstream failed; otherwise it is skipped,
+// and a Succeeded follower of the skipped branch is skipped in turn.
+func TestFailedBranchRunsOnFailureAndIsSkippedOtherwise(t *testing.T) {
+	train := followRun("train")
+	train.Status.Phase = RunPhaseComplete
+	alert := followRun("alert", "train")
+	alert.Spec.Follow.When = v1.FollowWhenFailed
+	page := followRun("page", "alert")
+	state := followWorld(train, alert, page)
+	clock := &qsClock{now: qsBase}
+
+	got := qsReconcile(t, state, clock, "alert")
+	if got.Status.Phase != RunPhaseFailed || !v1.RunSkipped(&got.Status) {
+		t.Fatalf("a Failed branch after a success must be skipped, got %s (%s)", got.Status.Phase, got.Status.Message)
+	}
+	got = qsReconcile(t, state, clock, "page")
+	if got.Status.Phase != RunPhaseFailed || !v1.RunSkipped(&got.Status) || got.Status.FollowDeadline != nil {
+		t.Fatalf("a follower of a skipped run must be skipped, not held for grace: %s (%s)", got.Status.Phase, got.Status.Message)
+	}
+
+	failed := followRun("train-2")
+	failed.Status.Phase = RunPhaseFailed
+	cleanup := followRun("cleanup", "train-2")
+	cleanup.Spec.Follow.When = v1.FollowWhenFailed
+	state = followWorld(failed, cleanup)
+	if got = qsReconcile(t, state, clock, "cleanup"); got.Status.Phase != RunPhasePending {
+		t.Fatalf("a Failed branch after a failure must run, got %s (%s)", got.Status.Phase, got.Status.Message)
+	}
+}
+
+// The gate records each upstream's outcome and /artifacts volume for the pods.
+func TestOpenGateRecordsUpstreamArtifacts(t *testing.T) {
+	up := followRun("preprocess")
+	up.Status.Phase = RunPhaseComplete
+	up.Spec.Roles = []v1.RunRole{{Name: "worker", Width: 1, GPUsPerPod: 4, Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "main", Image: "img", VolumeMounts: []corev1.VolumeMount{{Name: "out", MountPath: "/artifacts"}}}},
+		Volumes:    []corev1.Volume{{Name: "out", VolumeSource: corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "prep-out"}}}},
+	}}}}
+	skipped := followRun("rescue")
+	setState(skipped, v1.RunStateFollowSkipped, "skipped")
+	down := followRun("train", "preprocess", "rescue")
+	down.Spec.Follow.When = v1.FollowWhenDone
+	state := followWorld(up, skipped, down)
+
+	got := qsReconcile(t, state, &qsClock{now: qsBase}, "train")
+	want := []v1.RunUpstream{
+		{Name: "preprocess", Phase: RunPhaseComplete, Artifacts: "pvc://prep-out"},
+		{Name: "rescue", Phase: v1.PipelineStageSkipped},
+	}
+	if !reflect.DeepEqual(got.Status.Upstreams, want) {
+		t.Fatalf("upstreams: got %+v, want %+v", got.Status.Upstreams, want)
+	}
+}
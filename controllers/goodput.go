@@ -0,0 +1,137 @@
+package controllers
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/goodput"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+)
+
+// sizeByGoodput records the run's reported throughput against the width it is
+// running at, and moves status.goodput.targetGPUs one step when the curve says
+// to. reconcileElasticRun then grows or shrinks toward the target exactly as it
+// does toward a desired width an owner set by hand.
+//
+// Nothing is recorded or decided while the run is between widths or inside the
+// cooldown: a throughput reported mid-resize belongs to neither width, and a
+// decision taken from it is how leases thrash.
+func (c *RunController) sizeByGoodput(run *v1.Run, ev *funding.Evaluation, now time.Time) {
+	m := run.Spec.Malleable
+	if m == nil || m.Goodput == nil {
+		run.Status.Goodput = nil
+		return
+	}
+	allocated := summarizeRunWidth(run, c.State.Leases).Allocated
+	status := run.Status.Goodput
+	if status == nil {
+		// Sizing starts from where the run stands: the admission width, not the
+		// desired one. Reaching the desired width is what the curve must earn.
+		status = &v1.RunGoodputStatus{TargetGPUs: allocated}
+		if status.TargetGPUs < m.MinTotalGPUs {
+			status.TargetGPUs = m.MinTotalGPUs
+		}
+		run.Status.Goodput = status
+	}
+	if allocated != desiredWidth(run) {
+		return
+	}
+	if status.LastResize != nil && now.Sub(status.LastResize.Time) < goodput.Cooldown(m.Goodput) {
+		return
+	}
+	if throughput, ok := c.reportedThroughput(run); ok {
+		status.Curve = goodput.Record(status.Curve, allocated, throughput, now)
+	}
+
+	funded := false
+	if acct := ev.Run(keys.NamespacedKey(run.Namespace, run.Name)); acct != nil {
+		funded = acct.GPUs[funding.ClassUnfunded] == 0
+	}
+	next := goodput.Decide(goodput.Input{
+		Curve:   status.Curve,
+		Width:   allocated,
+		Min:     m.MinTotalGPUs,
+		Ceiling: scaledWidth(run, m.Desired()),
+		Step:    m.StepGPUs,
+		MinGain: goodput.MinGain(m.Goodput),
+		Funded:  funded,
+	})
+	if next == status.TargetGPUs {
+		return
+	}
+	status.TargetGPUs = next
+	resized := v1.NewTime(now)
+	status.LastResize = &resized
+	c.emit(run, EventTypeNormal, "GoodputResize", fmt.Sprintf("goodput sizing moved the target width from %d to %d GPUs", allocated, next))
+}
+
+// desiredWidth is the width reconcileElasticRun steers toward: the owner's
+// desired width, scaled to the run's flavor and lowered to the goodput target
+// when the run is sized by goodput. The owner's value is always the ceiling.
+func desiredWidth(run *v1.Run) int32 {
+	m := run.Spec.Malleable
+	desired := scaledWidth(run, m.Desired())
+	if m.Goodput == nil || run.Status.Goodput == nil {
+		return desired
+	}
+	if target := run.Status.Goodput.TargetGPUs; target > 0 && target < desired {
+		desired = target
+	}
+	if desired < m.MinTotalGPUs {
+		desired = m.MinTotalGPUs
+	}
+	return desired
+}
+
+// scaledWidth scales a malleable width by the widthPercent of the run's
+// flavor, rounded down onto the stepGPUs grid and never below minTotalGPUs.
+// On gpuType, or for a run that is not malleable, it returns width unchanged.
+func scaledWidth(run *v1.Run, width int32) int32 {
+	m := run.Spec.Malleable
+	if m == nil {
+		return width
+	}
+	flavor := run.Flavor()
+	for _, alt := range run.Spec.Resources.Alternatives {
+		if alt.GPUType != flavor || alt.WidthPercent == nil || *alt.WidthPercent == 100 {
+			continue
+		}
+		scaled := int32(int64(width) * int64(*alt.WidthPercent) / 100)
+		if scaled <= m.MinTotalGPUs || m.StepGPUs <= 0 {
+			return m.MinTotalGPUs
+		}
+		return scaled - (scaled-m.MinTotalGPUs)%m.StepGPUs
+	}
+	return width
+}
+
+// reportedThroughput reads the gang's throughput off its pods' annotations. One
+// member normally reports it; if several do, the largest report is taken, so a
+// rank that has not caught up since a resize never understates the gang.
+func (c *RunController) reportedThroughput(run *v1.Run) (float64, bool) {
+	var best float64
+	found := false
+	for i := range c.State.Pods {
+		pod := &c.State.Pods[i]
+		if pod.Namespace != run.Namespace || pod.Labels[binder.LabelRunName] != run.Name {
+			continue
+		}
+		raw := pod.Annotations[binder.ThroughputAnnotation]
+		if raw == "" {
+			continue
+		}
+		value, err := strconv.ParseFloat(raw, 64)
+		if err != nil || !(value > 0) || math.IsInf(value, 1) {
+			continue
+		}
+		if !found || value > best {
+			best, found = value, true
+		}
+	}
+	return best, found
+}
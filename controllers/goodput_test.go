@@ -0,0 +1,70 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+func reportThroughput(state *ClusterState, value string) {
+	for i := range state.Pods {
+		if state.Pods[i].Annotations == nil {
+			state.Pods[i].Annotations = map[string]string{}
+		}
+		state.Pods[i].Annotations[binder.ThroughputAnnotation] = value
+	}
+}
+
+// A goodput-sized run explores one step up from its admission width rather than
+// growing straight to the desired width, holds while the step's measured gain is
+// in the dead band, ignores reports taken inside the cooldown, and gives the
+// step back once its GPUs are seen adding nothing.
+func TestGoodputSizingStepsWithHysteresis(t *testing.T) {
+	t0 := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	run := nfRun("elastic", "org:ai:team", 2, t0)
+	run.Spec.Malleable = &v1.RunMalleability{MinTotalGPUs: 2, MaxTotalGPUs: 8, StepGPUs: 2, Goodput: &v1.RunGoodputPolicy{}}
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+		Runs:    map[string]*v1.Run{"default/elastic": run},
+		Leases:  []v1.GPULease{nfLease("elastic-g0", "elastic", "org:ai:team", "team", []string{"node-a#0", "node-a#1"}, binder.RoleActive, t0)},
+	}
+	mirrorPods(state)
+	reportThroughput(state, "100")
+	c := NewRunController(state, runClock{now: t0})
+
+	c.sizeByGoodput(run, c.evaluate(t0), t0)
+	if got := desiredWidth(run); got != 4 {
+		t.Fatalf("first decision: desired width %d, want one step up to 4, not the spec's 8", got)
+	}
+
+	// The step lands; its first report arrives inside the cooldown and is ignored.
+	grow := nfLeaseGroup("elastic-g1", "elastic", "org:ai:team", "team", "1", []string{"node-b#0", "node-b#1"}, binder.RoleActive, t0)
+	grow.Spec.Reason = binder.LeaseReasonGrow
+	state.Leases = append(state.Leases, grow)
+	mirrorPods(state)
+	reportThroughput(state, "300")
+	t1 := t0.Add(time.Minute)
+	c.sizeByGoodput(run, c.evaluate(t1), t1)
+	if n := len(run.Status.Goodput.Curve); n != 1 {
+		t.Fatalf("a report inside the cooldown was recorded: curve %+v", run.Status.Goodput.Curve)
+	}
+
+	// Settled: the step gained 4% per GPU, inside the dead band. Hold.
+	reportThroughput(state, "104")
+	t2 := t0.Add(11 * time.Minute)
+	c.sizeByGoodput(run, c.evaluate(t2), t2)
+	if got := run.Status.Goodput.TargetGPUs; got != 4 {
+		t.Fatalf("a step in the dead band moved the target to %d, want it held at 4", got)
+	}
+
+	// The step now measures slower than without it: give it back.
+	reportThroughput(state, "95")
+	t3 := t0.Add(22 * time.Minute)
+	c.sizeByGoodput(run, c.evaluate(t3), t3)
+	if got := desiredWidth(run); got != 2 {
+		t.Errorf("a step that added nothing was kept: desired width %d, want 2", got)
+	}
+}
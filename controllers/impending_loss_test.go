@@ -0,0 +1,90 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// A termination notice swaps the rank onto its spare while the node is still up,
+// and the closed slice records how much warning it had.
+func TestImpendingLossSwapsAheadAndRecordsTheLead(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+		Runs:    map[string]*v1.Run{"default/run": nfRun("run", "org:ai:team", 2, now)},
+		Leases: []v1.GPULease{
+			nfLease("active", "run", "org:ai:team", "team", []string{"node-a#0", "node-a#1"}, binder.RoleActive, now),
+			nfLease("spare", "run", "org:ai:team", "team", []string{"node-b#0", "node-b#1"}, binder.RoleSpare, now),
+		},
+	}
+	mirrorPods(state)
+	c := NewRunController(state, runClock{now: now})
+
+	if err := c.HandleImpendingNodeLoss("node-a", now.Add(90*time.Second), now); err != nil {
+		t.Fatalf("handle impending loss: %v", err)
+	}
+	if closed, reason := closureOf(state, "active"); !closed || reason != "ImpendingNodeLoss(lead=1m30s)" {
+		t.Errorf("the doomed slice must close with its lead time: closed=%v reason=%q", closed, reason)
+	}
+	if closed, reason := closureOf(state, "spare"); !closed || reason != "Swap" {
+		t.Errorf("the spare must be promoted: closed=%v reason=%q", closed, reason)
+	}
+	swapped := false
+	for i := range state.Pods {
+		if state.Pods[i].Annotations[binder.AnnotationSwapNode] == "node-b" {
+			swapped = true
+		}
+	}
+	if !swapped {
+		t.Errorf("no swap pod was emitted onto the spare's node")
+	}
+	if run := state.Runs["default/run"]; run.Status.Phase != RunPhaseRunning {
+		t.Errorf("a swap ahead of the loss keeps the run Running, got %s (%s)", run.Status.Phase, run.Status.Message)
+	}
+	assertSteady(t, c, "after the early swap")
+}
+
+// A notice is swap-only. A group with nothing safe to move onto keeps running on
+// the node until it is actually lost, and a spare on the doomed node is left to
+// die with it: failing work on a notice throws away the time the notice gave it.
+func TestImpendingLossNeverFailsAGroup(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	cases := []struct {
+		name   string
+		leases []v1.GPULease
+	}{
+		{"no spare", []v1.GPULease{
+			nfLease("active", "run", "org:ai:team", "team", []string{"node-a#0", "node-a#1"}, binder.RoleActive, now),
+		}},
+		{"spare on the doomed node", []v1.GPULease{
+			nfLease("active", "run", "org:ai:team", "team", []string{"node-a#0", "node-a#1"}, binder.RoleActive, now),
+			nfLease("spare", "run", "org:ai:team", "team", []string{"node-a#2", "node-a#3"}, binder.RoleSpare, now),
+		}},
+	}
+	for _, tc := range cases {
+		state := &ClusterState{
+			Nodes:   nodeFailureNodes(),
+			Budgets: []v1.Budget{nfBudget("team", "org:ai:team")},
+			Runs:    map[string]*v1.Run{"default/run": nfRun("run", "org:ai:team", 2, now)},
+			Leases:  tc.leases,
+		}
+		mirrorPods(state)
+		c := NewRunController(state, runClock{now: now})
+
+		if err := c.HandleImpendingNodeLoss("node-a", now.Add(30*time.Second), now); err != nil {
+			t.Fatalf("%s: handle impending loss: %v", tc.name, err)
+		}
+		for i := range state.Leases {
+			if lease := &state.Leases[i]; lease.Status.Closed {
+				t.Errorf("%s: %s closed with %q on a notice", tc.name, lease.Name, lease.Status.ClosureReason)
+			}
+		}
+		if run := state.Runs["default/run"]; run.Status.Phase != RunPhaseRunning {
+			t.Errorf("%s: the run must keep running out its notice, got %s (%s)", tc.name, run.Status.Phase, run.Status.Message)
+		}
+	}
+}
@@ -7,6 +7,7 @@ package kube
 import (
 	"context"
 	"fmt"
+	"math"
 	"reflect"
 	"strconv"
 	"strings"
@@ -25,17 +26,21 @@ import (
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/admission"
 	"github.com/davidlangworthy/jobtree/pkg/binder"
 	"github.com/davidlangworthy/jobtree/pkg/invariant"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
 	"github.com/davidlangworthy/jobtree/pkg/metrics"
+	"github.com/davidlangworthy/jobtree/pkg/snapshot"
+	"github.com/davidlangworthy/jobtree/pkg/sweep"
 	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
 
 const (
 	// GPUCapacityResource is the node capacity key the engine reads GPU
-	// counts from (the fake or real device plugin advertises it).
-	GPUCapacityResource = "nvidia.com/gpu"
+	// counts from (the fake or real device plugin advertises it). MIG slices
+	// are read from the nvidia.com/mig-<profile> keys beside it.
+	GPUCapacityResource = topology.GPUResource
 	// PodGPUAnnotation records how many GPUs of its node a workload pod
 	// claims. Aliased to the shared binder constant so the plugin reads the
 	// same key.
@@ -52,14 +57,20 @@ const (
 // Bridge loads ClusterState snapshots from the API server and applies the
 // engine's mutations back.
 //
-// All world access is serialized through one mutex and reads bypass the
-// informer cache: specs/BudgetConservation.tla shows that concurrent
-// admissions deciding from stale snapshots overspend envelopes, so exactly
-// one engine evaluation runs at a time, reading its own writes.
+// All world access is serialized through one mutex, and every pass reads its
+// own writes: specs/BudgetConservation.tla shows that concurrent admissions
+// deciding from stale snapshots overspend envelopes, so exactly one engine
+// evaluation runs at a time, starting from everything the last one wrote.
+// Without a World the snapshot is listed uncached through the APIReader; with
+// one it is read from the informer cache, overlaid with the writes the watch
+// has not yet delivered.
 type Bridge struct {
 	Client    client.Client
 	APIReader client.Reader
-	Clock     controllers.Clock
+	// World, when set, serves the engine's snapshot from memory instead of
+	// APIReader Lists. cmd/manager wires NewWorld(mgr.GetCache()).
+	World *World
+	Clock controllers.Clock
 	// Period is the accounting horizon for the funding derivation's
 	// admission lookahead (<= 0 uses funding.DefaultPeriod).
 	Period time.Duration
@@ -67,6 +78,11 @@ type Bridge struct {
 	// activate/resolver-action/swap/complete transitions. Nil is safe (no
 	// events are emitted); cmd/manager wires mgr.GetEventRecorderFor.
 	Recorder record.EventRecorder
+	// LossSources recognise termination notices on Nodes. A node under notice is
+	// not capacity — nothing new is placed on a machine that is about to go — and
+	// the node reconciler swaps its ranks onto spares while it is still up. Nil
+	// disables both; cmd/manager wires DefaultImpendingLossSources.
+	LossSources []ImpendingLossSource
 
 	mu sync.Mutex
 }
@@ -182,29 +198,60 @@ func (b *Bridge) reportSweep(ctx context.Context, sweep controllers.Sweep, snap
 	invariant.Report("Bridge.SettleLeases", violations)
 }
 
+// reader is where the engine's snapshot comes from: the World when one is
+// wired, the uncached APIReader otherwise.
+func (b *Bridge) reader() client.Reader {
+	if b.World != nil {
+		return b.World
+	}
+	return b.APIReader
+}
+
+// wrote hands an object the API server just returned to the World, so the
+// next pass reads it even before the watch delivers it. prev is the
+// resourceVersion the write was made over, empty for a create.
+func (b *Bridge) wrote(obj client.Object, prev string) {
+	if b.World != nil {
+		b.World.Wrote(obj, prev)
+	}
+}
+
 func (b *Bridge) load(ctx context.Context) (*worldSnapshot, error) {
+	reader := b.reader()
 	var runList v1.RunList
-	if err := b.APIReader.List(ctx, &runList); err != nil {
+	if err := reader.List(ctx, &runList); err != nil {
 		return nil, fmt.Errorf("list runs: %w", err)
 	}
 	var budgetList v1.BudgetList
-	if err := b.APIReader.List(ctx, &budgetList); err != nil {
+	if err := reader.List(ctx, &budgetList); err != nil {
 		return nil, fmt.Errorf("list budgets: %w", err)
 	}
 	var leaseList v1.GPULeaseList
-	if err := b.APIReader.List(ctx, &leaseList); err != nil {
+	if err := reader.List(ctx, &leaseList); err != nil {
 		return nil, fmt.Errorf("list leases: %w", err)
 	}
+	var archiveList v1.LeaseArchiveList
+	if err := reader.List(ctx, &archiveList); err != nil {
+		return nil, fmt.Errorf("list lease archives: %w", err)
+	}
+	var hierarchyList v1.TopologyHierarchyList
+	if err := reader.List(ctx, &hierarchyList); err != nil {
+		return nil, fmt.Errorf("list topology hierarchies: %w", err)
+	}
+	var snapshotList v1.QuotaSnapshotList
+	if err := reader.List(ctx, &snapshotList); err != nil {
+		return nil, fmt.Errorf("list quota snapshots: %w", err)
+	}
 	var reservationList v1.ReservationList
-	if err := b.APIReader.List(ctx, &reservationList); err != nil {
+	if err := reader.List(ctx, &reservationList); err != nil {
 		return nil, fmt.Errorf("list reservations: %w", err)
 	}
 	var nodeList corev1.NodeList
-	if err := b.APIReader.List(ctx, &nodeList); err != nil {
+	if err := reader.List(ctx, &nodeList); err != nil {
 		return nil, fmt.Errorf("list nodes: %w", err)
 	}
 	var podList corev1.PodList
-	if err := b.APIReader.List(ctx, &podList, client.HasLabels{binder.LabelRunName}); err != nil {
+	if err := reader.List(ctx, &podList, client.HasLabels{binder.LabelRunName}); err != nil {
 		return nil, fmt.Errorf("list pods: %w", err)
 	}
 
@@ -213,6 +260,9 @@ func (b *Bridge) load(ctx context.Context) (*worldSnapshot, error) {
 		Budgets:      budgetList.Items,
 		Leases:       leaseList.Items,
 		Reservations: make(map[string]*v1.Reservation, len(reservationList.Items)),
+		Archives:     archiveList.Items,
+		Transfers:    snapshot.PublishedTransfers(snapshotList.Items),
+		Topology:     admission.HierarchyFrom(hierarchyList.Items),
 	}
 	snap := &worldSnapshot{
 		state:        state,
@@ -243,18 +293,13 @@ func (b *Bridge) load(ctx context.Context) (*worldSnapshot, error) {
 		// An unusable node's GPUs must not look schedulable: the node
 		// reconciler evacuates such nodes by closing their leases, which
 		// would otherwise make the dead capacity immediately re-admittable.
-		if !nodeUsable(node) {
+		if !topology.NodeUsable(node) {
 			continue
 		}
-		gpus := 0
-		if qty, ok := node.Status.Capacity[GPUCapacityResource]; ok {
-			gpus = int(qty.Value())
+		if _, doomed := impendingLoss(b.LossSources, node); doomed {
+			continue
 		}
-		state.Nodes = append(state.Nodes, topology.SourceNode{
-			Name:   node.Name,
-			Labels: node.Labels,
-			GPUs:   gpus,
-		})
+		state.Nodes = append(state.Nodes, topology.SourceNodeOf(node))
 	}
 	for i := range podList.Items {
 		pod := &podList.Items[i]
@@ -311,18 +356,23 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 			if err := b.Client.Create(ctx, created); err != nil {
 				return fmt.Errorf("create lease %s: %w", key, err)
 			}
+			b.wrote(created, "")
 			if !reflect.DeepEqual(status, v1.GPULeaseStatus{}) {
 				created.Status = status
+				prev := created.ResourceVersion
 				if err := b.Client.Status().Update(ctx, created); err != nil {
 					return fmt.Errorf("update lease status %s: %w", key, err)
 				}
+				b.wrote(created, prev)
 			}
 			continue
 		}
 		if !reflect.DeepEqual(before.Status, lease.Status) {
+			prev := lease.ResourceVersion
 			if err := b.Client.Status().Update(ctx, lease); err != nil {
 				return fmt.Errorf("update lease status %s: %w", key, err)
 			}
+			b.wrote(lease, prev)
 		}
 	}
 
@@ -343,16 +393,24 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 				}
 				ensuredSvc[run.Name] = true
 			}
-			if err := b.Client.Create(ctx, buildPod(manifest, run)); err != nil {
+			pod := buildPod(manifest, run)
+			if err := b.Client.Create(ctx, pod); err != nil {
 				return fmt.Errorf("create pod %s: %w", key, err)
 			}
+			b.wrote(pod, "")
 		}
 	}
+	now := b.Clock.Now()
 	for key, pod := range snap.pods {
+		run := state.Runs[keys.NamespacedKey(pod.Namespace, pod.Labels[binder.LabelRunName])]
 		if _, still := current[key]; !still {
-			if err := b.Client.Delete(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+			if err := b.deletePod(ctx, pod, run, now); err != nil {
 				return fmt.Errorf("delete pod %s: %w", key, err)
 			}
+			continue
+		}
+		if err := b.signalTimeLimit(ctx, pod, run); err != nil {
+			return fmt.Errorf("annotate pod %s: %w", key, err)
 		}
 	}
 
@@ -379,18 +437,23 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 			if err := b.Client.Create(ctx, created); err != nil {
 				return fmt.Errorf("create reservation %s: %w", key, err)
 			}
+			b.wrote(created, "")
 			if !reflect.DeepEqual(status, v1.ReservationStatus{}) {
 				created.Status = status
+				prev := created.ResourceVersion
 				if err := b.Client.Status().Update(ctx, created); err != nil {
 					return fmt.Errorf("update reservation status %s: %w", key, err)
 				}
+				b.wrote(created, prev)
 			}
 			continue
 		}
 		if !reflect.DeepEqual(before.Status, res.Status) {
+			prev := res.ResourceVersion
 			if err := b.Client.Status().Update(ctx, res); err != nil {
 				return fmt.Errorf("update reservation status %s: %w", key, err)
 			}
+			b.wrote(res, prev)
 		}
 	}
 	for key, res := range snap.reservations {
@@ -398,6 +461,9 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 			if err := b.Client.Delete(ctx, res); err != nil && !apierrors.IsNotFound(err) {
 				return fmt.Errorf("delete reservation %s: %w", key, err)
 			}
+			if b.World != nil {
+				b.World.Deleted(res)
+			}
 		}
 	}
 
@@ -408,11 +474,74 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 			continue
 		}
 		if !reflect.DeepEqual(before.Status, run.Status) {
+			prev := run.ResourceVersion
 			if err := b.Client.Status().Update(ctx, run); err != nil {
 				return fmt.Errorf("update run status %s: %w", key, err)
 			}
+			b.wrote(run, prev)
+		}
+	}
+	return nil
+}
+
+// deletePod deletes a pod the engine dropped. A pod of a run inside its drain
+// window (a checkpointed run the resolver cut) is first annotated with the drain
+// deadline and then deleted with the time left as its grace, so the kubelet
+// sends SIGTERM, runs the template's preStop hooks, and waits for the checkpoint
+// before it kills the container. Every other pod takes its own grace period.
+func (b *Bridge) deletePod(ctx context.Context, pod *corev1.Pod, run *v1.Run, now time.Time) error {
+	var opts []client.DeleteOption
+	if run != nil && run.Status.DrainDeadline != nil && now.Before(run.Status.DrainDeadline.Time) {
+		deadline := run.Status.DrainDeadline.Time
+		prev := pod.ResourceVersion
+		patch := client.MergeFrom(pod.DeepCopy())
+		if pod.Annotations == nil {
+			pod.Annotations = map[string]string{}
+		}
+		pod.Annotations[binder.AnnotationDrainDeadline] = deadline.UTC().Format(time.RFC3339)
+		if err := b.Client.Patch(ctx, pod, patch); err != nil {
+			if apierrors.IsNotFound(err) {
+				return nil
+			}
+			return err
+		}
+		b.wrote(pod, prev)
+		grace := int64(math.Ceil(deadline.Sub(now).Seconds()))
+		opts = append(opts, client.GracePeriodSeconds(grace))
+	}
+	if err := b.Client.Delete(ctx, pod, opts...); err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	if b.World != nil {
+		b.World.Deleted(pod)
+	}
+	return nil
+}
+
+// signalTimeLimit annotates a live pod of a run warned of its time limit with
+// the deadline. A workload that projects its annotations through the downward
+// API sees the file appear and checkpoints before its leases close.
+func (b *Bridge) signalTimeLimit(ctx context.Context, pod *corev1.Pod, run *v1.Run) error {
+	if run == nil || run.Status.TimeLimit == nil || run.Status.TimeLimit.WarnedAt == nil {
+		return nil
+	}
+	deadline := run.Status.TimeLimit.Deadline.UTC().Format(time.RFC3339)
+	if pod.Annotations[binder.AnnotationTimeLimit] == deadline {
+		return nil
+	}
+	prev := pod.ResourceVersion
+	patch := client.MergeFrom(pod.DeepCopy())
+	if pod.Annotations == nil {
+		pod.Annotations = map[string]string{}
+	}
+	pod.Annotations[binder.AnnotationTimeLimit] = deadline
+	if err := b.Client.Patch(ctx, pod, patch); err != nil {
+		if apierrors.IsNotFound(err) {
+			return nil
 		}
+		return err
 	}
+	b.wrote(pod, prev)
 	return nil
 }
 
@@ -450,7 +579,8 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 //   - labels: LabelRunName / LabelGroupIndex / LabelRunRole merged in
 //     (see pkg/binder); researcher labels preserved
 //   - resources.limits["nvidia.com/gpu"] == requests == role.GPUsPerPod, on
-//     the GPU-target container (v1.GPUTargetContainerName, else index 0)
+//     the GPU-target container (v1.GPUTargetContainerName, else index 0); a
+//     MIG flavor requests its nvidia.com/mig-<profile> resource instead
 //
 // Not yet honoured, and deliberately not claimed anywhere a user can read:
 //
@@ -464,8 +594,8 @@ func (b *Bridge) apply(ctx context.Context, snap *worldSnapshot) error {
 //     reason WorkloadFailed, so a Failed pod is terminal rather than hanging
 //     the Run forever and charging its budget — R9 phase 9A-3 (absorbs R8)
 //
-// v1 admits exactly one role (webhook-validated), so today this renders a
-// single-role Run; multi-role RL gangs extend it additively.
+// A multi-role Run renders each pod from the role its role-name label names;
+// a single-role Run's pods carry no such label and take its only role.
 func buildPod(manifest binder.PodManifest, run *v1.Run) *corev1.Pod {
 	var spec corev1.PodSpec
 	targetIdx := 0
@@ -480,8 +610,8 @@ func buildPod(manifest binder.PodManifest, run *v1.Run) *corev1.Pod {
 			Image:   defaultWorkloadImage,
 			Command: []string{"sh", "-c", "echo jobtree-hot-spare; sleep 2147483647"},
 		}}}
-	case run != nil && len(run.Spec.Roles) > 0:
-		role := &run.Spec.Roles[0]
+	case podRole(run, manifest) != nil:
+		role := podRole(run, manifest)
 		spec = *role.Template.Spec.DeepCopy()
 		if idx := role.GPUTargetContainerIndex(); idx >= 0 {
 			targetIdx = idx
@@ -491,6 +621,9 @@ func buildPod(manifest binder.PodManifest, run *v1.Run) *corev1.Pod {
 			Name:    v1.GPUTargetContainerName,
 			Image:   defaultWorkloadImage,
 			Command: []string{"sh", "-c", "echo jobtree-placeholder; true"},
+			// A sweep child with no roles has no template for the sweep to
+			// stamp, so its parameters ride on the Run (sweep.Env).
+			Env: sweep.Env(run),
 		}}}
 	}
 
@@ -511,14 +644,18 @@ func buildPod(manifest binder.PodManifest, run *v1.Run) *corev1.Pod {
 		if c.Resources.Limits == nil {
 			c.Resources.Limits = corev1.ResourceList{}
 		}
-		c.Resources.Requests[GPUCapacityResource] = *q
-		c.Resources.Limits[GPUCapacityResource] = *q
+		resource := corev1.ResourceName(topology.ResourceName(run.Flavor()))
+		c.Resources.Requests[resource] = *q
+		c.Resources.Limits[resource] = *q
 	}
 
 	// Rendezvous env for distributed training (R9 9A-2), derived from the pod's
 	// ordinal hostname + the run's shape — so it is correct on every mint path
 	// (initial, top-up, swap) without per-path stamping.
 	injectRendezvousEnv(&spec, targetIdx, run, manifest)
+	if manifest.Labels[binder.LabelRunRole] != binder.RoleSpare {
+		injectUpstreamEnv(&spec, run)
+	}
 
 	// Advisory placement toward pack's chosen node: a preference the plugin's
 	// Filter/Score honor, NOT a pin.
@@ -527,8 +664,8 @@ func buildPod(manifest binder.PodManifest, run *v1.Run) *corev1.Pod {
 	}
 
 	annotations := map[string]string{PodGPUAnnotation: strconv.Itoa(manifest.GPUs)}
-	if run != nil && run.Spec.Resources.GPUType != "" {
-		annotations[binder.AnnotationFlavor] = run.Spec.Resources.GPUType
+	if run != nil && run.Flavor() != "" {
+		annotations[binder.AnnotationFlavor] = run.Flavor()
 	}
 	// Carry the run's per-incarnation nonce (a prefix of its UID) so the plugin's
 	// minted lease name is unique per incarnation — a delete+resubmit of a same-
@@ -640,7 +777,7 @@ func injectRendezvousEnv(spec *corev1.PodSpec, targetIdx int, run *v1.Run, manif
 	if run == nil || run.Spec.Malleable != nil || manifest.Labels[binder.LabelRunRole] != binder.RoleActive {
 		return
 	}
-	gpusPerPod, width := gangShape(run)
+	gpusPerPod, width := gangShape(run, manifest)
 	if width <= 1 || targetIdx < 0 || targetIdx >= len(spec.Containers) {
 		return
 	}
@@ -656,8 +793,14 @@ func injectRendezvousEnv(spec *corev1.PodSpec, targetIdx int, run *v1.Run, manif
 	if rank < 0 {
 		return
 	}
+	// Each role of a multi-role gang is its own torch world: its ranks meet at
+	// the role's rank 0, not the run's.
+	master := fmt.Sprintf("%s-active-0", run.Name)
+	if role := manifest.Labels[binder.LabelRoleName]; role != "" {
+		master = fmt.Sprintf("%s-%s-0", run.Name, role)
+	}
 	vals := map[string]string{
-		"MASTER_ADDR": fmt.Sprintf("%s-active-0.%s.%s.svc", run.Name, svc, run.Namespace),
+		"MASTER_ADDR": fmt.Sprintf("%s.%s.%s.svc", master, svc, run.Namespace),
 		"MASTER_PORT": "29500",
 		"WORLD_SIZE":  strconv.Itoa(width * gpusPerPod),
 		"NNODES":      strconv.Itoa(width),
@@ -676,16 +819,77 @@ func injectRendezvousEnv(spec *corev1.PodSpec, targetIdx int, run *v1.Run, manif
 	}
 }
 
-// gangShape is the (gpusPerPod, pod-count) of a run's base gang — mirrors
-// controllers.intentPodShape across the package boundary.
-func gangShape(run *v1.Run) (gpusPerPod, width int) {
-	if len(run.Spec.Roles) > 0 {
-		r := &run.Spec.Roles[0]
+// UpstreamsEnv lists, comma-separated, the runs a followed run waited on.
+// Each upstream that keeps durable outputs also gets
+// JOBTREE_UPSTREAM_<NAME>_ARTIFACTS, its /artifacts volume as a URI, with the
+// name upper-cased and every other non-alphanumeric byte made an underscore.
+const UpstreamsEnv = "JOBTREE_UPSTREAMS"
+
+// injectUpstreamEnv hands a followed run its upstreams' outputs, as the follow
+// gate recorded them in status.upstreams. It sets every container, not just the
+// GPU target: staging an upstream's checkpoint is often a sidecar's job.
+func injectUpstreamEnv(spec *corev1.PodSpec, run *v1.Run) {
+	if run == nil || len(run.Status.Upstreams) == 0 {
+		return
+	}
+	names := make([]string, 0, len(run.Status.Upstreams))
+	vars := []corev1.EnvVar{{Name: UpstreamsEnv}}
+	for _, up := range run.Status.Upstreams {
+		names = append(names, up.Name)
+		if up.Artifacts != "" {
+			vars = append(vars, corev1.EnvVar{Name: UpstreamArtifactsEnv(up.Name), Value: up.Artifacts})
+		}
+	}
+	vars[0].Value = strings.Join(names, ",")
+	for i := range spec.Containers {
+		ct := &spec.Containers[i]
+		kept := ct.Env[:0]
+		for _, e := range ct.Env {
+			if e.Name != UpstreamsEnv && !strings.HasPrefix(e.Name, "JOBTREE_UPSTREAM_") {
+				kept = append(kept, e)
+			}
+		}
+		ct.Env = append(kept, vars...)
+	}
+}
+
+// UpstreamArtifactsEnv is the env var carrying upstream's artifacts URI.
+func UpstreamArtifactsEnv(upstream string) string {
+	name := []byte(strings.ToUpper(upstream))
+	for i, b := range name {
+		if (b < 'A' || b > 'Z') && (b < '0' || b > '9') {
+			name[i] = '_'
+		}
+	}
+	return "JOBTREE_UPSTREAM_" + string(name) + "_ARTIFACTS"
+}
+
+// gangShape is the (gpusPerPod, pod-count) of the role manifest belongs to —
+// mirrors controllers.intentPodShape across the package boundary, per role.
+func gangShape(run *v1.Run, manifest binder.PodManifest) (gpusPerPod, width int) {
+	if r := podRole(run, manifest); r != nil {
 		return int(r.GPUsPerPod), int(r.Width)
 	}
 	return 1, int(run.Spec.Resources.TotalGPUs)
 }
 
+// podRole is the RunRole a pod renders: the one its role-name label names on a
+// multi-role run, else the run's first (only) role; nil for a Roles-less run.
+func podRole(run *v1.Run, manifest binder.PodManifest) *v1.RunRole {
+	if run == nil || len(run.Spec.Roles) == 0 {
+		return nil
+	}
+	if name := manifest.Labels[binder.LabelRoleName]; name != "" {
+		for i := range run.Spec.Roles {
+			if run.Spec.Roles[i].Name == name {
+				return &run.Spec.Roles[i]
+			}
+		}
+		return nil
+	}
+	return &run.Spec.Roles[0]
+}
+
 // podOrdinal parses the rank from a pod's ordinal name/hostname (`…-<i>`); -1 if none.
 func podOrdinal(name string) int {
 	i := strings.LastIndex(name, "-")
@@ -0,0 +1,85 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+)
+
+// A renewal awaiting approval is announced and left pending; once the Budget
+// is annotated, the reconciler writes the next window into the spec, announces
+// it, and the envelope drops off status.pendingRenewals.
+func TestBudgetReconcilerRenewsOnApproval(t *testing.T) {
+	now := time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC)
+	start, end := v1.NewTime(now.Add(-30*24*time.Hour)), v1.NewTime(now.Add(24*time.Hour))
+	budget := &v1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Spec: v1.BudgetSpec{
+			Owner: "org:team",
+			Envelopes: []v1.BudgetEnvelope{
+				{Name: "west", Flavor: "H100", Selector: map[string]string{"region": "us-west"}, Concurrency: 8, Start: &start, End: &end},
+			},
+			AutoRenew: &v1.AutoRenewSchedule{
+				NotifyBefore:    metav1.Duration{Duration: 48 * time.Hour},
+				RequireApproval: true,
+			},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(budget).WithStatusSubresource(&v1.Budget{}).Build()
+	rec := record.NewFakeRecorder(8)
+	r := &BudgetReconciler{Client: c, APIReader: c, Clock: staticClock{now}, Recorder: rec}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "team"}}
+	ctx := context.Background()
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	var got v1.Budget
+	if err := c.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got.Spec.Envelopes) != 1 || len(got.Status.PendingRenewals) != 1 {
+		t.Fatalf("unapproved: %d envelopes, pending %+v; want 1 and west pending", len(got.Spec.Envelopes), got.Status.PendingRenewals)
+	}
+	if !hasEvent(rec, "RenewalAwaitingApproval") {
+		t.Fatal("a held renewal must be announced")
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if hasEvent(rec, "RenewalAwaitingApproval") {
+		t.Fatal("a held renewal is announced once, not on every resync")
+	}
+	if err := c.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	got.Annotations = map[string]string{controllers.AnnotationApproveRenewal: "west"}
+	if err := c.Update(ctx, &got); err != nil {
+		t.Fatalf("approve: %v", err)
+	}
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if err := c.Get(ctx, req.NamespacedName, &got); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if len(got.Spec.Envelopes) != 2 || got.Spec.Envelopes[1].Name != "west-r2" || got.Spec.Envelopes[1].RenewalOf != "west" {
+		t.Fatalf("approved: envelopes %+v, want west-r2 renewing west", got.Spec.Envelopes)
+	}
+	if len(got.Status.PendingRenewals) != 0 {
+		t.Fatalf("a renewed envelope is no longer pending: %+v", got.Status.PendingRenewals)
+	}
+	if !hasEvent(rec, "EnvelopeRenewed") {
+		t.Fatal("a renewal must be announced")
+	}
+}
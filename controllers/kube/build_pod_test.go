@@ -1,6 +1,7 @@
 package kube
 
 import (
+	"reflect"
 	"strings"
 	"testing"
 
@@ -9,6 +10,7 @@ import (
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/sweep"
 )
 
 // R2: buildPod stamps a per-incarnation run nonce (a 12-char prefix of the Run
@@ -54,6 +56,30 @@ func TestBuildPodStampsRunNonce(t *testing.T) {
 	}
 }
 
+// A Roles-less sweep child has no template to stamp, so its parameters reach
+// the default container through the child's sweep-env annotation.
+func TestBuildPodSetsARolesLessSweepChildsEnv(t *testing.T) {
+	run := &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: "lr-1", Namespace: "default",
+			Annotations: map[string]string{sweep.AnnotationSweepEnv: `{"SWEEP_LR":"3e-4"}`}},
+		Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1}},
+	}
+	manifest := binder.PodManifest{
+		Namespace: "default", Name: "lr-1-active-0", GPUs: 1,
+		Labels: map[string]string{binder.LabelRunName: "lr-1", binder.LabelRunRole: binder.RoleActive},
+	}
+	pod := buildPod(manifest, run)
+	var got string
+	for _, e := range pod.Spec.Containers[0].Env {
+		if e.Name == "SWEEP_LR" {
+			got = e.Value
+		}
+	}
+	if got != "3e-4" {
+		t.Errorf("SWEEP_LR = %q, want the trial's 3e-4; env %v", got, pod.Spec.Containers[0].Env)
+	}
+}
+
 // buildPod must render a real, UNSCHEDULED pod: the researcher's container is
 // preserved, only scheduling-owned fields are overlaid, and nodeName is never
 // set (the plugin places it).
@@ -253,3 +279,59 @@ func TestBuildPodSwapHardTargetsAndCarriesProvenance(t *testing.T) {
 		t.Errorf("required affinity = %+v, want a single hostname==node-b term", terms)
 	}
 }
+
+// A followed run's pods see each upstream, and the outputs of those that kept
+// any, in every container; a stale template value is replaced, not duplicated.
+func TestBuildPodInjectsUpstreamArtifacts(t *testing.T) {
+	run := &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: "train", Namespace: "default"},
+		Spec: v1.RunSpec{
+			Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1},
+			Roles: []v1.RunRole{{Name: "trainer", Width: 1, GPUsPerPod: 1, Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+				Containers: []corev1.Container{
+					{Name: v1.GPUTargetContainerName, Image: "trainer:1", Env: []corev1.EnvVar{{Name: UpstreamsEnv, Value: "stale"}}},
+					{Name: "stager", Image: "stager:1"},
+				},
+			}}}},
+		},
+		Status: v1.RunStatus{Upstreams: []v1.RunUpstream{
+			{Name: "pre-process", Phase: "Completed", Artifacts: "pvc://prep-out"},
+			{Name: "audit", Phase: "Skipped"},
+		}},
+	}
+	manifest := binder.PodManifest{
+		Namespace: "default", Name: "train-active-0", GPUs: 1,
+		Labels: map[string]string{binder.LabelRunName: "train", binder.LabelRunRole: binder.RoleActive},
+	}
+	pod := buildPod(manifest, run)
+	want := []corev1.EnvVar{
+		{Name: UpstreamsEnv, Value: "pre-process,audit"},
+		{Name: "JOBTREE_UPSTREAM_PRE_PROCESS_ARTIFACTS", Value: "pvc://prep-out"},
+	}
+	for _, ct := range pod.Spec.Containers {
+		if !reflect.DeepEqual(ct.Env, want) {
+			t.Errorf("container %s env = %v, want %v", ct.Name, ct.Env, want)
+		}
+	}
+}
+
+// A MIG flavor's pod requests slices of its profile, not whole GPUs, so the
+// default NodeResourcesFit counts it against the node's MIG capacity.
+func TestBuildPodRequestsTheMIGResource(t *testing.T) {
+	run := &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: "eval", Namespace: "default"},
+		Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "a100-1g.10gb", TotalGPUs: 2}},
+	}
+	manifest := binder.PodManifest{
+		Namespace: "default", Name: "eval-active-0", GPUs: 2,
+		Labels: map[string]string{binder.LabelRunName: "eval", binder.LabelRunRole: binder.RoleActive},
+	}
+	c := buildPod(manifest, run).Spec.Containers[0]
+	mig := corev1.ResourceName("nvidia.com/mig-1g.10gb")
+	if got := c.Resources.Requests[mig]; got.Value() != 2 {
+		t.Errorf("request %s = %s, want 2", mig, got.String())
+	}
+	if _, ok := c.Resources.Limits[GPUCapacityResource]; ok {
+		t.Errorf("a MIG pod also requests whole GPUs: %v", c.Resources.Limits)
+	}
+}
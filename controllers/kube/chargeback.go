@@ -0,0 +1,79 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+)
+
+// ChargebackHandler serves the GPU-hour chargeback statement for a period:
+//
+//	GET /chargeback?from=2026-09-01&to=2026-10-01&format=csv
+//
+// from is required; to defaults to now; format is csv (default) or json. It reads
+// the whole ledger through the uncached Reader and folds it with
+// funding.Chargeback, the same fold `kubectl runs report chargeback` runs.
+type ChargebackHandler struct {
+	// Reader is set once the manager exists; until then the handler answers 503.
+	Reader client.Reader
+	Clock  controllers.Clock
+	// Period is the accounting horizon for the funding derivation.
+	Period time.Duration
+}
+
+func (h *ChargebackHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Reader == nil {
+		http.Error(w, "ledger reader not ready", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+	if query.Get("from") == "" {
+		http.Error(w, "from is required", http.StatusBadRequest)
+		return
+	}
+	from, err := funding.ParseInstant(query.Get("from"))
+	if err != nil {
+		http.Error(w, "from: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	to := h.Clock.Now()
+	if raw := query.Get("to"); raw != "" {
+		if to, err = funding.ParseInstant(raw); err != nil {
+			http.Error(w, "to: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	in, err := ledger.List(r.Context(), h.Reader)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	in.Period = h.Period
+	stmt, err := funding.Chargeback(in, from, to)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	switch format := query.Get("format"); format {
+	case "", "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		_ = stmt.WriteCSV(w)
+	case "json":
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(stmt)
+	default:
+		http.Error(w, fmt.Sprintf("format %q: want csv or json", format), http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,61 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+)
+
+func TestChargebackHandlerServesTheStatement(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	run := &v1.Run{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default"}}
+	lease := openLeaseOn("train-lease", "train", "node-a")
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(run, lease).Build()
+
+	if code := serveChargeback(&ChargebackHandler{Clock: staticClock{now}}, "/chargeback?from=2026-01-01").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("before the manager exists: got %d, want 503", code)
+	}
+	h := &ChargebackHandler{Reader: c, Clock: staticClock{now}}
+	for _, target := range []string{"/chargeback", "/chargeback?from=yesterday", "/chargeback?from=2026-01-01&format=xml"} {
+		if code := serveChargeback(h, target).Code; code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", target, code)
+		}
+	}
+
+	from := now.Add(-time.Hour).Format(time.RFC3339)
+	rec := serveChargeback(h, "/chargeback?format=json&from="+from)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("json: got %d: %s", rec.Code, rec.Body.String())
+	}
+	var stmt funding.Statement
+	if err := json.Unmarshal(rec.Body.Bytes(), &stmt); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	// No Budget backs the lease's envelope, so its minute is billed Unfunded.
+	if len(stmt.Lines) != 1 || stmt.Lines[0].Run != "default/train" || stmt.Lines[0].Class != funding.ClassUnfunded {
+		t.Fatalf("unexpected lines: %+v", stmt.Lines)
+	}
+	if got := stmt.Lines[0].GPUHours; got <= 0 || got > 2.0/60 {
+		t.Errorf("gpu-hours: got %.4f, want about one GPU-minute", got)
+	}
+
+	rec = serveChargeback(h, "/chargeback?from="+from)
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv" || !strings.HasPrefix(rec.Body.String(), strings.Join(funding.ChargebackHeaders, ",")) {
+		t.Errorf("csv is the default: content-type %q body %q", ct, rec.Body.String())
+	}
+}
+
+func serveChargeback(h http.Handler, target string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	return rec
+}
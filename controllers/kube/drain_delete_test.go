@@ -0,0 +1,47 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// A pod dropped from a run inside its drain window is told when its grace runs out
+// before it is deleted, so a preStop hook or a downward-API reader can checkpoint
+// against the deadline rather than the kubelet's default thirty seconds.
+func TestDeletePodAnnotatesADrainingRunsPod(t *testing.T) {
+	deadline := metav1.NewTime(time.Now().Add(10 * time.Minute).Truncate(time.Second))
+	run := liveRun("ckpt")
+	run.Status.DrainDeadline = &deadline
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "ckpt-active-0", Namespace: "default",
+		Labels: map[string]string{binder.LabelRunName: "ckpt"},
+		// Held by a finalizer so the fake client keeps the deleted object, as the
+		// apiserver keeps a pod through its grace period.
+		Finalizers: []string{"test/hold"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(pod).Build()
+	bridge := &Bridge{Client: c, APIReader: c, Clock: controllers.RealClock{}}
+
+	if err := bridge.deletePod(context.Background(), pod, run, time.Now()); err != nil {
+		t.Fatalf("deletePod: %v", err)
+	}
+	var got corev1.Pod
+	if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "ckpt-active-0"}, &got); err != nil {
+		t.Fatalf("get pod: %v", err)
+	}
+	if got.DeletionTimestamp.IsZero() {
+		t.Errorf("the pod was not deleted")
+	}
+	if want := deadline.UTC().Format(time.RFC3339); got.Annotations[binder.AnnotationDrainDeadline] != want {
+		t.Errorf("drain-deadline annotation = %q, want %q", got.Annotations[binder.AnnotationDrainDeadline], want)
+	}
+}
@@ -0,0 +1,117 @@
+package kube
+
+import (
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ImpendingLossSource recognises one way a platform announces that a node is
+// about to go away: a spot reclaim notice, a maintenance window, a drain taint
+// from a termination handler. It reports the instant the node is expected to be
+// lost; a zero deadline means the signal carries none, and the lead is recorded
+// as zero.
+//
+// A notice is not a fencing assertion. The node is still up and its kubelet still
+// answers, which is exactly what makes a swap safe to start early: the replaced
+// rank's pod is deleted gracefully and the kubelet stops it. NodeFailed is
+// unchanged, and a node only ever fails by being fenced.
+type ImpendingLossSource interface {
+	ImpendingLoss(node *corev1.Node) (deadline time.Time, ok bool)
+}
+
+// TaintLossSource reports loss for a node carrying the taint Key, due Lead after
+// the taint was added. Only NoExecute taints carry TimeAdded; on any other the
+// deadline is unknown.
+type TaintLossSource struct {
+	Key  string
+	Lead time.Duration
+}
+
+func (s TaintLossSource) ImpendingLoss(node *corev1.Node) (time.Time, bool) {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key != s.Key {
+			continue
+		}
+		if taint.TimeAdded == nil {
+			return time.Time{}, true
+		}
+		return taint.TimeAdded.Add(s.Lead), true
+	}
+	return time.Time{}, false
+}
+
+// ConditionLossSource reports loss for a node whose condition Type is True, due
+// Lead after the condition last turned True (the node-problem-detector style of
+// maintenance notice).
+type ConditionLossSource struct {
+	Type corev1.NodeConditionType
+	Lead time.Duration
+}
+
+func (s ConditionLossSource) ImpendingLoss(node *corev1.Node) (time.Time, bool) {
+	for _, cond := range node.Status.Conditions {
+		if cond.Type != s.Type || cond.Status != corev1.ConditionTrue {
+			continue
+		}
+		if cond.LastTransitionTime.IsZero() {
+			return time.Time{}, true
+		}
+		return cond.LastTransitionTime.Add(s.Lead), true
+	}
+	return time.Time{}, false
+}
+
+// AnnotationImpendingLoss is jobtree's own notice: an operator or a cloud hook
+// sets it on a Node to the RFC 3339 instant the node will be lost.
+const AnnotationImpendingLoss = "rq.davidlangworthy.io/impending-loss"
+
+// AnnotationLossSource reports loss for a node annotated with Key, whose value is
+// the RFC 3339 deadline. An unparsable value is still a notice, with no deadline:
+// the operator said the node is going, and a typo in when must not keep the gang
+// on it.
+type AnnotationLossSource struct {
+	Key string
+}
+
+func (s AnnotationLossSource) ImpendingLoss(node *corev1.Node) (time.Time, bool) {
+	value, ok := node.Annotations[s.Key]
+	if !ok {
+		return time.Time{}, false
+	}
+	deadline, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, true
+	}
+	return deadline, true
+}
+
+// DefaultImpendingLossSources are the notices cmd/manager watches unless told
+// otherwise: jobtree's own annotation, GKE's spot/preemptible termination taint
+// (30s notice), and the taints AWS Node Termination Handler applies for a spot
+// interruption (2m notice) and a scheduled maintenance event.
+func DefaultImpendingLossSources() []ImpendingLossSource {
+	return []ImpendingLossSource{
+		AnnotationLossSource{Key: AnnotationImpendingLoss},
+		TaintLossSource{Key: "cloud.google.com/impending-node-termination", Lead: 30 * time.Second},
+		TaintLossSource{Key: "aws-node-termination-handler/spot-itn", Lead: 2 * time.Minute},
+		TaintLossSource{Key: "aws-node-termination-handler/scheduled-maintenance"},
+	}
+}
+
+// impendingLoss returns the earliest deadline any source reports for node.
+func impendingLoss(sources []ImpendingLossSource, node *corev1.Node) (time.Time, bool) {
+	var deadline time.Time
+	found := false
+	for _, source := range sources {
+		d, ok := source.ImpendingLoss(node)
+		if !ok {
+			continue
+		}
+		if !found || (!d.IsZero() && (deadline.IsZero() || d.Before(deadline))) {
+			deadline = d
+		}
+		found = true
+	}
+	return deadline, found
+}
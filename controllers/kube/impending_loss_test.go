@@ -0,0 +1,135 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+func TestImpendingLossSourcesReadTheirNotices(t *testing.T) {
+	added := time.Date(2026, 7, 9, 12, 0, 0, 0, time.UTC)
+	spot := TaintLossSource{Key: "aws-node-termination-handler/spot-itn", Lead: 2 * time.Minute}
+	maintenance := ConditionLossSource{Type: "MaintenanceScheduled", Lead: time.Hour}
+	annotation := AnnotationLossSource{Key: AnnotationImpendingLoss}
+
+	for _, tc := range []struct {
+		name     string
+		source   ImpendingLossSource
+		node     *corev1.Node
+		ok       bool
+		deadline time.Time
+	}{
+		{"taint absent", spot, &corev1.Node{}, false, time.Time{}},
+		{"taint with TimeAdded", spot, &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{
+			Key: spot.Key, Effect: corev1.TaintEffectNoExecute, TimeAdded: &metav1.Time{Time: added},
+		}}}}, true, added.Add(2 * time.Minute)},
+		{"taint without TimeAdded", spot, &corev1.Node{Spec: corev1.NodeSpec{Taints: []corev1.Taint{{
+			Key: spot.Key, Effect: corev1.TaintEffectNoSchedule,
+		}}}}, true, time.Time{}},
+		{"condition true", maintenance, &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+			Type: "MaintenanceScheduled", Status: corev1.ConditionTrue, LastTransitionTime: metav1.NewTime(added),
+		}}}}, true, added.Add(time.Hour)},
+		{"condition false", maintenance, &corev1.Node{Status: corev1.NodeStatus{Conditions: []corev1.NodeCondition{{
+			Type: "MaintenanceScheduled", Status: corev1.ConditionFalse,
+		}}}}, false, time.Time{}},
+		{"annotation", annotation, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			AnnotationImpendingLoss: "2026-07-09T12:05:00Z",
+		}}}, true, added.Add(5 * time.Minute)},
+		{"unparsable annotation is still a notice", annotation, &corev1.Node{ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{
+			AnnotationImpendingLoss: "soon",
+		}}}, true, time.Time{}},
+	} {
+		deadline, ok := tc.source.ImpendingLoss(tc.node)
+		if ok != tc.ok || !deadline.Equal(tc.deadline) {
+			t.Errorf("%s: got (%v, %v), want (%v, %v)", tc.name, deadline, ok, tc.deadline, tc.ok)
+		}
+	}
+}
+
+// Several notices on one node: the earliest known deadline wins, and a notice
+// without one never masks one with.
+func TestImpendingLossTakesTheEarliestDeadline(t *testing.T) {
+	added := time.Date(2026, 7, 9, 12, 0, 0, 0, time.UTC)
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Annotations: map[string]string{AnnotationImpendingLoss: "2026-07-09T13:00:00Z"}},
+		Spec: corev1.NodeSpec{Taints: []corev1.Taint{
+			{Key: "cloud.google.com/impending-node-termination", Effect: corev1.TaintEffectNoExecute, TimeAdded: &metav1.Time{Time: added}},
+			{Key: "aws-node-termination-handler/scheduled-maintenance", Effect: corev1.TaintEffectNoSchedule},
+		}},
+	}
+	deadline, ok := impendingLoss(DefaultImpendingLossSources(), node)
+	if !ok || !deadline.Equal(added.Add(30*time.Second)) {
+		t.Errorf("got (%v, %v), want the GKE notice's deadline %v", deadline, ok, added.Add(30*time.Second))
+	}
+}
+
+// A Ready node under notice has its rank swapped onto the spare before it goes,
+// and stops counting as capacity. The same notice on a NotReady node moves
+// nothing: its kubelet may never act on the graceful delete of the old rank.
+func TestNodeUnderNoticeSwapsOnlyWhileReady(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	for _, ready := range []bool{true, false} {
+		doomed := healthyNode("node-a", 4)
+		doomed.Annotations = map[string]string{AnnotationImpendingLoss: now.Add(time.Minute).Format(time.RFC3339)}
+		if !ready {
+			doomed.Status.Conditions[0].Status = corev1.ConditionFalse
+		}
+		run := &v1.Run{
+			ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default"},
+			Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1}},
+			Status:     v1.RunStatus{Phase: controllers.RunPhaseRunning},
+		}
+		active := openLeaseOn("train-active", "train", "node-a")
+		spare := openLeaseOn("train-spare", "train", "node-b")
+		spare.Labels[binder.LabelRunRole] = binder.RoleSpare
+		spare.Spec.Slice.Role = binder.RoleSpare
+
+		c := fake.NewClientBuilder().WithScheme(testScheme()).
+			WithObjects(doomed, healthyNode("node-b", 4), run, active, spare).
+			WithStatusSubresource(&v1.Run{}, &v1.GPULease{}).
+			Build()
+		bridge := &Bridge{Client: c, APIReader: c, Clock: staticClock{now}, LossSources: DefaultImpendingLossSources()}
+
+		if _, err := (&NodeReconciler{Bridge: bridge}).Reconcile(context.Background(), ctrl.Request{
+			NamespacedName: types.NamespacedName{Name: "node-a"},
+		}); err != nil {
+			t.Fatalf("ready=%v: reconcile: %v", ready, err)
+		}
+
+		var got v1.GPULease
+		if err := c.Get(context.Background(), client.ObjectKey{Namespace: "default", Name: "train-active"}, &got); err != nil {
+			t.Fatalf("get lease: %v", err)
+		}
+		if !ready {
+			if got.Status.Closed {
+				t.Errorf("a notice on a NotReady node closed the lease (%q)", got.Status.ClosureReason)
+			}
+			continue
+		}
+		if !got.Status.Closed || got.Status.ClosureReason != "ImpendingNodeLoss(lead=1m0s)" {
+			t.Errorf("the doomed slice must close with its lead: closed=%v reason=%q", got.Status.Closed, got.Status.ClosureReason)
+		}
+		err := bridge.WithWorld(context.Background(), func(state *controllers.ClusterState, _ time.Time) error {
+			for _, n := range state.Nodes {
+				if n.Name == "node-a" {
+					t.Errorf("a node under notice was loaded as capacity")
+				}
+			}
+			return nil
+		})
+		if err != nil {
+			t.Fatalf("load: %v", err)
+		}
+	}
+}
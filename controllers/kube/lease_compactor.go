@@ -0,0 +1,191 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+)
+
+// The lease compactor keeps the ledger, and so every funding replay, bounded.
+//
+// Closed leases are never removed by anything else, and Bridge.load lists and
+// replays all of them on every reconcile. Once a window is older than the
+// retention, the compactor folds it into a LeaseArchive (funding.Compact) and
+// deletes the closed leases the archive holds whole. The replay then seeds its
+// accounts from the archives and starts at the latest Through, so nothing any
+// reader derives — envelope usage, run funding status, classification — moves.
+//
+// Ordering is what keeps that true for a reader that lists the ledger while a
+// sweep runs: the archive is created before any lease it folds is deleted, and
+// every reader lists leases before archives (ledger.List). The engine reads
+// through the World's informer caches instead, where the two kinds arrive on
+// separate watches; the compactor records the archive in that World before the
+// deletes, and the deletes after them, so the engine's reads keep the order. A reader that misses a deleted
+// lease therefore sees its archive, and one that sees an archive alongside the
+// leases it folded is unaffected, because the replay ignores everything before
+// the horizon.
+//
+// A folded lease is deleted only once its Run has finished or gone. The engine
+// still reads a live run's closed leases — a spare closed by a swap is the only
+// record that its slot is used up, and without it the run would provision the
+// spare again — and they cost the replay nothing: before the horizon they are
+// skipped.
+type LeaseCompactor struct {
+	Client    client.Client
+	APIReader client.Reader
+	Clock     controllers.Clock
+	Recorder  record.EventRecorder
+	// World is the Bridge's cached read path, when it has one. Nil when the
+	// engine lists uncached.
+	World *World
+
+	// Interval is the sweep cadence (default 1h).
+	Interval time.Duration
+	// Retention is how much recent ledger stays as raw leases (default 7 days).
+	// Only history older than this is folded.
+	Retention time.Duration
+	// Period is the accounting horizon the funding replay runs with.
+	Period time.Duration
+}
+
+// SetupWithManager registers the compactor as a manager Runnable; like the
+// ledger auditor it is a periodic sweep, not an object reconciler.
+func (c *LeaseCompactor) SetupWithManager(mgr ctrl.Manager) error {
+	c.defaults()
+	return mgr.Add(c)
+}
+
+func (c *LeaseCompactor) defaults() {
+	if c.Interval <= 0 {
+		c.Interval = time.Hour
+	}
+	if c.Retention <= 0 {
+		c.Retention = 7 * 24 * time.Hour
+	}
+	if c.Clock == nil {
+		c.Clock = controllers.RealClock{}
+	}
+}
+
+// NeedLeaderElection keeps compaction on the leader: two replicas folding the
+// same window would race to create the same archive.
+func (c *LeaseCompactor) NeedLeaderElection() bool { return true }
+
+// Start runs the sweep loop until the context is cancelled. Implements
+// manager.Runnable.
+func (c *LeaseCompactor) Start(ctx context.Context) error {
+	c.defaults()
+	ticker := time.NewTicker(c.Interval)
+	defer ticker.Stop()
+	logger := log.FromContext(ctx).WithName("lease-compactor")
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := c.Sweep(ctx); err != nil {
+				// The ledger is correct uncompacted, only slower to replay;
+				// the next tick retries.
+				logger.Error(err, "lease compaction sweep failed; will retry next interval")
+			}
+		}
+	}
+}
+
+// Sweep runs one compaction pass: fold everything older than the retention
+// into a new archive, then delete the folded leases of finished runs.
+// Exposed so tests drive a single pass with a controllable clock.
+func (c *LeaseCompactor) Sweep(ctx context.Context) error {
+	c.defaults()
+	logger := log.FromContext(ctx).WithName("lease-compactor")
+	in, err := c.loadLedger(ctx)
+	if err != nil {
+		return err
+	}
+	now := c.Clock.Now()
+	in.Now = now
+
+	horizon := funding.ArchiveHorizon(in.Archives)
+	if through := now.Add(-c.Retention); through.After(horizon) {
+		spec, err := funding.Compact(in, through)
+		if err != nil {
+			// Nothing new to fold: the window holds no ledger event.
+			logger.V(1).Info("nothing to compact", "reason", err.Error())
+		} else {
+			archive := &v1.LeaseArchive{
+				ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("ledger-%d", spec.Through.Unix())},
+				Spec:       spec,
+			}
+			switch err := c.Client.Create(ctx, archive); {
+			case apierrors.IsAlreadyExists(err):
+				// A previous sweep created it and failed before the deletes.
+				// The engine's cache may still lack it: read it back for the World.
+				if err := c.APIReader.Get(ctx, client.ObjectKeyFromObject(archive), archive); err != nil {
+					return fmt.Errorf("get lease archive %s: %w", archive.Name, err)
+				}
+				c.wroteArchive(archive)
+			case err != nil:
+				return fmt.Errorf("create lease archive %s: %w", archive.Name, err)
+			default:
+				c.wroteArchive(archive)
+				logger.Info("compacted the lease ledger", "archive", archive.Name,
+					"from", spec.From.Time, "through", spec.Through.Time, "foldedLeases", spec.FoldedLeases)
+				if c.Recorder != nil {
+					c.Recorder.Eventf(archive, corev1.EventTypeNormal, "LedgerCompacted",
+						"folded %d closed leases through %s", spec.FoldedLeases, spec.Through.UTC().Format(time.RFC3339))
+				}
+			}
+			horizon = spec.Through.Time
+		}
+	}
+	if horizon.IsZero() {
+		return nil
+	}
+
+	for i := range in.Leases {
+		lease := &in.Leases[i]
+		if !funding.FoldedBy(lease, horizon) {
+			continue
+		}
+		if run := in.Runs[keys.NamespacedKey(lease.Spec.RunRef.Namespace, lease.Spec.RunRef.Name)]; run != nil && !isTerminalPhase(run.Status.Phase) {
+			continue
+		}
+		if err := c.Client.Delete(ctx, lease); client.IgnoreNotFound(err) != nil {
+			return fmt.Errorf("delete folded lease %s/%s: %w", lease.Namespace, lease.Name, err)
+		}
+		if c.World != nil {
+			c.World.Deleted(lease)
+		}
+	}
+	return nil
+}
+
+// wroteArchive hands a just-written archive to the World, so the engine reads
+// it before any lease it folds goes missing from the cache.
+func (c *LeaseCompactor) wroteArchive(archive *v1.LeaseArchive) {
+	if c.World != nil {
+		c.World.Wrote(archive, "")
+	}
+}
+
+// loadLedger lists what the fold reads, in ledger.List's leases-then-archives
+// order.
+func (c *LeaseCompactor) loadLedger(ctx context.Context) (funding.Input, error) {
+	in, err := ledger.List(ctx, c.APIReader)
+	in.Period = c.Period
+	return in, err
+}
@@ -0,0 +1,167 @@
+package kube
+
+import (
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/record"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+)
+
+func ledgerLease(name, runName string, start time.Time, ended *time.Time) *v1.GPULease {
+	l := openLeaseOn(name, runName, "node-"+name)
+	l.Spec.Owner = "default"
+	l.Spec.PaidByBudgetNamespace = "default"
+	l.Spec.Interval.Start = metav1.NewTime(start)
+	if ended != nil {
+		at := metav1.NewTime(*ended)
+		l.Status.Closed, l.Status.Ended, l.Status.ClosureReason = true, &at, "Completed"
+	}
+	return l
+}
+
+func phasedRun(name, phase string) *v1.Run {
+	r := &v1.Run{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name}}
+	r.Status.Phase = phase
+	return r
+}
+
+// evaluateLedger replays what is in the API now, archives included.
+func evaluateLedger(t *testing.T, c client.Client, now time.Time) *funding.Evaluation {
+	t.Helper()
+	in, err := (&LeaseCompactor{APIReader: c}).loadLedger(context.Background())
+	if err != nil {
+		t.Fatalf("load ledger: %v", err)
+	}
+	in.Now = now
+	return funding.Evaluate(in)
+}
+
+// One sweep folds history older than the retention into an archive and
+// deletes the folded leases of finished runs, and nothing the replay derives
+// moves. A live run keeps its closed leases; a second sweep is a no-op.
+func TestCompactorFoldsOldHistoryAndDeletesFinishedRunsLeases(t *testing.T) {
+	start, now := baseTime, baseTime.Add(10*24*time.Hour)
+	ended := start.Add(24 * time.Hour)
+	budgetStart, budgetEnd := metav1.NewTime(start.Add(-time.Hour)), metav1.NewTime(now.Add(24*time.Hour))
+	budget := &v1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team"},
+		Spec: v1.BudgetSpec{Owner: "default", Envelopes: []v1.BudgetEnvelope{{
+			Name: "west", Flavor: "H100-80GB", Concurrency: 4, Start: &budgetStart, End: &budgetEnd,
+		}}},
+	}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(
+		budget,
+		phasedRun("done", controllers.RunPhaseComplete),
+		phasedRun("train", controllers.RunPhaseRunning),
+		ledgerLease("done-0", "done", start, &ended),
+		ledgerLease("train-old", "train", start, &ended),
+		ledgerLease("train-live", "train", ended, nil),
+		ledgerLease("gone-0", "gone", start, &ended),
+	).Build()
+	before := evaluateLedger(t, c, now)
+
+	rec := record.NewFakeRecorder(4)
+	compactor := &LeaseCompactor{Client: c, APIReader: c, Clock: staticClock{now}, Recorder: rec, Retention: 7 * 24 * time.Hour}
+	if err := compactor.Sweep(context.Background()); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+
+	var archives v1.LeaseArchiveList
+	if err := c.List(context.Background(), &archives); err != nil {
+		t.Fatalf("list archives: %v", err)
+	}
+	if len(archives.Items) != 1 {
+		t.Fatalf("got %d archives, want 1", len(archives.Items))
+	}
+	spec := archives.Items[0].Spec
+	if !spec.From.Time.Equal(start) || !spec.Through.Time.Equal(ended) || spec.FoldedLeases != 3 {
+		t.Errorf("archive window [%s, %s) folding %d leases, want [start, start+1d) folding 3", spec.From, spec.Through, spec.FoldedLeases)
+	}
+	if !hasEvent(rec, "LedgerCompacted") {
+		t.Error("a new archive must be announced")
+	}
+
+	var leases v1.GPULeaseList
+	if err := c.List(context.Background(), &leases); err != nil {
+		t.Fatalf("list leases: %v", err)
+	}
+	var kept []string
+	for _, l := range leases.Items {
+		kept = append(kept, l.Name)
+	}
+	if want := []string{"train-live", "train-old"}; !reflect.DeepEqual(kept, want) {
+		t.Errorf("kept leases %v, want %v (a live run keeps its history)", kept, want)
+	}
+
+	after := evaluateLedger(t, c, now)
+	for _, key := range []string{"default/done", "default/train", "default/gone"} {
+		if !reflect.DeepEqual(after.Run(key).GPUHours, before.Run(key).GPUHours) {
+			t.Errorf("run %s hours %v, before compaction %v", key, after.Run(key).GPUHours, before.Run(key).GPUHours)
+		}
+	}
+	env := funding.EnvelopeKey{Namespace: "default", Budget: "team", Envelope: "west"}
+	if got, want := after.Envelope(env).ConsumedGPUHours, before.Envelope(env).ConsumedGPUHours; got != want {
+		t.Errorf("envelope consumed %v, before compaction %v", got, want)
+	}
+
+	if err := compactor.Sweep(context.Background()); err != nil {
+		t.Fatalf("second sweep: %v", err)
+	}
+	if err := c.List(context.Background(), &archives); err != nil || len(archives.Items) != 1 {
+		t.Errorf("a sweep with nothing new to fold must not archive again (%d archives, err %v)", len(archives.Items), err)
+	}
+}
+
+// The engine reads leases and archives from separate informer caches. When the
+// lease watch delivers a compaction's deletes before the archive watch delivers
+// its archive, the World still reads the folded hours: the compactor recorded
+// the archive there first.
+func TestCompactorKeepsTheWorldsLedgerWhole(t *testing.T) {
+	ctx := context.Background()
+	start, now := baseTime, baseTime.Add(10*24*time.Hour)
+	ended := start.Add(24 * time.Hour)
+	objs := []client.Object{phasedRun("done", controllers.RunPhaseComplete), ledgerLease("done-0", "done", start, &ended)}
+	api := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(objs...).Build()
+	var frozen []client.Object
+	for _, obj := range objs {
+		cur := obj.DeepCopyObject().(client.Object)
+		if err := api.Get(ctx, client.ObjectKeyFromObject(obj), cur); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		frozen = append(frozen, cur)
+	}
+	cache := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(frozen...).Build()
+	world := NewWorld(cache)
+	before := evaluateLedger(t, api, now)
+
+	compactor := &LeaseCompactor{Client: api, APIReader: api, Clock: staticClock{now}, World: world, Retention: 7 * 24 * time.Hour}
+	if err := compactor.Sweep(ctx); err != nil {
+		t.Fatalf("sweep: %v", err)
+	}
+	// The lease watch delivers the delete; the archive watch has not delivered.
+	if err := cache.Delete(ctx, frozen[1]); err != nil {
+		t.Fatalf("deliver delete: %v", err)
+	}
+
+	in, err := ledger.List(ctx, world)
+	if err != nil {
+		t.Fatalf("list through the world: %v", err)
+	}
+	if len(in.Leases) != 0 || len(in.Archives) != 1 {
+		t.Fatalf("the world holds %d leases and %d archives, want the archive in place of the lease", len(in.Leases), len(in.Archives))
+	}
+	in.Now = now
+	if got, want := funding.Evaluate(in).Run("default/done").GPUHours, before.Run("default/done").GPUHours; !reflect.DeepEqual(got, want) {
+		t.Errorf("run hours read through the world %v, want %v", got, want)
+	}
+}
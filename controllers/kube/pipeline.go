@@ -0,0 +1,92 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/pipeline"
+)
+
+// PipelineReconciler stamps a Pipeline's stages into Runs and reports on them.
+// The stages' ordering is the engine's follow gate, so this reconciler never
+// starts or stops anything.
+type PipelineReconciler struct {
+	Client    client.Client
+	APIReader client.Reader
+	Clock     controllers.Clock
+	Recorder  record.EventRecorder
+}
+
+func (r *PipelineReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var p v1.Pipeline
+	if err := r.APIReader.Get(ctx, req.NamespacedName, &p); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if p.DeletionTimestamp != nil {
+		return ctrl.Result{}, nil
+	}
+	var stages v1.RunList
+	if err := r.APIReader.List(ctx, &stages, client.InNamespace(p.Namespace),
+		client.MatchingLabels{pipeline.LabelPipeline: p.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	decision := pipeline.Plan(&p, stages.Items, r.Clock.Now())
+	for i, run := range decision.Create {
+		err := r.Client.Create(ctx, run)
+		if err == nil || apierrors.IsAlreadyExists(err) {
+			continue
+		}
+		if !refusedForGood(err) {
+			return ctrl.Result{}, fmt.Errorf("create stage %s: %w", run.Name, err)
+		}
+		// A stage the apiserver refuses stays refused; without it the stages
+		// that follow it can never start, so the pipeline has failed.
+		decision.Refused(i, err, r.Clock.Now())
+		if r.Recorder != nil {
+			r.Recorder.Event(&p, corev1.EventTypeWarning, "StageRefused", decision.Status.Message)
+		}
+		break
+	}
+	if reflect.DeepEqual(p.Status, decision.Status) {
+		return ctrl.Result{}, nil
+	}
+	if p.Status.Phase != decision.Status.Phase {
+		log.FromContext(ctx).Info("pipeline phase", "pipeline", req.NamespacedName,
+			"phase", decision.Status.Phase, "message", decision.Status.Message)
+	}
+	p.Status = decision.Status
+	return ctrl.Result{}, r.Client.Status().Update(ctx, &p)
+}
+
+// As with sweeps, a stage wakes its pipeline only on a phase move, a create,
+// or a delete — not on every engine status write.
+func (r *PipelineReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	stagePhase := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldRun, ok1 := e.ObjectOld.(*v1.Run)
+			newRun, ok2 := e.ObjectNew.(*v1.Run)
+			return ok1 && ok2 && oldRun.Status.Phase != newRun.Status.Phase
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("pipeline").
+		For(&v1.Pipeline{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&v1.Run{}, builder.WithPredicates(stagePhase)).
+		WithOptions(serialWorker).
+		Complete(r)
+}
@@ -0,0 +1,136 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/pipeline"
+)
+
+func TestPipelineReconcilerStampsStagesAndReportsThem(t *testing.T) {
+	ctx := context.Background()
+	tmpl := v1.RunTemplate{Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1}}}
+	p := &v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", UID: "pipe-uid"},
+		Spec: v1.PipelineSpec{Stages: []v1.PipelineStage{
+			{Name: "preprocess", Template: tmpl},
+			{Name: "train", Follow: &v1.RunFollow{After: []string{"preprocess"}}, Template: tmpl},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).
+		WithObjects(p).
+		WithStatusSubresource(&v1.Pipeline{}, &v1.Run{}).
+		Build()
+	r := &PipelineReconciler{Client: c, APIReader: c, Clock: staticClock{time.Now()}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "nightly"}}); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+	}
+
+	reconcile()
+	var stages v1.RunList
+	if err := c.List(ctx, &stages, client.MatchingLabels{pipeline.LabelPipeline: "nightly"}); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(stages.Items) != 2 {
+		t.Fatalf("every stage is created up front, got %d", len(stages.Items))
+	}
+	var train v1.Run
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "nightly-train"}, &train); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if train.Spec.Follow == nil || train.Spec.Follow.After[0] != "nightly-preprocess" {
+		t.Errorf("the train stage must follow the preprocess Run, got %+v", train.Spec.Follow)
+	}
+
+	for _, name := range []string{"nightly-preprocess", "nightly-train"} {
+		var run v1.Run
+		if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: name}, &run); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		run.Status.Phase = controllers.RunPhaseComplete
+		if err := c.Status().Update(ctx, &run); err != nil {
+			t.Fatalf("complete: %v", err)
+		}
+	}
+	reconcile()
+	if err := c.Get(ctx, client.ObjectKeyFromObject(p), p); err != nil {
+		t.Fatalf("get pipeline: %v", err)
+	}
+	if p.Status.Phase != v1.PipelinePhaseComplete || p.Status.Succeeded != 2 || len(p.Status.Stages) != 2 {
+		t.Errorf("unexpected pipeline status %+v", p.Status)
+	}
+}
+
+// A stage the apiserver rejects as invalid fails the pipeline once, with the
+// rejection as its message, instead of retrying the create forever.
+func TestPipelineReconcilerFailsOnARefusedStage(t *testing.T) {
+	ctx := context.Background()
+	tmpl := v1.RunTemplate{Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1}}}
+	p := &v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "default", UID: "pipe-uid"},
+		Spec: v1.PipelineSpec{Stages: []v1.PipelineStage{
+			{Name: "preprocess", Template: tmpl},
+			{Name: "train", Follow: &v1.RunFollow{After: []string{"preprocess"}}, Template: tmpl},
+		}},
+	}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).
+		WithObjects(p).
+		WithStatusSubresource(&v1.Pipeline{}, &v1.Run{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if obj.GetName() == "nightly-train" {
+					return apierrors.NewInvalid(schema.GroupKind{Group: v1.GroupVersion.Group, Kind: "Run"}, obj.GetName(),
+						field.ErrorList{field.Invalid(field.NewPath("spec", "roles"), nil, "denied by policy")})
+				}
+				return c.Create(ctx, obj, opts...)
+			},
+		}).
+		Build()
+	r := &PipelineReconciler{Client: c, APIReader: c, Clock: staticClock{time.Now()}}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "nightly"}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, req); err != nil {
+			t.Fatalf("a refused stage must fail the pipeline, not requeue: %v", err)
+		}
+		if err := c.Get(ctx, client.ObjectKeyFromObject(p), p); err != nil {
+			t.Fatalf("get pipeline: %v", err)
+		}
+	}
+
+	reconcile()
+	if p.Status.Phase != v1.PipelinePhaseFailed || !strings.HasPrefix(p.Status.Message, "create stage train: ") ||
+		p.Status.Stages[1].Phase != v1.RunPhaseFailed || p.Status.CompletedAt == nil {
+		t.Fatalf("unexpected pipeline status %+v", p.Status)
+	}
+
+	var pre v1.Run
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "nightly-preprocess"}, &pre); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	pre.Status.Phase = controllers.RunPhaseComplete
+	if err := c.Status().Update(ctx, &pre); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	reconcile()
+	if p.Status.Phase != v1.PipelinePhaseFailed || !strings.HasPrefix(p.Status.Message, "create stage train: ") {
+		t.Errorf("a later pass must keep the refusal, got %+v", p.Status)
+	}
+}
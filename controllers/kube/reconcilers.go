@@ -8,6 +8,7 @@ import (
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -24,6 +25,8 @@ import (
 	"github.com/davidlangworthy/jobtree/pkg/binder"
 	"github.com/davidlangworthy/jobtree/pkg/funding"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
 
 // serialWorker pins every engine-driving controller to one worker: the
@@ -108,12 +111,17 @@ func (r *RunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	// generation; fall through and admit in the same pass (WithWorld reloads).
 	if !controllerutil.ContainsFinalizer(&run, FundingClosureFinalizer) {
 		controllerutil.AddFinalizer(&run, FundingClosureFinalizer)
+		prev := run.ResourceVersion
 		if err := r.Bridge.Client.Update(ctx, &run); err != nil {
 			return ctrl.Result{}, err
 		}
+		// The engine's status write below must carry this resourceVersion.
+		r.Bridge.wrote(&run, prev)
 	}
 
 	var parked, running, waiting bool
+	var timeLimit *v1.RunTimeLimitStatus
+	var attemptDue *time.Time
 	err := r.Bridge.WithWorld(ctx, func(state *controllers.ClusterState, now time.Time) error {
 		run, ok := state.Runs[key]
 		if !ok {
@@ -127,6 +135,10 @@ func (r *RunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 		parked = run.Status.Phase == controllers.RunPhasePending && run.Status.PendingReservation == nil
 		running = run.Status.Phase == controllers.RunPhaseRunning
 		waiting = run.Status.Phase == controllers.RunPhaseWaiting
+		timeLimit = run.Status.TimeLimit.DeepCopy()
+		if due, ok := rc.NextAttemptTimeout(run); ok {
+			attemptDue = &due
+		}
 		return err
 	})
 	switch {
@@ -137,11 +149,37 @@ func (r *RunReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.R
 	case parked:
 		return ctrl.Result{RequeueAfter: pendingRunResync}, nil
 	case running:
-		return ctrl.Result{RequeueAfter: runningRunResync}, nil
+		return ctrl.Result{RequeueAfter: r.runningResync(timeLimit, attemptDue)}, nil
 	}
 	return ctrl.Result{}, nil
 }
 
+// runningResync is runningRunResync, or sooner when the run's time-limit
+// warning or deadline, or a member's attempt timeout, falls inside it, so the
+// pass that acts on the earliest of them is scheduled for it rather than up to
+// a resync late.
+func (r *RunReconciler) runningResync(tl *v1.RunTimeLimitStatus, attemptDue *time.Time) time.Duration {
+	var due []time.Time
+	if tl != nil {
+		next := tl.Deadline.Time
+		if tl.WarnedAt == nil {
+			next = tl.WarnAt.Time
+		}
+		due = append(due, next)
+	}
+	if attemptDue != nil {
+		due = append(due, *attemptDue)
+	}
+	resync := runningRunResync
+	now := r.Bridge.Clock.Now()
+	for _, next := range due {
+		if until := next.Sub(now); until < resync {
+			resync = max(until, time.Second)
+		}
+	}
+	return resync
+}
+
 // cleanupDeletedRun closes the open leases, drops the pods, and removes the
 // reservations that belonged to a Run that no longer exists; otherwise the
 // leases keep charging the budget and occupying nodes forever.
@@ -186,10 +224,10 @@ func cleanupDeletedRun(state *controllers.ClusterState, runKey, namespace, name
 // loop.
 func (r *RunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	// A workload pod re-triggers its run only when it reaches Succeeded (so the
-	// gang can finalize — B0) or its reported ETA annotation changes (so status
-	// mirrors it — A). Pod creates (Pending), phase steps below Succeeded, and
-	// the controller's own deletes on completion do not match, so this adds no
-	// churn under the single serial worker at any reasonable ETA cadence.
+	// gang can finalize — B0), its reported ETA annotation changes (so status
+	// mirrors it — A), or it is deleted. Pod creates (Pending) and phase steps
+	// below Succeeded do not match, so this adds no churn under the single serial
+	// worker at any reasonable ETA cadence; a delete costs one pass per pod.
 	podWatch := predicate.Funcs{
 		CreateFunc: func(e event.CreateEvent) bool {
 			pod, ok := e.Object.(*corev1.Pod)
@@ -214,7 +252,9 @@ func (r *RunReconciler) SetupWithManager(mgr ctrl.Manager) error {
 			}
 			return oldPod.Annotations[binder.EtaAnnotation] != newPod.Annotations[binder.EtaAnnotation]
 		},
-		DeleteFunc:  func(event.DeleteEvent) bool { return false },
+		// A pod deleted under the run (an eviction, a drain, a user) must be
+		// seen now, not whenever some unrelated event brings the next pass.
+		DeleteFunc:  func(event.DeleteEvent) bool { return true },
 		GenericFunc: func(event.GenericEvent) bool { return false },
 	}
 	// A run reaching a terminal phase (or being deleted) re-triggers its
@@ -383,12 +423,27 @@ func (r *ReservationReconciler) Reconcile(ctx context.Context, req ctrl.Request)
 		}
 		if res.Spec.EarliestStart.Time.After(now) {
 			requeueAfter = res.Spec.EarliestStart.Time.Sub(now)
-			return nil
+			// A booking drains its slice ahead of the start, a little each
+			// pass: wake at the drain's start, then poll through it.
+			b := res.Spec.Booking
+			if b == nil {
+				return nil
+			}
+			if now.Before(b.DrainFrom.Time) {
+				requeueAfter = b.DrainFrom.Time.Sub(now)
+				return nil
+			}
+			if requeueAfter > pendingRunResync {
+				requeueAfter = pendingRunResync
+			}
 		}
 		rc := controllers.NewRunController(state, staticClock{now})
 		rc.Period = r.Bridge.Period
 		rc.Recorder = r.Bridge.recorderFor()
 		err := rc.ActivateReservations(now)
+		if res.Spec.EarliestStart.Time.After(now) {
+			return err
+		}
 		if res.Status.State == "Pending" || res.Status.State == "BlockedFunding" || res.Status.State == "" {
 			// A due reservation can park at activation (an unfunded promise
 			// waiting for physical capacity reclaims nothing — R7). No watch
@@ -411,7 +466,48 @@ func (r *ReservationReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
-// NodeReconciler performs spare swaps when a node stops being usable.
+// RemedyDirectiveReconciler executes the aggregate-cap remedies cmd/aggregator
+// writes into this cluster (M10). The answer is written to the directive's status
+// after the engine pass has applied the cut, so an outstanding directive is one
+// whose leases have not provably closed yet. If that write fails, the retry finds
+// the leases the cut closed under the directive's reason and answers from them;
+// it does not cut again.
+type RemedyDirectiveReconciler struct {
+	Bridge *Bridge
+}
+
+func (r *RemedyDirectiveReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var directive v1.RemedyDirective
+	if err := r.Bridge.APIReader.Get(ctx, req.NamespacedName, &directive); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if !directive.Outstanding() {
+		return ctrl.Result{}, nil
+	}
+	err := r.Bridge.WithWorld(ctx, func(state *controllers.ClusterState, now time.Time) error {
+		rc := controllers.NewRunController(state, staticClock{now})
+		rc.Period = r.Bridge.Period
+		rc.Recorder = r.Bridge.recorderFor()
+		return rc.ExecuteDirective(&directive, now)
+	})
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	log.FromContext(ctx).Info("remedy directive answered", "directive", req.NamespacedName,
+		"phase", directive.Status.Phase, "freedGPUs", directive.Status.FreedGPUs, "seed", directive.Spec.Seed)
+	return ctrl.Result{}, r.Bridge.Client.Status().Update(ctx, &directive)
+}
+
+func (r *RemedyDirectiveReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("remedydirective").
+		For(&v1.RemedyDirective{}).
+		WithOptions(serialWorker).
+		Complete(r)
+}
+
+// NodeReconciler performs spare swaps when a node is fenced, and ahead of time
+// when one of the Bridge's LossSources announces that it is about to go.
 type NodeReconciler struct {
 	Bridge *Bridge
 }
@@ -433,13 +529,13 @@ func (r *NodeReconciler) fenced(ctx context.Context, name types.NamespacedName)
 		}
 		return true, nil // a deleted node is a fenced node
 	}
-	if nodeFailed(&node) {
+	if topology.NodeFailed(&node) {
 		return true, nil
 	}
 	if !nodeReady(&node) {
 		// Visible, and nothing else. An operator (or a fencing agent) decides whether
 		// the machine is dead by deleting the Node or tainting it out-of-service.
-		log.FromContext(ctx).Info("node is NotReady; taking no action (a swap needs a fencing assertion: delete the Node or taint it "+taintOutOfService+")",
+		log.FromContext(ctx).Info("node is NotReady; taking no action (a swap needs a fencing assertion: delete the Node or taint it "+topology.TaintOutOfService+")",
 			"node", name.Name)
 	}
 	return false, nil
@@ -452,11 +548,18 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	// process — free for work that matters.
 	//
 	// This read decides NOTHING. It is a filter, not a verdict.
-	if ok, err := r.fenced(ctx, req.NamespacedName); err != nil || !ok {
+	ok, err := r.fenced(ctx, req.NamespacedName)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	if !ok {
+		if _, doomed, err := r.doomed(ctx, req.NamespacedName); err != nil || !doomed {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{}, r.swapAhead(ctx, req.NamespacedName)
+	}
 
-	err := r.Bridge.WithWorld(ctx, func(state *controllers.ClusterState, now time.Time) error {
+	err = r.Bridge.WithWorld(ctx, func(state *controllers.ClusterState, now time.Time) error {
 		// THE VERDICT, RE-TAKEN UNDER THE LOCK. This is the whole point.
 		//
 		// The filter above ran before WithWorld acquired the bridge mutex, and a node
@@ -506,105 +609,50 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	return ctrl.Result{}, err
 }
 
-// nodeNotReadyGrace is how long a node may report NotReady/Unknown before jobtree
-// treats it as failed and starts closing its leases.
-//
-// taintOutOfService is Kubernetes' sanctioned "I assert this node is dead" channel
-// (`node.kubernetes.io/out-of-service`, non-graceful node shutdown, GA in 1.28). It
-// is applied by a human or a fencing agent, never by the control plane on its own,
-// and Pod GC's gcTerminating force-deletes the pods of a node carrying it.
-const taintOutOfService = "node.kubernetes.io/out-of-service"
-
-// nodeReady reports whether the node's Ready condition is True. A node that has
-// not reported any Ready condition yet -- freshly created, kubelet not registered
-// -- is not Ready. It is also not failed. Those are different questions, and only
-// this one is about scheduling.
-func nodeReady(node *corev1.Node) bool {
-	for _, cond := range node.Status.Conditions {
-		if cond.Type == corev1.NodeReady {
-			return cond.Status == corev1.ConditionTrue
-		}
+// doomed re-reads the Node and reports the deadline of a termination notice on it.
+// Only a Ready, unfenced node qualifies: the early swap deletes the replaced rank's
+// pod gracefully, and only a kubelet the control plane can hear will act on that.
+// A notice on a NotReady node waits for its fencing assertion like any other.
+func (r *NodeReconciler) doomed(ctx context.Context, name types.NamespacedName) (time.Time, bool, error) {
+	if len(r.Bridge.LossSources) == 0 {
+		return time.Time{}, false, nil
 	}
-	return false
+	var node corev1.Node
+	if err := r.Bridge.APIReader.Get(ctx, name, &node); err != nil {
+		return time.Time{}, false, client.IgnoreNotFound(err)
+	}
+	if !nodeReady(&node) || topology.NodeFailed(&node) {
+		return time.Time{}, false, nil
+	}
+	deadline, ok := impendingLoss(r.Bridge.LossSources, &node)
+	return deadline, ok, nil
 }
 
-// SAFETY-CRITICAL SEMANTICS.
-//
-// This fencing decision is modeled in:
-//   - specs/NodeFailure.tla
-//   - specs/NodeFailure.md
-//
-// If you change what qualifies as node failure here, update that spec and rerun:
-//   - make node-failure-spec-check
-//   - make node-failure-spec-counterexamples
-//
-// The path-scoped CI rail is .github/workflows/node-failure-spec.yaml.
-//
-// nodeFailed reports whether a node has been FENCED: something that can actually
-// know has asserted the machine is dead. It is deliberately neither the negation of
-// nodeUsable nor the negation of nodeReady.
-//
-// The only safe trigger for a swap is a fencing assertion, because a swap starts a
-// second copy of a rank and jobtree cannot un-start the first one:
-//
-//   - CORDONED is not failed. `kubectl cordon` says "place nothing new here", not
-//     "the work here is dead". Acting on one swapped a healthy rank onto a spare
-//     while its pod kept running -- two live copies of one distributed-training
-//     rank, which is silent corruption rather than a crash (R21).
-//
-//   - NOTREADY IS NOT FAILED EITHER, for any duration. NotReady means the control
-//     plane cannot hear the kubelet; it does not mean the containers stopped. A
-//     partitioned kubelet keeps running them. Kubernetes marks a node NotReady
-//     after --node-monitor-grace-period (50s), then taint-eviction issues an
-//     ORDINARY GRACEFUL delete of its pods at tolerationSeconds (300s) -- a delete
-//     the unreachable kubelet never acts on, so the pod sits Terminating while its
-//     container runs. Upstream says so plainly, in "Force Delete StatefulSet Pods":
-//     force deletion "does not wait for confirmation from the kubelet", and doing
-//     it can "lead to the duplication of a still-running Pod". A NotReady timer,
-//     of any length, is a guess about a machine we cannot see.
-//
-// Two signals are not guesses. Both cause Pod GC to FORCE-delete (grace period 0),
-// in pkg/controller/podgc/gc_controller.go:
-//
-//   - The Node object is gone (gcOrphaned). Deletion is itself an assertion, by the
-//     cloud-controller-manager -- the instance is terminated -- or by an operator.
-//     The caller handles this case; a deleted node never reaches here.
-//   - The node carries `node.kubernetes.io/out-of-service` (gcTerminating).
-//
-// The cost of this is that a genuinely dead on-prem node whose object is never
-// deleted and never tainted will not swap: the run stalls rather than corrupting.
-// In cloud the CCM deletes the object automatically, which is the common path. For
-// a system whose worst outcome is two live copies of one rank, stalling is the
-// correct failure mode.
-//
-// Taking no timestamp is the point. There is no clock here, so the engine clock and
-// the wall clock never meet, and a compromised kubelet -- which writes its own node
-// status -- cannot backdate a LastTransitionTime to manufacture a failure.
-func nodeFailed(node *corev1.Node) bool {
-	for _, taint := range node.Spec.Taints {
-		if taint.Key == taintOutOfService {
-			return true
+// swapAhead moves the node's ranks onto their spares before the announced loss.
+// The notice is re-read under the bridge lock for the reason the fencing verdict
+// is: a notice withdrawn while this reconcile waited for the mutex (a cancelled
+// maintenance, a reclaim that went elsewhere) must not move a rank.
+func (r *NodeReconciler) swapAhead(ctx context.Context, name types.NamespacedName) error {
+	return r.Bridge.WithWorld(ctx, func(state *controllers.ClusterState, now time.Time) error {
+		deadline, doomed, err := r.doomed(ctx, name)
+		if err != nil || !doomed {
+			return err
 		}
-	}
-	return false
+		rc := controllers.NewRunController(state, staticClock{now})
+		rc.Period = r.Bridge.Period
+		rc.Recorder = r.Bridge.recorderFor()
+		if err := rc.HandleImpendingNodeLoss(name.Name, deadline, now); err != nil && !errors.Is(err, controllers.ErrNoLeaseOnNode) {
+			return err
+		}
+		return nil
+	})
 }
 
-// nodeUsable reports whether a node's GPUs may be counted as capacity and placed
-// on. It is about SCHEDULABILITY, which is a different question from nodeFailed's
-// (has something asserted the machine is dead) — but a fenced node answers both.
-func nodeUsable(node *corev1.Node) bool {
-	if node.Spec.Unschedulable {
-		return false
-	}
-	// A fenced node's GPUs do not exist. Leaving them in the pool let the engine
-	// admit and CHARGE a run for capacity on a machine jobtree had just declared
-	// dead and closed the leases of: the ledger says the GPUs are there, the
-	// NoExecute taint says nothing may run on them, and the next node event closes
-	// whatever was minted. The fencing taint outlives the failure it reports, so
-	// this is not self-correcting.
-	if nodeFailed(node) {
-		return false
-	}
+// nodeReady reports whether the node's Ready condition is True. A node that has
+// not reported any Ready condition yet -- freshly created, kubelet not registered
+// -- is not Ready. It is also not failed. Those are different questions, and only
+// this one is about scheduling.
+func nodeReady(node *corev1.Node) bool {
 	for _, cond := range node.Status.Conditions {
 		if cond.Type == corev1.NodeReady {
 			return cond.Status == corev1.ConditionTrue
@@ -623,12 +671,17 @@ func nodeUsable(node *corev1.Node) bool {
 // are not closed by this watch. That is R26's job (the ledger auditor sweeps leases
 // against live nodes); it is not something a predicate can fix.
 func (r *NodeReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	// NOT `!nodeUsable` -- that enqueues on every cordon, and a cordon is not a
-	// failure (R21). Enqueue on the fencing taint, and on NotReady so Reconcile can
-	// log it; Reconcile re-reads and only a fencing assertion moves anything.
+	// NOT `!topology.NodeUsable` -- that enqueues on every cordon, and a cordon is not a
+	// failure (R21). Enqueue on the fencing taint, on NotReady so Reconcile can
+	// log it, and on a termination notice; Reconcile re-reads, and only a fencing
+	// assertion or a notice on a live node moves anything.
 	interesting := predicate.NewPredicateFuncs(func(obj client.Object) bool {
 		node, ok := obj.(*corev1.Node)
-		return ok && (!nodeReady(node) || nodeFailed(node))
+		if !ok {
+			return false
+		}
+		_, doomed := impendingLoss(r.Bridge.LossSources, node)
+		return !nodeReady(node) || topology.NodeFailed(node) || doomed
 	})
 	anyDelete := predicate.Funcs{
 		CreateFunc:  func(event.CreateEvent) bool { return false },
@@ -652,6 +705,9 @@ type BudgetReconciler struct {
 	Period time.Duration
 	// ResyncPeriod re-reconciles budgets so GPU-hour accrual stays fresh.
 	ResyncPeriod time.Duration
+	// Recorder emits EnvelopeRenewed and RenewalAwaitingApproval on the
+	// Budget, the latter once per pending window; nil emits nothing.
+	Recorder record.EventRecorder
 }
 
 func (r *BudgetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
@@ -659,32 +715,42 @@ func (r *BudgetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 	if err := r.APIReader.Get(ctx, req.NamespacedName, &budget); err != nil {
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
-	// The evaluation is global: family sharing and lending mean other
-	// budgets' leases and runs decide what this budget's envelopes fund.
-	var budgetList v1.BudgetList
-	if err := r.APIReader.List(ctx, &budgetList); err != nil {
-		return ctrl.Result{}, err
+	// Renew before evaluating, so the evaluation already charges the leases
+	// crossing a seam to the window that continues them. The spec write is
+	// the budget controller's only one, and it only ever appends.
+	announced := map[string]bool{}
+	for _, due := range budget.Status.PendingRenewals {
+		announced[due.Name] = true
 	}
-	var leaseList v1.GPULeaseList
-	if err := r.APIReader.List(ctx, &leaseList); err != nil {
-		return ctrl.Result{}, err
+	renewal := controllers.RenewEnvelopes(&budget, r.Clock.Now())
+	if len(renewal.Renewed) > 0 {
+		if err := r.Client.Update(ctx, &budget); err != nil {
+			return ctrl.Result{}, err
+		}
 	}
-	var runList v1.RunList
-	if err := r.APIReader.List(ctx, &runList); err != nil {
+	if r.Recorder != nil {
+		for _, renewed := range renewal.Renewed {
+			r.Recorder.Event(&budget, corev1.EventTypeNormal, "EnvelopeRenewed", renewed.Message())
+		}
+		// A window already in status.pendingRenewals was announced by the
+		// pass that listed it; every renewal is a new name, so the next
+		// window is announced afresh.
+		for _, name := range renewal.Awaiting {
+			if announced[name] {
+				continue
+			}
+			r.Recorder.Eventf(&budget, corev1.EventTypeWarning, "RenewalAwaitingApproval",
+				"envelope %s is due for renewal; annotate the Budget %s=%s to approve", name, controllers.AnnotationApproveRenewal, name)
+		}
+	}
+	// The evaluation is global: family sharing and lending mean other
+	// budgets' leases and runs decide what this budget's envelopes fund.
+	in, err := ledger.List(ctx, r.APIReader)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
-	runs := make(map[string]*v1.Run, len(runList.Items))
-	for i := range runList.Items {
-		run := &runList.Items[i]
-		runs[keys.NamespacedKey(run.Namespace, run.Name)] = run
-	}
-	ev := funding.Evaluate(funding.Input{
-		Budgets: budgetList.Items,
-		Leases:  leaseList.Items,
-		Runs:    runs,
-		Now:     r.Clock.Now(),
-		Period:  r.Period,
-	})
+	in.Now, in.Period = r.Clock.Now(), r.Period
+	ev := funding.Evaluate(in)
 	bc := controllers.NewBudgetController(r.Clock, controllers.NewBudgetMetrics())
 	status := bc.ReconcileBudget(&budget, ev)
 	budget.Status = status
@@ -700,11 +766,12 @@ func (r *BudgetReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctr
 
 // The generation gate keeps the reconciler's own status writes (updatedAt
 // moves on every pass under a live clock) from re-triggering it; periodic
-// freshness comes from the resync requeue instead.
+// freshness comes from the resync requeue instead. Annotations pass too, so
+// a renewal approval is acted on when it is given.
 func (r *BudgetReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		Named("budget").
-		For(&v1.Budget{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		For(&v1.Budget{}, builder.WithPredicates(predicate.Or(predicate.GenerationChangedPredicate{}, predicate.AnnotationChangedPredicate{}))).
 		WithOptions(serialWorker).
 		Complete(r)
 }
@@ -116,3 +116,43 @@ func TestRendezvousEnvOverridesAResearcherSetName(t *testing.T) {
 		t.Errorf("WORLD_SIZE must appear exactly once, got %d", count)
 	}
 }
+
+// Each role of a multi-role gang renders its own template and meets at its own
+// rank 0: an actor's world is the actors, not the whole run.
+func TestRendezvousEnvPerRoleOfMultiRoleGang(t *testing.T) {
+	run := roledRun("train", 4, 1, false)
+	run.Spec.Resources.TotalGPUs = 8
+	run.Spec.Roles[0].Name = "actor"
+	learner := run.Spec.Roles[0]
+	learner.Name = "learner"
+	learner.Width, learner.GPUsPerPod = 2, 2
+	learner.Template = corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: v1.GPUTargetContainerName, Image: "learner:1"}},
+	}}
+	run.Spec.Roles = append(run.Spec.Roles, learner)
+
+	m := activeManifest("train-learner-1")
+	m.GPUs = 2
+	m.Labels[binder.LabelRoleName] = "learner"
+	pod := buildPod(m, run)
+	if got := pod.Spec.Containers[0].Image; got != "learner:1" {
+		t.Errorf("learner pod image = %q, want the learner role's template (learner:1)", got)
+	}
+	env := envOf(pod)
+	if env["MASTER_ADDR"] != "train-learner-0.train.default.svc" {
+		t.Errorf("MASTER_ADDR = %q, want the learner role's rank 0", env["MASTER_ADDR"])
+	}
+	if env["WORLD_SIZE"] != "4" || env["NNODES"] != "2" || env["NODE_RANK"] != "1" {
+		t.Errorf("WORLD_SIZE/NNODES/NODE_RANK = %q/%q/%q, want 4/2/1", env["WORLD_SIZE"], env["NNODES"], env["NODE_RANK"])
+	}
+
+	a := activeManifest("train-actor-3")
+	a.Labels[binder.LabelRoleName] = "actor"
+	actor := buildPod(a, run)
+	if got := actor.Spec.Containers[0].Image; got != "trainer:1" {
+		t.Errorf("actor pod image = %q, want the actor role's template (trainer:1)", got)
+	}
+	if env := envOf(actor); env["MASTER_ADDR"] != "train-actor-0.train.default.svc" || env["WORLD_SIZE"] != "4" {
+		t.Errorf("actor MASTER_ADDR/WORLD_SIZE = %q/%q, want train-actor-0…/4", env["MASTER_ADDR"], env["WORLD_SIZE"])
+	}
+}
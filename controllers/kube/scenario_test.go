@@ -102,7 +102,7 @@ func failNode(t *testing.T, name string) {
 		t.Fatalf("get %s: %v", name, err)
 	}
 	node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{
-		Key:    taintOutOfService,
+		Key:    topology.TaintOutOfService,
 		Effect: corev1.TaintEffectNoExecute,
 	})
 	if err := kubeClient.Update(suiteCtx, &node); err != nil {
@@ -375,7 +375,7 @@ func dumpRunLedger(t *testing.T, runName string) {
 	if err := kubeClient.List(suiteCtx, &nodes); err == nil {
 		for i := range nodes.Items {
 			n := &nodes.Items[i]
-			t.Logf("  node  %-28s usable=%v failed=%v ready=%v", n.Name, nodeUsable(n), nodeFailed(n), nodeReady(n))
+			t.Logf("  node  %-28s usable=%v failed=%v ready=%v", n.Name, topology.NodeUsable(n), topology.NodeFailed(n), nodeReady(n))
 		}
 	}
 }
@@ -20,9 +20,9 @@ import (
 	"github.com/davidlangworthy/jobtree/pkg/snapshot"
 )
 
-// SnapshotProducer is the in-tree controller of DESIGN-v5 build item 3: it reads
-// Budgets and Grants, validates each transition against the PRIOR ACCEPTED
-// graph, compiles, and publishes the versioned document.
+// SnapshotProducer is the in-tree controller of DESIGN-v5 build item 3: it
+// reads Budgets, Grants and BudgetTransfers, validates each transition against
+// the PRIOR ACCEPTED graph, compiles, and publishes the versioned document.
 //
 // It is the whole trust boundary (§11). No invariant the published document can
 // express says "this changeset was authored by someone entitled to make it" —
@@ -87,6 +87,10 @@ func (p *SnapshotProducer) Publish(ctx context.Context) error {
 	if err := p.APIReader.List(ctx, &grants); err != nil {
 		return fmt.Errorf("list grants: %w", err)
 	}
+	var transfers v1.BudgetTransferList
+	if err := p.APIReader.List(ctx, &transfers); err != nil {
+		return fmt.Errorf("list budget transfers: %w", err)
+	}
 	var namespaces corev1.NamespaceList
 	if err := p.APIReader.List(ctx, &namespaces); err != nil {
 		return fmt.Errorf("list namespaces: %w", err)
@@ -112,6 +116,7 @@ func (p *SnapshotProducer) Publish(ctx context.Context) error {
 	res, err := snapshot.Compile(snapshot.Input{
 		Budgets:       budgets.Items,
 		Grants:        grants.Items,
+		Transfers:     transfers.Items,
 		NamespaceUIDs: uids,
 		Prior:         prior,
 		Now:           p.Clock.Now(),
@@ -128,6 +133,7 @@ func (p *SnapshotProducer) Publish(ctx context.Context) error {
 
 	p.reportRefusals(ctx, res)
 	p.syncGrantStatus(ctx, grants.Items, res)
+	p.syncTransferStatus(ctx, transfers.Items, res)
 
 	return p.write(ctx, prior, res)
 }
@@ -268,6 +274,49 @@ func equalGrantStatus(a, b *v1.GrantStatus) bool {
 	return ac.Status == bc.Status && ac.Reason == bc.Reason && ac.Message == bc.Message
 }
 
+// syncTransferStatus records, on each BudgetTransfer copy, what the compile made
+// of it. A party that has accepted needs to see that the other has not yet —
+// AwaitingCounterparty is the answer to "why is my team not borrowing?" — so a
+// pending copy is reported as plainly as a refused one.
+func (p *SnapshotProducer) syncTransferStatus(ctx context.Context, transfers []v1.BudgetTransfer, res snapshot.Result) {
+	for i := range transfers {
+		t := &transfers[i]
+		key := t.Namespace + "/" + t.Name
+		d, ok := res.Transfers[key]
+		if !ok {
+			continue
+		}
+		cond := metav1.Condition{
+			Type:               "Accepted",
+			Status:             metav1.ConditionFalse,
+			Reason:             d.Reason,
+			Message:            d.Message,
+			LastTransitionTime: metav1.NewTime(p.Clock.Now()),
+		}
+		if d.Reason == v1.TransferReasonCompiled {
+			cond.Status = metav1.ConditionTrue
+			cond.Message = fmt.Sprintf("%s; compiled into snapshot %s", d.Message, res.Snapshot.Spec.SnapshotVersion)
+		}
+		if t.Status.SnapshotVersion == res.Snapshot.Spec.SnapshotVersion {
+			if c := meta.FindStatusCondition(t.Status.Conditions, "Accepted"); c != nil &&
+				c.Status == cond.Status && c.Reason == cond.Reason && c.Message == cond.Message {
+				continue
+			}
+		}
+		if err := retry.RetryOnConflict(retry.DefaultRetry, func() error {
+			var live v1.BudgetTransfer
+			if err := p.Client.Get(ctx, client.ObjectKey{Namespace: t.Namespace, Name: t.Name}, &live); err != nil {
+				return err
+			}
+			live.Status.SnapshotVersion = res.Snapshot.Spec.SnapshotVersion
+			meta.SetStatusCondition(&live.Status.Conditions, cond)
+			return p.Client.Status().Update(ctx, &live)
+		}); err != nil {
+			log.FromContext(ctx).Error(err, "could not record transfer status", "transfer", key)
+		}
+	}
+}
+
 // reportRefusals makes every refusal LOUD.
 //
 // §4: "a silent quarantine is a silent loss of authority." A refused Grant is a
@@ -291,6 +340,19 @@ func (p *SnapshotProducer) reportRefusals(ctx context.Context, res snapshot.Resu
 	}
 	report("Quarantined", res.Quarantined)
 	report("Rejected", res.Rejected)
+	for key, d := range res.Transfers {
+		if d.Reason != v1.TransferReasonNotCompiled {
+			continue
+		}
+		log.FromContext(ctx).Error(nil, "budget transfer did not compile into the snapshot", "transfer", key, "reason", d.Message)
+		if p.Recorder == nil {
+			continue
+		}
+		ns, name := splitKey(key)
+		p.Recorder.Eventf(
+			&v1.BudgetTransfer{ObjectMeta: v1.ObjectMeta{Namespace: ns, Name: name}},
+			corev1.EventTypeWarning, "TransferNotCompiled", "%s", d.Message)
+	}
 }
 
 func (p *SnapshotProducer) alarmCompileFailure(ctx context.Context, err error) {
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -27,6 +28,9 @@ func resetProducerWorld(t *testing.T) {
 		if err := kubeClient.DeleteAllOf(suiteCtx, &v1.Grant{}, client.InNamespace(ns)); err != nil {
 			t.Fatalf("clear grants in %s: %v", ns, err)
 		}
+		if err := kubeClient.DeleteAllOf(suiteCtx, &v1.BudgetTransfer{}, client.InNamespace(ns)); err != nil {
+			t.Fatalf("clear budget transfers in %s: %v", ns, err)
+		}
 	}
 	var doc v1.QuotaSnapshot
 	if err := kubeClient.Get(suiteCtx, client.ObjectKey{Name: snapshot.SnapshotName}, &doc); err == nil {
@@ -280,3 +284,88 @@ func TestProducerNoOpRecompileKeepsTheVersion(t *testing.T) {
 		return nil
 	})
 }
+
+func producerTransfer(t *testing.T, ns string) {
+	t.Helper()
+	start := metav1.NewTime(baseTime)
+	end := metav1.NewTime(baseTime.Add(48 * time.Hour))
+	tr := &v1.BudgetTransfer{
+		ObjectMeta: metav1.ObjectMeta{Name: "loan", Namespace: ns},
+		Spec: v1.BudgetTransferSpec{
+			From:        v1.TransferSource{Namespace: "prod-lead", Budget: "lead-budget", Envelope: "west"},
+			To:          v1.TransferRecipient{Owner: "org:team", Namespace: "prod-team"},
+			Concurrency: 8,
+			Start:       &start,
+			End:         &end,
+		},
+	}
+	if err := kubeClient.Create(suiteCtx, tr); err != nil {
+		t.Fatalf("create transfer in %s: %v", ns, err)
+	}
+}
+
+// A transfer written by the lender alone is an offer, not a transfer: it stays
+// out of the snapshot and says it is waiting. The recipient's copy completes it.
+func TestProducerCompilesATransferOnlyOnceBothPartiesAccept(t *testing.T) {
+	requireEnv(t)
+	resetWorld(t)
+	resetProducerWorld(t)
+
+	producerNamespace(t, "prod-lead")
+	producerNamespace(t, "prod-team")
+	producerBudget(t, "prod-lead", "lead-budget", "org:lead", 64)
+	producerBudget(t, "prod-team", "team-budget", "org:team", 32)
+	producerTransfer(t, "prod-lead")
+
+	p := &SnapshotProducer{
+		Client:    kubeClient,
+		APIReader: kubeClient,
+		Clock:     &testClock{now: baseTime},
+	}
+	accepted := func(ns string) *metav1.Condition {
+		var tr v1.BudgetTransfer
+		if err := kubeClient.Get(suiteCtx, client.ObjectKey{Namespace: ns, Name: "loan"}, &tr); err != nil {
+			return nil
+		}
+		return meta.FindStatusCondition(tr.Status.Conditions, "Accepted")
+	}
+
+	eventually(t, 20*time.Second, func() error {
+		if err := p.Publish(suiteCtx); err != nil {
+			return err
+		}
+		if c := accepted("prod-lead"); c == nil || c.Reason != v1.TransferReasonAwaitingCounterparty {
+			return errNotClosed
+		}
+		return nil
+	})
+	var doc v1.QuotaSnapshot
+	if err := kubeClient.Get(suiteCtx, client.ObjectKey{Name: snapshot.SnapshotName}, &doc); err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if len(doc.Spec.Transfers) != 0 {
+		t.Fatalf("a one-sided transfer compiled: %+v", doc.Spec.Transfers)
+	}
+
+	producerTransfer(t, "prod-team")
+	eventually(t, 20*time.Second, func() error {
+		if err := p.Publish(suiteCtx); err != nil {
+			return err
+		}
+		for _, ns := range []string{"prod-lead", "prod-team"} {
+			if c := accepted(ns); c == nil || c.Status != metav1.ConditionTrue {
+				return errNotClosed
+			}
+		}
+		return nil
+	})
+	if err := kubeClient.Get(suiteCtx, client.ObjectKey{Name: snapshot.SnapshotName}, &doc); err != nil {
+		t.Fatalf("get snapshot: %v", err)
+	}
+	if len(doc.Spec.Transfers) != 1 {
+		t.Fatalf("published transfers = %+v, want the accepted one", doc.Spec.Transfers)
+	}
+	if got := doc.Spec.Transfers[0]; got.Lender != "org:lead" || got.Borrower != "org:team" || got.Concurrency != 8 {
+		t.Errorf("published transfer = %+v, want 8 from org:lead to org:team", got)
+	}
+}
@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/client-go/tools/record"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/sweep"
+)
+
+// RunSweepReconciler expands RunSweeps into child Runs. It never drives the
+// engine: a child is an ordinary Run that the RunReconciler admits and funds
+// like any other, so this reconciler only creates, stops, and counts them.
+type RunSweepReconciler struct {
+	Client    client.Client
+	APIReader client.Reader
+	Clock     controllers.Clock
+	Recorder  record.EventRecorder
+}
+
+func (r *RunSweepReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	var sw v1.RunSweep
+	if err := r.APIReader.Get(ctx, req.NamespacedName, &sw); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+	if sw.DeletionTimestamp != nil {
+		// The children go with it through their owner references.
+		return ctrl.Result{}, nil
+	}
+	// Uncached: a child created last pass must be listed this pass, or the
+	// plan would read it as deleted and record the trial Stopped.
+	var children v1.RunList
+	if err := r.APIReader.List(ctx, &children, client.InNamespace(sw.Namespace),
+		client.MatchingLabels{sweep.LabelSweep: sw.Name}); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	decision := sweep.Plan(&sw, children.Items, r.Clock.Now())
+	for i, child := range decision.Create {
+		err := r.Client.Create(ctx, child)
+		if err == nil || apierrors.IsAlreadyExists(err) {
+			continue
+		}
+		if !refusedForGood(err) {
+			return ctrl.Result{}, fmt.Errorf("create trial %s: %w", child.Name, err)
+		}
+		// Every trial is the same template, so the rest would be refused too;
+		// fail the sweep rather than retry a create that cannot succeed.
+		decision.Refused(i, err, r.Clock.Now())
+		if r.Recorder != nil {
+			r.Recorder.Event(&sw, corev1.EventTypeWarning, "TrialRefused", decision.Status.Message)
+		}
+		break
+	}
+	for _, name := range decision.Stop {
+		child := &v1.Run{}
+		child.Namespace, child.Name = sw.Namespace, name
+		if err := r.Client.Delete(ctx, child); client.IgnoreNotFound(err) != nil {
+			return ctrl.Result{}, fmt.Errorf("stop trial %s: %w", name, err)
+		}
+	}
+	if len(decision.Stop) > 0 && r.Recorder != nil {
+		r.Recorder.Eventf(&sw, corev1.EventTypeNormal, "SweepEarlyStop", "winner %s; stopped %d running trials", decision.Status.Winner, len(decision.Stop))
+	}
+	if reflect.DeepEqual(sw.Status, decision.Status) {
+		return ctrl.Result{}, nil
+	}
+	if sw.Status.Phase != decision.Status.Phase {
+		log.FromContext(ctx).Info("run sweep phase", "sweep", req.NamespacedName,
+			"phase", decision.Status.Phase, "message", decision.Status.Message)
+	}
+	sw.Status = decision.Status
+	return ctrl.Result{}, r.Client.Status().Update(ctx, &sw)
+}
+
+// A child re-triggers its sweep only when its phase moves, or it is created or
+// deleted: a Run's status is rewritten every engine pass (float GPU-hours), and
+// an unfiltered Owns would wake the sweep on each one.
+func (r *RunSweepReconciler) SetupWithManager(mgr ctrl.Manager) error {
+	childPhase := predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldRun, ok1 := e.ObjectOld.(*v1.Run)
+			newRun, ok2 := e.ObjectNew.(*v1.Run)
+			return ok1 && ok2 && oldRun.Status.Phase != newRun.Status.Phase
+		},
+		GenericFunc: func(event.GenericEvent) bool { return false },
+	}
+	return ctrl.NewControllerManagedBy(mgr).
+		Named("runsweep").
+		For(&v1.RunSweep{}, builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Owns(&v1.Run{}, builder.WithPredicates(childPhase)).
+		WithOptions(serialWorker).
+		Complete(r)
+}
+
+// refusedForGood reports a create error that retrying the same object cannot
+// clear: the apiserver or an admission webhook rejected it as invalid, or policy
+// forbids it. Anything else (a conflict, a timeout, throttling) is retried.
+func refusedForGood(err error) bool {
+	return apierrors.IsInvalid(err) || apierrors.IsForbidden(err) || apierrors.IsBadRequest(err)
+}
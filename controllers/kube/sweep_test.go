@@ -0,0 +1,134 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/sweep"
+)
+
+func TestRunSweepReconcilerCreatesAndStopsChildren(t *testing.T) {
+	ctx := context.Background()
+	two := int32(2)
+	sw := &v1.RunSweep{
+		ObjectMeta: metav1.ObjectMeta{Name: "lr", Namespace: "default", UID: "sweep-uid"},
+		Spec: v1.RunSweepSpec{
+			Template: v1.RunTemplate{Spec: v1.RunSpec{
+				Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1},
+			}},
+			Parameters:    []v1.SweepParameter{{Name: "lr", Values: []string{"1e-4", "3e-4", "1e-3"}}},
+			MaxConcurrent: &two,
+			EarlyStop:     &v1.RunSweepEarlyStop{Succeeded: 1},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).
+		WithObjects(sw).
+		WithStatusSubresource(&v1.RunSweep{}, &v1.Run{}).
+		Build()
+	r := &RunSweepReconciler{Client: c, APIReader: c, Clock: staticClock{time.Now()}}
+	reconcile := func() {
+		t.Helper()
+		if _, err := r.Reconcile(ctx, ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "lr"}}); err != nil {
+			t.Fatalf("reconcile: %v", err)
+		}
+	}
+	children := func() []v1.Run {
+		t.Helper()
+		var list v1.RunList
+		if err := c.List(ctx, &list, client.MatchingLabels{sweep.LabelSweep: "lr"}); err != nil {
+			t.Fatalf("list: %v", err)
+		}
+		return list.Items
+	}
+
+	reconcile()
+	if got := children(); len(got) != 2 {
+		t.Fatalf("maxConcurrent 2: got %d children", len(got))
+	}
+
+	var winner v1.Run
+	if err := c.Get(ctx, client.ObjectKey{Namespace: "default", Name: "lr-1"}, &winner); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	winner.Status.Phase = controllers.RunPhaseComplete
+	if err := c.Status().Update(ctx, &winner); err != nil {
+		t.Fatalf("complete: %v", err)
+	}
+	reconcile()
+
+	got := children()
+	if len(got) != 1 || got[0].Name != "lr-1" {
+		t.Errorf("early stop must delete the running trial and create no more, left %d", len(got))
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(sw), sw); err != nil {
+		t.Fatalf("get sweep: %v", err)
+	}
+	if sw.Status.Phase != v1.SweepPhaseComplete || sw.Status.Winner != "lr-1" || sw.Status.Stopped != 1 || sw.Status.Trials != 3 {
+		t.Errorf("unexpected sweep status %+v", sw.Status)
+	}
+}
+
+func TestRunSweepReconcilerFailsOnARefusedTrial(t *testing.T) {
+	ctx := context.Background()
+	sw := &v1.RunSweep{
+		ObjectMeta: metav1.ObjectMeta{Name: "lr", Namespace: "default", UID: "sweep-uid"},
+		Spec: v1.RunSweepSpec{
+			Template: v1.RunTemplate{Spec: v1.RunSpec{
+				Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1},
+			}},
+			Parameters: []v1.SweepParameter{{Name: "lr", Values: []string{"1e-4", "3e-4", "1e-3"}}},
+		},
+	}
+	refusal := apierrors.NewForbidden(schema.GroupResource{Group: v1.GroupVersion.Group, Resource: "runs"}, "lr-0",
+		fmt.Errorf("exceeded quota"))
+	transient := false
+	c := fake.NewClientBuilder().WithScheme(testScheme()).
+		WithObjects(sw).
+		WithStatusSubresource(&v1.RunSweep{}, &v1.Run{}).
+		WithInterceptorFuncs(interceptor.Funcs{
+			Create: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.CreateOption) error {
+				if _, ok := obj.(*v1.Run); !ok {
+					return c.Create(ctx, obj, opts...)
+				}
+				if transient {
+					return apierrors.NewTimeoutError("etcd slow", 1)
+				}
+				return refusal
+			},
+		}).
+		Build()
+	r := &RunSweepReconciler{Client: c, APIReader: c, Clock: staticClock{time.Now()}}
+	req := ctrl.Request{NamespacedName: types.NamespacedName{Namespace: "default", Name: "lr"}}
+
+	if _, err := r.Reconcile(ctx, req); err != nil {
+		t.Fatalf("a refused trial must fail the sweep, not requeue: %v", err)
+	}
+	if err := c.Get(ctx, client.ObjectKeyFromObject(sw), sw); err != nil {
+		t.Fatalf("get sweep: %v", err)
+	}
+	if sw.Status.Phase != v1.SweepPhaseFailed || sw.Status.Created != 0 || len(sw.Status.Children) != 0 ||
+		sw.Status.Running != 0 || sw.Status.CompletedAt == nil {
+		t.Errorf("unexpected sweep status %+v", sw.Status)
+	}
+	if want := "create trial lr-0: " + refusal.Error(); sw.Status.Message != want {
+		t.Errorf("message %q, want %q", sw.Status.Message, want)
+	}
+
+	transient = true
+	if _, err := r.Reconcile(ctx, req); err == nil {
+		t.Errorf("a transient create error must be retried")
+	}
+}
@@ -0,0 +1,76 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// A running pod learns its run's deadline only once the run has been warned, so
+// the annotation appearing is the workload's cue to checkpoint.
+func TestSignalTimeLimitAnnotatesOnlyAWarnedRunsPods(t *testing.T) {
+	deadline := metav1.NewTime(time.Now().Add(10 * time.Minute).Truncate(time.Second))
+	run := liveRun("train")
+	run.Status.TimeLimit = &v1.RunTimeLimitStatus{Deadline: deadline, WarnAt: metav1.NewTime(deadline.Add(-15 * time.Minute))}
+	pod := &corev1.Pod{ObjectMeta: metav1.ObjectMeta{
+		Name: "train-active-0", Namespace: "default",
+		Labels: map[string]string{binder.LabelRunName: "train"},
+	}}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(pod).Build()
+	bridge := &Bridge{Client: c, APIReader: c, Clock: controllers.RealClock{}}
+	get := func() corev1.Pod {
+		var got corev1.Pod
+		if err := c.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "train-active-0"}, &got); err != nil {
+			t.Fatalf("get pod: %v", err)
+		}
+		return got
+	}
+
+	if err := bridge.signalTimeLimit(context.Background(), pod, run); err != nil {
+		t.Fatalf("signalTimeLimit: %v", err)
+	}
+	if got := get(); got.Annotations[binder.AnnotationTimeLimit] != "" {
+		t.Fatalf("annotated before the warning: %q", got.Annotations[binder.AnnotationTimeLimit])
+	}
+
+	warned := metav1.Now()
+	run.Status.TimeLimit.WarnedAt = &warned
+	if err := bridge.signalTimeLimit(context.Background(), pod, run); err != nil {
+		t.Fatalf("signalTimeLimit: %v", err)
+	}
+	if want, got := deadline.UTC().Format(time.RFC3339), get(); got.Annotations[binder.AnnotationTimeLimit] != want {
+		t.Errorf("time-limit annotation = %q, want %q", got.Annotations[binder.AnnotationTimeLimit], want)
+	}
+}
+
+// A running run is requeued for the earliest of its time-limit warning or
+// deadline and its members' attempt timeouts.
+func TestRunningResyncWakesForTheEarlierLimit(t *testing.T) {
+	now := time.Date(2026, 7, 3, 12, 0, 0, 0, time.UTC)
+	r := &RunReconciler{Bridge: &Bridge{Clock: staticClock{now}}}
+	warned := metav1.NewTime(now.Add(-time.Minute))
+	tl := &v1.RunTimeLimitStatus{Deadline: metav1.NewTime(now.Add(3 * time.Minute)), WarnedAt: &warned}
+	attempt := now.Add(time.Minute)
+
+	if got := r.runningResync(tl, nil); got != 3*time.Minute {
+		t.Errorf("deadline alone: requeue after %s, want 3m", got)
+	}
+	if got := r.runningResync(tl, &attempt); got != time.Minute {
+		t.Errorf("an attempt timing out first: requeue after %s, want 1m", got)
+	}
+	if got := r.runningResync(nil, &attempt); got != time.Minute {
+		t.Errorf("attempt timeout alone: requeue after %s, want 1m", got)
+	}
+	if got := r.runningResync(nil, nil); got != runningRunResync {
+		t.Errorf("no limits: requeue after %s, want %s", got, runningRunResync)
+	}
+}
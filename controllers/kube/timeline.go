@@ -0,0 +1,96 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/forecast"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// DefaultTimelineHours is how far ahead the timeline looks when the caller
+// does not say.
+const DefaultTimelineHours = 48
+
+// TimelineHandler serves a flavor's capacity timeline as JSON:
+//
+//	GET /timeline?flavor=H100-80GB&hours=72
+//
+// flavor is required; hours defaults to DefaultTimelineHours. It reads the
+// fleet and every lease, reservation, run and budget through the uncached
+// Reader and projects them with forecast.ProjectTimeline, the same projection
+// `kubectl runs timeline` prints.
+type TimelineHandler struct {
+	// Reader is set once the manager exists; until then the handler answers 503.
+	Reader client.Reader
+	Clock  controllers.Clock
+}
+
+func (h *TimelineHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "GET only", http.StatusMethodNotAllowed)
+		return
+	}
+	if h.Reader == nil {
+		http.Error(w, "reader not ready", http.StatusServiceUnavailable)
+		return
+	}
+	query := r.URL.Query()
+	in := forecast.TimelineInput{Flavor: query.Get("flavor"), From: h.Clock.Now(), Hours: DefaultTimelineHours}
+	if in.Flavor == "" {
+		http.Error(w, "flavor is required", http.StatusBadRequest)
+		return
+	}
+	if raw := query.Get("hours"); raw != "" {
+		hours, err := strconv.Atoi(raw)
+		if err != nil {
+			http.Error(w, "hours: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		in.Hours = hours
+	}
+	if err := h.claims(r, &in); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	timeline, err := forecast.ProjectTimeline(in)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(timeline)
+}
+
+// claims lists the usable nodes and everything that holds or will hold them.
+func (h *TimelineHandler) claims(r *http.Request, in *forecast.TimelineInput) error {
+	var nodes corev1.NodeList
+	if err := h.Reader.List(r.Context(), &nodes); err != nil {
+		return fmt.Errorf("list nodes: %w", err)
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !topology.NodeUsable(node) {
+			continue
+		}
+		in.Nodes = append(in.Nodes, topology.SourceNodeOf(node))
+	}
+	led, err := ledger.List(r.Context(), h.Reader)
+	if err != nil {
+		return err
+	}
+	var reservations v1.ReservationList
+	if err := h.Reader.List(r.Context(), &reservations); err != nil {
+		return fmt.Errorf("list reservations: %w", err)
+	}
+	in.Leases, in.Runs, in.Budgets, in.Reservations = led.Leases, led.Runs, led.Budgets, reservations.Items
+	return nil
+}
@@ -0,0 +1,53 @@
+package kube
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/forecast"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+func TestTimelineHandlerServesTheProjection(t *testing.T) {
+	now := time.Now().UTC().Truncate(time.Second)
+	node := healthyNode("node-a", 8)
+	node.Labels = map[string]string{
+		topology.LabelRegion: "us", topology.LabelCluster: "c1",
+		topology.LabelFabricDomain: "fab", topology.LabelGPUFlavor: "H100-80GB",
+	}
+	run := &v1.Run{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default"}}
+	lease := openLeaseOn("train-lease", "train", "node-a")
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(node, run, lease).Build()
+
+	if code := serveChargeback(&TimelineHandler{Clock: staticClock{now}}, "/timeline?flavor=H100-80GB").Code; code != http.StatusServiceUnavailable {
+		t.Errorf("before the manager exists: got %d, want 503", code)
+	}
+	h := &TimelineHandler{Reader: c, Clock: staticClock{now}}
+	for _, target := range []string{"/timeline", "/timeline?flavor=H100-80GB&hours=soon", "/timeline?flavor=H100-80GB&hours=100000"} {
+		if code := serveChargeback(h, target).Code; code != http.StatusBadRequest {
+			t.Errorf("%s: got %d, want 400", target, code)
+		}
+	}
+
+	rec := serveChargeback(h, "/timeline?flavor=H100-80GB&hours=3")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("got %d: %s", rec.Code, rec.Body.String())
+	}
+	var tl forecast.Timeline
+	if err := json.Unmarshal(rec.Body.Bytes(), &tl); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(tl.Domains) != 1 || tl.Domains[0].Capacity != 8 || len(tl.Domains[0].Hours) != 3 {
+		t.Fatalf("unexpected timeline: %+v", tl)
+	}
+	// The run has no ETA, so its open lease holds its GPU through the horizon.
+	if slot := tl.Domains[0].Hours[2]; slot.Running != 1 || slot.Free != 7 {
+		t.Errorf("last hour = %+v, want 1 running and 7 free", slot)
+	}
+}
@@ -0,0 +1,111 @@
+package kube
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// A Run that passes validation is answered with the funding preview as
+// warnings; one that fails validation is rejected before any preview.
+func TestRunValidatorWarnsWithTheFundingPreview(t *testing.T) {
+	now := time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC)
+	start, end := v1.NewTime(now.Add(-24*time.Hour)), v1.NewTime(now.Add(30*24*time.Hour))
+	labels := map[string]string{topology.LabelRegion: "us-west", topology.LabelCluster: "a", topology.LabelFabricDomain: "island-a", topology.LabelGPUFlavor: "H100"}
+	budget := &v1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Name: "team", Namespace: "default"},
+		Spec: v1.BudgetSpec{Owner: "org:team", Envelopes: []v1.BudgetEnvelope{
+			{Name: "west", Flavor: "H100", Selector: map[string]string{topology.LabelRegion: "us-west"}, Concurrency: 8, Start: &start, End: &end},
+		}},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "node-a", Labels: labels},
+		Status: corev1.NodeStatus{
+			Capacity:   corev1.ResourceList{GPUCapacityResource: resource.MustParse("32")},
+			Conditions: []corev1.NodeCondition{{Type: corev1.NodeReady, Status: corev1.ConditionTrue}},
+		},
+	}
+	c := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(budget, node).Build()
+	v := runValidator{Reader: c, Clock: staticClock{now}}
+	run := func(gpus int32) *v1.Run {
+		return &v1.Run{
+			ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "default"},
+			Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "H100", TotalGPUs: gpus}},
+		}
+	}
+	ctx := context.Background()
+
+	warnings, err := v.ValidateCreate(ctx, run(8))
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(warnings) != 1 || warnings[0] != "would start now on H100 as 8 Owned" {
+		t.Errorf("warnings = %q, want the owned funding mix", warnings)
+	}
+
+	warnings, err = v.ValidateCreate(ctx, run(16))
+	if err != nil {
+		t.Fatalf("validate: %v", err)
+	}
+	if len(warnings) != 1 || !strings.HasPrefix(warnings[0], "exceeds every envelope's concurrency") {
+		t.Errorf("warnings = %q, want the concurrency shortfall", warnings)
+	}
+
+	if _, err := v.ValidateCreate(ctx, run(0)); err == nil {
+		t.Error("an invalid run must still be rejected")
+	}
+}
+
+// The preview is under one deadline: a world read that never returns costs the
+// create its warnings, never its answer. A Run a controller owns is not
+// previewed at all.
+func TestRunValidatorSkipsOwnedRunsAndGivesUpAtTheDeadline(t *testing.T) {
+	stuck := make(chan struct{})
+	defer close(stuck)
+	reads := 0
+	c := fake.NewClientBuilder().WithScheme(testScheme()).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				reads++
+				<-stuck // ignores ctx, as a replay would
+				return nil
+			},
+		}).
+		Build()
+	v := runValidator{Reader: c, Clock: staticClock{time.Date(2026, 7, 31, 0, 0, 0, 0, time.UTC)}}
+	yes := true
+	run := &v1.Run{
+		ObjectMeta: metav1.ObjectMeta{Name: "trial-0", Namespace: "default", OwnerReferences: []metav1.OwnerReference{{
+			APIVersion: v1.GroupVersion.String(), Kind: "RunSweep", Name: "sweep", UID: "u1", Controller: &yes,
+		}}},
+		Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100", TotalGPUs: 8}},
+	}
+
+	warnings, err := v.ValidateCreate(context.Background(), run)
+	if err != nil || warnings != nil || reads != 0 {
+		t.Fatalf("an owned run: warnings %q, err %v, %d reads; want no preview", warnings, err, reads)
+	}
+
+	run.OwnerReferences = nil
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	began := time.Now()
+	warnings, err = v.ValidateCreate(ctx, run)
+	if err != nil || warnings != nil {
+		t.Fatalf("a preview past its deadline: warnings %q, err %v; want neither", warnings, err)
+	}
+	if waited := time.Since(began); waited > time.Second {
+		t.Errorf("the create waited %v on a stuck preview", waited)
+	}
+}
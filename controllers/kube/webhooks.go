@@ -3,12 +3,21 @@ package kube
 import (
 	"context"
 	"fmt"
+	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/webhook/admission"
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	jtadmission "github.com/davidlangworthy/jobtree/pkg/admission"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/ledger"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
 
 // The api/v1 types carry ValidateCreate/ValidateUpdate/ValidateDelete and
@@ -26,7 +35,7 @@ import (
 func SetupWebhooks(mgr ctrl.Manager) error {
 	if err := ctrl.NewWebhookManagedBy(mgr, &v1.Run{}).
 		WithCustomDefaulter(runDefaulter{}).
-		WithCustomValidator(legacyValidator{}).
+		WithCustomValidator(runValidator{Reader: mgr.GetClient(), Clock: controllers.RealClock{}}).
 		Complete(); err != nil {
 		return fmt.Errorf("run webhook: %w", err)
 	}
@@ -90,3 +99,91 @@ func (legacyValidator) ValidateDelete(_ context.Context, obj runtime.Object) (ad
 	}
 	return nil, validator.ValidateDelete()
 }
+
+// previewTimeout bounds the funding preview, the world read and the funding
+// replay together. The webhook fails closed, so a slow preview must cost the
+// submitter the preview, never the create.
+const previewTimeout = 2 * time.Second
+
+// runValidator is legacyValidator for Runs, plus a funding preview: a new
+// Run that validates is answered with admission.Preview's warnings, so the
+// submitter learns at create time whether it starts now and how it is
+// funded, or why it waits. The preview only ever warns; it rejects nothing.
+//
+// Reader is the manager's cached client: every Run create would otherwise
+// list the whole ledger and every node from the apiserver. The cache can lag
+// the compactor, so a preview may count a folded lease twice or miss one; it
+// only warns. A Run a controller owns (a sweep's trial or a pipeline stage)
+// gets no preview: nobody is at a terminal to read it, and a sweep would
+// replay the ledger once per trial.
+type runValidator struct {
+	legacyValidator
+	Reader client.Reader
+	Clock  controllers.Clock
+}
+
+func (v runValidator) ValidateCreate(ctx context.Context, obj runtime.Object) (admission.Warnings, error) {
+	if _, err := v.legacyValidator.ValidateCreate(ctx, obj); err != nil {
+		return nil, err
+	}
+	run, ok := obj.(*v1.Run)
+	if !ok || v.Reader == nil || metav1.GetControllerOf(run) != nil {
+		return nil, nil
+	}
+	ctx, cancel := context.WithTimeout(ctx, previewTimeout)
+	defer cancel()
+	// The replay cannot be interrupted, so it runs aside and is abandoned at
+	// the deadline: the create is answered without a preview, not held.
+	run = run.DeepCopy()
+	done := make(chan admission.Warnings, 1)
+	go func() {
+		in, err := previewWorld(ctx, v.Reader)
+		if err != nil {
+			if ctx.Err() != nil {
+				done <- nil
+				return
+			}
+			done <- admission.Warnings{fmt.Sprintf("funding preview unavailable: %v", err)}
+			return
+		}
+		in.Run, in.Now = run, v.Clock.Now()
+		in.Runs[keys.NamespacedKey(run.Namespace, run.Name)] = run
+		done <- jtadmission.Preview(in)
+	}()
+	select {
+	case warnings := <-done:
+		return warnings, nil
+	case <-ctx.Done():
+		return nil, nil
+	}
+}
+
+// previewWorld reads what admission decides against: every Budget, Run,
+// lease and archive, the usable nodes, the topology tiers, and the published
+// transfers.
+func previewWorld(ctx context.Context, reader client.Reader) (jtadmission.Input, error) {
+	var in jtadmission.Input
+	led, err := ledger.List(ctx, reader)
+	if err != nil {
+		return in, err
+	}
+	in.Budgets, in.Runs, in.Leases, in.Archives = led.Budgets, led.Runs, led.Leases, led.Archives
+	in.Transfers = led.Transfers
+	var nodes corev1.NodeList
+	if err := reader.List(ctx, &nodes); err != nil {
+		return in, fmt.Errorf("list nodes: %w", err)
+	}
+	var hierarchies v1.TopologyHierarchyList
+	if err := reader.List(ctx, &hierarchies); err != nil {
+		return in, fmt.Errorf("list topology hierarchies: %w", err)
+	}
+	for i := range nodes.Items {
+		node := &nodes.Items[i]
+		if !topology.NodeUsable(node) {
+			continue
+		}
+		in.Nodes = append(in.Nodes, topology.SourceNodeOf(node))
+	}
+	in.Topology = jtadmission.HierarchyFrom(hierarchies.Items)
+	return in, nil
+}
@@ -0,0 +1,275 @@
+package kube
+
+import (
+	"context"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// worldWriteTTL bounds how long a recorded create may stand in for the cache.
+// The watch delivers an engine write in milliseconds; a created object it has
+// not delivered after this long is one the cache will never show (someone else
+// deleted it before the informer listed it), and holding it longer would
+// resurrect it. An update or delete needs no clock: it is settled by the first
+// read that finds the cache past it.
+const worldWriteTTL = 2 * time.Minute
+
+// World is the engine's read path over the manager's informer cache: the
+// watches keep every kind the engine reads in memory, so a reconcile no longer
+// pays six uncached Lists, and each list is sorted by namespace/name so the
+// engine sees the same order the API server would have returned.
+//
+// The cache alone is not enough. specs/BudgetConservation.tla closes the
+// overspend race only if every engine pass reads the writes of the pass before
+// it, and the watch may not have delivered them yet. So the Bridge records each
+// object it writes, with the resourceVersion it wrote over and the one the API
+// server returned, and the World overlays that copy on the cache while the cache
+// still holds a version the write superseded. resourceVersions are opaque, so
+// they are only ever compared for equality: any other version in the cache is
+// the write itself or someone's later one, and the cache wins. Deletes are
+// recorded the same way: a deleted Reservation is hidden, and a deleted Pod
+// reads as terminating, which is how the List path showed it during its grace
+// period.
+//
+// An object missing from the cache settles its write too: an update's object
+// was there when the engine read it, so a missing one was deleted since, by the
+// engine or anyone else. Only a create stands in for a missing object, until
+// the cache first shows it or worldWriteTTL passes. The Bridge does not record
+// the update that removes a Run's last finalizer.
+//
+// Leases and archives are cached by separate informers, so a reader of the
+// cache can see a compacted lease deleted before it sees the archive that holds
+// it. The lease compactor shares the Bridge's World and records each archive
+// here before deleting anything it folds, so every World read that misses a
+// folded lease sees its archive, as the List path did.
+//
+// Writes by anyone else — the scheduler plugin minting leases, the kubelet
+// moving pods — arrive through the watch and are eventually consistent, as
+// they were to the List path between two reconciles. That is safe because the
+// plugin, the sole committer, re-derives funding from the API itself.
+type World struct {
+	// Cache is the informer-backed reader, normally mgr.GetCache(). Its Lists
+	// must return copies (the controller-runtime default): the engine mutates
+	// what it is given.
+	Cache client.Reader
+
+	mu      sync.Mutex
+	written map[worldKey]*worldWrite
+	now     func() time.Time
+}
+
+// NewWorld returns a World over the given informer cache.
+func NewWorld(cache client.Reader) *World {
+	return &World{Cache: cache}
+}
+
+type worldKey struct {
+	kind, namespace, name string
+}
+
+// worldWrite is one recorded engine write. obj is what the engine last wrote,
+// or, for a delete, what it should read in its place — nil hides the object.
+// stale holds the resourceVersions the write superseded: the one it was made
+// over, and those of the engine's earlier writes still pending for the key (a
+// create followed by its status update, say), plus, for a delete, the version
+// deleted. created marks a create the cache has not shown yet.
+type worldWrite struct {
+	obj     client.Object
+	stale   map[string]bool
+	deleted bool
+	created bool
+	at      time.Time
+}
+
+var _ client.Reader = (*World)(nil)
+
+// Wrote records an object as the API server returned it from a create, update
+// or patch; prev is the resourceVersion the write was made over, empty for a
+// create. While the cache still holds prev (or nothing, for a create), reads
+// return the written object.
+func (w *World) Wrote(obj client.Object, prev string) {
+	if obj.GetResourceVersion() == "" {
+		return
+	}
+	entry := &worldWrite{obj: obj.DeepCopyObject().(client.Object), stale: map[string]bool{}, created: prev == ""}
+	if prev != "" {
+		entry.stale[prev] = true
+	}
+	w.record(obj, entry)
+}
+
+// Deleted records a delete of obj, which carries the resourceVersion the
+// engine last saw. While the cache still holds that version, a Pod reads as
+// terminating and anything else reads as absent.
+func (w *World) Deleted(obj client.Object) {
+	rv := obj.GetResourceVersion()
+	if rv == "" {
+		return
+	}
+	entry := &worldWrite{stale: map[string]bool{rv: true}, deleted: true}
+	if _, isPod := obj.(*corev1.Pod); isPod {
+		terminating := obj.DeepCopyObject().(client.Object)
+		if terminating.GetDeletionTimestamp() == nil {
+			now := metav1.NewTime(w.clock())
+			terminating.SetDeletionTimestamp(&now)
+		}
+		entry.obj = terminating
+	}
+	w.record(obj, entry)
+}
+
+func (w *World) record(obj client.Object, entry *worldWrite) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.written == nil {
+		w.written = map[worldKey]*worldWrite{}
+	}
+	k := worldKey{kindOf(obj), obj.GetNamespace(), obj.GetName()}
+	if old := w.written[k]; old != nil {
+		// The cache may still be behind the earlier write too.
+		for rv := range old.stale {
+			entry.stale[rv] = true
+		}
+		if old.obj != nil {
+			entry.stale[old.obj.GetResourceVersion()] = true
+		}
+		entry.created = entry.created || (old.created && !entry.deleted)
+	}
+	entry.at = w.clock()
+	w.written[k] = entry
+}
+
+// Get reads one object from the cache, overlaid with the engine's own writes.
+func (w *World) Get(ctx context.Context, key client.ObjectKey, obj client.Object, opts ...client.GetOption) error {
+	err := w.Cache.Get(ctx, key, obj, opts...)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return err
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	k := worldKey{kindOf(obj), key.Namespace, key.Name}
+	entry := w.written[k]
+	if entry == nil {
+		return err
+	}
+	cached := ""
+	if err == nil {
+		cached = obj.GetResourceVersion()
+	}
+	if !w.pending(k, entry, cached, err == nil) {
+		return err
+	}
+	if entry.obj == nil {
+		return apierrors.NewNotFound(schema.GroupResource{Resource: strings.ToLower(k.kind)}, key.Name)
+	}
+	reflect.ValueOf(obj).Elem().Set(reflect.ValueOf(entry.obj.DeepCopyObject()).Elem())
+	return nil
+}
+
+// List reads a kind from the cache, overlaid with the engine's own writes and
+// sorted by namespace/name.
+func (w *World) List(ctx context.Context, list client.ObjectList, opts ...client.ListOption) error {
+	if err := w.Cache.List(ctx, list, opts...); err != nil {
+		return err
+	}
+	items, err := meta.ExtractList(list)
+	if err != nil {
+		return err
+	}
+	kind := strings.TrimSuffix(kindOf(list), "List")
+	listOpts := listFilter{(&client.ListOptions{}).ApplyOptions(opts)}
+
+	w.mu.Lock()
+	seen := make(map[worldKey]bool, len(items))
+	merged := items[:0]
+	for _, item := range items {
+		obj := item.(client.Object)
+		k := worldKey{kind, obj.GetNamespace(), obj.GetName()}
+		seen[k] = true
+		entry := w.written[k]
+		if entry == nil || !w.pending(k, entry, obj.GetResourceVersion(), true) {
+			merged = append(merged, item)
+			continue
+		}
+		if entry.obj != nil && listOpts.matches(entry.obj) {
+			merged = append(merged, entry.obj.DeepCopyObject())
+		}
+	}
+	for k, entry := range w.written {
+		if k.kind != kind || seen[k] {
+			continue
+		}
+		if w.pending(k, entry, "", false) && entry.obj != nil && listOpts.matches(entry.obj) {
+			merged = append(merged, entry.obj.DeepCopyObject())
+		}
+	}
+	w.mu.Unlock()
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		a, b := merged[i].(client.Object), merged[j].(client.Object)
+		if a.GetNamespace() != b.GetNamespace() {
+			return a.GetNamespace() < b.GetNamespace()
+		}
+		return a.GetName() < b.GetName()
+	})
+	return meta.SetList(list, merged)
+}
+
+// pending reports whether a recorded write still supersedes what the cache
+// holds for its key, and forgets it once it does not. The caller holds w.mu.
+//
+// While the cache holds a version the write superseded, it is behind and the
+// write stands. Any other cached version is the write's own or a later one. A
+// key missing from the cache keeps only a create the cache has yet to show,
+// and that only for worldWriteTTL.
+func (w *World) pending(k worldKey, entry *worldWrite, cachedRV string, inCache bool) bool {
+	var still bool
+	switch {
+	case inCache:
+		still = entry.stale[cachedRV]
+		if still {
+			entry.created = false
+		}
+	case entry.created:
+		still = w.clock().Sub(entry.at) < worldWriteTTL
+	}
+	if !still {
+		delete(w.written, k)
+	}
+	return still
+}
+
+func (w *World) clock() time.Time {
+	if w.now != nil {
+		return w.now()
+	}
+	return time.Now()
+}
+
+// listFilter is the subset of list options the overlay honours for objects it
+// adds or substitutes: namespace and label selector, which are all the engine
+// passes.
+type listFilter struct{ *client.ListOptions }
+
+func (o listFilter) matches(obj client.Object) bool {
+	if o.Namespace != "" && obj.GetNamespace() != o.Namespace {
+		return false
+	}
+	return o.LabelSelector == nil || o.LabelSelector.Matches(labels.Set(obj.GetLabels()))
+}
+
+func kindOf(obj runtime.Object) string {
+	return reflect.TypeOf(obj).Elem().Name()
+}
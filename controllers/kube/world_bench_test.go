@@ -0,0 +1,134 @@
+package kube
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/invariant"
+)
+
+// Reconcile latency at fleet scale: 10k leases (2k open, 8k closed) and 2k
+// pods on 250 eight-GPU nodes, reconciling one running run.
+//
+//	go test ./controllers/kube -run '^$' -bench Reconcile -benchmem
+//
+// "apireader" is the uncached path: six Lists per pass through the fake
+// client, which stands in for the API server without its network, etcd or
+// serialization, so it understates the real cost. "world" reads the same
+// objects from memory the way the informer cache does — deep copies of what
+// the watches hold — overlaid with the engine's writes.
+
+const (
+	benchNodes       = 250
+	benchLiveRuns    = 500
+	benchDoneRuns    = 2000
+	benchGPUsPerRun  = 4
+	benchGPUsPerNode = 8
+)
+
+// memReader stands in for the manager's informer cache.
+type memReader struct {
+	lists map[reflect.Type]client.ObjectList
+}
+
+func (m memReader) Get(context.Context, client.ObjectKey, client.Object, ...client.GetOption) error {
+	return fmt.Errorf("memReader serves Lists only")
+}
+
+func (m memReader) List(_ context.Context, list client.ObjectList, _ ...client.ListOption) error {
+	if src, ok := m.lists[reflect.TypeOf(list)]; ok {
+		reflect.ValueOf(list).Elem().Set(reflect.ValueOf(src.DeepCopyObject()).Elem())
+	}
+	return nil
+}
+
+func benchFleet() []client.Object {
+	start, end := metav1.NewTime(time.Now().Add(-24*time.Hour)), metav1.NewTime(time.Now().Add(30*24*time.Hour))
+	objs := []client.Object{&v1.Budget{
+		ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "team"},
+		Spec: v1.BudgetSpec{Owner: "org:team", Envelopes: []v1.BudgetEnvelope{{
+			Name: "west", Flavor: "H100-80GB", Concurrency: benchNodes * benchGPUsPerNode, Start: &start, End: &end,
+		}}},
+	}}
+	for n := 0; n < benchNodes; n++ {
+		objs = append(objs, healthyNode(fmt.Sprintf("node-%03d", n), benchGPUsPerNode))
+	}
+	slot := 0
+	for r := 0; r < benchLiveRuns; r++ {
+		name := fmt.Sprintf("train-%04d", r)
+		objs = append(objs, &v1.Run{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, Finalizers: []string{FundingClosureFinalizer}},
+			Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: benchGPUsPerRun}},
+			Status:     v1.RunStatus{Phase: controllers.RunPhaseRunning},
+		})
+		for g := 0; g < benchGPUsPerRun; g, slot = g+1, slot+1 {
+			node := fmt.Sprintf("node-%03d", slot/benchGPUsPerNode)
+			objs = append(objs,
+				openLeaseOn(fmt.Sprintf("%s-lease-%d", name, g), name, node),
+				runPod(fmt.Sprintf("%s-active-%d", name, g), name, node))
+		}
+	}
+	ended := metav1.NewTime(time.Now().Add(-time.Hour))
+	for r := 0; r < benchDoneRuns; r++ {
+		name := fmt.Sprintf("done-%04d", r)
+		objs = append(objs, &v1.Run{
+			ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name},
+			Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: benchGPUsPerRun}},
+			Status:     v1.RunStatus{Phase: controllers.RunPhaseComplete},
+		})
+		for g := 0; g < benchGPUsPerRun; g++ {
+			lease := openLeaseOn(fmt.Sprintf("%s-lease-%d", name, g), name, fmt.Sprintf("node-%03d", (r*benchGPUsPerRun+g)%(benchNodes*benchGPUsPerNode)/benchGPUsPerNode))
+			lease.Spec.Interval.Start = metav1.NewTime(time.Now().Add(-2 * time.Hour))
+			lease.Status.Closed, lease.Status.Ended, lease.Status.ClosureReason = true, &ended, "Completed"
+			objs = append(objs, lease)
+		}
+	}
+	return objs
+}
+
+func BenchmarkReconcile(b *testing.B) {
+	prior := invariant.Report
+	invariant.Report = func(string, []invariant.Violation) {}
+	b.Cleanup(func() { invariant.Report = prior })
+
+	for _, mode := range []string{"apireader", "world"} {
+		b.Run(mode, func(b *testing.B) {
+			ctx := context.Background()
+			api := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(benchFleet()...).
+				WithStatusSubresource(&v1.Run{}, &v1.GPULease{}, &v1.Reservation{}).Build()
+			bridge := &Bridge{Client: api, APIReader: api, Clock: controllers.RealClock{}}
+			r := &RunReconciler{Bridge: bridge}
+			// Settle the fleet once, so the timed passes are steady-state reconciles.
+			if _, err := r.Reconcile(ctx, req("train-0000")); err != nil {
+				b.Fatalf("warm-up reconcile: %v", err)
+			}
+			if mode == "world" {
+				cache := memReader{lists: map[reflect.Type]client.ObjectList{}}
+				for _, list := range []client.ObjectList{&v1.RunList{}, &v1.BudgetList{}, &v1.GPULeaseList{},
+					&v1.LeaseArchiveList{}, &v1.ReservationList{}, &corev1.NodeList{}, &corev1.PodList{}} {
+					if err := api.List(ctx, list); err != nil {
+						b.Fatalf("fill cache: %v", err)
+					}
+					cache.lists[reflect.TypeOf(list)] = list
+				}
+				bridge.World = NewWorld(cache)
+			}
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if _, err := r.Reconcile(ctx, req("train-0000")); err != nil {
+					b.Fatalf("reconcile: %v", err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,243 @@
+package kube
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/controllers"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// A cache that lags: each World here reads from its own fake client, which only
+// changes when the test says the watch has delivered.
+
+func leaseNames(t *testing.T, w *World) map[string]v1.GPULease {
+	t.Helper()
+	var list v1.GPULeaseList
+	if err := w.List(context.Background(), &list); err != nil {
+		t.Fatalf("list leases: %v", err)
+	}
+	out := map[string]v1.GPULease{}
+	for _, l := range list.Items {
+		out[l.Name] = l
+	}
+	return out
+}
+
+// The engine's own write stands in for the cache until the watch delivers the
+// same version, and not a moment longer.
+func TestWorldReadsItsOwnWritesUntilTheWatchCatchesUp(t *testing.T) {
+	ctx := context.Background()
+	api := fake.NewClientBuilder().WithScheme(testScheme()).
+		WithObjects(openLeaseOn("a", "train", "node-a")).
+		WithStatusSubresource(&v1.GPULease{}).Build()
+	var stale v1.GPULease
+	if err := api.Get(ctx, types.NamespacedName{Namespace: "default", Name: "a"}, &stale); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	cache := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(stale.DeepCopy()).Build()
+	world := NewWorld(cache)
+
+	closed := stale.DeepCopy()
+	closed.Status.Closed = true
+	if err := api.Status().Update(ctx, closed); err != nil {
+		t.Fatalf("close: %v", err)
+	}
+	world.Wrote(closed, stale.ResourceVersion)
+	created := openLeaseOn("b", "train", "node-b")
+	if err := api.Create(ctx, created); err != nil {
+		t.Fatalf("create: %v", err)
+	}
+	world.Wrote(created, "")
+
+	got := leaseNames(t, world)
+	if !got["a"].Status.Closed {
+		t.Error("the cache still holds the open lease; the next pass must read the closure it just wrote")
+	}
+	if _, ok := got["b"]; !ok {
+		t.Error("a lease the engine created must be visible before its add event arrives")
+	}
+
+	// The watch delivers: the cache now holds both at the written versions.
+	var delivered v1.GPULeaseList
+	if err := api.List(ctx, &delivered); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	world.Cache = fake.NewClientBuilder().WithScheme(testScheme()).WithLists(&delivered).Build()
+	if got := leaseNames(t, world); got["a"].ResourceVersion != closed.ResourceVersion {
+		t.Errorf("read version %s, want the delivered %s", got["a"].ResourceVersion, closed.ResourceVersion)
+	}
+	if len(world.written) != 0 {
+		t.Errorf("%d writes still overlay a cache that has caught up", len(world.written))
+	}
+}
+
+// Any version in the cache but the one the write was made over — someone else
+// wrote after us — wins at once. resourceVersions are opaque: the cached one
+// here sorts before ours as a string and as a number, and still wins.
+func TestWorldDefersToANewerCachedVersion(t *testing.T) {
+	lease := openLeaseOn("a", "train", "node-a")
+	lease.ResourceVersion = "7"
+	cache := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(lease).Build()
+	world := NewWorld(cache)
+
+	ours := lease.DeepCopy()
+	ours.ResourceVersion = "90"
+	ours.Status.Closed = true
+	world.Wrote(ours, "6")
+
+	var cached v1.GPULease
+	if err := cache.Get(context.Background(), types.NamespacedName{Namespace: "default", Name: "a"}, &cached); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	if got := leaseNames(t, world)["a"]; got.Status.Closed || got.ResourceVersion != cached.ResourceVersion {
+		t.Errorf("read version %s closed=%v; the cached version %s is newer than the write", got.ResourceVersion, got.Status.Closed, cached.ResourceVersion)
+	}
+}
+
+// A deleted Reservation is gone at once; a deleted Pod lingers as terminating,
+// which is how the API showed it to the List path during its grace period.
+func TestWorldHidesDeletesTheCacheHasNotSeen(t *testing.T) {
+	ctx := context.Background()
+	res := &v1.Reservation{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: "r"}}
+	pod := runPod("p", "train", "node-a")
+	cache := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(res, pod).Build()
+	world := NewWorld(cache)
+
+	for _, obj := range []client.Object{res, pod} {
+		if err := cache.Get(ctx, client.ObjectKeyFromObject(obj), obj); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+	}
+	world.Deleted(res)
+	world.Deleted(pod)
+
+	var reservations v1.ReservationList
+	if err := world.List(ctx, &reservations); err != nil || len(reservations.Items) != 0 {
+		t.Errorf("a deleted reservation is still listed (%d, err %v)", len(reservations.Items), err)
+	}
+	if err := world.Get(ctx, client.ObjectKeyFromObject(res), &v1.Reservation{}); !apierrors.IsNotFound(err) {
+		t.Errorf("get of a deleted reservation: %v, want NotFound", err)
+	}
+	var pods corev1.PodList
+	if err := world.List(ctx, &pods, client.HasLabels{binder.LabelRunName}); err != nil || len(pods.Items) != 1 {
+		t.Fatalf("a deleted pod must still be listed while it terminates (%d, err %v)", len(pods.Items), err)
+	}
+	if pods.Items[0].DeletionTimestamp == nil {
+		t.Error("a deleted pod must read as terminating")
+	}
+
+	// The watch delivers the deletes.
+	if err := cache.Delete(ctx, res); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := cache.Delete(ctx, pod); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := world.List(ctx, &pods); err != nil || len(pods.Items) != 0 {
+		t.Errorf("a pod the cache no longer holds is still listed (%d, err %v)", len(pods.Items), err)
+	}
+	if err := world.List(ctx, &reservations); err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(world.written) != 0 {
+		t.Errorf("%d deletes still recorded after the cache saw them", len(world.written))
+	}
+}
+
+// An object the engine patched and someone else then deleted is gone as soon
+// as the cache says so: only a create stands in for a missing object.
+func TestWorldDoesNotResurrectADeletedObject(t *testing.T) {
+	ctx := context.Background()
+	pod := runPod("p", "train", "node-a")
+	pod.ResourceVersion = "11"
+	cache := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(pod).Build()
+	world := NewWorld(cache)
+	if err := cache.Get(ctx, client.ObjectKeyFromObject(pod), pod); err != nil {
+		t.Fatalf("get: %v", err)
+	}
+
+	patched := pod.DeepCopy()
+	patched.Annotations = map[string]string{binder.AnnotationTimeLimit: "soon"}
+	patched.ResourceVersion = "12"
+	world.Wrote(patched, pod.ResourceVersion)
+	var pods corev1.PodList
+	if err := world.List(ctx, &pods); err != nil || len(pods.Items) != 1 || pods.Items[0].Annotations[binder.AnnotationTimeLimit] != "soon" {
+		t.Fatalf("the patch must be read while the cache holds the version it was made over (%v, err %v)", pods.Items, err)
+	}
+
+	if err := cache.Delete(ctx, pod); err != nil {
+		t.Fatalf("delete: %v", err)
+	}
+	if err := world.List(ctx, &pods); err != nil || len(pods.Items) != 0 {
+		t.Errorf("a pod deleted out from under the engine is still listed (%d, err %v)", len(pods.Items), err)
+	}
+	if len(world.written) != 0 {
+		t.Errorf("%d writes still recorded for an object the cache no longer holds", len(world.written))
+	}
+}
+
+// A create the watch never delivers — its object was deleted by someone else
+// before the informer saw it — expires rather than resurrecting the object.
+func TestWorldForgetsAWriteTheWatchNeverDelivers(t *testing.T) {
+	now := baseTime
+	world := NewWorld(fake.NewClientBuilder().WithScheme(testScheme()).Build())
+	world.now = func() time.Time { return now }
+
+	lease := openLeaseOn("a", "train", "node-a")
+	lease.ResourceVersion = "3"
+	world.Wrote(lease, "")
+	if _, ok := leaseNames(t, world)["a"]; !ok {
+		t.Fatal("a fresh write must be read")
+	}
+	now = now.Add(worldWriteTTL)
+	if _, ok := leaseNames(t, world)["a"]; ok {
+		t.Error("a write older than the TTL still stands in for the cache")
+	}
+}
+
+// The BudgetConservation property at the Bridge: with a watch that never
+// delivers, a second pass still starts from everything the first one wrote.
+// Reading the frozen cache instead, it would close the lease again from a stale
+// version (a conflict) and report the terminal run a second time.
+func TestWithWorldOverALaggingCacheReadsItsOwnWrites(t *testing.T) {
+	seen := captureReport(t)
+
+	run := &v1.Run{
+		ObjectMeta: metav1.ObjectMeta{Name: "dead", Namespace: "default"},
+		Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1}},
+		Status:     v1.RunStatus{Phase: controllers.RunPhaseFailed},
+	}
+	objs := []client.Object{healthyNode("node-a", 4), run, openLeaseOn("dead-lease", "dead", "node-a"), runPod("dead-active-0", "dead", "node-a")}
+	api := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(objs...).
+		WithStatusSubresource(&v1.Run{}, &v1.GPULease{}).Build()
+	var frozen []client.Object
+	for _, obj := range objs {
+		cur := obj.DeepCopyObject().(client.Object)
+		if err := api.Get(context.Background(), client.ObjectKeyFromObject(obj), cur); err != nil {
+			t.Fatalf("get: %v", err)
+		}
+		frozen = append(frozen, cur)
+	}
+	cache := fake.NewClientBuilder().WithScheme(testScheme()).WithObjects(frozen...).Build()
+	bridge := &Bridge{Client: api, APIReader: api, World: NewWorld(cache), Clock: controllers.RealClock{}}
+
+	noop := func(*controllers.ClusterState, time.Time) error { return nil }
+	for pass := 1; pass <= 2; pass++ {
+		if err := bridge.WithWorld(context.Background(), noop); err != nil {
+			t.Fatalf("pass %d: %v", pass, err)
+		}
+	}
+	if len(*seen) != 1 {
+		t.Errorf("the sweep reported %d violations over two passes, want 1: the second pass did not see the first's closure", len(*seen))
+	}
+}
@@ -0,0 +1,87 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// One A100 node with two whole GPUs and seven 1g.10gb slices, beside an
+// unsliced one. "notebook" holds both whole GPUs; "eval" wants six slices.
+
+func migState(now time.Time, sliceConcurrency int32) *ClusterState {
+	sliced := flavorNode("m1", "a100", 2)
+	sliced.Labels[topology.LabelFabricDomain] = "island-a"
+	sliced.Slices = map[string]int{"1g.10gb": 7}
+	whole := flavorNode("m2", "a100", 8)
+	whole.Labels[topology.LabelFabricDomain] = "island-a"
+
+	notebook := nfRun("notebook", "org:ai:team", 2, now)
+	notebook.Spec.Resources.GPUType = "a100"
+	held := nfLease("notebook-lease", "notebook", "org:ai:team", "team", []string{"m1#0", "m1#1"}, binder.RoleActive, now)
+	held.Spec.PaidByEnvelope = "west-a100"
+	state := &ClusterState{
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec: v1.BudgetSpec{Owner: "org:ai:team", Envelopes: []v1.BudgetEnvelope{
+				{Name: "west-a100", Flavor: "a100", Concurrency: 8, Start: &testWindowStart, End: &testWindowEnd},
+				{Name: "west-mig", Flavor: "a100-1g.10gb", Concurrency: sliceConcurrency, Start: &testWindowStart, End: &testWindowEnd},
+			}},
+		}},
+		Nodes: []topology.SourceNode{sliced, whole},
+		Runs: map[string]*v1.Run{
+			"default/notebook": notebook,
+			"default/eval": {
+				ObjectMeta: v1.ObjectMeta{Name: "eval", Namespace: "default"},
+				Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: "a100-1g.10gb", TotalGPUs: 6}},
+			},
+		},
+		Leases: []v1.GPULease{held},
+	}
+	mirrorPods(state)
+	return state
+}
+
+// The slices are counted apart from the node's whole GPUs: notebook's two do
+// not shrink the seven, and eval lands on the only node that has any.
+func TestMIGRunPlacesOnSlicesBesideWholeGPUs(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := migState(now, 7)
+	if err := NewRunController(state, runClock{now: now}).Reconcile("default", "eval"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	eval := state.Runs["default/eval"]
+	if got := activeIntentPods(state, "default", "eval"); got != 6 || admittedReason(eval) != v1.RunStateScheduling.Reason {
+		t.Fatalf("eval: %d intent pods, state %q (%s); want 6 and Scheduling", got, admittedReason(eval), eval.Status.Message)
+	}
+	for i := range state.Pods {
+		if pod := state.Pods[i]; pod.Labels[binder.LabelRunName] == "eval" && pod.NodeName != "m1" {
+			t.Errorf("eval's pod %s is hinted at %s, which has no slices", pod.Name, pod.NodeName)
+		}
+	}
+}
+
+// The slice envelope's concurrency is in slices: one already leased leaves
+// five of six, short of eval's six.
+func TestMIGEnvelopeConcurrencyCountsSlices(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	state := migState(now, 6)
+	probe := nfRun("probe", "org:ai:team", 1, now)
+	probe.Spec.Resources.GPUType = "a100-1g.10gb"
+	state.Runs["default/probe"] = probe
+	lease := nfLease("probe-lease", "probe", "org:ai:team", "team", []string{"m1#0"}, binder.RoleActive, now)
+	lease.Spec.PaidByEnvelope, lease.Spec.Slice.Profile = "west-mig", "1g.10gb"
+	state.Leases = append(state.Leases, lease)
+	mirrorPods(state)
+
+	if err := NewRunController(state, runClock{now: now}).Reconcile("default", "eval"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	eval := state.Runs["default/eval"]
+	if got := activeIntentPods(state, "default", "eval"); got != 0 || admittedReason(eval) == v1.RunStateScheduling.Reason {
+		t.Fatalf("eval scheduled %d pods on an envelope with five slices left (%s)", got, eval.Status.Message)
+	}
+}
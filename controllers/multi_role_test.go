@@ -0,0 +1,102 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// mrRun is an RL-shaped two-role run: four 1-GPU actors beside one 4-GPU learner.
+func mrRun() *v1.Run {
+	run := giRun("rl", 8, 0)
+	run.Spec.Roles = []v1.RunRole{
+		{Name: "actor", Width: 4, GPUsPerPod: 1},
+		{Name: "learner", Width: 1, GPUsPerPod: 4},
+	}
+	return run
+}
+
+// A multi-role run is planned as one gang and emitted as one: every role's pods,
+// at that role's size, under one expected width, so the plugin holds them all at
+// Permit until the whole gang is placed and funded.
+func TestMultiRoleRunEmitsEveryRoleAsOneGang(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	run := mrRun()
+	plan, err := planPlacement(run, giSnapshot(t, 2, 8))
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	state := &ClusterState{Runs: map[string]*v1.Run{"default/rl": run}}
+	c := NewRunController(state, runClock{now: now})
+	if created := c.emitIntentPods(run, plan); created != 5 {
+		t.Fatalf("emitted %d pods, want 5 (4 actors + 1 learner)", created)
+	}
+
+	gpusByRole := map[string]int{}
+	for _, p := range state.Pods {
+		role := p.Labels[binder.LabelRoleName]
+		gpusByRole[role] += p.GPUs
+		if p.Annotations[binder.AnnotationExpectedWidth] != "5" {
+			t.Errorf("%s expected width = %q, want 5: Permit must wait for every role", p.Name, p.Annotations[binder.AnnotationExpectedWidth])
+		}
+		if p.Labels[binder.LabelGroupIndex] == "" {
+			t.Errorf("%s carries no group index", p.Name)
+		}
+	}
+	if gpusByRole["actor"] != 4 || gpusByRole["learner"] != 4 {
+		t.Errorf("GPUs by role = %v, want actor=4 learner=4", gpusByRole)
+	}
+	if expectedActiveGPUs(run) != 8 {
+		t.Errorf("expectedActiveGPUs = %d, want the roles' sum 8", expectedActiveGPUs(run))
+	}
+
+	// Top-up with no plan in hand re-emits a lost learner under its role's name and
+	// its role's group, which follows the actors' group.
+	var learnerGroup string
+	kept := state.Pods[:0]
+	for _, p := range state.Pods {
+		if p.Name == "rl-learner-0" {
+			learnerGroup = p.Labels[binder.LabelGroupIndex]
+			continue
+		}
+		kept = append(kept, p)
+	}
+	state.Pods = kept
+	if created := c.topUpActiveGang(run); created != 1 {
+		t.Fatalf("top-up created %d pods, want the one missing learner", created)
+	}
+	last := state.Pods[len(state.Pods)-1]
+	if last.Name != "rl-learner-0" || last.GPUs != 4 || last.Labels[binder.LabelGroupIndex] != learnerGroup {
+		t.Errorf("re-emitted %s (%d GPUs, group %q), want rl-learner-0 (4 GPUs, group %q)",
+			last.Name, last.GPUs, last.Labels[binder.LabelGroupIndex], learnerGroup)
+	}
+}
+
+// Each role answers for its own failures: an Ignore role's failed pod does not hold
+// the run open, while a failed pod of a Fail role does.
+func TestMultiRoleFailurePolicyIsPerRole(t *testing.T) {
+	run := mrRun()
+	run.Spec.Roles[0].FailurePolicy = v1.FailurePolicyIgnore
+	pod := func(name, role, phase string) binder.PodManifest {
+		return binder.PodManifest{Namespace: "default", Name: name, Phase: phase, Labels: map[string]string{
+			binder.LabelRunName: "rl", binder.LabelRunRole: binder.RoleActive, binder.LabelRoleName: role,
+		}}
+	}
+	state := &ClusterState{
+		Runs: map[string]*v1.Run{"default/rl": run},
+		Pods: []binder.PodManifest{
+			pod("rl-actor-0", "actor", binder.PodPhaseFailed),
+			pod("rl-learner-0", "learner", binder.PodPhaseSucceeded),
+		},
+	}
+	c := NewRunController(state, runClock{now: time.Now()})
+	if !c.runGangComplete(run) {
+		t.Errorf("a failed actor under Ignore must not hold the run open")
+	}
+	state.Pods[1].Phase = binder.PodPhaseFailed
+	if c.runGangComplete(run) {
+		t.Errorf("a failed learner under the default Fail policy must hold the run open")
+	}
+}
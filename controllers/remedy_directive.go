@@ -0,0 +1,121 @@
+package controllers
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/resolver"
+)
+
+// ExecuteDirective runs this cluster's share of a cross-cluster aggregate-cap
+// remedy (M10) and records the answer on the directive's status. It is the local
+// half of the protocol cmd/aggregator drives: the aggregator decides how much
+// each cluster frees, the cluster's own resolver decides what.
+//
+// Only funded leases charged to the cap's member envelopes are candidates — they
+// are the width the cap counts, so cutting anything else would clear a local
+// deficit and leave the global breach standing. Unfunded work is not in the
+// aggregate either, which is why there is no reclaim-unfunded phase here. The
+// evaluation still goes to the resolver: the lottery weights each run's ticket
+// by the priority its envelope authorizes and draws owners by fair-share band,
+// exactly as a local cut does.
+//
+// The resolver is seeded from the directive's shared seed and issue instant, not
+// from this cluster's clock, so every cluster's lottery for one breach is replayable
+// from the one value the aggregator logged.
+//
+// Every lease the directive cuts is closed with directiveReason, so the cut is
+// idempotent: a directive whose cut landed but whose answer was never written
+// finds its leases already closed and is answered from them, not cut again. A
+// directive already answered is left alone; together that is what makes
+// redelivery safe.
+func (c *RunController) ExecuteDirective(d *v1.RemedyDirective, now time.Time) error {
+	if !d.Outstanding() {
+		return nil
+	}
+	executedAt := v1.NewTime(now)
+	d.Status.ExecutedAt = &executedAt
+	if err := d.Spec.Validate(); err != nil {
+		d.Status.Phase = v1.DirectivePhaseFailed
+		d.Status.Message = err.Error()
+		return nil
+	}
+	if freed := directiveFreed(c.State.Leases, d); freed > 0 {
+		answerDirective(d, freed)
+		return nil
+	}
+
+	ev := c.evaluate(now)
+	members := make(map[funding.EnvelopeKey]struct{}, len(d.Spec.Envelopes))
+	for _, env := range d.Spec.Envelopes {
+		members[funding.EnvelopeKey{Namespace: d.Namespace, Budget: d.Spec.Budget, Envelope: env}] = struct{}{}
+	}
+	var leases []*v1.GPULease
+	for _, lease := range activeLeasePointers(c.State.Leases) {
+		key := funding.EnvelopeKey{Namespace: lease.Spec.PaidByBudgetNamespace, Budget: lease.Spec.PaidByBudget, Envelope: lease.Spec.PaidByEnvelope}
+		if _, ok := members[key]; !ok {
+			continue
+		}
+		if class, ok := ev.Class(lease); !ok || class == funding.ClassUnfunded {
+			continue
+		}
+		leases = append(leases, lease)
+	}
+
+	resolution, err := resolver.Resolve(resolver.Input{
+		Deficit:    int(d.Spec.Deficit),
+		Flavor:     d.Spec.Flavor,
+		Scope:      d.Spec.Scope,
+		SeedSource: d.Spec.Seed,
+		Now:        d.Spec.IssuedAt.Time,
+		Nodes:      c.State.Nodes,
+		Leases:     leases,
+		Runs:       c.State.Runs,
+		Evaluation: ev,
+	})
+	if err != nil {
+		d.Status.Phase = v1.DirectivePhaseFailed
+		d.Status.Message = err.Error()
+		return nil
+	}
+	freed := 0
+	for i := range resolution.Actions {
+		action := &resolution.Actions[i]
+		action.Reason = directiveReason(d) + ": " + action.Reason
+		freed += action.GPUs
+	}
+	c.applyResolution(resolution, now)
+	answerDirective(d, freed)
+	return nil
+}
+
+// directiveReason prefixes the closure reason of every lease d cuts, as in
+// AggregateCap(default/org-3f2a…): RandomPreempt(0x…).
+func directiveReason(d *v1.RemedyDirective) string {
+	return fmt.Sprintf("AggregateCap(%s/%s)", d.Namespace, d.Name)
+}
+
+// directiveFreed is the GPUs of the leases d's cut already closed.
+func directiveFreed(leases []v1.GPULease, d *v1.RemedyDirective) int {
+	prefix := directiveReason(d) + ":"
+	freed := 0
+	for i := range leases {
+		lease := &leases[i]
+		if lease.Status.Closed && strings.HasPrefix(lease.Status.ClosureReason, prefix) {
+			freed += len(lease.Spec.Slice.Nodes)
+		}
+	}
+	return freed
+}
+
+func answerDirective(d *v1.RemedyDirective, freed int) {
+	d.Status.Phase = v1.DirectivePhaseExecuted
+	d.Status.FreedGPUs = int32(freed)
+	d.Status.Message = fmt.Sprintf("freed %d of %d GPU(s) under aggregate cap %s/%s", freed, d.Spec.Deficit, d.Spec.Budget, d.Spec.Cap)
+	if freed < int(d.Spec.Deficit) {
+		d.Status.Message += "; nothing else in scope is charged to the cap"
+	}
+}
@@ -0,0 +1,208 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/aggregator"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+)
+
+// fakeCluster is one member of the offline multi-cluster harness: an in-memory
+// world, the engine driving it, and the directives the aggregator wrote into it.
+type fakeCluster struct {
+	name       string
+	state      *ClusterState
+	controller *RunController
+	directives []v1.RemedyDirective
+}
+
+func (f *fakeCluster) view() aggregator.ClusterView {
+	return aggregator.ClusterView{
+		Name:       f.name,
+		Budgets:    f.state.Budgets,
+		Leases:     f.state.Leases,
+		Runs:       f.state.Runs,
+		Directives: f.directives,
+	}
+}
+
+// newCapCluster holds two funded 2-GPU runs under the org's shared H100 cap. The
+// cap is 6: each cluster alone sits well inside it.
+func newCapCluster(name string, now time.Time) *fakeCluster {
+	budget := nfBudget("team", "org:ai:team")
+	maxConcurrency := int32(6)
+	budget.Spec.AggregateCaps = []v1.AggregateCap{{Name: "org-h100", Flavor: "H100-80GB", Envelopes: []string{"west"}, MaxConcurrency: &maxConcurrency}}
+	state := &ClusterState{
+		Nodes:   nodeFailureNodes(),
+		Budgets: []v1.Budget{budget},
+		Runs: map[string]*v1.Run{
+			"default/r1": nfRun("r1", "org:ai:team", 2, now),
+			"default/r2": nfRun("r2", "org:ai:team", 2, now),
+		},
+		Leases: []v1.GPULease{
+			nfLeaseGroup("r1-g0", "r1", "org:ai:team", "team", "0", []string{"node-a#0", "node-a#1"}, binder.RoleActive, now),
+			nfLeaseGroup("r2-g0", "r2", "org:ai:team", "team", "0", []string{"node-b#0", "node-b#1"}, binder.RoleActive, now),
+		},
+	}
+	mirrorPods(state)
+	return &fakeCluster{name: name, state: state, controller: NewRunController(state, runClock{now: now})}
+}
+
+func aggregate(clusters []*fakeCluster, now time.Time) aggregator.Result {
+	views := make([]aggregator.ClusterView, 0, len(clusters))
+	for _, c := range clusters {
+		views = append(views, c.view())
+	}
+	return aggregator.Evaluate(aggregator.Input{Clusters: views, Now: now})
+}
+
+// Three clusters each inside the cap hold twice it between them. The aggregator
+// apportions the excess, each cluster's own resolver cuts its share from the
+// cap's envelopes under the one shared seed, and the next pass finds the cap met
+// and issues nothing.
+func TestAggregateCapBreachIsClearedAcrossClusters(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clusters := []*fakeCluster{newCapCluster("east", now), newCapCluster("west", now), newCapCluster("north", now)}
+
+	result := aggregate(clusters, now)
+	if len(result.Usage) != 1 || result.Usage[0].Width != 12 {
+		t.Fatalf("global usage = %+v, want one cap at width 12", result.Usage)
+	}
+	if len(result.Directives) != 3 {
+		t.Fatalf("got %d directives, want one per contributing cluster", len(result.Directives))
+	}
+	byName := map[string]*fakeCluster{}
+	for _, c := range clusters {
+		byName[c.name] = c
+	}
+	seed := result.Directives[0].Directive.Spec.Seed
+	for _, d := range result.Directives {
+		if d.Directive.Spec.Seed != seed {
+			t.Fatalf("directives for one breach must share a seed: %s vs %s", d.Directive.Spec.Seed, seed)
+		}
+		if d.Directive.Spec.Deficit != 2 {
+			t.Errorf("%s asked for %d GPU(s), want an even share of 2", d.Cluster, d.Directive.Spec.Deficit)
+		}
+		byName[d.Cluster].directives = append(byName[d.Cluster].directives, d.Directive)
+	}
+
+	later := now.Add(time.Minute)
+	for _, c := range clusters {
+		d := &c.directives[0]
+		if err := c.controller.ExecuteDirective(d, later); err != nil {
+			t.Fatalf("%s: execute: %v", c.name, err)
+		}
+		if d.Status.Phase != v1.DirectivePhaseExecuted || d.Status.FreedGPUs != 2 {
+			t.Errorf("%s answered %s freeing %d: %s", c.name, d.Status.Phase, d.Status.FreedGPUs, d.Status.Message)
+		}
+		assertSteady(t, c.controller, c.name+" after the remedy")
+	}
+
+	// Every cluster drew from the same attested seed.
+	var reason string
+	for _, c := range clusters {
+		for i := range c.state.Leases {
+			lease := &c.state.Leases[i]
+			if !lease.Status.Closed {
+				continue
+			}
+			if reason == "" {
+				reason = lease.Status.ClosureReason
+			} else if lease.Status.ClosureReason != reason {
+				t.Errorf("%s closed %s with %q, another cluster with %q", c.name, lease.Name, lease.Status.ClosureReason, reason)
+			}
+		}
+	}
+
+	after := aggregate(clusters, later)
+	if after.Usage[0].Width != 6 || len(after.Directives) != 0 {
+		t.Fatalf("after the remedy: width %d with %d new directive(s), want the cap met and none", after.Usage[0].Width, len(after.Directives))
+	}
+}
+
+// Until every cluster answers, the breach is not re-evaluated: the widths it was
+// computed from are mid-change, and a second directive would cut twice. A
+// directive of the breach that never landed is re-issued as it was first meant,
+// and nothing else is.
+func TestOutstandingDirectiveGatesTheCap(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	clusters := []*fakeCluster{newCapCluster("east", now), newCapCluster("west", now)}
+	first := aggregate(clusters, now)
+	if len(first.Directives) != 2 {
+		t.Fatalf("setup: 8 GPUs under a cap of 6 must breach in both clusters, got %d directive(s)", len(first.Directives))
+	}
+	// Only west's directive landed.
+	clusters[1].directives = append(clusters[1].directives, first.Directives[1].Directive)
+
+	again := aggregate(clusters, now.Add(time.Minute))
+	if !again.Usage[0].Outstanding || len(again.Directives) != 1 {
+		t.Fatalf("a partly written breach must re-issue only its missing directive: %+v", again)
+	}
+	if got, want := again.Directives[0], first.Directives[0]; got.Cluster != want.Cluster ||
+		got.Directive.Name != want.Directive.Name || got.Directive.Spec.Deficit != want.Directive.Spec.Deficit {
+		t.Errorf("re-issued %s %s for %d GPU(s), want %s %s for %d", got.Cluster, got.Directive.Name, got.Directive.Spec.Deficit,
+			want.Cluster, want.Directive.Name, want.Directive.Spec.Deficit)
+	}
+	clusters[0].directives = append(clusters[0].directives, again.Directives[0].Directive)
+	if full := aggregate(clusters, now.Add(2*time.Minute)); len(full.Directives) != 0 {
+		t.Fatalf("a cap with its whole breach unanswered must not be re-issued: %+v", full.Directives)
+	}
+
+	// Redelivering an answered directive is a no-op.
+	d := &clusters[1].directives[0]
+	if err := clusters[1].controller.ExecuteDirective(d, now); err != nil {
+		t.Fatal(err)
+	}
+	open := len(activeLeasePointers(clusters[1].state.Leases))
+	if err := clusters[1].controller.ExecuteDirective(d, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(activeLeasePointers(clusters[1].state.Leases)); got != open {
+		t.Errorf("re-executing an answered directive cut again: %d open lease(s), was %d", got, open)
+	}
+
+	// So is redelivering one whose cut landed but whose answer was lost: the
+	// leases it closed answer it.
+	lost := *d
+	lost.Status = v1.RemedyDirectiveStatus{}
+	if err := clusters[1].controller.ExecuteDirective(&lost, now); err != nil {
+		t.Fatal(err)
+	}
+	if got := len(activeLeasePointers(clusters[1].state.Leases)); got != open {
+		t.Errorf("re-executing a directive whose answer was lost cut again: %d open lease(s), was %d", got, open)
+	}
+	if lost.Status.Phase != v1.DirectivePhaseExecuted || lost.Status.FreedGPUs != d.Status.FreedGPUs {
+		t.Errorf("lost answer recovered as %s freeing %d, want Executed freeing %d", lost.Status.Phase, lost.Status.FreedGPUs, d.Status.FreedGPUs)
+	}
+}
+
+// A directive's cut is the resolver's lottery with the funding facts behind
+// it: the priority the envelope authorizes weights a run's ticket, so under
+// this seed the urgent run keeps its GPUs and the routine one gives them up.
+func TestDirectiveLotteryWeighsAuthorizedPriority(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	c := newCapCluster("east", now)
+	c.state.Budgets[0].Spec.Envelopes[0].MaxPriority = v1.MaxRunPriority
+	c.state.Runs["default/r2"].Spec.Priority = v1.MaxRunPriority
+	d := &v1.RemedyDirective{
+		ObjectMeta: v1.ObjectMeta{Name: "org-h100-1", Namespace: c.state.Budgets[0].Namespace},
+		Spec: v1.RemedyDirectiveSpec{
+			Budget: "team", Cap: "org-h100", Envelopes: []string{"west"}, Flavor: "H100-80GB",
+			Deficit: 2, Seed: "breach-10", IssuedAt: v1.NewTime(now),
+		},
+	}
+	if err := c.controller.ExecuteDirective(d, now.Add(time.Minute)); err != nil {
+		t.Fatalf("execute: %v", err)
+	}
+	if d.Status.Phase != v1.DirectivePhaseExecuted || d.Status.FreedGPUs != 2 {
+		t.Fatalf("answered %s freeing %d: %s", d.Status.Phase, d.Status.FreedGPUs, d.Status.Message)
+	}
+	if closed, reason := closureOf(c.state, "r2-g0"); closed {
+		t.Errorf("the priority-%d run was cut: %s", v1.MaxRunPriority, reason)
+	}
+	if closed, _ := closureOf(c.state, "r1-g0"); !closed {
+		t.Error("the priority-0 run must give up its GPUs")
+	}
+}
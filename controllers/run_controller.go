@@ -9,13 +9,17 @@ import (
 	"time"
 
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/admission"
+	"github.com/davidlangworthy/jobtree/pkg/artifacts"
 	"github.com/davidlangworthy/jobtree/pkg/binder"
 	"github.com/davidlangworthy/jobtree/pkg/cover"
+	"github.com/davidlangworthy/jobtree/pkg/defrag"
 	"github.com/davidlangworthy/jobtree/pkg/forecast"
 	"github.com/davidlangworthy/jobtree/pkg/funding"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
 	"github.com/davidlangworthy/jobtree/pkg/metrics"
 	"github.com/davidlangworthy/jobtree/pkg/pack"
+	"github.com/davidlangworthy/jobtree/pkg/pipeline"
 	"github.com/davidlangworthy/jobtree/pkg/resolver"
 	"github.com/davidlangworthy/jobtree/pkg/topology"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -66,6 +70,15 @@ type ClusterState struct {
 	Leases       []v1.GPULease
 	Pods         []binder.PodManifest
 	Reservations map[string]*v1.Reservation
+	// Archives are the ledger's compacted windows; the funding replay resumes
+	// from them.
+	Archives []v1.LeaseArchive
+	// Transfers are the BudgetTransfers compiled into the published
+	// QuotaSnapshot; funding classifies their borrowers' width Borrowed.
+	Transfers []v1.SnapshotTransfer
+	// Topology is the fleet's tiers below each fabric domain; placement packs
+	// into them and a run's locality names them.
+	Topology topology.Hierarchy
 }
 
 // RunController drives immediate admissions using the local state.
@@ -126,11 +139,13 @@ func NewRunController(state *ClusterState, clock Clock) *RunController {
 // classification back from status.
 func (c *RunController) evaluate(now time.Time) *funding.Evaluation {
 	return funding.Evaluate(funding.Input{
-		Budgets: c.State.Budgets,
-		Leases:  c.State.Leases,
-		Runs:    c.State.Runs,
-		Now:     now,
-		Period:  c.Period,
+		Budgets:   c.State.Budgets,
+		Leases:    c.State.Leases,
+		Runs:      c.State.Runs,
+		Now:       now,
+		Period:    c.Period,
+		Archives:  c.State.Archives,
+		Transfers: c.State.Transfers,
 	})
 }
 
@@ -148,7 +163,7 @@ func (c *RunController) Reconcile(namespace, name string) error {
 	before := c.snapshotWorld()
 	defer c.checkInvariants("RunController.Reconcile", before)
 
-	flavor := run.Spec.Resources.GPUType
+	flavor := run.Flavor()
 	start := time.Now()
 	result := "noop"
 	defer func() {
@@ -211,6 +226,19 @@ func (c *RunController) Reconcile(namespace, name string) error {
 		return nil
 	}
 
+	// The time limit, after the completion gate so a gang that finished on its
+	// last tick completes rather than being cut. A run requeued whole starts a
+	// fresh clock when it re-admits.
+	if run.Status.Phase == RunPhaseRunning {
+		if c.enforceTimeLimit(run, now) {
+			run.Status.Width = summarizeRunWidth(run, c.State.Leases)
+			result = "failed"
+			return nil
+		}
+	} else if isPreAdmission(run.Status.Phase) {
+		run.Status.TimeLimit = nil
+	}
+
 	// Follow gate: a run with unmet dependencies waits (or fails) before any
 	// admission — placed before topology/adoption so a Waiting run never packs,
 	// reserves, or is flipped Running by a stray lease. Once Running/terminal,
@@ -233,10 +261,26 @@ func (c *RunController) Reconcile(namespace, name string) error {
 		return nil
 	}
 
+	// Drain: a run the resolver requeued (a checkpointed cut) re-admits only once
+	// its old pods are gone — they are still writing the checkpoint it will resume
+	// from — or their grace has run out. The kubelet enforces the grace; this only
+	// keeps the run from re-planning onto GPUs its own draining pods still hold.
+	// The deadline itself stays until it passes: the bridge reads it when it
+	// deletes the cut pods, which may be after this reconcile in the same pass.
+	if deadline := run.Status.DrainDeadline; deadline != nil {
+		if !now.Before(deadline.Time) {
+			run.Status.DrainDeadline = nil
+		} else if run.Status.Phase == RunPhasePending && c.runIsDraining(run) {
+			run.Status.Width = summarizeRunWidth(run, c.State.Leases)
+			result = "draining"
+			return nil
+		}
+	}
+
 	c.mirrorETA(run, now)
 
 	usage := computeUsage(c.State.Leases, now)
-	snapshot, err := topology.BuildSnapshotForFlavor(c.State.Nodes, usage, run.Spec.Resources.GPUType)
+	snapshot, err := topology.BuildSnapshot(c.State.Nodes, usage, run.Flavor(), c.State.Topology)
 	if err != nil {
 		setState(run, v1.RunStateUnschedulable, err.Error())
 		run.Status.Width = summarizeRunWidth(run, c.State.Leases)
@@ -251,6 +295,8 @@ func (c *RunController) Reconcile(namespace, name string) error {
 
 	if run.Status.Phase == RunPhaseRunning {
 		if run.Spec.Malleable != nil {
+			c.sizeByGoodput(run, ev, now)
+			run.Status.Width = summarizeRunWidth(run, c.State.Leases)
 			if err := c.reconcileElasticRun(run, snapshot, inventory, now); err != nil {
 				result = "error"
 				return err
@@ -324,100 +370,213 @@ func (c *RunController) Reconcile(namespace, name string) error {
 		return nil
 	}
 
-	reclaimed := false
+	// A booking waits for its start, not for capacity. It is checked once, the
+	// first time the run is seen before its start; from then on the booking
+	// reservation is ActivateReservations' to start, so the run neither admits
+	// early nor re-plans a forecast reservation over it. A run whose booking
+	// was rejected stays rejected: it has to be rebooked.
+	if sched := run.Spec.Schedule; sched != nil {
+		if run.Status.Phase == RunPhaseFailed {
+			return nil
+		}
+		if c.heldBooking(run) != nil {
+			result = "booked"
+			return nil
+		}
+		if now.Before(sched.StartAfter.Time) {
+			c.bookRun(run, now)
+			result = "booked"
+			return nil
+		}
+	}
+
+	reclaimed, defragged := false, false
 	for {
-		packPlan, err := planPlacement(run, snapshot)
-		if err != nil {
-			planErr, ok := err.(*pack.PlanError)
-			if !ok || planErr.Reason == pack.FailureReasonInvalidRequest {
-				setState(run, v1.RunStateUnschedulable, err.Error())
+		// Each flavor in order of preference: the run is admitted on the first
+		// that both fits and funds now.
+		var attempts []admissionAttempt
+		for _, flavor := range c.admissionFlavors(run) {
+			attempt, err := c.attemptAdmission(run, flavor, ev, inventory, now)
+			if err != nil {
+				result = "error"
+				return err
+			}
+			if attempt.invalid != nil {
+				setState(run, attempt.invalidState, attempt.invalid.Error())
 				result = "waiting"
 				return nil
 			}
-			// R14: a funded admission reclaims opportunistic capacity
-			// before falling back to a reservation. One attempt per pass;
-			// fragmentation (deficit 0 with free GPUs) still reserves.
-			if !reclaimed && c.reclaimForAdmission(run, ev, inventory, snapshot, now) {
-				reclaimed = true
-				usage = computeUsage(c.State.Leases, now)
-				snapshot, err = topology.BuildSnapshotForFlavor(c.State.Nodes, usage, run.Spec.Resources.GPUType)
-				if err != nil {
-					result = "error"
-					return err
+			if attempt.packErr != nil || attempt.coverErr != nil {
+				attempts = append(attempts, attempt)
+				continue
+			}
+
+			// Placeable and fundable: emit unscheduled intent pods for the plugin to
+			// schedule and fund. The adoption path (above) flips the run Running once
+			// the plugin's leases appear — the run stays Pending until then.
+			if run.Flavor() != flavor {
+				// A goodput curve measured on another flavor says nothing here.
+				run.Status.Goodput = nil
+			}
+			run.Status.Flavor = flavor
+			created := c.emitIntentPods(run, *attempt.packPlan)
+			message := fmt.Sprintf("scheduling %d GPUs (awaiting the jobtree scheduler)", attempt.packPlan.TotalGPUs)
+			if flavor != run.Spec.Resources.GPUType {
+				message = fmt.Sprintf("scheduling %d GPUs on alternative flavor %s (awaiting the jobtree scheduler)", attempt.packPlan.TotalGPUs, flavor)
+			}
+			setState(run, v1.RunStateScheduling, message)
+			run.Status.Width = summarizeRunWidth(run, c.State.Leases)
+			run.Status.Funding = summarizeRunFunding(run, ev)
+			// Emit the observable request-for-width event once, when the intent pods
+			// are first created — not on every reconcile of an already-pending run.
+			if created > 0 {
+				c.emit(run, EventTypeNormal, "Scheduling", run.Status.Message)
+			}
+			result = "scheduling"
+			return nil
+		}
+
+		// R14: a funded admission reclaims opportunistic capacity before
+		// falling back to a reservation — on the first flavor whose placement,
+		// not funding, is what failed. One attempt per pass; fragmentation
+		// (deficit 0 with free GPUs) still reserves.
+		if !reclaimed {
+			reclaimed = true
+			freed := false
+			for _, attempt := range attempts {
+				if attempt.packErr != nil && c.reclaimForAdmission(run, attempt.flavor, ev, inventory, attempt.snapshot, now) {
+					freed = true
+					break
 				}
+			}
+			if freed {
 				ev = c.evaluate(now)
 				inventory = cover.NewInventory(ev)
 				continue
 			}
-			request := cover.Request{Owner: ev.OwnerOf(run.Namespace), Flavor: run.Spec.Resources.GPUType, Quantity: run.Spec.Resources.TotalGPUs, Now: now, Admitted: run.CreationTimestamp.Time, RunKey: key, AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow}
-			if run.Spec.Funding != nil {
-				request.Sponsors = append(request.Sponsors, run.Spec.Funding.Sponsors...)
+		}
+		// Fragmentation, not a deficit: before reserving behind scattered small
+		// runs, move the fewest of them that open a domain this run fits and
+		// funds in. Once per pass, like the reclaim.
+		if !defragged {
+			defragged = true
+			moved := false
+			for _, attempt := range attempts {
+				if c.defragForAdmission(run, attempt, inventory, now) {
+					moved = true
+					break
+				}
 			}
-			if err := c.planReservation(run, snapshot, nil, planErr, nil, ev, request, now); err != nil {
-				result = "error"
-				return err
+			if moved {
+				ev = c.evaluate(now)
+				inventory = cover.NewInventory(ev)
+				continue
+			}
+		}
+		if err := c.planReservation(run, attempts, ev, now); err != nil {
+			result = "error"
+			return err
+		}
+		result = "reserved"
+		return nil
+	}
+}
+
+// admissionAttempt is one flavor's admission outcome: a plan that fits and
+// funds, or the pack or cover failure a reservation is forecast from.
+type admissionAttempt struct {
+	flavor   string
+	snapshot *topology.Snapshot
+	packPlan *pack.Plan
+	packErr  *pack.PlanError
+	coverErr *cover.PlanError
+	// request is the cover request the reservation forecasts funding with.
+	request cover.Request
+	// invalid is a failure no reservation can cure, which parks the run in
+	// invalidState.
+	invalid      error
+	invalidState v1.RunState
+}
+
+// admissionFlavors is the order admission tries the run's flavors in. A run
+// whose pods are already out was placed on a flavor; they carry it, so it
+// stays until they are gone.
+func (c *RunController) admissionFlavors(run *v1.Run) []string {
+	if run.Status.Flavor != "" {
+		for i := range c.State.Pods {
+			p := &c.State.Pods[i]
+			if p.Namespace == run.Namespace && p.Labels[binder.LabelRunName] == run.Name && !p.Terminating {
+				return []string{run.Status.Flavor}
 			}
-			result = "reserved"
-			return nil
 		}
+	}
+	return run.Spec.Resources.Flavors()
+}
 
-		location := deriveLocation(packPlan)
-		spareTotal := expectedSpareTotal(run, &packPlan)
-		quantity := run.Spec.Resources.TotalGPUs + spareTotal
-		request := cover.Request{
-			Owner:       ev.OwnerOf(run.Namespace),
-			Flavor:      run.Spec.Resources.GPUType,
-			Quantity:    quantity,
-			Location:    location,
-			Now:         now,
-			Admitted:    run.CreationTimestamp.Time,
-			AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow,
+// attemptAdmission packs and funds the run on one flavor against the current
+// world. It mints and emits nothing.
+func (c *RunController) attemptAdmission(run *v1.Run, flavor string, ev *funding.Evaluation, inventory *cover.Inventory, now time.Time) (admissionAttempt, error) {
+	key := keys.NamespacedKey(run.Namespace, run.Name)
+	snapshot, err := topology.BuildSnapshot(c.State.Nodes, computeUsage(c.State.Leases, now), flavor, c.State.Topology)
+	if err != nil {
+		return admissionAttempt{}, err
+	}
+	attempt := admissionAttempt{flavor: flavor, snapshot: snapshot}
+	packPlan, err := planPlacement(run, snapshot)
+	if err != nil {
+		planErr, ok := err.(*pack.PlanError)
+		if !ok || planErr.Reason == pack.FailureReasonInvalidRequest {
+			attempt.invalid, attempt.invalidState = err, v1.RunStateUnschedulable
+			return attempt, nil
 		}
+		attempt.packErr = planErr
+		attempt.request = cover.Request{Owner: ev.OwnerOf(run.Namespace), Flavor: flavor, Quantity: run.Spec.Resources.TotalGPUs, Now: now, Priority: run.Spec.Priority, Admitted: run.CreationTimestamp.Time, RunKey: key, AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow}
 		if run.Spec.Funding != nil {
-			request.Sponsors = append(request.Sponsors, run.Spec.Funding.Sponsors...)
-			if run.Spec.Funding.MaxBorrowGPUs != nil {
-				remaining := *run.Spec.Funding.MaxBorrowGPUs - borrowedGPUsForRun(ev, run)
-				if remaining < 0 {
-					remaining = 0
-				}
-				request.MaxBorrowGPUs = &remaining
-			}
+			attempt.request.Sponsors = append(attempt.request.Sponsors, run.Spec.Funding.Sponsors...)
 		}
+		return attempt, nil
+	}
+	attempt.packPlan = &packPlan
 
-		// Cover is now a fundability PREDICTION, not a commit: if the run's
-		// width cannot be funded from its family/sponsors, reserve; the plugin
-		// is never handed unfundable pods to gate. The authoritative funding
-		// decision and the mint happen in the scheduler plugin's Permit/PreBind
-		// (borrow-vs-build.md §9) — the controller mints nothing.
-		if _, coverErr := inventory.Plan(request); coverErr != nil {
-			if planErr, ok := coverErr.(*cover.PlanError); ok && planErr.Reason != cover.FailureReasonInvalidRequest {
-				if err := c.planReservation(run, snapshot, &packPlan, nil, planErr, ev, request, now); err != nil {
-					result = "error"
-					return err
-				}
-				result = "reserved"
-				return nil
+	location := deriveLocation(packPlan)
+	spareTotal := expectedSpareTotal(run, &packPlan)
+	quantity := run.Spec.Resources.TotalGPUs + spareTotal
+	request := cover.Request{
+		Owner:       ev.OwnerOf(run.Namespace),
+		Flavor:      flavor,
+		Quantity:    quantity,
+		Location:    location,
+		Now:         now,
+		Priority:    run.Spec.Priority,
+		Admitted:    run.CreationTimestamp.Time,
+		AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow,
+	}
+	if run.Spec.Funding != nil {
+		request.Sponsors = append(request.Sponsors, run.Spec.Funding.Sponsors...)
+		if run.Spec.Funding.MaxBorrowGPUs != nil {
+			remaining := *run.Spec.Funding.MaxBorrowGPUs - borrowedGPUsForRun(ev, run)
+			if remaining < 0 {
+				remaining = 0
 			}
-			setState(run, v1.RunStateUnfunded, coverErr.Error())
-			result = "waiting"
-			return nil
+			request.MaxBorrowGPUs = &remaining
 		}
+	}
+	attempt.request = request
 
-		// Placeable and fundable: emit unscheduled intent pods for the plugin to
-		// schedule and fund. The adoption path (above) flips the run Running once
-		// the plugin's leases appear — the run stays Pending until then.
-		created := c.emitIntentPods(run, packPlan)
-		setState(run, v1.RunStateScheduling, fmt.Sprintf("scheduling %d GPUs (awaiting the jobtree scheduler)", packPlan.TotalGPUs))
-		run.Status.Width = summarizeRunWidth(run, c.State.Leases)
-		run.Status.Funding = summarizeRunFunding(run, ev)
-		// Emit the observable request-for-width event once, when the intent pods
-		// are first created — not on every reconcile of an already-pending run.
-		if created > 0 {
-			c.emit(run, EventTypeNormal, "Scheduling", run.Status.Message)
+	// Cover is now a fundability PREDICTION, not a commit: if the run's
+	// width cannot be funded from its family/sponsors, reserve; the plugin
+	// is never handed unfundable pods to gate. The authoritative funding
+	// decision and the mint happen in the scheduler plugin's Permit/PreBind
+	// (borrow-vs-build.md §9) — the controller mints nothing.
+	if _, coverErr := inventory.Plan(request); coverErr != nil {
+		planErr, ok := coverErr.(*cover.PlanError)
+		if !ok || planErr.Reason == cover.FailureReasonInvalidRequest {
+			attempt.invalid, attempt.invalidState = coverErr, v1.RunStateUnfunded
+			return attempt, nil
 		}
-		result = "scheduling"
-		return nil
+		attempt.coverErr = planErr
 	}
+	return attempt, nil
 }
 
 // reclaimForAdmission clears an admission's physical deficit from unfunded
@@ -427,7 +586,7 @@ func (c *RunController) Reconcile(namespace, name string) error {
 // unfunded pool is judged with the prospective claim ranked in, so family
 // work this admission recalls is reclaimable too. It reports whether
 // anything was reclaimed.
-func (c *RunController) reclaimForAdmission(run *v1.Run, ev *funding.Evaluation, inventory *cover.Inventory, snapshot *topology.Snapshot, now time.Time) bool {
+func (c *RunController) reclaimForAdmission(run *v1.Run, flavor string, ev *funding.Evaluation, inventory *cover.Inventory, snapshot *topology.Snapshot, now time.Time) bool {
 	totalNeeded := int(run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, nil))
 	deficit := computeDeficit(snapshot, nil, totalNeeded)
 	if deficit <= 0 {
@@ -435,9 +594,10 @@ func (c *RunController) reclaimForAdmission(run *v1.Run, ev *funding.Evaluation,
 	}
 	request := cover.Request{
 		Owner:       ev.OwnerOf(run.Namespace),
-		Flavor:      run.Spec.Resources.GPUType,
+		Flavor:      flavor,
 		Quantity:    run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, nil),
 		Now:         now,
+		Priority:    run.Spec.Priority,
 		Admitted:    run.CreationTimestamp.Time,
 		RunKey:      keys.NamespacedKey(run.Namespace, run.Name),
 		AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow,
@@ -458,7 +618,7 @@ func (c *RunController) reclaimForAdmission(run *v1.Run, ev *funding.Evaluation,
 	}
 	resolution, err := resolver.Resolve(resolver.Input{
 		Deficit:      deficit,
-		Flavor:       run.Spec.Resources.GPUType,
+		Flavor:       flavor,
 		SeedSource:   keys.NamespacedKey(run.Namespace, run.Name),
 		Now:          now,
 		Nodes:        c.State.Nodes,
@@ -474,6 +634,62 @@ func (c *RunController) reclaimForAdmission(run *v1.Run, ev *funding.Evaluation,
 	return true
 }
 
+// defragForAdmission opens a fast-fabric domain for a run whose placement
+// failed on topology alone: the fleet has the GPUs, but no single domain does.
+// It moves the fewest small checkpointing runs (pkg/defrag) that leave a
+// domain the run both fits and funds in, and only runs the blocked one
+// outranks or that hold no funded lease. Each moved run's leases close with
+// reason Defrag and it drains to its checkpoint, like a requeued resolver cut;
+// it re-admits elsewhere once its pods are gone, minting its new leases as
+// Defrag too. It reports whether anything moved.
+func (c *RunController) defragForAdmission(run *v1.Run, attempt admissionAttempt, inventory *cover.Inventory, now time.Time) bool {
+	if attempt.packErr == nil || attempt.packErr.Reason != pack.FailureReasonInsufficientTopology {
+		return false
+	}
+	need := int(run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, nil))
+	if attempt.snapshot.TotalFreeGPUs() < need {
+		return false
+	}
+	plan, ok := defrag.Compute(defrag.Input{
+		Run:        run,
+		Need:       need,
+		Snapshot:   attempt.snapshot,
+		Leases:     activeLeasePointers(c.State.Leases),
+		Runs:       c.State.Runs,
+		Evaluation: c.evaluate(now),
+		Place:      planPlacement,
+	})
+	if !ok {
+		return false
+	}
+	// Blocked on topology rather than budget: nothing moves for a run that
+	// could not pay for the domain once it is open.
+	request := attempt.request
+	request.Quantity = run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, &plan.Placement)
+	request.Location = deriveLocation(plan.Placement)
+	if _, err := inventory.Plan(request); err != nil {
+		return false
+	}
+
+	runKey := keys.NamespacedKey(run.Namespace, run.Name)
+	for _, move := range plan.Moves {
+		moved := move.Run
+		deadline := v1.NewTime(now.Add(checkpointGrace(moved)))
+		moved.Status.DrainDeadline = &deadline
+		c.releaseRun(moved, binder.LeaseReasonDefrag, now)
+		setState(moved, v1.RunStateDefragmented, fmt.Sprintf(
+			"moved to open %s for %s; draining to checkpoint until %s, then re-admits from it elsewhere",
+			plan.Domain, runKey, deadline.Time.UTC().Format(time.RFC3339)))
+		moved.Status.PendingReservation = nil
+		moved.Status.EarliestStart = nil
+		moved.Status.Width = summarizeRunWidth(moved, c.State.Leases)
+		c.emit(moved, EventTypeWarning, "Defragmented", moved.Status.Message)
+	}
+	c.emit(run, EventTypeNormal, "Defragmenting", fmt.Sprintf(
+		"moved %d checkpointing run(s) out of %s to open it", len(plan.Moves), plan.Domain))
+	return true
+}
+
 // hypotheticalEvaluation ranks a prospective claim into the derivation by
 // evaluating the current facts plus synthetic open leases paying the plan's
 // envelopes. This is the ranking function's "a new claim may displace the
@@ -510,11 +726,13 @@ func (c *RunController) hypotheticalEvaluation(run *v1.Run, plan cover.Plan, now
 		})
 	}
 	return funding.Evaluate(funding.Input{
-		Budgets: c.State.Budgets,
-		Leases:  leases,
-		Runs:    c.State.Runs,
-		Now:     now,
-		Period:  c.Period,
+		Budgets:   c.State.Budgets,
+		Leases:    leases,
+		Runs:      c.State.Runs,
+		Now:       now,
+		Period:    c.Period,
+		Archives:  c.State.Archives,
+		Transfers: c.State.Transfers,
 	})
 }
 
@@ -565,8 +783,6 @@ func (c *RunController) runGangComplete(run *v1.Run) bool {
 	// Under the Ignore failure policy (R9 9A-3) a terminally Failed active pod is a
 	// terminal member, not a blocker — an embarrassingly-parallel role completes when
 	// every member has finished, succeeded or not.
-	policy, _, _ := failurePolicyFor(run)
-	ignoreFailed := policy == v1.FailurePolicyIgnore
 	sawActive := false
 	for i := range c.State.Pods {
 		pod := &c.State.Pods[i]
@@ -580,8 +796,10 @@ func (c *RunController) runGangComplete(run *v1.Run) bool {
 		if pod.Phase == binder.PodPhaseSucceeded {
 			continue
 		}
-		if ignoreFailed && pod.Phase == binder.PodPhaseFailed {
-			continue
+		if pod.Phase == binder.PodPhaseFailed {
+			if policy, _, _ := failurePolicyFor(run, pod.Labels[binder.LabelRoleName]); policy == v1.FailurePolicyIgnore {
+				continue
+			}
 		}
 		return false
 	}
@@ -606,13 +824,15 @@ func (c *RunController) completeRun(run *v1.Run, now time.Time) {
 	c.emit(run, EventTypeNormal, "Completed", run.Status.Message)
 }
 
-// failurePolicyFor returns a run's role FailurePolicy (default Fail) with its Retries
-// and Backoff (R9 9A-3). A legacy Roles-less run uses the default.
-func failurePolicyFor(run *v1.Run) (policy string, retries int32, backoff time.Duration) {
-	if len(run.Spec.Roles) == 0 {
+// failurePolicyFor returns a role's FailurePolicy (default Fail) with its Retries
+// and Backoff (R9 9A-3). role is the pod's role-name label: a multi-role run keys
+// the policy by it, a single-role run (no label) uses its only role, and a legacy
+// Roles-less run uses the default.
+func failurePolicyFor(run *v1.Run, role string) (policy string, retries int32, backoff time.Duration) {
+	r := runRole(run, role)
+	if r == nil {
 		return v1.FailurePolicyFail, 0, 0
 	}
-	r := &run.Spec.Roles[0]
 	policy = r.FailurePolicy
 	if policy == "" {
 		policy = v1.FailurePolicyFail
@@ -650,11 +870,16 @@ func (c *RunController) firstFailedActivePod(run *v1.Run) *binder.PodManifest {
 // so the completion gate (which counts a Failed pod as terminal under Ignore)
 // finalizes the run; a run with no failed active pod also returns false.
 func (c *RunController) handleWorkloadFailure(run *v1.Run, now time.Time) (handled bool, result string) {
-	failed := c.firstFailedActivePod(run)
+	failed, what, reason := c.firstFailedActivePod(run), "failed", "WorkloadFailed"
+	if failed == nil {
+		// A member that outran its role's attemptTimeout is hung: it is retried
+		// like a crashed one.
+		failed, what, reason = c.overdueAttempt(run, now), "exceeded its attempt timeout", binder.LeaseReasonTimeLimit
+	}
 	if failed == nil {
 		return false, ""
 	}
-	policy, retries, backoff := failurePolicyFor(run)
+	policy, retries, backoff := failurePolicyFor(run, failed.Labels[binder.LabelRoleName])
 	switch policy {
 	case v1.FailurePolicyIgnore:
 		return false, "" // the completion gate treats a Failed member as terminal here
@@ -667,7 +892,7 @@ func (c *RunController) handleWorkloadFailure(run *v1.Run, now time.Time) (handl
 					d := v1.NewTime(now.Add(backoff))
 					run.Status.RetryAfter = &d
 					c.emit(run, EventTypeWarning, "WorkloadRetryScheduled",
-						fmt.Sprintf("active pod %s failed; retrying after %s", failed.Name, backoff))
+						fmt.Sprintf("active pod %s %s; retrying after %s", failed.Name, what, backoff))
 					return true, "retrying"
 				}
 				if now.Before(run.Status.RetryAfter.Time) {
@@ -677,16 +902,20 @@ func (c *RunController) handleWorkloadFailure(run *v1.Run, now time.Time) (handl
 			// Close the failed member's still-open lease (else it charges forever and
 			// the re-emitted member's fresh lease double-counts the rank), drop the
 			// pod, and re-emit the missing member — the plugin re-mints its lease.
-			c.closeMemberLease(run, failed.NodeName, now)
+			c.closeMemberLease(run, failed.NodeName, reason, now)
 			c.dropPod(failed.Namespace, failed.Name)
 			run.Status.FailedAttempts++
 			run.Status.RetryAfter = nil
 			created := c.topUpActiveGang(run)
 			c.emit(run, EventTypeWarning, "WorkloadRetry",
-				fmt.Sprintf("re-emitting failed active pod %s (attempt %d/%d, %d pod(s))", failed.Name, run.Status.FailedAttempts, retries, created))
+				fmt.Sprintf("re-emitting active pod %s, which %s (attempt %d/%d, %d pod(s))", failed.Name, what, run.Status.FailedAttempts, retries, created))
 			return true, "retrying"
 		}
 		// Retries exhausted → fall through to Fail.
+		if reason == binder.LeaseReasonTimeLimit {
+			c.exceedTimeLimit(run, fmt.Sprintf("active pod %s %s with its retries spent", failed.Name, what), now)
+			return true, "failed"
+		}
 	}
 
 	// Fail (default), or Retry exhausted.
@@ -827,9 +1056,16 @@ func (c *RunController) failWorkload(run *v1.Run, msg string, now time.Time) {
 
 // closeMemberLease closes the run's open active lease on node (a failed member's
 // lease) so a Retry re-emit does not leave two open leases for one rank.
-func (c *RunController) closeMemberLease(run *v1.Run, node string, now time.Time) {
+func (c *RunController) closeMemberLease(run *v1.Run, node, reason string, now time.Time) {
+	if l := c.memberLease(run, node); l != nil {
+		CloseLease(l, reason, now)
+	}
+}
+
+// memberLease returns the run's open active lease on node, or nil.
+func (c *RunController) memberLease(run *v1.Run, node string) *v1.GPULease {
 	if node == "" {
-		return
+		return nil
 	}
 	runKey := keys.NamespacedKey(run.Namespace, run.Name)
 	for i := range c.State.Leases {
@@ -842,11 +1078,11 @@ func (c *RunController) closeMemberLease(run *v1.Run, node string, now time.Time
 		}
 		for _, slot := range l.Spec.Slice.Nodes {
 			if nodeFromSlot(slot) == node {
-				CloseLease(l, "WorkloadFailed", now)
-				return
+				return l
 			}
 		}
 	}
+	return nil
 }
 
 // dropPod removes one pod manifest from state so Bridge.apply deletes it.
@@ -872,13 +1108,20 @@ func isPreAdmission(phase string) bool {
 }
 
 // evaluateFollow gates a pre-admission run on its follow dependencies. It
-// returns true when the run may proceed to admission (no deps, or every
-// upstream Completed); otherwise it sets Waiting or Failed and returns false.
-// Existence and cycle detection live here because the webhook has no cluster
-// view.
+// returns true when the run may proceed to admission (no deps, or the follow
+// condition holds); otherwise it sets Waiting, Failed, or Skipped and returns
+// false. Existence and cycle detection live here because the webhook has no
+// cluster view.
+//
+// A Succeeded follow (the default) needs follow.Needed() upstreams Completed; a
+// Failed follow needs every upstream finished and one of them failed; a Done
+// follow needs every upstream finished. A deleted upstream counts as failed,
+// and a skipped one as neither success nor failure: it never ran.
 func (c *RunController) evaluateFollow(run *v1.Run, now time.Time) bool {
-	if run.Spec.Follow == nil || len(run.Spec.Follow.After) == 0 {
+	follow := run.Spec.Follow
+	if follow == nil || len(follow.After) == 0 {
 		run.Status.FollowDeadline = nil
+		run.Status.Upstreams = nil
 		return true
 	}
 	if path, cyclic := c.followCycle(run); cyclic {
@@ -886,14 +1129,16 @@ func (c *RunController) evaluateFollow(run *v1.Run, now time.Time) bool {
 		return false
 	}
 
-	var pending, failed []string
-	for _, name := range run.Spec.Follow.After {
+	var complete, pending, failed, skipped []string
+	for _, name := range follow.After {
 		up, ok := c.State.Runs[keys.NamespacedKey(run.Namespace, name)]
 		switch {
 		case !ok:
 			failed = append(failed, name+" (deleted)")
 		case up.Status.Phase == RunPhaseComplete:
-			// satisfied
+			complete = append(complete, name)
+		case up.Status.Phase == RunPhaseFailed && v1.RunSkipped(&up.Status):
+			skipped = append(skipped, name)
 		case up.Status.Phase == RunPhaseFailed:
 			failed = append(failed, name)
 		default:
@@ -901,32 +1146,73 @@ func (c *RunController) evaluateFollow(run *v1.Run, now time.Time) bool {
 		}
 	}
 
-	if len(failed) > 0 {
-		if run.Spec.Follow.OnUpstreamFailure == v1.OnUpstreamFailureFail {
-			c.failRun(run, v1.RunStateUpstreamFailed, "upstream failed: "+strings.Join(failed, ", "))
+	switch follow.When {
+	case v1.FollowWhenFailed, v1.FollowWhenDone:
+		if len(pending) > 0 {
+			run.Status.FollowDeadline = nil
+			c.setWaiting(run, "waiting for: "+strings.Join(pending, ", "))
 			return false
 		}
-		// "wait" (default): give the researcher a grace window to fix and
-		// resubmit the failed stage, then fail so it is not a silent zombie.
-		if run.Status.FollowDeadline == nil {
-			deadline := v1.NewTime(now.Add(followGrace(run.Spec.Follow)))
-			run.Status.FollowDeadline = &deadline
-		}
-		if !now.Before(run.Status.FollowDeadline.Time) {
-			c.failRun(run, v1.RunStateUpstreamFailed, "upstream failed and grace expired: "+strings.Join(failed, ", "))
+		if follow.When == v1.FollowWhenFailed && len(failed) == 0 {
+			c.skipRun(run, "skipped: no upstream failed")
 			return false
 		}
-		c.setWaiting(run, fmt.Sprintf("waiting: upstream failed (%s); fails at %s if unresolved",
-			strings.Join(failed, ", "), run.Status.FollowDeadline.Time.UTC().Format(time.RFC3339)))
-		return false
+		c.openFollow(run)
+		return true
 	}
-	if len(pending) > 0 {
+
+	needed := follow.Needed()
+	if len(complete) >= needed {
+		c.openFollow(run)
+		return true
+	}
+	if len(complete)+len(pending) >= needed {
+		// Enough upstreams may still succeed: a failure the subset can absorb
+		// is not this run's problem yet.
 		run.Status.FollowDeadline = nil
 		c.setWaiting(run, "waiting for: "+strings.Join(pending, ", "))
 		return false
 	}
+	if len(failed) == 0 {
+		c.skipRun(run, "skipped: upstream skipped: "+strings.Join(skipped, ", "))
+		return false
+	}
+	if follow.OnUpstreamFailure == v1.OnUpstreamFailureFail {
+		c.failRun(run, v1.RunStateUpstreamFailed, "upstream failed: "+strings.Join(failed, ", "))
+		return false
+	}
+	// "wait" (default): give the researcher a grace window to fix and
+	// resubmit the failed stage, then fail so it is not a silent zombie.
+	if run.Status.FollowDeadline == nil {
+		deadline := v1.NewTime(now.Add(followGrace(follow)))
+		run.Status.FollowDeadline = &deadline
+	}
+	if !now.Before(run.Status.FollowDeadline.Time) {
+		c.failRun(run, v1.RunStateUpstreamFailed, "upstream failed and grace expired: "+strings.Join(failed, ", "))
+		return false
+	}
+	c.setWaiting(run, fmt.Sprintf("waiting: upstream failed (%s); fails at %s if unresolved",
+		strings.Join(failed, ", "), run.Status.FollowDeadline.Time.UTC().Format(time.RFC3339)))
+	return false
+}
+
+// openFollow records, as the gate opens, how each upstream finished and where
+// its outputs are, so the run's pods can be handed them.
+func (c *RunController) openFollow(run *v1.Run) {
 	run.Status.FollowDeadline = nil
-	return true
+	upstreams := make([]v1.RunUpstream, 0, len(run.Spec.Follow.After))
+	for _, name := range run.Spec.Follow.After {
+		entry := v1.RunUpstream{Name: name}
+		if up, ok := c.State.Runs[keys.NamespacedKey(run.Namespace, name)]; ok {
+			entry.Phase = up.Status.Phase
+			if entry.Phase == RunPhaseFailed && v1.RunSkipped(&up.Status) {
+				entry.Phase = v1.PipelineStageSkipped
+			}
+			entry.Artifacts = artifacts.URI(up)
+		}
+		upstreams = append(upstreams, entry)
+	}
+	run.Status.Upstreams = upstreams
 }
 
 // followGrace is the run's configured wait window on a failed upstream, or the
@@ -952,38 +1238,19 @@ func checkpointGrace(run *v1.Run) time.Duration {
 // followCycle reports whether the run's transitive follow closure contains a
 // cycle (which would deadlock it), returning a readable path. Same-namespace.
 func (c *RunController) followCycle(start *v1.Run) (string, bool) {
-	inStack := make(map[string]bool)
-	done := make(map[string]bool)
-	var path []string
-	var dfs func(r *v1.Run) (string, bool)
-	dfs = func(r *v1.Run) (string, bool) {
-		key := keys.NamespacedKey(r.Namespace, r.Name)
-		inStack[key] = true
-		path = append(path, r.Name)
-		if r.Spec.Follow != nil {
-			for _, name := range r.Spec.Follow.After {
-				up, ok := c.State.Runs[keys.NamespacedKey(r.Namespace, name)]
-				if !ok {
-					continue
-				}
-				upKey := keys.NamespacedKey(up.Namespace, up.Name)
-				if inStack[upKey] {
-					return strings.Join(append(path, up.Name), " -> "), true
-				}
-				if done[upKey] {
-					continue
-				}
-				if p, cyclic := dfs(up); cyclic {
-					return p, true
-				}
+	return pipeline.Cycle(start.Name, func(name string) []string {
+		r := start
+		if name != start.Name {
+			var ok bool
+			if r, ok = c.State.Runs[keys.NamespacedKey(start.Namespace, name)]; !ok {
+				return nil
 			}
 		}
-		inStack[key] = false
-		done[key] = true
-		path = path[:len(path)-1]
-		return "", false
-	}
-	return dfs(start)
+		if r.Spec.Follow == nil {
+			return nil
+		}
+		return r.Spec.Follow.After
+	})
 }
 
 // setWaiting parks a run on its follow dependencies (not admitted, no
@@ -1003,6 +1270,18 @@ func (c *RunController) setWaiting(run *v1.Run, msg string) {
 // comment. Enforce it instead: a failed run holds no open leases, or its budget
 // pays for GPUs nobody is using until someone deletes the Run object.
 func (c *RunController) failRun(run *v1.Run, state v1.RunState, msg string) {
+	c.endPreAdmission(run, state, msg)
+	c.emit(run, EventTypeWarning, "Failed", msg)
+}
+
+// skipRun ends a run whose follow condition can no longer hold. It is terminal
+// like a failure, but nothing went wrong, so the event is Normal.
+func (c *RunController) skipRun(run *v1.Run, msg string) {
+	c.endPreAdmission(run, v1.RunStateFollowSkipped, msg)
+	c.emit(run, EventTypeNormal, "Skipped", msg)
+}
+
+func (c *RunController) endPreAdmission(run *v1.Run, state v1.RunState, msg string) {
 	setState(run, state, msg)
 	run.Status.PendingReservation = nil
 	run.Status.EarliestStart = nil
@@ -1012,7 +1291,6 @@ func (c *RunController) failRun(run *v1.Run, state v1.RunState, msg string) {
 		c.emit(run, EventTypeWarning, "LeasesReleased", fmt.Sprintf(
 			"released %d open lease(s) still held by the failed run", closed))
 	}
-	c.emit(run, EventTypeWarning, "Failed", msg)
 }
 
 // releasePendingReservations marks every Pending reservation for the run as
@@ -1060,10 +1338,14 @@ func (c *RunController) releasePendingReservations(run *v1.Run, now time.Time) {
 	run.Status.CheckpointDeadline = nil
 }
 
-// ActivateReservations attempts to start any due reservations in sorted key
-// order, invoking the resolver if capacity deficits remain. A reservation
-// that fails to activate is recorded on its status and does not block later
-// reservations; the collected errors are returned as an aggregate.
+// ActivateReservations attempts to start any due reservations in the order
+// the funding evaluation ranks their runs' claims (funding.WaitingLess), so
+// the run kubectl-runs queue shows first in line is the one that starts
+// first; it invokes the resolver if capacity deficits remain. A booking not yet
+// due but inside its drain lead reclaims unfunded work from its slice instead
+// (drainAheadOfBooking). A reservation that fails to activate is recorded on
+// its status and does not block later reservations; the collected errors are
+// returned as an aggregate.
 func (c *RunController) ActivateReservations(now time.Time) error {
 	before := c.snapshotWorld()
 	defer c.checkInvariants("RunController.ActivateReservations", before)
@@ -1073,6 +1355,7 @@ func (c *RunController) ActivateReservations(now time.Time) error {
 		dueKeys = append(dueKeys, key)
 	}
 	sort.Strings(dueKeys)
+	c.rankReservations(dueKeys)
 
 	var errs []error
 	for _, key := range dueKeys {
@@ -1102,6 +1385,11 @@ func (c *RunController) ActivateReservations(now time.Time) error {
 			// frozen at whatever value it had when the reservation was
 			// created (audit finding #21).
 			c.refreshReservationBacklog(key, reservation, now)
+			if b := reservation.Spec.Booking; b != nil && !now.Before(b.DrainFrom.Time) {
+				if err := c.drainAheadOfBooking(reservation, now); err != nil {
+					errs = append(errs, fmt.Errorf("reservation %s: %w", key, err))
+				}
+			}
 			continue
 		}
 		if err := c.activateReservation(key, reservation, now); err != nil {
@@ -1112,6 +1400,35 @@ func (c *RunController) ActivateReservations(now time.Time) error {
 	return errors.Join(errs...)
 }
 
+// rankReservations orders reservation keys by funding.WaitingLess: each run
+// on the envelope its reservation pays from, a vanished run as a bare key.
+func (c *RunController) rankReservations(resKeys []string) {
+	type waiting struct {
+		env *v1.BudgetEnvelope
+		run *v1.Run
+	}
+	ranked := make(map[string]waiting, len(resKeys))
+	owners := map[string]string{}
+	for _, key := range resKeys {
+		res := c.State.Reservations[key]
+		ref := res.Spec.RunRef
+		run := c.State.Runs[keys.NamespacedKey(ref.Namespace, ref.Name)]
+		if run == nil {
+			run = &v1.Run{ObjectMeta: metav1.ObjectMeta{Namespace: ref.Namespace, Name: ref.Name}}
+		}
+		owner, ok := owners[run.Namespace]
+		if !ok {
+			owner = funding.OwnerOfNamespace(c.State.Budgets, run.Namespace)
+			owners[run.Namespace] = owner
+		}
+		ranked[key] = waiting{env: funding.OwnEnvelope(c.State.Budgets, owner, res.Spec.PayingEnvelope), run: run}
+	}
+	sort.SliceStable(resKeys, func(i, j int) bool {
+		a, b := ranked[resKeys[i]], ranked[resKeys[j]]
+		return funding.WaitingLess(a.env, a.run, b.env, b.run)
+	})
+}
+
 // refreshReservationBacklog recomputes a still-pending reservation's backlog
 // gauge from its EarliestStart against the current clock, so the value
 // tracks the shrinking countdown instead of freezing at the value it had
@@ -1126,7 +1443,16 @@ func (c *RunController) refreshReservationBacklog(key string, reservation *v1.Re
 	if delta < 0 {
 		delta = 0
 	}
-	metrics.SetReservationBacklog(key, run.Spec.Resources.GPUType, delta)
+	metrics.SetReservationBacklog(key, reservationFlavor(reservation, run), delta)
+}
+
+// reservationFlavor is the flavor a reservation holds its run a place on.
+// One written before runs had alternatives names none: the run's gpuType.
+func reservationFlavor(reservation *v1.Reservation, run *v1.Run) string {
+	if reservation.Spec.Flavor != "" {
+		return reservation.Spec.Flavor
+	}
+	return run.Spec.Resources.GPUType
 }
 
 func (c *RunController) activateReservation(key string, reservation *v1.Reservation, now time.Time) error {
@@ -1224,8 +1550,9 @@ func (c *RunController) activateReservation(key string, reservation *v1.Reservat
 		return nil
 	}
 
+	flavor := reservationFlavor(reservation, run)
 	usage := computeUsage(c.State.Leases, now)
-	snapshot, err := topology.BuildSnapshotForFlavor(c.State.Nodes, usage, run.Spec.Resources.GPUType)
+	snapshot, err := topology.BuildSnapshot(c.State.Nodes, usage, flavor, c.State.Topology)
 	if err != nil {
 		return err
 	}
@@ -1295,10 +1622,11 @@ func (c *RunController) activateReservation(key string, reservation *v1.Reservat
 	location := reservation.Spec.IntendedSlice.Domain
 	request := cover.Request{
 		Owner:       ev.OwnerOf(run.Namespace),
-		Flavor:      run.Spec.Resources.GPUType,
+		Flavor:      flavor,
 		Quantity:    run.Spec.Resources.TotalGPUs,
 		Location:    location,
 		Now:         now,
+		Priority:    run.Spec.Priority,
 		Admitted:    run.CreationTimestamp.Time,
 		RunKey:      keys.NamespacedKey(run.Namespace, run.Name),
 		AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow,
@@ -1381,7 +1709,7 @@ func (c *RunController) activateReservation(key string, reservation *v1.Reservat
 			leases := activeLeasePointers(c.State.Leases)
 			resInput := resolver.Input{
 				Deficit:    deficit,
-				Flavor:     run.Spec.Resources.GPUType,
+				Flavor:     flavor,
 				Scope:      scope,
 				SeedSource: reservation.Name,
 				Now:        now,
@@ -1398,7 +1726,7 @@ func (c *RunController) activateReservation(key string, reservation *v1.Reservat
 
 			// rebuild the world after resolution
 			usage = computeUsage(c.State.Leases, now)
-			snapshot, err = topology.BuildSnapshotForFlavor(c.State.Nodes, usage, run.Spec.Resources.GPUType)
+			snapshot, err = topology.BuildSnapshot(c.State.Nodes, usage, flavor, c.State.Topology)
 			if err != nil {
 				return err
 			}
@@ -1443,6 +1771,11 @@ func (c *RunController) activateReservation(key string, reservation *v1.Reservat
 		}
 	}
 
+	// The activation places the run on the reservation's flavor.
+	if run.Flavor() != flavor {
+		run.Status.Goodput = nil
+	}
+	run.Status.Flavor = flavor
 	activated := v1.NewTime(now)
 	if opportunistic {
 		// Promised-but-unfunded start: the plugin's Permit funding gate would
@@ -1494,6 +1827,18 @@ func (c *RunController) activateReservation(key string, reservation *v1.Reservat
 	return nil
 }
 
+// runIsDraining reports whether any of the run's pods is still under graceful
+// deletion.
+func (c *RunController) runIsDraining(run *v1.Run) bool {
+	for i := range c.State.Pods {
+		p := &c.State.Pods[i]
+		if p.Terminating && p.Namespace == run.Namespace && p.Labels[binder.LabelRunName] == run.Name {
+			return true
+		}
+	}
+	return false
+}
+
 // runHasActivePods reports whether the run already has unscheduled Active intent
 // pods emitted (funded reservation activation is idempotent per tick).
 func runHasActivePods(pods []binder.PodManifest, run *v1.Run) bool {
@@ -1531,6 +1876,7 @@ func runHasPromisePods(pods []binder.PodManifest, run *v1.Run) bool {
 // attribute the work to and nothing to re-fund from, so the caller must not
 // admit — the reservation fails terminally instead.
 func (c *RunController) opportunisticCoverPlan(run *v1.Run, reservation *v1.Reservation, ev *funding.Evaluation, quantity int32) (cover.Plan, bool) {
+	flavor := reservationFlavor(reservation, run)
 	owner := ev.OwnerOf(run.Namespace)
 	segment := cover.Segment{Owner: owner, Quantity: quantity}
 	found := false
@@ -1548,7 +1894,7 @@ func (c *RunController) opportunisticCoverPlan(run *v1.Run, reservation *v1.Rese
 			found = true
 			break
 		}
-		if !found && acct.Spec.Flavor == run.Spec.Resources.GPUType {
+		if !found && acct.Spec.Flavor == flavor {
 			segment.Namespace = acct.Key.Namespace
 			segment.BudgetName = acct.Key.Budget
 			segment.EnvelopeName = acct.Key.Envelope
@@ -1675,14 +2021,54 @@ var ErrNoLeaseOnNode = errors.New("jobtree: no lease found on node")
 //
 // The CALLER decides what "failed" means, and that is not incidental: ClusterState
 // carries topology.SourceNode{Name, Labels, GPUs}, and the bridge already drops
-// unusable nodes when it loads (nodeUsable, bridge.go:178), so the engine cannot
-// tell a cordoned node from a dead one. controllers/kube.nodeFailed makes that
+// unusable nodes when it loads (topology.NodeUsable), so the engine cannot
+// tell a cordoned node from a dead one. topology.NodeFailed makes that
 // judgement against the real corev1.Node. A bare `kubectl cordon` is not a failure
 // (R21) — acting on one starts a second copy of a rank that is still running.
 func (c *RunController) HandleNodeFailure(nodeName string, now time.Time) error {
+	return c.handleNodeLoss(nodeLoss{node: nodeName, reason: "NodeFailure"}, now)
+}
+
+// HandleImpendingNodeLoss swaps the node's active slices onto held spares ahead of
+// a planned termination (spot reclaim, drain, maintenance) due at deadline, while
+// the node is still up. The closed leases record the notice's lead time in their
+// closure reason, e.g. "ImpendingNodeLoss(lead=1m30s)".
+//
+// It is HandleNodeFailure restricted to swaps. A group with no usable spare keeps
+// running on the node until it is actually lost — failing it on the notice would
+// only throw away the time the notice left it — and a spare on the node is left to
+// die with it. Both are handled by HandleNodeFailure when the fencing assertion
+// arrives. The caller guarantees the node's kubelet is reachable: the replaced
+// rank's pod is deleted gracefully, and only a live kubelet stops it.
+func (c *RunController) HandleImpendingNodeLoss(nodeName string, deadline, now time.Time) error {
+	if now.IsZero() {
+		now = c.Clock.Now()
+	}
+	lead := deadline.Sub(now)
+	if lead < 0 {
+		lead = 0
+	}
+	return c.handleNodeLoss(nodeLoss{
+		node:      nodeName,
+		reason:    fmt.Sprintf("ImpendingNodeLoss(lead=%s)", lead.Round(time.Second)),
+		impending: true,
+	}, now)
+}
+
+// nodeLoss is why handleNodeLoss is closing a node's leases.
+type nodeLoss struct {
+	node string
+	// reason is the closure reason stamped on the leases the loss closes.
+	reason string
+	// impending is a planned termination announced ahead of time: swap only.
+	impending bool
+}
+
+func (c *RunController) handleNodeLoss(loss nodeLoss, now time.Time) error {
 	if now.IsZero() {
 		now = c.Clock.Now()
 	}
+	nodeName := loss.node
 
 	// Deferred, so the oracle sees the state only on RETURN. Mid-method this
 	// function legitimately violates INV-TERMINAL-PRESENT: it marks a run Failed
@@ -1707,13 +2093,13 @@ func (c *RunController) HandleNodeFailure(nodeName string, now time.Time) error
 	// lease pointed at a node that no longer exists.
 	for i := range c.State.Leases {
 		lease := &c.State.Leases[i]
-		if lease.Status.Closed || lease.Spec.Slice.Role != binder.RoleSpare {
+		if loss.impending || lease.Status.Closed || lease.Spec.Slice.Role != binder.RoleSpare {
 			continue
 		}
 		if !leaseContainsNode(lease, nodeName) {
 			continue
 		}
-		CloseLease(lease, "NodeFailure", now)
+		CloseLease(lease, loss.reason, now)
 		handled = true
 		// A run whose only stake on the node was a held spare loses its fault
 		// tolerance here, silently, without any change to its phase. Say so.
@@ -1772,13 +2158,21 @@ func (c *RunController) HandleNodeFailure(nodeName string, now time.Time) error
 		run := c.State.Runs[runKey]
 		groupIndex := leaseGroupIndex(lease)
 		if run == nil {
-			CloseLease(lease, "NodeFailure", now)
+			// An orphaned slice is the auditor's; a notice is no reason to touch it.
+			if !loss.impending {
+				CloseLease(lease, loss.reason, now)
+			}
 			continue
 		}
 
 		spareLease, spareIdx := findSpareLease(c.State.Leases, runKey, groupIndex)
+		if loss.impending && (spareLease == nil || leaseContainsNode(spareLease, nodeName)) {
+			// Nothing to move onto — a spare on the doomed node is no refuge. The
+			// group runs out its notice; the fencing assertion handles it.
+			continue
+		}
 		if spareLease == nil {
-			c.failGroupWithoutSpare(run, runKey, lease, nil, nodeName, now, phases)
+			c.failGroupWithoutSpare(run, runKey, lease, nil, loss, now, phases)
 			continue
 		}
 
@@ -1873,18 +2267,23 @@ func (c *RunController) HandleNodeFailure(nodeName string, now time.Time) error
 				"spare slots for group %s are held by funded run %s; declining the swap rather than evicting it",
 				groupIndex, otherKey))
 		}
+		if crossRunConflict && loss.impending {
+			// The group still runs on the node and still holds its spare; the
+			// declined swap is re-decided when the node is actually lost.
+			continue
+		}
 		if crossRunConflict {
 			// Pass the spare so it is RELEASED, not stranded. Declining the swap
 			// while leaving the spare's lease open charged the run's budget for GPUs
 			// it could never use, forever: nothing downstream closes a terminal
 			// run's leases.
-			c.failGroupWithoutSpare(run, runKey, lease, spareLease, nodeName, now, phases)
+			c.failGroupWithoutSpare(run, runKey, lease, spareLease, loss, now, phases)
 			continue
 		}
 
 		spareNodes := leaseNodeNames(spareLease)
 		CloseLease(spareLease, "Swap", now)
-		CloseLease(lease, "NodeFailure", now)
+		CloseLease(lease, loss.reason, now)
 		// Free the held spare's pod on the reclaimed node so the bridge deletes it
 		// and the swap pod (which hard-targets that node) can bind there.
 		c.removeSparePodOnNodes(run, leaseGroupIndex(spareLease), spareNodes)
@@ -1896,10 +2295,15 @@ func (c *RunController) HandleNodeFailure(nodeName string, now time.Time) error
 		// node) is passed to find the member being replaced.
 		c.emitSwapPod(run, groupIndex, spareLease, nodeName, now)
 		msg := fmt.Sprintf("group %s swapping to spare after node %s failure", groupIndex, nodeName)
+		reason := "NodeFailureSwap"
+		if loss.impending {
+			msg = fmt.Sprintf("group %s swapping to spare ahead of node %s loss (%s)", groupIndex, nodeName, loss.reason)
+			reason = "ImpendingLossSwap"
+		}
 		// Running is the mildest outcome: it must not overwrite a sibling group's
 		// Failed or Pending, whichever order the leases happen to be in.
 		phases.apply(run, runKey, v1.RunStateGangBound, msg)
-		c.emit(run, EventTypeNormal, "NodeFailureSwap", msg)
+		c.emit(run, EventTypeNormal, reason, msg)
 	}
 
 	// A run this call drove to Failed is dead as a gang: its surviving slices on
@@ -1965,8 +2369,9 @@ func (t runPhaseTracker) apply(run *v1.Run, runKey string, state v1.RunState, ms
 // spareLease is the spare this group holds and will NOT be using — nil on the
 // no-spare path, non-nil when the swap was declined because another funded run
 // holds the spare's exact slots.
-func (c *RunController) failGroupWithoutSpare(run *v1.Run, runKey string, lease, spareLease *v1.GPULease, nodeName string, now time.Time, phases runPhaseTracker) {
-	CloseLease(lease, "NodeFailure", now)
+func (c *RunController) failGroupWithoutSpare(run *v1.Run, runKey string, lease, spareLease *v1.GPULease, loss nodeLoss, now time.Time, phases runPhaseTracker) {
+	nodeName := loss.node
+	CloseLease(lease, loss.reason, now)
 
 	// The group is not runnable, so the spare it was holding can never cover it.
 	// Leaving that lease open charges the run's budget for GPUs it will never use
@@ -2195,10 +2600,13 @@ func (c *RunController) removeAllRunPods(run *v1.Run) {
 // caller may forget, it is inside the only function that closes a terminal run's
 // leases.
 //
-// CALL IT ONLY ON A TERMINAL RUN. The checkpoint-grace window is a deliberate,
-// bounded half-plane state: failGroupWithoutSpare parks the run Pending with a
-// CheckpointDeadline and leaves its containers running SO THEY CAN WRITE A
-// CHECKPOINT. It closes the dead group's lease and calls nothing here.
+// CALL IT ONLY ON A TERMINAL RUN, or on one requeued whole — a checkpointed run
+// the resolver cut or the defragmenter moved, which re-admits from scratch and
+// must not keep half a gang open while it waits. The checkpoint-grace window is
+// a deliberate, bounded half-plane state: failGroupWithoutSpare parks the run
+// Pending with a CheckpointDeadline and leaves its containers running SO THEY
+// CAN WRITE A CHECKPOINT. It closes the dead group's lease and calls nothing
+// here.
 func (c *RunController) releaseRun(run *v1.Run, reason string, now time.Time) int {
 	runKey := keys.NamespacedKey(run.Namespace, run.Name)
 	closed := 0
@@ -2222,34 +2630,43 @@ func ptrString(value string) *string { return &value }
 
 func ptrInt64(value int64) *int64 { return &value }
 
-func (c *RunController) planReservation(run *v1.Run, snapshot *topology.Snapshot, packPlan *pack.Plan, packErr *pack.PlanError, coverErr *cover.PlanError, ev *funding.Evaluation, request cover.Request, now time.Time) error {
+// planReservation reserves the run a place on whichever of its flavors, each
+// described by its failed admission attempt, forecasts the earliest start.
+func (c *RunController) planReservation(run *v1.Run, attempts []admissionAttempt, ev *funding.Evaluation, now time.Time) error {
 	if ev == nil {
 		ev = c.evaluate(now)
 	}
 
-	var planPtr *pack.Plan
-	if packPlan != nil {
-		copy := *packPlan
-		planPtr = &copy
+	inputs := make([]forecast.Input, 0, len(attempts))
+	for _, attempt := range attempts {
+		var planPtr *pack.Plan
+		if attempt.packPlan != nil {
+			copy := *attempt.packPlan
+			planPtr = &copy
+		}
+		request := attempt.request
+		request.Quantity = run.Spec.Resources.TotalGPUs + expectedSpareTotal(run, planPtr)
+		inputs = append(inputs, forecast.Input{
+			Flavor:       attempt.flavor,
+			Snapshot:     attempt.snapshot,
+			PackPlan:     planPtr,
+			PackErr:      attempt.packErr,
+			CoverErr:     attempt.coverErr,
+			CoverRequest: request,
+		})
 	}
-	spareTotal := expectedSpareTotal(run, planPtr)
-	request.Quantity = run.Spec.Resources.TotalGPUs + spareTotal
+	if len(inputs) == 0 {
+		return fmt.Errorf("run %s/%s: no admission attempt to reserve from", run.Namespace, run.Name)
+	}
+	in := inputs[0]
+	in.Run, in.Now, in.Evaluation, in.Runs = run, now, ev, c.State.Runs
+	in.Alternatives = inputs[1:]
 	forecastStart := time.Now()
-	forecastResult, err := forecast.Plan(forecast.Input{
-		Run:          run,
-		Now:          now,
-		Snapshot:     snapshot,
-		PackPlan:     planPtr,
-		PackErr:      packErr,
-		CoverErr:     coverErr,
-		CoverRequest: request,
-		Evaluation:   ev,
-		Runs:         c.State.Runs,
-	})
+	forecastResult, err := forecast.Plan(in)
 	// forecast.Plan is an inline library call made from this reconcile path,
 	// not a separate "forecast controller" (audit finding #24) — the metric
 	// is observed at the one call site that exists.
-	metrics.ObserveForecastLatency(run.Spec.Resources.GPUType, time.Since(forecastStart))
+	metrics.ObserveForecastLatency(in.Flavor, time.Since(forecastStart))
 	if err != nil {
 		setState(run, v1.RunStateUnschedulable, fmt.Sprintf("reservation planning failed: %v", err))
 		return nil
@@ -2287,6 +2704,7 @@ func (c *RunController) planReservation(run *v1.Run, snapshot *topology.Snapshot
 			IntendedSlice:  forecastResult.IntendedSlice,
 			PayingEnvelope: forecastResult.PayingEnvelope,
 			EarliestStart:  earliest,
+			Flavor:         forecastResult.Flavor,
 		},
 		Status: status,
 	}
@@ -2312,9 +2730,13 @@ func (c *RunController) planReservation(run *v1.Run, snapshot *topology.Snapshot
 		if delta < 0 {
 			delta = 0
 		}
-		metrics.SetReservationBacklog(key, run.Spec.Resources.GPUType, delta)
+		metrics.SetReservationBacklog(key, forecastResult.Flavor, delta)
 	}
 
+	if run.Flavor() != forecastResult.Flavor {
+		run.Status.Goodput = nil
+	}
+	run.Status.Flavor = forecastResult.Flavor
 	setState(run, v1.RunStateReserved, fmt.Sprintf("reservation %s scheduled for %s (deficit %d GPUs)", reservationName, forecastResult.EarliestStart.Format(time.RFC3339), forecastResult.Forecast.DeficitGPUs))
 	run.Status.PendingReservation = ptrString(reservationName)
 	run.Status.EarliestStart = &earliest
@@ -2381,6 +2803,15 @@ func (c *RunController) applyResolution(result resolver.Result, now time.Time) {
 		if run == nil {
 			continue
 		}
+		// A run that checkpoints is cut gracefully: every pod this pass drops is
+		// deleted with the checkpoint window as its grace (the bridge reads the
+		// deadline), so its containers get SIGTERM and their preStop hooks rather
+		// than losing everything since the last checkpoint.
+		grace := checkpointGrace(run)
+		if grace > 0 {
+			deadline := v1.NewTime(now.Add(grace))
+			run.Status.DrainDeadline = &deadline
+		}
 		// The old test here was `activeGPUsForRun(...) > 0`, which said a fixed-width
 		// gang missing half its groups is "Running". It is not: "start together or
 		// not at all". The lottery cuts group-by-group, so a deficit smaller than a
@@ -2412,7 +2843,12 @@ func (c *RunController) applyResolution(result resolver.Result, now time.Time) {
 			setState(run, v1.RunStateShrunk, "shrunk by resolver")
 			c.emit(run, EventTypeWarning, "ResolverShrink", run.Status.Message)
 		case reclaimedOnly[runKey]:
-			setState(run, v1.RunStateReclaimed, "reclaimed by funded demand; will re-admit when quota allows")
+			msg := "reclaimed by funded demand; will re-admit when quota allows"
+			if grace > 0 {
+				msg = fmt.Sprintf("reclaimed by funded demand; draining to checkpoint until %s, then re-admits from it when quota allows",
+					run.Status.DrainDeadline.Time.UTC().Format(time.RFC3339))
+			}
+			setState(run, v1.RunStateReclaimed, msg)
 			run.Status.PendingReservation = nil
 			run.Status.EarliestStart = nil
 			c.emit(run, EventTypeWarning, "ResolverReclaimed", run.Status.Message)
@@ -2429,6 +2865,23 @@ func (c *RunController) applyResolution(result resolver.Result, now time.Time) {
 			// passes (run_controller.go:167).
 			setState(run, v1.RunStateCheckpointGrace, "resolver cut during checkpoint grace; holding containers until the deadline")
 			c.emit(run, EventTypeWarning, "ResolverGraceHeld", run.Status.Message)
+		case grace > 0:
+			// A checkpointed run is requeued, not ended: the whole gang drains to
+			// its checkpoint and re-admits from it when capacity returns. Its rank
+			// is its CreationTimestamp — what every cover request and the funding
+			// replay order claims by — so the requeue keeps its place in line.
+			// Whatever the cut left open is released with it: a partial gang
+			// charges its budget for ranks that cannot make progress.
+			if closed := c.releaseRun(run, "Requeued", now); closed > 0 {
+				c.emit(run, EventTypeWarning, "LeasesReleased", fmt.Sprintf(
+					"released %d open lease(s) of the requeued run", closed))
+			}
+			setState(run, v1.RunStateReclaimed, fmt.Sprintf(
+				"cut by resolver; draining to checkpoint until %s, then re-admits from it when capacity returns",
+				run.Status.DrainDeadline.Time.UTC().Format(time.RFC3339)))
+			run.Status.PendingReservation = nil
+			run.Status.EarliestStart = nil
+			c.emit(run, EventTypeWarning, "ResolverRequeued", run.Status.Message)
 		default:
 			setState(run, v1.RunStateEndedByResolver, "ended by resolver")
 			// A terminal run holds no open lease. This was the one terminal-failing
@@ -2507,13 +2960,16 @@ func computeUsage(leases []v1.GPULease, now time.Time) map[string]int {
 			if idx := strings.IndexRune(id, '#'); idx >= 0 {
 				node = id[:idx]
 			}
-			usage[node]++
+			usage[topology.UsageKey(node, lease.Spec.Slice.Profile)]++
 		}
 	}
 	return usage
 }
 
 func planPlacement(run *v1.Run, snapshot *topology.Snapshot) (pack.Plan, error) {
+	if len(run.Spec.Roles) > 1 {
+		return pack.PlanGang(snapshot, snapshot.Flavor, admission.RolePackRequests(run))
+	}
 	allowSpread := run.Spec.AllowCrossGroupSpread()
 	var groupSize *int
 	if run.Spec.Locality != nil && run.Spec.Locality.GroupGPUs != nil {
@@ -2525,12 +2981,15 @@ func planPlacement(run *v1.Run, snapshot *topology.Snapshot) (pack.Plan, error)
 		spares = int(*run.Spec.Spares)
 	}
 	req := pack.Request{
-		Flavor:                run.Spec.Resources.GPUType,
+		Flavor:                snapshot.Flavor,
 		TotalGPUs:             int(run.Spec.Resources.TotalGPUs),
 		GroupGPUs:             groupSize,
 		AllowCrossGroupSpread: allowSpread,
 		SparesPerGroup:        spares,
 	}
+	if run.Spec.Locality != nil {
+		req.GroupWithin, req.RunWithin = run.Spec.Locality.GroupWithin, run.Spec.Locality.RunWithin
+	}
 	return pack.Planner(snapshot, req)
 }
 
@@ -2573,9 +3032,18 @@ func expectedSpareTotal(run *v1.Run, plan *pack.Plan) int32 {
 // renders them as soft nodeAffinity, never a nodeName pin). Idempotent: it only
 // tops up the pods that do not yet exist.
 func (c *RunController) emitIntentPods(run *v1.Run, packPlan pack.Plan) int {
+	reason := "Start"
+	if v1.RunDefragmented(&run.Status) {
+		// Re-admitting from the checkpoint it drained to when the defragmenter
+		// moved it: the new leases say why they exist.
+		reason = binder.LeaseReasonDefrag
+	}
+	if len(run.Spec.Roles) > 1 {
+		return c.emitRolePods(run, &packPlan, reason, nil)
+	}
 	gpusPerPod, width := intentPodShape(run)
-	created := c.emitCohortPods(run, packPlacements(packPlan, gpusPerPod, 0), gpusPerPod, width, "0", "Start", nil)
-	created += c.emitSparePods(run, packPlan, gpusPerPod, "Start", nil)
+	created := c.emitCohortPods(run, packPlacements(packPlan, gpusPerPod, 0), gpusPerPod, width, "0", reason, nil)
+	created += c.emitSparePods(run, packPlan, gpusPerPod, reason, nil)
 	return created
 }
 
@@ -2592,6 +3060,9 @@ func (c *RunController) emitPromisePods(run *v1.Run, packPlan pack.Plan, payer c
 		binder.AnnotationPayerBudget:    payer.BudgetName,
 		binder.AnnotationPayerEnvelope:  payer.EnvelopeName,
 	}
+	if len(run.Spec.Roles) > 1 {
+		return c.emitRolePods(run, &packPlan, binder.LeaseReasonPromise, extra)
+	}
 	gpusPerPod, width := intentPodShape(run)
 	created := c.emitCohortPods(run, packPlacements(packPlan, gpusPerPod, 0), gpusPerPod, width, "0", binder.LeaseReasonPromise, extra)
 	created += c.emitSparePods(run, packPlan, gpusPerPod, binder.LeaseReasonPromise, extra)
@@ -2835,11 +3306,105 @@ func (c *RunController) emitCohortPods(run *v1.Run, placements []podPlacement, g
 	return created
 }
 
+// rolePodName is the deterministic name of a multi-role run's i-th pod of role.
+// The role name replaces "active" so each role's rank 0 is addressable on its
+// own — the bridge points that role's MASTER_ADDR at it.
+func rolePodName(run *v1.Run, role string, i int) string {
+	return fmt.Sprintf("%s-%s-%d", run.Name, role, i)
+}
+
+// emitRolePods is emitCohortPods for a multi-role run's base gang: each role's
+// Width pods at that role's GPUsPerPod, labelled with the role name, all under
+// one expected width so the plugin's Permit holds every role until the whole
+// gang is funded and placed. Groups are numbered across roles in spec order,
+// the way pack.PlanGang numbers them. plan is nil on a top-up, when the group is
+// recomputed from the spec (roleGroupIndexForPodIndex) and no node hint is
+// given. Idempotent per pod name; returns how many pods it created.
+func (c *RunController) emitRolePods(run *v1.Run, plan *pack.Plan, reason string, extra map[string]string) int {
+	present := make(map[string]bool)
+	for i := range c.State.Pods {
+		p := &c.State.Pods[i]
+		if p.Namespace == run.Namespace && p.Labels[binder.LabelRunName] == run.Name &&
+			p.Labels[binder.LabelRunRole] == binder.RoleActive {
+			present[p.Name] = true
+		}
+	}
+	total := 0
+	for _, r := range run.Spec.Roles {
+		total += int(r.Width)
+	}
+	countStr := strconv.Itoa(total)
+	created := 0
+	for ri := range run.Spec.Roles {
+		role := &run.Spec.Roles[ri]
+		gpusPerPod := int(role.GPUsPerPod)
+		var placements []podPlacement
+		if plan != nil {
+			placements = packPlacements(rolePlan(*plan, role.Name), gpusPerPod, 0)
+		}
+		for i := 0; i < int(role.Width); i++ {
+			name := rolePodName(run, role.Name, i)
+			if present[name] {
+				continue
+			}
+			node := ""
+			group := roleGroupIndexForPodIndex(run, ri, i)
+			if len(placements) > 0 {
+				node = placements[i%len(placements)].Node
+				if i < len(placements) {
+					group = placements[i].Group
+				}
+			}
+			created++
+			annotations := map[string]string{
+				binder.AnnotationExpectedWidth: countStr,
+				binder.AnnotationLeaseReason:   reason,
+			}
+			for k, v := range extra {
+				annotations[k] = v
+			}
+			c.State.Pods = append(c.State.Pods, binder.PodManifest{
+				Namespace: run.Namespace,
+				Name:      name,
+				NodeName:  node, // advisory only, as in emitCohortPods
+				GPUs:      gpusPerPod,
+				Labels: map[string]string{
+					binder.LabelRunName:    run.Name,
+					binder.LabelRunRole:    binder.RoleActive,
+					binder.LabelGroupIndex: group,
+					binder.LabelRoleName:   role.Name,
+				},
+				Annotations: annotations,
+			})
+		}
+	}
+	return created
+}
+
+// rolePlan is the slice of a pack.PlanGang plan that placed one role.
+func rolePlan(plan pack.Plan, role string) pack.Plan {
+	out := pack.Plan{Flavor: plan.Flavor}
+	for _, g := range plan.Groups {
+		if g.Role == role {
+			out.Groups = append(out.Groups, g)
+		}
+	}
+	return out
+}
+
 // expectedActiveGPUs is the active width, in GPUs, that the run's base intent
-// gang was emitted at. CRD validation pins Width×GPUsPerPod == TotalGPUs for a
-// roled run, so this is TotalGPUs either way — but it is derived from the same
-// shape emitCohortPods emits, so the two can never drift.
+// gang was emitted at. CRD validation pins the roles' Width×GPUsPerPod sum to
+// TotalGPUs for a roled run, so this is TotalGPUs either way — but it is derived
+// from the same shapes emitCohortPods and emitRolePods emit, so the two can never
+// drift.
 func expectedActiveGPUs(run *v1.Run) int {
+	if len(run.Spec.Roles) > 1 {
+		total := 0
+		for _, r := range run.Spec.Roles {
+			total += int(r.Width * r.GPUsPerPod)
+		}
+		return total
+	}
 	gpusPerPod, width := intentPodShape(run)
 	return gpusPerPod * width
 }
@@ -3009,8 +3574,11 @@ func (c *RunController) gangProvenance(run *v1.Run) (string, map[string]string)
 // case — a member that is merely unbound still has its pod, and the plugin's
 // committed-count accounting re-admits it). Returns how many pods it created.
 func (c *RunController) topUpActiveGang(run *v1.Run) int {
-	gpusPerPod, width := intentPodShape(run)
 	reason, extra := c.gangProvenance(run)
+	if len(run.Spec.Roles) > 1 {
+		return c.emitRolePods(run, nil, reason, extra)
+	}
+	gpusPerPod, width := intentPodShape(run)
 	return c.emitCohortPods(run, nil, gpusPerPod, width, "0", reason, extra)
 }
 
@@ -3115,6 +3683,49 @@ func intentPodShape(run *v1.Run) (gpusPerPod, width int) {
 	return 1, int(run.Spec.Resources.TotalGPUs)
 }
 
+// runRole is the run's RunRole named role. An empty name (a single-role run's
+// pods carry no role-name label) is the first role; nil when the run has no
+// roles or none by that name.
+func runRole(run *v1.Run, role string) *v1.RunRole {
+	if len(run.Spec.Roles) == 0 {
+		return nil
+	}
+	if role == "" {
+		return &run.Spec.Roles[0]
+	}
+	for i := range run.Spec.Roles {
+		if run.Spec.Roles[i].Name == role {
+			return &run.Spec.Roles[i]
+		}
+	}
+	return nil
+}
+
+// roleGroupIndexForPodIndex is groupIndexForPodIndex for the i-th pod of a
+// multi-role run's role ri: the role's GPUs are grouped on their own, by the
+// same pack.DeriveGroups rule and group size admission.RolePackRequests hands
+// the packer, and numbered after every earlier role's groups.
+func roleGroupIndexForPodIndex(run *v1.Run, ri, i int) string {
+	requests := admission.RolePackRequests(run)
+	offset := 0
+	for k := 0; k < ri; k++ {
+		offset += len(pack.DeriveGroups(requests[k].TotalGPUs, requests[k].GroupGPUs))
+	}
+	sizes := pack.DeriveGroups(requests[ri].TotalGPUs, requests[ri].GroupGPUs)
+	gpusPerPod := int(run.Spec.Roles[ri].GPUsPerPod)
+	if len(sizes) == 0 || gpusPerPod <= 0 {
+		return strconv.Itoa(offset)
+	}
+	at := i * gpusPerPod
+	for idx, size := range sizes {
+		if at < size {
+			return strconv.Itoa(offset + idx)
+		}
+		at -= size
+	}
+	return strconv.Itoa(offset + len(sizes) - 1)
+}
+
 // podPlacement is one intent pod's ADVISORY node and its AUTHORITATIVE group.
 //
 // The node is a hint: the bridge turns it into soft affinity and the scheduler
@@ -3292,7 +3903,7 @@ func (c *RunController) reconcileElasticRun(run *v1.Run, snapshot *topology.Snap
 			run.Status.Width.Pending = fmt.Sprintf("Grow to %d", desired)
 		}
 		setMessage(run, fmt.Sprintf("grew to %d GPUs", newWidth.Allocated))
-		metrics.IncElasticGrow(run.Spec.Resources.GPUType)
+		metrics.IncElasticGrow(run.Flavor())
 		metrics.SetElasticWidth(keys.NamespacedKey(run.Namespace, run.Name), float64(newWidth.Allocated))
 		return nil
 	}
@@ -3312,7 +3923,7 @@ func (c *RunController) reconcileElasticRun(run *v1.Run, snapshot *topology.Snap
 			run.Status.Width.Pending = fmt.Sprintf("Shrink to %d", desired)
 		}
 		setMessage(run, fmt.Sprintf("shrunk to %d GPUs", newWidth.Allocated))
-		metrics.IncElasticShrink(run.Spec.Resources.GPUType)
+		metrics.IncElasticShrink(run.Flavor())
 		metrics.SetElasticWidth(keys.NamespacedKey(run.Namespace, run.Name), float64(newWidth.Allocated))
 	}
 
@@ -3335,13 +3946,23 @@ func (c *RunController) growRun(run *v1.Run, snapshot *topology.Snapshot, now ti
 	}
 	// Pack only the delta (no new spares — spares are established at the base)
 	// for the advisory placement hint and to confirm the delta can fit now.
-	plan, err := pack.Planner(snapshot, pack.Request{
-		Flavor:                run.Spec.Resources.GPUType,
+	req := pack.Request{
+		Flavor:                run.Flavor(),
 		TotalGPUs:             add,
 		GroupGPUs:             groupSize,
 		AllowCrossGroupSpread: run.Spec.AllowCrossGroupSpread(),
 		SparesPerGroup:        0,
-	})
+	}
+	if run.Spec.Locality != nil {
+		// A delta's groups keep the run's group bound; the run bound is kept
+		// by packing the delta into the base gang's unit alone.
+		req.GroupWithin = run.Spec.Locality.GroupWithin
+	}
+	snapshot, err := admission.GrowSnapshot(run, snapshot, c.State.Leases, now)
+	if err != nil {
+		return err
+	}
+	plan, err := pack.Planner(snapshot, req)
 	if err != nil {
 		return err
 	}
@@ -3444,8 +4065,8 @@ func summarizeRunWidth(run *v1.Run, leases []v1.GPULease) *v1.RunWidthStatus {
 	status := &v1.RunWidthStatus{Allocated: allocated}
 	if run.Spec.Malleable != nil {
 		status.Min = run.Spec.Malleable.MinTotalGPUs
-		status.Max = run.Spec.Malleable.MaxTotalGPUs
-		status.Desired = run.Spec.Malleable.Desired()
+		status.Max = scaledWidth(run, run.Spec.Malleable.MaxTotalGPUs)
+		status.Desired = desiredWidth(run)
 	} else {
 		total := run.Spec.Resources.TotalGPUs
 		status.Min = total
@@ -3475,6 +4096,7 @@ func summarizeRunFunding(run *v1.Run, ev *funding.Evaluation) *v1.RunFundingStat
 		BorrowedGPUHours: acct.GPUHours[funding.ClassBorrowed],
 		UnfundedGPUs:     acct.GPUs[funding.ClassUnfunded],
 		UnfundedGPUHours: acct.GPUHours[funding.ClassUnfunded],
+		Priority:         acct.Priority,
 	}
 	if len(acct.Lenders) > 0 || len(acct.LenderHours) > 0 {
 		owners := make(map[string]struct{}, len(acct.Lenders))
@@ -3495,6 +4117,19 @@ func summarizeRunFunding(run *v1.Run, ev *funding.Evaluation) *v1.RunFundingStat
 			return status.Lenders[i].Owner < status.Lenders[j].Owner
 		})
 	}
+	for role, gpus := range acct.RoleGPUs {
+		status.Roles = append(status.Roles, v1.RunFundingRoleShare{
+			Role:         role,
+			OwnedGPUs:    gpus[funding.ClassOwned],
+			SharedGPUs:   gpus[funding.ClassShared],
+			BorrowedGPUs: gpus[funding.ClassBorrowed],
+			UnfundedGPUs: gpus[funding.ClassUnfunded],
+			GPUHours:     acct.RoleGPUHours[role],
+		})
+	}
+	sort.Slice(status.Roles, func(i, j int) bool {
+		return status.Roles[i].Role < status.Roles[j].Role
+	})
 
 	if status.OwnedGPUs == 0 && status.SharedGPUs == 0 && status.BorrowedGPUs == 0 && status.UnfundedGPUs == 0 &&
 		status.OwnedGPUHours == 0 && status.SharedGPUHours == 0 && status.BorrowedGPUHours == 0 && status.UnfundedGPUHours == 0 {
@@ -0,0 +1,143 @@
+package controllers
+
+import (
+	"fmt"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+)
+
+// timeLimitWarning is how long before its limit a run is warned: warnBefore,
+// else its checkpoint window, which is how long it says a checkpoint takes.
+func timeLimitWarning(run *v1.Run) time.Duration {
+	rt := run.Spec.Runtime
+	if rt.WarnBefore != nil {
+		return rt.WarnBefore.Duration
+	}
+	if rt.Checkpoint.Duration > 0 && rt.Checkpoint.Duration < rt.MaxDuration.Duration {
+		return rt.Checkpoint.Duration
+	}
+	return v1.DefaultTimeLimitWarning
+}
+
+// gangStart is when the running run's gang started: the earliest start of its
+// open active leases. A retried or swapped member's fresher lease does not
+// move it; a run requeued whole has none until it re-admits.
+func gangStart(runKey string, leases []v1.GPULease) (time.Time, bool) {
+	var start time.Time
+	found := false
+	for i := range leases {
+		l := &leases[i]
+		if l.Status.Closed || l.Spec.Slice.Role == binder.RoleSpare {
+			continue
+		}
+		if keys.NamespacedKey(l.Spec.RunRef.Namespace, l.Spec.RunRef.Name) != runKey {
+			continue
+		}
+		if !found || l.Spec.Interval.Start.Time.Before(start) {
+			start, found = l.Spec.Interval.Start.Time, true
+		}
+	}
+	return start, found
+}
+
+// enforceTimeLimit applies spec.runtime.maxDuration to a running run. The
+// deadline and the warning are fixed the first pass the run is seen running.
+// At the warning the run is warned, once: an Event, and status.timeLimit.
+// warnedAt, which the bridge projects onto its pods as the time-limit
+// annotation so the workload can checkpoint. At the deadline the run's leases
+// close and it ends TimeLimitExceeded. It reports whether it ended the run.
+func (c *RunController) enforceTimeLimit(run *v1.Run, now time.Time) bool {
+	if run.Spec.Runtime == nil || run.Spec.Runtime.MaxDuration == nil {
+		run.Status.TimeLimit = nil
+		return false
+	}
+	limit := run.Spec.Runtime.MaxDuration.Duration
+	if run.Status.TimeLimit == nil {
+		start, ok := gangStart(keys.NamespacedKey(run.Namespace, run.Name), c.State.Leases)
+		if !ok {
+			return false
+		}
+		run.Status.TimeLimit = &v1.RunTimeLimitStatus{
+			Deadline: v1.NewTime(start.Add(limit)),
+			WarnAt:   v1.NewTime(start.Add(limit - timeLimitWarning(run))),
+		}
+	}
+	tl := run.Status.TimeLimit
+	deadline := tl.Deadline.UTC().Format(time.RFC3339)
+	if !now.Before(tl.Deadline.Time) {
+		c.exceedTimeLimit(run, fmt.Sprintf("reached its time limit of %s at %s", limit, deadline), now)
+		return true
+	}
+	if tl.WarnedAt == nil && !now.Before(tl.WarnAt.Time) {
+		warned := v1.NewTime(now)
+		tl.WarnedAt = &warned
+		c.emit(run, EventTypeWarning, "TimeLimitWarning", fmt.Sprintf(
+			"time limit of %s is reached at %s, when the run's leases close; checkpoint before then", limit, deadline))
+	}
+	return false
+}
+
+// exceedTimeLimit ends a run that outran its time limit: its leases close with
+// the TimeLimit reason, so the ledger tells a limit from a crash, and the run
+// is terminal.
+func (c *RunController) exceedTimeLimit(run *v1.Run, msg string, now time.Time) {
+	setState(run, v1.RunStateTimeLimitExceeded, msg)
+	run.Status.PendingReservation = nil
+	run.Status.EarliestStart = nil
+	run.Status.CheckpointDeadline = nil
+	run.Status.RetryAfter = nil
+	if closed := c.releaseRun(run, binder.LeaseReasonTimeLimit, now); closed > 0 {
+		c.emit(run, EventTypeWarning, "LeasesReleased",
+			fmt.Sprintf("released %d open lease(s) held by the run at its time limit", closed))
+	}
+	c.emit(run, EventTypeWarning, "TimeLimitExceeded", msg)
+}
+
+// overdueAttempt returns the run's first running active pod whose role sets an
+// attemptTimeout its lease has been open longer than, or nil.
+func (c *RunController) overdueAttempt(run *v1.Run, now time.Time) *binder.PodManifest {
+	for i := range c.State.Pods {
+		p := &c.State.Pods[i]
+		if due, ok := c.attemptDeadline(run, p); ok && !now.Before(due) {
+			return p
+		}
+	}
+	return nil
+}
+
+// NextAttemptTimeout is the earliest moment one of the run's running active
+// members outruns its role's attemptTimeout; false when none has one.
+func (c *RunController) NextAttemptTimeout(run *v1.Run) (time.Time, bool) {
+	var next time.Time
+	found := false
+	for i := range c.State.Pods {
+		if due, ok := c.attemptDeadline(run, &c.State.Pods[i]); ok && (!found || due.Before(next)) {
+			next, found = due, true
+		}
+	}
+	return next, found
+}
+
+// attemptDeadline is when p, a running active member of run, outruns its
+// role's attemptTimeout: its lease's start plus the timeout. False for any
+// other pod, or a member whose role sets none.
+func (c *RunController) attemptDeadline(run *v1.Run, p *binder.PodManifest) (time.Time, bool) {
+	if p.Namespace != run.Namespace || p.Labels[binder.LabelRunName] != run.Name {
+		return time.Time{}, false
+	}
+	if p.Labels[binder.LabelRunRole] == binder.RoleSpare || p.Phase == binder.PodPhaseSucceeded || p.Phase == binder.PodPhaseFailed {
+		return time.Time{}, false
+	}
+	role := runRole(run, p.Labels[binder.LabelRoleName])
+	if role == nil || role.FailurePolicy != v1.FailurePolicyRetry || role.AttemptTimeout == nil {
+		return time.Time{}, false
+	}
+	l := c.memberLease(run, p.NodeName)
+	if l == nil {
+		return time.Time{}, false
+	}
+	return l.Spec.Interval.Start.Add(role.AttemptTimeout.Duration), true
+}
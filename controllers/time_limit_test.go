@@ -0,0 +1,126 @@
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/binder"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+)
+
+// startLeases backdates every lease of the world to start at start.
+func startLeases(state *ClusterState, start time.Time) {
+	for i := range state.Leases {
+		state.Leases[i].Spec.Interval.Start = v1.NewTime(start)
+	}
+}
+
+// failedReason is the reason on the run's Failed condition, or "".
+func failedReason(run *v1.Run) string {
+	if cond := meta.FindStatusCondition(run.Status.Conditions, v1.RunConditionFailed); cond != nil {
+		return cond.Reason
+	}
+	return ""
+}
+
+// maxDuration: the deadline is fixed from the gang's start, the run is warned
+// once at the lead, and at the deadline its leases close with the TimeLimit
+// reason and it ends TimeLimitExceeded.
+func TestMaxDurationWarnsThenEndsTheRun(t *testing.T) {
+	start := time.Date(2026, 7, 3, 12, 0, 0, 0, time.UTC)
+	state, run, key := roledFailureWorld(v1.FailurePolicyIgnore, 0, []string{"Running", "Running"})
+	run.Spec.Runtime = &v1.RunRuntime{
+		MaxDuration: &v1.Duration{Duration: 2 * time.Hour},
+		WarnBefore:  &v1.Duration{Duration: 15 * time.Minute},
+	}
+	startLeases(state, start)
+	rec := &fakeRecorder{}
+	clock := &runClock{now: start.Add(time.Hour)}
+	c := NewRunController(state, clock)
+	c.Recorder = rec
+
+	if err := c.Reconcile(keys.DefaultNamespace, "job"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	tl := state.Runs[key].Status.TimeLimit
+	if tl == nil || !tl.Deadline.Time.Equal(start.Add(2*time.Hour)) || !tl.WarnAt.Time.Equal(start.Add(105*time.Minute)) {
+		t.Fatalf("time limit = %+v, want a 14:00 deadline warned at 13:45", tl)
+	}
+	if tl.WarnedAt != nil || rec.has("job", EventTypeWarning, "TimeLimitWarning", "") {
+		t.Fatalf("warned an hour before the warning is due")
+	}
+
+	clock.now = start.Add(110 * time.Minute)
+	if err := c.Reconcile(keys.DefaultNamespace, "job"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if tl := state.Runs[key].Status.TimeLimit; tl.WarnedAt == nil || !tl.WarnedAt.Time.Equal(clock.now) {
+		t.Fatalf("warnedAt = %v, want %v", tl.WarnedAt, clock.now)
+	}
+	if !rec.has("job", EventTypeWarning, "TimeLimitWarning", "2026-07-03T14:00:00Z") {
+		t.Fatalf("no TimeLimitWarning naming the deadline: %+v", rec.events)
+	}
+	if got := state.Runs[key].Status.Phase; got != RunPhaseRunning {
+		t.Fatalf("a warned run keeps running, got %s", got)
+	}
+
+	clock.now = start.Add(2 * time.Hour)
+	if err := c.Reconcile(keys.DefaultNamespace, "job"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	got := state.Runs[key]
+	if got.Status.Phase != RunPhaseFailed || failedReason(got) != v1.RunStateTimeLimitExceeded.Reason {
+		t.Fatalf("at the deadline the run must end TimeLimitExceeded, got %s/%s", got.Status.Phase, failedReason(got))
+	}
+	for i := range state.Leases {
+		if l := state.Leases[i]; !l.Status.Closed || l.Status.ClosureReason != binder.LeaseReasonTimeLimit {
+			t.Errorf("lease %s closed=%v reason=%q, want closed with %q", l.Name, l.Status.Closed, l.Status.ClosureReason, binder.LeaseReasonTimeLimit)
+		}
+	}
+	if !rec.has("job", EventTypeWarning, "TimeLimitExceeded", "") {
+		t.Errorf("no TimeLimitExceeded event: %+v", rec.events)
+	}
+}
+
+// attemptTimeout: an active member whose lease has been open longer than its
+// role allows is retried like a failed one; with its retries spent, the run
+// ends TimeLimitExceeded rather than Failed.
+func TestAttemptTimeoutRetriesThenExceeds(t *testing.T) {
+	start := time.Date(2026, 7, 3, 12, 0, 0, 0, time.UTC)
+	state, run, key := roledFailureWorld(v1.FailurePolicyRetry, 1, []string{"Running", "Running"})
+	run.Spec.Roles[0].AttemptTimeout = &v1.Duration{Duration: 30 * time.Minute}
+	startLeases(state, start)
+	state.Leases[1].Spec.Interval.Start = v1.NewTime(start.Add(20 * time.Minute))
+	clock := &runClock{now: start.Add(40 * time.Minute)}
+	c := NewRunController(state, clock)
+
+	if err := c.Reconcile(keys.DefaultNamespace, "job"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got := state.Runs[key]; got.Status.Phase != RunPhaseRunning || got.Status.FailedAttempts != 1 {
+		t.Fatalf("an overdue member with a retry left is retried: phase %s, attempts %d", got.Status.Phase, got.Status.FailedAttempts)
+	}
+	if closed, reason := closureOf(state, state.Leases[0].Name); !closed || reason != binder.LeaseReasonTimeLimit {
+		t.Fatalf("the overdue member's lease closed=%v reason=%q, want %q", closed, reason, binder.LeaseReasonTimeLimit)
+	}
+	if closed, _ := closureOf(state, state.Leases[1].Name); closed {
+		t.Fatalf("the member still inside its attempt timeout must be left alone")
+	}
+	if due, ok := c.NextAttemptTimeout(state.Runs[key]); !ok || !due.Equal(start.Add(50*time.Minute)) {
+		t.Fatalf("next attempt timeout = %v (%v), want the surviving member's at %v", due, ok, start.Add(50*time.Minute))
+	}
+
+	clock.now = start.Add(55 * time.Minute)
+	if err := c.Reconcile(keys.DefaultNamespace, "job"); err != nil {
+		t.Fatalf("reconcile: %v", err)
+	}
+	if got := state.Runs[key]; failedReason(got) != v1.RunStateTimeLimitExceeded.Reason {
+		t.Fatalf("with retries spent an overdue member ends the run TimeLimitExceeded, got %s/%s", got.Status.Phase, failedReason(got))
+	}
+	if openLeaseCount(state) != 0 {
+		t.Errorf("the run's leases must all close, open = %d", openLeaseCount(state))
+	}
+}
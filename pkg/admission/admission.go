@@ -21,6 +21,7 @@ package admission
 
 import (
 	"fmt"
+	"sort"
 	"strings"
 	"time"
 
@@ -40,9 +41,15 @@ type Input struct {
 	Budgets []v1.Budget
 	Runs    map[string]*v1.Run // keyed by keys.NamespacedKey, for funding.Evaluate
 	Leases  []v1.GPULease      // the live ledger (open + closed)
-	Nodes   []topology.SourceNode
-	Now     time.Time
-	Period  time.Duration // funding accounting horizon; <=0 uses funding.DefaultPeriod
+	// Archives are the ledger's compacted windows, for funding.Evaluate.
+	Archives []v1.LeaseArchive
+	// Transfers are the published snapshot's compiled BudgetTransfers.
+	Transfers []v1.SnapshotTransfer
+	Nodes     []topology.SourceNode
+	// Topology is the fleet's tiers below each fabric domain (HierarchyFrom).
+	Topology topology.Hierarchy
+	Now      time.Time
+	Period   time.Duration // funding accounting horizon; <=0 uses funding.DefaultPeriod
 	// Reason is the LeaseReason stamped on minted leases (Start/Grow/Swap/...).
 	// Empty defaults to "Start" (binder.Materialize's default).
 	Reason string
@@ -52,6 +59,10 @@ type Input struct {
 	// incrementally on top of the base leases already in the ledger, rather than
 	// re-funding the whole run.
 	Quantity int32
+	// Flavor, when set, holds the decision to that one GPU flavor. The plugin
+	// passes the flavor its pods were emitted for, so the gang is funded on the
+	// same flavor Filter binds it to. Empty tries the run's flavors in order.
+	Flavor string
 }
 
 // Result is an admittable gang's committed plan: the topology placement, the
@@ -74,14 +85,50 @@ type Result struct {
 // forecasts a reservation. The returned funding.Evaluation is reused by callers
 // (e.g. status mirrors). This is the authoritative check the plugin repeats at
 // Permit (optimistic) and PreBind (under lock, before the mint) per §9 D6.
+//
+// A run with alternative flavors is tried on each in order — gpuType first —
+// and admitted on the first that both fits and funds; the plan's Flavor names
+// it. When none does, the error is the first flavor's. A run already placed
+// on a flavor (status.flavor), a grow cohort, and a pinned in.Flavor are tried
+// on that flavor alone.
 func Feasible(in Input) (pack.Plan, cover.Plan, *funding.Evaluation, error) {
 	if in.Run == nil {
 		return pack.Plan{}, cover.Plan{}, nil, fmt.Errorf("run must be provided")
 	}
+	var (
+		firstPack pack.Plan
+		firstEv   *funding.Evaluation
+		firstErr  error
+	)
+	for i, flavor := range candidateFlavors(in) {
+		packPlan, coverPlan, ev, err := feasibleOn(in, flavor)
+		if err == nil {
+			return packPlan, coverPlan, ev, nil
+		}
+		if i == 0 {
+			firstPack, firstEv, firstErr = packPlan, ev, err
+		}
+	}
+	return firstPack, cover.Plan{}, firstEv, firstErr
+}
+
+// candidateFlavors is the order Feasible tries the run's flavors in.
+func candidateFlavors(in Input) []string {
+	switch {
+	case in.Flavor != "":
+		return []string{in.Flavor}
+	case in.Quantity > 0 || in.Run.Status.Flavor != "":
+		return []string{in.Run.Flavor()}
+	}
+	return in.Run.Spec.Resources.Flavors()
+}
+
+// feasibleOn is Feasible on one flavor.
+func feasibleOn(in Input, flavor string) (pack.Plan, cover.Plan, *funding.Evaluation, error) {
 	run := in.Run
 
 	usage := computeUsage(in.Leases, in.Now)
-	snapshot, err := topology.BuildSnapshotForFlavor(in.Nodes, usage, run.Spec.Resources.GPUType)
+	snapshot, err := topology.BuildSnapshot(in.Nodes, usage, flavor, in.Topology)
 	if err != nil {
 		return pack.Plan{}, cover.Plan{}, nil, err
 	}
@@ -96,26 +143,34 @@ func Feasible(in Input) (pack.Plan, cover.Plan, *funding.Evaluation, error) {
 		spares = 0
 	}
 
+	if in.Quantity > 0 {
+		if snapshot, err = GrowSnapshot(run, snapshot, in.Leases, in.Now); err != nil {
+			return pack.Plan{}, cover.Plan{}, nil, err
+		}
+	}
 	packPlan, err := planPlacement(run, snapshot, totalGPUs, spares)
 	if err != nil {
 		return pack.Plan{}, cover.Plan{}, nil, err
 	}
 
 	ev := funding.Evaluate(funding.Input{
-		Budgets: in.Budgets,
-		Leases:  in.Leases,
-		Runs:    in.Runs,
-		Now:     in.Now,
-		Period:  in.Period,
+		Budgets:   in.Budgets,
+		Leases:    in.Leases,
+		Runs:      in.Runs,
+		Now:       in.Now,
+		Period:    in.Period,
+		Archives:  in.Archives,
+		Transfers: in.Transfers,
 	})
 	inventory := cover.NewInventory(ev)
 
 	request := cover.Request{
 		Owner:       ev.OwnerOf(run.Namespace),
-		Flavor:      run.Spec.Resources.GPUType,
+		Flavor:      flavor,
 		Quantity:    int32(totalGPUs) + int32(packPlan.TotalSpares),
 		Location:    deriveLocation(packPlan),
 		Now:         in.Now,
+		Priority:    run.Spec.Priority,
 		Admitted:    run.CreationTimestamp.Time,
 		AllowBorrow: run.Spec.Funding != nil && run.Spec.Funding.AllowBorrow,
 	}
@@ -189,6 +244,91 @@ func PerPodPayer(plan cover.Plan, gpusPerPod int) ([]cover.Segment, error) {
 	return out, nil
 }
 
+// RolePackRequests is a multi-role run's gang as pack.PlanGang takes it: one
+// request per role, in spec order, sized width*gpusPerPod. A role's own
+// groupGPUs wins over spec.locality.groupGPUs, and the run's cross-group spread
+// setting applies to every role. The controller's planner and the plugin's gate
+// both build the gang through here, so the two cannot pack it differently.
+func RolePackRequests(run *v1.Run) []pack.RoleRequest {
+	out := make([]pack.RoleRequest, 0, len(run.Spec.Roles))
+	for i := range run.Spec.Roles {
+		role := &run.Spec.Roles[i]
+		var groupSize *int
+		switch {
+		case role.GroupGPUs != nil:
+			value := int(*role.GroupGPUs)
+			groupSize = &value
+		case run.Spec.Locality != nil && run.Spec.Locality.GroupGPUs != nil:
+			value := int(*run.Spec.Locality.GroupGPUs)
+			groupSize = &value
+		}
+		req := pack.Request{
+			TotalGPUs:             int(role.Width * role.GPUsPerPod),
+			GroupGPUs:             groupSize,
+			AllowCrossGroupSpread: run.Spec.AllowCrossGroupSpread(),
+		}
+		if run.Spec.Locality != nil {
+			req.GroupWithin, req.RunWithin = run.Spec.Locality.GroupWithin, run.Spec.Locality.RunWithin
+		}
+		out = append(out, pack.RoleRequest{Name: role.Name, Request: req})
+	}
+	return out
+}
+
+// GangPodSizes lists the GPUs of every Active pod in a multi-role run's base
+// gang, role by role in spec order: Width entries of GPUsPerPod each. It is
+// the shape PerPodPayers funds and the plugin's gang hands payers out against.
+func GangPodSizes(run *v1.Run) []int {
+	var sizes []int
+	for i := range run.Spec.Roles {
+		role := &run.Spec.Roles[i]
+		for k := int32(0); k < role.Width; k++ {
+			sizes = append(sizes, int(role.GPUsPerPod))
+		}
+	}
+	return sizes
+}
+
+// PerPodPayers is PerPodPayer for a gang whose pods are not all one size: it
+// gives each pod, in order, the first cover segment with enough quantity left
+// to fund it whole (a pod is funded by exactly one envelope, as in the uniform
+// case). The sizes are taken largest-first so a big learner pod is not
+// stranded by actor pods having nibbled every segment below its size; the
+// result is returned in the caller's order. It fails when the segments cannot
+// be cut into the pods — the heterogeneous form of PerPodPayer's alignment
+// error.
+func PerPodPayers(plan cover.Plan, sizes []int) ([]cover.Segment, error) {
+	remaining := make([]int, len(plan.Segments))
+	for i, seg := range plan.Segments {
+		remaining[i] = int(seg.Quantity)
+	}
+	order := make([]int, len(sizes))
+	for i := range order {
+		order[i] = i
+	}
+	sort.SliceStable(order, func(a, b int) bool { return sizes[order[a]] > sizes[order[b]] })
+	out := make([]cover.Segment, len(sizes))
+	for _, idx := range order {
+		size := sizes[idx]
+		if size <= 0 {
+			return nil, fmt.Errorf("pod size must be positive, got %d", size)
+		}
+		placed := false
+		for i := range plan.Segments {
+			if remaining[i] >= size {
+				remaining[i] -= size
+				out[idx] = plan.Segments[i]
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			return nil, fmt.Errorf("no cover segment has %d GPUs left to fund a pod whole", size)
+		}
+	}
+	return out, nil
+}
+
 // leaseLabels builds a minted Lease's labels. The group index is omitted only when
 // the caller has none (the phantom pending leases the plugin folds into its funding
 // arithmetic and never creates).
@@ -244,7 +384,7 @@ func PodLeaseWithRole(run *v1.Run, seg cover.Segment, node string, gpusPerPod in
 		Spec: v1.GPULeaseSpec{
 			Owner:                 seg.Owner,
 			RunRef:                v1.RunReference{Name: run.Name, Namespace: run.Namespace},
-			Slice:                 v1.GPULeaseSlice{Nodes: slots, Role: role},
+			Slice:                 v1.GPULeaseSlice{Nodes: slots, Role: role, Profile: sliceProfile(run.Flavor())},
 			Interval:              v1.GPULeaseInterval{Start: v1.NewTime(now)},
 			PaidByBudgetNamespace: seg.Namespace,
 			PaidByBudget:          seg.BudgetName,
@@ -285,21 +425,102 @@ func StampGangIdentity(lease *v1.GPULease, cohort, podName string) {
 	}
 }
 
+// StampRoleName records on a freshly-minted lease the RunRole its pod belongs
+// to, copied from the pod's role-name label; a no-op for a single-role pod,
+// which carries none. It is what lets the funding derivation attribute a
+// multi-role run's width per role.
+func StampRoleName(lease *v1.GPULease, role string) {
+	if role == "" {
+		return
+	}
+	if lease.Labels == nil {
+		lease.Labels = map[string]string{}
+	}
+	lease.Labels[binder.LabelRoleName] = role
+}
+
+// HierarchyFrom is the fleet's topology hierarchy as placement reads it: the
+// TopologyHierarchy named "default" among items, or no tiers without one.
+func HierarchyFrom(items []v1.TopologyHierarchy) topology.Hierarchy {
+	for i := range items {
+		if items[i].Name != v1.TopologyHierarchyName {
+			continue
+		}
+		var h topology.Hierarchy
+		for _, level := range items[i].Spec.Levels {
+			h.Levels = append(h.Levels, topology.Level{Name: level.Name, Label: level.NodeLabel})
+		}
+		return h
+	}
+	return topology.Hierarchy{}
+}
+
 // --- helpers moved from controllers/run_controller.go (admission-only) ---
 
 func planPlacement(run *v1.Run, snapshot *topology.Snapshot, totalGPUs, spares int) (pack.Plan, error) {
+	// A multi-role base gang packs role by role; a grow cohort cannot occur for
+	// one (validation refuses malleable multi-role runs), so totalGPUs is the
+	// run's own and the roles account for it.
+	if len(run.Spec.Roles) > 1 && totalGPUs == int(run.Spec.Resources.TotalGPUs) {
+		return pack.PlanGang(snapshot, snapshot.Flavor, RolePackRequests(run))
+	}
 	var groupSize *int
 	if run.Spec.Locality != nil && run.Spec.Locality.GroupGPUs != nil {
 		value := int(*run.Spec.Locality.GroupGPUs)
 		groupSize = &value
 	}
-	return pack.Planner(snapshot, pack.Request{
-		Flavor:                run.Spec.Resources.GPUType,
+	req := pack.Request{
+		Flavor:                snapshot.Flavor,
 		TotalGPUs:             totalGPUs,
 		GroupGPUs:             groupSize,
 		AllowCrossGroupSpread: run.Spec.AllowCrossGroupSpread(),
 		SparesPerGroup:        spares,
-	})
+	}
+	if run.Spec.Locality != nil {
+		req.GroupWithin = run.Spec.Locality.GroupWithin
+		// A grow cohort packs only its delta into the snapshot GrowSnapshot
+		// has already narrowed to the base gang's unit.
+		if totalGPUs == int(run.Spec.Resources.TotalGPUs) {
+			req.RunWithin = run.Spec.Locality.RunWithin
+		}
+	}
+	return pack.Planner(snapshot, req)
+}
+
+// GrowSnapshot narrows snapshot, for a grow cohort of a run with
+// spec.locality.runWithin, to the unit of that level that holds the base
+// gang's open leases, so the delta lands beside the gang it joins. A run
+// without the bound gets snapshot back; one whose base gang holds no open
+// lease on the snapshot's nodes cannot grow inside its unit and fails.
+func GrowSnapshot(run *v1.Run, snapshot *topology.Snapshot, leases []v1.GPULease, now time.Time) (*topology.Snapshot, error) {
+	if run.Spec.Locality == nil || run.Spec.Locality.RunWithin == "" {
+		return snapshot, nil
+	}
+	within := run.Spec.Locality.RunWithin
+	level, ok := snapshot.Hierarchy.Index(within)
+	if !ok {
+		return nil, fmt.Errorf("unknown topology level %q", within)
+	}
+	runKey := keys.NamespacedKey(run.Namespace, run.Name)
+	for i := range leases {
+		lease := &leases[i]
+		if keys.NamespacedKey(lease.Spec.RunRef.Namespace, lease.Spec.RunRef.Name) != runKey || lease.Status.Closed {
+			continue
+		}
+		if lease.Spec.Interval.End != nil && !now.Before(lease.Spec.Interval.End.Time) {
+			continue
+		}
+		for _, id := range lease.Spec.Slice.Nodes {
+			node := id
+			if idx := strings.IndexRune(id, '#'); idx >= 0 {
+				node = id[:idx]
+			}
+			if unit, ok := snapshot.UnitOf(level, node); ok {
+				return snapshot.Within(level, unit), nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no open lease of the base gang lies in a %s to grow within", within)
 }
 
 // runSpares is the run's declared per-group spare count (0 if none).
@@ -322,6 +543,12 @@ func deriveLocation(plan pack.Plan) map[string]string {
 	}
 }
 
+// sliceProfile is the MIG profile a lease of flavor holds slices of, or "".
+func sliceProfile(flavor string) string {
+	_, profile, _ := topology.MIGProfile(flavor)
+	return profile
+}
+
 func computeUsage(leases []v1.GPULease, now time.Time) map[string]int {
 	usage := make(map[string]int)
 	for _, lease := range leases {
@@ -336,7 +563,7 @@ func computeUsage(leases []v1.GPULease, now time.Time) map[string]int {
 			if idx := strings.IndexRune(id, '#'); idx >= 0 {
 				node = id[:idx]
 			}
-			usage[node]++
+			usage[topology.UsageKey(node, lease.Spec.Slice.Profile)]++
 		}
 	}
 	return usage
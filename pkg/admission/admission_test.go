@@ -202,6 +202,34 @@ func TestPerPodPayerAttributesOwnedBeforeBorrowed(t *testing.T) {
 	}
 }
 
+// PerPodPayers cuts a cover plan into a mixed-size gang's pods without splitting
+// any pod across envelopes: the 4-GPU learner is placed first, so it is not
+// stranded by the actors spreading across both segments.
+func TestPerPodPayersFundsEachPodFromOneSegment(t *testing.T) {
+	plan := cover.Plan{Segments: []cover.Segment{
+		{Owner: "org:ai:rai", BudgetName: "rai", EnvelopeName: "west", Quantity: 3},
+		{Owner: "org:ai:mm:vision", BudgetName: "vision", EnvelopeName: "west", Quantity: 5},
+	}}
+	payers, err := PerPodPayers(plan, []int{1, 1, 1, 1, 4})
+	if err != nil {
+		t.Fatalf("perPodPayers: %v", err)
+	}
+	if payers[4].BudgetName != "vision" {
+		t.Errorf("learner payer = %s, want vision (the only segment with 4 GPUs)", payers[4].BudgetName)
+	}
+	funded := map[string]int{}
+	for i, p := range payers {
+		funded[p.BudgetName] += []int{1, 1, 1, 1, 4}[i]
+	}
+	if funded["rai"] != 3 || funded["vision"] != 5 {
+		t.Errorf("funded per budget = %v, want rai=3 vision=5", funded)
+	}
+
+	if _, err := PerPodPayers(plan, []int{4, 4}); err == nil {
+		t.Errorf("two 4-GPU pods cannot be cut from 3+5 GPUs without splitting one; want an error")
+	}
+}
+
 // Incremental delta funding (elastic grow): with the base leases already in the
 // ledger, Feasible(Quantity=delta) funds ONLY the delta on top — not the whole
 // run again — so a grow cohort is funded incrementally.
@@ -258,6 +286,61 @@ func TestFeasibleQuantityFundsDeltaOnly(t *testing.T) {
 	}
 }
 
+// A grow cohort of a run held within a spine lands in the spine the base gang
+// sits in, even when another spine has more room for the delta.
+func TestFeasibleGrowStaysInTheBaseGangsUnit(t *testing.T) {
+	now := time.Date(2024, 2, 2, 10, 0, 0, 0, time.UTC)
+	spine := func(name, id string, gpus int) topology.SourceNode {
+		n := node(name, gpus)
+		n.Labels["spine"] = id
+		return n
+	}
+	levels := v1.TopologyHierarchySpec{Levels: []v1.TopologyLevel{{Name: "spine", NodeLabel: "spine"}}}
+	in := Input{
+		Now: now,
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "default"},
+			Spec: v1.BudgetSpec{Owner: "org:ai:rai", Envelopes: []v1.BudgetEnvelope{{
+				Name: "west", Flavor: "H100-80GB", Selector: sel(), Concurrency: 64, Start: &testWindowStart, End: &testWindowEnd,
+			}}},
+		}},
+		Nodes:    []topology.SourceNode{spine("node-a", "s1", 8), spine("node-b", "s1", 4), spine("node-c", "s1", 4)},
+		Topology: HierarchyFrom([]v1.TopologyHierarchy{{ObjectMeta: v1.ObjectMeta{Name: v1.TopologyHierarchyName}, Spec: levels}}),
+		Run: &v1.Run{
+			ObjectMeta: v1.ObjectMeta{Name: "train", Namespace: "default"},
+			Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8},
+				Locality: &v1.RunLocality{RunWithin: "spine"}},
+		},
+	}
+	in.Runs = map[string]*v1.Run{"default/train": in.Run}
+	base, err := Plan(in)
+	if err != nil {
+		t.Fatalf("base admission: %v", err)
+	}
+
+	in.Nodes = append(in.Nodes, spine("node-0", "s2", 16))
+	in.Leases = base.Leases
+	in.Quantity = 8
+	in.Reason = "Grow"
+	grow, err := Plan(in)
+	if err != nil {
+		t.Fatalf("grow admission: %v", err)
+	}
+	for _, g := range grow.Pack.Groups {
+		for _, p := range g.NodePlacements {
+			if p.Node == "node-0" {
+				t.Fatalf("grow cohort placed on %s, outside the base gang's spine", p.Node)
+			}
+		}
+	}
+
+	// With no open base lease to anchor it, the delta has no unit to grow in.
+	in.Leases = nil
+	if _, err := Plan(in); err == nil {
+		t.Fatal("a grow with no base gang must not admit")
+	}
+}
+
 // A grow that exceeds the budget's remaining headroom is not fundable: the
 // envelope has room for the base but not the delta.
 func TestFeasibleQuantityDeltaRespectsBudget(t *testing.T) {
@@ -371,3 +454,95 @@ func TestPodLeaseWithRoleStampsThePlacementGroup(t *testing.T) {
 		t.Errorf("a phantom pending lease must not invent a group index it does not have")
 	}
 }
+
+// A run's locality reaches the packer through the hierarchy the cluster
+// declares: only the TopologyHierarchy named default counts, and with it the
+// run's group is held to the one NVLink island that fits it whole.
+func TestPlanHoldsAGroupWithinTheDeclaredLevel(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	island := func(name, id string, gpus int) topology.SourceNode {
+		n := node(name, gpus)
+		n.Labels["nvlink.island"] = id
+		return n
+	}
+	levels := v1.TopologyHierarchySpec{Levels: []v1.TopologyLevel{{Name: "nvlink", NodeLabel: "nvlink.island"}}}
+	in := Input{
+		Now: now,
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "rai", Namespace: "default"},
+			Spec: v1.BudgetSpec{Owner: "org:ai:rai", Envelopes: []v1.BudgetEnvelope{{
+				Name: "west-h100", Flavor: "H100-80GB", Selector: sel(), Concurrency: 32, Start: &testWindowStart, End: &testWindowEnd,
+			}}},
+		}},
+		Nodes: []topology.SourceNode{island("node-a", "i1", 4), island("node-b", "i2", 4), island("node-c", "i2", 4)},
+		Topology: HierarchyFrom([]v1.TopologyHierarchy{
+			{ObjectMeta: v1.ObjectMeta{Name: "staging"}},
+			{ObjectMeta: v1.ObjectMeta{Name: v1.TopologyHierarchyName}, Spec: levels},
+		}),
+		Run: &v1.Run{
+			ObjectMeta: v1.ObjectMeta{Name: "train-8", Namespace: "default"},
+			Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8},
+				Locality: &v1.RunLocality{GroupWithin: "nvlink"}},
+		},
+	}
+	in.Runs = map[string]*v1.Run{"default/train-8": in.Run}
+
+	res, err := Plan(in)
+	if err != nil {
+		t.Fatalf("expected admission, got error: %v", err)
+	}
+	if g := res.Pack.Groups[0]; g.Within != "nvlink" || len(g.NodePlacements) != 2 {
+		t.Fatalf("group within %q on %+v, want island i2's two nodes", g.Within, g.NodePlacements)
+	}
+
+	// Twelve GPUs in one group would span both islands.
+	in.Run.Spec.Resources.TotalGPUs = 12
+	in.Run.Spec.Locality.AllowCrossGroupSpread = new(bool)
+	if _, err := Plan(in); err == nil {
+		t.Fatal("a 12-GPU group spans two islands; the run must not admit")
+	}
+}
+
+// A run that would take H100 or A100 admits on the A100 envelope while the
+// H100 nodes are full, and names the flavor it chose. Held to H100 — as the
+// plugin holds it to the flavor its pods were emitted for — it does not.
+func TestFeasibleFallsThroughToAnAlternativeFlavor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	a100 := node("node-b", 8)
+	a100.Labels[topology.LabelGPUFlavor] = "A100-80GB"
+	in := Input{
+		Now: now,
+		Budgets: []v1.Budget{{
+			ObjectMeta: v1.ObjectMeta{Name: "rai", Namespace: "default"},
+			Spec: v1.BudgetSpec{Owner: "org:ai:rai", Envelopes: []v1.BudgetEnvelope{
+				{Name: "west-h100", Flavor: "H100-80GB", Selector: sel(), Concurrency: 8, Start: &testWindowStart, End: &testWindowEnd},
+				{Name: "west-a100", Flavor: "A100-80GB", Selector: sel(), Concurrency: 8, Start: &testWindowStart, End: &testWindowEnd},
+			}},
+		}},
+		Nodes: []topology.SourceNode{node("node-a", 4), a100},
+		Run: &v1.Run{
+			ObjectMeta: v1.ObjectMeta{Name: "train-8", Namespace: "default"},
+			Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 8,
+				Alternatives: []v1.FlavorAlternative{{GPUType: "A100-80GB"}}}},
+		},
+	}
+	in.Runs = map[string]*v1.Run{"default/train-8": in.Run}
+
+	packPlan, coverPlan, _, err := Feasible(in)
+	if err != nil {
+		t.Fatalf("expected admission on the alternative, got %v", err)
+	}
+	if packPlan.Flavor != "A100-80GB" || coverPlan.Segments[0].EnvelopeName != "west-a100" {
+		t.Fatalf("admitted on %s paid by %s, want A100 paid by west-a100", packPlan.Flavor, coverPlan.Segments[0].EnvelopeName)
+	}
+
+	in.Flavor = "H100-80GB"
+	if _, _, _, err := Feasible(in); err == nil {
+		t.Fatal("held to H100, 8 GPUs do not fit on a 4-GPU node")
+	}
+	in.Flavor = ""
+	in.Run.Status.Flavor = "H100-80GB"
+	if _, _, _, err := Feasible(in); err == nil {
+		t.Fatal("a run placed on H100 must stay there")
+	}
+}
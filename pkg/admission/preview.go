@@ -0,0 +1,94 @@
+package admission
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/davidlangworthy/jobtree/pkg/cover"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+)
+
+// Preview tells a submitter, before anything is created, how the run would
+// fare against the world in in: the funding mix it would start with now, or
+// why it would wait. It runs Feasible and commits nothing, so the answer is
+// the admission gate's own as of in.Now, not a promise: other runs may take
+// the capacity first. Each line is one sentence; the Run webhook returns them
+// as admission warnings and `kubectl runs submit --preview` prints them.
+func Preview(in Input) []string {
+	run := in.Run
+	if run == nil {
+		return nil
+	}
+	if sched := run.Spec.Schedule; sched != nil {
+		return []string{fmt.Sprintf("booked from %s: the booking is checked when the controller first sees the run; `kubectl runs plan` shows it",
+			sched.StartAfter.UTC().Format(time.RFC3339))}
+	}
+	var notes []string
+	if follow := run.Spec.Follow; follow != nil && len(follow.After) > 0 {
+		notes = append(notes, fmt.Sprintf("waits for %s to complete; what follows is its funding if it were admitted now", strings.Join(follow.After, ", ")))
+	}
+
+	packPlan, coverPlan, ev, err := Feasible(in)
+	flavor := packPlan.Flavor
+	if flavor == "" {
+		flavor = candidateFlavors(in)[0]
+	}
+	quantity := int32(run.Spec.Resources.TotalGPUs) + int32(packPlan.TotalSpares)
+	if err == nil {
+		return append(notes, fmt.Sprintf("would start now on %s as %s", flavor, fundingMix(coverPlan, ev.OwnerOf(run.Namespace))))
+	}
+	var planErr *cover.PlanError
+	if !errors.As(err, &planErr) {
+		// Placement failed before funding was asked.
+		return append(notes, fmt.Sprintf("no placement for %d GPUs of %s fits now (%v); it would wait for a Reservation", quantity, flavor, err))
+	}
+
+	owner := ev.OwnerOf(run.Namespace)
+	if owner == "" {
+		return append(notes, fmt.Sprintf("namespace %s has no funding principal (no Budget, or Budgets naming different owners); nothing can fund the run", run.Namespace))
+	}
+	var largest, total int32
+	for _, acct := range ev.Envelopes() {
+		if acct.Owner != owner || acct.Spec.Flavor != flavor {
+			continue
+		}
+		total += acct.Spec.Concurrency
+		largest = max(largest, acct.Spec.Concurrency)
+	}
+	switch {
+	case total == 0:
+		notes = append(notes, fmt.Sprintf("no envelope for flavor %s in namespace %s: only family or sponsors can fund it, and they cannot now; it would wait for a Reservation", flavor, run.Namespace))
+	case quantity > total:
+		notes = append(notes, fmt.Sprintf("exceeds every envelope's concurrency: %d GPUs of %s requested, the largest of %s's envelopes grants %d and all of them %d; it would wait for a Reservation and start partly Unfunded when that activates",
+			quantity, flavor, owner, largest, total))
+	default:
+		notes = append(notes, fmt.Sprintf("not fundable now (%s); it would wait for a Reservation and may start Unfunded when that activates", planErr.Reason))
+	}
+	return notes
+}
+
+// fundingMix summarises a cover plan by the class each segment would
+// classify as: the owner's own envelopes Owned, the family's Shared, a
+// sponsor's or a transfer's Borrowed. For example "24 Owned + 8 Shared".
+func fundingMix(plan cover.Plan, owner string) string {
+	byClass := map[funding.Class]int32{}
+	for _, seg := range plan.Segments {
+		switch {
+		case seg.Borrowed:
+			byClass[funding.ClassBorrowed] += seg.Quantity
+		case seg.Owner == owner:
+			byClass[funding.ClassOwned] += seg.Quantity
+		default:
+			byClass[funding.ClassShared] += seg.Quantity
+		}
+	}
+	var parts []string
+	for _, class := range []funding.Class{funding.ClassOwned, funding.ClassShared, funding.ClassBorrowed} {
+		if n := byClass[class]; n > 0 {
+			parts = append(parts, fmt.Sprintf("%d %s", n, class))
+		}
+	}
+	return strings.Join(parts, " + ")
+}
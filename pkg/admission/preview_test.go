@@ -0,0 +1,65 @@
+package admission
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// Preview answers in one sentence per concern: the funding mix when the run
+// fits now, otherwise the reason it would wait.
+func TestPreviewDescribesFundingOrWhyItWaits(t *testing.T) {
+	now := time.Date(2024, 1, 2, 0, 0, 0, 0, time.UTC)
+	world := func(envs []v1.BudgetEnvelope, gpus int32) Input {
+		in := Input{
+			Now: now,
+			Budgets: []v1.Budget{
+				{ObjectMeta: v1.ObjectMeta{Name: "rai", Namespace: "default"}, Spec: v1.BudgetSpec{Owner: "org:ai:rai", Envelopes: envs}},
+				{ObjectMeta: v1.ObjectMeta{Name: "vision", Namespace: "vision"}, Spec: v1.BudgetSpec{Owner: "org:ai:mm:vision", Envelopes: []v1.BudgetEnvelope{{
+					Name: "west-h100", Flavor: "H100-80GB", Selector: sel(), Concurrency: 64,
+					Lending: &v1.LendingPolicy{Allow: true, To: []string{"org:ai:rai"}, MaxConcurrency: i32(8)}, Start: &testWindowStart, End: &testWindowEnd,
+				}}}},
+			},
+			Nodes: []topology.SourceNode{node("node-a", 32), node("node-b", 32)},
+			Run: &v1.Run{
+				ObjectMeta: v1.ObjectMeta{Name: "train", Namespace: "default"},
+				Spec: v1.RunSpec{Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: gpus},
+					Funding: &v1.RunFunding{AllowBorrow: true, Sponsors: []string{"org:ai:mm:vision"}}},
+			},
+		}
+		in.Runs = map[string]*v1.Run{"default/train": in.Run}
+		return in
+	}
+	h100 := func(concurrency int32) []v1.BudgetEnvelope {
+		return []v1.BudgetEnvelope{{Name: "west-h100", Flavor: "H100-80GB", Selector: sel(), Concurrency: concurrency, Start: &testWindowStart, End: &testWindowEnd}}
+	}
+	a100 := []v1.BudgetEnvelope{{Name: "west-a100", Flavor: "A100-80GB", Selector: sel(), Concurrency: 32, Start: &testWindowStart, End: &testWindowEnd}}
+
+	cases := []struct {
+		name string
+		in   Input
+		want string
+	}{
+		{"fits", world(h100(24), 32), "would start now on H100-80GB as 24 Owned + 8 Borrowed"},
+		{"no envelope", world(a100, 16), "no envelope for flavor H100-80GB in namespace default"},
+		{"too wide", world(h100(16), 48), "exceeds every envelope's concurrency: 48 GPUs of H100-80GB requested, the largest of org:ai:rai's envelopes grants 16"},
+		{"no placement", world(h100(128), 96), "no placement for 96 GPUs of H100-80GB fits now"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := Preview(tc.in)
+			if len(got) != 1 || !strings.HasPrefix(got[0], tc.want) {
+				t.Fatalf("preview = %q, want one line starting %q", got, tc.want)
+			}
+		})
+	}
+
+	follows := world(h100(24), 8)
+	follows.Run.Spec.Follow = &v1.RunFollow{After: []string{"prep"}}
+	if got := Preview(follows); len(got) != 2 || !strings.HasPrefix(got[0], "waits for prep") {
+		t.Errorf("follower preview = %q, want a waits-for line before the funding line", got)
+	}
+}
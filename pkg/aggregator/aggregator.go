@@ -0,0 +1,376 @@
+// Package aggregator enforces aggregate caps across clusters (M10).
+//
+// Each cluster's funding derivation bounds an AggregateCap by its own leases
+// only, so clusters sharing one org budget can each fill the cap. The
+// aggregator folds every cluster's derivation into one global width per cap and,
+// when the sum exceeds the cap, apportions the excess as RemedyDirectives each
+// cluster executes with its own resolver. It is pure: cmd/aggregator supplies
+// the replicated state and writes the directives back.
+package aggregator
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+)
+
+// AnnotationBreachShares records on every directive of one breach the whole
+// apportionment, as cluster=gpus pairs. A pass that wrote only some of the set
+// leaves the cap outstanding; the next pass reads the set back from any
+// directive that landed and re-issues the ones that did not.
+const AnnotationBreachShares = "rq.davidlangworthy.io/breach-shares"
+
+// ClusterView is one cluster's replicated state as the aggregator ingests it.
+type ClusterView struct {
+	Name    string
+	Budgets []v1.Budget
+	Leases  []v1.GPULease
+	Runs    map[string]*v1.Run
+	// Archives are the cluster's compacted ledger windows.
+	Archives []v1.LeaseArchive
+	// Transfers are the cluster's published, compiled BudgetTransfers.
+	Transfers []v1.SnapshotTransfer
+	// Directives are the RemedyDirectives already written into this cluster.
+	Directives []v1.RemedyDirective
+	// Scope is copied into every directive issued to this cluster; empty means
+	// the resolver may cut anywhere in it.
+	Scope map[string]string
+}
+
+// Input is everything one aggregation pass reads.
+type Input struct {
+	Clusters []ClusterView
+	Now      time.Time
+	// Period is the accounting horizon each cluster's derivation runs with.
+	Period time.Duration
+}
+
+// CapUsage is the global view of one aggregate cap.
+type CapUsage struct {
+	Namespace string
+	Budget    string
+	Cap       string
+	Flavor    string
+	Envelopes []string
+	// MaxConcurrency is the smallest bound any cluster declares for the cap:
+	// replicas of one budget should agree, and when they do not the tighter one
+	// is the one somebody meant.
+	MaxConcurrency int32
+	// Width is the funded width summed over clusters; ByCluster splits it.
+	Width     int32
+	ByCluster map[string]int32
+	// Outstanding is set while any cluster has yet to answer a directive for
+	// this cap. No new breach is directed until it clears: the widths it was
+	// computed from are about to change.
+	Outstanding bool
+
+	// breach is one of the cap's outstanding directives; held names the
+	// clusters already holding a directive of that breach.
+	breach *v1.RemedyDirective
+	held   map[string]bool
+}
+
+// Excess is the width above the cap, or zero.
+func (u CapUsage) Excess() int32 {
+	if u.Width <= u.MaxConcurrency {
+		return 0
+	}
+	return u.Width - u.MaxConcurrency
+}
+
+// Directive pairs a RemedyDirective with the cluster it must be written to.
+type Directive struct {
+	Cluster   string
+	Directive v1.RemedyDirective
+}
+
+// Result is the aggregation outcome: the global usage of every bounded cap, and
+// the directives that bring the breached ones back under.
+type Result struct {
+	Usage      []CapUsage
+	Directives []Directive
+}
+
+// Evaluate computes global usage per aggregate cap and the remedy directives for
+// every breached cap that has none outstanding. Caps without a MaxConcurrency are
+// not reported: there is nothing to enforce.
+func Evaluate(in Input) Result {
+	clusters := append([]ClusterView(nil), in.Clusters...)
+	sort.Slice(clusters, func(i, j int) bool { return clusters[i].Name < clusters[j].Name })
+
+	usage := make(map[string]*CapUsage)
+	var order []string
+	for _, cluster := range clusters {
+		ev := funding.Evaluate(funding.Input{
+			Budgets:   cluster.Budgets,
+			Leases:    cluster.Leases,
+			Runs:      cluster.Runs,
+			Now:       in.Now,
+			Period:    in.Period,
+			Archives:  cluster.Archives,
+			Transfers: cluster.Transfers,
+		})
+		for i := range cluster.Budgets {
+			budget := &cluster.Budgets[i]
+			if len(budget.Spec.AggregateCaps) == 0 {
+				continue
+			}
+			widths := make(map[string]int32)
+			for _, agg := range ev.Aggregates(budget.Name) {
+				widths[agg.Name] = agg.FundedWidth
+			}
+			for _, spec := range budget.Spec.AggregateCaps {
+				if spec.MaxConcurrency == nil {
+					continue
+				}
+				key := capKey(budget.Namespace, budget.Name, spec.Name)
+				u, ok := usage[key]
+				if !ok {
+					u = &CapUsage{
+						Namespace:      budget.Namespace,
+						Budget:         budget.Name,
+						Cap:            spec.Name,
+						Flavor:         spec.Flavor,
+						Envelopes:      append([]string(nil), spec.Envelopes...),
+						MaxConcurrency: *spec.MaxConcurrency,
+						ByCluster:      make(map[string]int32),
+					}
+					usage[key] = u
+					order = append(order, key)
+				}
+				if *spec.MaxConcurrency < u.MaxConcurrency {
+					u.MaxConcurrency = *spec.MaxConcurrency
+				}
+				u.Width += widths[spec.Name]
+				u.ByCluster[cluster.Name] += widths[spec.Name]
+			}
+		}
+	}
+	// Directives are matched only once every cluster has folded: a cap first
+	// declared by a later cluster is still gated by an earlier one's directive.
+	for _, cluster := range clusters {
+		for i := range cluster.Directives {
+			d := &cluster.Directives[i]
+			if u, ok := usage[capKey(d.Namespace, d.Spec.Budget, d.Spec.Cap)]; ok && d.Outstanding() {
+				u.Outstanding = true
+				if u.breach == nil {
+					u.breach = d
+				}
+			}
+		}
+	}
+	for _, u := range usage {
+		if u.breach == nil {
+			continue
+		}
+		u.held = make(map[string]bool)
+		for _, cluster := range clusters {
+			for _, d := range cluster.Directives {
+				if d.Name == u.breach.Name && d.Namespace == u.breach.Namespace {
+					u.held[cluster.Name] = true
+				}
+			}
+		}
+	}
+	sort.Strings(order)
+
+	var result Result
+	scopes := make(map[string]map[string]string, len(clusters))
+	for _, cluster := range clusters {
+		scopes[cluster.Name] = cluster.Scope
+	}
+	for _, key := range order {
+		u := usage[key]
+		result.Usage = append(result.Usage, *u)
+		if u.Outstanding {
+			result.Directives = append(result.Directives, missingDirectives(u, scopes)...)
+			continue
+		}
+		if u.Excess() == 0 {
+			continue
+		}
+		seed := breachSeed(u, in.Now)
+		// metav1.Time serializes at second precision, and the resolver's seed is a
+		// function of IssuedAt: a sub-second instant would not survive the round
+		// trip through the API, and each cluster would draw from a different seed.
+		spec := v1.RemedyDirectiveSpec{
+			Budget:    u.Budget,
+			Cap:       u.Cap,
+			Envelopes: append([]string(nil), u.Envelopes...),
+			Flavor:    u.Flavor,
+			Seed:      seed,
+			IssuedAt:  v1.NewTime(in.Now.Truncate(time.Second)),
+		}
+		shares := apportion(u.Excess(), u.ByCluster)
+		for _, share := range shares {
+			result.Directives = append(result.Directives, directiveFor(u.Namespace, spec, share, shares, scopes))
+		}
+	}
+	return result
+}
+
+// missingDirectives re-issues the directives of an outstanding breach that
+// never landed, from the apportionment its landed directives record. They are
+// the same objects the first pass meant to write: same name, seed and share.
+func missingDirectives(u *CapUsage, scopes map[string]map[string]string) []Directive {
+	if u.breach == nil {
+		return nil
+	}
+	shares := parseShares(u.breach.Annotations[AnnotationBreachShares])
+	var out []Directive
+	for _, share := range shares {
+		if u.held[share.cluster] {
+			continue
+		}
+		if _, member := scopes[share.cluster]; !member {
+			continue
+		}
+		out = append(out, directiveFor(u.breach.Namespace, u.breach.Spec, share, shares, scopes))
+	}
+	return out
+}
+
+// directiveFor is share's directive of the breach spec describes.
+func directiveFor(namespace string, spec v1.RemedyDirectiveSpec, share clusterShare, shares []clusterShare, scopes map[string]map[string]string) Directive {
+	spec.Envelopes = append([]string(nil), spec.Envelopes...)
+	spec.Scope = copyScope(scopes[share.cluster])
+	spec.Deficit = share.gpus
+	return Directive{
+		Cluster: share.cluster,
+		Directive: v1.RemedyDirective{
+			ObjectMeta: v1.ObjectMeta{
+				Name:        directiveName(spec.Budget, spec.Seed),
+				Namespace:   namespace,
+				Annotations: map[string]string{AnnotationBreachShares: formatShares(shares)},
+			},
+			Spec: spec,
+		},
+	}
+}
+
+func formatShares(shares []clusterShare) string {
+	parts := make([]string, 0, len(shares))
+	for _, share := range shares {
+		parts = append(parts, fmt.Sprintf("%s=%d", share.cluster, share.gpus))
+	}
+	return strings.Join(parts, ",")
+}
+
+// parseShares reads formatShares back. A malformed pair is skipped: it can
+// only cost the re-issue of one directive, never add a cut.
+func parseShares(value string) []clusterShare {
+	var shares []clusterShare
+	for _, pair := range strings.Split(value, ",") {
+		name, gpus, ok := strings.Cut(pair, "=")
+		n, err := strconv.ParseInt(gpus, 10, 32)
+		if !ok || name == "" || err != nil || n <= 0 {
+			continue
+		}
+		shares = append(shares, clusterShare{cluster: name, gpus: int32(n)})
+	}
+	return shares
+}
+
+type clusterShare struct {
+	cluster string
+	gpus    int32
+}
+
+// apportion splits excess across clusters in proportion to the width each holds
+// under the cap, by largest remainder; ties go to the cluster name first in order
+// so every replay of the same observation issues the same directives. No cluster
+// is asked for more than it holds.
+func apportion(excess int32, byCluster map[string]int32) []clusterShare {
+	var total int64
+	names := make([]string, 0, len(byCluster))
+	for name, width := range byCluster {
+		if width <= 0 {
+			continue
+		}
+		total += int64(width)
+		names = append(names, name)
+	}
+	if total == 0 || excess <= 0 {
+		return nil
+	}
+	sort.Strings(names)
+	if int64(excess) > total {
+		excess = int32(total)
+	}
+	shares := make([]clusterShare, len(names))
+	remainders := make([]int64, len(names))
+	var assigned int32
+	for i, name := range names {
+		exact := int64(excess) * int64(byCluster[name])
+		shares[i] = clusterShare{cluster: name, gpus: int32(exact / total)}
+		remainders[i] = exact % total
+		assigned += shares[i].gpus
+	}
+	idx := make([]int, len(names))
+	for i := range idx {
+		idx[i] = i
+	}
+	sort.SliceStable(idx, func(a, b int) bool { return remainders[idx[a]] > remainders[idx[b]] })
+	for _, i := range idx {
+		if assigned >= excess {
+			break
+		}
+		if shares[i].gpus < byCluster[shares[i].cluster] {
+			shares[i].gpus++
+			assigned++
+		}
+	}
+	out := shares[:0]
+	for _, share := range shares {
+		if share.gpus > 0 {
+			out = append(out, share)
+		}
+	}
+	return out
+}
+
+// breachSeed is the seed every directive for one breach shares. It commits to the
+// cap, the observed per-cluster widths, and the instant, so an auditor holding the
+// aggregator's log can recompute it.
+func breachSeed(u *CapUsage, now time.Time) string {
+	names := make([]string, 0, len(u.ByCluster))
+	for name := range u.ByCluster {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	payload := fmt.Sprintf("%s|%d", capKey(u.Namespace, u.Budget, u.Cap), now.Truncate(time.Second).Unix())
+	for _, name := range names {
+		payload += fmt.Sprintf("|%s=%d", name, u.ByCluster[name])
+	}
+	digest := sha256.Sum256([]byte(payload))
+	return "0x" + hex.EncodeToString(digest[:8])
+}
+
+// directiveName is the idempotency key: re-issuing the same breach in the same
+// cluster collides on it instead of cutting twice.
+func directiveName(budget, seed string) string {
+	return fmt.Sprintf("%s-%s", budget, seed[2:14])
+}
+
+func capKey(namespace, budget, cap string) string {
+	return keys.NamespacedKey(namespace, budget) + "/" + cap
+}
+
+func copyScope(scope map[string]string) map[string]string {
+	if len(scope) == 0 {
+		return nil
+	}
+	out := make(map[string]string, len(scope))
+	for k, v := range scope {
+		out[k] = v
+	}
+	return out
+}
@@ -0,0 +1,77 @@
+package aggregator
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func TestApportionIsProportionalAndBoundedByWidth(t *testing.T) {
+	cases := []struct {
+		name      string
+		excess    int32
+		byCluster map[string]int32
+		want      []clusterShare
+	}{
+		{"even", 6, map[string]int32{"a": 4, "b": 4, "c": 4}, []clusterShare{{"a", 2}, {"b", 2}, {"c", 2}}},
+		{"proportional", 3, map[string]int32{"a": 8, "b": 4}, []clusterShare{{"a", 2}, {"b", 1}}},
+		{"remainder to first name on ties", 1, map[string]int32{"a": 2, "b": 2}, []clusterShare{{"a", 1}}},
+		{"never above what a cluster holds", 10, map[string]int32{"a": 3, "b": 1}, []clusterShare{{"a", 3}, {"b", 1}}},
+		{"idle clusters are not asked", 2, map[string]int32{"a": 4, "b": 0}, []clusterShare{{"a", 2}}},
+	}
+	for _, tc := range cases {
+		if got := apportion(tc.excess, tc.byCluster); !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s: apportion(%d, %v) = %v, want %v", tc.name, tc.excess, tc.byCluster, got, tc.want)
+		}
+	}
+}
+
+// Replicas of one budget that disagree on a cap are held to the tighter bound,
+// and a cap with no bound is not reported at all.
+func TestEvaluateTakesTheTightestDeclaredBound(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	budget := func(max *int32) v1.Budget {
+		start := v1.NewTime(now.Add(-time.Hour))
+		end := v1.NewTime(now.Add(time.Hour))
+		return v1.Budget{
+			ObjectMeta: v1.ObjectMeta{Name: "org", Namespace: "default"},
+			Spec: v1.BudgetSpec{
+				Owner:     "org:ai",
+				Envelopes: []v1.BudgetEnvelope{{Name: "h100", Flavor: "H100", Concurrency: 8, Start: &start, End: &end}},
+				AggregateCaps: []v1.AggregateCap{
+					{Name: "bounded", Flavor: "H100", Envelopes: []string{"h100"}, MaxConcurrency: max},
+					{Name: "unbounded", Flavor: "H100", Envelopes: []string{"h100"}},
+				},
+			},
+		}
+	}
+	four, six := int32(4), int32(6)
+	result := Evaluate(Input{Now: now, Clusters: []ClusterView{
+		{Name: "a", Budgets: []v1.Budget{budget(&six)}},
+		{Name: "b", Budgets: []v1.Budget{budget(&four)}},
+	}})
+	if len(result.Usage) != 1 || result.Usage[0].Cap != "bounded" {
+		t.Fatalf("usage = %+v, want only the bounded cap", result.Usage)
+	}
+	if got := result.Usage[0].MaxConcurrency; got != 4 {
+		t.Errorf("MaxConcurrency = %d, want the tighter 4", got)
+	}
+	if len(result.Directives) != 0 {
+		t.Errorf("an idle cap issued %d directive(s)", len(result.Directives))
+	}
+}
+
+func TestBreachSeedCommitsToTheObservation(t *testing.T) {
+	now := time.Date(2024, 1, 1, 10, 0, 0, 0, time.UTC)
+	u := &CapUsage{Namespace: "default", Budget: "org", Cap: "c", ByCluster: map[string]int32{"a": 4, "b": 4}}
+	seed := breachSeed(u, now)
+	if breachSeed(u, now.Add(500*time.Millisecond)) != seed {
+		t.Errorf("the seed must survive the API's second-precision round trip")
+	}
+	u.ByCluster["b"] = 3
+	if breachSeed(u, now) == seed {
+		t.Errorf("a different observation must not reuse the seed")
+	}
+}
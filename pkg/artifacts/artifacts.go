@@ -0,0 +1,57 @@
+// Package artifacts locates a Run's outputs. jobtree does not provide storage:
+// by convention a role mounts a volume at MountPath and writes its outputs
+// there. This package reads that location back out of the role templates, for
+// the CLI and for handing an upstream's outputs to the runs that follow it.
+package artifacts
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// MountPath is the conventional path a role writes its outputs under
+// (checkpoints, logs worth keeping, the trained model).
+const MountPath = "/artifacts"
+
+// URI names the durable volume a run mounts writable at MountPath, as
+// pvc://claim, nfs://server/path, or csi://driver. The first role that mounts
+// one wins. It is empty when no role does, or when the volume does not outlive
+// its pod (emptyDir, hostPath): a downstream run could not read it anyway.
+func URI(run *v1.Run) string {
+	if run == nil {
+		return ""
+	}
+	for ri := range run.Spec.Roles {
+		pod := &run.Spec.Roles[ri].Template.Spec
+		for ci := range pod.Containers {
+			for _, m := range pod.Containers[ci].VolumeMounts {
+				if m.ReadOnly || strings.TrimSuffix(m.MountPath, "/") != MountPath {
+					continue
+				}
+				for _, vol := range pod.Volumes {
+					if vol.Name == m.Name {
+						if uri := volumeURI(vol); uri != "" {
+							return uri
+						}
+					}
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func volumeURI(v corev1.Volume) string {
+	switch {
+	case v.PersistentVolumeClaim != nil:
+		return "pvc://" + v.PersistentVolumeClaim.ClaimName
+	case v.NFS != nil:
+		return "nfs://" + v.NFS.Server + "/" + strings.TrimPrefix(v.NFS.Path, "/")
+	case v.CSI != nil:
+		return "csi://" + v.CSI.Driver
+	}
+	return ""
+}
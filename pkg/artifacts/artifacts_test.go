@@ -0,0 +1,39 @@
+package artifacts
+
+import (
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func runMounting(readOnly bool, path string, src corev1.VolumeSource) *v1.Run {
+	return &v1.Run{Spec: v1.RunSpec{Roles: []v1.RunRole{{Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{
+		Containers: []corev1.Container{{Name: "main", VolumeMounts: []corev1.VolumeMount{{Name: "out", MountPath: path, ReadOnly: readOnly}}}},
+		Volumes:    []corev1.Volume{{Name: "out", VolumeSource: src}},
+	}}}}}}
+}
+
+func TestURI(t *testing.T) {
+	pvc := corev1.VolumeSource{PersistentVolumeClaim: &corev1.PersistentVolumeClaimVolumeSource{ClaimName: "ckpt"}}
+	cases := []struct {
+		name string
+		run  *v1.Run
+		want string
+	}{
+		{"pvc", runMounting(false, MountPath, pvc), "pvc://ckpt"},
+		{"trailing slash", runMounting(false, MountPath+"/", pvc), "pvc://ckpt"},
+		{"nfs", runMounting(false, MountPath, corev1.VolumeSource{NFS: &corev1.NFSVolumeSource{Server: "fs1", Path: "/exports/run"}}), "nfs://fs1/exports/run"},
+		{"csi", runMounting(false, MountPath, corev1.VolumeSource{CSI: &corev1.CSIVolumeSource{Driver: "s3.csi.aws.com"}}), "csi://s3.csi.aws.com"},
+		{"emptyDir does not outlive the pod", runMounting(false, MountPath, corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}), ""},
+		{"read-only is an input", runMounting(true, MountPath, pvc), ""},
+		{"off convention", runMounting(false, "/outputs", pvc), ""},
+		{"nil run", nil, ""},
+	}
+	for _, tc := range cases {
+		if got := URI(tc.run); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
@@ -8,6 +8,7 @@ import (
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/pkg/cover"
 	"github.com/davidlangworthy/jobtree/pkg/pack"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
 
 const (
@@ -17,6 +18,13 @@ const (
 	LabelGroupIndex = "rq.davidlangworthy.io/group-index"
 	// LabelRunRole marks whether a pod is active or a spare.
 	LabelRunRole = "rq.davidlangworthy.io/role"
+	// LabelRoleName names the RunRole a pod (and the lease minted for it)
+	// belongs to. It is stamped only on multi-role runs, where the bridge
+	// needs it to pick the role's template and the funding derivation to
+	// attribute width per role; a single-role run has nothing to tell apart.
+	// LabelRunRole keeps meaning Active/Spare — the slice fact — so no
+	// consumer of that label has to learn role names.
+	LabelRoleName = "rq.davidlangworthy.io/role-name"
 	// LabelCohort records, on the minted LEASE, the admission cohort the pod
 	// belonged to (base gang "0", or an elastic-grow step). Until this, a Lease
 	// carried no cohort — LeaseReasonGrow was the only (indirect) proxy — so gang
@@ -44,7 +52,8 @@ const (
 	// nvidia.com/gpu request. Kept string-typed for the annotation map.
 	AnnotationGPUs = "rq.davidlangworthy.io/gpus"
 	// AnnotationExpectedWidth is the gang's target Active pod count
-	// (== RunRole.Width). Permit gates on all this many members being
+	// (== RunRole.Width; a multi-role gang's is the sum over its roles, since
+	// every role's pods are members of the one gang). Permit gates on all this many members being
 	// simultaneously waiting before it commits funding — reimplementing
 	// PodGroup.minMember's purpose without a PodGroup CRD.
 	AnnotationExpectedWidth = "rq.davidlangworthy.io/expected-width"
@@ -101,6 +110,13 @@ const (
 	// adopt-at-width check must exclude it: grow width can otherwise stand in for
 	// missing base-gang width and adopt a run whose gang never assembled (R2).
 	LeaseReasonGrow = "Grow"
+	// LeaseReasonDefrag closes the leases of a run the defragmenter moved out of
+	// a fast-fabric domain, and marks the leases it is minted on elsewhere when
+	// it re-admits from its checkpoint.
+	LeaseReasonDefrag = "Defrag"
+	// LeaseReasonTimeLimit closes the leases of a run that reached its
+	// spec.runtime.maxDuration.
+	LeaseReasonTimeLimit = "TimeLimit"
 	// AnnotationRunNonce carries a per-incarnation identifier of the owning Run
 	// (its UID) into the Lease name the plugin mints. Pod names are deterministic,
 	// so without it a delete+resubmit of a same-named Run would have PreBind's
@@ -110,6 +126,16 @@ const (
 	// R2). A fresh UID per incarnation makes the lease name unique per incarnation
 	// while staying deterministic across PreBind retries of the same one.
 	AnnotationRunNonce = "rq.davidlangworthy.io/run-nonce"
+	// AnnotationDrainDeadline is patched onto a checkpointed run's pod just
+	// before the bridge deletes it for a resolver cut: the RFC 3339 instant the
+	// graceful deletion's grace runs out. A workload that projects its
+	// annotations through the downward API (or reads them in a preStop hook)
+	// learns how long it has to write its checkpoint.
+	AnnotationDrainDeadline = "rq.davidlangworthy.io/drain-deadline"
+	// AnnotationTimeLimit is patched onto a running run's pods when it is
+	// warned of its spec.runtime.maxDuration: the RFC 3339 instant its leases
+	// close. A workload that watches its annotations checkpoints before then.
+	AnnotationTimeLimit = "rq.davidlangworthy.io/time-limit"
 )
 
 // LeaseCohort is the admission cohort a lease was minted for — the base gang is the
@@ -123,6 +149,12 @@ func LeaseCohort(lease *v1.GPULease) string {
 	return "0"
 }
 
+// LeaseRoleName is the RunRole a lease funds, or "" when the run has a single
+// role (or none) and the lease was never stamped with one.
+func LeaseRoleName(lease *v1.GPULease) string {
+	return lease.Labels[LabelRoleName]
+}
+
 // LeasePodName is the pod a lease was minted for, or "" on an unstamped legacy lease.
 func LeasePodName(lease *v1.GPULease) string {
 	return lease.Annotations[AnnotationPodName]
@@ -164,6 +196,13 @@ const PodPhaseFailed = "Failed"
 // Run.status.eta. Optional and observability only.
 const EtaAnnotation = "rq.davidlangworthy.io/eta"
 
+// ThroughputAnnotation is the pod annotation a malleable workload sets to report
+// the gang's current throughput (any positive decimal, in whatever unit the
+// workload counts — samples/s, tokens/s). One member, conventionally rank 0,
+// reports it; the run controller records it on Run.status.goodput and sizes the
+// run by it when spec.malleable.goodput is set.
+const ThroughputAnnotation = "rq.davidlangworthy.io/throughput"
+
 // PodManifest captures the minimal data needed to create a pod-like workload.
 // Phase is populated only for pods loaded from the cluster (empty for pods the
 // binder is about to create); the run controller reads it to detect gang
@@ -229,6 +268,7 @@ func Materialize(req Request) (Result, error) {
 		if allocated != group.Size {
 			return Result{}, fmt.Errorf("placement allocation mismatch for group %d", group.GroupIndex)
 		}
+		m.roleName = group.Role
 		segments, err = m.assign(group.GroupIndex+req.GroupIndexOffset, group.NodePlacements, segments, "")
 		if err != nil {
 			return Result{}, err
@@ -249,6 +289,7 @@ func Materialize(req Request) (Result, error) {
 		if allocated != group.Spares {
 			return Result{}, fmt.Errorf("spare allocation mismatch for group %d", group.GroupIndex)
 		}
+		m.roleName = group.Role
 		segments, err = m.assign(group.GroupIndex+req.GroupIndexOffset, group.SparePlacements, segments, RoleSpare)
 		if err != nil {
 			return Result{}, err
@@ -282,8 +323,11 @@ type materializer struct {
 	now    time.Time
 	reason string
 	seq    int
-	pods   []PodManifest
-	leases []v1.GPULease
+	// roleName is the RunRole of the group being assigned (pack.PlanGang
+	// plans), stamped as LabelRoleName; empty for a single-role plan.
+	roleName string
+	pods     []PodManifest
+	leases   []v1.GPULease
 }
 
 // assign walks allocation chunks and cover segments as two cursors. Each
@@ -356,6 +400,9 @@ func (m *materializer) buildPod(groupIndex int, slots []nodeSlot, role string) P
 		LabelGroupIndex: fmt.Sprintf("%d", groupIndex),
 		LabelRunRole:    role,
 	}
+	if m.roleName != "" {
+		labels[LabelRoleName] = m.roleName
+	}
 	return PodManifest{
 		Namespace: m.run.Namespace,
 		Name:      fmt.Sprintf("%s-g%02d-%s-%s-%d", m.run.Name, groupIndex, strings.ToLower(role), nodeName, m.seq),
@@ -370,6 +417,15 @@ func (m *materializer) buildLease(groupIndex int, slots []nodeSlot, seg cover.Se
 	for i, slot := range slots {
 		nodes[i] = fmt.Sprintf("%s#%d", slot.node, slot.ordinal)
 	}
+	labels := map[string]string{
+		LabelRunName:    m.run.Name,
+		LabelGroupIndex: fmt.Sprintf("%d", groupIndex),
+		LabelRunRole:    role,
+	}
+	if m.roleName != "" {
+		labels[LabelRoleName] = m.roleName
+	}
+	_, profile, _ := topology.MIGProfile(m.run.Flavor())
 	return v1.GPULease{
 		ObjectMeta: v1.ObjectMeta{
 			Namespace: m.run.Namespace,
@@ -377,12 +433,8 @@ func (m *materializer) buildLease(groupIndex int, slots []nodeSlot, seg cover.Se
 			// budgets); the seeded sequence number makes names unique within
 			// and across materializations, and the nanosecond timestamp is a
 			// second line of defense should a caller reuse a seed.
-			Name: fmt.Sprintf("%s-g%02d-%s-%s-%d-%d", m.run.Name, groupIndex, seg.BudgetName, seg.EnvelopeName, m.now.UnixNano(), m.seq),
-			Labels: map[string]string{
-				LabelRunName:    m.run.Name,
-				LabelGroupIndex: fmt.Sprintf("%d", groupIndex),
-				LabelRunRole:    role,
-			},
+			Name:   fmt.Sprintf("%s-g%02d-%s-%s-%d-%d", m.run.Name, groupIndex, seg.BudgetName, seg.EnvelopeName, m.now.UnixNano(), m.seq),
+			Labels: labels,
 		},
 		Spec: v1.GPULeaseSpec{
 			Owner: seg.Owner,
@@ -391,8 +443,9 @@ func (m *materializer) buildLease(groupIndex int, slots []nodeSlot, seg cover.Se
 				Namespace: m.run.Namespace,
 			},
 			Slice: v1.GPULeaseSlice{
-				Nodes: nodes,
-				Role:  role,
+				Nodes:   nodes,
+				Role:    role,
+				Profile: profile,
 			},
 			Interval: v1.GPULeaseInterval{
 				Start: v1.NewTime(m.now),
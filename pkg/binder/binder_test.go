@@ -199,3 +199,41 @@ func TestMaterializeAppliesOffsetAndReason(t *testing.T) {
 		t.Fatalf("expected lease name to include offset index, got %s", res.Leases[0].Name)
 	}
 }
+
+func TestMaterializeStampsRoleNamePerGangGroup(t *testing.T) {
+	run := &v1.Run{}
+	run.Name = "rlhf"
+	run.Namespace = "default"
+
+	packPlan := pack.Plan{
+		Flavor:    "H100-80GB",
+		TotalGPUs: 6,
+		Groups: []pack.GroupPlacement{
+			{GroupIndex: 0, Role: "learner", Size: 4, NodePlacements: []pack.NodeAllocation{{Node: "node-a", GPUs: 4}}},
+			{GroupIndex: 1, Role: "actor", Size: 2, NodePlacements: []pack.NodeAllocation{{Node: "node-b", GPUs: 2}}},
+		},
+	}
+	coverPlan := cover.Plan{Segments: []cover.Segment{
+		{BudgetName: "rai", EnvelopeName: "core", Owner: "org:ai:rai", Quantity: 6},
+	}}
+
+	res, err := Materialize(Request{Run: run, PackPlan: packPlan, CoverPlan: coverPlan, Now: time.Unix(0, 0)})
+	if err != nil {
+		t.Fatalf("materialize failed: %v", err)
+	}
+	want := []string{"learner", "actor"}
+	if len(res.Pods) != len(want) || len(res.Leases) != len(want) {
+		t.Fatalf("expected %d pods and leases, got %d and %d", len(want), len(res.Pods), len(res.Leases))
+	}
+	for i, role := range want {
+		if got := res.Pods[i].Labels[LabelRoleName]; got != role {
+			t.Errorf("pod %d: role-name %q, want %q", i, got, role)
+		}
+		if got := LeaseRoleName(&res.Leases[i]); got != role {
+			t.Errorf("lease %d: role-name %q, want %q", i, got, role)
+		}
+		if res.Leases[i].Spec.Slice.Role != RoleActive {
+			t.Errorf("lease %d: slice role %q, want the Active slice fact", i, res.Leases[i].Spec.Slice.Role)
+		}
+	}
+}
@@ -2,7 +2,8 @@
 // admission-side view of the one funding derivation (pkg/funding): the
 // planner walks envelopes in the proximity order of quota-semantics.md
 // Decision 2 — own first, then parents, siblings, cousins (family excess
-// needs no lending policy), then sponsors under their lending contracts —
+// needs no lending policy), then envelopes transferred to the owner, then
+// sponsors under their lending contracts —
 // same-location before cross-location at each tier, and asks the
 // evaluation how much width the prospective claim could get funded. The
 // phase order and the ranking function agree by construction: both walk
@@ -24,6 +25,9 @@ type Request struct {
 	Quantity int32
 	Location map[string]string
 	Now      time.Time
+	// Priority is the run's requested spec.priority; each envelope ranks the
+	// claim at the part of it that envelope authorizes.
+	Priority int32
 	// Admitted ranks the prospective claim: a run keeps the rank of its
 	// original admission when it grows. Zero means "now".
 	Admitted time.Time
@@ -95,11 +99,16 @@ type phase struct {
 	requireSame  bool
 	requireOther bool
 	sponsor      bool
+	// transferred restricts a phase to the envelopes a BudgetTransfer lends
+	// to the run's owner. Width drawn there is borrowed like a sponsor's, so
+	// the run's borrow opt-in and cap hold for it too.
+	transferred map[funding.EnvelopeKey]bool
 }
 
 // buildPhases emits the proximity-major walk. Within each family tier the
 // same-location pass precedes the cross-location pass (Decision 2);
-// sponsors come last and only when the run opts into borrowing.
+// transfers follow the family and sponsors come last, both only when the run
+// opts into borrowing.
 func (inv *Inventory) buildPhases(req Request) []phase {
 	graph := inv.eval.Graph
 	tiers := [][]string{
@@ -118,7 +127,24 @@ func (inv *Inventory) buildPhases(req Request) []phase {
 			phase{owners: owners, requireOther: true},
 		)
 	}
-	if req.AllowBorrow && len(req.Sponsors) > 0 {
+	if !req.AllowBorrow {
+		return phases
+	}
+	if keys := inv.eval.TransfersTo(req.Owner); len(keys) > 0 {
+		transferred := make(map[funding.EnvelopeKey]bool, len(keys))
+		var lenders []string
+		for _, key := range keys {
+			transferred[key] = true
+			if acct := inv.eval.Envelope(key); acct != nil {
+				lenders = append(lenders, acct.Owner)
+			}
+		}
+		phases = append(phases,
+			phase{owners: lenders, requireSame: true, transferred: transferred},
+			phase{owners: lenders, requireOther: true, transferred: transferred},
+		)
+	}
+	if len(req.Sponsors) > 0 {
 		phases = append(phases,
 			phase{owners: req.Sponsors, requireSame: true, sponsor: true},
 			phase{owners: req.Sponsors, requireOther: true, sponsor: true},
@@ -142,7 +168,7 @@ func (inv *Inventory) Plan(req Request) (Plan, error) {
 		req.Admitted = req.Now
 	}
 
-	admission := inv.eval.NewAdmission(req.Owner, req.Admitted, req.RunKey)
+	admission := inv.eval.NewAdmission(req.Owner, req.Priority, req.Admitted, req.RunKey)
 	remaining := req.Quantity
 	borrowedTotal := int32(0)
 	borrowAttempted := false
@@ -164,6 +190,9 @@ func (inv *Inventory) Plan(req Request) (Plan, error) {
 				if acct.Spec.Flavor != req.Flavor {
 					continue
 				}
+				if ph.transferred != nil && !ph.transferred[acct.Key] {
+					continue
+				}
 				sameLocation := matchesLocation(acct.Spec.Selector, req.Location)
 				if ph.requireSame && !sameLocation {
 					continue
@@ -174,7 +203,7 @@ func (inv *Inventory) Plan(req Request) (Plan, error) {
 				if !windowAllowsAdmission(acct.Spec, req.Now) {
 					continue
 				}
-				borrow := ph.sponsor && acct.Owner != req.Owner
+				borrow := (ph.sponsor || ph.transferred != nil) && acct.Owner != req.Owner
 				if borrow {
 					borrowAttempted = true
 					if !req.AllowBorrow {
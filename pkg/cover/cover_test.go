@@ -215,6 +215,52 @@ func TestPlanRespectsBorrowLimit(t *testing.T) {
 	}
 }
 
+// A transfer's width is borrowed like a sponsor's: a run that does not opt
+// into borrowing never draws on it, and one that does draws no more than its
+// borrow cap.
+func TestPlanHoldsTransfersToTheBorrowGate(t *testing.T) {
+	now := time.Date(2025, 11, 1, 12, 0, 0, 0, time.UTC)
+	ev := funding.Evaluate(funding.Input{
+		Budgets: []v1.Budget{
+			budgetOf("budget-lender", "org:lender", nil, envSpec{name: "env-lender", flavor: "H100", concurrency: 8, selector: map[string]string{"region": "us-west"}}),
+			budgetOf("budget-child", "org:child", nil, envSpec{name: "env-child", flavor: "H100", concurrency: 2, selector: map[string]string{"region": "us-west"}}),
+		},
+		Transfers: []v1.SnapshotTransfer{{
+			Name:        "loan",
+			Lender:      "org:lender",
+			Borrower:    "org:child",
+			From:        v1.TransferSource{Namespace: nsForOwner("org:lender"), Budget: "budget-lender", Envelope: "env-lender"},
+			Flavor:      "H100",
+			Concurrency: 4,
+			Start:       v1.NewTime(now.Add(-time.Hour)),
+			End:         v1.NewTime(now.Add(time.Hour)),
+		}},
+		Now: now,
+	})
+	inv := NewInventory(ev)
+	req := Request{Owner: "org:child", Flavor: "H100", Quantity: 6, Location: map[string]string{"region": "us-west"}, Now: now}
+
+	if _, err := inv.Plan(req); err == nil {
+		t.Fatal("a run that does not allow borrowing drew on a transfer")
+	}
+
+	req.AllowBorrow = true
+	plan, err := inv.Plan(req)
+	if err != nil {
+		t.Fatalf("plan failed: %v", err)
+	}
+	if len(plan.Segments) != 2 || !plan.Segments[1].Borrowed || plan.Segments[1].Quantity != 4 {
+		t.Fatalf("expected the transfer's 4 GPUs borrowed after the owner's 2, got %+v", plan.Segments)
+	}
+
+	limit := int32(2)
+	req.MaxBorrowGPUs = &limit
+	_, err = inv.Plan(req)
+	if pe, ok := err.(*PlanError); !ok || pe.Reason != FailureReasonBorrowLimit {
+		t.Fatalf("expected the borrow cap to stop the transfer, got %v", err)
+	}
+}
+
 type envSpec struct {
 	name        string
 	flavor      string
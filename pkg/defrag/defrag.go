@@ -0,0 +1,269 @@
+// Package defrag opens a fast-fabric domain for a run that the fleet's free
+// GPUs could hold but no single domain of them can: it picks the fewest small,
+// checkpointing runs whose move out of one domain leaves room there, and
+// checks that each of them still fits somewhere once the blocked run is in.
+//
+// It only plans. The caller executes a plan the way every other move is made:
+// the moved run's leases close, it drains to its checkpoint, and it re-admits
+// from it, minting new leases wherever admission then places it.
+package defrag
+
+import (
+	"sort"
+	"strings"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/pack"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// Input is a run blocked on topology and the fleet it is blocked in.
+type Input struct {
+	// Run is the blocked run; Need is the GPUs it takes in one domain,
+	// spares included.
+	Run  *v1.Run
+	Need int
+	// Snapshot is the run's flavor at current usage.
+	Snapshot *topology.Snapshot
+	// Leases are the open leases, owned by Runs.
+	Leases []*v1.GPULease
+	Runs   map[string]*v1.Run
+	// Evaluation classifies Leases. A run holding a funded (Owned or Shared)
+	// lease is moved only for a blocked run that outranks it; nil treats
+	// every lease as funded.
+	Evaluation *funding.Evaluation
+	// Place packs a run onto a snapshot the way admission would.
+	Place func(run *v1.Run, snapshot *topology.Snapshot) (pack.Plan, error)
+}
+
+// Move is one run relocated out of the opened domain.
+type Move struct {
+	Run *v1.Run
+	// GPUs is everything the run holds; Freed is the part of it inside the
+	// opened domain.
+	GPUs  int
+	Freed int
+}
+
+// Plan opens Domain for the blocked run, which then packs as Placement.
+type Plan struct {
+	Domain    topology.DomainKey
+	Moves     []Move
+	Placement pack.Plan
+}
+
+// Movable reports whether the defragmenter may move a run at all: it is
+// running, it checkpoints (a move without one loses its work), and it has not
+// opted out.
+func Movable(run *v1.Run) bool {
+	if run == nil || run.Status.Phase != v1.RunPhaseRunning || run.Spec.Runtime == nil {
+		return false
+	}
+	return run.Spec.Runtime.Checkpoint.Duration > 0 && !run.Spec.Runtime.NoDefrag
+}
+
+// Outranks reports whether a run ranks ahead of other for a move: a higher
+// spec.priority, then the earlier admission, then the lower key.
+func Outranks(run, other *v1.Run) bool {
+	if run.Spec.Priority != other.Spec.Priority {
+		return run.Spec.Priority > other.Spec.Priority
+	}
+	if !run.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return run.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return keys.NamespacedKey(run.Namespace, run.Name) < keys.NamespacedKey(other.Namespace, other.Name)
+}
+
+// holder is a movable run and where its open GPUs sit.
+type holder struct {
+	run    *v1.Run
+	gpus   int
+	byNode map[string]int
+}
+
+// Compute returns the plan that opens a domain with the fewest moves, and the
+// fewest GPUs moved between plans with as many; false when none does. Only
+// runs smaller than the blocked one are moved, so a move never displaces more
+// work than it makes room for, and a funded run only for one that outranks
+// it, so a move never takes a place its holder is owed.
+func Compute(in Input) (Plan, bool) {
+	if in.Run == nil || in.Snapshot == nil || in.Place == nil || in.Need <= 0 {
+		return Plan{}, false
+	}
+	blocked := keys.NamespacedKey(in.Run.Namespace, in.Run.Name)
+	holders := gatherHolders(in, blocked)
+
+	var best Plan
+	bestGPUs, found := 0, false
+	for _, dom := range in.Snapshot.SortedDomains() {
+		short := in.Need - dom.FreeGPUs()
+		if short <= 0 || dom.TotalGPUs() < in.Need {
+			continue
+		}
+		moves := pick(holders, dom, short)
+		if moves == nil {
+			continue
+		}
+		moved := 0
+		for _, m := range moves {
+			moved += m.GPUs
+		}
+		if found && (len(moves) > len(best.Moves) || (len(moves) == len(best.Moves) && moved >= bestGPUs)) {
+			continue
+		}
+		placement, ok := simulate(in, holders, moves)
+		if !ok {
+			continue
+		}
+		best, bestGPUs, found = Plan{Domain: dom.Key, Moves: moves, Placement: placement}, moved, true
+	}
+	return best, found
+}
+
+func gatherHolders(in Input, blocked string) map[string]*holder {
+	holders := map[string]*holder{}
+	funded := map[string]bool{}
+	for _, lease := range in.Leases {
+		if lease == nil || lease.Status.Closed {
+			continue
+		}
+		key := keys.NamespacedKey(lease.Spec.RunRef.Namespace, lease.Spec.RunRef.Name)
+		run := in.Runs[key]
+		if key == blocked || !Movable(run) || run.Flavor() != in.Snapshot.Flavor {
+			continue
+		}
+		if class, ok := classOf(in.Evaluation, lease); !ok || class == funding.ClassOwned || class == funding.ClassShared {
+			funded[key] = true
+		}
+		h := holders[key]
+		if h == nil {
+			h = &holder{run: run, byNode: map[string]int{}}
+			holders[key] = h
+		}
+		for _, slot := range lease.Spec.Slice.Nodes {
+			h.byNode[nodeOf(slot)]++
+			h.gpus++
+		}
+	}
+	for key, h := range holders {
+		if h.gpus >= in.Need || (funded[key] && !Outranks(in.Run, h.run)) {
+			delete(holders, key)
+		}
+	}
+	return holders
+}
+
+// classOf is lease's class under ev; false without one.
+func classOf(ev *funding.Evaluation, lease *v1.GPULease) (funding.Class, bool) {
+	if ev == nil {
+		return "", false
+	}
+	return ev.Class(lease)
+}
+
+// pick takes the runs freeing the most inside dom until they cover short —
+// the fewest that can — then drops any the rest still cover without. nil when
+// the movable runs in dom cannot cover it.
+func pick(holders map[string]*holder, dom *topology.Domain, short int) []Move {
+	var candidates []Move
+	for _, h := range holders {
+		freed := 0
+		for _, node := range dom.Nodes {
+			freed += h.byNode[node.Name]
+		}
+		if freed > 0 {
+			candidates = append(candidates, Move{Run: h.run, GPUs: h.gpus, Freed: freed})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.Freed != b.Freed {
+			return a.Freed > b.Freed
+		}
+		if a.GPUs != b.GPUs {
+			return a.GPUs < b.GPUs
+		}
+		return keyOf(a.Run) < keyOf(b.Run)
+	})
+	var moves []Move
+	covered := 0
+	for _, c := range candidates {
+		if covered >= short {
+			break
+		}
+		moves = append(moves, c)
+		covered += c.Freed
+	}
+	if covered < short {
+		return nil
+	}
+	for i := len(moves) - 1; i >= 0; i-- {
+		if covered-moves[i].Freed >= short {
+			covered -= moves[i].Freed
+			moves = append(moves[:i], moves[i+1:]...)
+		}
+	}
+	return moves
+}
+
+// simulate releases the moved runs, packs the blocked run, and then packs
+// each moved run, largest first, onto what is left.
+func simulate(in Input, holders map[string]*holder, moves []Move) (pack.Plan, bool) {
+	work := in.Snapshot.Clone()
+	nodes := map[string]*topology.Node{}
+	for _, dom := range work.Domains {
+		for _, node := range dom.Nodes {
+			nodes[node.Name] = node
+		}
+	}
+	for _, m := range moves {
+		for name, gpus := range holders[keyOf(m.Run)].byNode {
+			if node := nodes[name]; node != nil {
+				node.Used -= gpus
+				if node.Used < 0 {
+					node.Used = 0
+				}
+			}
+		}
+	}
+	placement, err := in.Place(in.Run, work)
+	if err != nil {
+		return pack.Plan{}, false
+	}
+	occupy(nodes, placement)
+
+	order := append([]Move(nil), moves...)
+	sort.SliceStable(order, func(i, j int) bool { return order[i].GPUs > order[j].GPUs })
+	for _, m := range order {
+		to, err := in.Place(m.Run, work)
+		if err != nil {
+			return pack.Plan{}, false
+		}
+		occupy(nodes, to)
+	}
+	return placement, true
+}
+
+func occupy(nodes map[string]*topology.Node, plan pack.Plan) {
+	for _, g := range plan.Groups {
+		for _, alloc := range g.NodePlacements {
+			nodes[alloc.Node].Used += alloc.GPUs
+		}
+		for _, alloc := range g.SparePlacements {
+			nodes[alloc.Node].Used += alloc.GPUs
+		}
+	}
+}
+
+func keyOf(run *v1.Run) string {
+	return keys.NamespacedKey(run.Namespace, run.Name)
+}
+
+func nodeOf(slot string) string {
+	if i := strings.IndexByte(slot, '#'); i >= 0 {
+		return slot[:i]
+	}
+	return slot
+}
@@ -0,0 +1,145 @@
+package defrag
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/pack"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+const testFlavor = "H100-80GB"
+
+// Three 8-GPU islands of two 4-GPU nodes. island-a holds one 4-GPU run,
+// island-b two 2-GPU runs, island-c a 6-GPU run; 10 GPUs are free, no island
+// has 8 of them.
+
+type fleet struct {
+	runs   map[string]*v1.Run
+	leases []*v1.GPULease
+	nodes  []topology.SourceNode
+}
+
+func newFleet() *fleet {
+	f := &fleet{runs: map[string]*v1.Run{}}
+	for _, island := range []string{"a", "b", "c"} {
+		for i := 1; i <= 2; i++ {
+			f.nodes = append(f.nodes, topology.SourceNode{Name: fmt.Sprintf("%s%d", island, i), GPUs: 4, Labels: map[string]string{
+				topology.LabelRegion: "us-west", topology.LabelCluster: "cluster-a",
+				topology.LabelFabricDomain: "island-" + island, topology.LabelGPUFlavor: testFlavor,
+			}})
+		}
+	}
+	f.running("small-a", "10m", "a1#0", "a1#1", "a1#2", "a1#3")
+	f.running("pair-x", "10m", "b1#0", "b1#1")
+	f.running("pair-y", "10m", "b2#0", "b2#1")
+	f.running("wide-c", "10m", "c1#0", "c1#1", "c1#2", "c1#3", "c2#0", "c2#1")
+	return f
+}
+
+func (f *fleet) running(name, checkpoint string, slots ...string) *v1.Run {
+	run := &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "default"},
+		Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: testFlavor, TotalGPUs: int32(len(slots))}},
+		Status:     v1.RunStatus{Phase: v1.RunPhaseRunning},
+	}
+	if checkpoint != "" {
+		d, _ := time.ParseDuration(checkpoint)
+		run.Spec.Runtime = &v1.RunRuntime{Checkpoint: v1.Duration{Duration: d}}
+	}
+	f.runs[keys.NamespacedKey(run.Namespace, name)] = run
+	f.leases = append(f.leases, &v1.GPULease{
+		ObjectMeta: v1.ObjectMeta{Name: name + "-lease", Namespace: "default"},
+		Spec: v1.GPULeaseSpec{
+			RunRef: v1.RunReference{Name: name, Namespace: "default"},
+			Slice:  v1.GPULeaseSlice{Nodes: slots, Role: "Active"},
+		},
+	})
+	return run
+}
+
+func (f *fleet) input(t *testing.T, need int) Input {
+	t.Helper()
+	usage := map[string]int{}
+	for _, lease := range f.leases {
+		for _, slot := range lease.Spec.Slice.Nodes {
+			usage[nodeOf(slot)]++
+		}
+	}
+	snapshot, err := topology.BuildSnapshotForFlavor(f.nodes, usage, testFlavor)
+	if err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+	blocked := &v1.Run{
+		ObjectMeta: v1.ObjectMeta{Name: "big", Namespace: "default"},
+		Spec:       v1.RunSpec{Resources: v1.RunResources{GPUType: testFlavor, TotalGPUs: int32(need)}},
+	}
+	return Input{Run: blocked, Need: need, Snapshot: snapshot, Leases: f.leases, Runs: f.runs, Place: placeInOneDomain}
+}
+
+func placeInOneDomain(run *v1.Run, snapshot *topology.Snapshot) (pack.Plan, error) {
+	return pack.Planner(snapshot, pack.Request{Flavor: snapshot.Flavor, TotalGPUs: int(run.Spec.Resources.TotalGPUs)})
+}
+
+func moved(plan Plan) []string {
+	var names []string
+	for _, m := range plan.Moves {
+		names = append(names, m.Run.Name)
+	}
+	return names
+}
+
+func TestComputeOpensTheDomainWithTheFewestMoves(t *testing.T) {
+	f := newFleet()
+	plan, ok := Compute(f.input(t, 8))
+	if !ok {
+		t.Fatal("no plan, but moving small-a opens island-a")
+	}
+	if plan.Domain.Fabric != "island-a" || len(plan.Moves) != 1 || plan.Moves[0].Run.Name != "small-a" {
+		t.Fatalf("opened %s by moving %v, want island-a by moving small-a alone", plan.Domain.Fabric, moved(plan))
+	}
+	if plan.Moves[0].Freed != 4 || plan.Placement.TotalGPUs != 8 {
+		t.Errorf("freed %d and placed %d, want 4 and 8", plan.Moves[0].Freed, plan.Placement.TotalGPUs)
+	}
+}
+
+func TestComputeRespectsOptOutAndCheckpoints(t *testing.T) {
+	f := newFleet()
+	f.runs["default/small-a"].Spec.Runtime.NoDefrag = true
+	plan, ok := Compute(f.input(t, 8))
+	if !ok || plan.Domain.Fabric != "island-b" || len(plan.Moves) != 2 {
+		t.Fatalf("ok=%v opened %s by moving %v, want island-b by moving both pairs", ok, plan.Domain.Fabric, moved(plan))
+	}
+
+	// Without a checkpoint a move loses the work, so the pairs stay too.
+	f.runs["default/pair-x"].Spec.Runtime = nil
+	if plan, ok := Compute(f.input(t, 8)); ok {
+		t.Fatalf("opened %s by moving %v; nothing left is movable", plan.Domain.Fabric, moved(plan))
+	}
+}
+
+// A move is only a move if the run lands somewhere: small-a's 4 GPUs need one
+// island with 4 free once the blocked run is in.
+func TestComputeRefusesAMoveWithNowhereToGo(t *testing.T) {
+	f := newFleet()
+	f.runs["default/pair-x"].Spec.Runtime.NoDefrag = true
+	f.runs["default/pair-y"].Spec.Runtime.NoDefrag = true
+	f.running("filler-b", "", "b1#2", "b1#3")
+	if plan, ok := Compute(f.input(t, 8)); ok {
+		t.Fatalf("opened %s by moving %v, but only 2+2 GPUs would be left for small-a", plan.Domain.Fabric, moved(plan))
+	}
+}
+
+// A funded run the blocked one does not outrank keeps its place: with small-a
+// on a higher priority, island-b's pairs move instead.
+func TestComputeMovesOnlyRunsTheBlockedOneOutranks(t *testing.T) {
+	f := newFleet()
+	f.runs["default/small-a"].Spec.Priority = 5
+	plan, ok := Compute(f.input(t, 8))
+	if !ok || plan.Domain.Fabric != "island-b" || len(plan.Moves) != 2 {
+		t.Fatalf("ok=%v opened %s by moving %v, want island-b by moving both pairs", ok, plan.Domain.Fabric, moved(plan))
+	}
+}
@@ -46,6 +46,14 @@ type Input struct {
 	// actually exists to shrink). Optional: when nil, that remedy is omitted
 	// rather than guessed.
 	Runs map[string]*v1.Run
+	// Flavor is the GPU flavor Snapshot, the pack outcome and CoverRequest
+	// describe. Empty reads as the run's gpuType.
+	Flavor string
+	// Alternatives are the same question asked of the run's other flavors,
+	// each with its own Flavor, Snapshot, and pack and cover outcome. Plan
+	// reserves on whichever forecasts the earliest start, the earlier listed
+	// on a tie.
+	Alternatives []Input
 }
 
 // Result contains the reservation plan emitted by the forecaster.
@@ -55,9 +63,14 @@ type Result struct {
 	EarliestStart  time.Time
 	Forecast       v1.ReservationForecast
 	Reason         string
+	// Flavor is the flavor the reservation is planned on.
+	Flavor string
 }
 
-// Plan determines how to represent a reservation for a run that cannot start immediately.
+// Plan determines how to represent a reservation for a run that cannot start
+// immediately, on the flavor among in and its alternatives that can start it
+// soonest. A flavor that cannot be forecast at all (no envelope of it) is
+// passed over; the error is returned only when none can.
 func Plan(in Input) (Result, error) {
 	if in.Run == nil {
 		return Result{}, errors.New("run must be provided")
@@ -65,6 +78,21 @@ func Plan(in Input) (Result, error) {
 	if in.Now.IsZero() {
 		in.Now = time.Now().UTC()
 	}
+	best, bestErr := planFlavor(in)
+	for _, alt := range in.Alternatives {
+		alt.Run, alt.Now, alt.Evaluation, alt.Runs = in.Run, in.Now, in.Evaluation, in.Runs
+		res, err := planFlavor(alt)
+		if err != nil {
+			continue
+		}
+		if bestErr != nil || res.EarliestStart.Before(best.EarliestStart) {
+			best, bestErr = res, nil
+		}
+	}
+	return best, bestErr
+}
+
+func planFlavor(in Input) (Result, error) {
 	scope := deriveScope(in)
 
 	envelope, err := selectEnvelope(in, scope)
@@ -95,9 +123,17 @@ func Plan(in Input) (Result, error) {
 		EarliestStart:  earliest,
 		Forecast:       forecast,
 		Reason:         reason,
+		Flavor:         in.flavor(),
 	}, nil
 }
 
+func (in Input) flavor() string {
+	if in.Flavor != "" {
+		return in.Flavor
+	}
+	return in.Run.Spec.Resources.GPUType
+}
+
 func deriveScope(in Input) map[string]string {
 	if in.CoverRequest.Location != nil && len(in.CoverRequest.Location) > 0 {
 		return cloneMap(in.CoverRequest.Location)
@@ -234,13 +270,13 @@ func computeHeadroom(in Input) int {
 		admitted = in.Now
 	}
 	owner := in.Evaluation.OwnerOf(in.Run.Namespace)
-	admission := in.Evaluation.NewAdmission(owner, admitted, in.CoverRequest.RunKey)
+	admission := in.Evaluation.NewAdmission(owner, in.CoverRequest.Priority, admitted, in.CoverRequest.RunKey)
 	remaining := 0
 	for _, acct := range in.Evaluation.Envelopes() {
 		if acct.Owner != owner {
 			continue
 		}
-		if acct.Spec.Flavor != in.Run.Spec.Resources.GPUType {
+		if acct.Spec.Flavor != in.flavor() {
 			continue
 		}
 		if !windowAllowsAdmission(acct.Spec, in.Now) {
@@ -274,7 +310,7 @@ func selectEnvelope(in Input, scope map[string]string) (*funding.EnvelopeAccount
 			if acct.Owner != owner {
 				continue
 			}
-			if acct.Spec.Flavor != in.Run.Spec.Resources.GPUType {
+			if acct.Spec.Flavor != in.flavor() {
 				continue
 			}
 			if len(scope) > 0 && !matchesScope(acct.Spec.Selector, scope) {
@@ -350,7 +386,7 @@ func hasUnfundedCapacity(in Input) bool {
 	if in.Evaluation == nil || in.Run == nil {
 		return false
 	}
-	flavor := in.Run.Spec.Resources.GPUType
+	flavor := in.flavor()
 	for _, acct := range in.Evaluation.Envelopes() {
 		if acct.Spec.Flavor != flavor {
 			continue
@@ -366,7 +402,7 @@ func hasSpareCapacity(in Input) bool {
 	if in.Evaluation == nil || in.Run == nil {
 		return false
 	}
-	flavor := in.Run.Spec.Resources.GPUType
+	flavor := in.flavor()
 	for _, acct := range in.Evaluation.Envelopes() {
 		if acct.Spec.Flavor != flavor {
 			continue
@@ -382,12 +418,12 @@ func hasMalleableRuns(in Input) bool {
 	if in.Run == nil {
 		return false
 	}
-	flavor := in.Run.Spec.Resources.GPUType
+	flavor := in.flavor()
 	for key, run := range in.Runs {
 		if run == nil || run.Spec.Malleable == nil {
 			continue
 		}
-		if run.Spec.Resources.GPUType != flavor {
+		if run.Flavor() != flavor {
 			continue
 		}
 		if in.Run != nil && key == runKey(in.Run) {
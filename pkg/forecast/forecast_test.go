@@ -514,3 +514,54 @@ func TestPlanHeadroomWindowGated(t *testing.T) {
 		t.Fatalf("expected paying envelope east, got %s", result.PayingEnvelope)
 	}
 }
+
+// A run with alternatives reserves on whichever flavor starts it soonest: the
+// A100 envelope is open now, the H100 one only opens next week.
+func TestPlanReservesOnTheEarliestFlavor(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	run := runOf("train", "org:ai:team", 8)
+	run.Spec.Resources.Alternatives = []v1.FlavorAlternative{{GPUType: "A100-80GB"}}
+	nextWeek := v1.NewTime(now.Add(7 * 24 * time.Hour))
+	budgets := []v1.Budget{{
+		ObjectMeta: v1.ObjectMeta{Name: "team", Namespace: "default"},
+		Spec: v1.BudgetSpec{Owner: "org:ai:team", Envelopes: []v1.BudgetEnvelope{
+			{Name: "west-h100", Flavor: testFlavor, Concurrency: 16, Start: &nextWeek, End: &testWindowEnd},
+			{Name: "west-a100", Flavor: "A100-80GB", Concurrency: 16, Start: &testWindowStart, End: &testWindowEnd},
+		}},
+	}}
+	ev := funding.Evaluate(funding.Input{Budgets: budgets, Now: now})
+	owner := ev.OwnerOf(run.Namespace)
+
+	plan, err := Plan(Input{
+		Run:          run,
+		Now:          now,
+		Evaluation:   ev,
+		CoverErr:     &cover.PlanError{Reason: cover.FailureReasonNoMatchingEnvelope},
+		CoverRequest: cover.Request{Owner: owner, Flavor: testFlavor},
+		Alternatives: []Input{{
+			Flavor:       "A100-80GB",
+			PackErr:      &pack.PlanError{Reason: pack.FailureReasonInsufficientCapacity},
+			CoverRequest: cover.Request{Owner: owner, Flavor: "A100-80GB"},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if plan.Flavor != "A100-80GB" || plan.PayingEnvelope != "west-a100" {
+		t.Fatalf("reserved on %s via %s, want the A100 envelope that is open now", plan.Flavor, plan.PayingEnvelope)
+	}
+	if !plan.EarliestStart.Before(nextWeek.Time) {
+		t.Fatalf("earliest start %s is not before the H100 window", plan.EarliestStart)
+	}
+
+	// Without the alternative the same run waits for the H100 window.
+	only, err := Plan(Input{Run: run, Now: now, Evaluation: ev,
+		CoverErr:     &cover.PlanError{Reason: cover.FailureReasonNoMatchingEnvelope},
+		CoverRequest: cover.Request{Owner: owner, Flavor: testFlavor}})
+	if err != nil {
+		t.Fatalf("plan: %v", err)
+	}
+	if only.Flavor != testFlavor || only.EarliestStart.Before(nextWeek.Time) {
+		t.Fatalf("reserved on %s at %s, want H100 once its window opens", only.Flavor, only.EarliestStart)
+	}
+}
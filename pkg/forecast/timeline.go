@@ -0,0 +1,354 @@
+package forecast
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+// MaxTimelineHours bounds how far ahead a timeline projects: beyond a couple
+// of weeks every open lease and reservation is a guess about runs nobody has
+// submitted yet.
+const MaxTimelineHours = 14 * 24
+
+// Timeline mark kinds.
+const (
+	MarkEnvelopeOpens  = "EnvelopeOpens"
+	MarkEnvelopeCloses = "EnvelopeCloses"
+	// MarkRenewalDue is when spec.autoRenew starts reporting the envelope as
+	// pending renewal: notifyBefore ahead of its end.
+	MarkRenewalDue = "RenewalDue"
+	// MarkRenewal is the envelope's end on a budget with spec.autoRenew: the
+	// seam where its renewal takes over its leases.
+	MarkRenewal = "Renewal"
+)
+
+// TimelineInput is what a capacity timeline is projected from: the fleet, and
+// everything that already has a claim on it.
+type TimelineInput struct {
+	Flavor string
+	// From is rounded down to the hour; the timeline covers Hours hours from
+	// it.
+	From         time.Time
+	Hours        int
+	Nodes        []topology.SourceNode
+	Leases       []v1.GPULease
+	Reservations []v1.Reservation
+	Runs         map[string]*v1.Run
+	Budgets      []v1.Budget
+}
+
+// Timeline is a flavor's GPUs per fabric domain per hour: how many are held by
+// running work, how many are promised to reservations, and how many are left,
+// with the envelope windows that open, close or come up for renewal inside
+// it.
+type Timeline struct {
+	Flavor  string           `json:"flavor"`
+	From    time.Time        `json:"from"`
+	Hours   int              `json:"hours"`
+	Domains []DomainTimeline `json:"domains"`
+	Marks   []TimelineMark   `json:"marks,omitempty"`
+	// Unplaced lists the waiting reservations of the flavor that name no
+	// domain of the fleet, so their width is not drawn anywhere.
+	Unplaced []string `json:"unplaced,omitempty"`
+}
+
+// DomainTimeline is one fabric domain's hours.
+type DomainTimeline struct {
+	Domain   string     `json:"domain"`
+	Capacity int        `json:"capacity"`
+	Hours    []HourSlot `json:"hours"`
+}
+
+// HourSlot is one domain over one hour. Running and Reserved count what holds
+// GPUs at any point in the hour, so Free is what the hour can promise from
+// start to end.
+type HourSlot struct {
+	Start    time.Time `json:"start"`
+	Running  int       `json:"running"`
+	Reserved int       `json:"reserved"`
+	Free     int       `json:"free"`
+}
+
+// TimelineMark is one envelope boundary inside the timeline.
+type TimelineMark struct {
+	At          time.Time `json:"at"`
+	Kind        string    `json:"kind"`
+	Budget      string    `json:"budget"`
+	Envelope    string    `json:"envelope"`
+	Concurrency int32     `json:"concurrency"`
+	Detail      string    `json:"detail,omitempty"`
+}
+
+// commitment is width held on one domain over [start, end); a zero end holds
+// it past the horizon.
+type commitment struct {
+	domain     string
+	gpus       int
+	start, end time.Time
+	reserved   bool
+}
+
+// ProjectTimeline projects the flavor's fleet forward an hour at a time.
+//
+// An open lease holds its GPUs until its interval ends or, failing that, until
+// its run's status.eta or spec.runtime.maxDuration, whichever comes first; a
+// run with neither holds them through the horizon. A waiting reservation holds
+// its run's width in its intended domain from earliestStart until its
+// booking's end, or through the horizon. The
+// projection only sees claims that exist: a slot shown free is free of every
+// lease and reservation made so far, not of the runs that will queue for it.
+func ProjectTimeline(in TimelineInput) (*Timeline, error) {
+	if in.Flavor == "" {
+		return nil, fmt.Errorf("flavor is required")
+	}
+	if in.Hours <= 0 || in.Hours > MaxTimelineHours {
+		return nil, fmt.Errorf("hours must be between 1 and %d, got %d", MaxTimelineHours, in.Hours)
+	}
+	snapshot, err := topology.BuildSnapshotForFlavor(in.Nodes, nil, in.Flavor)
+	if err != nil {
+		return nil, err
+	}
+	from := in.From.UTC().Truncate(time.Hour)
+	horizon := from.Add(time.Duration(in.Hours) * time.Hour)
+	out := &Timeline{Flavor: in.Flavor, From: from, Hours: in.Hours}
+
+	domainOf := map[string]string{}
+	byKey := map[topology.DomainKey]string{}
+	for _, dom := range snapshot.Domains {
+		name := dom.Key.String()
+		byKey[dom.Key] = name
+		for _, node := range dom.Nodes {
+			domainOf[node.Name] = name
+		}
+	}
+
+	var held []commitment
+	_, profile, _ := topology.MIGProfile(in.Flavor)
+	for i := range in.Leases {
+		lease := &in.Leases[i]
+		if lease.Status.Closed || lease.Spec.Slice.Profile != profile {
+			continue
+		}
+		end := time.Time{}
+		if lease.Spec.Interval.End != nil {
+			end = lease.Spec.Interval.End.Time
+		} else if run := in.Runs[keys.NamespacedKey(lease.Spec.RunRef.Namespace, lease.Spec.RunRef.Name)]; run != nil {
+			end = runEnd(run, lease)
+		}
+		if !end.IsZero() && !end.After(from) {
+			continue
+		}
+		for _, id := range lease.Spec.Slice.Nodes {
+			node, _, _ := strings.Cut(id, "#")
+			if domain, ok := domainOf[node]; ok {
+				held = append(held, commitment{domain: domain, gpus: 1, start: from, end: end})
+			}
+		}
+	}
+
+	for i := range in.Reservations {
+		res := &in.Reservations[i]
+		switch res.Status.State {
+		case "", "Pending", "BlockedFunding":
+		default:
+			continue
+		}
+		run := in.Runs[keys.NamespacedKey(res.Spec.RunRef.Namespace, res.Spec.RunRef.Name)]
+		if run == nil {
+			continue
+		}
+		flavor := res.Spec.Flavor
+		if flavor == "" {
+			flavor = run.Spec.Resources.GPUType
+		}
+		if flavor != in.Flavor {
+			continue
+		}
+		end := time.Time{}
+		if b := res.Spec.Booking; b != nil && b.Until != nil {
+			end = b.Until.Time
+		}
+		domain := reservationDomain(res, domainOf, byKey)
+		if domain == "" {
+			out.Unplaced = append(out.Unplaced, keys.NamespacedKey(res.Namespace, res.Name))
+			continue
+		}
+		held = append(held, commitment{domain: domain, gpus: int(run.Spec.Resources.TotalGPUs),
+			start: res.Spec.EarliestStart.Time, end: end, reserved: true})
+	}
+	sort.Strings(out.Unplaced)
+
+	for _, dom := range snapshot.Domains {
+		name := dom.Key.String()
+		row := DomainTimeline{Domain: name, Capacity: dom.TotalGPUs(), Hours: make([]HourSlot, in.Hours)}
+		for h := range row.Hours {
+			start := from.Add(time.Duration(h) * time.Hour)
+			slot := HourSlot{Start: start}
+			for _, c := range held {
+				if c.domain != name || !c.start.Before(start.Add(time.Hour)) || (!c.end.IsZero() && !c.end.After(start)) {
+					continue
+				}
+				if c.reserved {
+					slot.Reserved += c.gpus
+				} else {
+					slot.Running += c.gpus
+				}
+			}
+			slot.Free = max(0, row.Capacity-slot.Running-slot.Reserved)
+			row.Hours[h] = slot
+		}
+		out.Domains = append(out.Domains, row)
+	}
+	sort.Slice(out.Domains, func(i, j int) bool { return out.Domains[i].Domain < out.Domains[j].Domain })
+
+	out.Marks = envelopeMarks(in.Budgets, in.Flavor, from, horizon)
+	return out, nil
+}
+
+// runEnd is when a running run is expected to release a lease: its reported
+// ETA or its time limit, whichever is sooner, or zero when it declares
+// neither. A limit the controller has not fixed yet is counted from the
+// lease's own start.
+func runEnd(run *v1.Run, lease *v1.GPULease) time.Time {
+	var limit time.Time
+	if tl := run.Status.TimeLimit; tl != nil {
+		limit = tl.Deadline.Time
+	} else if rt := run.Spec.Runtime; rt != nil && rt.MaxDuration != nil {
+		limit = lease.Spec.Interval.Start.Add(rt.MaxDuration.Duration)
+	}
+	if eta := run.Status.ETA; eta != nil && (limit.IsZero() || eta.EstimatedCompletion.Before(&v1.Time{Time: limit})) {
+		return eta.EstimatedCompletion.Time
+	}
+	return limit
+}
+
+// reservationDomain places a reservation on the domain of its first intended
+// node, or else on the domain its intended labels name.
+func reservationDomain(res *v1.Reservation, domainOf map[string]string, byKey map[topology.DomainKey]string) string {
+	for _, node := range res.Spec.IntendedSlice.Nodes {
+		if domain, ok := domainOf[node]; ok {
+			return domain
+		}
+	}
+	labels := res.Spec.IntendedSlice.Domain
+	return byKey[topology.DomainKey{
+		Region:  labels[topology.LabelRegion],
+		Cluster: labels[topology.LabelCluster],
+		Fabric:  labels[topology.LabelFabricDomain],
+	}]
+}
+
+// envelopeMarks lists the flavor's envelope boundaries in [from, horizon). A
+// budget with spec.autoRenew also marks when each envelope comes up for
+// renewal and the seam at its end. A renewal already written is an envelope
+// like any other and marks its own opening; one still to come is annotated,
+// not projected as capacity.
+func envelopeMarks(budgets []v1.Budget, flavor string, from, horizon time.Time) []TimelineMark {
+	var marks []TimelineMark
+	within := func(t time.Time) bool { return !t.Before(from) && t.Before(horizon) }
+	for i := range budgets {
+		budget := &budgets[i]
+		name := keys.NamespacedKey(budget.Namespace, budget.Name)
+		renewedAs := map[string]string{}
+		for _, env := range budget.Spec.Envelopes {
+			if env.RenewalOf != "" {
+				renewedAs[env.RenewalOf] = env.Name
+			}
+		}
+		for _, env := range budget.Spec.Envelopes {
+			if env.Flavor != flavor {
+				continue
+			}
+			mark := func(at time.Time, kind, detail string) {
+				if within(at) {
+					marks = append(marks, TimelineMark{At: at, Kind: kind, Budget: name, Envelope: env.Name, Concurrency: env.Concurrency, Detail: detail})
+				}
+			}
+			if env.Start != nil {
+				mark(env.Start.Time, MarkEnvelopeOpens, "")
+			}
+			if env.End == nil {
+				continue
+			}
+			renew := budget.Spec.AutoRenew
+			if renew == nil {
+				mark(env.End.Time, MarkEnvelopeCloses, "")
+				continue
+			}
+			if next, ok := renewedAs[env.Name]; ok {
+				mark(env.End.Time, MarkRenewal, "renewed as "+next)
+				continue
+			}
+			mark(env.End.Add(-renew.NotifyBefore.Duration), MarkRenewalDue, "closes "+env.End.UTC().Format(time.RFC3339))
+			detail := "renews at notice"
+			if renew.RequireApproval {
+				detail = "closes unless renewal is approved"
+			}
+			period := renew.Period.Duration
+			if period <= 0 && env.Start != nil {
+				period = env.End.Sub(env.Start.Time)
+			}
+			detail += "; next period to " + env.End.Add(period).UTC().Format(time.RFC3339)
+			mark(env.End.Time, MarkRenewal, detail)
+		}
+	}
+	sort.SliceStable(marks, func(i, j int) bool {
+		if !marks[i].At.Equal(marks[j].At) {
+			return marks[i].At.Before(marks[j].At)
+		}
+		if marks[i].Budget != marks[j].Budget {
+			return marks[i].Budget < marks[j].Budget
+		}
+		return marks[i].Envelope < marks[j].Envelope
+	})
+	return marks
+}
+
+// Headers are the columns Rows emits: the hour, one per domain, and the marks.
+func (t *Timeline) Headers() []string {
+	headers := []string{"Hour"}
+	for _, dom := range t.Domains {
+		headers = append(headers, fmt.Sprintf("%s (%d)", dom.Domain, dom.Capacity))
+	}
+	return append(headers, "Marks")
+}
+
+// Rows renders the timeline an hour a row, each domain as free/running/
+// reserved GPUs. An hour identical to the one above it and carrying no marks
+// is folded into it, so a quiet week reads as a few lines.
+func (t *Timeline) Rows() [][]string {
+	var rows [][]string
+	var last []string
+	for h := 0; h < t.Hours; h++ {
+		start := t.From.Add(time.Duration(h) * time.Hour)
+		cells := make([]string, 0, len(t.Domains))
+		for _, dom := range t.Domains {
+			slot := dom.Hours[h]
+			cells = append(cells, fmt.Sprintf("%d free / %d run / %d rsv", slot.Free, slot.Running, slot.Reserved))
+		}
+		var marks []string
+		for _, m := range t.Marks {
+			if !m.At.Before(start) && m.At.Before(start.Add(time.Hour)) {
+				text := fmt.Sprintf("%s %s/%s (%d)", m.Kind, m.Budget, m.Envelope, m.Concurrency)
+				if m.Detail != "" {
+					text += ": " + m.Detail
+				}
+				marks = append(marks, text)
+			}
+		}
+		if h > 0 && len(marks) == 0 && slices.Equal(cells, last) {
+			continue
+		}
+		last = cells
+		row := append([]string{start.Format("2006-01-02 15:04")}, cells...)
+		rows = append(rows, append(row, strings.Join(marks, "; ")))
+	}
+	return rows
+}
@@ -0,0 +1,154 @@
+package forecast
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/topology"
+)
+
+func timelineNode(name, fabric string) topology.SourceNode {
+	return topology.SourceNode{Name: name, GPUs: 8, Labels: map[string]string{
+		topology.LabelRegion: "us-west", topology.LabelCluster: "c1",
+		topology.LabelFabricDomain: fabric, topology.LabelGPUFlavor: testFlavor,
+	}}
+}
+
+// An open lease holds its GPUs until its run's ETA, a booking holds its
+// width from its start to its end, and each lands on its own domain.
+func TestProjectTimelineHoldsLeasesToTheirETAAndBookingsToTheirWindow(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 30, 0, 0, time.UTC)
+	running := runOf("train", "org:ai", 4)
+	running.Status.ETA = &v1.RunETA{EstimatedCompletion: v1.NewTime(now.Add(2 * time.Hour))}
+	lease := leaseOf("train-lease", "train", "team", "west", 4, now.Add(-time.Hour))
+	lease.Spec.Slice.Nodes = []string{"a1#0", "a1#1", "a1#2", "a1#3"}
+
+	booked := runOf("eval", "org:ai", 8)
+	until := v1.NewTime(now.Add(5 * time.Hour))
+	booking := v1.Reservation{
+		ObjectMeta: v1.ObjectMeta{Name: "eval-booking", Namespace: "default"},
+		Spec: v1.ReservationSpec{
+			RunRef:        v1.RunReference{Name: "eval", Namespace: "default"},
+			IntendedSlice: v1.IntendedSlice{Nodes: []string{"b1"}},
+			EarliestStart: v1.NewTime(now.Add(3 * time.Hour)),
+			Booking:       &v1.ReservationBooking{DrainFrom: v1.NewTime(now.Add(2 * time.Hour)), Until: &until},
+		},
+		Status: v1.ReservationStatus{State: "Pending"},
+	}
+
+	tl, err := ProjectTimeline(TimelineInput{
+		Flavor:       testFlavor,
+		From:         now,
+		Hours:        8,
+		Nodes:        []topology.SourceNode{timelineNode("a1", "fab-a"), timelineNode("b1", "fab-b")},
+		Leases:       []v1.GPULease{lease},
+		Reservations: []v1.Reservation{booking},
+		Runs:         runsMap(running, booked),
+	})
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	if !tl.From.Equal(now.Truncate(time.Hour)) || len(tl.Domains) != 2 {
+		t.Fatalf("timeline from %v over %d domains, want the hour and 2", tl.From, len(tl.Domains))
+	}
+	a, b := tl.Domains[0], tl.Domains[1]
+	// The lease ends at 11:30, so it holds the 09:00, 10:00 and 11:00 hours.
+	for h, want := range []int{4, 4, 4, 0} {
+		if got := a.Hours[h].Running; got != want {
+			t.Errorf("%s hour %d running = %d, want %d", a.Domain, h, got, want)
+		}
+	}
+	// The booking holds 12:30 to 14:30: the 12:00, 13:00 and 14:00 hours.
+	for h, want := range []int{0, 0, 0, 8, 8, 8, 0} {
+		if got := b.Hours[h].Reserved; got != want {
+			t.Errorf("%s hour %d reserved = %d, want %d", b.Domain, h, got, want)
+		}
+	}
+	if got := b.Hours[3].Free; got != 0 {
+		t.Errorf("booked hour free = %d, want 0", got)
+	}
+}
+
+// Envelope windows are marked where they fall; on an autoRenew budget the end
+// is a renewal, preceded by the notice.
+func TestProjectTimelineMarksEnvelopeWindowsAndRenewals(t *testing.T) {
+	now := time.Date(2026, 3, 2, 0, 0, 0, 0, time.UTC)
+	envelope := func(name string, start, end time.Time) v1.BudgetEnvelope {
+		s, e := v1.NewTime(start), v1.NewTime(end)
+		return v1.BudgetEnvelope{Name: name, Flavor: testFlavor, Concurrency: 16, Start: &s, End: &e}
+	}
+	plain := v1.Budget{ObjectMeta: v1.ObjectMeta{Name: "plain", Namespace: "team"}, Spec: v1.BudgetSpec{
+		Envelopes: []v1.BudgetEnvelope{envelope("spring", now.Add(10*time.Hour), now.Add(30*time.Hour))},
+	}}
+	renewing := v1.Budget{ObjectMeta: v1.ObjectMeta{Name: "renewing", Namespace: "team"}, Spec: v1.BudgetSpec{
+		Envelopes: []v1.BudgetEnvelope{envelope("q1", now.Add(-time.Hour), now.Add(20*time.Hour))},
+		AutoRenew: &v1.AutoRenewSchedule{
+			Period:       v1.Duration{Duration: 24 * time.Hour},
+			NotifyBefore: v1.Duration{Duration: 6 * time.Hour},
+		},
+	}}
+
+	tl, err := ProjectTimeline(TimelineInput{
+		Flavor:  testFlavor,
+		From:    now,
+		Hours:   24,
+		Nodes:   []topology.SourceNode{timelineNode("a1", "fab-a")},
+		Budgets: []v1.Budget{plain, renewing},
+	})
+	if err != nil {
+		t.Fatalf("project: %v", err)
+	}
+	var got []string
+	for _, m := range tl.Marks {
+		got = append(got, m.At.Format("15")+" "+m.Kind+" "+m.Envelope)
+	}
+	// spring's end falls past the horizon, q1's start before it.
+	want := "10 EnvelopeOpens spring, 14 RenewalDue q1, 20 Renewal q1"
+	if strings.Join(got, ", ") != want {
+		t.Errorf("marks = %q, want %q", strings.Join(got, ", "), want)
+	}
+	if !strings.Contains(tl.Marks[2].Detail, "2026-03-03T20:00:00Z") {
+		t.Errorf("renewal detail %q should name the next period's end", tl.Marks[2].Detail)
+	}
+	// Nothing holds the domain, so only the marked hours break the fold.
+	if rows := tl.Rows(); len(rows) != 4 {
+		t.Errorf("rows = %d, want the first hour and the three marked ones", len(rows))
+	}
+}
+
+// A run that declares maxDuration frees its lease at the limit when it has no
+// ETA, or at the ETA when that comes first.
+func TestProjectTimelineEndsLeasesAtTheTimeLimit(t *testing.T) {
+	now := time.Date(2026, 3, 2, 9, 0, 0, 0, time.UTC)
+	limited := runOf("train", "org:ai", 4)
+	limited.Spec.Runtime = &v1.RunRuntime{MaxDuration: &v1.Duration{Duration: 3 * time.Hour}}
+	lease := leaseOf("train-lease", "train", "team", "west", 4, now.Add(-time.Hour))
+	lease.Spec.Slice.Nodes = []string{"a1#0", "a1#1", "a1#2", "a1#3"}
+	in := TimelineInput{
+		Flavor: testFlavor, From: now, Hours: 4,
+		Nodes:  []topology.SourceNode{timelineNode("a1", "fab-a")},
+		Leases: []v1.GPULease{lease},
+		Runs:   runsMap(limited),
+	}
+	running := func() []int {
+		tl, err := ProjectTimeline(in)
+		if err != nil {
+			t.Fatalf("project: %v", err)
+		}
+		var got []int
+		for _, slot := range tl.Domains[0].Hours {
+			got = append(got, slot.Running)
+		}
+		return got
+	}
+	// Started at 08:00 with a three-hour limit: held through the 10:00 hour.
+	if got := running(); got[0] != 4 || got[1] != 4 || got[2] != 0 {
+		t.Errorf("running = %v, want the lease freed at 11:00", got)
+	}
+	limited.Status.ETA = &v1.RunETA{EstimatedCompletion: v1.NewTime(now.Add(30 * time.Minute))}
+	if got := running(); got[0] != 4 || got[1] != 0 {
+		t.Errorf("running = %v, want the lease freed at the 09:30 ETA", got)
+	}
+}
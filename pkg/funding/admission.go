@@ -11,6 +11,7 @@ import (
 type Admission struct {
 	ev       *Evaluation
 	owner    string
+	priority int32
 	admitted time.Time
 	name     string
 
@@ -19,17 +20,19 @@ type Admission struct {
 }
 
 // NewAdmission starts planning a claim for owner, ranked at its run's
-// admission time and key (a growing run keeps the rank of its original
+// requested priority (each envelope honors it up to its maxPriority),
+// admission time, and key (a growing run keeps the rank of its original
 // admission). The key is the prospective run's namespaced key; it drives the
 // deterministic name tiebreak among same-second, same-tier peers. Pass "" to
 // take the conservative estimate when no run identity is available.
-func (ev *Evaluation) NewAdmission(owner string, admitted time.Time, name string) *Admission {
+func (ev *Evaluation) NewAdmission(owner string, priority int32, admitted time.Time, name string) *Admission {
 	if admitted.IsZero() {
 		admitted = ev.Now
 	}
 	return &Admission{
 		ev:         ev,
 		owner:      owner,
+		priority:   priority,
 		admitted:   admitted,
 		name:       name,
 		envPending: make(map[EnvelopeKey]int32),
@@ -47,7 +50,7 @@ func (a *Admission) Available(key EnvelopeKey, sponsor bool) int32 {
 	if acct == nil {
 		return 0
 	}
-	available := a.ev.AvailableWidth(key, a.owner, a.admitted, a.name, sponsor)
+	available := a.ev.AvailableWidth(key, a.owner, a.priority, a.admitted, a.name, sponsor)
 	available -= a.envPending[key]
 	for _, agg := range acct.aggregates {
 		if pending := a.aggPending[agg]; pending > 0 {
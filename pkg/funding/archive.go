@@ -0,0 +1,169 @@
+package funding
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// Ledger compaction is a checkpoint, not a filter. Dropping old closed leases
+// from the replay would change the history of the leases kept: a closed lease
+// that outranked an open one while both were live is why the open one's early
+// hours were Unfunded. So a LeaseArchive folds everything accrued before its
+// Through — closed and still-open leases alike — and the replay resumes at
+// the latest Through. Nothing after that instant depends on a lease that had
+// ended before it, except through the fair-share meters, which the archive
+// carries too. Resuming there reproduces the full replay's accounts and its
+// classification at Now.
+//
+// What an archive does NOT do is re-price. The full replay classifies history
+// against today's Budgets, so an edited envelope re-prices the past; an
+// archived window keeps the classes it was folded with.
+
+// ArchiveHorizon is the instant the replay resumes from: the latest Through
+// across the archives, or the zero time when there are none.
+func ArchiveHorizon(archives []v1.LeaseArchive) time.Time {
+	var horizon time.Time
+	for i := range archives {
+		if t := archives[i].Spec.Through.Time; t.After(horizon) {
+			horizon = t
+		}
+	}
+	return horizon
+}
+
+// FoldedBy reports whether an archive through the given instant holds all of
+// the lease's accrual: it is closed and ended no later than through. Only such
+// a lease may be deleted.
+func FoldedBy(lease *v1.GPULease, through time.Time) bool {
+	if through.IsZero() || !lease.Status.Closed {
+		return false
+	}
+	end := effectiveEnd(lease)
+	return !end.IsZero() && !end.After(through)
+}
+
+// seedArchives charges every archived hour to the accounts the replay would
+// have charged it to, and primes the fair-share meters from the latest
+// archive. An archived envelope that no longer exists is charged to nobody,
+// exactly as the replay charges a lease whose payer was deleted.
+func (ev *Evaluation) seedArchives(archives []v1.LeaseArchive) {
+	var latest *v1.LeaseArchive
+	for i := range archives {
+		archive := &archives[i]
+		if latest == nil || archive.Spec.Through.After(latest.Spec.Through.Time) {
+			latest = archive
+		}
+		for _, charge := range archive.Spec.Charges {
+			class := Class(charge.Class)
+			if acct := ev.envelopes[EnvelopeKey{Namespace: charge.BudgetNamespace, Budget: charge.Budget, Envelope: charge.Envelope}]; acct != nil {
+				acct.HoursByClass[class] += charge.GPUHours
+				if class != ClassUnfunded {
+					acct.ConsumedGPUHours += charge.GPUHours
+					for _, agg := range acct.aggregates {
+						agg.consumed += charge.GPUHours
+					}
+				}
+			}
+			run := ev.runAccount(charge.Run)
+			run.GPUHours[class] += charge.GPUHours
+			if charge.Lender != "" && (class == ClassShared || class == ClassBorrowed) {
+				run.LenderHours[charge.Lender] += charge.GPUHours
+			}
+		}
+		for _, role := range archive.Spec.RoleHours {
+			ev.runAccount(role.Run).RoleGPUHours[role.Role] += role.GPUHours
+		}
+	}
+	if latest == nil {
+		return
+	}
+	for _, share := range latest.Spec.FairShare {
+		// A half-life no budget asks for any more has no meter to prime; a
+		// budget that changed its half-life starts its meter from zero.
+		if m := ev.meters[share.HalfLife.Duration]; m != nil {
+			m.usage[share.Owner] = share.GPUHours
+		}
+	}
+}
+
+// Compact folds the ledger in in from the current horizon (or the first
+// lease's start, before any archive) into the next archive's spec. The window
+// ends at the last ledger event at or before through, not at through itself:
+// the replay re-ranks only at events, and resuming at an instant the full
+// replay never stops at would re-rank there (a fair-share band can move
+// between events) and so could diverge from it. through must not pass in.Now,
+// or a lease still live at Now would be folded for hours it has not yet run;
+// in.Now is otherwise ignored.
+func Compact(in Input, through time.Time) (v1.LeaseArchiveSpec, error) {
+	if !in.Now.IsZero() && through.After(in.Now) {
+		return v1.LeaseArchiveSpec{}, fmt.Errorf("cannot fold through %s: it is past now (%s)",
+			through.Format(time.RFC3339), in.Now.Format(time.RFC3339))
+	}
+	from := ArchiveHorizon(in.Archives)
+	in.Now = through
+	times := eventTimes(in, buildLeaseFacts(in), from)
+	if len(times) == 0 {
+		return v1.LeaseArchiveSpec{}, fmt.Errorf("the ledger has nothing to fold before %s", through.Format(time.RFC3339))
+	}
+	if from.IsZero() {
+		for i := range in.Leases {
+			if start := in.Leases[i].Spec.Interval.Start.Time; from.IsZero() || start.Before(from) {
+				from = start
+			}
+		}
+	}
+	through = times[len(times)-1]
+	if !from.Before(through) {
+		return v1.LeaseArchiveSpec{}, fmt.Errorf("already folded through %s; no ledger event since", from.Format(time.RFC3339))
+	}
+
+	in.Now = through
+	stmt := &Statement{From: from, To: through, hours: make(map[chargeKey]float64), owners: make(map[string]string), roles: make(map[roleKey]float64)}
+	ev := evaluate(in, stmt)
+	roles := stmt.roles
+	stmt.fold()
+
+	spec := v1.LeaseArchiveSpec{From: metav1.NewTime(from), Through: metav1.NewTime(through)}
+	for i := range in.Leases {
+		if FoldedBy(&in.Leases[i], through) {
+			spec.FoldedLeases++
+		}
+	}
+	for _, line := range stmt.Lines {
+		spec.Charges = append(spec.Charges, v1.ArchivedCharge{
+			Run: line.Run, Owner: line.Owner,
+			BudgetNamespace: line.Envelope.Namespace, Budget: line.Envelope.Budget, Envelope: line.Envelope.Envelope,
+			Class: string(line.Class), Lender: line.Lender, GPUHours: line.GPUHours,
+		})
+	}
+	for key, hours := range roles {
+		spec.RoleHours = append(spec.RoleHours, v1.ArchivedRoleHours{Run: key.run, Role: key.role, GPUHours: hours})
+	}
+	sort.Slice(spec.RoleHours, func(i, j int) bool {
+		a, b := spec.RoleHours[i], spec.RoleHours[j]
+		if a.Run != b.Run {
+			return a.Run < b.Run
+		}
+		return a.Role < b.Role
+	})
+	for halfLife, m := range ev.meters {
+		for owner, used := range m.usage {
+			if used > 0 {
+				spec.FairShare = append(spec.FairShare, v1.ArchivedShare{HalfLife: metav1.Duration{Duration: halfLife}, Owner: owner, GPUHours: used})
+			}
+		}
+	}
+	sort.Slice(spec.FairShare, func(i, j int) bool {
+		a, b := spec.FairShare[i], spec.FairShare[j]
+		if a.HalfLife.Duration != b.HalfLife.Duration {
+			return a.HalfLife.Duration < b.HalfLife.Duration
+		}
+		return a.Owner < b.Owner
+	})
+	return spec, nil
+}
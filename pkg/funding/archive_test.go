@@ -0,0 +1,297 @@
+package funding
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// compactWorld folds w's ledger through the given instants in turn, as the
+// compactor would, deleting the leases each archive holds whole. It returns
+// the archives and the surviving leases, or ok=false when there was nothing
+// to fold at the first instant.
+func compactWorld(t *testing.T, w world, throughs ...time.Time) ([]v1.LeaseArchive, []v1.GPULease, bool) {
+	t.Helper()
+	var archives []v1.LeaseArchive
+	leases := w.leases
+	for _, through := range throughs {
+		spec, err := Compact(Input{Budgets: w.budgets, Leases: leases, Runs: w.runs, Now: w.now, Period: w.period, Archives: archives}, through)
+		if err != nil {
+			if len(archives) == 0 {
+				return nil, nil, false
+			}
+			continue
+		}
+		archives = append(archives, v1.LeaseArchive{Spec: spec})
+		var kept []v1.GPULease
+		for i := range leases {
+			if !FoldedBy(&leases[i], spec.Through.Time) {
+				kept = append(kept, leases[i])
+			}
+		}
+		leases = kept
+	}
+	return archives, leases, true
+}
+
+func hoursClose(a, b float64) bool {
+	return math.Abs(a-b) <= 1e-9*(1+math.Abs(a))
+}
+
+func sameHours[K comparable](a, b map[K]float64) bool {
+	for k, v := range a {
+		if !hoursClose(v, b[k]) {
+			return false
+		}
+	}
+	for k, v := range b {
+		if !hoursClose(a[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// sameWidths compares width maps, a missing key reading as zero.
+func sameWidths[K comparable](a, b map[K]int32) bool {
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	for k, v := range b {
+		if a[k] != v {
+			return false
+		}
+	}
+	return true
+}
+
+// Compaction is exact: resuming the replay from archives, with the folded
+// leases deleted, reproduces every envelope's usage, every run's funding
+// status, and the classification at Now of the full replay — with fair share
+// on, and across chained archives.
+func TestPropertyCompactionPreservesTheAccounts(t *testing.T) {
+	folded := 0
+	for seed := int64(0); seed < 150; seed++ {
+		rng := rand.New(rand.NewSource(seed))
+		w := genWorld(rng)
+		if len(w.budgets) > 0 && rng.Intn(2) == 0 {
+			policy := &v1.FairSharePolicy{HalfLife: metav1.Duration{Duration: time.Duration(30+rng.Intn(90)) * time.Minute}}
+			w.budgets[rng.Intn(len(w.budgets))].Spec.FairShare = policy
+		}
+		full := evaluateWorld(w)
+
+		span := int(w.now.Sub(base.Add(-3*time.Hour)) / time.Minute)
+		first := base.Add(-3 * time.Hour).Add(time.Duration(rng.Intn(span)) * time.Minute)
+		throughs := []time.Time{first}
+		if rng.Intn(2) == 0 {
+			throughs = append(throughs, first.Add(time.Duration(rng.Intn(int(w.now.Sub(first)/time.Minute)+1))*time.Minute))
+		}
+		archives, leases, ok := compactWorld(t, w, throughs...)
+		if !ok {
+			continue
+		}
+		folded++
+		resumed := Evaluate(Input{Budgets: w.budgets, Leases: leases, Runs: w.runs, Now: w.now, Period: w.period, Archives: archives})
+
+		for _, want := range full.Envelopes() {
+			got := resumed.Envelope(want.Key)
+			if !sameHours(got.HoursByClass, want.HoursByClass) || !hoursClose(got.ConsumedGPUHours, want.ConsumedGPUHours) {
+				t.Fatalf("seed %d: envelope %v hours %v (consumed %v), full replay %v (consumed %v)",
+					seed, want.Key, got.HoursByClass, got.ConsumedGPUHours, want.HoursByClass, want.ConsumedGPUHours)
+			}
+			if !sameWidths(got.WidthByClass, want.WidthByClass) || got.SpareWidth != want.SpareWidth {
+				t.Fatalf("seed %d: envelope %v width %v, full replay %v", seed, want.Key, got.WidthByClass, want.WidthByClass)
+			}
+		}
+		for key, want := range full.runs {
+			got := resumed.Run(key)
+			if got == nil {
+				t.Fatalf("seed %d: run %s lost its account", seed, key)
+			}
+			if !sameWidths(got.GPUs, want.GPUs) || got.SpareGPUs != want.SpareGPUs || !sameWidths(got.Lenders, want.Lenders) ||
+				got.Priority != want.Priority || got.ShareBand != want.ShareBand {
+				t.Fatalf("seed %d: run %s status %+v, full replay %+v", seed, key, got, want)
+			}
+			if !sameHours(got.GPUHours, want.GPUHours) || !sameHours(got.LenderHours, want.LenderHours) {
+				t.Fatalf("seed %d: run %s hours %v lenders %v, full replay %v lenders %v",
+					seed, key, got.GPUHours, got.LenderHours, want.GPUHours, want.LenderHours)
+			}
+		}
+		for i := range leases {
+			want, wok := full.Class(&leases[i])
+			got, gok := resumed.Class(&leases[i])
+			if want != got || wok != gok {
+				t.Fatalf("seed %d: lease %s classified %s, full replay %s", seed, leases[i].Name, got, want)
+			}
+		}
+		for i := range w.budgets {
+			b := &w.budgets[i]
+			want, got := full.FairShare(b.Namespace, b.Name), resumed.FairShare(b.Namespace, b.Name)
+			if len(want) != len(got) {
+				t.Fatalf("seed %d: fair share %+v, full replay %+v", seed, got, want)
+			}
+			for j := range want {
+				if want[j].Owner != got[j].Owner || want[j].Band != got[j].Band || !hoursClose(want[j].GPUHours, got[j].GPUHours) {
+					t.Fatalf("seed %d: fair share %+v, full replay %+v", seed, got, want)
+				}
+			}
+		}
+	}
+	if folded < 50 {
+		t.Fatalf("only %d of 150 worlds had anything to fold; the generator no longer exercises compaction", folded)
+	}
+}
+
+func TestCompactFoldsAWindow(t *testing.T) {
+	team := budgetOf("team", "team-budget", nil, env("west", 8))
+	team.Spec.FairShare = &v1.FairSharePolicy{HalfLife: metav1.Duration{Duration: time.Hour}}
+	budgets := []v1.Budget{team, budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1))}
+	trainer := runOf("train", "team", base, false)
+	borrower := runOf("borrow", "team/child", base, false)
+	worker := func(l *v1.GPULease) { l.Labels[roleNameLabel] = "worker" }
+	leases := []v1.GPULease{
+		leaseOf("l-done", "train", "team", "team-budget", "west", 4, base, closedAt(base.Add(2*time.Hour)), worker),
+		leaseOf("l-live", "train", "team", "team-budget", "west", 2, base.Add(time.Hour), withGroup(1)),
+		leaseOf("l-borrow", "borrow", "team", "team-budget", "west", 4, base, forRunOwner("team/child"), closedAt(base.Add(3*time.Hour))),
+	}
+	now := base.Add(4 * time.Hour)
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(trainer, borrower), Now: now}
+
+	// 2h30 is between events; the window ends at the last one, 2h.
+	spec, err := Compact(in, base.Add(150*time.Minute))
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if !spec.From.Time.Equal(base) || !spec.Through.Time.Equal(base.Add(2*time.Hour)) || spec.FoldedLeases != 1 {
+		t.Fatalf("window [%s, %s) folding %d leases, want [base, base+2h) folding 1", spec.From, spec.Through, spec.FoldedLeases)
+	}
+	wantCharges := []v1.ArchivedCharge{
+		{Run: "team/train", Owner: "team", BudgetNamespace: "team", Budget: "team-budget", Envelope: "west", Class: "Owned", GPUHours: 10},
+		{Run: "team-child/borrow", Owner: "team/child", BudgetNamespace: "team", Budget: "team-budget", Envelope: "west", Class: "Shared", Lender: "team", GPUHours: 4},
+		{Run: "team-child/borrow", Owner: "team/child", BudgetNamespace: "team", Budget: "team-budget", Envelope: "west", Class: "Unfunded", GPUHours: 4},
+	}
+	if !reflect.DeepEqual(spec.Charges, wantCharges) {
+		t.Errorf("charges:\n got %+v\nwant %+v", spec.Charges, wantCharges)
+	}
+	if want := []v1.ArchivedRoleHours{{Run: "team/train", Role: "worker", GPUHours: 8}}; !reflect.DeepEqual(spec.RoleHours, want) {
+		t.Errorf("role hours: got %+v, want %+v", spec.RoleHours, want)
+	}
+	if len(spec.FairShare) != 1 || spec.FairShare[0].Owner != "team/child" || spec.FairShare[0].HalfLife.Duration != time.Hour {
+		t.Errorf("the archive must carry the borrower's meter: %+v", spec.FairShare)
+	}
+
+	archives := []v1.LeaseArchive{{Spec: spec}}
+	full := Evaluate(in)
+	resumed := Evaluate(Input{Budgets: budgets, Leases: leases[1:], Runs: in.Runs, Now: now, Archives: archives})
+	for _, key := range []string{"team/train", "team-child/borrow"} {
+		if want, got := full.Run(key), resumed.Run(key); !reflect.DeepEqual(got, want) {
+			t.Errorf("run %s:\n got %+v\nwant %+v", key, got, want)
+		}
+	}
+	key := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "west"}
+	if want, got := full.Envelope(key), resumed.Envelope(key); !reflect.DeepEqual(got.HoursByClass, want.HoursByClass) {
+		t.Errorf("envelope hours: got %v, want %v", got.HoursByClass, want.HoursByClass)
+	}
+
+	if _, err := Compact(Input{Budgets: budgets, Leases: leases[1:], Runs: in.Runs, Now: now, Archives: archives}, base.Add(2*time.Hour)); err == nil {
+		t.Errorf("a window with no event since the horizon must be refused")
+	}
+	if _, err := Compact(in, now.Add(time.Minute)); err == nil {
+		t.Errorf("folding past now must be refused")
+	}
+	if FoldedBy(&leases[1], spec.Through.Time) || FoldedBy(&leases[2], spec.Through.Time) {
+		t.Errorf("a live lease, or one closed after Through, is not folded")
+	}
+}
+
+// Two siblings contend for the family envelope while their fair-share figures
+// converge; their bands cross between ledger events. The full replay re-ranks
+// them only at the next event, so an archive ending where they cross would
+// hand the envelope over early. Compact ends the window on the last event.
+func TestCompactEndsOnALedgerEvent(t *testing.T) {
+	team := budgetOf("team", "team-budget", nil, env("west", 8))
+	team.Spec.FairShare = &v1.FairSharePolicy{HalfLife: metav1.Duration{Duration: time.Hour}}
+	budgets := []v1.Budget{
+		team,
+		budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+		budgetOf("team/child2", "child2-budget", []string{"team"}, env("scratch", 1)),
+	}
+	hog := runOf("hog", "team/child", base.Add(-3*time.Hour), false)
+	fresh := runOf("fresh", "team/child2", base, false)
+	leases := []v1.GPULease{
+		leaseOf("l-early", "hog", "team", "team-budget", "west", 8, base.Add(-3*time.Hour), forRunOwner("team/child"), closedAt(base)),
+		leaseOf("l-hog", "hog", "team", "team-budget", "west", 8, base, forRunOwner("team/child"), withGroup(1)),
+		leaseOf("l-fresh", "fresh", "team", "team-budget", "west", 8, base, forRunOwner("team/child2")),
+	}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(hog, fresh), Now: base.Add(3 * time.Hour)}
+
+	// By 2h the newcomer's figure has climbed into the hog's band.
+	spec, err := Compact(in, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	if !spec.Through.Time.Equal(base) {
+		t.Fatalf("the window must end on the last ledger event (base), got %s", spec.Through)
+	}
+	full := Evaluate(in)
+	resumed := Evaluate(Input{Budgets: budgets, Leases: leases[1:], Runs: in.Runs, Now: in.Now, Archives: []v1.LeaseArchive{{Spec: spec}}})
+	for _, key := range []string{"team-child/hog", "team-child2/fresh"} {
+		if want, got := full.Run(key).GPUHours, resumed.Run(key).GPUHours; !sameHours(got, want) {
+			t.Errorf("run %s hours %v, full replay %v", key, got, want)
+		}
+	}
+}
+
+// A chargeback across the horizon takes the archived window whole and agrees
+// with the statement the full ledger gives; one that splits the window is
+// refused.
+func TestChargebackReadsArchives(t *testing.T) {
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil, env("west", 8)),
+		budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+	}
+	childRun := runOf("child-train", "team/child", base, false)
+	ownerRun := runOf("boss-train", "team", base.Add(30*time.Minute), false)
+	leases := []v1.GPULease{
+		leaseOf("l-child", "child-train", "team", "team-budget", "west", 8, base, forRunOwner("team/child")),
+		leaseOf("l-boss", "boss-train", "team", "team-budget", "west", 4, base.Add(30*time.Minute), closedAt(base.Add(time.Hour))),
+	}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(childRun, ownerRun), Now: base.Add(3 * time.Hour)}
+	spec, err := Compact(in, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("compact: %v", err)
+	}
+	compacted := in
+	compacted.Leases = leases[:1]
+	compacted.Archives = []v1.LeaseArchive{{Spec: spec}}
+
+	want, err := Chargeback(in, base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("chargeback: %v", err)
+	}
+	got, err := Chargeback(compacted, base, base.Add(2*time.Hour))
+	if err != nil {
+		t.Fatalf("chargeback over archives: %v", err)
+	}
+	if !reflect.DeepEqual(got.Lines, want.Lines) || !reflect.DeepEqual(got.Credits, want.Credits) {
+		t.Errorf("statement over archives:\n got %+v\nwant %+v", got.Lines, want.Lines)
+	}
+	if got := hoursOf(got, "team-child/child-train", ClassShared); got != 12 {
+		t.Errorf("shared hours: got %v, want 12", got)
+	}
+
+	if _, err := Chargeback(compacted, base.Add(30*time.Minute), base.Add(2*time.Hour)); err == nil {
+		t.Errorf("a period that splits an archived window must be refused")
+	}
+	if _, err := Chargeback(compacted, base.Add(time.Hour), base.Add(2*time.Hour)); err != nil {
+		t.Errorf("a period starting on the horizon needs only the live ledger: %v", err)
+	}
+}
@@ -0,0 +1,243 @@
+package funding
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+)
+
+// Statement is a chargeback for the period [From, To): every GPU-hour the lease
+// ledger accrued in it, attributed to the run that used it, the envelope its
+// leases named as payer, and the class the replay derived for it at the time.
+//
+// The classification is the evaluation's own, replayed against today's Budgets:
+// a budget edited mid-period re-prices the whole period. The ledger keeps lease
+// intervals, not past budget specs, so that is the only history there is.
+type Statement struct {
+	From    time.Time      `json:"from"`
+	To      time.Time      `json:"to"`
+	Lines   []ChargeLine   `json:"lines"`
+	Credits []LenderCredit `json:"credits,omitempty"`
+
+	hours  map[chargeKey]float64
+	owners map[string]string       // run key -> derived owner
+	roles  map[roleKey]float64     // multi-role hours, kept only when compacting
+	leases map[leaseDayKey]float64 // per-lease hours by UTC day, kept only for LeaseCharges
+}
+
+// ChargeLine is one run's hours on one envelope in one class.
+type ChargeLine struct {
+	Run string `json:"run"`
+	// Owner is the run's derived owner — who is billed.
+	Owner    string      `json:"owner"`
+	Envelope EnvelopeKey `json:"envelope"`
+	Class    Class       `json:"class"`
+	// Lender is the envelope owner whose capacity funded Shared and Borrowed
+	// hours; empty for Owned and Unfunded.
+	Lender   string  `json:"lender,omitempty"`
+	GPUHours float64 `json:"gpuHours"`
+}
+
+// LenderCredit is what one owner's envelopes lent one borrowing owner, by
+// class: the other side of the Shared and Borrowed lines.
+type LenderCredit struct {
+	Lender   string  `json:"lender"`
+	Borrower string  `json:"borrower"`
+	Class    Class   `json:"class"`
+	GPUHours float64 `json:"gpuHours"`
+}
+
+type chargeKey struct {
+	run    string
+	env    EnvelopeKey
+	class  Class
+	lender string
+}
+
+type roleKey struct {
+	run  string
+	role string
+}
+
+// Chargeback folds the ledger in in into a Statement for [from, to). in.Now is
+// ignored: the replay runs to the end of the period.
+//
+// A period reaching back before the archive horizon takes the archived windows
+// it covers whole, as they were folded. An archived window is indivisible, so
+// a period that starts or ends inside one is refused.
+func Chargeback(in Input, from, to time.Time) (*Statement, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("chargeback period is empty: from %s is not before to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	in.Now = to
+	stmt := &Statement{From: from, To: to, hours: make(map[chargeKey]float64), owners: make(map[string]string)}
+	for i := range in.Archives {
+		spec := &in.Archives[i].Spec
+		start, end := spec.From.Time, spec.Through.Time
+		if !end.After(from) || !start.Before(to) {
+			continue
+		}
+		if start.Before(from) || end.After(to) {
+			return nil, fmt.Errorf("the period splits the archived window [%s, %s); ask for a period on archive boundaries",
+				spec.From.UTC().Format(time.RFC3339), spec.Through.UTC().Format(time.RFC3339))
+		}
+		for _, charge := range spec.Charges {
+			key := chargeKey{
+				run:    charge.Run,
+				env:    EnvelopeKey{Namespace: charge.BudgetNamespace, Budget: charge.Budget, Envelope: charge.Envelope},
+				class:  Class(charge.Class),
+				lender: charge.Lender,
+			}
+			stmt.hours[key] += charge.GPUHours
+			if _, ok := stmt.owners[charge.Run]; !ok {
+				stmt.owners[charge.Run] = charge.Owner
+			}
+		}
+	}
+	evaluate(in, stmt)
+	stmt.fold()
+	return stmt, nil
+}
+
+// charge attributes the replay segment [t0, t1), clipped to the period.
+func (s *Statement) charge(ev *Evaluation, in Input, res *fillResult, t0, t1 time.Time) {
+	if t0.Before(s.From) {
+		t0 = s.From
+	}
+	if t1.After(s.To) {
+		t1 = s.To
+	}
+	hours := t1.Sub(t0).Hours()
+	if hours <= 0 {
+		return
+	}
+	for _, f := range res.live {
+		class := res.classes[f]
+		runKey := keys.NamespacedKey(f.lease.Spec.RunRef.Namespace, f.lease.Spec.RunRef.Name)
+		key := chargeKey{
+			run:   runKey,
+			env:   EnvelopeKey{Namespace: f.lease.Spec.PaidByBudgetNamespace, Budget: f.lease.Spec.PaidByBudget, Envelope: f.lease.Spec.PaidByEnvelope},
+			class: class,
+		}
+		if acct := res.payer[f]; acct != nil {
+			// Past a renewal seam the hours are the renewing window's.
+			key.env = acct.Key
+		}
+		if class == ClassShared || class == ClassBorrowed {
+			key.lender = res.claimOwner[f]
+		}
+		s.hours[key] += float64(f.width) * hours
+		if role := f.lease.Labels[roleNameLabel]; role != "" && s.roles != nil {
+			s.roles[roleKey{run: runKey, role: role}] += float64(f.width) * hours
+		}
+		if _, ok := s.owners[runKey]; !ok {
+			s.owners[runKey] = ev.OwnerOf(f.lease.Spec.RunRef.Namespace)
+		}
+		if s.leases != nil {
+			s.chargeDays(LeaseKey(f.lease), class, f.width, t0, t1)
+		}
+	}
+}
+
+// fold turns the accumulated hours into sorted lines and lender credits.
+func (s *Statement) fold() {
+	credits := make(map[LenderCredit]float64)
+	for key, hours := range s.hours {
+		owner := s.owners[key.run]
+		s.Lines = append(s.Lines, ChargeLine{
+			Run: key.run, Owner: owner, Envelope: key.env, Class: key.class, Lender: key.lender, GPUHours: hours,
+		})
+		if key.lender != "" {
+			credits[LenderCredit{Lender: key.lender, Borrower: owner, Class: key.class}] += hours
+		}
+	}
+	for credit, hours := range credits {
+		credit.GPUHours = hours
+		s.Credits = append(s.Credits, credit)
+	}
+	sort.Slice(s.Lines, func(i, j int) bool {
+		a, b := s.Lines[i], s.Lines[j]
+		if a.Owner != b.Owner {
+			return a.Owner < b.Owner
+		}
+		if a.Run != b.Run {
+			return a.Run < b.Run
+		}
+		if a.Envelope != b.Envelope {
+			return envelopeKeyLess(a.Envelope, b.Envelope)
+		}
+		if a.Class != b.Class {
+			return a.Class < b.Class
+		}
+		return a.Lender < b.Lender
+	})
+	sort.Slice(s.Credits, func(i, j int) bool {
+		a, b := s.Credits[i], s.Credits[j]
+		if a.Lender != b.Lender {
+			return a.Lender < b.Lender
+		}
+		if a.Borrower != b.Borrower {
+			return a.Borrower < b.Borrower
+		}
+		return a.Class < b.Class
+	})
+	s.hours, s.owners = nil, nil
+}
+
+func envelopeKeyLess(a, b EnvelopeKey) bool {
+	if a.Namespace != b.Namespace {
+		return a.Namespace < b.Namespace
+	}
+	if a.Budget != b.Budget {
+		return a.Budget < b.Budget
+	}
+	return a.Envelope < b.Envelope
+}
+
+// ChargebackHeaders are the CSV columns WriteCSV emits, in order.
+var ChargebackHeaders = []string{"from", "to", "owner", "run", "budget_namespace", "budget", "envelope", "class", "lender", "gpu_hours"}
+
+// Rows renders the statement's lines as ChargebackHeaders-ordered strings.
+func (s *Statement) Rows() [][]string {
+	from, to := s.From.UTC().Format(time.RFC3339), s.To.UTC().Format(time.RFC3339)
+	rows := make([][]string, 0, len(s.Lines))
+	for _, line := range s.Lines {
+		rows = append(rows, []string{
+			from, to, line.Owner, line.Run,
+			line.Envelope.Namespace, line.Envelope.Budget, line.Envelope.Envelope,
+			string(line.Class), line.Lender,
+			strconv.FormatFloat(line.GPUHours, 'f', 4, 64),
+		})
+	}
+	return rows
+}
+
+// WriteCSV writes the statement's lines, with a header row.
+func (s *Statement) WriteCSV(w io.Writer) error {
+	out := csv.NewWriter(w)
+	if err := out.Write(ChargebackHeaders); err != nil {
+		return err
+	}
+	if err := out.WriteAll(s.Rows()); err != nil {
+		return err
+	}
+	return out.Error()
+}
+
+// ParseInstant reads a period bound as RFC 3339 or as a bare date (midnight UTC),
+// the form a monthly statement is usually asked for in.
+func ParseInstant(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	t, err := time.Parse(time.DateOnly, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("want RFC 3339 or YYYY-MM-DD, got %q", value)
+	}
+	return t, nil
+}
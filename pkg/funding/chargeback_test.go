@@ -0,0 +1,108 @@
+package funding
+
+import (
+	"bytes"
+	"math"
+	"strings"
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func hoursOf(stmt *Statement, run string, class Class) float64 {
+	var total float64
+	for _, line := range stmt.Lines {
+		if line.Run == run && line.Class == class {
+			total += line.GPUHours
+		}
+	}
+	return total
+}
+
+// A recall mid-period splits the borrower's hours across classes at the instant
+// it happened, and the family lender is credited only for the Shared half.
+func TestChargebackSplitsClassesAtTheRecall(t *testing.T) {
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil, env("west", 8)),
+		budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+	}
+	childRun := runOf("child-train", "team/child", base, false)
+	ownerRun := runOf("boss-train", "team", base.Add(30*time.Minute), false)
+	leases := []v1.GPULease{
+		leaseOf("l-child", "child-train", "team", "team-budget", "west", 8, base, forRunOwner("team/child")),
+		leaseOf("l-boss", "boss-train", "team", "team-budget", "west", 4, base.Add(30*time.Minute)),
+	}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(childRun, ownerRun)}
+
+	stmt, err := Chargeback(in, base, base.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("chargeback: %v", err)
+	}
+	for _, tc := range []struct {
+		run   string
+		class Class
+		want  float64
+	}{
+		{"team-child/child-train", ClassShared, 4},
+		{"team-child/child-train", ClassUnfunded, 4},
+		{"team/boss-train", ClassOwned, 2},
+	} {
+		if got := hoursOf(stmt, tc.run, tc.class); math.Abs(got-tc.want) > 1e-9 {
+			t.Errorf("%s %s: got %.4f GPU-hours, want %.4f", tc.run, tc.class, got, tc.want)
+		}
+	}
+	for _, line := range stmt.Lines {
+		if line.Run == "team-child/child-train" && line.Owner != "team/child" {
+			t.Errorf("the borrower's lines must bill its own owner, got %q", line.Owner)
+		}
+		if (line.Class == ClassShared) != (line.Lender == "team") {
+			t.Errorf("only the Shared line names the lender: %+v", line)
+		}
+	}
+	want := []LenderCredit{{Lender: "team", Borrower: "team/child", Class: ClassShared, GPUHours: 4}}
+	if len(stmt.Credits) != 1 || stmt.Credits[0] != want[0] {
+		t.Errorf("credits: got %+v, want %+v", stmt.Credits, want)
+	}
+}
+
+// Hours outside [from, to) are not billed: a period that starts and ends inside
+// both leases charges only the overlap.
+func TestChargebackClipsToThePeriod(t *testing.T) {
+	six := int32(6)
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil,
+			env("west", 8, withLending(v1.LendingPolicy{Allow: true, MaxConcurrency: &six}))),
+		budgetOf("org:other", "other-budget", nil, env("other", 1)),
+	}
+	guest := runOf("guest", "org:other", base, false)
+	leases := []v1.GPULease{
+		leaseOf("l-guest", "guest", "team", "team-budget", "west", 6, base,
+			forRunOwner("org:other"), closedAt(base.Add(2*time.Hour))),
+	}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(guest)}
+
+	stmt, err := Chargeback(in, base.Add(90*time.Minute), base.Add(3*time.Hour))
+	if err != nil {
+		t.Fatalf("chargeback: %v", err)
+	}
+	if got := hoursOf(stmt, "org-other/guest", ClassBorrowed); math.Abs(got-3) > 1e-9 {
+		t.Errorf("borrowed hours: got %.4f, want 3 (6 GPUs for the 30 minutes inside the period)", got)
+	}
+	if len(stmt.Credits) != 1 || stmt.Credits[0].Lender != "team" || stmt.Credits[0].Borrower != "org:other" {
+		t.Errorf("the sponsor must be credited: %+v", stmt.Credits)
+	}
+
+	var out bytes.Buffer
+	if err := stmt.WriteCSV(&out); err != nil {
+		t.Fatalf("write csv: %v", err)
+	}
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 || lines[0] != strings.Join(ChargebackHeaders, ",") || !strings.HasSuffix(lines[1], ",Borrowed,team,3.0000") {
+		t.Errorf("unexpected csv:\n%s", out.String())
+	}
+
+	if _, err := Chargeback(in, base, base); err == nil {
+		t.Errorf("an empty period must be rejected")
+	}
+}
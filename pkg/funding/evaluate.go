@@ -29,6 +29,14 @@ type Input struct {
 	Runs    map[string]*v1.Run // keyed by keys.NamespacedKey
 	Now     time.Time
 	Period  time.Duration // accounting horizon; <= 0 uses DefaultPeriod (gates nothing — see DefaultPeriod)
+	// Archives are the compacted windows of the ledger. The replay seeds its
+	// accounts from them and resumes at the latest Through (see archive.go).
+	Archives []v1.LeaseArchive
+	// Transfers are the BudgetTransfers compiled into the published
+	// QuotaSnapshot. While one's window is open, its borrower's width on the
+	// lending envelope classifies Borrowed up to its concurrency, with or
+	// without a LendingPolicy; at its end the same leases coast Unfunded.
+	Transfers []v1.SnapshotTransfer
 }
 
 // Evaluation is the derived classification at Input.Now plus the replayed
@@ -57,6 +65,22 @@ type Evaluation struct {
 	claimsByEnv map[EnvelopeKey][]*claim
 	fundedWidth map[claimKey]int32
 	aggWidth    map[*aggregateAccount]int32
+	// lentWidth and transferred split each envelope's borrowed width at Now
+	// between its LendingPolicy and its transfers (by borrower), so each
+	// contract's headroom is measured against its own pool.
+	lentWidth   map[EnvelopeKey]int32
+	transferred map[EnvelopeKey]map[string]int32
+
+	// transfers index the compiled transfers by the envelope they lend from.
+	transfers map[EnvelopeKey][]v1.SnapshotTransfer
+
+	// meters hold the fair-share usage, one per half-life in use, advanced
+	// with the replay so each fill ranks by the usage as of its instant.
+	meters map[time.Duration]*shareMeter
+
+	// renewals map an envelope to the one renewing it (its renewalOf), which
+	// pays for its open leases once its window ends.
+	renewals map[EnvelopeKey]EnvelopeKey
 }
 
 // EnvelopeAccount reports one envelope's derived usage.
@@ -82,6 +106,7 @@ type EnvelopeAccount struct {
 	HoursByClass map[Class]float64
 
 	aggregates []*aggregateAccount
+	halfLife   time.Duration // the budget's fair-share half-life; 0 without the policy
 }
 
 // FundedWidth is the total width charged against the envelope at Now.
@@ -104,6 +129,17 @@ type RunAccount struct {
 	Lenders map[string]int32
 	// LenderHours attributes the run's funded accrual per lending owner.
 	LenderHours map[string]float64
+	// RoleGPUs splits GPUs by RunRole name, per class, for the leases of a
+	// multi-role run (the ones stamped with a role name); RoleGPUHours does
+	// the same for accrued hours. Both are empty for any other run.
+	RoleGPUs     map[string]map[Class]int32
+	RoleGPUHours map[string]float64
+	// Priority is the highest priority any envelope paying for the run's
+	// leases authorizes; the preemption lottery weighs the run by it.
+	Priority int32
+	// ShareBand is the highest fair-share band any of the run's family claims
+	// ranks in; the unfunded reclaim draws its owner more often for it.
+	ShareBand int32
 }
 
 // aggregateAccount carries an aggregate cap's cumulative funded accrual.
@@ -272,6 +308,13 @@ func OwnerOfNamespace(budgets []v1.Budget, namespace string) string {
 // funded: exhaustion demotes every claim the envelope was covering — the
 // ledger hit zero — and never overdraws it.
 func Evaluate(in Input) *Evaluation {
+	return evaluate(in, nil)
+}
+
+// evaluate is Evaluate, additionally charging each replayed segment to stmt
+// when one is given. The statement sees exactly the classification the
+// evaluation accrues under, segment by segment; it is not a second derivation.
+func evaluate(in Input, stmt *Statement) *Evaluation {
 	if in.Period <= 0 {
 		in.Period = DefaultPeriod
 	}
@@ -289,14 +332,26 @@ func Evaluate(in Input) *Evaluation {
 		claimsByEnv: make(map[EnvelopeKey][]*claim),
 		fundedWidth: make(map[claimKey]int32),
 		aggWidth:    make(map[*aggregateAccount]int32),
+		lentWidth:   make(map[EnvelopeKey]int32),
+		transferred: make(map[EnvelopeKey]map[string]int32),
+		transfers:   make(map[EnvelopeKey][]v1.SnapshotTransfer),
+		renewals:    make(map[EnvelopeKey]EnvelopeKey),
 	}
 	ev.deriveOwners(in.Budgets)
+	for _, t := range in.Transfers {
+		key := EnvelopeKey{Namespace: t.From.Namespace, Budget: t.From.Budget, Envelope: t.From.Envelope}
+		ev.transfers[key] = append(ev.transfers[key], t)
+	}
 
 	envIndex := make(map[EnvelopeKey]*EnvelopeAccount)
 	var envOrder []EnvelopeKey
 	for i := range in.Budgets {
 		b := &in.Budgets[i]
 		byName := make(map[string]*EnvelopeAccount, len(b.Spec.Envelopes))
+		var halfLife time.Duration
+		if b.Spec.FairShare != nil {
+			halfLife = b.Spec.FairShare.HalfLife.Duration
+		}
 		for j := range b.Spec.Envelopes {
 			env := b.Spec.Envelopes[j]
 			key := EnvelopeKey{Namespace: b.Namespace, Budget: b.Name, Envelope: env.Name}
@@ -306,11 +361,29 @@ func Evaluate(in Input) *Evaluation {
 				Spec:         env,
 				WidthByClass: make(map[Class]int32),
 				HoursByClass: make(map[Class]float64),
+				halfLife:     halfLife,
 			}
 			envIndex[key] = acct
 			byName[env.Name] = acct
 			envOrder = append(envOrder, key)
 		}
+		for j := range b.Spec.Envelopes {
+			acct := byName[b.Spec.Envelopes[j].Name]
+			pred, ok := byName[acct.Spec.RenewalOf]
+			if !ok || pred == acct {
+				continue
+			}
+			// A renewal opening after its predecessor closed is a new window,
+			// not a seam: nothing funded the gap, so nothing continues across it.
+			if acct.Spec.Start != nil && pred.Spec.End != nil && acct.Spec.Start.After(pred.Spec.End.Time) {
+				continue
+			}
+			// Two renewals of one window: the earlier-starting one continues it.
+			if cur, dup := ev.renewals[pred.Key]; dup && !acct.Spec.Start.Before(envIndex[cur].Spec.Start) {
+				continue
+			}
+			ev.renewals[pred.Key] = acct.Key
+		}
 		for j := range b.Spec.AggregateCaps {
 			cap := b.Spec.AggregateCaps[j]
 			acct := &aggregateAccount{spec: cap}
@@ -346,7 +419,14 @@ func Evaluate(in Input) *Evaluation {
 	// constant between events. Segments are no longer sub-split: with
 	// GPU-hours metered rather than enforced there is no integral to deplete
 	// mid-segment (Ruling 10).
-	times := eventTimes(in, facts)
+	horizon := ArchiveHorizon(in.Archives)
+	times := eventTimes(in, facts, horizon)
+	start := in.Now
+	if len(times) > 0 {
+		start = times[0]
+	}
+	ev.meters = newShareMeters(in.Budgets, start)
+	ev.seedArchives(in.Archives)
 	for idx := 0; idx < len(times); idx++ {
 		t0 := times[idx]
 		var t1 time.Time
@@ -363,7 +443,12 @@ func Evaluate(in Input) *Evaluation {
 		// already in `times`, so nothing can change class strictly inside a
 		// segment. The sub-splitting loop this replaced existed solely to cut
 		// the segment at integral-depletion crossings, which no longer exist.
-		ev.fill(in, facts, envOrder, t0).accrue(ev, t0, t1)
+		res := ev.fill(in, facts, envOrder, t0)
+		res.accrue(ev, t0, t1)
+		res.meter(ev, t1)
+		if stmt != nil {
+			stmt.charge(ev, in, res, t0, t1)
+		}
 	}
 
 	// Final classification at Now.
@@ -372,6 +457,10 @@ func Evaluate(in Input) *Evaluation {
 	return ev
 }
 
+// roleNameLabel is binder.LabelRoleName, spelled out because binder imports
+// this package (through cover) and cannot be imported back.
+const roleNameLabel = "rq.davidlangworthy.io/role-name"
+
 // buildLeaseFacts parses widths and group indices once.
 func buildLeaseFacts(in Input) []*leaseFact {
 	facts := make([]*leaseFact, 0, len(in.Leases))
@@ -398,15 +487,17 @@ func buildLeaseFacts(in Input) []*leaseFact {
 }
 
 // eventTimes collects the sorted, deduplicated timeline: lease starts and
-// effective ends plus envelope window boundaries, all clamped to Now.
-func eventTimes(in Input, facts []*leaseFact) []time.Time {
+// effective ends plus envelope window boundaries, all clamped to Now. With an
+// archive horizon the timeline starts there: everything before it is folded.
+func eventTimes(in Input, facts []*leaseFact, horizon time.Time) []time.Time {
 	set := make(map[int64]time.Time)
 	add := func(t time.Time) {
-		if t.IsZero() || t.After(in.Now) {
+		if t.IsZero() || t.After(in.Now) || t.Before(horizon) {
 			return
 		}
 		set[t.UnixNano()] = t
 	}
+	add(horizon)
 	for _, f := range facts {
 		add(f.lease.Spec.Interval.Start.Time)
 		add(effectiveEnd(f.lease))
@@ -421,6 +512,12 @@ func eventTimes(in Input, facts []*leaseFact) []time.Time {
 			}
 		}
 	}
+	// A transfer opening or expiring changes who is funded, so its window
+	// bounds a segment just as an envelope's does.
+	for _, t := range in.Transfers {
+		add(t.Start.Time)
+		add(t.End.Time)
+	}
 	out := make([]time.Time, 0, len(set))
 	for _, t := range set {
 		out = append(out, t)
@@ -487,12 +584,32 @@ func windowActive(env *v1.BudgetEnvelope, t time.Time) bool {
 	return true
 }
 
+// continuation follows an envelope's renewals to the one paying for its
+// leases at t: the envelope itself until its window ends, then its renewal,
+// and so on down the chain.
+func (ev *Evaluation) continuation(acct *EnvelopeAccount, t time.Time) (EnvelopeKey, *EnvelopeAccount) {
+	for hops := 0; hops < len(ev.renewals); hops++ {
+		if acct.Spec.End == nil || t.Before(acct.Spec.End.Time) {
+			break
+		}
+		next, ok := ev.renewals[acct.Key]
+		if !ok {
+			break
+		}
+		acct = ev.envelopes[next]
+	}
+	return acct.Key, acct
+}
+
 // fillResult captures one instant's ranked greedy fill: which lease is
 // funded with which class, and the per-envelope funded rates needed for
 // accrual and depletion prediction.
 type fillResult struct {
 	classes    map[*leaseFact]Class
 	claimOwner map[*leaseFact]string // envelope owner for funded leases
+	// payer is the envelope each live lease is charged to at this instant:
+	// the one it names, or past that one's end, its renewal.
+	payer map[*leaseFact]*EnvelopeAccount
 	// live keeps input order so float accumulation is deterministic —
 	// map-order iteration would make repeated evaluations disagree in the
 	// last bits.
@@ -510,6 +627,9 @@ type fillEnv struct {
 	fundedWidth int32
 	// lentWidth is the sponsor-funded subset — the lending integral's rate.
 	lentWidth int32
+	// transferred is the width funded under transfers, by borrower. It is
+	// borrowed like lentWidth but never counts against the LendingPolicy.
+	transferred map[string]int32
 }
 
 // fill runs the normative ranked greedy fill at time t. Sponsor claims are
@@ -522,6 +642,7 @@ func (ev *Evaluation) fill(in Input, facts []*leaseFact, envOrder []EnvelopeKey,
 	res := &fillResult{
 		classes:    make(map[*leaseFact]Class),
 		claimOwner: make(map[*leaseFact]string),
+		payer:      make(map[*leaseFact]*EnvelopeAccount),
 		aggWidth:   make(map[*aggregateAccount]int32),
 		fundedByCk: make(map[claimKey]int32),
 		claims:     make(map[EnvelopeKey][]*claim),
@@ -543,6 +664,10 @@ func (ev *Evaluation) fill(in Input, facts []*leaseFact, envOrder []EnvelopeKey,
 			res.classes[f] = ClassUnfunded
 			continue
 		}
+		// Past its window's end a lease is charged to the window renewing
+		// the one it names, and ranks there as it did before the seam.
+		envKey, acct = ev.continuation(acct, t)
+		res.payer[f] = acct
 		runKey := keys.NamespacedKey(lease.Spec.RunRef.Namespace, lease.Spec.RunRef.Name)
 		ck := claimKey{env: envKey, runKey: runKey}
 		cl, ok := claimIndex[ck]
@@ -550,8 +675,11 @@ func (ev *Evaluation) fill(in Input, facts []*leaseFact, envOrder []EnvelopeKey,
 			run := in.Runs[runKey]
 			cl = &claim{key: ck, name: runKey}
 			if run != nil {
-				cl.tier = ev.Graph.Tier(acct.Owner, ev.OwnerOf(run.Namespace))
+				cl.borrower = ev.OwnerOf(run.Namespace)
+				cl.tier = ev.Graph.Tier(acct.Owner, cl.borrower)
 				cl.sponsored = cl.tier == tierNone
+				cl.band = ev.claimBand(acct, cl.tier, ev.OwnerOf(run.Namespace))
+				cl.priority = authorizedPriority(&acct.Spec, run.Spec.Priority)
 				cl.admitted = run.CreationTimestamp.Time
 				cl.malleable = run.Spec.Malleable != nil
 			} else {
@@ -610,13 +738,24 @@ func (ev *Evaluation) fill(in Input, facts []*leaseFact, envOrder []EnvelopeKey,
 			continue
 		}
 
-		ef := &envFill{acct: acct}
+		ef := &envFill{acct: acct, st: &fillState{
+			res:          res,
+			env:          fe,
+			lendPolicy:   acct.Spec.Lending,
+			transferLeft: ev.transferAllowances(envKey, t),
+		}}
+		fe.transferred = make(map[string]int32)
 		for _, cl := range claims {
 			switch {
 			case cl.tier == -1:
 				res.markClaim(cl, nil, acct) // orphan: all unfunded
 			case cl.sponsored:
 				ef.sponsors = append(ef.sponsors, cl)
+			case cl.tier != TierOwner && ef.st.transferLeft[cl.borrower] > 0:
+				// Family can be party to a transfer too; its claim is tried
+				// against the transfer first and only falls back to family
+				// sharing if the transfer cannot hold it.
+				ef.sponsors = append(ef.sponsors, cl)
 			case cl.tier != TierOwner && !envelopeSharable(&acct.Spec):
 				res.markClaim(cl, nil, acct) // family opted out
 			default:
@@ -624,29 +763,32 @@ func (ev *Evaluation) fill(in Input, facts []*leaseFact, envOrder []EnvelopeKey,
 			}
 		}
 		sort.Slice(ef.sponsors, func(i, j int) bool { return rankLess(ef.sponsors[i], ef.sponsors[j]) })
-		ef.st = &fillState{
-			res:        res,
-			env:        fe,
-			lendPolicy: acct.Spec.Lending,
-		}
 		fills = append(fills, ef)
 	}
 
 	// Pass 1: sponsors, per envelope. Existing sponsor leases are contract
 	// facts, senior on the envelope they borrow (the sponsor carve-out); the
-	// lending caps bound this pool only.
+	// lending caps and transfers bound this pool only.
 	for _, ef := range fills {
 		for _, cl := range ef.sponsors {
-			run := in.Runs[cl.key.runKey]
-			eligible := ef.acct.Spec.Lending != nil && ef.acct.Spec.Lending.Allow &&
-				run != nil && lendingAllows(ef.acct.Spec.Lending, ev.OwnerOf(run.Namespace))
-			if !eligible {
+			if !cl.sponsored {
+				if ef.st.transferLeft[cl.borrower] >= cl.width {
+					res.fillClaim(cl, ef.st, ef.acct, true)
+				} else if envelopeSharable(&ef.acct.Spec) {
+					ef.family = append(ef.family, cl)
+				} else {
+					res.markClaim(cl, nil, ef.acct)
+				}
+				continue
+			}
+			if !ef.st.lends(cl.borrower) && ef.st.transferLeft[cl.borrower] == 0 {
 				res.markClaim(cl, nil, ef.acct)
 				continue
 			}
 			res.fillClaim(cl, ef.st, ef.acct, true)
 		}
 		ef.st.lendPolicy = nil // lending caps bound the sponsor pool only
+		ef.st.transferLeft = nil
 	}
 
 	// Pass 2: owner and family claims in GLOBAL rank order (tier, admission,
@@ -679,6 +821,14 @@ type fillState struct {
 	res        *fillResult
 	env        *fillEnv
 	lendPolicy *v1.LendingPolicy
+	// transferLeft is each borrower's unused transfer width on the envelope
+	// at this instant.
+	transferLeft map[string]int32
+}
+
+// lends reports whether the envelope's LendingPolicy lends to borrower.
+func (st *fillState) lends(borrower string) bool {
+	return st.lendPolicy != nil && st.lendPolicy.Allow && lendingAllows(st.lendPolicy, borrower)
 }
 
 // admit reports whether width more GPUs fit under every cap at this point
@@ -690,11 +840,18 @@ type fillState struct {
 // is purely concurrency-determined (DESIGN-v5 §5a). What remains of
 // "demote-not-kill" is unchanged and now comes entirely from ranking — a claim
 // outranked on concurrency demotes and coasts; nothing is stranded.
-func (st *fillState) admit(width int32, acct *EnvelopeAccount, sponsored bool) bool {
+//
+// A sponsored claim is charged to its borrower's transfer while that holds
+// it, and to the LendingPolicy otherwise.
+func (st *fillState) admit(width int32, acct *EnvelopeAccount, sponsored bool, borrower string) bool {
 	if st.env.fundedWidth+width > acct.Spec.Concurrency {
 		return false
 	}
-	if sponsored && st.lendPolicy != nil {
+	transfer := sponsored && st.transferLeft[borrower] >= width
+	if sponsored && !transfer {
+		if !st.lends(borrower) {
+			return false
+		}
 		if st.lendPolicy.MaxConcurrency != nil && st.env.lentWidth+width > *st.lendPolicy.MaxConcurrency {
 			return false
 		}
@@ -705,7 +862,11 @@ func (st *fillState) admit(width int32, acct *EnvelopeAccount, sponsored bool) b
 		}
 	}
 	st.env.fundedWidth += width
-	if sponsored {
+	switch {
+	case transfer:
+		st.transferLeft[borrower] -= width
+		st.env.transferred[borrower] += width
+	case sponsored:
 		st.env.lentWidth += width
 	}
 	for _, agg := range acct.aggregates {
@@ -724,7 +885,7 @@ func (res *fillResult) fillClaim(cl *claim, st *fillState, acct *EnvelopeAccount
 		class = ClassBorrowed
 	}
 	if !cl.malleable {
-		if st.admit(cl.width, acct, sponsored) {
+		if st.admit(cl.width, acct, sponsored, cl.borrower) {
 			res.markClaim(cl, &class, acct)
 			res.fundedByCk[cl.key] += cl.width
 		} else {
@@ -733,7 +894,7 @@ func (res *fillResult) fillClaim(cl *claim, st *fillState, acct *EnvelopeAccount
 		return
 	}
 	for _, f := range cl.leases {
-		if st.admit(f.width, acct, sponsored) {
+		if st.admit(f.width, acct, sponsored, cl.borrower) {
 			res.markLease(f, class, acct)
 			res.fundedByCk[cl.key] += f.width
 		} else {
@@ -772,9 +933,7 @@ func (res *fillResult) accrue(ev *Evaluation, t0, t1 time.Time) {
 	for _, f := range res.live {
 		class := res.classes[f]
 		leaseHours := float64(f.width) * hours
-		envKey := EnvelopeKey{Namespace: f.lease.Spec.PaidByBudgetNamespace, Budget: f.lease.Spec.PaidByBudget, Envelope: f.lease.Spec.PaidByEnvelope}
-		acct := ev.envelopes[envKey]
-		if acct != nil {
+		if acct := res.payer[f]; acct != nil {
 			acct.HoursByClass[class] += leaseHours
 			if class != ClassUnfunded {
 				// Recorded as observed, never clamped. There is no cap to
@@ -793,6 +952,9 @@ func (res *fillResult) accrue(ev *Evaluation, t0, t1 time.Time) {
 		// separates spares into SpareGPUs — the two dimensions use different
 		// conventions on purpose.
 		run.GPUHours[class] += leaseHours
+		if role := f.lease.Labels[roleNameLabel]; role != "" {
+			run.RoleGPUHours[role] += leaseHours
+		}
 		if class == ClassShared || class == ClassBorrowed {
 			if owner, ok := res.claimOwner[f]; ok {
 				run.LenderHours[owner] += leaseHours
@@ -814,9 +976,7 @@ func (res *fillResult) commit(ev *Evaluation) {
 	for _, f := range res.live {
 		class := res.classes[f]
 		ev.classes[f.name] = class
-		envKey := EnvelopeKey{Namespace: f.lease.Spec.PaidByBudgetNamespace, Budget: f.lease.Spec.PaidByBudget, Envelope: f.lease.Spec.PaidByEnvelope}
-		acct := ev.envelopes[envKey]
-		if acct != nil {
+		if acct := res.payer[f]; acct != nil {
 			acct.WidthByClass[class] += f.width
 			if class != ClassUnfunded && f.lease.Spec.Slice.Role == "Spare" {
 				acct.SpareWidth += f.width
@@ -828,6 +988,12 @@ func (res *fillResult) commit(ev *Evaluation) {
 			run.SpareGPUs += f.width
 		} else {
 			run.GPUs[class] += f.width
+			if role := f.lease.Labels[roleNameLabel]; role != "" {
+				if run.RoleGPUs[role] == nil {
+					run.RoleGPUs[role] = make(map[Class]int32)
+				}
+				run.RoleGPUs[role][class] += f.width
+			}
 			// Lender attribution mirrors the per-class width: only non-spare
 			// borrowed/shared width credits a lender, so sum(Lenders) never
 			// exceeds SharedGPUs+BorrowedGPUs.
@@ -838,26 +1004,83 @@ func (res *fillResult) commit(ev *Evaluation) {
 			}
 		}
 	}
+	for _, claims := range res.claims {
+		for _, cl := range claims {
+			run := ev.runs[cl.key.runKey]
+			if run == nil {
+				continue
+			}
+			if cl.priority > run.Priority {
+				run.Priority = cl.priority
+			}
+			if cl.band > run.ShareBand {
+				run.ShareBand = cl.band
+			}
+		}
+	}
 	ev.claimsByEnv = res.claims
 	ev.fundedWidth = res.fundedByCk
 	ev.aggWidth = res.aggWidth
+	for _, fe := range res.envs {
+		ev.lentWidth[fe.acct.Key] = fe.lentWidth
+		ev.transferred[fe.acct.Key] = fe.transferred
+	}
 }
 
 func (ev *Evaluation) runAccount(runKey string) *RunAccount {
 	acct, ok := ev.runs[runKey]
 	if !ok {
 		acct = &RunAccount{
-			Key:         runKey,
-			GPUs:        make(map[Class]int32),
-			GPUHours:    make(map[Class]float64),
-			Lenders:     make(map[string]int32),
-			LenderHours: make(map[string]float64),
+			Key:          runKey,
+			GPUs:         make(map[Class]int32),
+			GPUHours:     make(map[Class]float64),
+			Lenders:      make(map[string]int32),
+			LenderHours:  make(map[string]float64),
+			RoleGPUs:     make(map[string]map[Class]int32),
+			RoleGPUHours: make(map[string]float64),
 		}
 		ev.runs[runKey] = acct
 	}
 	return acct
 }
 
+// transferAllowances sums, per borrower, the concurrency of the transfers
+// lending from key whose window holds t. An expired transfer simply stops
+// appearing here, which is the whole of its expiry.
+func (ev *Evaluation) transferAllowances(key EnvelopeKey, t time.Time) map[string]int32 {
+	out := make(map[string]int32)
+	for _, tr := range ev.transfers[key] {
+		if t.Before(tr.Start.Time) || !t.Before(tr.End.Time) || tr.Borrower == "" {
+			continue
+		}
+		out[tr.Borrower] += tr.Concurrency
+	}
+	return out
+}
+
+// TransfersTo lists the envelopes lending to owner under a transfer open at
+// Now, in envelope order. The cover planner walks them after the owner's
+// family and before any sponsor: both parties already agreed, so the run need
+// not opt into borrowing.
+func (ev *Evaluation) TransfersTo(owner string) []EnvelopeKey {
+	var out []EnvelopeKey
+	for key := range ev.transfers {
+		if ev.transferAllowances(key, ev.Now)[owner] > 0 && ev.envelopes[key] != nil {
+			out = append(out, key)
+		}
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Namespace != out[j].Namespace {
+			return out[i].Namespace < out[j].Namespace
+		}
+		if out[i].Budget != out[j].Budget {
+			return out[i].Budget < out[j].Budget
+		}
+		return out[i].Envelope < out[j].Envelope
+	})
+	return out
+}
+
 func lendingAllows(policy *v1.LendingPolicy, borrower string) bool {
 	if policy == nil {
 		return false
@@ -918,7 +1141,7 @@ func lendingAllows(policy *v1.LendingPolicy, borrower string) bool {
 // tiebreak against same-tier, same-second peers so admission agrees with the
 // classifier's ranking. An empty name falls back to the conservative
 // estimate (every same-time peer treated as senior).
-func (ev *Evaluation) AvailableWidth(key EnvelopeKey, runOwner string, admitted time.Time, name string, sponsor bool) int32 {
+func (ev *Evaluation) AvailableWidth(key EnvelopeKey, runOwner string, priority int32, admitted time.Time, name string, sponsor bool) int32 {
 	acct := ev.envelopes[key]
 	if acct == nil {
 		return 0
@@ -931,31 +1154,42 @@ func (ev *Evaluation) AvailableWidth(key EnvelopeKey, runOwner string, admitted
 		}
 	}
 	if sponsor {
-		policy := acct.Spec.Lending
-		if policy == nil || !policy.Allow || !lendingAllows(policy, runOwner) {
+		// The contracts' room: the LendingPolicy's pool plus whatever of the
+		// borrower's own transfers it is not yet using.
+		room := int32(0)
+		if policy := acct.Spec.Lending; policy != nil && policy.Allow && lendingAllows(policy, runOwner) {
+			room = math.MaxInt32
+			if policy.MaxConcurrency != nil {
+				room = *policy.MaxConcurrency - ev.lentWidth[key]
+			}
+		}
+		if left := ev.transferAllowances(key, ev.Now)[runOwner] - ev.transferred[key][runOwner]; left > 0 && room < math.MaxInt32 {
+			room += left
+		}
+		if room <= 0 {
 			return 0
 		}
+		bound(room)
 		bound(acct.Spec.Concurrency - acct.FundedWidth())
-		if policy.MaxConcurrency != nil {
-			bound(*policy.MaxConcurrency - borrowedWidth)
-		}
 	}
 
-	tier := tierNone
+	// The prospective claim, ranked as fill would rank it.
+	prospect := &claim{tier: tierNone, priority: authorizedPriority(&acct.Spec, priority), admitted: admitted, name: name}
 	if !sponsor {
-		tier = ev.Graph.Tier(acct.Owner, runOwner)
-		if tier == tierNone {
+		prospect.tier = ev.Graph.Tier(acct.Owner, runOwner)
+		if prospect.tier == tierNone {
 			return 0
 		}
-		if tier != TierOwner && !envelopeSharable(&acct.Spec) {
+		if prospect.tier != TierOwner && !envelopeSharable(&acct.Spec) {
 			return 0
 		}
+		prospect.band = ev.claimBand(acct, prospect.tier, runOwner)
 		counted := borrowedWidth
 		for _, cl := range ev.claimsByEnv[key] {
 			if cl.sponsored || cl.tier < TierOwner {
 				continue
 			}
-			if claimAtOrAbove(cl, tier, admitted, name) {
+			if claimAtOrAbove(cl, prospect) {
 				counted += ev.fundedWidth[cl.key]
 			}
 		}
@@ -973,7 +1207,7 @@ func (ev *Evaluation) AvailableWidth(key EnvelopeKey, runOwner string, admitted
 	for _, agg := range acct.aggregates {
 		width := ev.aggWidth[agg]
 		if !sponsor {
-			width = ev.aggFundedNotOutranked(agg, tier, admitted, name)
+			width = ev.aggFundedNotOutranked(agg, prospect)
 		}
 		if agg.spec.MaxConcurrency != nil {
 			bound(*agg.spec.MaxConcurrency - width)
@@ -987,13 +1221,12 @@ func (ev *Evaluation) AvailableWidth(key EnvelopeKey, runOwner string, admitted
 }
 
 // aggFundedNotOutranked sums the funded width across an aggregate's member
-// envelopes that a prospective family/owner claim (of the given tier and
-// admission time) does not outrank: senior or equal-ranked family/owner
-// peers, plus every sponsor (borrowed capacity is contractual and never
-// recallable). The junior family width the prospective claim could recall is
-// excluded, so the aggregate cap honors owner recall just as a single
-// envelope's concurrency does.
-func (ev *Evaluation) aggFundedNotOutranked(agg *aggregateAccount, tier int, admitted time.Time, name string) int32 {
+// envelopes that a prospective family/owner claim does not outrank: senior or
+// equal-ranked family/owner peers, plus every sponsor (borrowed capacity is
+// contractual and never recallable). The junior family width the prospective
+// claim could recall is excluded, so the aggregate cap honors owner recall just
+// as a single envelope's concurrency does.
+func (ev *Evaluation) aggFundedNotOutranked(agg *aggregateAccount, prospect *claim) int32 {
 	var total int32
 	for key, claims := range ev.claimsByEnv {
 		acct := ev.envelopes[key]
@@ -1008,7 +1241,7 @@ func (ev *Evaluation) aggFundedNotOutranked(agg *aggregateAccount, tier int, adm
 			if cl.tier < TierOwner {
 				continue
 			}
-			if claimAtOrAbove(cl, tier, admitted, name) {
+			if claimAtOrAbove(cl, prospect) {
 				total += ev.fundedWidth[cl.key]
 			}
 		}
@@ -1017,20 +1250,17 @@ func (ev *Evaluation) aggFundedNotOutranked(agg *aggregateAccount, tier int, adm
 }
 
 // claimAtOrAbove reports whether an existing claim ranks at or above a
-// prospective claim of (tier, admitted, name) — i.e. it is senior and holds
-// capacity the prospective claim cannot recall. It mirrors rankLess's
-// (tier, admission, name) order, counting an equal key as senior (the run's
-// own existing width). An empty prospective name falls back to counting
+// prospective one — i.e. it is senior and holds capacity the prospective
+// claim cannot recall. It is rankLess with an equal key counted as senior (the
+// run's own existing width). An empty prospective name falls back to counting
 // every same-time peer as senior, the conservative estimate for callers
 // without a run identity.
-func claimAtOrAbove(cl *claim, tier int, admitted time.Time, name string) bool {
-	if cl.tier != tier {
-		return cl.tier < tier
-	}
-	if !cl.admitted.Equal(admitted) {
-		return cl.admitted.Before(admitted)
+func claimAtOrAbove(cl, prospect *claim) bool {
+	if prospect.name == "" && cl.tier == prospect.tier && cl.band == prospect.band &&
+		cl.priority == prospect.priority && cl.admitted.Equal(prospect.admitted) {
+		return true
 	}
-	return name == "" || cl.name <= name
+	return !rankLess(prospect, cl)
 }
 
 // aggregateMember reports whether the envelope belongs to the aggregate cap.
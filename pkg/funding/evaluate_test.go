@@ -9,6 +9,8 @@ import (
 	"testing"
 	"time"
 
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
 )
@@ -215,6 +217,33 @@ func TestOwnerClaimFunded(t *testing.T) {
 	}
 }
 
+// A multi-role run's width and hours are split per role from the role-name
+// label, each under the class its own lease derived — one role can run owned
+// while another coasts unfunded.
+func TestRunAccountSplitsWidthByRole(t *testing.T) {
+	budgets := []v1.Budget{budgetOf("team", "team-budget", nil, env("west", 8))}
+	runs := runsMap(runOf("rlhf", "team", base, false))
+	withRoleName := func(name string) leaseOpt {
+		return func(l *v1.GPULease) { l.Labels["rq.davidlangworthy.io/role-name"] = name }
+	}
+	leases := []v1.GPULease{
+		leaseOf("l-learner", "rlhf", "team", "team-budget", "west", 4, base, withRoleName("learner")),
+		leaseOf("l-actor", "rlhf", "team", "team-budget", "gone", 2, base, withRoleName("actor")),
+	}
+	ev := Evaluate(Input{Budgets: budgets, Leases: leases, Runs: runs, Now: base.Add(time.Hour)})
+
+	run := ev.Run("team/rlhf")
+	if got := run.RoleGPUs["learner"][ClassOwned]; got != 4 {
+		t.Errorf("expected 4 owned learner GPUs, got %d", got)
+	}
+	if got := run.RoleGPUs["actor"][ClassUnfunded]; got != 2 {
+		t.Errorf("expected 2 unfunded actor GPUs (no such envelope), got %d", got)
+	}
+	if math.Abs(run.RoleGPUHours["learner"]-4) > 1e-9 || math.Abs(run.RoleGPUHours["actor"]-2) > 1e-9 {
+		t.Errorf("expected 4 learner and 2 actor GPU-hours, got %v / %v", run.RoleGPUHours["learner"], run.RoleGPUHours["actor"])
+	}
+}
+
 func TestSkipSemantics(t *testing.T) {
 	budgets := []v1.Budget{budgetOf("team", "team-budget", nil, env("west", 8))}
 	big := runOf("big", "team", base, false)
@@ -491,18 +520,18 @@ func TestAvailableWidthRecallsThroughAggregate(t *testing.T) {
 	// the borrowed aggregate width on the empty member (west) and on the
 	// member the family currently holds (east) alike.
 	westKey := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "west"}
-	if got := ev.AvailableWidth(westKey, "team", base, "", false); got != 8 {
+	if got := ev.AvailableWidth(westKey, "team", 0, base, "", false); got != 8 {
 		t.Errorf("owner should recall the family borrower's aggregate width on west, want 8, got %d", got)
 	}
 	eastKey := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "east"}
-	if got := ev.AvailableWidth(eastKey, "team", base, "", false); got != 8 {
+	if got := ev.AvailableWidth(eastKey, "team", 0, base, "", false); got != 8 {
 		t.Errorf("owner should recall the family borrower on east too, want 8, got %d", got)
 	}
 	// A junior cousin does NOT outrank the sitting family claim, so it sees
 	// none of the aggregate width the owner could recall.
 	cousin := budgetOf("team/cousin", "cousin-budget", []string{"team"}, idleEnvelope())
 	ev2 := Evaluate(Input{Budgets: []v1.Budget{budget, child, cousin}, Leases: leases, Runs: runsMap(familyRun), Now: base.Add(time.Hour)})
-	if got := ev2.AvailableWidth(westKey, "team/cousin", base.Add(time.Minute), "", false); got != 0 {
+	if got := ev2.AvailableWidth(westKey, "team/cousin", 0, base.Add(time.Minute), "", false); got != 0 {
 		t.Errorf("a later cousin cannot recall the family claim through the aggregate, want 0, got %d", got)
 	}
 }
@@ -531,19 +560,19 @@ func TestAvailableWidthRecallAndSponsor(t *testing.T) {
 	key := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "west"}
 	// The owner sees the full envelope: the child's shared claim is
 	// recallable and does not count against an owner admission.
-	if got := ev.AvailableWidth(key, "team", base.Add(time.Hour), "", false); got != 8 {
+	if got := ev.AvailableWidth(key, "team", 0, base.Add(time.Hour), "", false); got != 8 {
 		t.Errorf("owner admission should see 8 available (recall), got %d", got)
 	}
 	// A sibling arriving later ranks below the child's existing claim.
-	if got := ev.AvailableWidth(key, "team/child2", base.Add(time.Hour), "", false); got != 2 {
+	if got := ev.AvailableWidth(key, "team/child2", 0, base.Add(time.Hour), "", false); got != 2 {
 		t.Errorf("later same-tier claim should see the remainder 2, got %d", got)
 	}
 	// A sponsor is junior to all funded width and bounded by lending caps.
-	if got := ev.AvailableWidth(key, "org:guest", base.Add(time.Hour), "", true); got != 2 {
+	if got := ev.AvailableWidth(key, "org:guest", 0, base.Add(time.Hour), "", true); got != 2 {
 		t.Errorf("sponsor should see min(capacity remainder, lending cap) = 2, got %d", got)
 	}
 	// A stranger without the sponsor path gets nothing.
-	if got := ev.AvailableWidth(key, "org:guest", base.Add(time.Hour), "", false); got != 0 {
+	if got := ev.AvailableWidth(key, "org:guest", 0, base.Add(time.Hour), "", false); got != 0 {
 		t.Errorf("stranger without lending path should see 0, got %d", got)
 	}
 }
@@ -568,20 +597,143 @@ func TestAvailableWidthNameTiebreak(t *testing.T) {
 
 	// Same tier (child), same admission second: a name-senior prospective
 	// outranks the sitting claim and recalls all 8.
-	if got := ev.AvailableWidth(key, "team/child2", base, "team-child/aaa-run", false); got != 8 {
+	if got := ev.AvailableWidth(key, "team/child2", 0, base, "team-child/aaa-run", false); got != 8 {
 		t.Errorf("name-senior peer should recall the sitting claim, want 8, got %d", got)
 	}
 	// A name-junior prospective ranks below it and sees nothing.
-	if got := ev.AvailableWidth(key, "team/child2", base, "team-child/zzz-run", false); got != 0 {
+	if got := ev.AvailableWidth(key, "team/child2", 0, base, "team-child/zzz-run", false); got != 0 {
 		t.Errorf("name-junior peer must not recall the sitting claim, want 0, got %d", got)
 	}
 	// Empty name keeps the conservative estimate (every same-time peer
 	// senior), so no recall.
-	if got := ev.AvailableWidth(key, "team/child2", base, "", false); got != 0 {
+	if got := ev.AvailableWidth(key, "team/child2", 0, base, "", false); got != 0 {
 		t.Errorf("empty name should be conservative (0), got %d", got)
 	}
 }
 
+// Priority ranks a claim ahead of an earlier admission in its own tier, but
+// only as far as the envelope authorizes, and never across a tier.
+func TestAuthorizedPriorityRanksWithinTier(t *testing.T) {
+	withMax := func(p int32) func(*v1.BudgetEnvelope) { return func(e *v1.BudgetEnvelope) { e.MaxPriority = p } }
+	world := func(maxPriority int32, urgentOwner string) (*Evaluation, []v1.GPULease) {
+		budgets := []v1.Budget{
+			budgetOf("team", "team-budget", nil, env("west", 8, withMax(maxPriority))),
+			budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+			budgetOf("team/child2", "child2-budget", []string{"team"}, env("scratch", 1)),
+		}
+		old := runOf("old", "team/child", base, false)
+		urgent := runOf("urgent", urgentOwner, base.Add(time.Minute), false)
+		urgent.Spec.Priority = 7
+		leases := []v1.GPULease{
+			leaseOf("l-old", "old", "team", "team-budget", "west", 8, base, forRunOwner("team/child")),
+			leaseOf("l-urgent", "urgent", "team", "team-budget", "west", 8, base.Add(time.Minute), forRunOwner(urgentOwner)),
+		}
+		return Evaluate(Input{Budgets: budgets, Leases: leases, Runs: runsMap(old, urgent), Now: base.Add(time.Hour)}), leases
+	}
+
+	ev, leases := world(0, "team/child2")
+	if classOf(t, ev, leases, "l-old") != ClassShared || classOf(t, ev, leases, "l-urgent") != ClassUnfunded {
+		t.Errorf("an unauthorized priority must not change the order")
+	}
+
+	ev, leases = world(5, "team/child2")
+	if classOf(t, ev, leases, "l-urgent") != ClassShared || classOf(t, ev, leases, "l-old") != ClassUnfunded {
+		t.Errorf("an authorized priority must rank ahead of the earlier admission")
+	}
+	if got := ev.Run(keys.NamespacedKey(nsForOwner("team/child2"), "urgent")).Priority; got != 5 {
+		t.Errorf("authorized priority = %d, want the envelope's cap 5", got)
+	}
+	key := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "west"}
+	if got := ev.AvailableWidth(key, "team/child", 5, base.Add(2*time.Minute), "team-child/next", false); got != 0 {
+		t.Errorf("an equal-priority newcomer must not recall the urgent claim, got %d", got)
+	}
+
+	// A family claim never outranks the owner's own, however urgent.
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil, env("west", 8, withMax(9))),
+		budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+	}
+	own := runOf("own", "team", base.Add(time.Minute), false)
+	family := runOf("family", "team/child", base, false)
+	family.Spec.Priority = 9
+	leases = []v1.GPULease{
+		leaseOf("l-own", "own", "team", "team-budget", "west", 8, base.Add(time.Minute)),
+		leaseOf("l-family", "family", "team", "team-budget", "west", 8, base, forRunOwner("team/child")),
+	}
+	ev = Evaluate(Input{Budgets: budgets, Leases: leases, Runs: runsMap(own, family), Now: base.Add(time.Hour)})
+	if classOf(t, ev, leases, "l-own") != ClassOwned || classOf(t, ev, leases, "l-family") != ClassUnfunded {
+		t.Errorf("priority must not cross a tier")
+	}
+}
+
+// A sibling that has held the family envelope for hours ranks behind one that
+// just arrived once the envelope's budget asks for fair share; without the
+// policy admission order holds.
+func TestFairShareDownRanksRecentFamilyUse(t *testing.T) {
+	now := base.Add(3 * time.Hour)
+	world := func(policy *v1.FairSharePolicy) (*Evaluation, []v1.GPULease) {
+		team := budgetOf("team", "team-budget", nil, env("west", 8))
+		team.Spec.FairShare = policy
+		budgets := []v1.Budget{
+			team,
+			budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+			budgetOf("team/child2", "child2-budget", []string{"team"}, env("scratch", 1)),
+		}
+		hog := runOf("hog", "team/child", base, false)
+		fresh := runOf("fresh", "team/child2", now.Add(-time.Minute), false)
+		leases := []v1.GPULease{
+			leaseOf("l-hog", "hog", "team", "team-budget", "west", 8, base, forRunOwner("team/child")),
+			leaseOf("l-fresh", "fresh", "team", "team-budget", "west", 8, now.Add(-time.Minute), forRunOwner("team/child2")),
+		}
+		return Evaluate(Input{Budgets: budgets, Leases: leases, Runs: runsMap(hog, fresh), Now: now}), leases
+	}
+
+	ev, leases := world(nil)
+	if classOf(t, ev, leases, "l-hog") != ClassShared || classOf(t, ev, leases, "l-fresh") != ClassUnfunded {
+		t.Errorf("without fair share the earlier admission keeps the excess")
+	}
+	if ev.FairShare("team", "team-budget") != nil {
+		t.Errorf("a budget without the policy reports no fair share")
+	}
+
+	ev, leases = world(&v1.FairSharePolicy{HalfLife: metav1.Duration{Duration: time.Hour}})
+	if classOf(t, ev, leases, "l-fresh") != ClassShared || classOf(t, ev, leases, "l-hog") != ClassUnfunded {
+		t.Errorf("fair share must rank the sibling with less recent use first")
+	}
+	// Three hours of 8 GPUs at a one-hour half-life: 8/ln2 × (1 - 1/8) ≈ 10.1.
+	shares := ev.FairShare("team", "team-budget")
+	if len(shares) != 2 || shares[0].Owner != "team/child" || shares[0].Band != 4 || math.Abs(shares[0].GPUHours-10.1) > 0.05 {
+		t.Fatalf("unexpected fair-share report %+v", shares)
+	}
+	if shares[1].Owner != "team/child2" || shares[1].Band != 0 {
+		t.Errorf("the newcomer used under a GPU-hour, got %+v", shares[1])
+	}
+	if got := ev.Run(keys.NamespacedKey(nsForOwner("team/child"), "hog")).ShareBand; got != 4 {
+		t.Errorf("hog share band = %d, want 4", got)
+	}
+	key := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "west"}
+	if got := ev.AvailableWidth(key, "team/child", 0, base, "team-child/again", false); got != 0 {
+		t.Errorf("the heavy sibling must not recall the newcomer's width, got %d", got)
+	}
+}
+
+func TestShareMeterDecaysAtItsHalfLife(t *testing.T) {
+	m := &shareMeter{halfLife: time.Hour, at: base, usage: map[string]float64{}}
+	m.advance(base.Add(time.Hour), map[string]int32{"a": 1})
+	if want := 0.5 / math.Ln2; math.Abs(m.usage["a"]-want) > 1e-9 {
+		t.Fatalf("one GPU for one half-life = %v, want %v", m.usage["a"], want)
+	}
+	m.advance(base.Add(2*time.Hour), nil)
+	if want := 0.25 / math.Ln2; math.Abs(m.usage["a"]-want) > 1e-9 {
+		t.Errorf("an idle half-life must halve the figure, got %v want %v", m.usage["a"], want)
+	}
+	for usage, band := range map[float64]int32{0.99: 0, 1: 1, 3.9: 2, 4: 3, 10.1: 4} {
+		if got := shareBand(usage); got != band {
+			t.Errorf("shareBand(%v) = %d, want %d", usage, got, band)
+		}
+	}
+}
+
 // --- property tests -------------------------------------------------------
 //
 // Hand-rolled generators in the style of the binder property tests: a
@@ -0,0 +1,137 @@
+package funding
+
+import (
+	"math"
+	"math/bits"
+	"sort"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// shareMeter tracks each principal's Shared and Unfunded width, decayed at
+// one half-life, as the replay walks the lease timeline. Every budget that
+// sets spec.fairShare with that half-life reads the same meter.
+type shareMeter struct {
+	halfLife time.Duration
+	at       time.Time
+	usage    map[string]float64 // decayed GPU-hours by principal, as of at
+}
+
+// advance carries the meter from m.at to t, with each principal drawing
+// rate GPUs of family capacity throughout: the old figure decays by
+// 2^(-dt/halfLife) and the segment's own use is integrated under the same
+// curve, so a GPU-hour used at the very end of the segment counts whole.
+func (m *shareMeter) advance(t time.Time, rate map[string]int32) {
+	dt := t.Sub(m.at)
+	if dt <= 0 {
+		return
+	}
+	decay := math.Exp2(-float64(dt) / float64(m.halfLife))
+	for owner, used := range m.usage {
+		m.usage[owner] = used * decay
+	}
+	scale := m.halfLife.Hours() / math.Ln2 * (1 - decay)
+	for owner, width := range rate {
+		m.usage[owner] += float64(width) * scale
+	}
+	m.at = t
+}
+
+// shareBand is the rank penalty for a decayed figure: 0 below one GPU-hour,
+// then one per doubling. Banding keeps two claimants of about the same use
+// from trading places on every evaluation as their figures cross.
+func shareBand(usage float64) int32 {
+	if usage < 1 {
+		return 0
+	}
+	return int32(bits.Len64(uint64(usage)))
+}
+
+// newShareMeters returns one meter per distinct half-life the budgets ask
+// for, started at the first replay instant.
+func newShareMeters(budgets []v1.Budget, start time.Time) map[time.Duration]*shareMeter {
+	meters := make(map[time.Duration]*shareMeter)
+	for i := range budgets {
+		fs := budgets[i].Spec.FairShare
+		if fs == nil || fs.HalfLife.Duration <= 0 {
+			continue
+		}
+		if _, ok := meters[fs.HalfLife.Duration]; !ok {
+			meters[fs.HalfLife.Duration] = &shareMeter{halfLife: fs.HalfLife.Duration, at: start, usage: make(map[string]float64)}
+		}
+	}
+	return meters
+}
+
+// meter advances every share meter across [t0, t1) under one fill. Only
+// Shared and Unfunded width counts: an owner on its own envelope is spending
+// its grant, not the family's.
+func (res *fillResult) meter(ev *Evaluation, t1 time.Time) {
+	if len(ev.meters) == 0 {
+		return
+	}
+	rate := make(map[string]int32)
+	for _, f := range res.live {
+		if class := res.classes[f]; class != ClassShared && class != ClassUnfunded {
+			continue
+		}
+		if owner := ev.OwnerOf(f.lease.Spec.RunRef.Namespace); owner != "" {
+			rate[owner] += f.width
+		}
+	}
+	for _, m := range ev.meters {
+		m.advance(t1, rate)
+	}
+}
+
+// claimBand is the fair-share band of a family claim by owner on acct. Owner
+// claims and envelopes without the policy carry none.
+func (ev *Evaluation) claimBand(acct *EnvelopeAccount, tier int, owner string) int32 {
+	if tier <= TierOwner || acct.halfLife <= 0 {
+		return 0
+	}
+	m := ev.meters[acct.halfLife]
+	if m == nil {
+		return 0
+	}
+	return shareBand(m.usage[owner])
+}
+
+// ShareUsage is one family principal's decayed consumption as a budget with
+// a fair-share policy sees it.
+type ShareUsage struct {
+	Owner    string
+	GPUHours float64
+	Band     int32
+}
+
+// shareReportFloor hides the long tail of a decayed figure: below a hundredth
+// of a GPU-hour a principal has, for reporting, stopped using the family.
+const shareReportFloor = 0.01
+
+// FairShare lists, in owner order, the family principals of the budget's
+// owner with recent Shared or Unfunded use under the budget's half-life. It
+// is nil for a budget without the policy.
+func (ev *Evaluation) FairShare(namespace, budget string) []ShareUsage {
+	var acct *EnvelopeAccount
+	for key, env := range ev.envelopes {
+		if key.Namespace == namespace && key.Budget == budget {
+			acct = env
+			break
+		}
+	}
+	if acct == nil || acct.halfLife <= 0 || ev.meters[acct.halfLife] == nil {
+		return nil
+	}
+	m := ev.meters[acct.halfLife]
+	var out []ShareUsage
+	for owner, used := range m.usage {
+		if used < shareReportFloor || ev.Graph.Tier(acct.Owner, owner) <= TierOwner {
+			continue
+		}
+		out = append(out, ShareUsage{Owner: owner, GPUHours: used, Band: shareBand(used)})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Owner < out[j].Owner })
+	return out
+}
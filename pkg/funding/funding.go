@@ -177,9 +177,9 @@ func classForTier(tier int) Class {
 // own namespace — without it, their envelopes collide in the funding index and one
 // tenant silently charges (or shadows) the other's budget (Codex #1 / task #62).
 type EnvelopeKey struct {
-	Namespace string
-	Budget    string
-	Envelope  string
+	Namespace string `json:"namespace"`
+	Budget    string `json:"budget"`
+	Envelope  string `json:"envelope"`
 }
 
 // claimKey identifies a claim: one run's demand on one envelope.
@@ -195,8 +195,11 @@ type claimKey struct {
 // groups the shrink path would cut.
 type claim struct {
 	key       claimKey
-	tier      int  // family tier, or tierNone for sponsor/stranger claims
-	sponsored bool // true when tierNone: classified by the lending contract
+	tier      int    // family tier, or tierNone for sponsor/stranger claims
+	sponsored bool   // true when tierNone: classified by the lending contract
+	borrower  string // the run's owner; a contract lends to it by name
+	band      int32  // fair-share band on the envelope; 0 without the policy
+	priority  int32  // spec.priority as the envelope authorizes it
 	admitted  time.Time
 	name      string // deterministic tiebreak (run key; lease name for orphans)
 	malleable bool
@@ -212,20 +215,87 @@ type leaseFact struct {
 	name       string
 }
 
-// rankLess orders claims by (tier, admission time, name) — the normative
-// ranking from quota-semantics.md Decision 3. Sponsor claims are ordered
-// among themselves by (admission time, name); the caller keeps the two
-// pools separate.
+// rankLess orders claims by (tier, fair-share band, priority, admission time,
+// name) — the normative ranking from quota-semantics.md Decision 3, with the
+// fair-share band (lowest first) and the authorized priority (highest first)
+// ahead of admission time. Neither crosses a tier, so owner recall is
+// untouched. Sponsor claims carry no band and are ordered among themselves by
+// (priority, admission time, name); the caller keeps the two pools separate.
 func rankLess(a, b *claim) bool {
 	if a.tier != b.tier {
 		return a.tier < b.tier
 	}
+	if a.band != b.band {
+		return a.band < b.band
+	}
+	if a.priority != b.priority {
+		return a.priority > b.priority
+	}
 	if !a.admitted.Equal(b.admitted) {
 		return a.admitted.Before(b.admitted)
 	}
 	return a.name < b.name
 }
 
+// authorizedPriority is the part of a run's requested priority the envelope
+// grants: at most its maxPriority, which defaults to none.
+func authorizedPriority(env *v1.BudgetEnvelope, requested int32) int32 {
+	if requested < 0 {
+		return 0
+	}
+	if requested > env.MaxPriority {
+		return env.MaxPriority
+	}
+	return requested
+}
+
+// WaitingLess reports whether run a, waiting on envelope envA, goes before run
+// b, waiting on envB. A reservation pays from its run owner's own envelope, so
+// each is ranked as rankLess ranks an owner's claim, with no fair-share band:
+// the priority its envelope authorizes, then creation time, then run key. A
+// nil envelope authorizes none. ActivateReservations starts due reservations
+// in this order and kubectl-runs queue numbers the line by it.
+func WaitingLess(envA *v1.BudgetEnvelope, a *v1.Run, envB *v1.BudgetEnvelope, b *v1.Run) bool {
+	return rankLess(waitingClaim(envA, a), waitingClaim(envB, b))
+}
+
+func waitingClaim(env *v1.BudgetEnvelope, run *v1.Run) *claim {
+	if env == nil {
+		env = &v1.BudgetEnvelope{}
+	}
+	return &claim{
+		tier:     TierOwner,
+		priority: authorizedPriority(env, run.Spec.Priority),
+		admitted: run.CreationTimestamp.Time,
+		name:     keys.NamespacedKey(run.Namespace, run.Name),
+	}
+}
+
+// OwnEnvelope is the owner's envelope of that name, looked up the way the
+// controller resolves a reservation's PayingEnvelope: budgets by name, the
+// first match wins. It is nil for an unbound namespace's "" owner and when no
+// Budget of the owner carries one.
+func OwnEnvelope(budgets []v1.Budget, owner, name string) *v1.BudgetEnvelope {
+	if owner == "" {
+		return nil
+	}
+	var found *v1.BudgetEnvelope
+	budgetName := ""
+	for i := range budgets {
+		b := &budgets[i]
+		if b.Spec.Owner != owner || (found != nil && b.Name >= budgetName) {
+			continue
+		}
+		for j := range b.Spec.Envelopes {
+			if b.Spec.Envelopes[j].Name == name {
+				found, budgetName = &b.Spec.Envelopes[j], b.Name
+				break
+			}
+		}
+	}
+	return found
+}
+
 // LeaseKey names a lease for classification lookups.
 func LeaseKey(lease *v1.GPULease) string {
 	return keys.NamespacedKey(lease.Namespace, lease.Name)
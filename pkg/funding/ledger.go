@@ -0,0 +1,73 @@
+package funding
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// LeaseCharge is one lease's GPU-hours in one class on one UTC day: the
+// per-lease grain of a Statement, for ledger queries that filter and regroup
+// individual leases.
+type LeaseCharge struct {
+	// Lease is the lease's namespace/name.
+	Lease    string    `json:"lease"`
+	Day      time.Time `json:"day"`
+	Class    Class     `json:"class"`
+	GPUHours float64   `json:"gpuHours"`
+}
+
+type leaseDayKey struct {
+	lease string
+	day   time.Time
+	class Class
+}
+
+// LeaseCharges folds the ledger in in over [from, to) into each lease's
+// GPU-hours by UTC day and by the class the replay derived for it at the time,
+// the same replay Chargeback charges from. An archived window carries no
+// per-lease detail — its leases were compacted away — so a period that starts
+// before the archive horizon is refused rather than silently short of those
+// hours; Chargeback reports them by run.
+func LeaseCharges(in Input, from, to time.Time) ([]LeaseCharge, error) {
+	if !from.Before(to) {
+		return nil, fmt.Errorf("ledger period is empty: from %s is not before to %s", from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
+	if horizon := ArchiveHorizon(in.Archives); from.Before(horizon) {
+		return nil, fmt.Errorf("ledger period starts at %s, before the lease archive horizon %s: archived hours have no per-lease detail, start at the horizon or use report chargeback",
+			from.Format(time.RFC3339), horizon.Format(time.RFC3339))
+	}
+	in.Now = to
+	stmt := &Statement{From: from, To: to, hours: make(map[chargeKey]float64), owners: make(map[string]string),
+		leases: make(map[leaseDayKey]float64)}
+	evaluate(in, stmt)
+	charges := make([]LeaseCharge, 0, len(stmt.leases))
+	for key, hours := range stmt.leases {
+		charges = append(charges, LeaseCharge{Lease: key.lease, Day: key.day, Class: key.class, GPUHours: hours})
+	}
+	sort.Slice(charges, func(i, j int) bool {
+		a, b := charges[i], charges[j]
+		if a.Lease != b.Lease {
+			return a.Lease < b.Lease
+		}
+		if !a.Day.Equal(b.Day) {
+			return a.Day.Before(b.Day)
+		}
+		return a.Class < b.Class
+	})
+	return charges, nil
+}
+
+// chargeDays splits the already-clipped segment [t0, t1) at UTC midnights and
+// charges each day's share to the lease.
+func (s *Statement) chargeDays(lease string, class Class, width int32, t0, t1 time.Time) {
+	for t0.Before(t1) {
+		day := t0.UTC().Truncate(24 * time.Hour)
+		end := day.Add(24 * time.Hour)
+		if end.After(t1) {
+			end = t1
+		}
+		s.leases[leaseDayKey{lease: lease, day: day, class: class}] += float64(width) * end.Sub(t0).Hours()
+		t0 = end
+	}
+}
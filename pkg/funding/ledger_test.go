@@ -0,0 +1,63 @@
+package funding
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// Each lease's hours are split at UTC midnight and at the recall, in the class
+// the replay derived for each stretch.
+func TestLeaseChargesSplitByDayAndClass(t *testing.T) {
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil, env("west", 8)),
+		budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+	}
+	recall := base.Add(11*time.Hour + 30*time.Minute) // 23:30
+	childRun := runOf("child-train", "team/child", base, false)
+	ownerRun := runOf("boss-train", "team", recall, false)
+	leases := []v1.GPULease{
+		leaseOf("l-child", "child-train", "team", "team-budget", "west", 8, base, forRunOwner("team/child")),
+		leaseOf("l-boss", "boss-train", "team", "team-budget", "west", 4, recall),
+	}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(childRun, ownerRun)}
+
+	charges, err := LeaseCharges(in, base.Add(11*time.Hour), base.Add(13*time.Hour))
+	if err != nil {
+		t.Fatalf("lease charges: %v", err)
+	}
+	var got []string
+	for _, c := range charges {
+		name := c.Lease[strings.LastIndex(c.Lease, "/")+1:]
+		got = append(got, fmt.Sprintf("%s %s %s %.1f", name, c.Day.Format("01-02"), c.Class, c.GPUHours))
+	}
+	want := []string{
+		// team-child/ sorts ahead of team/.
+		"l-child 07-01 Shared 4.0",
+		"l-child 07-01 Unfunded 4.0",
+		"l-child 07-02 Unfunded 8.0",
+		"l-boss 07-01 Owned 2.0",
+		"l-boss 07-02 Owned 4.0",
+	}
+	if strings.Join(got, "; ") != strings.Join(want, "; ") {
+		t.Errorf("charges =\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+	if _, err := LeaseCharges(in, base, base); err == nil {
+		t.Errorf("an empty period must be rejected")
+	}
+
+	// Hours before the archive horizon were folded away lease by lease, so a
+	// period reaching back past it would come up short; it is refused.
+	in.Archives = []v1.LeaseArchive{{Spec: v1.LeaseArchiveSpec{Through: metav1.NewTime(base.Add(12 * time.Hour))}}}
+	if _, err := LeaseCharges(in, base.Add(11*time.Hour), base.Add(13*time.Hour)); err == nil || !strings.Contains(err.Error(), "archive horizon") {
+		t.Errorf("a period before the archive horizon must be refused, got %v", err)
+	}
+	if _, err := LeaseCharges(in, base.Add(12*time.Hour), base.Add(13*time.Hour)); err != nil {
+		t.Errorf("a period from the horizon: %v", err)
+	}
+}
@@ -0,0 +1,53 @@
+package funding
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+// A lease open across its envelope's end stays Owned when a renewal continues
+// the envelope, and its hours past the seam charge the renewal; without the
+// renewalOf link the same lease coasts Unfunded from the end.
+func TestRenewalCarriesLeasesAcrossTheSeam(t *testing.T) {
+	seam := base.Add(time.Hour)
+	now := base.Add(3 * time.Hour)
+	run := runOf("train", "team", base.Add(-3*time.Hour), false)
+	leases := []v1.GPULease{leaseOf("l1", "train", "team", "b", "gpus", 8, base.Add(-2*time.Hour))}
+	budgetWith := func(renewalOf string) v1.Budget {
+		return budgetOf("team", "b", nil,
+			env("gpus", 8, withWindow(base.Add(-24*time.Hour), seam)),
+			env("gpus-r2", 8, withWindow(seam, seam.Add(30*24*time.Hour)), func(e *v1.BudgetEnvelope) { e.RenewalOf = renewalOf }),
+		)
+	}
+
+	ev := Evaluate(Input{Budgets: []v1.Budget{budgetWith("gpus")}, Leases: leases, Runs: runsMap(run), Now: now})
+	if got := classOf(t, ev, leases, "l1"); got != ClassOwned {
+		t.Fatalf("a lease crossing a renewal seam must stay Owned, got %s", got)
+	}
+	old := ev.Envelope(EnvelopeKey{Namespace: "team", Budget: "b", Envelope: "gpus"})
+	renewal := ev.Envelope(EnvelopeKey{Namespace: "team", Budget: "b", Envelope: "gpus-r2"})
+	if renewal.WidthByClass[ClassOwned] != 8 || old.FundedWidth() != 0 {
+		t.Fatalf("width at now: renewal %v, old %v; want the renewal to carry all 8", renewal.WidthByClass, old.WidthByClass)
+	}
+	if old.ConsumedGPUHours != 24 || renewal.ConsumedGPUHours != 16 {
+		t.Fatalf("hours: old %.1f renewal %.1f, want 24 before the seam and 16 after", old.ConsumedGPUHours, renewal.ConsumedGPUHours)
+	}
+
+	ev = Evaluate(Input{Budgets: []v1.Budget{budgetWith("")}, Leases: leases, Runs: runsMap(run), Now: now})
+	if got := classOf(t, ev, leases, "l1"); got != ClassUnfunded {
+		t.Fatalf("an unrelated envelope must not continue the lease, got %s", got)
+	}
+
+	// A renewal written after the window had already closed opens later; the
+	// lease does not continue across the gap, even once the renewal opens.
+	late := budgetOf("team", "b", nil,
+		env("gpus", 8, withWindow(base.Add(-24*time.Hour), seam)),
+		env("gpus-r2", 8, withWindow(seam.Add(time.Hour), seam.Add(30*24*time.Hour)), func(e *v1.BudgetEnvelope) { e.RenewalOf = "gpus" }),
+	)
+	ev = Evaluate(Input{Budgets: []v1.Budget{late}, Leases: leases, Runs: runsMap(run), Now: now})
+	if got := classOf(t, ev, leases, "l1"); got != ClassUnfunded {
+		t.Fatalf("a lease must not continue across a gap between windows, got %s", got)
+	}
+}
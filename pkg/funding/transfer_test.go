@@ -0,0 +1,94 @@
+package funding
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func transferOf(borrower string, concurrency int32, start, end time.Time) v1.SnapshotTransfer {
+	return v1.SnapshotTransfer{
+		Name:        "loan",
+		Lender:      "team",
+		Borrower:    borrower,
+		From:        v1.TransferSource{Namespace: nsForOwner("team"), Budget: "team-budget", Envelope: "west"},
+		Flavor:      testFlavor,
+		Concurrency: concurrency,
+		Start:       v1.NewTime(start),
+		End:         v1.NewTime(end),
+	}
+}
+
+// A transfer lends without any LendingPolicy: its borrower's width is Borrowed
+// while the window is open and coasts Unfunded once it closes, with nothing
+// deleted in between.
+func TestTransferFundsBorrowedUntilItExpires(t *testing.T) {
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil, env("west", 8)),
+		budgetOf("org:other", "other-budget", nil, env("other", 1)),
+	}
+	guest := runOf("guest", "org:other", base, false)
+	leases := []v1.GPULease{leaseOf("l-guest", "guest", "team", "team-budget", "west", 4, base, forRunOwner("org:other"))}
+	transfers := []v1.SnapshotTransfer{transferOf("org:other", 4, base, base.Add(2*time.Hour))}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(guest), Transfers: transfers, Now: base.Add(time.Hour)}
+
+	ev := Evaluate(in)
+	if got := classOf(t, ev, leases, "l-guest"); got != ClassBorrowed {
+		t.Fatalf("transferred width should be Borrowed, got %s", got)
+	}
+	if got := ev.Run("org-other/guest").Lenders["team"]; got != 4 {
+		t.Errorf("lender attribution: %d, want 4 from team", got)
+	}
+	key := EnvelopeKey{Namespace: "team", Budget: "team-budget", Envelope: "west"}
+	if got := ev.AvailableWidth(key, "org:other", 0, in.Now, "", true); got != 0 {
+		t.Errorf("a spent transfer leaves %d to admit, want 0", got)
+	}
+	if got := ev.TransfersTo("org:other"); len(got) != 1 || got[0] != key {
+		t.Errorf("TransfersTo: %v, want the west envelope", got)
+	}
+
+	// Without the transfer the same lease has no contract at all.
+	in.Transfers = nil
+	if got := classOf(t, Evaluate(in), leases, "l-guest"); got != ClassUnfunded {
+		t.Errorf("without a transfer or lending policy the lease should be Unfunded, got %s", got)
+	}
+
+	// An hour past the end: expired, and the replay split the hours there.
+	in.Transfers, in.Now = transfers, base.Add(3*time.Hour)
+	ev = Evaluate(in)
+	if got := classOf(t, ev, leases, "l-guest"); got != ClassUnfunded {
+		t.Errorf("after the transfer ends the lease should be Unfunded, got %s", got)
+	}
+	hours := ev.Run("org-other/guest").GPUHours
+	if hours[ClassBorrowed] != 8 || hours[ClassUnfunded] != 4 {
+		t.Errorf("hours: %v, want 8 borrowed then 4 unfunded", hours)
+	}
+	if len(ev.TransfersTo("org:other")) != 0 {
+		t.Error("an expired transfer is still offered to the planner")
+	}
+}
+
+// A transfer to family holds even where family sharing is switched off, and
+// stays senior to the owner's own later claims: it is a contract, not excess.
+func TestTransferToFamilyIsNotRecallable(t *testing.T) {
+	budgets := []v1.Budget{
+		budgetOf("team", "team-budget", nil, env("west", 8, withSharing(v1.SharingNone))),
+		budgetOf("team/child", "child-budget", []string{"team"}, env("scratch", 1)),
+	}
+	child := runOf("child-train", "team/child", base, false)
+	owner := runOf("boss", "team", base.Add(time.Minute), false)
+	leases := []v1.GPULease{
+		leaseOf("l-child", "child-train", "team", "team-budget", "west", 6, base, forRunOwner("team/child")),
+		leaseOf("l-boss", "boss", "team", "team-budget", "west", 4, base.Add(time.Minute)),
+	}
+	in := Input{Budgets: budgets, Leases: leases, Runs: runsMap(child, owner), Now: base.Add(time.Hour),
+		Transfers: []v1.SnapshotTransfer{transferOf("team/child", 6, base, base.Add(24*time.Hour))}}
+	ev := Evaluate(in)
+	if got := classOf(t, ev, leases, "l-child"); got != ClassBorrowed {
+		t.Errorf("the child's transferred claim should be Borrowed, got %s", got)
+	}
+	if got := classOf(t, ev, leases, "l-boss"); got != ClassUnfunded {
+		t.Errorf("the owner's claim beyond what it transferred away should be Unfunded, got %s", got)
+	}
+}
@@ -0,0 +1,144 @@
+// Package goodput sizes malleable runs by what their GPUs are measured to be
+// worth. A run reports its throughput; the curve of throughput against width
+// decides whether the next stepGPUs is worth funding, whether the last one
+// should be given back, and — for the resolver — whose width costs the least
+// progress to take.
+package goodput
+
+import (
+	"sort"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+const (
+	// DefaultMinGainPercent is the gain a step up must return when the policy
+	// leaves MinGainPercent unset.
+	DefaultMinGainPercent = 10
+	// DefaultCooldown is the settle time between resizes when the policy leaves
+	// Cooldown unset: long enough for a data-parallel job to re-rendezvous and
+	// for its reported throughput to reflect the new width.
+	DefaultCooldown = 10 * time.Minute
+)
+
+// MinGain returns the policy's grow threshold as a fraction.
+func MinGain(p *v1.RunGoodputPolicy) float64 {
+	if p == nil || p.MinGainPercent <= 0 {
+		return DefaultMinGainPercent / 100.0
+	}
+	return float64(p.MinGainPercent) / 100.0
+}
+
+// Cooldown returns the policy's settle time.
+func Cooldown(p *v1.RunGoodputPolicy) time.Duration {
+	if p == nil || p.Cooldown.Duration <= 0 {
+		return DefaultCooldown
+	}
+	return p.Cooldown.Duration
+}
+
+// Throughput returns the throughput recorded at gpus.
+func Throughput(curve []v1.RunGoodputPoint, gpus int32) (float64, bool) {
+	for _, pt := range curve {
+		if pt.GPUs == gpus {
+			return pt.Throughput, true
+		}
+	}
+	return 0, false
+}
+
+// Record sets the throughput observed at gpus, replacing any older observation
+// at that width, and keeps the curve ascending by width.
+func Record(curve []v1.RunGoodputPoint, gpus int32, throughput float64, at time.Time) []v1.RunGoodputPoint {
+	point := v1.RunGoodputPoint{GPUs: gpus, Throughput: throughput, ObservedAt: v1.NewTime(at)}
+	for i := range curve {
+		if curve[i].GPUs == gpus {
+			curve[i] = point
+			return curve
+		}
+	}
+	curve = append(curve, point)
+	sort.Slice(curve, func(i, j int) bool { return curve[i].GPUs < curve[j].GPUs })
+	return curve
+}
+
+// Gain is what each GPU added between lo and hi returned, as a fraction of the
+// average per-GPU throughput at lo: 1 is linear scaling, 0 is GPUs that added
+// nothing, and a negative gain is GPUs that made the run slower. ok is false
+// unless both widths have been measured and lo did useful work.
+func Gain(curve []v1.RunGoodputPoint, lo, hi int32) (float64, bool) {
+	if lo <= 0 || hi <= lo {
+		return 0, false
+	}
+	tlo, ok := Throughput(curve, lo)
+	if !ok || tlo <= 0 {
+		return 0, false
+	}
+	thi, ok := Throughput(curve, hi)
+	if !ok {
+		return 0, false
+	}
+	marginal := (thi - tlo) / float64(hi-lo)
+	return marginal / (tlo / float64(lo)), true
+}
+
+// Input is one sizing decision for a run settled at Width.
+type Input struct {
+	Curve []v1.RunGoodputPoint
+	Width int32
+	// Min and Ceiling bound the target: spec.malleable.minTotalGPUs and the
+	// desired width the owner asked for.
+	Min, Ceiling, Step int32
+	MinGain            float64
+	// Funded is false while any of the run's width is unfunded. A step up is
+	// then not considered: a marginal GPU nobody pays for is not a gain the
+	// owner chose, and it is the first thing the resolver takes back.
+	Funded bool
+}
+
+// Decide returns the width to steer toward next: one step down, one step up,
+// or Width.
+//
+// Hysteresis is the gap between the two thresholds. A step is given back only
+// when its GPUs added nothing (gain <= 0), and taken only when the next step is
+// worth at least MinGain — measured, or, for a width never tried, inferred from
+// the last step having cleared the same bar. A run whose last step landed in
+// between holds where it is, so noise around a plateau does not flap leases.
+func Decide(in Input) int32 {
+	if in.Step <= 0 {
+		return in.Width
+	}
+	if t, ok := Throughput(in.Curve, in.Width); !ok || t <= 0 {
+		return in.Width
+	}
+	down, up := in.Width-in.Step, in.Width+in.Step
+	last, measured := Gain(in.Curve, down, in.Width)
+	if down >= in.Min && measured && last <= 0 {
+		return down
+	}
+	if up > in.Ceiling || !in.Funded {
+		return in.Width
+	}
+	if next, ok := Gain(in.Curve, in.Width, up); ok {
+		if next >= in.MinGain {
+			return up
+		}
+		return in.Width
+	}
+	if !measured || last >= in.MinGain {
+		return up
+	}
+	return in.Width
+}
+
+// Slope is the gain of the step that brought a run to its current target: how
+// much its newest GPUs are worth. ok is false for a run not sized by goodput
+// or without both ends of that step measured.
+func Slope(run *v1.Run) (float64, bool) {
+	if run == nil || run.Spec.Malleable == nil || run.Status.Goodput == nil {
+		return 0, false
+	}
+	status := run.Status.Goodput
+	return Gain(status.Curve, status.TargetGPUs-run.Spec.Malleable.StepGPUs, status.TargetGPUs)
+}
@@ -0,0 +1,72 @@
+package goodput
+
+import (
+	"testing"
+	"time"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func curve(points ...float64) []v1.RunGoodputPoint {
+	// points are throughputs at 8, 16, 24, ... GPUs; a negative entry is unmeasured.
+	var out []v1.RunGoodputPoint
+	for i, t := range points {
+		if t < 0 {
+			continue
+		}
+		out = append(out, v1.RunGoodputPoint{GPUs: int32(8 * (i + 1)), Throughput: t})
+	}
+	return out
+}
+
+func TestDecideStepsOnlyWhenTheCurvePays(t *testing.T) {
+	cases := []struct {
+		name   string
+		curve  []v1.RunGoodputPoint
+		width  int32
+		min    int32
+		funded bool
+		want   int32
+	}{
+		{"first width explores upward", curve(100), 8, 8, true, 16},
+		{"a step that scaled explores the next", curve(100, 180), 16, 8, true, 24},
+		{"a step inside the dead band holds", curve(100, 104), 16, 8, true, 16},
+		{"a step that added nothing is given back", curve(100, 99), 16, 8, true, 8},
+		{"a measured poor next step is not retried", curve(100, 180, 185), 16, 8, true, 16},
+		{"a measured good next step is retaken", curve(100, 180, 250), 16, 8, true, 24},
+		{"never above the ceiling", curve(100, 180, 250, 320), 32, 8, true, 32},
+		{"never below the minimum", curve(-1, 100, 90), 24, 24, true, 24},
+		{"unfunded width does not grow", curve(100, 180), 16, 8, false, 16},
+		{"unfunded width still gives back a dead step", curve(100, 90), 16, 8, false, 8},
+		{"no report at the current width holds", curve(100), 16, 8, true, 16},
+	}
+	for _, tc := range cases {
+		got := Decide(Input{Curve: tc.curve, Width: tc.width, Min: tc.min, Ceiling: 32, Step: 8, MinGain: 0.1, Funded: tc.funded})
+		if got != tc.want {
+			t.Errorf("%s: Decide = %d, want %d", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestGainIsPerAddedGPURelativeToTheAverage(t *testing.T) {
+	c := curve(100, 200, 250)
+	if g, ok := Gain(c, 8, 16); !ok || g != 1 {
+		t.Errorf("linear scaling: gain = %v, %v; want 1", g, ok)
+	}
+	if g, ok := Gain(c, 16, 24); !ok || g != 0.5 {
+		t.Errorf("half scaling: gain = %v, %v; want 0.5", g, ok)
+	}
+	if _, ok := Gain(c, 24, 32); ok {
+		t.Errorf("an unmeasured width has no gain")
+	}
+}
+
+func TestRecordReplacesAndKeepsTheCurveSorted(t *testing.T) {
+	at := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	c := Record(nil, 16, 180, at)
+	c = Record(c, 8, 100, at)
+	c = Record(c, 16, 170, at.Add(time.Minute))
+	if len(c) != 2 || c[0].GPUs != 8 || c[1].GPUs != 16 || c[1].Throughput != 170 {
+		t.Errorf("curve = %+v, want 8→100, 16→170", c)
+	}
+}
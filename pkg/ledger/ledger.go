@@ -0,0 +1,67 @@
+// Package ledger reads the GPU-hour ledger from the apiserver: the budgets,
+// leases, lease archives, runs, and published transfers a funding replay
+// classifies. The manager's reconcilers and endpoints, the admission webhook,
+// the aggregator, and kubectl-runs all list it through here, so they all list
+// it in the one order the lease compactor relies on. The order only holds
+// against the apiserver; the webhook reads the manager's cache for a preview
+// that merely warns.
+package ledger
+
+import (
+	"context"
+	"fmt"
+
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+	"github.com/davidlangworthy/jobtree/pkg/snapshot"
+)
+
+// List reads the whole ledger into a funding.Input, leaving Now and Period to
+// the caller. The classification is global, so even a question about one owner
+// needs every other owner's claims.
+//
+// Leases are listed BEFORE archives. The compactor creates an archive before it
+// deletes the leases it folds, so a lease missing from the first list is in an
+// archive the second one sees; the reverse order could miss both.
+func List(ctx context.Context, reader client.Reader) (funding.Input, error) {
+	var budgets v1.BudgetList
+	if err := reader.List(ctx, &budgets); err != nil {
+		return funding.Input{}, fmt.Errorf("list budgets: %w", err)
+	}
+	var leases v1.GPULeaseList
+	if err := reader.List(ctx, &leases); err != nil {
+		return funding.Input{}, fmt.Errorf("list leases: %w", err)
+	}
+	var archives v1.LeaseArchiveList
+	if err := reader.List(ctx, &archives); err != nil {
+		return funding.Input{}, fmt.Errorf("list lease archives: %w", err)
+	}
+	var runs v1.RunList
+	if err := reader.List(ctx, &runs); err != nil {
+		return funding.Input{}, fmt.Errorf("list runs: %w", err)
+	}
+	var snapshots v1.QuotaSnapshotList
+	if err := reader.List(ctx, &snapshots); err != nil {
+		return funding.Input{}, fmt.Errorf("list quota snapshots: %w", err)
+	}
+	return funding.Input{
+		Budgets:   budgets.Items,
+		Leases:    leases.Items,
+		Runs:      RunIndex(runs.Items),
+		Archives:  archives.Items,
+		Transfers: snapshot.PublishedTransfers(snapshots.Items),
+	}, nil
+}
+
+// RunIndex keys runs by keys.NamespacedKey, the form funding.Input.Runs takes.
+func RunIndex(runs []v1.Run) map[string]*v1.Run {
+	index := make(map[string]*v1.Run, len(runs))
+	for i := range runs {
+		run := &runs[i]
+		index[keys.NamespacedKey(run.Namespace, run.Name)] = run
+	}
+	return index
+}
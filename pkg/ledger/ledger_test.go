@@ -0,0 +1,52 @@
+package ledger
+
+import (
+	"context"
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+func TestListReadsLeasesBeforeArchives(t *testing.T) {
+	scheme := runtime.NewScheme()
+	if err := v1.AddToScheme(scheme); err != nil {
+		t.Fatal(err)
+	}
+	var order []string
+	c := fake.NewClientBuilder().WithScheme(scheme).
+		WithObjects(
+			&v1.Budget{ObjectMeta: metav1.ObjectMeta{Name: "b", Namespace: "team"}},
+			&v1.Run{ObjectMeta: metav1.ObjectMeta{Name: "train", Namespace: "team"}},
+			&v1.GPULease{ObjectMeta: metav1.ObjectMeta{Name: "l", Namespace: "team"}},
+			&v1.LeaseArchive{ObjectMeta: metav1.ObjectMeta{Name: "a"}},
+		).
+		WithInterceptorFuncs(interceptor.Funcs{
+			List: func(ctx context.Context, c client.WithWatch, list client.ObjectList, opts ...client.ListOption) error {
+				switch list.(type) {
+				case *v1.GPULeaseList:
+					order = append(order, "leases")
+				case *v1.LeaseArchiveList:
+					order = append(order, "archives")
+				}
+				return c.List(ctx, list, opts...)
+			},
+		}).
+		Build()
+
+	in, err := List(context.Background(), c)
+	if err != nil {
+		t.Fatalf("list: %v", err)
+	}
+	if len(order) != 2 || order[0] != "leases" {
+		t.Errorf("leases must be listed before archives, got %v", order)
+	}
+	if len(in.Budgets) != 1 || len(in.Leases) != 1 || len(in.Archives) != 1 || in.Runs["team/train"] == nil {
+		t.Errorf("unexpected ledger %+v", in)
+	}
+}
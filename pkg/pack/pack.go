@@ -1,6 +1,7 @@
 package pack
 
 import (
+	"errors"
 	"fmt"
 	"sort"
 
@@ -14,6 +15,12 @@ type Request struct {
 	GroupGPUs             *int
 	AllowCrossGroupSpread bool
 	SparesPerGroup        int
+	// GroupWithin names the hierarchy level each group must fit inside one
+	// unit of; empty lets a group span its domain.
+	GroupWithin string
+	// RunWithin names the hierarchy level the whole request, spares included,
+	// must fit inside one unit of; empty lets it span domains.
+	RunWithin string
 }
 
 // FailureReason explains why planning failed.
@@ -41,9 +48,16 @@ type NodeAllocation struct {
 
 // GroupPlacement captures where a logical group of GPUs will run.
 type GroupPlacement struct {
-	GroupIndex      int
-	Size            int
-	Domain          topology.DomainKey
+	GroupIndex int
+	// Role names the RunRole the group was packed for. Empty for a plan from
+	// Planner, which packs one uniform gang; PlanGang sets it on every group.
+	Role   string
+	Size   int
+	Domain topology.DomainKey
+	// Within names the innermost hierarchy level one unit of which holds the
+	// group's active GPUs; empty when the group spans its domain or the
+	// snapshot has no hierarchy.
+	Within          string
 	NodePlacements  []NodeAllocation
 	Spares          int
 	SparePlacements []NodeAllocation
@@ -73,28 +87,166 @@ func Planner(snapshot *topology.Snapshot, req Request) (Plan, error) {
 		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "snapshot flavor mismatch"}
 	}
 
-	work := snapshot.Clone()
+	return planRun(snapshot.Clone(), req.RunWithin, func(work *topology.Snapshot) (Plan, error) {
+		return plan(work, req)
+	})
+}
+
+// plan dispatches to the packing strategy on a working snapshot it may mutate.
+func plan(work *topology.Snapshot, req Request) (Plan, error) {
+	minLevel, err := levelIndex(work.Hierarchy, req.GroupWithin)
+	if err != nil {
+		return Plan{}, err
+	}
 	if !req.AllowCrossGroupSpread {
-		return planSingleDomain(work, req)
+		return planSingleDomain(work, req, minLevel)
 	}
 	if req.GroupGPUs != nil {
-		return planWithGroups(work, req)
+		return planWithGroups(work, req, minLevel)
 	}
-	return planFillDomains(work, req)
+	return planFillDomains(work, req, minLevel)
 }
 
-func planSingleDomain(snapshot *topology.Snapshot, req Request) (Plan, error) {
-	var candidate *topology.Domain
-	sorted := snapshot.SortedDomains()
-	for _, dom := range sorted {
-		if dom.FreeGPUs() >= req.TotalGPUs {
-			candidate = dom
-			break
+// levelIndex resolves a hierarchy level name; "" is -1, the whole domain.
+func levelIndex(h topology.Hierarchy, name string) (int, error) {
+	if name == "" {
+		return -1, nil
+	}
+	i, ok := h.Index(name)
+	if !ok {
+		return 0, &PlanError{Reason: FailureReasonInvalidRequest, Msg: fmt.Sprintf("unknown topology level %q", name)}
+	}
+	return i, nil
+}
+
+// planRun runs fn against work, or, when within names a hierarchy level,
+// against each unit of that level in turn, keeping the plan whose widest group
+// spans the least (the first such unit in order of free capacity). The kept
+// plan's usage is applied to work.
+func planRun(work *topology.Snapshot, within string, fn func(*topology.Snapshot) (Plan, error)) (Plan, error) {
+	if within == "" {
+		return fn(work)
+	}
+	level, err := levelIndex(work.Hierarchy, within)
+	if err != nil {
+		return Plan{}, err
+	}
+	units := work.UnitsAt(level)
+	sort.SliceStable(units, func(i, j int) bool { return units[i].FreeGPUs() > units[j].FreeGPUs() })
+	var best Plan
+	var bestWork *topology.Snapshot
+	bestSpan := 0
+	for _, unit := range units {
+		if unit.FreeGPUs() == 0 {
+			continue
+		}
+		trial := work.Within(level, unit.Key)
+		p, err := fn(trial)
+		var perr *PlanError
+		if errors.As(err, &perr) && perr.Reason == FailureReasonInvalidRequest {
+			return Plan{}, err
+		}
+		if err != nil {
+			continue
+		}
+		if span := widestSpan(p, work.Hierarchy); bestWork == nil || span > bestSpan {
+			best, bestWork, bestSpan = p, trial, span
 		}
 	}
-	if candidate == nil {
-		return Plan{}, &PlanError{Reason: FailureReasonInsufficientTopology, Msg: "no single domain can satisfy request"}
+	if bestWork == nil {
+		return Plan{}, &PlanError{Reason: FailureReasonInsufficientTopology, Msg: fmt.Sprintf("no single %s can satisfy request", within)}
 	}
+	work.CopyUsage(bestWork)
+	best.Residual = computeResidual(work)
+	return best, nil
+}
+
+// widestSpan is the outermost level any group of p spans: -1 when a group
+// spans its domain, len(levels) when p has no groups.
+func widestSpan(p Plan, h topology.Hierarchy) int {
+	widest := len(h.Levels)
+	for _, g := range p.Groups {
+		level := -1
+		if g.Within != "" {
+			level, _ = h.Index(g.Within)
+		}
+		if level < widest {
+			widest = level
+		}
+	}
+	return widest
+}
+
+// RoleRequest is one role of a heterogeneous gang. Flavor is ignored: a gang
+// has one flavor, passed to PlanGang.
+type RoleRequest struct {
+	Name string
+	Request
+}
+
+// PlanGang packs a multi-role gang role by role against ONE working copy of
+// the snapshot, so each role sees only the capacity the roles before it left:
+// the gang fits as a whole or the call fails, never half of it. Each role
+// keeps its own group size and spread setting (a learner may insist on one
+// fast-fabric domain while its rollout actors spread), and a role with spread
+// disallowed is held to one domain for ITSELF only — roles are separate
+// process groups, so the gang as a whole may span domains.
+//
+// Groups are numbered consecutively across roles in request order and carry
+// their Role, so a group index still names exactly one slice of the gang (the
+// resolver and the node-failure path address work by it). RunWithin holds the
+// whole gang to one unit of a hierarchy level.
+func PlanGang(snapshot *topology.Snapshot, flavor string, roles []RoleRequest) (Plan, error) {
+	if snapshot == nil {
+		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "snapshot is nil"}
+	}
+	if len(roles) == 0 {
+		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "gang has no roles"}
+	}
+	if flavor == "" {
+		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "flavor must be set"}
+	}
+	if flavor != snapshot.Flavor {
+		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "snapshot flavor mismatch"}
+	}
+	// RunWithin bounds the gang, not a role, so every role must carry the same.
+	within := roles[0].RunWithin
+	for _, role := range roles[1:] {
+		if role.RunWithin != within {
+			return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: fmt.Sprintf("role %q: runWithin %q differs from the gang's %q", role.Name, role.RunWithin, within)}
+		}
+	}
+	return planRun(snapshot.Clone(), within, func(work *topology.Snapshot) (Plan, error) {
+		out := Plan{Flavor: flavor}
+		for _, role := range roles {
+			req := role.Request
+			req.Flavor = flavor
+			if req.TotalGPUs <= 0 {
+				return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: fmt.Sprintf("role %q: totalGPUs must be positive", role.Name)}
+			}
+			part, err := plan(work, req)
+			if err != nil {
+				var perr *PlanError
+				if errors.As(err, &perr) {
+					return Plan{}, &PlanError{Reason: perr.Reason, Msg: fmt.Sprintf("role %q: %s", role.Name, perr.Msg)}
+				}
+				return Plan{}, err
+			}
+			offset := len(out.Groups)
+			for _, g := range part.Groups {
+				g.GroupIndex += offset
+				g.Role = role.Name
+				out.Groups = append(out.Groups, g)
+			}
+			out.TotalGPUs += part.TotalGPUs
+			out.TotalSpares += part.TotalSpares
+		}
+		out.Residual = computeResidual(work)
+		return out, nil
+	})
+}
+
+func planSingleDomain(snapshot *topology.Snapshot, req Request, minLevel int) (Plan, error) {
 	groups := DeriveGroups(req.TotalGPUs, req.GroupGPUs)
 	// Symmetric with planWithGroups: DeriveGroups returns empty for GroupGPUs<=0.
 	// Run.Validate rejects that at admission, so this is unreachable in production —
@@ -103,16 +255,57 @@ func planSingleDomain(snapshot *topology.Snapshot, req Request) (Plan, error) {
 	if len(groups) == 0 {
 		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "no groups derived"}
 	}
+	var candidates []*topology.Domain
+	for _, dom := range snapshot.SortedDomains() {
+		if dom.FreeGPUs() >= req.TotalGPUs {
+			candidates = append(candidates, dom)
+		}
+	}
+	if len(candidates) == 0 {
+		return Plan{}, &PlanError{Reason: FailureReasonInsufficientTopology, Msg: "no single domain can satisfy request"}
+	}
+	if len(snapshot.Hierarchy.Levels) == 0 {
+		return planInDomain(snapshot, candidates[0].Key, groups, req, minLevel)
+	}
+	// With tiers, the roomiest domain is not necessarily the tightest: try each
+	// and keep the one whose widest group spans least, earliest on a tie.
+	var best Plan
+	var bestWork *topology.Snapshot
+	bestSpan := 0
+	var lastErr error
+	for _, dom := range candidates {
+		trial := snapshot.Clone()
+		p, err := planInDomain(trial, dom.Key, groups, req, minLevel)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		if span := widestSpan(p, snapshot.Hierarchy); bestWork == nil || span > bestSpan {
+			best, bestWork, bestSpan = p, trial, span
+		}
+	}
+	if bestWork == nil {
+		return Plan{}, lastErr
+	}
+	snapshot.CopyUsage(bestWork)
+	best.Residual = computeResidual(snapshot)
+	return best, nil
+}
+
+// planInDomain places every group, and then the spares, in one domain.
+func planInDomain(snapshot *topology.Snapshot, key topology.DomainKey, groups []int, req Request, minLevel int) (Plan, error) {
+	dom, _ := snapshot.DomainByKey(key)
 	var placements []GroupPlacement
 	for idx, size := range groups {
-		allocs, err := allocateInDomain(candidate, size)
+		allocs, within, err := allocateGroup(snapshot.Hierarchy, dom, size, minLevel)
 		if err != nil {
 			return Plan{}, err
 		}
 		placements = append(placements, GroupPlacement{
 			GroupIndex:     idx,
 			Size:           size,
-			Domain:         candidate.Key,
+			Domain:         dom.Key,
+			Within:         within,
 			NodePlacements: allocs,
 		})
 	}
@@ -124,7 +317,7 @@ func planSingleDomain(snapshot *topology.Snapshot, req Request) (Plan, error) {
 	return Plan{Flavor: req.Flavor, TotalGPUs: req.TotalGPUs, Groups: placements, Residual: residual, TotalSpares: totalSpares}, nil
 }
 
-func planWithGroups(snapshot *topology.Snapshot, req Request) (Plan, error) {
+func planWithGroups(snapshot *topology.Snapshot, req Request, minLevel int) (Plan, error) {
 	groups := DeriveGroups(req.TotalGPUs, req.GroupGPUs)
 	if len(groups) == 0 {
 		return Plan{}, &PlanError{Reason: FailureReasonInvalidRequest, Msg: "no groups derived"}
@@ -132,12 +325,12 @@ func planWithGroups(snapshot *topology.Snapshot, req Request) (Plan, error) {
 	var placements []GroupPlacement
 	domainUsage := make(map[*topology.Domain]int)
 	for idx, size := range groups {
-		sorted := snapshot.SortedDomains()
+		sorted := tightestDomains(snapshot.SortedDomains(), size, minLevel)
 		dom := chooseDomainForGroup(sorted, domainUsage, size)
 		if dom == nil {
 			return Plan{}, &PlanError{Reason: FailureReasonInsufficientCapacity, Msg: fmt.Sprintf("insufficient capacity for group %d", idx)}
 		}
-		allocs, err := allocateInDomain(dom, size)
+		allocs, within, err := allocateGroup(snapshot.Hierarchy, dom, size, minLevel)
 		if err != nil {
 			return Plan{}, err
 		}
@@ -146,6 +339,7 @@ func planWithGroups(snapshot *topology.Snapshot, req Request) (Plan, error) {
 			GroupIndex:     idx,
 			Size:           size,
 			Domain:         dom.Key,
+			Within:         within,
 			NodePlacements: allocs,
 		})
 	}
@@ -157,15 +351,16 @@ func planWithGroups(snapshot *topology.Snapshot, req Request) (Plan, error) {
 	return Plan{Flavor: req.Flavor, TotalGPUs: req.TotalGPUs, Groups: placements, Residual: residual, TotalSpares: totalSpares}, nil
 }
 
-func planFillDomains(snapshot *topology.Snapshot, req Request) (Plan, error) {
+func planFillDomains(snapshot *topology.Snapshot, req Request, minLevel int) (Plan, error) {
 	remaining := req.TotalGPUs
 	var placements []GroupPlacement
 	groupIndex := 0
 	for remaining > 0 {
 		sorted := snapshot.SortedDomains()
 		var dom *topology.Domain
+		room := 0
 		for _, candidate := range sorted {
-			if candidate.FreeGPUs() > 0 {
+			if room = roomAt(candidate, minLevel); room > 0 {
 				dom = candidate
 				break
 			}
@@ -173,11 +368,11 @@ func planFillDomains(snapshot *topology.Snapshot, req Request) (Plan, error) {
 		if dom == nil {
 			return Plan{}, &PlanError{Reason: FailureReasonInsufficientCapacity, Msg: "insufficient capacity"}
 		}
-		assign := dom.FreeGPUs()
+		assign := room
 		if assign > remaining {
 			assign = remaining
 		}
-		allocs, err := allocateInDomain(dom, assign)
+		allocs, within, err := allocateGroup(snapshot.Hierarchy, dom, assign, minLevel)
 		if err != nil {
 			return Plan{}, err
 		}
@@ -185,6 +380,7 @@ func planFillDomains(snapshot *topology.Snapshot, req Request) (Plan, error) {
 			GroupIndex:     groupIndex,
 			Size:           assign,
 			Domain:         dom.Key,
+			Within:         within,
 			NodePlacements: allocs,
 		})
 		remaining -= assign
@@ -198,6 +394,118 @@ func planFillDomains(snapshot *topology.Snapshot, req Request) (Plan, error) {
 	return Plan{Flavor: req.Flavor, TotalGPUs: req.TotalGPUs, Groups: placements, Residual: residual, TotalSpares: totalSpares}, nil
 }
 
+// roomAt is the largest group dom can take inside one unit of level: its whole
+// free capacity for -1.
+func roomAt(dom *topology.Domain, level int) int {
+	if level < 0 {
+		return dom.FreeGPUs()
+	}
+	room := 0
+	for _, unit := range dom.UnitsAt(level) {
+		if free := unit.FreeGPUs(); free > room {
+			room = free
+		}
+	}
+	return room
+}
+
+// fitLevel is the innermost level with one unit of dom that can take size
+// GPUs: -1 when only the domain as a whole can, -2 when not even that.
+func fitLevel(dom *topology.Domain, levels, size int) int {
+	for level := levels - 1; level >= 0; level-- {
+		if roomAt(dom, level) >= size {
+			return level
+		}
+	}
+	if dom.FreeGPUs() >= size {
+		return -1
+	}
+	return -2
+}
+
+// tightestDomains narrows domains to those that hold a group of size in the
+// innermost unit any of them can, and at least as deep as minLevel. Without
+// tiers every domain fits at -1 and the list is unchanged.
+func tightestDomains(domains []*topology.Domain, size, minLevel int) []*topology.Domain {
+	if len(domains) == 0 || len(domains[0].Nodes) == 0 || len(domains[0].Nodes[0].Path) == 0 {
+		return domains
+	}
+	levels := len(domains[0].Nodes[0].Path)
+	best := -2
+	fits := make([]int, len(domains))
+	for i, dom := range domains {
+		fits[i] = fitLevel(dom, levels, size)
+		if fits[i] >= minLevel && fits[i] > best {
+			best = fits[i]
+		}
+	}
+	var out []*topology.Domain
+	for i, dom := range domains {
+		if best > -2 && fits[i] == best {
+			out = append(out, dom)
+		}
+	}
+	return out
+}
+
+// allocateGroup places one group of size in dom inside the innermost unit
+// that can hold it — the deepest level first, the tightest fit at that level,
+// so a small group does not break up a large free unit — and never wider than
+// minLevel. Without tiers it is allocateInDomain. It returns the name of the
+// innermost level one unit of which holds the group.
+func allocateGroup(h topology.Hierarchy, dom *topology.Domain, size, minLevel int) ([]NodeAllocation, string, error) {
+	for level := len(h.Levels) - 1; level >= 0 && level >= minLevel; level-- {
+		var fit *topology.Unit
+		for _, unit := range dom.UnitsAt(level) {
+			if free := unit.FreeGPUs(); free >= size && (fit == nil || free < fit.FreeGPUs()) {
+				fit = unit
+			}
+		}
+		if fit == nil {
+			continue
+		}
+		allocs, err := allocateOnNodes(fit.Nodes, size)
+		if err != nil {
+			return nil, "", err
+		}
+		return allocs, h.LevelName(spanOf(dom, allocs)), nil
+	}
+	if minLevel >= 0 {
+		return nil, "", &PlanError{Reason: FailureReasonInsufficientTopology, Msg: fmt.Sprintf("no single %s can hold a group of %d GPUs", h.LevelName(minLevel), size)}
+	}
+	allocs, err := allocateInDomain(dom, size)
+	if err != nil {
+		return nil, "", err
+	}
+	return allocs, h.LevelName(spanOf(dom, allocs)), nil
+}
+
+// spanOf is the innermost level one unit of which holds every node of allocs,
+// -1 when none does.
+func spanOf(dom *topology.Domain, allocs []NodeAllocation) int {
+	paths := make(map[string][]string, len(dom.Nodes))
+	for _, node := range dom.Nodes {
+		paths[node.Name] = node.Path
+	}
+	if len(allocs) == 0 {
+		return -1
+	}
+	first := paths[allocs[0].Node]
+	for level := len(first) - 1; level >= 0; level-- {
+		shared := true
+		for _, a := range allocs[1:] {
+			if p := paths[a.Node]; level >= len(p) || p[level] != first[level] {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			return level
+		}
+	}
+	return -1
+}
+
 func assignSpares(snapshot *topology.Snapshot, placements []GroupPlacement, sparesPerGroup int) (int, error) {
 	if sparesPerGroup <= 0 {
 		return 0, nil
@@ -302,8 +610,13 @@ func allocateInDomain(domain *topology.Domain, amount int) ([]NodeAllocation, er
 	if domain.FreeGPUs() < amount {
 		return nil, &PlanError{Reason: FailureReasonInsufficientCapacity, Msg: "domain does not have enough capacity"}
 	}
-	nodes := make([]*topology.Node, len(domain.Nodes))
-	copy(nodes, domain.Nodes)
+	return allocateOnNodes(domain.Nodes, amount)
+}
+
+// allocateOnNodes takes amount GPUs from candidates, the freest nodes first.
+func allocateOnNodes(candidates []*topology.Node, amount int) ([]NodeAllocation, error) {
+	nodes := make([]*topology.Node, len(candidates))
+	copy(nodes, candidates)
 	topology.SortNodesByFree(nodes)
 	remaining := amount
 	var allocs []NodeAllocation
@@ -1,6 +1,7 @@
 package pack
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/davidlangworthy/jobtree/pkg/topology"
@@ -223,3 +224,186 @@ func TestDeriveGroupsContract(t *testing.T) {
 		}
 	}
 }
+
+func TestPlanGangPacksRolesAgainstOneSnapshot(t *testing.T) {
+	snapshot := buildSnapshot(t, []topology.SourceNode{
+		fakeNode("a1", "us-west", "gpu-a", "A", 16),
+		fakeNode("b1", "us-west", "gpu-a", "B", 8),
+	}, nil)
+
+	plan, err := PlanGang(snapshot, "H100-80GB", []RoleRequest{
+		{Name: "learner", Request: Request{TotalGPUs: 16}},
+		{Name: "actor", Request: Request{TotalGPUs: 8, AllowCrossGroupSpread: true}},
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.TotalGPUs != 24 || len(plan.Groups) != 2 {
+		t.Fatalf("expected 24 GPUs in 2 groups, got %d in %d", plan.TotalGPUs, len(plan.Groups))
+	}
+	learner, actor := plan.Groups[0], plan.Groups[1]
+	if learner.Role != "learner" || learner.GroupIndex != 0 || learner.Domain.Fabric != "A" {
+		t.Fatalf("expected learner as group 0 on domain A, got %+v", learner)
+	}
+	// The learner took all of A, so the actor must see B only.
+	if actor.Role != "actor" || actor.GroupIndex != 1 || actor.Domain.Fabric != "B" {
+		t.Fatalf("expected actor as group 1 on domain B, got %+v", actor)
+	}
+	if snapshot.TotalFreeGPUs() != 24 {
+		t.Fatalf("PlanGang must not mutate the caller's snapshot")
+	}
+}
+
+func TestPlanGangFailsWholeWhenALaterRoleDoesNotFit(t *testing.T) {
+	snapshot := buildSnapshot(t, []topology.SourceNode{
+		fakeNode("a1", "us-west", "gpu-a", "A", 16),
+	}, nil)
+
+	_, err := PlanGang(snapshot, "H100-80GB", []RoleRequest{
+		{Name: "learner", Request: Request{TotalGPUs: 12}},
+		{Name: "reward", Request: Request{TotalGPUs: 8}},
+	})
+	perr, ok := err.(*PlanError)
+	if !ok {
+		t.Fatalf("expected a *PlanError, got %v", err)
+	}
+	if perr.Reason != FailureReasonInsufficientTopology {
+		t.Fatalf("expected InsufficientTopology, got %s (%s)", perr.Reason, perr.Msg)
+	}
+}
+
+// tiers is the hierarchy the tiered tests declare: spine pods, then racks,
+// then NVLink islands.
+var tiers = topology.Hierarchy{Levels: []topology.Level{
+	{Name: "spine", Label: "spine"},
+	{Name: "rack", Label: "rack"},
+	{Name: "nvlink", Label: "nvlink.island"},
+}}
+
+func tieredNode(name, spine, rack, island string, gpus int) topology.SourceNode {
+	node := fakeNode(name, "us-west", "gpu-a", "A", gpus)
+	node.Labels["spine"], node.Labels["rack"], node.Labels["nvlink.island"] = spine, rack, island
+	return node
+}
+
+// Spine s1 holds rack r1 (islands i1 and i2, 8 GPUs each) and rack r2 (island
+// i3, 16 GPUs); spine s2 holds one 8-GPU island.
+func tieredSnapshot(t *testing.T) *topology.Snapshot {
+	t.Helper()
+	snapshot, err := topology.BuildSnapshot([]topology.SourceNode{
+		tieredNode("n1", "s1", "r1", "i1", 8),
+		tieredNode("n2", "s1", "r1", "i2", 4),
+		tieredNode("n3", "s1", "r1", "i2", 4),
+		tieredNode("n4", "s1", "r2", "i3", 8),
+		tieredNode("n5", "s1", "r2", "i3", 8),
+		tieredNode("n6", "s2", "r3", "i4", 8),
+	}, nil, "H100-80GB", tiers)
+	if err != nil {
+		t.Fatalf("failed to build snapshot: %v", err)
+	}
+	return snapshot
+}
+
+func nodesOf(g GroupPlacement) map[string]int {
+	out := map[string]int{}
+	for _, a := range g.NodePlacements {
+		out[a.Node] += a.GPUs
+	}
+	return out
+}
+
+// A group lands in the innermost unit that holds it, and in the tightest such
+// unit, so it does not break up the one 16-GPU island a larger group needs.
+func TestPlannerPacksEachGroupIntoTheTightestUnit(t *testing.T) {
+	plan, err := Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 16, GroupGPUs: intPtr(8), AllowCrossGroupSpread: false})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	want := []map[string]int{{"n1": 8}, {"n2": 4, "n3": 4}}
+	for i, g := range plan.Groups {
+		if g.Within != "nvlink" || len(nodesOf(g)) != len(want[i]) {
+			t.Fatalf("group %d within %q on %v, want one island %v", i, g.Within, nodesOf(g), want[i])
+		}
+		for node, gpus := range want[i] {
+			if nodesOf(g)[node] != gpus {
+				t.Fatalf("group %d on %v, want %v", i, nodesOf(g), want[i])
+			}
+		}
+	}
+
+	// Too big for any island: the next level out holds it.
+	plan, err = Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 32, AllowCrossGroupSpread: false})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := plan.Groups[0].Within; got != "spine" {
+		t.Fatalf("a 32-GPU group fits one spine and nothing smaller, got within %q", got)
+	}
+}
+
+func TestPlannerHoldsEachGroupWithinALevel(t *testing.T) {
+	plan, err := Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 24, GroupGPUs: intPtr(12), AllowCrossGroupSpread: true, GroupWithin: "rack"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if plan.Groups[0].Within != "nvlink" || plan.Groups[1].Within != "rack" {
+		t.Fatalf("groups within %q and %q, want the 16-GPU island and then a rack", plan.Groups[0].Within, plan.Groups[1].Within)
+	}
+
+	_, err = Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 24, GroupGPUs: intPtr(12), AllowCrossGroupSpread: true, GroupWithin: "nvlink"})
+	var perr *PlanError
+	if !errors.As(err, &perr) || perr.Reason == FailureReasonInvalidRequest {
+		t.Fatalf("only one island holds 12 GPUs, so the second group cannot be placed; got %v", err)
+	}
+
+	_, err = Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 8, GroupWithin: "pod"})
+	if !errors.As(err, &perr) || perr.Reason != FailureReasonInvalidRequest {
+		t.Fatalf("an undeclared level is an invalid request, got %v", err)
+	}
+}
+
+func TestPlannerHoldsTheRunWithinALevel(t *testing.T) {
+	plan, err := Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 24, GroupGPUs: intPtr(8), AllowCrossGroupSpread: true, RunWithin: "spine"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, g := range plan.Groups {
+		if nodesOf(g)["n6"] > 0 {
+			t.Fatalf("group %d reached spine s2: %v", g.GroupIndex, nodesOf(g))
+		}
+	}
+	if plan.Residual[plan.Groups[0].Domain] != 16 {
+		t.Fatalf("residual %d, want the 16 GPUs the run left", plan.Residual[plan.Groups[0].Domain])
+	}
+
+	// 40 GPUs are free, but no one spine holds 40.
+	if _, err := Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 40, AllowCrossGroupSpread: true}); err != nil {
+		t.Fatalf("without the bound the run fits: %v", err)
+	}
+	if _, err := Planner(tieredSnapshot(t), Request{Flavor: "H100-80GB", TotalGPUs: 40, AllowCrossGroupSpread: true, RunWithin: "spine"}); err == nil {
+		t.Fatal("no spine holds 40 GPUs")
+	}
+}
+
+// The run bound holds the whole gang, so every role must carry the same one.
+func TestPlanGangHoldsTheGangWithinALevel(t *testing.T) {
+	roles := []RoleRequest{
+		{Name: "learner", Request: Request{TotalGPUs: 16, AllowCrossGroupSpread: false, RunWithin: "spine"}},
+		{Name: "actor", Request: Request{TotalGPUs: 8, AllowCrossGroupSpread: true, RunWithin: "spine"}},
+	}
+	plan, err := PlanGang(tieredSnapshot(t), "H100-80GB", roles)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	for _, g := range plan.Groups {
+		if nodesOf(g)["n6"] > 0 {
+			t.Fatalf("role %s reached spine s2: %v", g.Role, nodesOf(g))
+		}
+	}
+
+	roles[1].RunWithin = "rack"
+	var perr *PlanError
+	if _, err := PlanGang(tieredSnapshot(t), "H100-80GB", roles); !errors.As(err, &perr) || perr.Reason != FailureReasonInvalidRequest {
+		t.Fatalf("roles that disagree on the run bound are an invalid request, got %v", err)
+	}
+}
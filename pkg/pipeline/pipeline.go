@@ -0,0 +1,256 @@
+// Package pipeline stamps a Pipeline's stages into follow-joined Runs and folds
+// their phases back into per-stage status. Like sweep it is pure: the kube
+// reconciler lists the stage Runs, calls Plan, and applies the result. The
+// gating itself — when a stage may start, when it is skipped — is the engine's
+// follow gate; a stage is just a Run with spec.follow set.
+package pipeline
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/artifacts"
+)
+
+const (
+	// LabelPipeline names the pipeline a stage Run was stamped from.
+	LabelPipeline = "rq.davidlangworthy.io/pipeline"
+	// LabelPipelineStage is the stage a Run implements.
+	LabelPipelineStage = "rq.davidlangworthy.io/pipeline-stage"
+)
+
+// Cycle reports whether the follow graph reachable from start contains a
+// cycle, returning it as a readable path. after returns a node's upstreams;
+// names it does not know are leaves. Both the engine (over Runs) and a
+// Pipeline (over its stages) check their graphs with it.
+func Cycle(start string, after func(name string) []string) (string, bool) {
+	inStack := make(map[string]bool)
+	done := make(map[string]bool)
+	var path []string
+	var dfs func(name string) (string, bool)
+	dfs = func(name string) (string, bool) {
+		inStack[name] = true
+		path = append(path, name)
+		for _, up := range after(name) {
+			if inStack[up] {
+				return strings.Join(append(path, up), " -> "), true
+			}
+			if done[up] {
+				continue
+			}
+			if p, cyclic := dfs(up); cyclic {
+				return p, true
+			}
+		}
+		inStack[name] = false
+		done[name] = true
+		path = path[:len(path)-1]
+		return "", false
+	}
+	return dfs(start)
+}
+
+// Validate checks the whole DAG: each stage, every edge, and that no stage
+// can reach itself.
+func Validate(p *v1.Pipeline) error {
+	if err := p.Spec.Validate(); err != nil {
+		return err
+	}
+	after := make(map[string][]string, len(p.Spec.Stages))
+	for _, stage := range p.Spec.Stages {
+		if stage.Follow != nil {
+			after[stage.Name] = stage.Follow.After
+		}
+	}
+	for _, stage := range p.Spec.Stages {
+		if path, cyclic := Cycle(stage.Name, func(name string) []string { return after[name] }); cyclic {
+			return fmt.Errorf("follow cycle: %s", path)
+		}
+	}
+	return nil
+}
+
+// StageName is the Run name of a pipeline's stage.
+func StageName(p *v1.Pipeline, stage string) string {
+	return p.Name + "-" + stage
+}
+
+// Stage stamps the Run for one stage: the template's spec, with the stage's
+// follow edges pointed at its upstreams' Runs, owned by the pipeline.
+func Stage(p *v1.Pipeline, stage *v1.PipelineStage) *v1.Run {
+	tmpl := &stage.Template
+	labels := make(map[string]string, len(tmpl.Labels)+2)
+	for k, v := range tmpl.Labels {
+		labels[k] = v
+	}
+	labels[LabelPipeline] = p.Name
+	labels[LabelPipelineStage] = stage.Name
+	var annotations map[string]string
+	if len(tmpl.Annotations) > 0 {
+		annotations = make(map[string]string, len(tmpl.Annotations))
+		for k, v := range tmpl.Annotations {
+			annotations[k] = v
+		}
+	}
+
+	spec := tmpl.Spec.DeepCopy()
+	if stage.Follow != nil {
+		spec.Follow = stage.Follow.DeepCopy()
+		for i, up := range spec.Follow.After {
+			spec.Follow.After[i] = StageName(p, up)
+		}
+	}
+	run := &v1.Run{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        StageName(p, stage.Name),
+			Namespace:   p.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}
+	if p.UID != "" {
+		yes := true
+		run.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion:         v1.GroupVersion.String(),
+			Kind:               "Pipeline",
+			Name:               p.Name,
+			UID:                p.UID,
+			Controller:         &yes,
+			BlockOwnerDeletion: &yes,
+		}}
+	}
+	return run
+}
+
+// Decision is what Plan asks the reconciler to do, and the status to write
+// once it has.
+type Decision struct {
+	// Create are the stage Runs that do not exist, in spec order.
+	Create []*v1.Run
+	Status v1.PipelineStatus
+}
+
+// Plan decides the pipeline's next step from the stage Runs that exist.
+//
+// Every stage is created up front: the engine holds each one Waiting until its
+// follow gate opens, so the pipeline need not sequence anything itself. While
+// the pipeline is Running, a stage whose Run is missing is (re)created — which
+// is how a researcher retries a failed stage: delete its Run, and stages
+// waiting on it under the "wait" policy pick up the new one. Once the pipeline
+// has finished nothing is recreated, and a deleted stage keeps the phase last
+// recorded for it.
+func Plan(p *v1.Pipeline, runs []v1.Run, now time.Time) Decision {
+	prev := p.Status
+	if err := Validate(p); err != nil {
+		status := prev
+		status.Phase = v1.PipelinePhaseFailed
+		status.Message = err.Error()
+		if status.CompletedAt == nil {
+			at := metav1.NewTime(now)
+			status.CompletedAt = &at
+		}
+		return Decision{Status: status}
+	}
+
+	byStage := make(map[string]*v1.Run, len(runs))
+	for i := range runs {
+		run := &runs[i]
+		if name := run.Labels[LabelPipelineStage]; name != "" && run.DeletionTimestamp == nil {
+			byStage[name] = run
+		}
+	}
+	recorded := make(map[string]v1.PipelineStageStatus, len(prev.Stages))
+	for _, s := range prev.Stages {
+		recorded[s.Name] = s
+	}
+	finished := prev.Phase == v1.PipelinePhaseComplete || prev.Phase == v1.PipelinePhaseFailed
+
+	var decision Decision
+	status := v1.PipelineStatus{Total: int32(len(p.Spec.Stages)), CompletedAt: prev.CompletedAt}
+	var running, failed []string
+	for i := range p.Spec.Stages {
+		stage := &p.Spec.Stages[i]
+		entry := v1.PipelineStageStatus{Name: stage.Name, Run: StageName(p, stage.Name)}
+		switch run, ok := byStage[stage.Name]; {
+		case ok:
+			entry.Phase = stagePhase(run)
+			entry.Artifacts = artifacts.URI(run)
+		case finished:
+			entry = recorded[stage.Name]
+			entry.Name, entry.Run = stage.Name, StageName(p, stage.Name)
+		default:
+			decision.Create = append(decision.Create, Stage(p, stage))
+		}
+		switch entry.Phase {
+		case v1.RunPhaseComplete:
+			status.Succeeded++
+		case v1.RunPhaseFailed:
+			failed = append(failed, stage.Name)
+		case v1.PipelineStageSkipped:
+		default:
+			running = append(running, stage.Name)
+		}
+		status.Stages = append(status.Stages, entry)
+	}
+
+	switch {
+	case len(running) > 0 && !finished:
+		status.Phase = v1.PipelinePhaseRunning
+		status.Message = fmt.Sprintf("%d of %d stages succeeded; running or waiting: %s",
+			status.Succeeded, status.Total, strings.Join(running, ", "))
+	case len(failed) > 0:
+		status.Phase = v1.PipelinePhaseFailed
+		status.Message = "failed stages: " + strings.Join(failed, ", ")
+		if finished && prev.Phase == v1.PipelinePhaseFailed {
+			// Keep why it failed, which may be a refused create (Refused)
+			// that no stage Run records.
+			status.Message = prev.Message
+		}
+	default:
+		status.Phase = v1.PipelinePhaseComplete
+		status.Message = fmt.Sprintf("%d of %d stages succeeded", status.Succeeded, status.Total)
+	}
+	if status.Phase == v1.PipelinePhaseRunning {
+		status.CompletedAt = nil
+	} else if status.CompletedAt == nil {
+		at := metav1.NewTime(now)
+		status.CompletedAt = &at
+	}
+	decision.Status = status
+	return decision
+}
+
+// Refused records that the apiserver refused Create[i] for good (invalid or
+// forbidden). That stage is Failed, the stages after it in Create are left
+// uncreated, and the pipeline is Failed with the refusal; being finished, it
+// recreates nothing on later passes.
+func (d *Decision) Refused(i int, err error, now time.Time) {
+	refused := d.Create[i]
+	stage := refused.Labels[LabelPipelineStage]
+	d.Create = d.Create[:i]
+	for j := range d.Status.Stages {
+		if d.Status.Stages[j].Name == stage {
+			d.Status.Stages[j].Phase = v1.RunPhaseFailed
+		}
+	}
+	d.Status.Phase = v1.PipelinePhaseFailed
+	d.Status.Message = fmt.Sprintf("create stage %s: %v", stage, err)
+	if d.Status.CompletedAt == nil {
+		at := metav1.NewTime(now)
+		d.Status.CompletedAt = &at
+	}
+}
+
+// stagePhase is a stage Run's phase, with a skipped Run told apart from one
+// that failed.
+func stagePhase(run *v1.Run) string {
+	if run.Status.Phase == v1.RunPhaseFailed && v1.RunSkipped(&run.Status) {
+		return v1.PipelineStageSkipped
+	}
+	return run.Status.Phase
+}
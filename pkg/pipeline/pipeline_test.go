@@ -0,0 +1,147 @@
+package pipeline
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+var now = time.Date(2026, 8, 1, 9, 0, 0, 0, time.UTC)
+
+func stage(name string, after ...string) v1.PipelineStage {
+	s := v1.PipelineStage{Name: name, Template: v1.RunTemplate{Spec: v1.RunSpec{
+		Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1},
+	}}}
+	if len(after) > 0 {
+		s.Follow = &v1.RunFollow{After: after}
+	}
+	return s
+}
+
+// nightly is preprocess -> train -> {eval, alert on failure} -> export after eval.
+func nightly() *v1.Pipeline {
+	alert := stage("alert", "train")
+	alert.Follow.When = v1.FollowWhenFailed
+	return &v1.Pipeline{
+		ObjectMeta: metav1.ObjectMeta{Name: "nightly", Namespace: "team", UID: "pipe-uid"},
+		Spec: v1.PipelineSpec{Stages: []v1.PipelineStage{
+			stage("preprocess"), stage("train", "preprocess"), stage("eval", "train"), alert, stage("export", "eval"),
+		}},
+	}
+}
+
+// withPhases returns the pipeline's stage Runs as Plan would create them, in the
+// given phases; a stage absent from phases has no Run.
+func withPhases(p *v1.Pipeline, phases map[string]string) []v1.Run {
+	var runs []v1.Run
+	for i := range p.Spec.Stages {
+		phase, ok := phases[p.Spec.Stages[i].Name]
+		if !ok {
+			continue
+		}
+		run := Stage(p, &p.Spec.Stages[i])
+		if phase == v1.PipelineStageSkipped {
+			v1.SetRunState(&run.Status, 0, v1.RunStateFollowSkipped, "skipped")
+		} else {
+			run.Status.Phase = phase
+		}
+		runs = append(runs, *run)
+	}
+	return runs
+}
+
+func TestStageFollowsItsUpstreamsRuns(t *testing.T) {
+	p := nightly()
+	run := Stage(p, &p.Spec.Stages[3])
+	if run.Name != "nightly-alert" || run.Labels[LabelPipeline] != "nightly" || run.Labels[LabelPipelineStage] != "alert" {
+		t.Errorf("identity: %s %v", run.Name, run.Labels)
+	}
+	if run.Spec.Follow == nil || !reflect.DeepEqual(run.Spec.Follow.After, []string{"nightly-train"}) || run.Spec.Follow.When != v1.FollowWhenFailed {
+		t.Errorf("follow: %+v", run.Spec.Follow)
+	}
+	if p.Spec.Stages[3].Follow.After[0] != "train" {
+		t.Errorf("stamping a stage rewrote the spec")
+	}
+	if ref := metav1.GetControllerOf(run); ref == nil || ref.Kind != "Pipeline" || ref.UID != "pipe-uid" {
+		t.Errorf("the stage must be controlled by its pipeline, got %+v", ref)
+	}
+}
+
+func TestValidateRejectsTheWholeDAGUpFront(t *testing.T) {
+	cyclic := nightly()
+	cyclic.Spec.Stages[0].Follow = &v1.RunFollow{After: []string{"export"}}
+	unknown := nightly()
+	unknown.Spec.Stages[2].Follow.After = []string{"tain"}
+	templated := nightly()
+	templated.Spec.Stages[1].Template.Spec.Follow = &v1.RunFollow{After: []string{"other"}}
+	for name, tc := range map[string]struct {
+		p    *v1.Pipeline
+		want string
+	}{
+		"cycle":           {cyclic, "follow cycle"},
+		"unknown stage":   {unknown, "not a stage"},
+		"template follow": {templated, "must not set spec.follow"},
+	} {
+		err := Validate(tc.p)
+		if err == nil || !strings.Contains(err.Error(), tc.want) {
+			t.Errorf("%s: got %v, want an error containing %q", name, err, tc.want)
+		}
+		d := Plan(tc.p, nil, now)
+		if len(d.Create) != 0 || d.Status.Phase != v1.PipelinePhaseFailed {
+			t.Errorf("%s: an invalid pipeline must fail without creating anything: %+v", name, d)
+		}
+	}
+	if err := Validate(nightly()); err != nil {
+		t.Errorf("valid pipeline: %v", err)
+	}
+}
+
+func TestPlanCreatesEveryStageUpFront(t *testing.T) {
+	p := nightly()
+	d := Plan(p, nil, now)
+	if len(d.Create) != 5 || d.Status.Phase != v1.PipelinePhaseRunning || d.Status.Total != 5 {
+		t.Fatalf("first pass: %d creates, status %+v", len(d.Create), d.Status)
+	}
+	p.Status = d.Status
+
+	// A stage deleted mid-flight is recreated: that is how a stage is retried.
+	d = Plan(p, withPhases(p, map[string]string{"preprocess": v1.RunPhaseComplete, "eval": "", "alert": "", "export": ""}), now)
+	if len(d.Create) != 1 || d.Create[0].Name != "nightly-train" {
+		t.Errorf("the missing stage must be recreated, got %d creates", len(d.Create))
+	}
+}
+
+// A skipped branch does not fail the pipeline; a failed stage does, and a
+// finished pipeline recreates nothing.
+func TestPipelinePhaseFromStages(t *testing.T) {
+	p := nightly()
+	d := Plan(p, withPhases(p, map[string]string{
+		"preprocess": v1.RunPhaseComplete, "train": v1.RunPhaseComplete, "eval": v1.RunPhaseComplete,
+		"alert": v1.PipelineStageSkipped, "export": v1.RunPhaseComplete,
+	}), now)
+	if d.Status.Phase != v1.PipelinePhaseComplete || d.Status.Succeeded != 4 || d.Status.CompletedAt == nil {
+		t.Fatalf("unexpected status %+v", d.Status)
+	}
+	if got := d.Status.Stages[3]; got.Name != "alert" || got.Run != "nightly-alert" || got.Phase != v1.PipelineStageSkipped {
+		t.Errorf("alert stage: %+v", got)
+	}
+
+	d = Plan(p, withPhases(p, map[string]string{
+		"preprocess": v1.RunPhaseComplete, "train": v1.RunPhaseFailed, "eval": v1.PipelineStageSkipped,
+		"alert": v1.RunPhaseComplete, "export": v1.PipelineStageSkipped,
+	}), now)
+	if d.Status.Phase != v1.PipelinePhaseFailed || !strings.Contains(d.Status.Message, "train") {
+		t.Fatalf("unexpected status %+v", d.Status)
+	}
+	p.Status = d.Status
+
+	d = Plan(p, withPhases(p, map[string]string{"preprocess": v1.RunPhaseComplete}), now.Add(time.Hour))
+	if len(d.Create) != 0 || d.Status.Phase != v1.PipelinePhaseFailed || d.Status.Stages[1].Phase != v1.RunPhaseFailed {
+		t.Errorf("a finished pipeline must keep its record and recreate nothing: %+v", d)
+	}
+}
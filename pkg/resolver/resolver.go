@@ -14,6 +14,7 @@ import (
 	v1 "github.com/davidlangworthy/jobtree/api/v1"
 	"github.com/davidlangworthy/jobtree/pkg/binder"
 	"github.com/davidlangworthy/jobtree/pkg/funding"
+	"github.com/davidlangworthy/jobtree/pkg/goodput"
 	"github.com/davidlangworthy/jobtree/pkg/keys"
 	"github.com/davidlangworthy/jobtree/pkg/topology"
 )
@@ -177,6 +178,84 @@ type runState struct {
 type lotteryToken struct {
 	runKey string
 	group  *runGroup
+	weight int
+	band   int32 // the run's fair-share band
+}
+
+// LotteryWeight is how many tickets a run's group holds in its owner's draw,
+// out of MaxRunPriority+1: a priority-0 run holds them all, and each authorized
+// priority step removes one. The owner is still drawn uniformly first, so
+// priority reorders risk inside a tenant and never shifts it onto another.
+func LotteryWeight(priority int32) int {
+	if priority < 0 {
+		priority = 0
+	}
+	if priority > v1.MaxRunPriority {
+		priority = v1.MaxRunPriority
+	}
+	return v1.MaxRunPriority + 1 - int(priority)
+}
+
+// tokenFor builds a run group's ticket, weighted by the priority the run's
+// envelopes authorize. Without funding facts nothing is authorized.
+func tokenFor(in Input, runKey string, grp *runGroup) lotteryToken {
+	tok := lotteryToken{runKey: runKey, group: grp}
+	var priority int32
+	if in.Evaluation != nil {
+		if acct := in.Evaluation.Run(runKey); acct != nil {
+			priority = acct.Priority
+			tok.band = acct.ShareBand
+		}
+	}
+	tok.weight = LotteryWeight(priority)
+	return tok
+}
+
+// drawToken picks a token index by weight.
+func drawToken(rng *rand.Rand, tokens []lotteryToken) int {
+	return drawWeighted(rng, len(tokens), func(i int) int { return tokens[i].weight })
+}
+
+// drawOwner picks the next victim owner for the unfunded reclaim. An owner
+// holds one ticket plus one per fair-share band of its most family-hungry
+// run, so a principal that has been living on family excess is drawn and gives
+// back its opportunistic work first.
+func drawOwner(rng *rand.Rand, owners []string, tokensByOwner map[string][]lotteryToken) int {
+	return drawWeighted(rng, len(owners), func(i int) int {
+		var band int32
+		for _, tok := range tokensByOwner[owners[i]] {
+			if tok.band > band {
+				band = tok.band
+			}
+		}
+		return 1 + int(band)
+	})
+}
+
+// drawWeighted picks an index in [0, n). Equal weights draw exactly as the
+// unweighted lottery always has, so a seed recorded before weights existed
+// still replays to the same victim; only a mixed pool takes the weighted
+// path.
+func drawWeighted(rng *rand.Rand, n int, weight func(i int) int) int {
+	total, uniform := 0, true
+	for i := 0; i < n; i++ {
+		w := weight(i)
+		total += w
+		if w != weight(0) {
+			uniform = false
+		}
+	}
+	if uniform {
+		return rng.Intn(n)
+	}
+	pick := rng.Intn(total)
+	for i := 0; i < n; i++ {
+		if pick < weight(i) {
+			return i
+		}
+		pick -= weight(i)
+	}
+	return n - 1
 }
 
 func indexNodes(nodes []topology.SourceNode) map[string]map[string]string {
@@ -206,7 +285,7 @@ func gatherCandidates(in Input, nodeIndex map[string]map[string]string) candidat
 		if run == nil {
 			continue
 		}
-		if run.Spec.Resources.GPUType != in.Flavor {
+		if run.Flavor() != in.Flavor {
 			continue
 		}
 		if !leaseInScope(lease, nodeIndex, in.Scope) {
@@ -303,7 +382,7 @@ func reclaimUnfunded(deficit int, in Input, candidates candidateSet) ([]Action,
 				continue
 			}
 			owner := ownerOf(in, st.Run)
-			tokensByOwner[owner] = append(tokensByOwner[owner], lotteryToken{runKey: runKey, group: grp})
+			tokensByOwner[owner] = append(tokensByOwner[owner], tokenFor(in, runKey, grp))
 			owners = append(owners, owner)
 		}
 	}
@@ -314,14 +393,14 @@ func reclaimUnfunded(deficit int, in Input, candidates candidateSet) ([]Action,
 	var actions []Action
 	freed := 0
 	for deficit > 0 && len(owners) > 0 {
-		ownerIdx := rng.Intn(len(owners))
+		ownerIdx := drawOwner(rng, owners, tokensByOwner)
 		owner := owners[ownerIdx]
 		tokens := tokensByOwner[owner]
 		if len(tokens) == 0 {
 			owners = append(owners[:ownerIdx], owners[ownerIdx+1:]...)
 			continue
 		}
-		tokenIdx := rng.Intn(len(tokens))
+		tokenIdx := drawToken(rng, tokens)
 		tok := tokens[tokenIdx]
 		tokensByOwner[owner] = removeToken(tokens, tokenIdx)
 		if len(tokensByOwner[owner]) == 0 {
@@ -398,11 +477,28 @@ func shrinkMalleable(deficit int, candidates candidateSet) ([]Action, int) {
 		}
 	}
 
+	// The flattest goodput curve gives up width first: its newest GPUs are the
+	// ones adding the least. Runs without a measured curve follow, by key.
+	slopes := make(map[string]float64, len(candidates.Runs))
+	for runKey, st := range candidates.Runs {
+		if slope, ok := goodput.Slope(st.Run); ok {
+			slopes[runKey] = slope
+		}
+	}
 	sort.Slice(shrinkList, func(i, j int) bool {
-		if shrinkList[i].runKey == shrinkList[j].runKey {
-			return cutBefore(shrinkList[i].group.GroupIndex, shrinkList[j].group.GroupIndex)
+		a, b := shrinkList[i], shrinkList[j]
+		if a.runKey == b.runKey {
+			return cutBefore(a.group.GroupIndex, b.group.GroupIndex)
+		}
+		sa, aok := slopes[a.runKey]
+		sb, bok := slopes[b.runKey]
+		if aok != bok {
+			return aok
+		}
+		if aok && sa != sb {
+			return sa < sb
 		}
-		return shrinkList[i].runKey < shrinkList[j].runKey
+		return a.runKey < b.runKey
 	})
 
 	for _, item := range shrinkList {
@@ -525,11 +621,6 @@ func runLottery(deficit int, in Input, candidates candidateSet) ([]Action, strin
 	seed := computeSeed(in.SeedSource, in.Now)
 	rng := rand.New(rand.NewSource(seedValue(seed)))
 
-	type token struct {
-		runKey string
-		group  *runGroup
-	}
-
 	tokensByOwner := make(map[string][]lotteryToken)
 	owners := make([]string, 0)
 
@@ -543,7 +634,7 @@ func runLottery(deficit int, in Input, candidates candidateSet) ([]Action, strin
 			if st.Malleable != nil && st.Remaining-grp.GPUs < int(st.Malleable.MinTotalGPUs) {
 				continue
 			}
-			tokensByOwner[owner] = append(tokensByOwner[owner], lotteryToken{runKey: runKey, group: grp})
+			tokensByOwner[owner] = append(tokensByOwner[owner], tokenFor(in, runKey, grp))
 			available = true
 		}
 		if available {
@@ -571,7 +662,7 @@ func runLottery(deficit int, in Input, candidates candidateSet) ([]Action, strin
 			owners = append(owners[:ownerIdx], owners[ownerIdx+1:]...)
 			continue
 		}
-		tokenIdx := rng.Intn(len(tokens))
+		tokenIdx := drawToken(rng, tokens)
 		tok := tokens[tokenIdx]
 		grp := tok.group
 		if grp.Marked {
@@ -2,6 +2,7 @@ package resolver
 
 import (
 	"fmt"
+	"math/rand"
 	"strings"
 	"testing"
 	"time"
@@ -105,6 +106,55 @@ func TestResolveShrinksBeforeLottery(t *testing.T) {
 	}
 }
 
+// Two elastic runs, one deficit of a step: the run whose last step added the
+// least throughput gives it up, whatever the key order says.
+func TestResolveShrinksTheFlattestGoodputCurveFirst(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	curve := func(at8, at16 float64) *v1.RunGoodputStatus {
+		return &v1.RunGoodputStatus{TargetGPUs: 16, Curve: []v1.RunGoodputPoint{
+			{GPUs: 8, Throughput: at8}, {GPUs: 16, Throughput: at16},
+		}}
+	}
+	// "a-scaling" sorts first by key and scales well; "b-plateau" gained 5%.
+	scaling := buildRun("team-a", "", "a-scaling", "H100")
+	plateau := buildRun("team-b", "", "b-plateau", "H100")
+	var leases []*v1.GPULease
+	runs := map[string]*v1.Run{}
+	for i, run := range []*v1.Run{scaling, plateau} {
+		run.Spec.Malleable = &v1.RunMalleability{MinTotalGPUs: 8, MaxTotalGPUs: 16, StepGPUs: 8}
+		node := fmt.Sprintf("node-%d", i)
+		for g := 0; g < 2; g++ {
+			var slots []string
+			for k := 0; k < 8; k++ {
+				slots = append(slots, fmt.Sprintf("%s#%d", node, g*8+k))
+			}
+			leases = append(leases, buildLease(run, fmt.Sprint(g), "Active", slots, now))
+		}
+		runs[keys.NamespacedKey(run.Namespace, run.Name)] = run
+	}
+	scaling.Status.Goodput = curve(100, 190)
+	plateau.Status.Goodput = curve(100, 105)
+
+	result, err := Resolve(Input{
+		Deficit: 8, Flavor: "H100", SeedSource: "flat", Now: now,
+		Nodes: []topology.SourceNode{
+			sourceNode("node-0", "us-west", "cluster-a", "island-a", "H100", 16),
+			sourceNode("node-1", "us-west", "cluster-a", "island-a", "H100", 16),
+		},
+		Leases: leases,
+		Runs:   runs,
+	})
+	if err != nil {
+		t.Fatalf("resolve failed: %v", err)
+	}
+	if len(result.Actions) != 1 || result.Actions[0].Kind != ActionShrink {
+		t.Fatalf("expected one shrink, got %+v", result.Actions)
+	}
+	if got := result.Actions[0].Run.Name; got != "b-plateau" {
+		t.Errorf("shrank %s; the plateaued run must give up its step first", got)
+	}
+}
+
 func TestResolveLotteryDeterministic(t *testing.T) {
 	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
 	runA := buildRun("owner-a", "default", "run-a", "H100")
@@ -272,3 +322,51 @@ func sourceNode(name, region, cluster, fabric, flavor string, gpus int) topology
 		GPUs: gpus,
 	}
 }
+
+// A pool of equal priorities draws exactly as the unweighted lottery did, so an
+// old seed replays; a mixed pool draws in proportion to LotteryWeight.
+func TestDrawTokenWeighsByAuthorizedPriority(t *testing.T) {
+	uniform := []lotteryToken{{weight: LotteryWeight(3)}, {weight: LotteryWeight(3)}, {weight: LotteryWeight(3)}}
+	for seed := int64(0); seed < 50; seed++ {
+		got := drawToken(rand.New(rand.NewSource(seed)), uniform)
+		if want := rand.New(rand.NewSource(seed)).Intn(3); got != want {
+			t.Fatalf("seed %d: uniform pool drew %d, the unweighted lottery draws %d", seed, got, want)
+		}
+	}
+
+	if LotteryWeight(0) != 10 || LotteryWeight(9) != 1 || LotteryWeight(42) != 1 {
+		t.Fatalf("weights: p0=%d p9=%d p42=%d", LotteryWeight(0), LotteryWeight(9), LotteryWeight(42))
+	}
+	mixed := []lotteryToken{{weight: LotteryWeight(0)}, {weight: LotteryWeight(9)}}
+	rng := rand.New(rand.NewSource(7))
+	var counts [2]int
+	for i := 0; i < 11000; i++ {
+		counts[drawToken(rng, mixed)]++
+	}
+	if counts[1] < 700 || counts[1] > 1300 {
+		t.Errorf("a priority-9 group should be drawn about 1 time in 11, got %d of 11000", counts[1])
+	}
+}
+
+// Owners with no fair-share band draw as before; one with a band is drawn
+// once per ticket, one plus its band.
+func TestDrawOwnerFavorsTheFamilyHungry(t *testing.T) {
+	owners := []string{"a", "b"}
+	even := map[string][]lotteryToken{"a": {{weight: 10}}, "b": {{weight: 10}}}
+	for seed := int64(0); seed < 50; seed++ {
+		got := drawOwner(rand.New(rand.NewSource(seed)), owners, even)
+		if want := rand.New(rand.NewSource(seed)).Intn(2); got != want {
+			t.Fatalf("seed %d: bandless owners drew %d, the uniform draw is %d", seed, got, want)
+		}
+	}
+
+	hungry := map[string][]lotteryToken{"a": {{weight: 10}}, "b": {{weight: 10}, {weight: 10, band: 3}}}
+	rng := rand.New(rand.NewSource(3))
+	var counts [2]int
+	for i := 0; i < 5000; i++ {
+		counts[drawOwner(rng, owners, hungry)]++
+	}
+	if counts[1] < 3500 || counts[1] > 4500 {
+		t.Errorf("a band-3 owner holds 4 of 5 tickets, drawn %d of 5000", counts[1])
+	}
+}
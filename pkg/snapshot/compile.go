@@ -1,5 +1,6 @@
-// Package snapshot compiles Budgets and Grants into the versioned identity
-// document of DESIGN-v5 §3, and is the whole trust boundary of the design (§11).
+// Package snapshot compiles Budgets, Grants and BudgetTransfers into the
+// versioned identity document of DESIGN-v5 §3, and is the whole trust boundary
+// of the design (§11).
 //
 // THE TRUST BOUNDARY IS HERE, NOT IN THE DOCUMENT. No invariant a published
 // document can express says *"this changeset was authored by someone entitled to
@@ -31,6 +32,9 @@ import (
 type Input struct {
 	Budgets []v1.Budget
 	Grants  []v1.Grant
+	// Transfers are every party's BudgetTransfer copies; a transfer compiles
+	// in only when the lender's and the recipient's agree.
+	Transfers []v1.BudgetTransfer
 	// NamespaceUIDs maps namespace NAME to its immutable UID. Identity keys on
 	// the UID: a namespace deleted and recreated under the same name is a
 	// DIFFERENT principal, and only the UID can tell you so. A namespace absent
@@ -55,6 +59,19 @@ type Result struct {
 	// naming a principal that does not exist is REJECTED so it cannot spring
 	// alive later (INV-GRANT-ENDPOINTS-RESOLVE, §2c).
 	Rejected map[string]string
+	// Transfers reports every BudgetTransfer copy's disposition, keyed by
+	// "namespace/name". It is kept apart from the Grant maps above because a
+	// transfer that is merely waiting for its counterpart is neither
+	// quarantined nor rejected — and a Grant and a transfer may share a key.
+	Transfers map[string]TransferDisposition
+}
+
+// TransferDisposition is what became of one BudgetTransfer copy.
+type TransferDisposition struct {
+	// Reason is one of v1.TransferReasonCompiled, AwaitingCounterparty or
+	// NotCompiled.
+	Reason  string
+	Message string
 }
 
 // Compile builds the next snapshot.
@@ -68,6 +85,7 @@ func Compile(in Input) (Result, error) {
 	res := Result{
 		Quarantined: map[string]string{},
 		Rejected:    map[string]string{},
+		Transfers:   map[string]TransferDisposition{},
 	}
 
 	principals, err := bindPrincipals(in)
@@ -80,7 +98,7 @@ func Compile(in Input) (Result, error) {
 		return res, err
 	}
 
-	doc := assemble(in, principals, accepted)
+	doc := assemble(in, principals, accepted, &res)
 	res.Snapshot = doc
 	return res, nil
 }
@@ -336,7 +354,7 @@ func isAncestor(principals map[string]*principal, candidate, target string) bool
 }
 
 // assemble clamps and emits the document.
-func assemble(in Input, principals map[string]*principal, accepted []*v1.Grant) v1.QuotaSnapshot {
+func assemble(in Input, principals map[string]*principal, accepted []*v1.Grant, res *Result) v1.QuotaSnapshot {
 	owners := make([]string, 0, len(principals))
 	for owner := range principals {
 		owners = append(owners, owner)
@@ -384,6 +402,7 @@ func assemble(in Input, principals map[string]*principal, accepted []*v1.Grant)
 		EffectiveFrom: metav1.NewTime(in.Now.UTC()),
 		Roots:         roots,
 		Principals:    out,
+		Transfers:     compileTransfers(in, principals, out, res),
 	}
 	spec.ContentHash = contentHash(spec)
 	spec.SnapshotVersion = nextVersion(in.Prior, spec.ContentHash)
@@ -476,6 +495,135 @@ func clampEnvelopes(p *principal) []v1.SnapshotEnvelope {
 	return out
 }
 
+// compileTransfers pairs each party's BudgetTransfer copy with its
+// counterpart's and emits the transfers both accepted, clamped to the lending
+// envelope as compiled above.
+//
+// Consent is checked the way grant authority is: each party is whoever is
+// bound to the namespace a copy was WRITTEN in. A copy in from.namespace is the
+// lender's, a copy in to.namespace the recipient's, and a copy anywhere else
+// speaks for nobody. A transfer compiles only when both exist and name the
+// same terms, so neither party can commit the other.
+func compileTransfers(in Input, principals map[string]*principal, compiled []v1.SnapshotPrincipal, res *Result) []v1.SnapshotTransfer {
+	ownerByNamespace := map[string]string{}
+	for _, p := range principals {
+		ownerByNamespace[p.nsName] = p.owner
+	}
+	// The compiled envelopes, keyed back to the Budget that authored them:
+	// envelope names are unique per principal (INV-ENVELOPE-UNIQUE), so the
+	// owner and name find the one a transfer's from names.
+	effective := map[string]map[string]v1.SnapshotEnvelope{}
+	for _, sp := range compiled {
+		byName := map[string]v1.SnapshotEnvelope{}
+		for _, e := range sp.Envelopes {
+			byName[e.Name] = e
+		}
+		effective[sp.Owner] = byName
+	}
+	envelopes := map[v1.TransferSource]v1.SnapshotEnvelope{}
+	for i := range in.Budgets {
+		b := &in.Budgets[i]
+		if ownerByNamespace[b.Namespace] != b.Spec.Owner {
+			continue
+		}
+		for j := range b.Spec.Envelopes {
+			if e, ok := effective[b.Spec.Owner][b.Spec.Envelopes[j].Name]; ok {
+				envelopes[v1.TransferSource{Namespace: b.Namespace, Budget: b.Name, Envelope: e.Name}] = e
+			}
+		}
+	}
+
+	copies := append([]v1.BudgetTransfer(nil), in.Transfers...)
+	sort.Slice(copies, func(i, j int) bool {
+		if copies[i].Name != copies[j].Name {
+			return copies[i].Name < copies[j].Name
+		}
+		return copies[i].Namespace < copies[j].Namespace
+	})
+	byKey := map[string]*v1.BudgetTransfer{}
+	for i := range copies {
+		byKey[copies[i].Namespace+"/"+copies[i].Name] = &copies[i]
+	}
+	refuse := func(key, msg string) {
+		res.Transfers[key] = TransferDisposition{Reason: v1.TransferReasonNotCompiled, Message: msg}
+	}
+
+	var out []v1.SnapshotTransfer
+	for i := range copies {
+		t := &copies[i]
+		key := t.Namespace + "/" + t.Name
+		if err := t.Spec.Validate(); err != nil {
+			refuse(key, "invalid transfer: "+err.Error())
+			continue
+		}
+		lenderSide := t.Namespace == t.Spec.From.Namespace
+		if !lenderSide && t.Namespace != t.Spec.To.Namespace {
+			refuse(key, fmt.Sprintf("written in %q, which is neither party's namespace; a transfer is accepted by where it is WRITTEN", t.Namespace))
+			continue
+		}
+		lender, ok := ownerByNamespace[t.Spec.From.Namespace]
+		if !ok {
+			refuse(key, fmt.Sprintf("namespace %q is bound to no principal, so it has nothing to lend", t.Spec.From.Namespace))
+			continue
+		}
+		if borrower := ownerByNamespace[t.Spec.To.Namespace]; borrower != t.Spec.To.Owner {
+			refuse(key, fmt.Sprintf("recipient %q is not the principal bound to namespace %q", t.Spec.To.Owner, t.Spec.To.Namespace))
+			continue
+		}
+		env, ok := envelopes[t.Spec.From]
+		if !ok {
+			refuse(key, fmt.Sprintf("envelope %s/%s/%s does not exist or funds nothing", t.Spec.From.Namespace, t.Spec.From.Budget, t.Spec.From.Envelope))
+			continue
+		}
+		otherNS, other := t.Spec.To.Namespace, "recipient"
+		if !lenderSide {
+			otherNS, other = t.Spec.From.Namespace, "lender"
+		}
+		counterpart := byKey[otherNS+"/"+t.Name]
+		if counterpart == nil {
+			res.Transfers[key] = TransferDisposition{Reason: v1.TransferReasonAwaitingCounterparty,
+				Message: fmt.Sprintf("waiting for the %s to write %s/%s", other, otherNS, t.Name)}
+			continue
+		}
+		if !t.Spec.Terms(&counterpart.Spec) {
+			refuse(key, fmt.Sprintf("%s/%s names different terms; both parties must write the same transfer", otherNS, t.Name))
+			continue
+		}
+		res.Transfers[key] = TransferDisposition{Reason: v1.TransferReasonCompiled, Message: "accepted by both parties"}
+		if !lenderSide {
+			continue // emitted once, from the lender's copy
+		}
+
+		// Clamp exactly as an envelope clamps against its grant: a transfer can
+		// lend no more, and for no longer, than the envelope itself holds.
+		concurrency := t.Spec.Concurrency
+		if concurrency > env.Concurrency {
+			concurrency = env.Concurrency
+		}
+		start, end := t.Spec.Start.Time, t.Spec.End.Time
+		if env.Start.Time.After(start) {
+			start = env.Start.Time
+		}
+		if env.End.Time.Before(end) {
+			end = env.End.Time
+		}
+		if !end.After(start) {
+			concurrency, end = 0, start
+		}
+		out = append(out, v1.SnapshotTransfer{
+			Name:        t.Name,
+			Lender:      lender,
+			Borrower:    t.Spec.To.Owner,
+			From:        t.Spec.From,
+			Flavor:      env.Flavor,
+			Concurrency: concurrency,
+			Start:       metav1.NewTime(start),
+			End:         metav1.NewTime(end),
+		})
+	}
+	return out
+}
+
 // SnapshotName is the single published document's name. One document; sharding
 // is not built (Ruling 18).
 const SnapshotName = "cluster"
@@ -510,6 +658,11 @@ func contentHash(spec v1.QuotaSnapshotSpec) string {
 				e.Start.UTC().Format(time.RFC3339), e.End.UTC().Format(time.RFC3339), over, until)
 		}
 	}
+	for _, t := range spec.Transfers {
+		fmt.Fprintf(&b, "transfer=%s %s->%s from=%s/%s/%s flavor=%s c=%d [%s,%s)\n",
+			t.Name, t.Lender, t.Borrower, t.From.Namespace, t.From.Budget, t.From.Envelope, t.Flavor, t.Concurrency,
+			t.Start.UTC().Format(time.RFC3339), t.End.UTC().Format(time.RFC3339))
+	}
 	sum := sha256.Sum256([]byte(b.String()))
 	return hex.EncodeToString(sum[:])
 }
@@ -530,3 +683,16 @@ func nextVersion(prior *v1.QuotaSnapshot, hash string) string {
 	}
 	return fmt.Sprint(n + 1)
 }
+
+// PublishedTransfers reads the compiled transfers out of a listed set of
+// snapshots: the one published document's, or none before the producer has
+// published. Every funding evaluation takes its transfers from here, so the
+// engine, the plugin and the reports agree on what is borrowed.
+func PublishedTransfers(items []v1.QuotaSnapshot) []v1.SnapshotTransfer {
+	for i := range items {
+		if items[i].Name == SnapshotName {
+			return items[i].Spec.Transfers
+		}
+	}
+	return nil
+}
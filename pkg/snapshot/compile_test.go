@@ -452,3 +452,76 @@ func indexOf(s, sub string) int {
 	}
 	return -1
 }
+
+func transfer(ns, name string, concurrency int32, start, end time.Time) v1.BudgetTransfer {
+	return v1.BudgetTransfer{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: ns},
+		Spec: v1.BudgetTransferSpec{
+			From:        v1.TransferSource{Namespace: "ns-a", Budget: "org:a-budget", Envelope: "west"},
+			To:          v1.TransferRecipient{Owner: "org:b", Namespace: "ns-b"},
+			Concurrency: concurrency,
+			Start:       tp(start),
+			End:         tp(end),
+		},
+	}
+}
+
+// A transfer is two writes. The lender's copy alone waits; a copy written by a
+// third party speaks for nobody; once the recipient writes the same terms it
+// compiles in, clamped to the envelope it lends from.
+func TestTransferCompilesOnlyWhenBothPartiesWriteIt(t *testing.T) {
+	in := Input{
+		Budgets: []v1.Budget{
+			budget("ns-a", "org:a", envelope("west", 16, base, base.Add(240*time.Hour))),
+			budget("ns-b", "org:b", envelope("west", 8, base, base.Add(720*time.Hour))),
+			budget("ns-c", "org:c", envelope("west", 8, base, base.Add(720*time.Hour))),
+		},
+		Transfers: []v1.BudgetTransfer{
+			transfer("ns-a", "loan", 24, base, base.Add(480*time.Hour)),
+			transfer("ns-c", "loan", 24, base, base.Add(480*time.Hour)),
+		},
+		NamespaceUIDs: uids("ns-a", "uid-a", "ns-b", "uid-b", "ns-c", "uid-c"),
+		Now:           base,
+	}
+	res, err := Compile(in)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if len(res.Snapshot.Spec.Transfers) != 0 {
+		t.Fatalf("a one-sided transfer compiled in: %+v", res.Snapshot.Spec.Transfers)
+	}
+	if d := res.Transfers["ns-a/loan"]; d.Reason != v1.TransferReasonAwaitingCounterparty {
+		t.Errorf("lender's copy: %+v, want AwaitingCounterparty", d)
+	}
+	if d := res.Transfers["ns-c/loan"]; d.Reason != v1.TransferReasonNotCompiled || !contains(d.Message, "neither party") {
+		t.Errorf("third party's copy: %+v, want refused for where it was written", d)
+	}
+
+	// The recipient writes different terms first: both copies say so.
+	disagree := transfer("ns-b", "loan", 4, base, base.Add(480*time.Hour))
+	in.Transfers = append(in.Transfers, disagree)
+	if res, _ = Compile(in); res.Transfers["ns-b/loan"].Reason != v1.TransferReasonNotCompiled || len(res.Snapshot.Spec.Transfers) != 0 {
+		t.Fatalf("copies with different terms: %+v, %+v", res.Transfers, res.Snapshot.Spec.Transfers)
+	}
+
+	in.Transfers[2] = transfer("ns-b", "loan", 24, base, base.Add(480*time.Hour))
+	res, err = Compile(in)
+	if err != nil {
+		t.Fatalf("compile: %v", err)
+	}
+	if len(res.Snapshot.Spec.Transfers) != 1 {
+		t.Fatalf("compiled %d transfers, want 1: %+v", len(res.Snapshot.Spec.Transfers), res.Transfers)
+	}
+	got := res.Snapshot.Spec.Transfers[0]
+	if got.Lender != "org:a" || got.Borrower != "org:b" || got.Flavor != "H100" {
+		t.Errorf("parties/flavor: %+v", got)
+	}
+	if got.Concurrency != 16 || !got.End.Time.Equal(base.Add(240*time.Hour)) {
+		t.Errorf("clamped to %d until %v, want the envelope's 16 until its end", got.Concurrency, got.End)
+	}
+	for _, key := range []string{"ns-a/loan", "ns-b/loan"} {
+		if d := res.Transfers[key]; d.Reason != v1.TransferReasonCompiled {
+			t.Errorf("%s: %+v, want Compiled", key, d)
+		}
+	}
+}
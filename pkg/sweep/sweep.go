@@ -0,0 +1,412 @@
+// Package sweep expands a RunSweep into its child Runs and decides, from the
+// children that exist, which to create next and which to stop. It is pure: the
+// kube reconciler lists the children, calls Plan, and applies the result.
+package sweep
+
+import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+	"github.com/davidlangworthy/jobtree/pkg/keys"
+)
+
+const (
+	// LabelSweep names the sweep a child Run was stamped from.
+	LabelSweep = "rq.davidlangworthy.io/sweep"
+	// LabelSweepTrial is the child's trial index within its sweep.
+	LabelSweepTrial = "rq.davidlangworthy.io/sweep-trial"
+	// AnnotationSweepParameters carries the child's parameter values as a JSON
+	// object, for tooling that wants them without reading the pod env.
+	AnnotationSweepParameters = "rq.davidlangworthy.io/sweep-parameters"
+	// AnnotationSweepEnv carries the same values keyed by the env var each is
+	// injected as. A Roles-less child has no pod template to stamp; the bridge
+	// sets these in its default container instead.
+	AnnotationSweepEnv = "rq.davidlangworthy.io/sweep-env"
+)
+
+// MaxTrials bounds a sweep's expansion. Every trial is a Run the engine admits
+// and a row in the sweep's status; a matrix past this is a typo, not a sweep.
+const MaxTrials = 1000
+
+// Trial is one point of the matrix.
+type Trial struct {
+	Index      int32
+	Parameters map[string]string
+}
+
+// Expand returns the sweep's trials in creation order. A Grid sweep enumerates
+// the matrix with the last parameter varying fastest; a Random sweep draws
+// spec.samples distinct points of the same matrix, seeded so the draw is the
+// same on every reconcile.
+func Expand(sweep *v1.RunSweep) ([]Trial, error) {
+	spec := &sweep.Spec
+	if err := spec.Validate(); err != nil {
+		return nil, err
+	}
+	size := 1
+	for _, p := range spec.Parameters {
+		size *= len(p.Values)
+		if size > MaxTrials {
+			return nil, fmt.Errorf("the parameter matrix exceeds %d trials", MaxTrials)
+		}
+	}
+	points := make([]int, size)
+	for i := range points {
+		points[i] = i
+	}
+	if spec.Strategy == v1.SweepStrategyRandom {
+		samples := int(*spec.Samples)
+		if samples > size {
+			return nil, fmt.Errorf("samples %d exceeds the %d-point matrix", samples, size)
+		}
+		points = rand.New(rand.NewSource(seedOf(sweep))).Perm(size)[:samples]
+	}
+	trials := make([]Trial, len(points))
+	for i, point := range points {
+		trials[i] = Trial{Index: int32(i), Parameters: gridPoint(spec.Parameters, point)}
+	}
+	return trials, nil
+}
+
+// gridPoint decodes point as mixed-radix digits over the parameters' values.
+func gridPoint(params []v1.SweepParameter, point int) map[string]string {
+	values := make(map[string]string, len(params))
+	for i := len(params) - 1; i >= 0; i-- {
+		n := len(params[i].Values)
+		values[params[i].Name] = params[i].Values[point%n]
+		point /= n
+	}
+	return values
+}
+
+func seedOf(sweep *v1.RunSweep) int64 {
+	seed := sweep.Spec.Seed
+	if seed == "" {
+		seed = keys.NamespacedKey(sweep.Namespace, sweep.Name)
+	}
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(seed))
+	return int64(h.Sum64())
+}
+
+// EnvName is the environment variable a parameter is injected as.
+func EnvName(p v1.SweepParameter) string {
+	if p.Env != "" {
+		return p.Env
+	}
+	return "SWEEP_" + strings.ToUpper(p.Name)
+}
+
+// ChildName is the Run name of a sweep's trial.
+func ChildName(sweep *v1.RunSweep, index int32) string {
+	return fmt.Sprintf("%s-%d", sweep.Name, index)
+}
+
+// Child stamps the Run for one trial: the template's spec with the trial's
+// values set in every container of every role, owned by the sweep. The values
+// are also recorded in AnnotationSweepEnv, which is how a Roles-less template's
+// single default container gets them.
+func Child(sweep *v1.RunSweep, trial Trial) *v1.Run {
+	tmpl := &sweep.Spec.Template
+	labels := make(map[string]string, len(tmpl.Labels)+2)
+	for k, v := range tmpl.Labels {
+		labels[k] = v
+	}
+	labels[LabelSweep] = sweep.Name
+	labels[LabelSweepTrial] = strconv.Itoa(int(trial.Index))
+	annotations := make(map[string]string, len(tmpl.Annotations)+2)
+	for k, v := range tmpl.Annotations {
+		annotations[k] = v
+	}
+	encoded, _ := json.Marshal(trial.Parameters)
+	annotations[AnnotationSweepParameters] = string(encoded)
+
+	spec := tmpl.Spec.DeepCopy()
+	env := make([]corev1.EnvVar, 0, len(sweep.Spec.Parameters))
+	byEnv := make(map[string]string, len(sweep.Spec.Parameters))
+	for _, p := range sweep.Spec.Parameters {
+		env = append(env, corev1.EnvVar{Name: EnvName(p), Value: trial.Parameters[p.Name]})
+		byEnv[EnvName(p)] = trial.Parameters[p.Name]
+	}
+	encoded, _ = json.Marshal(byEnv)
+	annotations[AnnotationSweepEnv] = string(encoded)
+	for i := range spec.Roles {
+		pod := &spec.Roles[i].Template.Spec
+		for j := range pod.Containers {
+			pod.Containers[j].Env = setEnv(pod.Containers[j].Env, env)
+		}
+	}
+
+	run := &v1.Run{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        ChildName(sweep, trial.Index),
+			Namespace:   sweep.Namespace,
+			Labels:      labels,
+			Annotations: annotations,
+		},
+		Spec: *spec,
+	}
+	if sweep.UID != "" {
+		yes := true
+		run.OwnerReferences = []metav1.OwnerReference{{
+			APIVersion:         v1.GroupVersion.String(),
+			Kind:               "RunSweep",
+			Name:               sweep.Name,
+			UID:                sweep.UID,
+			Controller:         &yes,
+			BlockOwnerDeletion: &yes,
+		}}
+	}
+	return run
+}
+
+// Env is the parameter env a sweep child's containers run with, from its
+// AnnotationSweepEnv, sorted by name. It is nil for a Run that is not a child.
+func Env(run *v1.Run) []corev1.EnvVar {
+	if run == nil {
+		return nil
+	}
+	raw := run.Annotations[AnnotationSweepEnv]
+	if raw == "" {
+		return nil
+	}
+	var byEnv map[string]string
+	if err := json.Unmarshal([]byte(raw), &byEnv); err != nil {
+		return nil
+	}
+	env := make([]corev1.EnvVar, 0, len(byEnv))
+	for name, value := range byEnv {
+		env = append(env, corev1.EnvVar{Name: name, Value: value})
+	}
+	sort.Slice(env, func(i, j int) bool { return env[i].Name < env[j].Name })
+	return env
+}
+
+// setEnv sets each of vars in env, replacing a template entry of the same name:
+// the sweep's value is the point of the trial, so it wins over a default.
+func setEnv(env, vars []corev1.EnvVar) []corev1.EnvVar {
+	out := make([]corev1.EnvVar, 0, len(env)+len(vars))
+	for _, e := range env {
+		overridden := false
+		for _, v := range vars {
+			if v.Name == e.Name {
+				overridden = true
+				break
+			}
+		}
+		if !overridden {
+			out = append(out, e)
+		}
+	}
+	return append(out, vars...)
+}
+
+// TrialIndex reads a child's trial index from its label; ok is false for a Run
+// the sweep did not stamp.
+func TrialIndex(run *v1.Run) (int32, bool) {
+	n, err := strconv.Atoi(run.Labels[LabelSweepTrial])
+	if err != nil || n < 0 {
+		return 0, false
+	}
+	return int32(n), true
+}
+
+// Decision is what Plan asks the reconciler to do, and the status to write
+// once it has.
+type Decision struct {
+	// Create are the children to create, in trial order.
+	Create []*v1.Run
+	// Stop names running children to delete: the sweep met its early-stop
+	// target and they are no longer needed.
+	Stop   []string
+	Status v1.RunSweepStatus
+}
+
+// Refused records that the apiserver refused Create[i] for good (invalid or
+// forbidden): that child and the ones after it were never created, so they are
+// dropped from the status, and the sweep is Failed with the refusal. Retrying
+// cannot succeed until the sweep's spec, or the policy refusing it, changes.
+func (d *Decision) Refused(i int, err error, now time.Time) {
+	uncreated := make(map[string]bool, len(d.Create)-i)
+	for _, run := range d.Create[i:] {
+		uncreated[run.Name] = true
+	}
+	message := fmt.Sprintf("create trial %s: %v", d.Create[i].Name, err)
+	d.Create = d.Create[:i]
+	kept := d.Status.Children[:0]
+	for _, child := range d.Status.Children {
+		if uncreated[child.Name] {
+			d.Status.Running--
+			continue
+		}
+		kept = append(kept, child)
+	}
+	d.Status.Children = kept
+	d.Status.Created = int32(len(kept))
+	d.Status.Phase = v1.SweepPhaseFailed
+	d.Status.Message = message
+	if d.Status.CompletedAt == nil {
+		at := metav1.NewTime(now)
+		d.Status.CompletedAt = &at
+	}
+}
+
+// Plan decides the sweep's next step from the children that exist. Children
+// the previous status recorded but that are no longer listed were deleted; a
+// deleted trial is never recreated and counts as Stopped unless it had
+// already finished.
+func Plan(sweep *v1.RunSweep, children []v1.Run, now time.Time) Decision {
+	prev := sweep.Status
+	trials, err := Expand(sweep)
+	if err != nil {
+		status := prev
+		status.Phase = v1.SweepPhaseFailed
+		status.Message = err.Error()
+		if status.CompletedAt == nil {
+			at := metav1.NewTime(now)
+			status.CompletedAt = &at
+		}
+		return Decision{Status: status}
+	}
+
+	status := v1.RunSweepStatus{Trials: int32(len(trials)), Winner: prev.Winner, CompletedAt: prev.CompletedAt}
+	byIndex := make(map[int32]*v1.RunSweepChild)
+	record := func(child v1.RunSweepChild) { byIndex[child.Index] = &child }
+	for _, child := range prev.Children {
+		if !isTerminal(child.Phase) {
+			// Not listed below means deleted; a listed child overwrites this.
+			child.Phase = v1.SweepChildStopped
+		}
+		record(child)
+	}
+	live := make(map[int32]*v1.Run, len(children))
+	for i := range children {
+		run := &children[i]
+		index, ok := TrialIndex(run)
+		if !ok {
+			continue
+		}
+		live[index] = run
+		params := map[string]string(nil)
+		if int(index) < len(trials) {
+			params = trials[index].Parameters
+		}
+		if old, ok := byIndex[index]; ok && old.Parameters != nil {
+			params = old.Parameters
+		}
+		record(v1.RunSweepChild{Name: run.Name, Index: index, Parameters: params, Phase: run.Status.Phase})
+	}
+
+	// The winner is the first success the sweep saw; when several land in the
+	// same pass, the lowest trial index.
+	indices := make([]int32, 0, len(byIndex))
+	for index := range byIndex {
+		indices = append(indices, index)
+	}
+	sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	for _, index := range indices {
+		if status.Winner == "" && byIndex[index].Phase == v1.RunPhaseComplete {
+			status.Winner = byIndex[index].Name
+		}
+	}
+
+	succeeded := int32(0)
+	for _, index := range indices {
+		if byIndex[index].Phase == v1.RunPhaseComplete {
+			succeeded++
+		}
+	}
+	stopped := sweep.Spec.EarlyStop != nil && succeeded >= sweep.Spec.EarlyStop.Target()
+
+	var decision Decision
+	active := int32(0)
+	for _, index := range indices {
+		child := byIndex[index]
+		run, ok := live[index]
+		if !ok || isTerminal(child.Phase) {
+			continue
+		}
+		if stopped || run.DeletionTimestamp != nil {
+			if run.DeletionTimestamp == nil {
+				decision.Stop = append(decision.Stop, run.Name)
+			}
+			child.Phase = v1.SweepChildStopped
+			continue
+		}
+		active++
+	}
+
+	if !stopped {
+		slots := int32(len(trials))
+		if sweep.Spec.MaxConcurrent != nil {
+			slots = *sweep.Spec.MaxConcurrent
+		}
+		for _, trial := range trials {
+			if active >= slots {
+				break
+			}
+			if _, created := byIndex[trial.Index]; created {
+				continue
+			}
+			run := Child(sweep, trial)
+			decision.Create = append(decision.Create, run)
+			indices = append(indices, trial.Index)
+			record(v1.RunSweepChild{Name: run.Name, Index: trial.Index, Parameters: trial.Parameters})
+			active++
+		}
+		sort.Slice(indices, func(i, j int) bool { return indices[i] < indices[j] })
+	}
+
+	for _, index := range indices {
+		child := byIndex[index]
+		status.Children = append(status.Children, *child)
+		switch child.Phase {
+		case v1.RunPhaseComplete:
+			status.Succeeded++
+		case v1.RunPhaseFailed:
+			status.Failed++
+		case v1.SweepChildStopped:
+			status.Stopped++
+		default:
+			status.Running++
+		}
+	}
+	status.Created = int32(len(status.Children))
+
+	switch {
+	case stopped:
+		status.Phase = v1.SweepPhaseComplete
+		status.Message = fmt.Sprintf("early stop: %d of %d trials succeeded", status.Succeeded, status.Trials)
+	case status.Created < status.Trials || status.Running > 0:
+		status.Phase = v1.SweepPhaseRunning
+		status.Message = fmt.Sprintf("%d of %d trials created, %d running", status.Created, status.Trials, status.Running)
+	case status.Succeeded > 0:
+		status.Phase = v1.SweepPhaseComplete
+		status.Message = fmt.Sprintf("%d of %d trials succeeded", status.Succeeded, status.Trials)
+	default:
+		status.Phase = v1.SweepPhaseFailed
+		status.Message = fmt.Sprintf("no trial succeeded (%d failed, %d stopped)", status.Failed, status.Stopped)
+	}
+	if status.Phase == v1.SweepPhaseRunning {
+		status.CompletedAt = nil
+	} else if status.CompletedAt == nil {
+		at := metav1.NewTime(now)
+		status.CompletedAt = &at
+	}
+	decision.Status = status
+	return decision
+}
+
+func isTerminal(phase string) bool {
+	return phase == v1.RunPhaseComplete || phase == v1.RunPhaseFailed || phase == v1.SweepChildStopped
+}
@@ -0,0 +1,230 @@
+package sweep
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	v1 "github.com/davidlangworthy/jobtree/api/v1"
+)
+
+var now = time.Date(2026, 8, 1, 9, 0, 0, 0, time.UTC)
+
+func int32p(v int32) *int32 { return &v }
+
+func lrSweep() *v1.RunSweep {
+	return &v1.RunSweep{
+		ObjectMeta: metav1.ObjectMeta{Name: "lr", Namespace: "team", UID: "sweep-uid"},
+		Spec: v1.RunSweepSpec{
+			Template: v1.RunTemplate{Spec: v1.RunSpec{
+				Resources: v1.RunResources{GPUType: "H100-80GB", TotalGPUs: 1},
+				Roles: []v1.RunRole{{
+					Name: "trainer", Width: 1, GPUsPerPod: 1,
+					Template: corev1.PodTemplateSpec{Spec: corev1.PodSpec{Containers: []corev1.Container{{
+						Name: "workload", Image: "trainer:1",
+						Env: []corev1.EnvVar{{Name: "SWEEP_LR", Value: "default"}, {Name: "KEEP", Value: "me"}},
+					}}}},
+				}},
+			}},
+			Parameters: []v1.SweepParameter{
+				{Name: "lr", Values: []string{"1e-4", "1e-3"}},
+				{Name: "batch", Env: "BATCH", Values: []string{"256", "512", "1024"}},
+			},
+		},
+	}
+}
+
+// child returns the Run Plan would create for trial index, in phase.
+func child(t *testing.T, sw *v1.RunSweep, index int32, phase string) v1.Run {
+	t.Helper()
+	trials, err := Expand(sw)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	run := Child(sw, trials[index])
+	run.Status.Phase = phase
+	return *run
+}
+
+func TestGridExpandsWithTheLastParameterFastest(t *testing.T) {
+	trials, err := Expand(lrSweep())
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	want := []map[string]string{
+		{"lr": "1e-4", "batch": "256"}, {"lr": "1e-4", "batch": "512"}, {"lr": "1e-4", "batch": "1024"},
+		{"lr": "1e-3", "batch": "256"}, {"lr": "1e-3", "batch": "512"}, {"lr": "1e-3", "batch": "1024"},
+	}
+	if len(trials) != len(want) {
+		t.Fatalf("got %d trials, want %d", len(trials), len(want))
+	}
+	for i, trial := range trials {
+		if trial.Index != int32(i) || !reflect.DeepEqual(trial.Parameters, want[i]) {
+			t.Errorf("trial %d: got %+v, want %v", i, trial, want[i])
+		}
+	}
+}
+
+// A Random draw is recomputed every reconcile, so it must be a function of the
+// spec alone: distinct points, the same ones each time, and a different seed
+// drawing a different set.
+func TestRandomDrawIsStableAndDistinct(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.Strategy = v1.SweepStrategyRandom
+	sw.Spec.Samples = int32p(4)
+	first, err := Expand(sw)
+	if err != nil {
+		t.Fatalf("expand: %v", err)
+	}
+	again, _ := Expand(sw)
+	if !reflect.DeepEqual(first, again) {
+		t.Fatalf("the draw changed between expansions")
+	}
+	seen := map[string]bool{}
+	for _, trial := range first {
+		point := trial.Parameters["lr"] + "/" + trial.Parameters["batch"]
+		if seen[point] {
+			t.Errorf("point %s drawn twice", point)
+		}
+		seen[point] = true
+	}
+	if len(first) != 4 {
+		t.Errorf("got %d samples, want 4", len(first))
+	}
+
+	sw.Spec.Samples = int32p(7)
+	if _, err := Expand(sw); err == nil {
+		t.Errorf("more samples than grid points must be rejected")
+	}
+}
+
+func TestChildCarriesItsParameters(t *testing.T) {
+	sw := lrSweep()
+	run := child(t, sw, 4, "")
+	if run.Name != "lr-4" || run.Namespace != "team" {
+		t.Errorf("got %s/%s, want team/lr-4", run.Namespace, run.Name)
+	}
+	if run.Labels[LabelSweep] != "lr" || run.Labels[LabelSweepTrial] != "4" {
+		t.Errorf("bookkeeping labels: %v", run.Labels)
+	}
+	if run.Annotations[AnnotationSweepParameters] != `{"batch":"512","lr":"1e-3"}` {
+		t.Errorf("parameters annotation: %q", run.Annotations[AnnotationSweepParameters])
+	}
+	wantEnv := []corev1.EnvVar{{Name: "KEEP", Value: "me"}, {Name: "SWEEP_LR", Value: "1e-3"}, {Name: "BATCH", Value: "512"}}
+	if got := run.Spec.Roles[0].Template.Spec.Containers[0].Env; !reflect.DeepEqual(got, wantEnv) {
+		t.Errorf("env: got %v, want %v", got, wantEnv)
+	}
+	if ref := metav1.GetControllerOf(&run); ref == nil || ref.Kind != "RunSweep" || ref.UID != "sweep-uid" {
+		t.Errorf("the child must be controlled by its sweep, got %+v", ref)
+	}
+	if env := sw.Spec.Template.Spec.Roles[0].Template.Spec.Containers[0].Env; env[0].Value != "default" {
+		t.Errorf("stamping a child rewrote the template")
+	}
+}
+
+func TestRolesLessChildRecordsItsEnv(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.Template.Spec.Roles = nil
+	run := child(t, sw, 4, "")
+	want := []corev1.EnvVar{{Name: "BATCH", Value: "512"}, {Name: "SWEEP_LR", Value: "1e-3"}}
+	if got := Env(&run); !reflect.DeepEqual(got, want) {
+		t.Errorf("a Roles-less child must still carry its parameters: got %v, want %v", got, want)
+	}
+	other := child(t, sw, 0, "")
+	if reflect.DeepEqual(Env(&other), Env(&run)) {
+		t.Errorf("two trials of a Roles-less sweep must differ")
+	}
+	if Env(&v1.Run{}) != nil {
+		t.Errorf("a Run that is not a sweep child has no sweep env")
+	}
+}
+
+func TestMaxConcurrentMetersCreation(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.MaxConcurrent = int32p(2)
+
+	d := Plan(sw, nil, now)
+	if len(d.Create) != 2 || d.Create[0].Name != "lr-0" || d.Create[1].Name != "lr-1" {
+		t.Fatalf("first pass must create the first two trials, got %d", len(d.Create))
+	}
+	sw.Status = d.Status
+
+	// One finishes: exactly one slot opens.
+	d = Plan(sw, []v1.Run{child(t, sw, 0, v1.RunPhaseFailed), child(t, sw, 1, v1.RunPhaseRunning)}, now)
+	if len(d.Create) != 1 || d.Create[0].Name != "lr-2" {
+		t.Fatalf("a finished trial frees one slot, got %d creates", len(d.Create))
+	}
+	if d.Status.Phase != v1.SweepPhaseRunning || d.Status.Created != 3 || d.Status.Failed != 1 || d.Status.Running != 2 {
+		t.Errorf("unexpected status %+v", d.Status)
+	}
+}
+
+// The first success stops the rest: nothing more is created and the running
+// children are deleted.
+func TestEarlyStopStopsTheRemainder(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.MaxConcurrent = int32p(3)
+	sw.Spec.EarlyStop = &v1.RunSweepEarlyStop{}
+	sw.Status = Plan(sw, nil, now).Status
+
+	d := Plan(sw, []v1.Run{
+		child(t, sw, 0, v1.RunPhaseRunning),
+		child(t, sw, 1, v1.RunPhaseComplete),
+		child(t, sw, 2, v1.RunPhasePending),
+	}, now)
+	if len(d.Create) != 0 {
+		t.Errorf("a stopped sweep created %d trials", len(d.Create))
+	}
+	if !reflect.DeepEqual(d.Stop, []string{"lr-0", "lr-2"}) {
+		t.Errorf("stop: got %v, want [lr-0 lr-2]", d.Stop)
+	}
+	if d.Status.Phase != v1.SweepPhaseComplete || d.Status.Winner != "lr-1" || d.Status.Stopped != 2 || d.Status.CompletedAt == nil {
+		t.Errorf("unexpected status %+v", d.Status)
+	}
+	sw.Status = d.Status
+
+	// The stopped children are gone next pass; the verdict holds.
+	d = Plan(sw, []v1.Run{child(t, sw, 1, v1.RunPhaseComplete)}, now.Add(time.Minute))
+	if len(d.Create) != 0 || len(d.Stop) != 0 || d.Status.Phase != v1.SweepPhaseComplete || !d.Status.CompletedAt.Equal(&metav1.Time{Time: now}) {
+		t.Errorf("the stopped sweep must stay stopped: %+v", d)
+	}
+}
+
+// A trial whose Run was deleted by hand is not recreated; a sweep whose every
+// trial ended without a success is Failed.
+func TestDeletedTrialsAreNotRecreated(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.Parameters = sw.Spec.Parameters[:1]
+	sw.Status = Plan(sw, nil, now).Status
+
+	d := Plan(sw, []v1.Run{child(t, sw, 1, v1.RunPhaseFailed)}, now)
+	if len(d.Create) != 0 {
+		t.Errorf("a deleted trial was recreated")
+	}
+	if d.Status.Stopped != 1 || d.Status.Failed != 1 || d.Status.Phase != v1.SweepPhaseFailed {
+		t.Errorf("unexpected status %+v", d.Status)
+	}
+}
+
+func TestInvalidSweepFails(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.Parameters = append(sw.Spec.Parameters, v1.SweepParameter{Name: "lr", Values: []string{"1"}})
+	d := Plan(sw, nil, now)
+	if len(d.Create) != 0 || d.Status.Phase != v1.SweepPhaseFailed || d.Status.Message == "" {
+		t.Errorf("an invalid sweep must fail without creating anything: %+v", d)
+	}
+}
+
+func TestInvalidTemplateFails(t *testing.T) {
+	sw := lrSweep()
+	sw.Spec.Template.Spec.Resources.TotalGPUs = 0
+	d := Plan(sw, nil, now)
+	if len(d.Create) != 0 || d.Status.Phase != v1.SweepPhaseFailed ||
+		!strings.Contains(d.Status.Message, "spec.template") {
+		t.Errorf("a template the Run webhook refuses must fail the sweep up front: %+v", d.Status)
+	}
+}
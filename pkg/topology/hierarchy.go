@@ -0,0 +1,156 @@
+package topology
+
+import "sort"
+
+// Level is one tier of the placement hierarchy below a fast-fabric domain: a
+// spine pod, a rack, an NVLink island. Label is the node label whose value
+// names a node's unit at the tier.
+type Level struct {
+	Name  string
+	Label string
+}
+
+// Hierarchy orders the tiers outermost first; each unit of a level lies
+// inside one unit of the level before it. The zero Hierarchy has no tiers: a
+// domain is flat, as it was before tiers existed.
+type Hierarchy struct {
+	Levels []Level
+}
+
+// Index returns the position of the named level, outermost 0.
+func (h Hierarchy) Index(name string) (int, bool) {
+	for i, level := range h.Levels {
+		if level.Name == name {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// LevelName returns the name of level i, or "" for -1 (the whole domain).
+func (h Hierarchy) LevelName(i int) string {
+	if i < 0 || i >= len(h.Levels) {
+		return ""
+	}
+	return h.Levels[i].Name
+}
+
+// unitPath keys a node's unit at every level. A key carries its domain and its
+// parents, so two racks both called "r1" in different spines, or two spines
+// both called "s1" in different fabric domains, stay distinct; a node missing
+// a level's label is a unit of its own at that level and every level inside it.
+func (h Hierarchy) unitPath(domain DomainKey, name string, labels map[string]string) []string {
+	if len(h.Levels) == 0 {
+		return nil
+	}
+	path := make([]string, len(h.Levels))
+	parent, alone := domain.String(), false
+	for i, level := range h.Levels {
+		value := labels[level.Label]
+		if value == "" || alone {
+			value, alone = "node:"+name, true
+		}
+		parent += "/" + value
+		path[i] = parent
+	}
+	return path
+}
+
+// Unit is one unit of a hierarchy level: the nodes of one domain that share it.
+type Unit struct {
+	Key    string
+	Domain *Domain
+	Nodes  []*Node
+}
+
+// FreeGPUs returns the remaining capacity across the unit.
+func (u *Unit) FreeGPUs() int {
+	free := 0
+	for _, node := range u.Nodes {
+		free += node.FreeGPUs()
+	}
+	return free
+}
+
+// UnitsAt groups a domain's nodes by their unit at level i, ordered by key.
+func (d *Domain) UnitsAt(i int) []*Unit {
+	byKey := map[string]*Unit{}
+	var units []*Unit
+	for _, node := range d.Nodes {
+		if i < 0 || i >= len(node.Path) {
+			continue
+		}
+		unit, ok := byKey[node.Path[i]]
+		if !ok {
+			unit = &Unit{Key: node.Path[i], Domain: d}
+			byKey[unit.Key] = unit
+			units = append(units, unit)
+		}
+		unit.Nodes = append(unit.Nodes, node)
+	}
+	sort.Slice(units, func(a, b int) bool { return units[a].Key < units[b].Key })
+	return units
+}
+
+// UnitsAt lists the units of level i across every domain.
+func (s *Snapshot) UnitsAt(i int) []*Unit {
+	var units []*Unit
+	for _, dom := range s.Domains {
+		units = append(units, dom.UnitsAt(i)...)
+	}
+	return units
+}
+
+// Within returns a copy of the snapshot holding only the nodes of one unit at
+// level i. It shares nothing with s.
+func (s *Snapshot) Within(i int, key string) *Snapshot {
+	clone := s.Clone()
+	var doms []*Domain
+	for _, dom := range clone.Domains {
+		var nodes []*Node
+		for _, node := range dom.Nodes {
+			if i < len(node.Path) && node.Path[i] == key {
+				nodes = append(nodes, node)
+			}
+		}
+		if len(nodes) > 0 {
+			dom.Nodes = nodes
+			doms = append(doms, dom)
+		}
+	}
+	clone.Domains = doms
+	clone.byKey = make(map[DomainKey]*Domain, len(doms))
+	for _, dom := range doms {
+		clone.byKey[dom.Key] = dom
+	}
+	return clone
+}
+
+// UnitOf returns the key of the unit at level i that holds the named node.
+func (s *Snapshot) UnitOf(i int, name string) (string, bool) {
+	for _, dom := range s.Domains {
+		for _, node := range dom.Nodes {
+			if node.Name == name && i >= 0 && i < len(node.Path) {
+				return node.Path[i], true
+			}
+		}
+	}
+	return "", false
+}
+
+// CopyUsage sets every node of s that also appears in from to from's usage.
+func (s *Snapshot) CopyUsage(from *Snapshot) {
+	used := map[string]int{}
+	for _, dom := range from.Domains {
+		for _, node := range dom.Nodes {
+			used[node.Name] = node.Used
+		}
+	}
+	for _, dom := range s.Domains {
+		for _, node := range dom.Nodes {
+			if u, ok := used[node.Name]; ok {
+				node.Used = u
+			}
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package topology
+
+import (
+	"regexp"
+	"strings"
+)
+
+const (
+	// GPUResource is the extended resource a whole GPU is advertised and
+	// requested as.
+	GPUResource = "nvidia.com/gpu"
+	// MIGResourcePrefix prefixes the extended resource of each MIG profile
+	// the device plugin advertises under the mixed strategy, e.g.
+	// nvidia.com/mig-1g.10gb.
+	MIGResourcePrefix = "nvidia.com/mig-"
+)
+
+// migProfile matches an NVIDIA MIG profile name: compute slices, then memory.
+var migProfile = regexp.MustCompile(`^[1-9][0-9]*g\.[1-9][0-9]*gb$`)
+
+// MIGProfile splits a MIG flavor into the node flavor it partitions and its
+// profile: "a100-1g.10gb" is a 1g.10gb slice of a node labelled
+// gpu.flavor=a100. ok is false for a whole-GPU flavor.
+func MIGProfile(flavor string) (base, profile string, ok bool) {
+	i := strings.LastIndexByte(flavor, '-')
+	if i <= 0 || !migProfile.MatchString(flavor[i+1:]) {
+		return "", "", false
+	}
+	return flavor[:i], flavor[i+1:], true
+}
+
+// ResourceName is the extended resource a pod of flavor requests and a node
+// advertises its capacity of flavor as: the profile's MIG resource for a MIG
+// flavor, nvidia.com/gpu otherwise.
+func ResourceName(flavor string) string {
+	if _, profile, ok := MIGProfile(flavor); ok {
+		return MIGResourcePrefix + profile
+	}
+	return GPUResource
+}
+
+// ServesFlavor reports whether a node with these labels provides flavor: its
+// gpu.flavor label names the flavor or, for a MIG flavor, the GPU it slices.
+// It says nothing about whether the node advertises any of the profile; Units
+// does.
+func ServesFlavor(labels map[string]string, flavor string) bool {
+	if base, _, ok := MIGProfile(flavor); ok {
+		return labels[LabelGPUFlavor] == base
+	}
+	return labels[LabelGPUFlavor] == flavor
+}
+
+// Units is how many units of flavor the node provides: whole GPUs, or slices
+// of a MIG flavor's profile. Zero when the node does not serve the flavor.
+func (n SourceNode) Units(flavor string) int {
+	if !ServesFlavor(n.Labels, flavor) {
+		return 0
+	}
+	if _, profile, ok := MIGProfile(flavor); ok {
+		return n.Slices[profile]
+	}
+	return n.GPUs
+}
+
+// UsageKey keys a node's consumption in the usage map BuildSnapshot reads:
+// whole GPUs by the node's name, MIG slices by the node and their profile, so
+// a node split between the two never counts one against the other.
+func UsageKey(node, profile string) string {
+	if profile == "" {
+		return node
+	}
+	return node + "/" + profile
+}
@@ -0,0 +1,127 @@
+package topology
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// TaintOutOfService is Kubernetes' sanctioned "I assert this node is dead" channel
+// (`node.kubernetes.io/out-of-service`, non-graceful node shutdown, GA in 1.28). It
+// is applied by a human or a fencing agent, never by the control plane on its own,
+// and Pod GC's gcTerminating force-deletes the pods of a node carrying it.
+const TaintOutOfService = "node.kubernetes.io/out-of-service"
+
+// SAFETY-CRITICAL SEMANTICS.
+//
+// This fencing decision is modeled in:
+//   - specs/NodeFailure.tla
+//   - specs/NodeFailure.md
+//
+// If you change what qualifies as node failure here, update that spec and rerun:
+//   - make node-failure-spec-check
+//   - make node-failure-spec-counterexamples
+//
+// The path-scoped CI rail is .github/workflows/node-failure-spec.yaml.
+//
+// NodeFailed reports whether a node has been FENCED: something that can actually
+// know has asserted the machine is dead. It is deliberately neither the negation of
+// NodeUsable nor the negation of the Ready condition.
+//
+// The only safe trigger for a swap is a fencing assertion, because a swap starts a
+// second copy of a rank and jobtree cannot un-start the first one:
+//
+//   - CORDONED is not failed. `kubectl cordon` says "place nothing new here", not
+//     "the work here is dead". Acting on one swapped a healthy rank onto a spare
+//     while its pod kept running -- two live copies of one distributed-training
+//     rank, which is silent corruption rather than a crash (R21).
+//
+//   - NOTREADY IS NOT FAILED EITHER, for any duration. NotReady means the control
+//     plane cannot hear the kubelet; it does not mean the containers stopped. A
+//     partitioned kubelet keeps running them. Kubernetes marks a node NotReady
+//     after --node-monitor-grace-period (50s), then taint-eviction issues an
+//     ORDINARY GRACEFUL delete of its pods at tolerationSeconds (300s) -- a delete
+//     the unreachable kubelet never acts on, so the pod sits Terminating while its
+//     container runs. Upstream says so plainly, in "Force Delete StatefulSet Pods":
+//     force deletion "does not wait for confirmation from the kubelet", and doing
+//     it can "lead to the duplication of a still-running Pod". A NotReady timer,
+//     of any length, is a guess about a machine we cannot see.
+//
+// Two signals are not guesses. Both cause Pod GC to FORCE-delete (grace period 0),
+// in pkg/controller/podgc/gc_controller.go:
+//
+//   - The Node object is gone (gcOrphaned). Deletion is itself an assertion, by the
+//     cloud-controller-manager -- the instance is terminated -- or by an operator.
+//     The caller handles this case; a deleted node never reaches here.
+//   - The node carries `node.kubernetes.io/out-of-service` (gcTerminating).
+//
+// The cost of this is that a genuinely dead on-prem node whose object is never
+// deleted and never tainted will not swap: the run stalls rather than corrupting.
+// In cloud the CCM deletes the object automatically, which is the common path. For
+// a system whose worst outcome is two live copies of one rank, stalling is the
+// correct failure mode.
+//
+// Taking no timestamp is the point. There is no clock here, so the engine clock and
+// the wall clock never meet, and a compromised kubelet -- which writes its own node
+// status -- cannot backdate a LastTransitionTime to manufacture a failure.
+func NodeFailed(node *corev1.Node) bool {
+	for _, taint := range node.Spec.Taints {
+		if taint.Key == TaintOutOfService {
+			return true
+		}
+	}
+	return false
+}
+
+// NodeUsable reports whether a node's GPUs may be counted as capacity and placed
+// on. It is about SCHEDULABILITY, which is a different question from NodeFailed's
+// (has something asserted the machine is dead) — but a fenced node answers both.
+func NodeUsable(node *corev1.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+	// A fenced node's GPUs do not exist. Leaving them in the pool let the engine
+	// admit and CHARGE a run for capacity on a machine jobtree had just declared
+	// dead and closed the leases of: the ledger says the GPUs are there, the
+	// NoExecute taint says nothing may run on them, and the next node event closes
+	// whatever was minted. The fencing taint outlives the failure it reports, so
+	// this is not self-correcting.
+	if NodeFailed(node) {
+		return false
+	}
+	for _, cond := range node.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			return cond.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// MIGSlices reads a node's MIG capacity by profile from its
+// nvidia.com/mig-<profile> resources; nil when it advertises none.
+func MIGSlices(capacity corev1.ResourceList) map[string]int {
+	var slices map[string]int
+	for name, qty := range capacity {
+		profile, ok := strings.CutPrefix(string(name), MIGResourcePrefix)
+		if !ok || qty.Value() <= 0 {
+			continue
+		}
+		if slices == nil {
+			slices = map[string]int{}
+		}
+		slices[profile] = int(qty.Value())
+	}
+	return slices
+}
+
+// SourceNodeOf reads a node's capacity: whole GPUs from nvidia.com/gpu, MIG
+// slices by profile from each nvidia.com/mig-<profile>. The manager, the
+// scheduler plugin, the admission webhook and kubectl-runs all read nodes
+// through here.
+func SourceNodeOf(node *corev1.Node) SourceNode {
+	gpus := 0
+	if qty, ok := node.Status.Capacity[GPUResource]; ok {
+		gpus = int(qty.Value())
+	}
+	return SourceNode{Name: node.Name, Labels: node.Labels, GPUs: gpus, Slices: MIGSlices(node.Status.Capacity)}
+}
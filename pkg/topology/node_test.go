@@ -1,10 +1,11 @@
-package kube
+package topology
 
 import (
 	"testing"
 	"time"
 
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -20,14 +21,14 @@ func nodeWithReady(status corev1.ConditionStatus, transitioned time.Time) *corev
 
 func fence(node *corev1.Node) *corev1.Node {
 	node.Spec.Taints = append(node.Spec.Taints, corev1.Taint{
-		Key:    taintOutOfService,
+		Key:    TaintOutOfService,
 		Effect: corev1.TaintEffectNoExecute,
 	})
 	return node
 }
 
 // R21 — a `kubectl cordon` is not a node failure. Driving HandleNodeFailure off
-// `!nodeUsable` swapped a healthy rank onto a spare while the original pod kept
+// `!NodeUsable` swapped a healthy rank onto a spare while the original pod kept
 // running: two live copies of the same distributed-training rank, which is silent
 // data corruption rather than a crash.
 func TestCordonIsNotANodeFailure(t *testing.T) {
@@ -35,10 +36,10 @@ func TestCordonIsNotANodeFailure(t *testing.T) {
 	node := nodeWithReady(corev1.ConditionTrue, now.Add(-time.Hour))
 	node.Spec.Unschedulable = true // kubectl cordon
 
-	if nodeUsable(node) {
+	if NodeUsable(node) {
 		t.Fatalf("setup: a cordoned node is not usable for NEW placement")
 	}
-	if nodeFailed(node) {
+	if NodeFailed(node) {
 		t.Errorf("a cordoned but Ready node must never be treated as failed — that is the two-live-ranks bug")
 	}
 }
@@ -65,7 +66,7 @@ func TestNotReadyIsNeverAFailure(t *testing.T) {
 		{"unreachable an hour", nodeWithReady(corev1.ConditionUnknown, now.Add(-time.Hour))},
 		{"no transition time", nodeWithReady(corev1.ConditionFalse, time.Time{})},
 	} {
-		if nodeFailed(tc.node) {
+		if NodeFailed(tc.node) {
 			t.Errorf("%s: NotReady is not a fencing assertion; a swap here can duplicate a live rank", tc.name)
 		}
 	}
@@ -76,10 +77,10 @@ func TestNotReadyIsNeverAFailure(t *testing.T) {
 func TestNodeThatHasNotReportedYetIsNotFailed(t *testing.T) {
 	fresh := &corev1.Node{} // created, kubelet has not posted a Ready condition
 
-	if nodeUsable(fresh) {
+	if NodeUsable(fresh) {
 		t.Fatalf("setup: a node with no Ready condition is not usable")
 	}
-	if nodeFailed(fresh) {
+	if NodeFailed(fresh) {
 		t.Errorf("a node that has not reported yet must not be treated as failed")
 	}
 }
@@ -92,7 +93,7 @@ func TestOutOfServiceTaintIsAFailure(t *testing.T) {
 
 	// The realistic shape: unreachable kubelet, then an operator fences it.
 	fenced := fence(nodeWithReady(corev1.ConditionUnknown, now.Add(-10*time.Minute)))
-	if !nodeFailed(fenced) {
+	if !NodeFailed(fenced) {
 		t.Errorf("an out-of-service node is fenced and must be treated as failed")
 	}
 
@@ -100,7 +101,7 @@ func TestOutOfServiceTaintIsAFailure(t *testing.T) {
 	// Ready=True status — a node nobody can reach may still be reporting Ready
 	// from the last heartbeat the API server saw.
 	stillReady := fence(nodeWithReady(corev1.ConditionTrue, now.Add(-time.Hour)))
-	if !nodeFailed(stillReady) {
+	if !NodeFailed(stillReady) {
 		t.Errorf("the fencing taint is the assertion; a stale Ready condition must not veto it")
 	}
 }
@@ -114,7 +115,7 @@ func TestUnrelatedTaintIsNotAFailure(t *testing.T) {
 		{Key: "node.kubernetes.io/unreachable", Effect: corev1.TaintEffectNoExecute},
 	}
 
-	if nodeFailed(node) {
+	if NodeFailed(node) {
 		t.Errorf("only the out-of-service taint fences; `unreachable` is applied automatically and proves nothing")
 	}
 }
@@ -126,14 +127,14 @@ func TestCordonStillRemovesTheNodeFromCapacity(t *testing.T) {
 	node := nodeWithReady(corev1.ConditionTrue, now.Add(-time.Hour))
 	node.Spec.Unschedulable = true
 
-	if nodeUsable(node) {
+	if NodeUsable(node) {
 		t.Errorf("bridge.load must keep excluding a cordoned node's GPUs from capacity")
 	}
 }
 
 // A fenced node's GPUs do not exist, whatever its Ready condition still says.
 //
-// `nodeFailed` and `nodeUsable` answer different questions, but a fence answers
+// `NodeFailed` and `NodeUsable` answer different questions, but a fence answers
 // both. Counting a fenced node's GPUs let the engine admit and CHARGE a run for
 // capacity on a machine jobtree had just declared dead: the ledger says the GPUs
 // are there, the NoExecute taint says nothing may run on them, and the next node
@@ -145,20 +146,38 @@ func TestFencedNodeIsNotCapacity(t *testing.T) {
 	// The dangerous shape: the kubelet's last heartbeat still says Ready, and an
 	// operator has fenced the machine.
 	fenced := fence(nodeWithReady(corev1.ConditionTrue, now.Add(-time.Hour)))
-	if !nodeFailed(fenced) {
+	if !NodeFailed(fenced) {
 		t.Fatalf("setup: an out-of-service node is fenced")
 	}
-	if nodeUsable(fenced) {
+	if NodeUsable(fenced) {
 		t.Errorf("a fenced node's GPUs must leave the capacity pool; otherwise a run is charged for capacity that cannot run it")
 	}
 
 	// A NotReady node is NOT usable either — but for the ordinary reason, and
 	// without being treated as failed.
 	notReady := nodeWithReady(corev1.ConditionUnknown, now.Add(-time.Hour))
-	if nodeUsable(notReady) {
+	if NodeUsable(notReady) {
 		t.Errorf("a NotReady node is not schedulable")
 	}
-	if nodeFailed(notReady) {
+	if NodeFailed(notReady) {
 		t.Errorf("...but it is still not failed: only a fencing assertion is")
 	}
 }
+
+// A node's whole GPUs and its MIG slices by profile are both read; a profile
+// advertised at zero is none.
+func TestSourceNodeOfReadsGPUsAndSlices(t *testing.T) {
+	node := &corev1.Node{}
+	node.Name = "a1"
+	node.Labels = map[string]string{LabelGPUFlavor: "a100"}
+	node.Status.Capacity = corev1.ResourceList{
+		GPUResource:                   resource.MustParse("4"),
+		MIGResourcePrefix + "1g.10gb": resource.MustParse("7"),
+		MIGResourcePrefix + "3g.40gb": resource.MustParse("0"),
+		corev1.ResourceCPU:            resource.MustParse("64"),
+	}
+	got := SourceNodeOf(node)
+	if got.Name != "a1" || got.GPUs != 4 || len(got.Slices) != 1 || got.Slices["1g.10gb"] != 7 {
+		t.Fatalf("got %+v, want 4 GPUs and 7 1g.10gb slices", got)
+	}
+}
@@ -22,6 +22,9 @@ type Node struct {
 	Labels   map[string]string
 	Capacity int
 	Used     int
+	// Path keys the node's unit at each level of the snapshot's Hierarchy,
+	// outermost first. Empty when the hierarchy has no levels.
+	Path []string
 }
 
 // FreeGPUs returns remaining capacity on the node.
@@ -61,6 +64,8 @@ func (d *Domain) FreeGPUs() int {
 type Snapshot struct {
 	Flavor  string
 	Domains []*Domain
+	// Hierarchy is the tiers below each domain that Node.Path keys.
+	Hierarchy Hierarchy
 	// index for quick lookups by domain key.
 	byKey map[DomainKey]*Domain
 }
@@ -100,30 +105,43 @@ type SourceNode struct {
 	Name   string
 	Labels map[string]string
 	GPUs   int
+	// Slices counts the MIG slices the node advertises, by profile. A MIG
+	// flavor's snapshot sizes the node by its profile's count instead of GPUs.
+	Slices map[string]int
 }
 
 // BuildSnapshotForFlavor constructs a topology snapshot filtering nodes by GPU flavor.
-// usage maps node name to GPUs already consumed on that node.
+// usage maps UsageKey to the units already consumed on that node. For a MIG
+// flavor every capacity in the snapshot counts slices of its profile. Its domains are
+// flat; BuildSnapshot adds the tiers of a Hierarchy.
 func BuildSnapshotForFlavor(nodes []SourceNode, usage map[string]int, flavor string) (*Snapshot, error) {
+	return BuildSnapshot(nodes, usage, flavor, Hierarchy{})
+}
+
+// BuildSnapshot is BuildSnapshotForFlavor with each node placed in the units of
+// the hierarchy's levels.
+func BuildSnapshot(nodes []SourceNode, usage map[string]int, flavor string, hierarchy Hierarchy) (*Snapshot, error) {
 	domains := map[DomainKey]*Domain{}
+	_, profile, _ := MIGProfile(flavor)
 	for _, node := range nodes {
 		labels := node.Labels
-		if labels[LabelGPUFlavor] != flavor {
+		if !ServesFlavor(labels, flavor) {
 			continue
 		}
 		region, cluster, fabric := labels[LabelRegion], labels[LabelCluster], labels[LabelFabricDomain]
 		if region == "" || cluster == "" || fabric == "" {
 			return nil, fmt.Errorf("node %q missing topology labels", node.Name)
 		}
-		if node.GPUs <= 0 {
+		capacity := node.Units(flavor)
+		if capacity <= 0 {
 			continue
 		}
 		used := 0
 		if usage != nil {
-			used = usage[node.Name]
+			used = usage[UsageKey(node.Name, profile)]
 		}
-		if used < 0 || used > node.GPUs {
-			return nil, fmt.Errorf("node %q usage %d exceeds capacity %d", node.Name, used, node.GPUs)
+		if used < 0 || used > capacity {
+			return nil, fmt.Errorf("node %q usage %d exceeds capacity %d", node.Name, used, capacity)
 		}
 		key := DomainKey{Region: region, Cluster: cluster, Fabric: fabric}
 		dom, ok := domains[key]
@@ -134,14 +152,15 @@ func BuildSnapshotForFlavor(nodes []SourceNode, usage map[string]int, flavor str
 		nodeCopy := &Node{
 			Name:     node.Name,
 			Labels:   map[string]string{LabelRack: labels[LabelRack]},
-			Capacity: node.GPUs,
+			Capacity: capacity,
 			Used:     used,
+			Path:     hierarchy.unitPath(key, node.Name, labels),
 		}
 		dom.Nodes = append(dom.Nodes, nodeCopy)
 	}
 
 	if len(domains) == 0 {
-		return &Snapshot{Flavor: flavor, Domains: nil, byKey: map[DomainKey]*Domain{}}, nil
+		return &Snapshot{Flavor: flavor, Domains: nil, Hierarchy: hierarchy, byKey: map[DomainKey]*Domain{}}, nil
 	}
 
 	doms := make([]*Domain, 0, len(domains))
@@ -164,7 +183,7 @@ func BuildSnapshotForFlavor(nodes []SourceNode, usage map[string]int, flavor str
 	for _, dom := range doms {
 		byKey[dom.Key] = dom
 	}
-	return &Snapshot{Flavor: flavor, Domains: doms, byKey: byKey}, nil
+	return &Snapshot{Flavor: flavor, Domains: doms, Hierarchy: hierarchy, byKey: byKey}, nil
 }
 
 // Clone returns a deep copy of the snapshot. Useful for mutation in packers.
@@ -188,7 +207,7 @@ func (s *Snapshot) Clone() *Snapshot {
 		doms[i] = domCopy
 		byKey[domCopy.Key] = domCopy
 	}
-	return &Snapshot{Flavor: s.Flavor, Domains: doms, byKey: byKey}
+	return &Snapshot{Flavor: s.Flavor, Domains: doms, Hierarchy: s.Hierarchy, byKey: byKey}
 }
 
 // SortedDomains returns domains ordered by descending free GPUs, breaking ties deterministically.
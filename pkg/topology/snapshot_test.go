@@ -110,6 +110,44 @@ func TestBuildSnapshotMissingLabels(t *testing.T) {
 	}
 }
 
+// A100 nodes split between whole GPUs and 1g.10gb slices: the MIG flavor's
+// snapshot counts slices, and each side's usage leaves the other alone.
+func TestBuildSnapshotCountsMIGSlices(t *testing.T) {
+	labels := map[string]string{
+		LabelRegion: "us-west", LabelCluster: "gpu-a", LabelFabricDomain: "A", LabelGPUFlavor: "a100",
+	}
+	split := fakeNode("m1", labels, 4)
+	split.Slices = map[string]int{"1g.10gb": 14}
+	whole := fakeNode("m2", labels, 8)
+	usage := map[string]int{"m1": 2, UsageKey("m1", "1g.10gb"): 3}
+
+	slices, err := BuildSnapshotForFlavor([]SourceNode{split, whole}, usage, "a100-1g.10gb")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(slices.Domains) != 1 || len(slices.Domains[0].Nodes) != 1 {
+		t.Fatalf("MIG snapshot holds %+v, want only m1, the node with slices", slices.Domains)
+	}
+	if node := slices.Domains[0].Nodes[0]; node.Capacity != 14 || node.Used != 3 {
+		t.Errorf("m1 as slices: capacity %d used %d, want 14 and 3", node.Capacity, node.Used)
+	}
+
+	gpus, err := BuildSnapshotForFlavor([]SourceNode{split, whole}, usage, "a100")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if free := gpus.TotalFreeGPUs(); free != 10 {
+		t.Errorf("whole-GPU snapshot has %d free, want 2 on m1 and 8 on m2", free)
+	}
+
+	if got := ResourceName("a100-1g.10gb"); got != "nvidia.com/mig-1g.10gb" {
+		t.Errorf("ResourceName(a100-1g.10gb) = %q", got)
+	}
+	if _, _, ok := MIGProfile("H100-80GB"); ok || ResourceName("H100-80GB") != GPUResource {
+		t.Error("a whole-GPU flavor parsed as a MIG profile")
+	}
+}
+
 func fakeNode(name string, labels map[string]string, gpus int) SourceNode {
 	return SourceNode{
 		Name:   name,
@@ -117,3 +155,77 @@ func fakeNode(name string, labels map[string]string, gpus int) SourceNode {
 		GPUs:   gpus,
 	}
 }
+
+// A unit's key carries its parents, so equally named racks in two spines stay
+// apart, and a node missing a tier's label is a unit of its own from there in.
+func TestBuildSnapshotPlacesNodesInTheirUnits(t *testing.T) {
+	hierarchy := Hierarchy{Levels: []Level{{Name: "spine", Label: "spine"}, {Name: "rack", Label: LabelRack}}}
+	tiered := func(name, spine, rack string) SourceNode {
+		labels := map[string]string{
+			LabelRegion: "us-west", LabelCluster: "gpu-a", LabelFabricDomain: "A", LabelGPUFlavor: "H100-80GB",
+			"spine": spine, LabelRack: rack,
+		}
+		return fakeNode(name, labels, 8)
+	}
+	snapshot, err := BuildSnapshot([]SourceNode{
+		tiered("a1", "s1", "r1"),
+		tiered("a2", "s1", "r1"),
+		tiered("a3", "s2", "r1"),
+		tiered("a4", "", "r1"),
+	}, map[string]int{"a1": 2}, "H100-80GB", hierarchy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	racks := snapshot.UnitsAt(1)
+	if len(racks) != 3 {
+		t.Fatalf("expected 3 racks (s1/r1, s2/r1, a4 alone), got %d", len(racks))
+	}
+	shared := racks[len(racks)-1]
+	for _, unit := range racks {
+		if len(unit.Nodes) == 2 {
+			shared = unit
+		}
+	}
+	if shared.Key != "us-west/gpu-a/A/s1/r1" || shared.FreeGPUs() != 14 {
+		t.Fatalf("expected rack us-west/gpu-a/A/s1/r1 with 14 free GPUs, got %s with %d", shared.Key, shared.FreeGPUs())
+	}
+
+	within := snapshot.Within(0, "us-west/gpu-a/A/s1")
+	if got := within.TotalFreeGPUs(); got != 14 {
+		t.Fatalf("expected spine s1 to hold 14 free GPUs, got %d", got)
+	}
+	within.Domains[0].Nodes[0].Used = 8
+	snapshot.CopyUsage(within)
+	if got := snapshot.TotalFreeGPUs(); got != 24 {
+		t.Fatalf("expected usage copied back to leave 24 free GPUs, got %d", got)
+	}
+}
+
+// Two fabric domains may reuse a tier's names; a unit is still one domain's.
+func TestWithinKeepsOneDomainsUnit(t *testing.T) {
+	hierarchy := Hierarchy{Levels: []Level{{Name: "spine", Label: "spine"}}}
+	tiered := func(name, fabric string) SourceNode {
+		labels := map[string]string{
+			LabelRegion: "us-west", LabelCluster: "gpu-a", LabelFabricDomain: fabric, LabelGPUFlavor: "H100-80GB",
+			"spine": "s1",
+		}
+		return fakeNode(name, labels, 8)
+	}
+	snapshot, err := BuildSnapshot([]SourceNode{tiered("a1", "A"), tiered("b1", "B")}, nil, "H100-80GB", hierarchy)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if units := snapshot.UnitsAt(0); len(units) != 2 {
+		t.Fatalf("expected one spine per domain, got %d", len(units))
+	}
+	key, ok := snapshot.UnitOf(0, "a1")
+	if !ok {
+		t.Fatalf("expected a1 to sit in a spine")
+	}
+	within := snapshot.Within(0, key)
+	if len(within.Domains) != 1 || within.Domains[0].Key.Fabric != "A" || within.TotalFreeGPUs() != 8 {
+		t.Fatalf("expected only fabric A's spine, got %d domains with %d free GPUs", len(within.Domains), within.TotalFreeGPUs())
+	}
+}
@@ -242,7 +242,7 @@ LeaseIndex(l) == CHOOSE i \in DOMAIN LeasePriority : LeasePriority[i] = l
 
 VARIABLES
   \* Abstracts the fenced/deleted-vs-cordoned/NotReady distinction read by
-  \* nodeFailed/nodeUsable in controllers/kube/reconcilers.go:345-460.
+  \* NodeFailed/NodeUsable in pkg/topology/node.go.
   nodeState,
   \* Abstracts Run.Status.Phase and checkpoint grace decisions written by
   \* HandleNodeFailure and failGroupWithoutSpare in